@@ -22,6 +22,8 @@ type Client interface {
 	GetIssuer(ctx context.Context, issuer string) (*IssuerResponse, error)
 	// GetIssuerV3 returns issuers based on issuer name. Should be used when retrieving version 3 issuers.
 	GetIssuerV3(ctx context.Context, issuer string) (*IssuerResponse, error)
+	// RotateIssuerV3 creates keys for a version 3 issuer.
+	RotateIssuerV3(ctx context.Context, issuer string, rotateIssuerV3 IssuerRotateRequest) error
 	SignCredentials(ctx context.Context, issuer string, creds []string) (*CredentialsIssueResponse, error)
 	RedeemCredential(ctx context.Context, issuer string, preimage string, signature string, payload string) error
 	RedeemCredentials(ctx context.Context, credentials []CredentialRedemption, payload string) error
@@ -59,6 +61,15 @@ type IssuerResponse struct {
 	PublicKey string `json:"public_key"`
 	ExpiresAt string `json:"expires_at,omitempty"`
 	Cohort    int16  `json:"cohort,omitempty"`
+	// Keys are only returned for version 3 issuers.
+	Keys []IssuerKey `json:"keys,omitempty"`
+}
+
+// IssuerKey is a signing key of a version 3 issuer, valid for a period.
+type IssuerKey struct {
+	PublicKey string     `json:"public_key"`
+	StartAt   *time.Time `json:"start_at"`
+	EndAt     *time.Time `json:"end_at"`
 }
 
 // CreateIssuer with the provided name and token cap
@@ -99,6 +110,26 @@ func (c *HTTPClient) CreateIssuerV3(ctx context.Context, issuerRequest IssuerReq
 	return err
 }
 
+// IssuerRotateRequest is a request to create keys for a version 3 issuer.
+type IssuerRotateRequest struct {
+	// Buffer is the number of keys to create after the last key of the issuer.
+	Buffer int `json:"buffer"`
+	// Force replaces the keys of the issuer, starting from now.
+	Force bool `json:"force"`
+}
+
+// RotateIssuerV3 creates keys for a version 3 issuer.
+func (c *HTTPClient) RotateIssuerV3(ctx context.Context, issuer string, rotateIssuerV3 IssuerRotateRequest) error {
+	req, err := c.client.NewRequest(ctx, http.MethodPost, "v3/issuer/"+issuer+"/rotate", rotateIssuerV3, nil)
+	if err != nil {
+		return fmt.Errorf("error creating rotate issuer request v3: %w", err)
+	}
+
+	_, err = c.client.Do(ctx, req, nil)
+
+	return err
+}
+
 // GetIssuer by name
 func (c *HTTPClient) GetIssuer(ctx context.Context, issuer string) (*IssuerResponse, error) {
 	req, err := c.client.NewRequest(ctx, "GET", "v1/issuer/"+issuer, nil, nil)
@@ -136,6 +136,20 @@ func (_d ClientWithPrometheus) RedeemCredentials(ctx context.Context, credential
 	return _d.base.RedeemCredentials(ctx, credentials, payload)
 }
 
+// RotateIssuerV3 implements Client
+func (_d ClientWithPrometheus) RotateIssuerV3(ctx context.Context, issuer string, rotateIssuerV3 IssuerRotateRequest) (err error) {
+	_since := time.Now()
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+
+		clientDurationSummaryVec.WithLabelValues(_d.instanceName, "RotateIssuerV3", result).Observe(time.Since(_since).Seconds())
+	}()
+	return _d.base.RotateIssuerV3(ctx, issuer, rotateIssuerV3)
+}
+
 // SignCredentials implements Client
 func (_d ClientWithPrometheus) SignCredentials(ctx context.Context, issuer string, creds []string) (cp1 *CredentialsIssueResponse, err error) {
 	_since := time.Now()
@@ -135,6 +135,20 @@ func (mr *MockClientMockRecorder) RedeemCredentials(ctx, credentials, payload in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemCredentials", reflect.TypeOf((*MockClient)(nil).RedeemCredentials), ctx, credentials, payload)
 }
 
+// RotateIssuerV3 mocks base method.
+func (m *MockClient) RotateIssuerV3(ctx context.Context, issuer string, rotateIssuerV3 cbr.IssuerRotateRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateIssuerV3", ctx, issuer, rotateIssuerV3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateIssuerV3 indicates an expected call of RotateIssuerV3.
+func (mr *MockClientMockRecorder) RotateIssuerV3(ctx, issuer, rotateIssuerV3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateIssuerV3", reflect.TypeOf((*MockClient)(nil).RotateIssuerV3), ctx, issuer, rotateIssuerV3)
+}
+
 // SignCredentials mocks base method.
 func (m *MockClient) SignCredentials(ctx context.Context, issuer string, creds []string) (*cbr.CredentialsIssueResponse, error) {
 	m.ctrl.T.Helper()
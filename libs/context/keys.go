@@ -114,6 +114,8 @@ const (
 	DisableBitflyerLinkingCTXKey CTXKey = "disable_bitflyer_linking"
 	// DisableSolanaLinkingCTXKey - this informs if solana linking is enabled
 	DisableSolanaLinkingCTXKey CTXKey = "disable_solana_linking"
+	// DisableEthereumLinkingCTXKey - this informs if ethereum linking is enabled
+	DisableEthereumLinkingCTXKey CTXKey = "disable_ethereum_linking"
 
 	// stripe related keys
 
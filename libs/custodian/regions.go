@@ -93,18 +93,73 @@ func (ps *PayoutStatus) Validate(ctx context.Context) error {
 	if !isValid {
 		return errors.New("invalid input")
 	}
+	for k, v := range ps.Other {
+		if !govalidator.IsIn(v, "off", "processing", "complete") {
+			return fmt.Errorf("invalid payout status for %s: %s", k, v)
+		}
+	}
 	return nil
 }
 
 // PayoutStatus - current state of the payout status
+//
+// The status of custodians without a field of their own is kept in Other, keyed by custodian name.
 type PayoutStatus struct {
-	Unverified string `json:"unverified" valid:"in(off|processing|complete)"`
-	Uphold     string `json:"uphold" valid:"in(off|processing|complete)"`
-	Gemini     string `json:"gemini" valid:"in(off|processing|complete)"`
-	Bitflyer   string `json:"bitflyer" valid:"in(off|processing|complete)"`
-	Zebpay     string `json:"zebpay" valid:"in(off|processing|complete)"`
-	Solana     string `json:"solana" valid:"in(off|processing|complete)"`
-	Date       string `json:"payoutDate" valid:"-"`
+	Unverified string            `json:"unverified" valid:"in(off|processing|complete)"`
+	Uphold     string            `json:"uphold" valid:"in(off|processing|complete)"`
+	Gemini     string            `json:"gemini" valid:"in(off|processing|complete)"`
+	Bitflyer   string            `json:"bitflyer" valid:"in(off|processing|complete)"`
+	Zebpay     string            `json:"zebpay" valid:"in(off|processing|complete)"`
+	Solana     string            `json:"solana" valid:"in(off|processing|complete)"`
+	Date       string            `json:"payoutDate" valid:"-"`
+	Other      map[string]string `json:"-" valid:"-"`
+}
+
+// Get returns the payout status of the named custodian.
+func (ps *PayoutStatus) Get(custodian string) (string, bool) {
+	switch custodian {
+	case "unverified":
+		return ps.Unverified, true
+	case "uphold":
+		return ps.Uphold, true
+	case "gemini":
+		return ps.Gemini, true
+	case "bitflyer":
+		return ps.Bitflyer, true
+	case "zebpay":
+		return ps.Zebpay, true
+	case "solana":
+		return ps.Solana, true
+	}
+
+	v, ok := ps.Other[custodian]
+	return v, ok
+}
+
+// MarshalJSON - implement json.Marshaler, the entries of Other are written alongside the fields
+func (ps PayoutStatus) MarshalJSON() ([]byte, error) {
+	type payoutStatus PayoutStatus
+	return marshalWithOther(payoutStatus(ps), ps.Other)
+}
+
+// UnmarshalJSON - implement json.Unmarshaler, entries which are not fields are read into Other
+func (ps *PayoutStatus) UnmarshalJSON(data []byte) error {
+	type payoutStatus PayoutStatus
+
+	var known payoutStatus
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+
+	other, err := unmarshalOther[string](data, payoutStatus{})
+	if err != nil {
+		return err
+	}
+
+	*ps = PayoutStatus(known)
+	ps.Other = other
+
+	return nil
 }
 
 // GeoAllowBlockMap - this is the allow / block list of geos for a custodian
@@ -136,12 +191,60 @@ func (gabm GeoAllowBlockMap) Verdict(countries ...string) bool {
 }
 
 // Regions - Supported Regions
+//
+// The regions of custodians without a field of their own are kept in Other, keyed by custodian name.
 type Regions struct {
-	Uphold   GeoAllowBlockMap `json:"uphold" valid:"-"`
-	Gemini   GeoAllowBlockMap `json:"gemini" valid:"-"`
-	Bitflyer GeoAllowBlockMap `json:"bitflyer" valid:"-"`
-	Zebpay   GeoAllowBlockMap `json:"zebpay" valid:"-"`
-	Solana   GeoAllowBlockMap `json:"solana" valid:"-"`
+	Uphold   GeoAllowBlockMap            `json:"uphold" valid:"-"`
+	Gemini   GeoAllowBlockMap            `json:"gemini" valid:"-"`
+	Bitflyer GeoAllowBlockMap            `json:"bitflyer" valid:"-"`
+	Zebpay   GeoAllowBlockMap            `json:"zebpay" valid:"-"`
+	Solana   GeoAllowBlockMap            `json:"solana" valid:"-"`
+	Other    map[string]GeoAllowBlockMap `json:"-" valid:"-"`
+}
+
+// Get returns the supported regions of the named custodian.
+func (cr *Regions) Get(custodian string) (GeoAllowBlockMap, bool) {
+	switch custodian {
+	case "uphold":
+		return cr.Uphold, true
+	case "gemini":
+		return cr.Gemini, true
+	case "bitflyer":
+		return cr.Bitflyer, true
+	case "zebpay":
+		return cr.Zebpay, true
+	case "solana":
+		return cr.Solana, true
+	}
+
+	v, ok := cr.Other[custodian]
+	return v, ok
+}
+
+// MarshalJSON - implement json.Marshaler, the entries of Other are written alongside the fields
+func (cr Regions) MarshalJSON() ([]byte, error) {
+	type regions Regions
+	return marshalWithOther(regions(cr), cr.Other)
+}
+
+// UnmarshalJSON - implement json.Unmarshaler, entries which are not fields are read into Other
+func (cr *Regions) UnmarshalJSON(data []byte) error {
+	type regions Regions
+
+	var known regions
+	if err := json.Unmarshal(data, &known); err != nil {
+		return err
+	}
+
+	other, err := unmarshalOther[GeoAllowBlockMap](data, regions{})
+	if err != nil {
+		return err
+	}
+
+	*cr = Regions(known)
+	cr.Other = other
+
+	return nil
 }
 
 // HandleErrors - handle any errors in input
@@ -165,3 +268,71 @@ func (cr *Regions) Validate(_ context.Context) error {
 	}
 	return nil
 }
+
+// marshalWithOther marshals v, and adds the entries of other which do not clash with the fields of v.
+func marshalWithOther[T any](v interface{}, other map[string]T) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(other) == 0 {
+		return b, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	for k := range other {
+		if _, ok := fields[k]; ok {
+			continue
+		}
+
+		raw, err := json.Marshal(other[k])
+		if err != nil {
+			return nil, err
+		}
+
+		fields[k] = raw
+	}
+
+	return json.Marshal(fields)
+}
+
+// unmarshalOther returns the entries of data which are not fields of known.
+//
+// Entries which do not decode into T are skipped, as unknown entries have always been ignored.
+func unmarshalOther[T any](data []byte, known interface{}) (map[string]T, error) {
+	kb, err := json.Marshal(known)
+	if err != nil {
+		return nil, err
+	}
+
+	var knownFields map[string]json.RawMessage
+	if err := json.Unmarshal(kb, &knownFields); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	var result map[string]T
+	for k, raw := range fields {
+		if _, ok := knownFields[k]; ok {
+			continue
+		}
+
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			continue
+		}
+
+		if result == nil {
+			result = make(map[string]T)
+		}
+
+		result[k] = v
+	}
+
+	return result, nil
+}
@@ -2,6 +2,7 @@ package custodian
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,3 +77,68 @@ func TestRegions_Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestRegions_Other(t *testing.T) {
+	input := []byte(`{"gemini":{"allow":["US"],"block":[]},"ethereum":{"allow":["AA"],"block":["AB"]},"note":"x"}`)
+
+	regions := Regions{}
+	err := regions.Decode(context.Background(), input)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"US"}, regions.Gemini.Allow)
+
+	actual, ok := regions.Get("ethereum")
+	require.True(t, ok)
+	assert.Equal(t, GeoAllowBlockMap{Allow: []string{"AA"}, Block: []string{"AB"}}, actual)
+
+	_, ok = regions.Get("note")
+	assert.False(t, ok)
+
+	b, err := json.Marshal(&regions)
+	require.NoError(t, err)
+
+	var roundTrip Regions
+	err = json.Unmarshal(b, &roundTrip)
+	require.NoError(t, err)
+
+	assert.Equal(t, regions, roundTrip)
+}
+
+func TestPayoutStatus_Other(t *testing.T) {
+	type testCase struct {
+		name  string
+		given []byte
+		exp   string
+		err   bool
+	}
+
+	testCases := []testCase{
+		{
+			name:  "valid",
+			given: []byte(`{"uphold":"off","gemini":"off","bitflyer":"off","zebpay":"off","solana":"off","unverified":"off","ethereum":"processing","payoutDate":"2024-01-07"}`),
+			exp:   "processing",
+		},
+		{
+			name:  "invalid",
+			given: []byte(`{"uphold":"off","gemini":"off","bitflyer":"off","zebpay":"off","solana":"off","unverified":"off","ethereum":"unknown"}`),
+			exp:   "unknown",
+			err:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ps := PayoutStatus{}
+			err := ps.Decode(context.Background(), tc.given)
+			require.NoError(t, err)
+
+			actual, ok := ps.Get("ethereum")
+			require.True(t, ok)
+			assert.Equal(t, tc.exp, actual)
+
+			assert.Equal(t, tc.err, ps.Validate(context.Background()) != nil)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/brave-intl/bat-go/libs/logging"
+)
+
+// commitTimeout limits the time spent on committing offsets when a batch consumer stops.
+const commitTimeout = 10 * time.Second
+
+var (
+	// consumerLag is the number of messages in a partition which a consumer has not yet read.
+	consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_consumer_lag",
+			Help: "Number of messages in a partition not yet read by the consumer.",
+		},
+		[]string{"topic", "partition"},
+	)
+
+	// consumerPaused reports whether a consumer of the topic is paused.
+	consumerPaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kafka_consumer_paused",
+			Help: "Whether the consumer of the topic is paused.",
+		},
+		[]string{"topic"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(consumerLag, consumerPaused)
+}
+
+// BatchOptions configures ConsumeBatch.
+type BatchOptions struct {
+	// Size is the number of handled messages after which offsets are committed.
+	Size int
+
+	// Interval is the longest time for which offsets of handled messages are left uncommitted.
+	Interval time.Duration
+
+	// Control allows to pause and resume the consumer. It is optional.
+	Control *ConsumerControl
+}
+
+// ConsumerControl allows to pause and resume a consumer, and reports its lag.
+//
+// It is safe for concurrent use.
+type ConsumerControl struct {
+	topic string
+
+	mu      sync.RWMutex
+	resumed chan struct{}
+	lag     map[int]int64
+}
+
+// NewConsumerControl returns a control for a consumer of topic.
+func NewConsumerControl(topic string) *ConsumerControl {
+	return &ConsumerControl{topic: topic, lag: make(map[int]int64)}
+}
+
+// Pause stops the consumer from fetching messages until Resume is called.
+//
+// Offsets of the messages handled before pausing are committed.
+func (c *ConsumerControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed == nil {
+		c.resumed = make(chan struct{})
+	}
+
+	consumerPaused.WithLabelValues(c.topic).Set(1)
+}
+
+// Resume lets a paused consumer continue.
+func (c *ConsumerControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resumed != nil {
+		close(c.resumed)
+		c.resumed = nil
+	}
+
+	consumerPaused.WithLabelValues(c.topic).Set(0)
+}
+
+// IsPaused reports whether the consumer is paused.
+func (c *ConsumerControl) IsPaused() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.resumed != nil
+}
+
+// Lag returns the last known lag by partition.
+func (c *ConsumerControl) Lag() map[int]int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[int]int64, len(c.lag))
+	for k, v := range c.lag {
+		result[k] = v
+	}
+
+	return result
+}
+
+func (c *ConsumerControl) setLag(msg kafkago.Message) {
+	lag := msg.HighWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+
+	c.mu.Lock()
+	c.lag[msg.Partition] = lag
+	c.mu.Unlock()
+
+	consumerLag.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).Set(float64(lag))
+}
+
+// wait blocks while the consumer is paused.
+func (c *ConsumerControl) wait(ctx context.Context) error {
+	c.mu.RLock()
+	resumed := c.resumed
+	c.mu.RUnlock()
+
+	if resumed == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
+
+// ConsumeBatch implements a consumer loop which commits offsets in batches.
+//
+// Messages are delivered at least once to handler, as a restart loses uncommitted offsets.
+// Handlers which must not process a message twice should record offsets as part of processing.
+func ConsumeBatch(ctx context.Context, reader Consumer, handler Handler, errorHandler ErrorHandler, opts BatchOptions) error {
+	logger := logging.Logger(ctx, "kafka batch consumer")
+	logger.Info().Msg("starting consumer")
+
+	ctl := opts.Control
+	if ctl == nil {
+		ctl = NewConsumerControl("")
+	}
+
+	var (
+		pending    []kafkago.Message
+		lastCommit = time.Now()
+	)
+
+	commit := func(ctx context.Context) {
+		lastCommit = time.Now()
+
+		if len(pending) == 0 {
+			return
+		}
+
+		if err := reader.CommitMessages(ctx, pending...); err != nil {
+			last := pending[len(pending)-1]
+
+			logger.Err(err).
+				Int("count", len(pending)).
+				Int("partition", last.Partition).
+				Int64("offset", last.Offset).
+				Msg("error committing kafka messages")
+			sentry.CaptureException(err)
+		}
+
+		pending = pending[:0]
+	}
+
+	// Handled messages are committed on the way out, so that a restart replays as few as possible.
+	// The context might have been cancelled by then.
+	defer func() {
+		cctx, cancel := context.WithTimeout(context.Background(), commitTimeout)
+		defer cancel()
+
+		commit(cctx)
+	}()
+
+	for {
+		if ctl.IsPaused() {
+			commit(ctx)
+
+			if err := ctl.wait(ctx); err != nil {
+				return err
+			}
+		}
+
+		message, err := fetchMessage(ctx, reader, opts.Interval)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				commit(ctx)
+				continue
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("error fetching message key %s partition %d offset %d: %w",
+				string(message.Key), message.Partition, message.Offset, err)
+		}
+
+		ctl.setLag(message)
+
+		if err := handler.Handle(ctx, message); err != nil {
+			sentry.CaptureException(err)
+			logger.Err(err).Msg("error processing message sending to dlq")
+
+			if err := errorHandler.Handle(ctx, message, err); err != nil {
+				logger.Err(err).
+					Str("key", string(message.Key)).
+					Int("partition", message.Partition).
+					Int64("offset", message.Offset).
+					Msg("error writing message to dlq")
+
+				return fmt.Errorf("error writing message to dlq: %w", err)
+			}
+		}
+
+		pending = append(pending, message)
+
+		if len(pending) >= opts.Size || (opts.Interval > 0 && time.Since(lastCommit) >= opts.Interval) {
+			commit(ctx)
+		}
+	}
+}
+
+// fetchMessage fetches the next message, waiting for at most interval when it is positive.
+func fetchMessage(ctx context.Context, reader Consumer, interval time.Duration) (kafkago.Message, error) {
+	if interval <= 0 {
+		return reader.FetchMessage(ctx)
+	}
+
+	fctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	return reader.FetchMessage(fctx)
+}
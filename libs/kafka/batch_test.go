@@ -0,0 +1,244 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	kafkago "github.com/segmentio/kafka-go"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	mockdialer "github.com/brave-intl/bat-go/libs/kafka/mock"
+)
+
+type fakeHandler struct {
+	err     error
+	handled []int64
+}
+
+func (h *fakeHandler) Handle(ctx context.Context, msg kafkago.Message) error {
+	h.handled = append(h.handled, msg.Offset)
+
+	return h.err
+}
+
+type fakeErrorHandler struct {
+	err    error
+	failed []int64
+}
+
+func (h *fakeErrorHandler) Handle(ctx context.Context, msg kafkago.Message, _ error) error {
+	h.failed = append(h.failed, msg.Offset)
+
+	return h.err
+}
+
+func TestConsumeBatch(t *testing.T) {
+	type tcGiven struct {
+		msgs       []kafkago.Message
+		size       int
+		handlerErr error
+		dlqErr     error
+	}
+
+	type tcExpected struct {
+		commits [][]int64
+		handled []int64
+		failed  []int64
+		lag     map[int]int64
+		mustErr bool
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "batches",
+			given: tcGiven{
+				msgs: []kafkago.Message{
+					{Offset: 10, HighWaterMark: 15},
+					{Offset: 11, HighWaterMark: 15},
+					{Offset: 12, HighWaterMark: 15},
+				},
+				size: 2,
+			},
+			exp: tcExpected{
+				commits: [][]int64{{10, 11}, {12}},
+				handled: []int64{10, 11, 12},
+				lag:     map[int]int64{0: 2},
+			},
+		},
+
+		{
+			name: "dlq",
+			given: tcGiven{
+				msgs: []kafkago.Message{
+					{Offset: 10, HighWaterMark: 11},
+				},
+				size:       10,
+				handlerErr: errors.New("something went wrong"),
+			},
+			exp: tcExpected{
+				commits: [][]int64{{10}},
+				handled: []int64{10},
+				failed:  []int64{10},
+				lag:     map[int]int64{0: 0},
+			},
+		},
+
+		{
+			name: "dlq_error",
+			given: tcGiven{
+				msgs: []kafkago.Message{
+					{Offset: 10, HighWaterMark: 12},
+					{Offset: 11, HighWaterMark: 12},
+				},
+				size:       10,
+				handlerErr: errors.New("something went wrong"),
+				dlqErr:     errors.New("dlq unavailable"),
+			},
+			exp: tcExpected{
+				handled: []int64{10},
+				failed:  []int64{10},
+				lag:     map[int]int64{0: 1},
+				mustErr: true,
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reader := mockdialer.NewMockConsumer(ctrl)
+
+			var next int
+			reader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(func(ctx context.Context) (kafkago.Message, error) {
+				if next < len(tc.given.msgs) {
+					next++
+
+					return tc.given.msgs[next-1], nil
+				}
+
+				cancel()
+
+				return kafkago.Message{}, ctx.Err()
+			}).AnyTimes()
+
+			var commits [][]int64
+			reader.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msgs ...kafkago.Message) error {
+				offsets := make([]int64, 0, len(msgs))
+				for j := range msgs {
+					offsets = append(offsets, msgs[j].Offset)
+				}
+
+				commits = append(commits, offsets)
+
+				return nil
+			}).AnyTimes()
+
+			handler := &fakeHandler{err: tc.given.handlerErr}
+			errHandler := &fakeErrorHandler{err: tc.given.dlqErr}
+			ctl := NewConsumerControl("topic")
+
+			err := ConsumeBatch(ctx, reader, handler, errHandler, BatchOptions{Size: tc.given.size, Control: ctl})
+			must.Error(t, err)
+
+			if tc.exp.mustErr {
+				should.NotErrorIs(t, err, context.Canceled)
+			} else {
+				should.ErrorIs(t, err, context.Canceled)
+			}
+
+			should.Equal(t, tc.exp.commits, commits)
+			should.Equal(t, tc.exp.handled, handler.handled)
+			should.Equal(t, tc.exp.failed, errHandler.failed)
+			should.Equal(t, tc.exp.lag, ctl.Lag())
+		})
+	}
+}
+
+func TestConsumeBatch_Interval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := mockdialer.NewMockConsumer(ctrl)
+
+	var fetched int
+	reader.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(func(fctx context.Context) (kafkago.Message, error) {
+		fetched++
+
+		if fetched == 1 {
+			return kafkago.Message{Offset: 10, HighWaterMark: 11}, nil
+		}
+
+		// Idle until the interval passes.
+		<-fctx.Done()
+
+		return kafkago.Message{}, fctx.Err()
+	}).AnyTimes()
+
+	reader.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msgs ...kafkago.Message) error {
+		// The message is committed while the consumer is still running.
+		should.NoError(t, ctx.Err())
+		should.Len(t, msgs, 1)
+
+		cancel()
+
+		return nil
+	}).Times(1)
+
+	err := ConsumeBatch(ctx, reader, &fakeHandler{}, &fakeErrorHandler{}, BatchOptions{Size: 10, Interval: 10 * time.Millisecond})
+	should.ErrorIs(t, err, context.Canceled)
+}
+
+func TestConsumerControl(t *testing.T) {
+	ctl := NewConsumerControl("topic")
+	should.False(t, ctl.IsPaused())
+
+	// Not paused.
+	must.NoError(t, ctl.wait(context.Background()))
+
+	ctl.Pause()
+	ctl.Pause()
+	should.True(t, ctl.IsPaused())
+
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		should.ErrorIs(t, ctl.wait(ctx), context.DeadlineExceeded)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- ctl.wait(context.Background())
+	}()
+
+	ctl.Resume()
+	ctl.Resume()
+	should.False(t, ctl.IsPaused())
+
+	select {
+	case err := <-done:
+		should.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("consumer was not resumed")
+	}
+}
@@ -19,8 +19,6 @@ type ErrorHandler interface {
 	Handle(ctx context.Context, message kafkago.Message, errorMessage error) error
 }
 
-// TODO: Commit many messages off cache.
-
 // Consume implements consumer loop.
 func Consume(ctx context.Context, reader Consumer, handler Handler, errorHandler ErrorHandler) error {
 	logger := logging.Logger(ctx, "kafka consumer")
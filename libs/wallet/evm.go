@@ -0,0 +1,264 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/brave-intl/bat-go/libs/altcurrency"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const (
+	// EVMSignEIP191 is a signature made with personal_sign over the linking message.
+	EVMSignEIP191 = "eip191"
+	// EVMSignEIP712 is a signature made with eth_signTypedData_v4 over the LinkAddress typed data.
+	EVMSignEIP712 = "eip712"
+)
+
+// EVMLinkReq is a request to link an EVM address to a rewards wallet.
+//
+// For EVMSignEIP191 Msg is the signed linking message, in the same format as the Solana one.
+// For EVMSignEIP712 the signed typed data is built from ChainID, Nonce and RewardsSig.
+type EVMLinkReq struct {
+	Address    string
+	Sig        string
+	Kind       string
+	Msg        string
+	ChainID    int64
+	RewardsSig string
+	Nonce      string
+}
+
+// LinkEVMAddress sets the checksummed address as the deposit destination if the request is valid.
+func (w *Info) LinkEVMAddress(_ context.Context, e EVMLinkReq) error {
+	addr, err := w.isEVMLinkReqValid(e)
+	if err != nil {
+		return newLinkEVMAddressError(err)
+	}
+
+	w.UserDepositDestination = addr
+
+	return nil
+}
+
+func (w *Info) isEVMLinkReqValid(e EVMLinkReq) (string, error) {
+	addr, err := parseEVMAddress(e.Address)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		digest []byte
+		rm     rewMsg
+	)
+
+	switch e.Kind {
+	case EVMSignEIP191:
+		p := newSolMsgParser(w.ID, e.Address, e.Nonce)
+		rm, err = p.parse(e.Msg)
+		if err != nil {
+			// The parser names the address after Solana.
+			if errors.Is(err, errInvalidSolanaPubKey) {
+				err = errInvalidEVMAddress
+			}
+
+			return "", fmt.Errorf("parsing error: %w", err)
+		}
+
+		digest = eip191Hash(e.Msg)
+
+	case EVMSignEIP712:
+		rm = rewMsg{msg: w.ID + "." + e.Nonce, sig: e.RewardsSig}
+
+		digest, err = eip712LinkHash(e.ChainID, w.ID, addr, e.Nonce, e.RewardsSig)
+		if err != nil {
+			return "", err
+		}
+
+	default:
+		return "", errInvalidEVMSignatureKind
+	}
+
+	if err := verifyEVMSignature(addr, digest, e.Sig); err != nil {
+		return "", err
+	}
+
+	if err := verifyRewardsSignature(w.PublicKey, rm.msg, rm.sig); err != nil {
+		return "", err
+	}
+
+	return addr, nil
+}
+
+const (
+	errInvalidEVMAddress       Error = "wallet: invalid evm address"
+	errInvalidEVMSignature     Error = "wallet: invalid evm signature for message and address"
+	errInvalidEVMSignatureKind Error = "wallet: invalid evm signature kind"
+	errInvalidEVMChainID       Error = "wallet: invalid evm chain id"
+	errInvalidABIUint          Error = "wallet: value out of range for abi uint256"
+)
+
+// parseEVMAddress returns the EIP-55 checksummed form of addr.
+//
+// An address in mixed case must already carry a valid checksum.
+func parseEVMAddress(addr string) (string, error) {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return "", errInvalidEVMAddress
+	}
+
+	if _, err := hex.DecodeString(addr[2:]); err != nil {
+		return "", errInvalidEVMAddress
+	}
+
+	result := altcurrency.ToChecksumETHAddress(addr)
+
+	raw := addr[2:]
+	if raw != strings.ToLower(raw) && raw != strings.ToUpper(raw) && addr != result {
+		return "", errInvalidEVMAddress
+	}
+
+	return result, nil
+}
+
+// eip191Hash returns the hash personal_sign signs for msg.
+func eip191Hash(msg string) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return altcurrency.Keccak256([]byte(prefix), []byte(msg))
+}
+
+var (
+	eip712DomainType = altcurrency.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId)"))
+	eip712LinkType   = altcurrency.Keccak256([]byte("LinkAddress(string paymentId,address address,string nonce,string rewardsSignature)"))
+)
+
+// eip712LinkHash returns the hash eth_signTypedData_v4 signs for the LinkAddress typed data.
+//
+// The domain is named Brave Rewards, version 1, and the chain is whichever the address is linked from, so chainID
+// must be positive.
+func eip712LinkHash(chainID int64, paymentID, addr, nonce, rewardsSig string) ([]byte, error) {
+	if chainID <= 0 {
+		return nil, errInvalidEVMChainID
+	}
+
+	chain, err := abiUint(big.NewInt(chainID))
+	if err != nil {
+		return nil, err
+	}
+
+	domain := altcurrency.Keccak256(
+		eip712DomainType,
+		altcurrency.Keccak256([]byte("Brave Rewards")),
+		altcurrency.Keccak256([]byte("1")),
+		chain,
+	)
+
+	msg := altcurrency.Keccak256(
+		eip712LinkType,
+		altcurrency.Keccak256([]byte(paymentID)),
+		abiAddress(addr),
+		altcurrency.Keccak256([]byte(nonce)),
+		altcurrency.Keccak256([]byte(rewardsSig)),
+	)
+
+	return eip712Hash(domain, msg), nil
+}
+
+func eip712Hash(domainSeparator, structHash []byte) []byte {
+	return altcurrency.Keccak256([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+// abiUint returns v encoded as an ABI uint256, v must be in [0, 2²⁵⁶).
+func abiUint(v *big.Int) ([]byte, error) {
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		return nil, errInvalidABIUint
+	}
+
+	return v.FillBytes(make([]byte, 32)), nil
+}
+
+func abiAddress(addr string) []byte {
+	b, _ := hex.DecodeString(addr[2:])
+	return append(make([]byte, 12), b...)
+}
+
+// verifyEVMSignature checks that the hex encoded 65 byte signature sig of digest was made by the key of addr.
+func verifyEVMSignature(addr string, digest []byte, sig string) error {
+	b, err := hex.DecodeString(strings.TrimPrefix(sig, "0x"))
+	if err != nil {
+		return fmt.Errorf("error decoding evm signature: %w", err)
+	}
+
+	signer, err := recoverEVMAddress(digest, b)
+	if err != nil {
+		return fmt.Errorf("error recovering evm signer: %w", err)
+	}
+
+	if signer != addr {
+		return errInvalidEVMSignature
+	}
+
+	return nil
+}
+
+// recoverEVMAddress returns the checksummed address of the secp256k1 key which made the signature sig of digest.
+//
+// The signature is r || s || v, where v is 0 or 1, or 27 or 28. Only signatures with a low s are accepted.
+func recoverEVMAddress(digest, sig []byte) (string, error) {
+	if len(sig) != 65 {
+		return "", errors.New("bad signature length: " + strconv.Itoa(len(sig)))
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+
+	if v > 1 {
+		return "", errors.New("bad signature recovery id")
+	}
+
+	var s secp256k1.ModNScalar
+	if overflow := s.SetByteSlice(sig[32:64]); overflow || s.IsOverHalfOrder() {
+		return "", errors.New("signature s is not low")
+	}
+
+	// RecoverCompact takes v || r || s, with v offset by 27 for an uncompressed key.
+	compact := make([]byte, 0, 65)
+	compact = append(compact, v+27)
+	compact = append(compact, sig[:64]...)
+
+	pub, _, err := ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		return "", err
+	}
+
+	return evmAddress(pub), nil
+}
+
+// evmAddress returns the checksummed address of the public key pub.
+func evmAddress(pub *secp256k1.PublicKey) string {
+	b := pub.SerializeUncompressed()[1:]
+	return altcurrency.ToChecksumETHAddress(hex.EncodeToString(altcurrency.Keccak256(b)[12:]))
+}
+
+type LinkEVMAddressError struct {
+	err error
+}
+
+func newLinkEVMAddressError(err error) error {
+	return &LinkEVMAddressError{err: err}
+}
+
+func (e *LinkEVMAddressError) Error() string {
+	return e.err.Error()
+}
+
+func (e *LinkEVMAddressError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,440 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/brave-intl/bat-go/libs/altcurrency"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+)
+
+func TestRecoverEVMAddress_EIP712Example(t *testing.T) {
+	// The Mail example from the EIP-712 specification, signed by the key keccak256("cow").
+	const (
+		cow = "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"
+		bob = "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"
+	)
+
+	chain, err := abiUint(big.NewInt(1))
+	must.NoError(t, err)
+
+	domainType := altcurrency.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	domain := altcurrency.Keccak256(
+		domainType,
+		altcurrency.Keccak256([]byte("Ether Mail")),
+		altcurrency.Keccak256([]byte("1")),
+		chain,
+		abiAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"),
+	)
+
+	personType := altcurrency.Keccak256([]byte("Person(string name,address wallet)"))
+	mailType := altcurrency.Keccak256([]byte("Mail(Person from,Person to,string contents)Person(string name,address wallet)"))
+
+	mail := altcurrency.Keccak256(
+		mailType,
+		altcurrency.Keccak256(personType, altcurrency.Keccak256([]byte("Cow")), abiAddress(cow)),
+		altcurrency.Keccak256(personType, altcurrency.Keccak256([]byte("Bob")), abiAddress(bob)),
+		altcurrency.Keccak256([]byte("Hello, Bob!")),
+	)
+
+	digest := eip712Hash(domain, mail)
+	should.Equal(t, "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hex.EncodeToString(digest))
+
+	sig, err := hex.DecodeString(
+		"4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+			"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c")
+	must.NoError(t, err)
+
+	actual, err := recoverEVMAddress(digest, sig)
+	must.NoError(t, err)
+
+	should.Equal(t, cow, actual)
+
+	priv := secp256k1.PrivKeyFromBytes(altcurrency.Keccak256([]byte("cow")))
+	should.Equal(t, cow, evmAddress(priv.PubKey()))
+}
+
+func TestRecoverEVMAddress(t *testing.T) {
+	type tcGiven struct {
+		digest []byte
+		sig    string
+	}
+
+	type tcExpected struct {
+		addr   string
+		errMsg string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			// web3.eth.accounts.sign("Some data", "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+			name: "web3js_personal_sign",
+			given: tcGiven{
+				digest: eip191Hash("Some data"),
+				sig: "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+					"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029" + "1c",
+			},
+			exp: tcExpected{addr: "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"},
+		},
+
+		{
+			// eth-sig-util personalSign of "hello world" with the key 0x4af1bceebf7f3634ec3cff8a2c38e51178d5d4ce585c52d6043e5e2cc3418bb0.
+			name: "eth_sig_util_personal_sign",
+			given: tcGiven{
+				digest: eip191Hash("hello world"),
+				sig: "ce909e8ea6851bc36c007a0072d0524b07a3ff8d4e623aca4c71ca8e57250c4d" +
+					"0a3fc38fa8fbaaa81ead4b9f6bd03356b6f8bf18bccad167d78891636e1d6956" + "1b",
+			},
+			exp: tcExpected{addr: "0xbE93f9BacBcFFC8ee6663f2647917ed7A20a57BB"},
+		},
+
+		{
+			name: "recovery_id_without_offset",
+			given: tcGiven{
+				digest: eip191Hash("Some data"),
+				sig: "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+					"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029" + "01",
+			},
+			exp: tcExpected{addr: "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"},
+		},
+
+		{
+			// The web3js signature with s replaced by n - s, and the recovery id flipped.
+			name: "high_s",
+			given: tcGiven{
+				digest: eip191Hash("Some data"),
+				sig: "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+					"9ff818b327d1fc847ffe79bdd03d25e83e3a5df66962ceb160751b8bd754a118" + "1b",
+			},
+			exp: tcExpected{errMsg: "signature s is not low"},
+		},
+
+		{
+			name: "bad_recovery_id",
+			given: tcGiven{
+				digest: eip191Hash("Some data"),
+				sig: "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd" +
+					"6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a029" + "1d",
+			},
+			exp: tcExpected{errMsg: "bad signature recovery id"},
+		},
+
+		{
+			name: "bad_length",
+			given: tcGiven{
+				digest: eip191Hash("Some data"),
+				sig:    "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd",
+			},
+			exp: tcExpected{errMsg: "bad signature length: 32"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			sig, err := hex.DecodeString(tc.given.sig)
+			must.NoError(t, err)
+
+			actual, err := recoverEVMAddress(tc.given.digest, sig)
+			if tc.exp.errMsg != "" {
+				must.EqualError(t, err, tc.exp.errMsg)
+				return
+			}
+
+			must.NoError(t, err)
+			should.Equal(t, tc.exp.addr, actual)
+		})
+	}
+}
+
+func TestEIP712LinkHash(t *testing.T) {
+	for _, chainID := range []int64{0, -1} {
+		actual, err := eip712LinkHash(chainID, "payment_id", "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", "nonce", "sig")
+		should.ErrorIs(t, err, errInvalidEVMChainID)
+		should.Nil(t, actual)
+	}
+
+	actual, err := eip712LinkHash(1, "payment_id", "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", "nonce", "sig")
+	must.NoError(t, err)
+	should.Len(t, actual, 32)
+}
+
+func TestAbiUint(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *big.Int
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "zero",
+			given: big.NewInt(0),
+		},
+
+		{
+			name:  "max",
+			given: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+		},
+
+		{
+			name:  "negative",
+			given: big.NewInt(-1),
+			exp:   errInvalidABIUint,
+		},
+
+		{
+			name:  "too_big",
+			given: new(big.Int).Lsh(big.NewInt(1), 256),
+			exp:   errInvalidABIUint,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := abiUint(tc.given)
+			must.ErrorIs(t, err, tc.exp)
+
+			if tc.exp == nil {
+				should.Zero(t, tc.given.Cmp(new(big.Int).SetBytes(actual)))
+			}
+		})
+	}
+}
+
+func TestParseEVMAddress(t *testing.T) {
+	type tcExpected struct {
+		addr string
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given string
+		exp   tcExpected
+	}
+
+	const addr = "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"
+
+	tests := []testCase{
+		{
+			name:  "checksummed",
+			given: addr,
+			exp:   tcExpected{addr: addr},
+		},
+
+		{
+			name:  "lower_case",
+			given: "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf",
+			exp:   tcExpected{addr: addr},
+		},
+
+		{
+			name:  "bad_checksum",
+			given: "0x7e5F4552091A69125d5DfCb7b8C2659029395Bdf",
+			exp:   tcExpected{err: errInvalidEVMAddress},
+		},
+
+		{
+			name:  "no_prefix",
+			given: "7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+			exp:   tcExpected{err: errInvalidEVMAddress},
+		},
+
+		{
+			name:  "not_hex",
+			given: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdz",
+			exp:   tcExpected{err: errInvalidEVMAddress},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseEVMAddress(tc.given)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.addr, actual)
+		})
+	}
+}
+
+func TestInfo_LinkEVMAddress(t *testing.T) {
+	rewPub, rewPriv, err := ed25519.GenerateKey(nil)
+	must.NoError(t, err)
+
+	evmKey, err := secp256k1.GeneratePrivateKey()
+	must.NoError(t, err)
+
+	otherKey, err := secp256k1.GeneratePrivateKey()
+	must.NoError(t, err)
+
+	const (
+		paymentID = "5e99b1ae-6e91-481f-9021-7fb3c97327d4"
+		nonce     = "86d6f240-df9b-4167-a66e-5df6da80ac24"
+	)
+
+	addr := evmAddress(evmKey.PubKey())
+	rewSig := base64.URLEncoding.EncodeToString(ed25519.Sign(rewPriv, []byte(paymentID+"."+nonce)))
+
+	msg := newMsg(msgParts{paymentID: paymentID, solPub: addr, nonce: nonce, rewSig: rewSig})
+
+	type tcExpected struct {
+		addr string
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given EVMLinkReq
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "eip191",
+			given: EVMLinkReq{
+				Address: addr,
+				Sig:     signEVM(t, evmKey, eip191Hash(msg)),
+				Kind:    EVMSignEIP191,
+				Msg:     msg,
+				Nonce:   nonce,
+			},
+			exp: tcExpected{addr: addr},
+		},
+
+		{
+			name: "eip712",
+			given: EVMLinkReq{
+				Address:    addr,
+				Sig:        signEVM(t, evmKey, linkHash(t, 8453, paymentID, addr, nonce, rewSig)),
+				Kind:       EVMSignEIP712,
+				ChainID:    8453,
+				RewardsSig: rewSig,
+				Nonce:      nonce,
+			},
+			exp: tcExpected{addr: addr},
+		},
+
+		{
+			name: "eip712_other_chain",
+			given: EVMLinkReq{
+				Address:    addr,
+				Sig:        signEVM(t, evmKey, linkHash(t, 8453, paymentID, addr, nonce, rewSig)),
+				Kind:       EVMSignEIP712,
+				ChainID:    1,
+				RewardsSig: rewSig,
+				Nonce:      nonce,
+			},
+			exp: tcExpected{err: errInvalidEVMSignature},
+		},
+
+		{
+			name: "eip712_no_chain",
+			given: EVMLinkReq{
+				Address:    addr,
+				Sig:        signEVM(t, evmKey, linkHash(t, 1, paymentID, addr, nonce, rewSig)),
+				Kind:       EVMSignEIP712,
+				RewardsSig: rewSig,
+				Nonce:      nonce,
+			},
+			exp: tcExpected{err: errInvalidEVMChainID},
+		},
+
+		{
+			name: "signed_by_other_key",
+			given: EVMLinkReq{
+				Address: addr,
+				Sig:     signEVM(t, otherKey, eip191Hash(msg)),
+				Kind:    EVMSignEIP191,
+				Msg:     msg,
+				Nonce:   nonce,
+			},
+			exp: tcExpected{err: errInvalidEVMSignature},
+		},
+
+		{
+			name: "message_for_other_address",
+			given: EVMLinkReq{
+				Address: "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+				Sig:     signEVM(t, evmKey, eip191Hash(msg)),
+				Kind:    EVMSignEIP191,
+				Msg:     msg,
+				Nonce:   nonce,
+			},
+			exp: tcExpected{err: errInvalidEVMAddress},
+		},
+
+		{
+			name: "invalid_rewards_signature",
+			given: EVMLinkReq{
+				Address:    addr,
+				Sig:        signEVM(t, evmKey, linkHash(t, 1, paymentID, addr, "other", rewSig)),
+				Kind:       EVMSignEIP712,
+				ChainID:    1,
+				RewardsSig: rewSig,
+				Nonce:      "other",
+			},
+			exp: tcExpected{err: errInvalidRewardsSignature},
+		},
+
+		{
+			name:  "unknown_kind",
+			given: EVMLinkReq{Address: addr, Kind: "eip1271"},
+			exp:   tcExpected{err: errInvalidEVMSignatureKind},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			w := Info{ID: paymentID, PublicKey: hex.EncodeToString(rewPub)}
+
+			err := w.LinkEVMAddress(context.TODO(), tc.given)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			if tc.exp.err != nil {
+				var linkErr *LinkEVMAddressError
+				should.ErrorAs(t, err, &linkErr)
+			}
+
+			should.Equal(t, tc.exp.addr, w.UserDepositDestination)
+		})
+	}
+}
+
+// signEVM signs digest with key, and returns the hex encoded r || s || v signature.
+func signEVM(t *testing.T, key *secp256k1.PrivateKey, digest []byte) string {
+	// SignCompact returns v || r || s, with v offset by 27 for an uncompressed key, and always a low s.
+	compact := ecdsa.SignCompact(key, digest, false)
+
+	sig := append(compact[1:], compact[0])
+	must.Len(t, sig, 65)
+
+	return "0x" + hex.EncodeToString(sig)
+}
+
+func linkHash(t *testing.T, chainID int64, paymentID, addr, nonce, rewardsSig string) []byte {
+	result, err := eip712LinkHash(chainID, paymentID, addr, nonce, rewardsSig)
+	must.NoError(t, err)
+
+	return result
+}
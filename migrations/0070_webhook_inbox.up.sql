@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS webhook_inbox (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    vendor text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    num_attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at timestamp with time zone,
+    CONSTRAINT webhook_inbox_check_status CHECK (status IN ('pending', 'processed', 'dead'))
+);
+
+CREATE INDEX IF NOT EXISTS webhook_inbox_pending_next_attempt_at_idx ON webhook_inbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,7 @@
+UPDATE orders SET status = 'canceled' WHERE status = 'refunded';
+
+ALTER TABLE orders DROP CONSTRAINT status_check;
+
+ALTER TABLE orders ADD CONSTRAINT status_check CHECK (
+    status IN ('pending', 'paid', 'fulfilled', 'canceled')
+);
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP CONSTRAINT status_check;
+
+ALTER TABLE orders ADD CONSTRAINT status_check CHECK (
+    status IN ('pending', 'paid', 'fulfilled', 'canceled', 'refunded')
+);
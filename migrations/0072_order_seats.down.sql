@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS time_limited_v2_order_creds_seat_id_idx;
+
+ALTER TABLE time_limited_v2_order_creds DROP COLUMN IF EXISTS seat_id;
+
+DROP TABLE IF EXISTS order_seats;
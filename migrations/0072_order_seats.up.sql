@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS order_seats (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    order_id uuid NOT NULL REFERENCES orders(id),
+    invite_token text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    redeemed_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    CONSTRAINT order_seats_invite_token_uniq UNIQUE (invite_token),
+    CONSTRAINT order_seats_check_status CHECK (status IN ('pending', 'active', 'revoked'))
+);
+
+CREATE INDEX IF NOT EXISTS order_seats_order_id_idx ON order_seats (order_id);
+
+ALTER TABLE time_limited_v2_order_creds ADD COLUMN seat_id uuid REFERENCES order_seats(id);
+
+CREATE INDEX IF NOT EXISTS time_limited_v2_order_creds_seat_id_idx ON time_limited_v2_order_creds (seat_id) WHERE seat_id IS NOT NULL;
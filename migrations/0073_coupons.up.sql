@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS coupons (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    code text NOT NULL,
+    discount_type text NOT NULL,
+    amount numeric(28, 18) NOT NULL,
+    currency text,
+    skus text[] NOT NULL DEFAULT '{}',
+    max_redemptions integer,
+    num_redemptions integer NOT NULL DEFAULT 0,
+    expires_at timestamp with time zone,
+    stripe_coupon_id text,
+    CONSTRAINT coupons_code_uniq UNIQUE (code),
+    CONSTRAINT coupons_check_discount_type CHECK (discount_type IN ('percentage', 'fixed')),
+    CONSTRAINT coupons_check_amount CHECK (amount > 0 AND (discount_type != 'percentage' OR amount <= 100)),
+    CONSTRAINT coupons_check_currency CHECK (discount_type != 'fixed' OR currency IS NOT NULL)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS order_events_outbox_unsent_idx;
+
+DROP TABLE IF EXISTS order_events_outbox;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS order_events_outbox (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    order_id uuid NOT NULL REFERENCES orders(id),
+    event_type text NOT NULL,
+    occurred_at timestamp with time zone NOT NULL,
+    sent_at timestamp with time zone,
+    CONSTRAINT order_events_outbox_event_uniq UNIQUE (order_id, event_type, occurred_at),
+    CONSTRAINT order_events_outbox_check_event_type CHECK (
+        event_type IN ('order.created', 'order.paid', 'order.renewed', 'order.canceled', 'order.payment_failed', 'order.expired')
+    )
+);
+
+CREATE INDEX IF NOT EXISTS order_events_outbox_unsent_idx ON order_events_outbox (created_at) WHERE sent_at IS NULL;
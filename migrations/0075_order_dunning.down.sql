@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS order_dunning_order_id_active_uniq;
+
+DROP TABLE IF EXISTS order_dunning;
+
+DELETE FROM order_events_outbox WHERE event_type = 'order.payment_reminder';
+
+ALTER TABLE order_events_outbox DROP CONSTRAINT order_events_outbox_check_event_type;
+
+ALTER TABLE order_events_outbox ADD CONSTRAINT order_events_outbox_check_event_type CHECK (
+    event_type IN ('order.created', 'order.paid', 'order.renewed', 'order.canceled', 'order.payment_failed', 'order.expired')
+);
+
+UPDATE orders SET status = 'canceled' WHERE status = 'past_due';
+
+ALTER TABLE orders DROP CONSTRAINT status_check;
+
+ALTER TABLE orders ADD CONSTRAINT status_check CHECK (
+    status IN ('pending', 'paid', 'fulfilled', 'canceled', 'refunded')
+);
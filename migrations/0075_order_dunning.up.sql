@@ -0,0 +1,26 @@
+ALTER TABLE orders DROP CONSTRAINT status_check;
+
+ALTER TABLE orders ADD CONSTRAINT status_check CHECK (
+    status IN ('pending', 'paid', 'past_due', 'fulfilled', 'canceled', 'refunded')
+);
+
+ALTER TABLE order_events_outbox DROP CONSTRAINT order_events_outbox_check_event_type;
+
+ALTER TABLE order_events_outbox ADD CONSTRAINT order_events_outbox_check_event_type CHECK (
+    event_type IN ('order.created', 'order.paid', 'order.renewed', 'order.canceled', 'order.payment_failed', 'order.payment_reminder', 'order.expired')
+);
+
+CREATE TABLE IF NOT EXISTS order_dunning (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    order_id uuid NOT NULL REFERENCES orders(id),
+    vendor text NOT NULL,
+    grace_ends_at timestamp with time zone NOT NULL,
+    next_reminder_at timestamp with time zone NOT NULL,
+    num_reminders integer NOT NULL DEFAULT 0,
+    resolved_at timestamp with time zone,
+    canceled_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS order_dunning_order_id_active_uniq ON order_dunning (order_id) WHERE resolved_at IS NULL AND canceled_at IS NULL;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS sku_catalog (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sku text NOT NULL,
+    sku_variant text NOT NULL,
+    location text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    period text NOT NULL DEFAULT '',
+    price numeric(28, 18) NOT NULL,
+    currency text NOT NULL DEFAULT 'USD',
+    credential_type text NOT NULL,
+    credential_valid_duration text NOT NULL DEFAULT '',
+    each_credential_valid_duration text,
+    issuance_interval text,
+    issuer_token_buffer integer,
+    issuer_token_overlap integer,
+    allowed_payment_methods text[] NOT NULL DEFAULT '{}',
+    stripe_product_id text,
+    stripe_price_id text,
+    store_product_ids text[] NOT NULL DEFAULT '{}',
+    CONSTRAINT sku_catalog_sku_variant_uniq UNIQUE (sku_variant),
+    CONSTRAINT sku_catalog_check_price CHECK (price >= 0),
+    CONSTRAINT sku_catalog_check_credential_type CHECK (credential_type IN ('single-use', 'time-limited', 'time-limited-v2'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sku_catalog_stripe_price_id_uniq ON sku_catalog (stripe_price_id) WHERE stripe_price_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS sku_catalog_store_product_ids_idx ON sku_catalog USING GIN (store_product_ids);
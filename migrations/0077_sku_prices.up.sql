@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sku_prices (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sku_catalog_id uuid NOT NULL REFERENCES sku_catalog(id) ON DELETE CASCADE,
+    currency text NOT NULL,
+    country text NOT NULL DEFAULT '',
+    amount numeric(28, 18) NOT NULL,
+    stripe_price_id text,
+    CONSTRAINT sku_prices_catalog_currency_country_uniq UNIQUE (sku_catalog_id, currency, country),
+    CONSTRAINT sku_prices_check_amount CHECK (amount >= 0)
+);
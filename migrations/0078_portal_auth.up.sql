@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS portal_login_codes (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    email text NOT NULL,
+    code_hash text NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    num_attempts integer NOT NULL DEFAULT 0,
+    used_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS portal_login_codes_email_created_at_idx ON portal_login_codes (email, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS portal_sessions (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    email text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    CONSTRAINT portal_sessions_token_hash_uniq UNIQUE (token_hash)
+);
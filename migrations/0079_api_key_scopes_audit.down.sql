@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS api_key_audit;
+
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS rotated_from_id,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS scopes;
@@ -0,0 +1,17 @@
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS scopes text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS last_used_at timestamp with time zone,
+    ADD COLUMN IF NOT EXISTS rotated_from_id uuid REFERENCES api_keys(id);
+
+CREATE TABLE IF NOT EXISTS api_key_audit (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    key_id uuid NOT NULL REFERENCES api_keys(id),
+    merchant_id text NOT NULL,
+    method text NOT NULL,
+    path text NOT NULL,
+    status integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_key_audit_merchant_id_created_at_idx ON api_key_audit (merchant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS api_key_audit_key_id_created_at_idx ON api_key_audit (key_id, created_at DESC);
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS merchant_webhook_deliveries;
+DROP TABLE IF EXISTS merchant_webhook_endpoints;
+
+DROP INDEX IF EXISTS order_events_outbox_webhooks_unqueued_idx;
+
+ALTER TABLE order_events_outbox DROP COLUMN IF EXISTS webhooks_queued_at;
+
+DELETE FROM order_events_outbox WHERE event_type = 'order.credentials_signed';
+
+ALTER TABLE order_events_outbox DROP CONSTRAINT order_events_outbox_check_event_type;
+
+ALTER TABLE order_events_outbox ADD CONSTRAINT order_events_outbox_check_event_type CHECK (
+    event_type IN ('order.created', 'order.paid', 'order.renewed', 'order.canceled', 'order.payment_failed', 'order.payment_reminder', 'order.expired')
+);
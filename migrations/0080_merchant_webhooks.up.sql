@@ -0,0 +1,42 @@
+ALTER TABLE order_events_outbox DROP CONSTRAINT order_events_outbox_check_event_type;
+
+ALTER TABLE order_events_outbox ADD CONSTRAINT order_events_outbox_check_event_type CHECK (
+    event_type IN ('order.created', 'order.paid', 'order.renewed', 'order.canceled', 'order.payment_failed', 'order.payment_reminder', 'order.expired', 'order.credentials_signed')
+);
+
+-- Events recorded before webhooks existed are not delivered.
+ALTER TABLE order_events_outbox ADD COLUMN IF NOT EXISTS webhooks_queued_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE order_events_outbox ALTER COLUMN webhooks_queued_at DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS order_events_outbox_webhooks_unqueued_idx ON order_events_outbox (created_at) WHERE webhooks_queued_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS merchant_webhook_endpoints (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    merchant_id text NOT NULL,
+    url text NOT NULL,
+    key_id uuid NOT NULL REFERENCES api_keys(id),
+    event_types text[] NOT NULL DEFAULT '{}',
+    disabled_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS merchant_webhook_endpoints_merchant_id_idx ON merchant_webhook_endpoints (merchant_id) WHERE disabled_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS merchant_webhook_deliveries (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    endpoint_id uuid NOT NULL REFERENCES merchant_webhook_endpoints(id),
+    event_id uuid NOT NULL REFERENCES order_events_outbox(id),
+    status text NOT NULL DEFAULT 'pending',
+    num_attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone,
+    last_attempt_at timestamp with time zone,
+    last_status_code integer,
+    last_error text,
+    delivered_at timestamp with time zone,
+    CONSTRAINT merchant_webhook_deliveries_check_status CHECK (status IN ('pending', 'delivered', 'failed')),
+    UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS merchant_webhook_deliveries_next_attempt_at_idx ON merchant_webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS merchant_webhook_deliveries_endpoint_id_created_at_idx ON merchant_webhook_deliveries (endpoint_id, created_at DESC);
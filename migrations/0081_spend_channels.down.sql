@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS spend_drain;
+DROP TABLE IF EXISTS spend_channels;
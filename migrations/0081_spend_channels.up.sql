@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS spend_channels (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    merchant_id text NOT NULL,
+    name text NOT NULL,
+    topic text NOT NULL,
+    payload_schema text NOT NULL,
+    allowed_skus text[] NOT NULL,
+    disabled_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS spend_channels_merchant_id_name_uniq ON spend_channels (merchant_id, name) WHERE disabled_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS spend_drain (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    channel_id uuid NOT NULL REFERENCES spend_channels(id),
+    credentials json NOT NULL,
+    payload text NOT NULL,
+    spend_event bytea NOT NULL,
+    redeemed boolean NOT NULL DEFAULT false,
+    erred boolean NOT NULL DEFAULT false,
+    errcode text,
+    processed boolean NOT NULL DEFAULT false
+);
+
+CREATE INDEX IF NOT EXISTS spend_drain_processed_erred_idx ON spend_drain (created_at) WHERE processed = false AND erred = false;
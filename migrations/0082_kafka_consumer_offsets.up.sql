@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS kafka_consumer_offsets (
+    group_id text NOT NULL,
+    topic text NOT NULL,
+    partition integer NOT NULL,
+    last_offset bigint NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, topic, partition)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS issuer_keys;
+
+DROP INDEX IF EXISTS order_cred_issuers_keys_checked_at_idx;
+
+ALTER TABLE order_cred_issuers DROP COLUMN IF EXISTS keys_checked_at;
+ALTER TABLE order_cred_issuers DROP COLUMN IF EXISTS version;
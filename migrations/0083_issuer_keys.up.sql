@@ -0,0 +1,17 @@
+ALTER TABLE order_cred_issuers ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE order_cred_issuers ADD COLUMN IF NOT EXISTS keys_checked_at timestamp with time zone;
+
+UPDATE order_cred_issuers SET version = 3 WHERE id IN (SELECT DISTINCT issuer_id FROM time_limited_v2_order_creds);
+
+CREATE INDEX IF NOT EXISTS order_cred_issuers_keys_checked_at_idx ON order_cred_issuers (keys_checked_at NULLS FIRST) WHERE version = 3;
+
+CREATE TABLE IF NOT EXISTS issuer_keys (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    issuer_id uuid NOT NULL REFERENCES order_cred_issuers(id),
+    public_key text NOT NULL,
+    valid_from timestamp with time zone NOT NULL,
+    valid_to timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone,
+    CONSTRAINT issuer_keys_issuer_id_public_key_uniq UNIQUE (issuer_id, public_key)
+);
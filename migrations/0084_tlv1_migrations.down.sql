@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tlv1_presentations;
+DROP TABLE IF EXISTS tlv1_migrations;
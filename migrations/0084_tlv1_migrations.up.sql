@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS tlv1_migrations (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    merchant_id text NOT NULL,
+    sku text NOT NULL,
+    each_credential_valid_duration text NOT NULL,
+    issuer_token_buffer integer NOT NULL,
+    issuer_token_overlap integer NOT NULL DEFAULT 0,
+    v1_accepted_until timestamp with time zone,
+    CONSTRAINT tlv1_migrations_merchant_id_sku_uniq UNIQUE (merchant_id, sku)
+);
+
+CREATE TABLE IF NOT EXISTS tlv1_presentations (
+    merchant_id text NOT NULL,
+    sku text NOT NULL,
+    day date NOT NULL,
+    num_presented bigint NOT NULL DEFAULT 0,
+    last_presented_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (merchant_id, sku, day)
+);
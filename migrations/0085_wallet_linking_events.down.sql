@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS handle_wallet_linking_events_change ON wallet_linking_events;
+DROP FUNCTION IF EXISTS forbid_wallet_linking_events_change();
+DROP TABLE IF EXISTS wallet_linking_events;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS wallet_linking_events (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    wallet_id uuid NOT NULL,
+    custodian text NOT NULL,
+    linking_id uuid,
+    country text NOT NULL DEFAULT '',
+    outcome text NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    CONSTRAINT wallet_linking_events_outcome_check CHECK (outcome IN ('linked', 'unlinked', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS wallet_linking_events_wallet_id_idx ON wallet_linking_events(wallet_id);
+CREATE INDEX IF NOT EXISTS wallet_linking_events_linking_id_idx ON wallet_linking_events(linking_id);
+CREATE INDEX IF NOT EXISTS wallet_linking_events_created_at_idx ON wallet_linking_events(created_at);
+
+CREATE OR REPLACE FUNCTION forbid_wallet_linking_events_change() RETURNS TRIGGER AS $$
+    BEGIN
+        RAISE EXCEPTION 'wallet_linking_events is append-only';
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER handle_wallet_linking_events_change
+BEFORE UPDATE OR DELETE ON wallet_linking_events FOR EACH ROW EXECUTE PROCEDURE forbid_wallet_linking_events_change();
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS handle_linking_limit_change ON linking_limits;
+DROP FUNCTION IF EXISTS save_linking_limit_history();
+DROP TABLE IF EXISTS linking_limit_history;
+DROP TABLE IF EXISTS linking_limits;
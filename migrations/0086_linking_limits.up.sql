@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS linking_limits (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    custodian text NOT NULL,
+    country text NOT NULL DEFAULT '',
+    linking_id uuid,
+    max_slots integer NOT NULL,
+    relink_cooldown_seconds integer NOT NULL DEFAULT 0,
+    CONSTRAINT linking_limits_max_slots_check CHECK (max_slots >= 0),
+    CONSTRAINT linking_limits_relink_cooldown_seconds_check CHECK (relink_cooldown_seconds >= 0),
+    CONSTRAINT linking_limits_scope_check CHECK (linking_id IS NULL OR country = '')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS linking_limits_custodian_country_idx ON linking_limits(custodian, country) WHERE linking_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS linking_limits_custodian_linking_id_idx ON linking_limits(custodian, linking_id) WHERE linking_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS linking_limit_history (
+    id bigserial PRIMARY KEY,
+    operation text NOT NULL,
+    executed_by text NOT NULL DEFAULT current_user,
+    recorded_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    linking_limit_id uuid NOT NULL,
+    value_before jsonb,
+    value_after jsonb
+);
+
+CREATE INDEX IF NOT EXISTS linking_limit_history_linking_limit_id_idx ON linking_limit_history(linking_limit_id);
+
+CREATE OR REPLACE FUNCTION save_linking_limit_history() RETURNS TRIGGER AS $$
+    BEGIN
+        IF (TG_OP = 'INSERT') THEN
+            INSERT INTO linking_limit_history(operation, linking_limit_id, value_after)
+            VALUES (TG_OP, NEW.id, row_to_json(NEW)::jsonb);
+
+            RETURN NEW;
+
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO linking_limit_history(operation, linking_limit_id, value_before, value_after)
+            VALUES (TG_OP, NEW.id, row_to_json(OLD)::jsonb, row_to_json(NEW)::jsonb);
+
+            RETURN NEW;
+
+        ELSIF (TG_OP = 'DELETE') THEN
+            INSERT INTO linking_limit_history(operation, linking_limit_id, value_before)
+            VALUES (TG_OP, OLD.id, row_to_json(OLD)::jsonb);
+
+            RETURN OLD;
+        END IF;
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER handle_linking_limit_change
+AFTER INSERT OR UPDATE OR DELETE ON linking_limits FOR EACH ROW EXECUTE FUNCTION save_linking_limit_history();
+
+
+-- Custodians without a row keep the limit from their *_WALLET_LINKING_LIMIT env var, see getEnvMaxCards.
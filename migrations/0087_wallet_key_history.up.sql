@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS wallet_key_history (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    wallet_id uuid NOT NULL,
+    public_key text NOT NULL,
+    valid_from timestamp with time zone,
+    valid_to timestamp with time zone NOT NULL,
+    next_public_key text NOT NULL,
+    signature text NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_key_history_public_key_idx ON wallet_key_history(public_key);
+CREATE INDEX IF NOT EXISTS wallet_key_history_wallet_id_valid_to_idx ON wallet_key_history(wallet_id, valid_to);
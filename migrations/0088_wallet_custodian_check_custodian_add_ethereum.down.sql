@@ -0,0 +1,4 @@
+DELETE FROM linking_limits WHERE custodian = 'ethereum';
+
+ALTER TABLE wallet_custodian DROP CONSTRAINT IF EXISTS check_custodian,
+    ADD CONSTRAINT check_custodian CHECK (custodian IN ('brave', 'uphold', 'bitflyer', 'gemini', 'zebpay', 'solana'));
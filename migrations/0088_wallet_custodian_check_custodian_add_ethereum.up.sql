@@ -0,0 +1,4 @@
+ALTER TABLE wallet_custodian DROP CONSTRAINT IF EXISTS check_custodian,
+    ADD CONSTRAINT check_custodian CHECK (custodian IN ('brave', 'uphold', 'bitflyer', 'gemini', 'zebpay', 'solana', 'ethereum'));
+
+INSERT INTO linking_limits (custodian, max_slots) VALUES ('ethereum', 4) ON CONFLICT DO NOTHING;
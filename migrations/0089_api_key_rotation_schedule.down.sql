@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS api_key_audit_created_at_idx;
+
+DROP INDEX IF EXISTS api_keys_next_rotation_at_idx;
+
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS next_rotation_at,
+    DROP COLUMN IF EXISTS rotation_overlap_seconds,
+    DROP COLUMN IF EXISTS rotate_every_seconds;
@@ -0,0 +1,8 @@
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS rotate_every_seconds integer,
+    ADD COLUMN IF NOT EXISTS rotation_overlap_seconds integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_rotation_at timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS api_keys_next_rotation_at_idx ON api_keys (next_rotation_at) WHERE next_rotation_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS api_key_audit_created_at_idx ON api_key_audit (created_at);
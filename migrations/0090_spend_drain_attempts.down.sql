@@ -0,0 +1,4 @@
+ALTER TABLE spend_drain
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS num_attempts;
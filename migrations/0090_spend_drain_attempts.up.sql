@@ -0,0 +1,4 @@
+ALTER TABLE spend_drain
+    ADD COLUMN IF NOT EXISTS num_attempts integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS last_error text;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS kafka_consumer_pauses (
+    group_id text NOT NULL,
+    topic text NOT NULL,
+    paused boolean NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, topic)
+);
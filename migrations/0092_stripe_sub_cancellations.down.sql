@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS stripe_sub_cancellations_pending_idx;
+
+DROP TABLE IF EXISTS stripe_sub_cancellations;
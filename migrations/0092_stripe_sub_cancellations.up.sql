@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stripe_sub_cancellations (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    order_id uuid NOT NULL REFERENCES orders(id),
+    sub_id text NOT NULL,
+    num_attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error text,
+    canceled_at timestamp with time zone,
+    CONSTRAINT stripe_sub_cancellations_sub_id_uniq UNIQUE (sub_id)
+);
+
+CREATE INDEX IF NOT EXISTS stripe_sub_cancellations_pending_idx ON stripe_sub_cancellations (next_attempt_at) WHERE canceled_at IS NULL;
@@ -0,0 +1,8 @@
+DELETE FROM merchant_webhook_deliveries WHERE key_event_id IS NOT NULL;
+
+ALTER TABLE merchant_webhook_deliveries DROP CONSTRAINT IF EXISTS merchant_webhook_deliveries_check_event;
+ALTER TABLE merchant_webhook_deliveries DROP CONSTRAINT IF EXISTS merchant_webhook_deliveries_key_event_uniq;
+ALTER TABLE merchant_webhook_deliveries DROP COLUMN IF EXISTS key_event_id;
+ALTER TABLE merchant_webhook_deliveries ALTER COLUMN event_id SET NOT NULL;
+
+DROP TABLE IF EXISTS merchant_key_events;
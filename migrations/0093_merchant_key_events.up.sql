@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS merchant_key_events (
+    id uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    merchant_id text NOT NULL,
+    event_type text NOT NULL,
+    key_id uuid NOT NULL REFERENCES api_keys(id),
+    new_key_id uuid NOT NULL REFERENCES api_keys(id),
+    occurred_at timestamp with time zone NOT NULL,
+    CONSTRAINT merchant_key_events_check_event_type CHECK (event_type IN ('key.rotated'))
+);
+
+ALTER TABLE merchant_webhook_deliveries ALTER COLUMN event_id DROP NOT NULL;
+ALTER TABLE merchant_webhook_deliveries ADD COLUMN IF NOT EXISTS key_event_id uuid REFERENCES merchant_key_events(id);
+ALTER TABLE merchant_webhook_deliveries ADD CONSTRAINT merchant_webhook_deliveries_key_event_uniq UNIQUE (endpoint_id, key_event_id);
+ALTER TABLE merchant_webhook_deliveries ADD CONSTRAINT merchant_webhook_deliveries_check_event CHECK (num_nonnulls(event_id, key_event_id) = 1);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS webhook_inbox_pending_order_key_idx;
+
+ALTER TABLE webhook_inbox DROP CONSTRAINT IF EXISTS webhook_inbox_vendor_event_id_uniq;
+ALTER TABLE webhook_inbox DROP COLUMN IF EXISTS order_key;
+ALTER TABLE webhook_inbox DROP COLUMN IF EXISTS event_id;
@@ -0,0 +1,5 @@
+ALTER TABLE webhook_inbox ADD COLUMN IF NOT EXISTS event_id text;
+ALTER TABLE webhook_inbox ADD COLUMN IF NOT EXISTS order_key text;
+ALTER TABLE webhook_inbox ADD CONSTRAINT webhook_inbox_vendor_event_id_uniq UNIQUE (vendor, event_id);
+
+CREATE INDEX IF NOT EXISTS webhook_inbox_pending_order_key_idx ON webhook_inbox (order_key, created_at) WHERE status = 'pending';
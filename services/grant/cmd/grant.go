@@ -93,6 +93,11 @@ func init() {
 		Bind("disable-solana-linking").
 		Env("DISABLE_SOLANA_LINKING")
 
+	flagBuilder.Flag().Bool("disable-ethereum-linking", false,
+		"disable address linking for ethereum").
+		Bind("disable-ethereum-linking").
+		Env("DISABLE_ETHEREUM_LINKING")
+
 	flagBuilder.Flag().StringSlice("brave-transfer-promotion-ids", []string{""},
 		"brave vg deposit destination promotion id").
 		Bind("brave-transfer-promotion-ids").
@@ -169,6 +174,37 @@ func init() {
 		"the server webhook secret for radom",
 	).Bind("radom-webhook-secret").Env("RADOM_WEBHOOK_SECRET")
 
+	// Configuration for PayPal.
+	flagBuilder.Flag().Bool(
+		"paypal-enabled",
+		false,
+		"is paypal enabled for skus",
+	).Bind("paypal-enabled").Env("PAYPAL_ENABLED")
+
+	flagBuilder.Flag().String(
+		"paypal-server",
+		"",
+		"the server address for paypal",
+	).Bind("paypal-server").Env("PAYPAL_SERVER")
+
+	flagBuilder.Flag().String(
+		"paypal-client-id",
+		"",
+		"the client id for paypal",
+	).Bind("paypal-client-id").Env("PAYPAL_CLIENT_ID")
+
+	flagBuilder.Flag().String(
+		"paypal-secret",
+		"",
+		"the client secret for paypal",
+	).Bind("paypal-secret").Env("PAYPAL_SECRET")
+
+	flagBuilder.Flag().String(
+		"paypal-webhook-id",
+		"",
+		"the id of the webhook which paypal notifications are signed for",
+	).Bind("paypal-webhook-id").Env("PAYPAL_WEBHOOK_ID")
+
 	// stripe configurations
 	flagBuilder.Flag().Bool("stripe-enabled", false,
 		"is stripe enabled for skus").
@@ -455,8 +491,24 @@ func setupRouter(ctx context.Context, logger *zerolog.Logger) (context.Context,
 	skuCtx = context.WithValue(skuCtx, appctx.GeminiClientSecretCTXKey, viper.GetString("skus-gemini-client-secret"))
 
 	skuTLV2Repo := repository.NewTLV2()
-
-	skusService, err := skus.InitService(skuCtx, skusPG, walletService, skuOrderRepo, skuOrderItemRepo, skuIssuerRepo, skuOrderPayHistRepo, skuTLV2Repo)
+	skuWebhookInboxRepo := repository.NewWebhookInbox()
+	skuTxnRepo := repository.NewTransaction()
+	skuSeatRepo := repository.NewOrderSeat()
+	skuCouponRepo := repository.NewCoupon()
+	skuOrderEvRepo := repository.NewOrderEvent()
+	skuOrderDunRepo := repository.NewOrderDunning()
+	skuSubCancelRepo := repository.NewStripeSubCancel()
+	skuCatalogRepo := repository.NewSKUCatalog()
+	skuPriceRepo := repository.NewSKUPrice()
+	skuPortalRepo := repository.NewPortal()
+	skuMerchKeyRepo := repository.NewMerchantKey()
+	skuMerchHookRepo := repository.NewMerchantWebhook()
+	skuSpendRepo := repository.NewSpend()
+	skuOffsetRepo := repository.NewConsumerOffset()
+	skuIssuerKeyRepo := repository.NewIssuerKey()
+	skuTLV1MigRepo := repository.NewTLV1Migration()
+
+	skusService, err := skus.InitService(skuCtx, skusPG, walletService, skuOrderRepo, skuOrderItemRepo, skuIssuerRepo, skuOrderPayHistRepo, skuTLV2Repo, skuWebhookInboxRepo, skuTxnRepo, skuSeatRepo, skuCouponRepo, skuOrderEvRepo, skuOrderDunRepo, skuSubCancelRepo, skuCatalogRepo, skuPriceRepo, skuPortalRepo, skuMerchKeyRepo, skuMerchHookRepo, skuSpendRepo, skuOffsetRepo, skuIssuerKeyRepo, skuTLV1MigRepo)
 	if err != nil {
 		sentry.CaptureException(err)
 		logger.Panic().Err(err).Msg("SKUs service initialization failed")
@@ -474,13 +526,16 @@ func setupRouter(ctx context.Context, logger *zerolog.Logger) (context.Context,
 		corsOpts := skus.NewCORSOpts(origins, dbg)
 
 		authMwr := skus.NewAuthMwr(skusService)
+		ordAuthMwr := skus.WithKeyScope(authMwr, skus.KeyScopeManageOrders)
 
 		r.Mount("/v1/credentials", skus.CredentialRouter(skusService, authMwr))
 		r.Mount("/v2/credentials", skus.CredentialV2Router(skusService, authMwr))
 		r.Mount("/v1/orders", skus.Router(skusService, authMwr, middleware.InstrumentHandler, corsOpts))
+		r.Mount("/v1/portal", skus.PortalRouter(ctx, skusService, corsOpts))
+		r.Mount("/v1/merchant", skus.MerchantAPIRouter(skusService, authMwr))
 
 		subr := chi.NewRouter()
-		orderh := handler.NewOrder(skusService)
+		orderh := handler.NewOrder(skusService, skus.NewGeoProxy())
 
 		if os.Getenv("ENV") == "local" {
 			corsMwrPost := skus.NewCORSMwr(corsOpts, http.MethodPost)
@@ -503,7 +558,7 @@ func setupRouter(ctx context.Context, logger *zerolog.Logger) (context.Context,
 			subr.Method(
 				http.MethodPost,
 				"/",
-				middleware.InstrumentHandler("CreateOrderNew", authMwr(handlers.AppHandler(orderh.CreateNew))),
+				middleware.InstrumentHandler("CreateOrderNew", ordAuthMwr(handlers.AppHandler(orderh.CreateNew))),
 			)
 		}
 
@@ -514,7 +569,7 @@ func setupRouter(ctx context.Context, logger *zerolog.Logger) (context.Context,
 			"/{orderID}",
 			middleware.InstrumentHandler(
 				"CancelOrderNew",
-				corsMwrDelete(authMwr(handlers.AppHandler(orderh.Cancel))),
+				corsMwrDelete(ordAuthMwr(handlers.AppHandler(orderh.Cancel))),
 			),
 		)
 
@@ -522,7 +577,13 @@ func setupRouter(ctx context.Context, logger *zerolog.Logger) (context.Context,
 	}
 
 	r.Mount("/v1/webhooks", skus.WebhookRouter(skusService))
+	r.Mount("/v1/coupons", skus.CouponRouter(skusService))
+	r.Mount("/v1/sku-catalog", skus.SKUCatalogRouter(skusService))
+	r.Mount("/v1/consumers", skus.ConsumerRouter(skusService))
+	r.Mount("/v1/issuers", skus.IssuerRouter(skusService))
+	r.Mount("/v1/tlv1-migrations", skus.TLV1MigrationRouter(skusService))
 	r.Mount("/v1/votes", skus.VoteRouter(skusService, middleware.InstrumentHandler))
+	r.Mount("/v1/spend", skus.SpendRouter(skusService, middleware.InstrumentHandler))
 
 	// add profiling flag to enable profiling routes
 	if os.Getenv("PPROF_ENABLED") != "" {
@@ -635,6 +696,7 @@ func GrantServer(
 	ctx = context.WithValue(ctx, appctx.DisableGeminiLinkingCTXKey, viper.GetBool("disable-gemini-linking"))
 	ctx = context.WithValue(ctx, appctx.DisableBitflyerLinkingCTXKey, viper.GetBool("disable-bitflyer-linking"))
 	ctx = context.WithValue(ctx, appctx.DisableSolanaLinkingCTXKey, viper.GetBool("disable-solana-linking"))
+	ctx = context.WithValue(ctx, appctx.DisableEthereumLinkingCTXKey, viper.GetBool("disable-ethereum-linking"))
 
 	// stripe variables
 	ctx = context.WithValue(ctx, appctx.StripeEnabledCTXKey, viper.GetBool("stripe-enabled"))
@@ -724,6 +786,7 @@ func newSrvStatusFromCtx(ctx context.Context) map[string]any {
 	bf, _ := ctx.Value(appctx.DisableBitflyerLinkingCTXKey).(bool)
 	zp, _ := ctx.Value(appctx.DisableZebPayLinkingCTXKey).(bool)
 	s, _ := ctx.Value(appctx.DisableSolanaLinkingCTXKey).(bool)
+	eth, _ := ctx.Value(appctx.DisableEthereumLinkingCTXKey).(bool)
 
 	result := map[string]interface{}{
 		"wallet": map[string]bool{
@@ -732,6 +795,7 @@ func newSrvStatusFromCtx(ctx context.Context) map[string]any {
 			"bitflyer": !bf,
 			"zebpay":   !zp,
 			"solana":   !s,
+			"ethereum": !eth,
 		},
 	}
 
@@ -17,6 +17,7 @@ func TestNewSrvStatusFromCtx(t *testing.T) {
 	ctx = context.WithValue(ctx, appctx.DisableBitflyerLinkingCTXKey, true)
 	ctx = context.WithValue(ctx, appctx.DisableZebPayLinkingCTXKey, true)
 	ctx = context.WithValue(ctx, appctx.DisableSolanaLinkingCTXKey, true)
+	ctx = context.WithValue(ctx, appctx.DisableEthereumLinkingCTXKey, true)
 
 	act := newSrvStatusFromCtx(ctx)
 	exp := map[string]interface{}{
@@ -26,6 +27,7 @@ func TestNewSrvStatusFromCtx(t *testing.T) {
 			"bitflyer": false,
 			"zebpay":   false,
 			"solana":   false,
+			"ethereum": false,
 		},
 	}
 
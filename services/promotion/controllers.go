@@ -2,7 +2,6 @@ package promotion
 
 import (
 	"context"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -121,30 +120,10 @@ func WalletEventRouter(service *Service, vbatExpires time.Time) chi.Router {
 }
 
 // LookupVerifier based on the HTTP signing keyID, which in our case is the walletID
+//
+// It defers to the wallet service, so that the previous key of a wallet is accepted during its key rotation overlap.
 func (service *Service) LookupVerifier(ctx context.Context, keyID string) (context.Context, httpsignature.Verifier, error) {
-	walletID, err := uuid.FromString(keyID)
-	if err != nil {
-		return nil, nil, errorutils.Wrap(err, "KeyID format is invalid")
-	}
-
-	wallet, err := service.wallet.GetWallet(ctx, walletID)
-	if err != nil {
-		return nil, nil, errorutils.Wrap(err, "error getting wallet")
-	}
-
-	if wallet == nil {
-		return nil, nil, nil
-	}
-
-	var publicKey httpsignature.Ed25519PubKey
-	if len(wallet.PublicKey) > 0 {
-		var err error
-		publicKey, err = hex.DecodeString(wallet.PublicKey)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-	return ctx, publicKey, nil
+	return service.wallet.LookupVerifier(ctx, keyID)
 }
 
 // PromotionsResponse is a list of known promotions to be consumed by the browser
@@ -1,17 +1,23 @@
 package skus
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/awa/go-iap/appstore"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 	"github.com/square/go-jose"
 
+	"github.com/brave-intl/bat-go/libs/logging"
+
 	"github.com/brave-intl/bat-go/services/skus/model"
 )
 
@@ -53,6 +59,12 @@ type assnHeader struct {
 type appStoreSrvNotification struct {
 	pubKey *ecdsa.PublicKey
 	val    *appstore.SubscriptionNotificationV2DecodedPayload
+
+	// renewal is only parsed for notifications about payment failures.
+	renewal *appstore.JWSRenewalInfoDecodedPayload
+
+	// price is only parsed for notifications about refunds.
+	price *appStoreTxnPrice
 }
 
 // shouldProcess determines whether x should be processed.
@@ -67,7 +79,7 @@ func (x *appStoreSrvNotification) shouldProcess() bool {
 	// - x.val.NotificationType == appstore.NotificationTypeV2Revoke && x.val.Subtype == "":
 	//     - a family member lost access to the subscription.
 
-	return x.shouldRenew() || x.shouldCancel()
+	return x.shouldRenew() || x.shouldCancel() || x.shouldChangePlan() || x.shouldRefund() || x.shouldRecordPayFailure()
 }
 
 // shouldRenew reports whether the ntf is about renewal.
@@ -109,13 +121,6 @@ func (x *appStoreSrvNotification) shouldCancel() bool {
 	case x.val.NotificationType == appstore.NotificationTypeV2DidChangeRenewalStatus && x.val.Subtype == appstore.SubTypeV2AutoRenewDisabled:
 		return true
 
-	// Extra events relating to cancellations or refunds.
-	//
-	// Need to make sure the order is cancelled.
-	// Apple processed a refund.
-	case x.val.NotificationType == appstore.NotificationTypeV2Refund && x.val.Subtype == "":
-		return true
-
 	// Expiration after user's cancellation.
 	case x.val.NotificationType == appstore.NotificationTypeV2Expired && x.val.Subtype == appstore.SubTypeV2Voluntary:
 		return true
@@ -133,6 +138,31 @@ func (x *appStoreSrvNotification) shouldCancel() bool {
 	}
 }
 
+// shouldRefund reports whether the ntf is about a refund processed by Apple.
+func (x *appStoreSrvNotification) shouldRefund() bool {
+	return x.val.NotificationType == appstore.NotificationTypeV2Refund && x.val.Subtype == ""
+}
+
+// shouldChangePlan reports whether the ntf is about an immediate plan change.
+//
+// Only upgrades take effect immediately.
+// A downgrade takes effect on the next renewal, and is handled as part of it.
+func (x *appStoreSrvNotification) shouldChangePlan() bool {
+	return x.val.NotificationType == appstore.NotificationTypeV2DidChangeRenewalPref && x.val.Subtype == appstore.SubTypeV2Upgrade
+}
+
+// shouldRecordPayFailure reports whether the ntf is about a failed renewal.
+//
+// The subtype is GRACE_PERIOD when Apple's billing grace period is enabled, and empty otherwise.
+func (x *appStoreSrvNotification) shouldRecordPayFailure() bool {
+	return x.val.NotificationType == appstore.NotificationTypeV2DidFailToRenew
+}
+
+// isInBillingRetry reports whether Apple is still attempting to renew the subscription.
+func (x *appStoreSrvNotification) isInBillingRetry() bool {
+	return x.renewal != nil && x.renewal.IsInBillingRetryPeriod
+}
+
 func (x *appStoreSrvNotification) ntfType() string {
 	return string(x.val.NotificationType)
 }
@@ -147,11 +177,35 @@ func (x *appStoreSrvNotification) effect() string {
 		return "renew"
 	case x.shouldCancel():
 		return "cancel"
+	case x.shouldChangePlan():
+		return "change_plan"
+	case x.shouldRefund():
+		return "refund"
+	case x.shouldRecordPayFailure():
+		return "record_payment_failure"
 	default:
 		return "skip"
 	}
 }
 
+func (x *appStoreSrvNotification) eventID() string {
+	return x.val.NotificationUUID
+}
+
+// orderKey returns the original transaction id, which identifies the subscription.
+func (x *appStoreSrvNotification) orderKey() string {
+	if x.pubKey == nil {
+		return ""
+	}
+
+	txn, err := parseTxnInfo(x.pubKey, x.val.Data.SignedTransactionInfo)
+	if err != nil {
+		return ""
+	}
+
+	return txn.OriginalTransactionId
+}
+
 func (x *appStoreSrvNotification) pkg() string {
 	return x.val.Data.BundleID
 }
@@ -217,7 +271,33 @@ func parseTxnInfo(pubKey *ecdsa.PublicKey, spayload appstore.JWSTransaction) (*a
 	return (*appStoreTransaction)(result), nil
 }
 
-//nolint:unused
+// appStoreTxnPrice is the price of a transaction, which the version of appstore.JWSTransactionDecodedPayload in use lacks.
+//
+// The price is in milliunits of the currency.
+type appStoreTxnPrice struct {
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+func parseTxnPrice(pubKey *ecdsa.PublicKey, spayload appstore.JWSTransaction) (*appStoreTxnPrice, error) {
+	raw, err := jose.ParseSigned(string(spayload))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := raw.Verify(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &appStoreTxnPrice{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func parseRenewalInfo(pubKey *ecdsa.PublicKey, spayload appstore.JWSRenewalInfo) (*appstore.JWSRenewalInfoDecodedPayload, error) {
 	raw, err := jose.ParseSigned(string(spayload))
 	if err != nil {
@@ -353,6 +433,20 @@ func (x *appStoreTransaction) isRevoked(now time.Time) bool {
 	return x.RevocationDate > 0 && now.After(time.UnixMilli(x.RevocationDate))
 }
 
+// newAppStoreRefundTxn returns a refund transaction for the price of the refunded transaction.
+//
+// Transactions signed before Apple added the price are refunded at the order's price.
+func newAppStoreRefundTxn(ord *model.Order, extID string, price *appStoreTxnPrice) model.TransactionNew {
+	result := newOrderRefundTxn(ord, extID)
+
+	if price != nil && price.Price > 0 && price.Currency != "" {
+		result.Currency = strings.ToUpper(price.Currency)
+		result.Amount = decimal.New(-price.Price, -3)
+	}
+
+	return result
+}
+
 func newReceiptDataApple(req model.ReceiptRequest, item *wrapAppStoreInApp) model.ReceiptData {
 	result := model.ReceiptData{
 		Type:      req.Type,
@@ -400,3 +494,145 @@ func (x *appStoreInApp) expiresTime() time.Time {
 
 	return time.UnixMilli(expms).UTC()
 }
+
+type appStoreProcessor struct {
+	vrf     *assnCertVerifier
+	catalog *skuCatalog
+}
+
+func newAppStoreProcessor(vrf *assnCertVerifier, catalog *skuCatalog) *appStoreProcessor {
+	return &appStoreProcessor{vrf: vrf, catalog: catalog}
+}
+
+func (p *appStoreProcessor) Name() string {
+	return model.WebhookVendorAppStore
+}
+
+// CreateCheckoutSession is not supported, as orders are created from receipts.
+func (p *appStoreProcessor) CreateCheckoutSession(_ context.Context, _ *model.CreateOrderRequestNew, _ *model.Order, _ *model.Coupon) (*CheckoutSession, error) {
+	return nil, errPaymentCheckoutUnsupported
+}
+
+// AuthenticateNotification extracts the signed payload from the body.
+//
+// The signature is verified when the payload is parsed, as parsing and verification are inseparable.
+func (p *appStoreProcessor) AuthenticateNotification(_ context.Context, _ *http.Request, body []byte) ([]byte, error) {
+	spayload := &struct {
+		SignedPayload string `json:"signedPayload"`
+	}{}
+
+	if err := json.Unmarshal(body, spayload); err != nil {
+		// Nothing can be done about a malformed body, so it is acknowledged.
+		return nil, newPaymentNtfError(err, http.StatusOK)
+	}
+
+	return []byte(spayload.SignedPayload), nil
+}
+
+func (p *appStoreProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	return parseAppStoreSrvNotification(p.vrf, string(payload))
+}
+
+// ProcessNotification processes ntf.
+//
+// More on ntf types https://developer.apple.com/documentation/appstoreservernotifications/notificationtype#4304524.
+func (p *appStoreProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*appStoreSrvNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	txn, err := parseTxnInfo(ntf.pubKey, ntf.val.Data.SignedTransactionInfo)
+	if err != nil {
+		return err
+	}
+
+	if ntf.shouldRecordPayFailure() {
+		ntf.renewal, err = parseRenewalInfo(ntf.pubKey, ntf.val.Data.SignedRenewalInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ntf.shouldRefund() {
+		ntf.price, err = parseTxnPrice(ntf.pubKey, ntf.val.Data.SignedTransactionInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.processTx(ctx, dbi, sm, ntf, txn)
+}
+
+func (p *appStoreProcessor) processTx(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntf *appStoreSrvNotification, txn *appStoreTransaction) error {
+	ord, err := sm.getOrderByExternalIDTx(ctx, dbi, txn.OriginalTransactionId)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ntf.shouldRenew():
+		expt := txn.expiresTime().Add(24 * time.Hour)
+		paidt := time.Now()
+
+		// A downgrade takes effect on renewal, with the new product in txn.
+		//
+		// The renewal has been paid for, so it's applied even if the plan can't be changed.
+		if err := p.changeOrderPlan(ctx, dbi, sm, ord, txn, expt); err != nil {
+			if !isErrOrderPlanChange(err) {
+				return err
+			}
+
+			logging.Logger(ctx, "skus").Warn().Err(err).Str("order_id", ord.ID.String()).Str("product_id", txn.ProductId).Msg("failed to change order plan on renewal")
+		}
+
+		return sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt)
+
+	case ntf.shouldCancel():
+		return sm.cancelOrderTx(ctx, dbi, ord.ID)
+
+	case ntf.shouldChangePlan():
+		expt := txn.expiresTime().Add(24 * time.Hour)
+
+		return p.changeOrderPlan(ctx, dbi, sm, ord, txn, expt)
+
+	case ntf.shouldRefund():
+		req := newAppStoreRefundTxn(ord, txn.TransactionId, ntf.price)
+
+		// The App Store refunds whole transactions.
+		return sm.refundOrderTx(ctx, dbi, req, true, time.Now())
+
+	case ntf.shouldRecordPayFailure():
+		// Apple keeps retrying only while the subscription is in the billing retry period.
+		// Otherwise, the subscription expires, and an expiration notification follows.
+		if !ntf.isInBillingRetry() {
+			return nil
+		}
+
+		return sm.recordPayFailureTx(ctx, dbi, ord, model.WebhookVendorAppStore, time.Now())
+
+	default:
+		return nil
+	}
+}
+
+func (p *appStoreProcessor) changeOrderPlan(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ord *model.Order, txn *appStoreTransaction, expt time.Time) error {
+	itemReq, err := p.catalog.itemReqByStoreProductID(ctx, dbi, txn.ProductId)
+	if err != nil {
+		return err
+	}
+
+	items, err := sm.getOrderItemsTx(ctx, dbi, ord.ID)
+	if err != nil {
+		return err
+	}
+
+	ord.Items = items
+
+	return sm.changeOrderPlanTx(ctx, dbi, ord, itemReq.SKUVnt, expt, time.Now())
+}
+
+// Subscription is not supported, as App Store subscriptions are only known from receipts and notifications.
+func (p *appStoreProcessor) Subscription(_ context.Context, _ string) (*PaymentSubscription, error) {
+	return nil, errPaymentSubUnsupported
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/awa/go-iap/appstore"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	should "github.com/stretchr/testify/assert"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
@@ -40,6 +42,27 @@ func TestAppStoreSrvNotification_shouldProcess(t *testing.T) {
 			exp: true,
 		},
 
+		{
+			name: "should_change_plan",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidChangeRenewalPref,
+					Subtype:          appstore.SubTypeV2Upgrade,
+				},
+			},
+			exp: true,
+		},
+
+		{
+			name: "should_record_pay_failure",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidFailToRenew,
+				},
+			},
+			exp: true,
+		},
+
 		{
 			name: "anything_else",
 			given: &appStoreSrvNotification{
@@ -170,7 +193,6 @@ func TestAppStoreSrvNotification_shouldCancel(t *testing.T) {
 					NotificationType: appstore.NotificationTypeV2Refund,
 				},
 			},
-			exp: true,
 		},
 
 		{
@@ -226,6 +248,103 @@ func TestAppStoreSrvNotification_shouldCancel(t *testing.T) {
 	}
 }
 
+func TestAppStoreSrvNotification_shouldRefund(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *appStoreSrvNotification
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "refund",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2Refund,
+				},
+			},
+			exp: true,
+		},
+
+		{
+			name: "refund_declined",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2RefundDeclined,
+				},
+			},
+		},
+
+		{
+			name: "cancel",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidChangeRenewalStatus,
+					Subtype:          appstore.SubTypeV2AutoRenewDisabled,
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.shouldRefund()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestAppStoreSrvNotification_shouldChangePlan(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *appStoreSrvNotification
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "upgrade",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidChangeRenewalPref,
+					Subtype:          appstore.SubTypeV2Upgrade,
+				},
+			},
+			exp: true,
+		},
+
+		{
+			name: "downgrade",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidChangeRenewalPref,
+					Subtype:          appstore.SubTypeV2Downgrade,
+				},
+			},
+		},
+
+		{
+			name: "renew",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidRenew,
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.shouldChangePlan()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
 func TestAppStoreSrvNotification_effect(t *testing.T) {
 	type testCase struct {
 		name  string
@@ -255,6 +374,28 @@ func TestAppStoreSrvNotification_effect(t *testing.T) {
 			exp: "cancel",
 		},
 
+		{
+			name: "should_change_plan",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidChangeRenewalPref,
+					Subtype:          appstore.SubTypeV2Upgrade,
+				},
+			},
+			exp: "change_plan",
+		},
+
+		{
+			name: "should_record_pay_failure",
+			given: &appStoreSrvNotification{
+				val: &appstore.SubscriptionNotificationV2DecodedPayload{
+					NotificationType: appstore.NotificationTypeV2DidFailToRenew,
+					Subtype:          appstore.SubTypeV2GracePeriod,
+				},
+			},
+			exp: "record_payment_failure",
+		},
+
 		{
 			name: "anything_else",
 			given: &appStoreSrvNotification{
@@ -277,6 +418,45 @@ func TestAppStoreSrvNotification_effect(t *testing.T) {
 	}
 }
 
+func TestAppStoreSrvNotification_isInBillingRetry(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *appStoreSrvNotification
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "no_renewal_info",
+			given: &appStoreSrvNotification{},
+		},
+
+		{
+			name: "not_in_billing_retry",
+			given: &appStoreSrvNotification{
+				renewal: &appstore.JWSRenewalInfoDecodedPayload{},
+			},
+		},
+
+		{
+			name: "in_billing_retry",
+			given: &appStoreSrvNotification{
+				renewal: &appstore.JWSRenewalInfoDecodedPayload{IsInBillingRetryPeriod: true},
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.isInBillingRetry()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
 func TestShouldCancelOrderIOS(t *testing.T) {
 	type tcGiven struct {
 		now  time.Time
@@ -392,6 +572,62 @@ func TestShouldCancelOrderIOS(t *testing.T) {
 	}
 }
 
+func TestNewAppStoreRefundTxn(t *testing.T) {
+	type tcGiven struct {
+		price *appStoreTxnPrice
+	}
+
+	type tcExpected struct {
+		currency string
+		amount   decimal.Decimal
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_price",
+			exp:  tcExpected{currency: "USD", amount: decimal.RequireFromString("-9.99")},
+		},
+
+		{
+			name:  "zero_price",
+			given: tcGiven{price: &appStoreTxnPrice{Currency: "EUR"}},
+			exp:   tcExpected{currency: "USD", amount: decimal.RequireFromString("-9.99")},
+		},
+
+		{
+			name:  "price",
+			given: tcGiven{price: &appStoreTxnPrice{Price: 8990, Currency: "eur"}},
+			exp:   tcExpected{currency: "EUR", amount: decimal.RequireFromString("-8.99")},
+		},
+	}
+
+	ord := &model.Order{
+		ID:         uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+		Currency:   "USD",
+		TotalPrice: decimal.RequireFromString("9.99"),
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := newAppStoreRefundTxn(ord, "720000000000002", tc.given.price)
+
+			should.Equal(t, ord.ID, actual.OrderID)
+			should.Equal(t, "720000000000002", actual.ExternalTransactionID)
+			should.Equal(t, model.TransactionKindRefund, actual.Kind)
+			should.Equal(t, tc.exp.currency, actual.Currency)
+			should.True(t, tc.exp.amount.Equal(actual.Amount))
+		})
+	}
+}
+
 func TestNewReceiptDataApple(t *testing.T) {
 	type tcGiven struct {
 		req  model.ReceiptRequest
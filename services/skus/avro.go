@@ -24,6 +24,43 @@ const voteSchema = `{
   ]
 }`
 
+const spendEventSchema = `{
+  "namespace": "brave.payments",
+  "type": "record",
+  "name": "spendEvent",
+  "doc": "This message is sent when credentials have been redeemed on a spend channel. The payload is encoded with the payload schema of the channel",
+  "fields": [
+    { "name": "id", "type": "string" },
+    { "name": "createdAt", "type": "string" },
+    { "name": "merchantId", "type": "string" },
+    { "name": "channel", "type": "string" },
+    { "name": "sku", "type": "string" },
+    { "name": "tally", "type": "long" },
+    { "name": "payload", "type": "bytes" }
+  ]
+}`
+
+const orderEventSchema = `{
+  "namespace": "brave.payments",
+  "type": "record",
+  "name": "orderEvent",
+  "doc": "This message is sent when the lifecycle status of a skus order changes",
+  "fields": [
+    { "name": "id", "type": "string" },
+    { "name": "type", "type": "string" },
+    { "name": "order_id", "type": "string" },
+    { "name": "occurred_at", "type": "string" }
+  ]
+}`
+
+// OrderEventMessage is the structure of an order lifecycle event message.
+type OrderEventMessage struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	OrderID    string `json:"order_id"`
+	OccurredAt string `json:"occurred_at"`
+}
+
 const signingOrderRequestSchema = `{
     "namespace": "brave.payments",
     "type": "record",
@@ -77,10 +114,11 @@ type SigningOrder struct {
 
 // Metadata - skus metadata structure
 type Metadata struct {
-	ItemID         uuid.UUID `json:"itemId"`
-	OrderID        uuid.UUID `json:"orderId"`
-	IssuerID       uuid.UUID `json:"issuerId"`
-	CredentialType string    `json:"credential_type"`
+	ItemID         uuid.UUID  `json:"itemId"`
+	OrderID        uuid.UUID  `json:"orderId"`
+	IssuerID       uuid.UUID  `json:"issuerId"`
+	CredentialType string     `json:"credential_type"`
+	SeatID         *uuid.UUID `json:"seatId,omitempty"`
 }
 
 const signingOrderResultSchema = `{
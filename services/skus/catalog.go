@@ -0,0 +1,199 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/handler"
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type skuCatalogStore interface {
+	Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error)
+	Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.SKUCatalogEntry, error)
+	GetBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error)
+	GetByStoreProductID(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error)
+	GetByStripePriceID(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error)
+	List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.SKUCatalogEntry, error)
+	Update(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error)
+	Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+type skuPriceStore interface {
+	Upsert(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID, req *model.SKUPriceRequest) (*model.SKUPrice, error)
+	ListByCatalogID(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID) ([]model.SKUPrice, error)
+	FindBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error)
+	Delete(ctx context.Context, dbi sqlx.ExecerContext, catalogID, id uuid.UUID) error
+}
+
+// skuCatalog resolves products sold through vendors from the catalog stored in the database.
+//
+// Products which are not in the catalog are resolved from the built-in set.
+// TODO: Remove the built-in set once the catalog has been populated in all environments.
+type skuCatalog struct {
+	repo skuCatalogStore
+	set  map[string]model.OrderItemRequestNew
+}
+
+func newSKUCatalog(repo skuCatalogStore, env string) *skuCatalog {
+	return &skuCatalog{repo: repo, set: newOrderItemReqNewMobileSet(env)}
+}
+
+// itemReqBySKUVnt returns a request for the variant.
+func (c *skuCatalog) itemReqBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (model.OrderItemRequestNew, error) {
+	entry, err := c.repo.GetBySKUVnt(ctx, dbi, skuVnt)
+	if err == nil {
+		return entry.OrderItemRequestNew(), nil
+	}
+
+	if !errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+		return model.OrderItemRequestNew{}, err
+	}
+
+	result, ok := c.set[skuVnt]
+	if !ok {
+		return model.OrderItemRequestNew{}, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return result, nil
+}
+
+// itemReqByStoreProductID returns a request for the variant sold under the App Store or Play Store product id.
+func (c *skuCatalog) itemReqByStoreProductID(ctx context.Context, dbi sqlx.QueryerContext, productID string) (model.OrderItemRequestNew, error) {
+	entry, err := c.repo.GetByStoreProductID(ctx, dbi, productID)
+	if err == nil {
+		return entry.OrderItemRequestNew(), nil
+	}
+
+	if !errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+		return model.OrderItemRequestNew{}, err
+	}
+
+	return newOrderItemReqForSubID(c.set, productID)
+}
+
+// skuVntByStripePriceID returns the variant sold at the Stripe price.
+func (c *skuCatalog) skuVntByStripePriceID(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (string, error) {
+	entry, err := c.repo.GetByStripePriceID(ctx, dbi, priceID)
+	if err == nil {
+		return entry.SKUVnt, nil
+	}
+
+	if !errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+		return "", err
+	}
+
+	skuVnt, ok := skuVntByStripePriceID(c.set, priceID)
+	if !ok {
+		return "", model.ErrSKUCatalogEntryNotFound
+	}
+
+	return skuVnt, nil
+}
+
+// CreateSKUCatalogEntry adds a variant to the catalog.
+func (s *Service) CreateSKUCatalogEntry(ctx context.Context, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	if !req.IsValid() {
+		return nil, model.ErrSKUCatalogEntryInvalid
+	}
+
+	return s.catalog.repo.Create(ctx, s.Datastore.RawDB(), req)
+}
+
+// GetSKUCatalogEntry returns the catalog entry with the id.
+func (s *Service) GetSKUCatalogEntry(ctx context.Context, id uuid.UUID) (*model.SKUCatalogEntry, error) {
+	return s.catalog.repo.Get(ctx, s.Datastore.RawDB(), id)
+}
+
+// ListSKUCatalog returns all catalog entries.
+func (s *Service) ListSKUCatalog(ctx context.Context) ([]model.SKUCatalogEntry, error) {
+	return s.catalog.repo.List(ctx, s.Datastore.RawDB())
+}
+
+// UpdateSKUCatalogEntry replaces the catalog entry with the id.
+//
+// Existing orders are not affected.
+func (s *Service) UpdateSKUCatalogEntry(ctx context.Context, id uuid.UUID, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	if !req.IsValid() {
+		return nil, model.ErrSKUCatalogEntryInvalid
+	}
+
+	return s.catalog.repo.Update(ctx, s.Datastore.RawDB(), id, req)
+}
+
+// DeleteSKUCatalogEntry removes the catalog entry with the id.
+func (s *Service) DeleteSKUCatalogEntry(ctx context.Context, id uuid.UUID) error {
+	return s.catalog.repo.Delete(ctx, s.Datastore.RawDB(), id)
+}
+
+// SetSKUPrice sets the price of the catalog entry for the currency and, optionally, the country.
+func (s *Service) SetSKUPrice(ctx context.Context, catalogID uuid.UUID, req *model.SKUPriceRequest) (*model.SKUPrice, error) {
+	if !req.IsValid() {
+		return nil, model.ErrSKUPriceInvalid
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := s.catalog.repo.Get(ctx, tx, catalogID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.skuPriceRepo.Upsert(ctx, tx, catalogID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListSKUPrices returns the price book of the catalog entry.
+func (s *Service) ListSKUPrices(ctx context.Context, catalogID uuid.UUID) ([]model.SKUPrice, error) {
+	return s.skuPriceRepo.ListByCatalogID(ctx, s.Datastore.RawDB(), catalogID)
+}
+
+// DeleteSKUPrice removes the price from the price book of the catalog entry.
+func (s *Service) DeleteSKUPrice(ctx context.Context, catalogID, id uuid.UUID) error {
+	return s.skuPriceRepo.Delete(ctx, s.Datastore.RawDB(), catalogID, id)
+}
+
+// NewGeoProxy returns the edge whose geolocation selects regional prices.
+//
+// The edge must set SKUS_GEO_PROXY_HEADER to SKUS_GEO_PROXY_SECRET on the requests it forwards.
+func NewGeoProxy() handler.GeoProxy {
+	return handler.GeoProxy{
+		Header: os.Getenv("SKUS_GEO_PROXY_HEADER"),
+		Secret: os.Getenv("SKUS_GEO_PROXY_SECRET"),
+	}
+}
+
+// applyPriceBook prices items from the price books of their variants for the currency and country.
+//
+// Items without a matching price keep their price.
+func (s *Service) applyPriceBook(ctx context.Context, dbi sqlx.QueryerContext, currency, country string, items []model.OrderItem) error {
+	for i := range items {
+		price, err := s.skuPriceRepo.FindBySKUVnt(ctx, dbi, items[i].SKUVnt, currency, country)
+		if err != nil {
+			if errors.Is(err, model.ErrSKUPriceNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		items[i].SetPrice(price)
+	}
+
+	return nil
+}
@@ -0,0 +1,398 @@
+package skus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestSKUCatalog_itemReqBySKUVnt(t *testing.T) {
+	type tcGiven struct {
+		repo   *repository.MockSKUCatalog
+		skuVnt string
+	}
+
+	type tcExpected struct {
+		val model.OrderItemRequestNew
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_repo",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetBySKUVnt: func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				skuVnt: "brave-vpn-premium",
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "not_found",
+			given: tcGiven{
+				repo:   &repository.MockSKUCatalog{},
+				skuVnt: "brave-search-premium",
+			},
+			exp: tcExpected{err: model.ErrSKUCatalogEntryNotFound},
+		},
+
+		{
+			name: "from_catalog",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetBySKUVnt: func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error) {
+						result := &model.SKUCatalogEntry{
+							SKU:            "brave-search-premium",
+							SKUVnt:         skuVnt,
+							CredentialType: "time-limited",
+						}
+
+						return result, nil
+					},
+				},
+				skuVnt: "brave-search-premium",
+			},
+			exp: tcExpected{
+				val: model.OrderItemRequestNew{
+					Quantity:       1,
+					SKU:            "brave-search-premium",
+					SKUVnt:         "brave-search-premium",
+					CredentialType: "time-limited",
+				},
+			},
+		},
+
+		{
+			name: "from_builtin_set",
+			given: tcGiven{
+				repo:   &repository.MockSKUCatalog{},
+				skuVnt: "brave-vpn-premium",
+			},
+			exp: tcExpected{
+				val: newOrderItemReqNewMobileSet("development")["brave-vpn-premium"],
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cat := newSKUCatalog(tc.given.repo, "development")
+
+			actual, err := cat.itemReqBySKUVnt(context.Background(), nil, tc.given.skuVnt)
+			should.ErrorIs(t, err, tc.exp.err)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestSKUCatalog_itemReqByStoreProductID(t *testing.T) {
+	type tcGiven struct {
+		repo      *repository.MockSKUCatalog
+		productID string
+	}
+
+	type tcExpected struct {
+		val model.OrderItemRequestNew
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_repo",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetByStoreProductID: func(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				productID: "brave.vpn.monthly",
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "invalid_product",
+			given: tcGiven{
+				repo:      &repository.MockSKUCatalog{},
+				productID: "brave.search.monthly",
+			},
+			exp: tcExpected{err: model.ErrInvalidMobileProduct},
+		},
+
+		{
+			name: "from_catalog",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetByStoreProductID: func(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error) {
+						result := &model.SKUCatalogEntry{
+							SKU:             "brave-search-premium",
+							SKUVnt:          "brave-search-premium",
+							CredentialType:  "time-limited",
+							StoreProductIDs: []string{productID},
+						}
+
+						return result, nil
+					},
+				},
+				productID: "brave.search.monthly",
+			},
+			exp: tcExpected{
+				val: model.OrderItemRequestNew{
+					Quantity:       1,
+					SKU:            "brave-search-premium",
+					SKUVnt:         "brave-search-premium",
+					CredentialType: "time-limited",
+				},
+			},
+		},
+
+		{
+			name: "from_builtin_set",
+			given: tcGiven{
+				repo:      &repository.MockSKUCatalog{},
+				productID: "brave.vpn.monthly",
+			},
+			exp: tcExpected{
+				val: newOrderItemReqNewMobileSet("development")["brave-vpn-premium"],
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cat := newSKUCatalog(tc.given.repo, "development")
+
+			actual, err := cat.itemReqByStoreProductID(context.Background(), nil, tc.given.productID)
+			should.ErrorIs(t, err, tc.exp.err)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestSKUCatalog_skuVntByStripePriceID(t *testing.T) {
+	type tcGiven struct {
+		repo    *repository.MockSKUCatalog
+		priceID string
+	}
+
+	type tcExpected struct {
+		val string
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_repo",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetByStripePriceID: func(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				priceID: "price_1",
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "not_found",
+			given: tcGiven{
+				repo:    &repository.MockSKUCatalog{},
+				priceID: "price_unknown",
+			},
+			exp: tcExpected{err: model.ErrSKUCatalogEntryNotFound},
+		},
+
+		{
+			name: "from_catalog",
+			given: tcGiven{
+				repo: &repository.MockSKUCatalog{
+					FnGetByStripePriceID: func(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error) {
+						return &model.SKUCatalogEntry{SKUVnt: "brave-search-premium-year"}, nil
+					},
+				},
+				priceID: "price_search_year",
+			},
+			exp: tcExpected{val: "brave-search-premium-year"},
+		},
+
+		{
+			name: "from_builtin_set",
+			given: tcGiven{
+				repo:    &repository.MockSKUCatalog{},
+				priceID: newOrderItemReqNewMobileSet("development")["brave-leo-premium-year"].StripeMetadata.ItemID,
+			},
+			exp: tcExpected{val: "brave-leo-premium-year"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cat := newSKUCatalog(tc.given.repo, "development")
+
+			actual, err := cat.skuVntByStripePriceID(context.Background(), nil, tc.given.priceID)
+			should.ErrorIs(t, err, tc.exp.err)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_applyPriceBook(t *testing.T) {
+	type tcGiven struct {
+		repo  *repository.MockSKUPrice
+		req   *model.CreateOrderRequestNew
+		items []model.OrderItem
+	}
+
+	type tcExpected struct {
+		items []model.OrderItem
+		err   error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_repo",
+			given: tcGiven{
+				repo: &repository.MockSKUPrice{
+					FnFindBySKUVnt: func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				req:   &model.CreateOrderRequestNew{Currency: "EUR"},
+				items: []model.OrderItem{{SKUVnt: "brave-leo-premium", Quantity: 1}},
+			},
+			exp: tcExpected{
+				items: []model.OrderItem{{SKUVnt: "brave-leo-premium", Quantity: 1}},
+				err:   model.Error("something_went_wrong"),
+			},
+		},
+
+		{
+			name: "no_price_keeps_requested",
+			given: tcGiven{
+				repo: &repository.MockSKUPrice{},
+				req:  &model.CreateOrderRequestNew{Currency: "USD"},
+				items: []model.OrderItem{
+					{
+						SKUVnt:   "brave-leo-premium",
+						Currency: "USD",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("15"),
+						Subtotal: decimal.RequireFromString("15"),
+					},
+				},
+			},
+			exp: tcExpected{
+				items: []model.OrderItem{
+					{
+						SKUVnt:   "brave-leo-premium",
+						Currency: "USD",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("15"),
+						Subtotal: decimal.RequireFromString("15"),
+					},
+				},
+			},
+		},
+
+		{
+			name: "regional_price",
+			given: tcGiven{
+				repo: &repository.MockSKUPrice{
+					FnFindBySKUVnt: func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error) {
+						if skuVnt != "brave-leo-premium" || currency != "INR" || country != "IN" {
+							return nil, model.Error("unexpected")
+						}
+
+						result := &model.SKUPrice{
+							ID:       uuid.Must(uuid.FromString("cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d")),
+							Currency: "INR",
+							Country:  "IN",
+							Amount:   decimal.RequireFromString("499"),
+						}
+
+						return result, nil
+					},
+				},
+				req: &model.CreateOrderRequestNew{Currency: "INR", Country: "IN"},
+				items: []model.OrderItem{
+					{
+						SKUVnt:   "brave-leo-premium",
+						Currency: "INR",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("15"),
+						Subtotal: decimal.RequireFromString("15"),
+					},
+				},
+			},
+			exp: tcExpected{
+				items: []model.OrderItem{
+					{
+						SKUVnt:   "brave-leo-premium",
+						Currency: "INR",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("499"),
+						Subtotal: decimal.RequireFromString("499"),
+						Metadata: datastore.Metadata{"price_id": "cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d", "price_country": "IN"},
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{skuPriceRepo: tc.given.repo}
+
+			err := svc.applyPriceBook(context.Background(), nil, tc.given.req.Currency, tc.given.req.Country, tc.given.items)
+			should.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.items, tc.given.items)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/asaskevich/govalidator"
@@ -17,9 +18,7 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/go-playground/validator/v10"
 	uuid "github.com/satori/go.uuid"
-	"github.com/stripe/stripe-go/v72/webhook"
 
-	appctx "github.com/brave-intl/bat-go/libs/context"
 	"github.com/brave-intl/bat-go/libs/handlers"
 	"github.com/brave-intl/bat-go/libs/inputs"
 	"github.com/brave-intl/bat-go/libs/logging"
@@ -29,7 +28,6 @@ import (
 
 	"github.com/brave-intl/bat-go/services/skus/handler"
 	"github.com/brave-intl/bat-go/services/skus/model"
-	"github.com/brave-intl/bat-go/services/skus/radom"
 )
 
 const (
@@ -46,7 +44,9 @@ func Router(
 ) chi.Router {
 	r := chi.NewRouter()
 
-	orderh := handler.NewOrder(svc)
+	orderh := handler.NewOrder(svc, NewGeoProxy())
+
+	ordAuthMwr := WithKeyScope(authMwr, KeyScopeManageOrders)
 
 	corsMwrPost := NewCORSMwr(copts, http.MethodPost)
 
@@ -78,13 +78,13 @@ func Router(
 	r.Method(
 		http.MethodDelete,
 		"/{orderID}",
-		metricsMwr("CancelOrder", NewCORSMwr(copts, http.MethodDelete)(authMwr(CancelOrder(svc)))),
+		metricsMwr("CancelOrder", NewCORSMwr(copts, http.MethodDelete)(ordAuthMwr(CancelOrder(svc)))),
 	)
 
 	r.Method(
 		http.MethodPatch,
 		"/{orderID}/set-trial",
-		metricsMwr("SetOrderTrialDays", NewCORSMwr(copts, http.MethodPatch)(authMwr(handleSetOrderTrialDays(svc)))),
+		metricsMwr("SetOrderTrialDays", NewCORSMwr(copts, http.MethodPatch)(ordAuthMwr(handleSetOrderTrialDays(svc)))),
 	)
 
 	r.Method(http.MethodGet, "/{orderID}/transactions", metricsMwr("GetTransactions", GetTransactions(svc)))
@@ -106,7 +106,7 @@ func Router(
 		// It received 0 requests in June 2024.
 		r.Method(http.MethodPost, "/{orderID}/submit-receipt", metricsMwr("SubmitReceipt", corsMwrPost(handleSubmitReceipt(svc, valid))))
 		r.Method(http.MethodPost, "/receipt", metricsMwr("createOrderFromReceipt", corsMwrPost(handleCreateOrderFromReceipt(svc, valid))))
-		r.Method(http.MethodPost, "/{orderID}/receipt", metricsMwr("checkOrderReceipt", authMwr(handleCheckOrderReceipt(svc, valid))))
+		r.Method(http.MethodPost, "/{orderID}/receipt", metricsMwr("checkOrderReceipt", ordAuthMwr(handleCheckOrderReceipt(svc, valid))))
 	}
 
 	credh := handler.NewCred(svc)
@@ -115,7 +115,7 @@ func Router(
 		cr.Use(NewCORSMwr(copts, http.MethodGet, http.MethodPost))
 		cr.Method(http.MethodGet, "/", metricsMwr("GetOrderCreds", GetOrderCreds(svc)))
 		cr.Method(http.MethodPost, "/", metricsMwr("CreateOrderCreds", CreateOrderCreds(svc)))
-		cr.Method(http.MethodDelete, "/", metricsMwr("DeleteOrderCreds", authMwr(deleteOrderCreds(svc))))
+		cr.Method(http.MethodDelete, "/", metricsMwr("DeleteOrderCreds", ordAuthMwr(deleteOrderCreds(svc))))
 
 		// For now, this endpoint is placed directly under /credentials.
 		// It would make sense to put it under /items/item_id, had the caller known the item id.
@@ -123,7 +123,7 @@ func Router(
 		// This extra round-trip currently does not make sense.
 		// So until Bundles came along we can benefit from the fact that there is one item per order.
 		// By the time Bundles arrive, the caller would either have to fetch order anyway, or this can be communicated in another way.
-		cr.Method(http.MethodGet, "/batches/count", metricsMwr("CountBatches", authMwr(handlers.AppHandler(credh.CountBatches))))
+		cr.Method(http.MethodGet, "/batches/count", metricsMwr("CountBatches", ordAuthMwr(handlers.AppHandler(credh.CountBatches))))
 
 		// Handle the old endpoint while the new is being rolled out:
 		// - true: the handler uses itemID as the request id, which is the old mode;
@@ -134,6 +134,20 @@ func Router(
 		cr.Method(http.MethodPut, "/items/{itemID}/batches/{requestID}", metricsMwr("CreateOrderItemCreds", createItemCreds(svc)))
 	})
 
+	// Seats allow other devices to redeem their own batches against the order.
+	// The purchaser manages seats, and the device which has redeemed an invite token submits creds for its seat.
+	// All seat endpoints require the same authorisation as managing the order, so only the merchant backend may call
+	// them. An invited device redeems its token and submits creds for its seat through the merchant backend.
+	// Signed creds are fetched via /credentials/items/{itemID}/batches/{requestID}.
+	r.Route("/{orderID}/seats", func(sr chi.Router) {
+		sr.Use(NewCORSMwr(copts, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete))
+		sr.Method(http.MethodGet, "/", metricsMwr("ListOrderSeats", ordAuthMwr(handleListOrderSeats(svc))))
+		sr.Method(http.MethodPost, "/", metricsMwr("CreateOrderSeat", ordAuthMwr(handleCreateOrderSeat(svc))))
+		sr.Method(http.MethodPost, "/redeem", metricsMwr("RedeemOrderSeat", ordAuthMwr(handleRedeemOrderSeat(svc))))
+		sr.Method(http.MethodDelete, "/{seatID}", metricsMwr("RevokeOrderSeat", ordAuthMwr(handleRevokeOrderSeat(svc))))
+		sr.Method(http.MethodPut, "/{seatID}/items/{itemID}/batches/{requestID}", metricsMwr("CreateOrderSeatCreds", ordAuthMwr(handleCreateOrderSeatCreds(svc))))
+	})
+
 	return r
 }
 
@@ -141,12 +155,14 @@ func Router(
 func CredentialRouter(svc *Service, authMwr middlewareFn) chi.Router {
 	r := chi.NewRouter()
 
+	vrfAuthMwr := WithKeyScope(authMwr, KeyScopeVerify)
+
 	valid := validator.New()
 
 	r.Method(
 		http.MethodPost,
 		"/subscription/verifications",
-		middleware.InstrumentHandler("handleVerifyCredV1", authMwr(handleVerifyCredV1(svc, valid))),
+		middleware.InstrumentHandler("handleVerifyCredV1", vrfAuthMwr(handleVerifyCredV1(svc, valid))),
 	)
 
 	return r
@@ -156,12 +172,20 @@ func CredentialRouter(svc *Service, authMwr middlewareFn) chi.Router {
 func CredentialV2Router(svc *Service, authMwr middlewareFn) chi.Router {
 	r := chi.NewRouter()
 
+	vrfAuthMwr := WithKeyScope(authMwr, KeyScopeVerify)
+
 	valid := validator.New()
 
 	r.Method(
 		http.MethodPost,
 		"/subscription/verifications",
-		middleware.InstrumentHandler("handleVerifyCredV2", authMwr(handleVerifyCredV2(svc, valid))),
+		middleware.InstrumentHandler("handleVerifyCredV2", vrfAuthMwr(handleVerifyCredV2(svc, valid))),
+	)
+
+	r.Method(
+		http.MethodPost,
+		"/verify/batch",
+		middleware.InstrumentHandler("handleVerifyCredBatch", vrfAuthMwr(handleVerifyCredBatch(svc, valid))),
 	)
 
 	return r
@@ -185,6 +209,10 @@ func MerchantRouter(service *Service) chi.Router {
 				kr.Method("GET", "/", middleware.InstrumentHandler("GetKeys", GetKeys(service)))
 				kr.Method("POST", "/", middleware.InstrumentHandler("CreateKey", CreateKey(service)))
 				kr.Method("DELETE", "/{id}", middleware.InstrumentHandler("DeleteKey", DeleteKey(service)))
+				kr.Method("POST", "/{id}/rotate", middleware.InstrumentHandler("RotateKey", RotateKey(service)))
+				kr.Method("PUT", "/{id}/rotation", middleware.InstrumentHandler("SetKeyRotation", SetKeyRotation(service)))
+				kr.Method("GET", "/{id}/secret", middleware.InstrumentHandler("GetKeySecret", GetKeySecret(service)))
+				kr.Method("GET", "/uses", middleware.InstrumentHandler("GetKeyUses", GetKeyUses(service)))
 			})
 			mr.Route("/transactions", func(kr chi.Router) {
 				kr.Method("GET", "/", middleware.InstrumentHandler("MerchantTransactions", MerchantTransactions(service)))
@@ -195,6 +223,39 @@ func MerchantRouter(service *Service) chi.Router {
 	return r
 }
 
+// MerchantAPIRouter handles requests merchants sign with their keys.
+func MerchantAPIRouter(svc *Service, authMwr middlewareFn) chi.Router {
+	r := chi.NewRouter()
+
+	r.Method(
+		http.MethodGet,
+		"/transactions",
+		middleware.InstrumentHandler("handleListMerchantTransactions", WithKeyScope(authMwr, KeyScopeReadTransactions)(handleListMerchantTransactions(svc))),
+	)
+
+	r.Method(
+		http.MethodGet,
+		"/keys/uses",
+		middleware.InstrumentHandler("handleListMerchantKeyUses", authMwr(handleListMerchantKeyUses(svc))),
+	)
+
+	r.Route("/webhooks", func(wr chi.Router) {
+		wr.Method(http.MethodPost, "/", middleware.InstrumentHandler("handleCreateWebhookEndpoint", authMwr(handleCreateWebhookEndpoint(svc))))
+		wr.Method(http.MethodGet, "/", middleware.InstrumentHandler("handleListWebhookEndpoints", authMwr(handleListWebhookEndpoints(svc))))
+		wr.Method(http.MethodDelete, "/{id}", middleware.InstrumentHandler("handleDisableWebhookEndpoint", authMwr(handleDisableWebhookEndpoint(svc))))
+		wr.Method(http.MethodGet, "/deliveries", middleware.InstrumentHandler("handleListWebhookDeliveries", authMwr(handleListWebhookDeliveries(svc))))
+		wr.Method(http.MethodPost, "/deliveries/{id}/redeliver", middleware.InstrumentHandler("handleRedeliverWebhook", authMwr(handleRedeliverWebhook(svc))))
+	})
+
+	r.Route("/spend-channels", func(sr chi.Router) {
+		sr.Method(http.MethodPost, "/", middleware.InstrumentHandler("handleCreateSpendChannel", authMwr(handleCreateSpendChannel(svc))))
+		sr.Method(http.MethodGet, "/", middleware.InstrumentHandler("handleListSpendChannels", authMwr(handleListSpendChannels(svc))))
+		sr.Method(http.MethodDelete, "/{name}", middleware.InstrumentHandler("handleDisableSpendChannel", authMwr(handleDisableSpendChannel(svc))))
+	})
+
+	return r
+}
+
 // DeleteKeyRequest includes information needed to delete a key
 type DeleteKeyRequest struct {
 	DelaySeconds int `json:"delaySeconds" valid:"-"`
@@ -202,7 +263,13 @@ type DeleteKeyRequest struct {
 
 // CreateKeyRequest includes information needed to create a key
 type CreateKeyRequest struct {
-	Name string `json:"name" valid:"required"`
+	Name   string   `json:"name" valid:"required"`
+	Scopes []string `json:"scopes" valid:"-"`
+}
+
+// RotateKeyRequest includes information needed to rotate a key
+type RotateKeyRequest struct {
+	OverlapSeconds int `json:"overlapSeconds" valid:"-"`
 }
 
 // CreateKeyResponse includes information about the created key
@@ -222,12 +289,16 @@ func CreateKey(service *Service) handlers.AppHandler {
 			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
 		}
 
+		if err := validateKeyScopes(req.Scopes); err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"scopes": err.Error()})
+		}
+
 		encrypted, nonce, err := GenerateSecret()
 		if err != nil {
 			return handlers.WrapError(err, "Could not generate a secret key ", http.StatusInternalServerError)
 		}
 
-		key, err := service.Datastore.CreateKey(reqMerchant, req.Name, encrypted, nonce)
+		key, err := service.Datastore.CreateKey(reqMerchant, req.Name, encrypted, nonce, req.Scopes)
 		if err != nil {
 			return handlers.WrapError(err, "Error create api keys", http.StatusInternalServerError)
 		}
@@ -304,6 +375,122 @@ func GetKeys(service *Service) handlers.AppHandler {
 	})
 }
 
+// RotateKey replaces a key with a new one, keeping the old key valid for the requested overlap
+func RotateKey(service *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		id, err := uuid.FromString(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"id": err.Error()})
+		}
+
+		var req RotateKeyRequest
+		if err := requestutils.ReadJSON(ctx, r.Body, &req); err != nil {
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
+
+		if req.OverlapSeconds < 0 {
+			return handlers.ValidationError("request", map[string]interface{}{"overlapSeconds": "must not be negative"})
+		}
+
+		key, err := service.RotateMerchantKey(ctx, chi.URLParamFromCtx(ctx, "merchantID"), id, time.Duration(req.OverlapSeconds)*time.Second)
+		if err != nil {
+			if errors.Is(err, model.ErrMerchantKeyNotFound) {
+				return handlers.WrapError(err, "Key not found", http.StatusNotFound)
+			}
+
+			return handlers.WrapError(err, "Error rotating key", http.StatusInternalServerError)
+		}
+
+		sk, err := key.GetSecretKey()
+		if err != nil {
+			return handlers.WrapError(err, "Error rotating key", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, CreateKeyResponse{Key: key, SecretKey: *sk}, w, http.StatusOK)
+	})
+}
+
+// SetKeyRotation schedules a key to be rotated periodically, or turns scheduled rotation off
+func SetKeyRotation(service *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		id, err := uuid.FromString(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"id": err.Error()})
+		}
+
+		var req model.MerchantKeyRotation
+		if err := requestutils.ReadJSON(ctx, r.Body, &req); err != nil {
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
+
+		if err := service.SetMerchantKeyRotation(ctx, chi.URLParamFromCtx(ctx, "merchantID"), id, req); err != nil {
+			switch {
+			case errors.Is(err, model.ErrMerchantKeyRotationInvalid):
+				return handlers.ValidationError("request", map[string]interface{}{"rotation": "overlapSeconds must be less than everySeconds, and neither negative"})
+
+			case errors.Is(err, model.ErrMerchantKeyNotFound):
+				return handlers.WrapError(err, "Key not found", http.StatusNotFound)
+
+			default:
+				return handlers.WrapError(err, "Error scheduling key rotation", http.StatusInternalServerError)
+			}
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+// GetKeySecret returns an active key with its secret, e.g. after the key has been rotated on schedule
+func GetKeySecret(service *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		id, err := uuid.FromString(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"id": err.Error()})
+		}
+
+		key, err := service.GetMerchantKey(ctx, chi.URLParamFromCtx(ctx, "merchantID"), id)
+		if err != nil {
+			if errors.Is(err, model.ErrMerchantKeyNotFound) {
+				return handlers.WrapError(err, "Key not found", http.StatusNotFound)
+			}
+
+			return handlers.WrapError(err, "Error getting key", http.StatusInternalServerError)
+		}
+
+		sk, err := key.GetSecretKey()
+		if err != nil {
+			return handlers.WrapError(err, "Error getting key", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, CreateKeyResponse{Key: key, SecretKey: *sk}, w, http.StatusOK)
+	})
+}
+
+// GetKeyUses returns the audit log of a merchant's keys
+func GetKeyUses(service *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		filter, err := parseKeyUseFilter(r)
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"query": err.Error()})
+		}
+
+		uses, err := service.ListMerchantKeyUses(ctx, chi.URLParamFromCtx(ctx, "merchantID"), filter)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting key uses", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, &model.MerchantKeyUsesResponse{Uses: uses}, w, http.StatusOK)
+	})
+}
+
 // VoteRouter for voting endpoint
 func VoteRouter(service *Service, instrumentHandler middleware.InstrumentHandlerDef) chi.Router {
 	r := chi.NewRouter()
@@ -311,6 +498,15 @@ func VoteRouter(service *Service, instrumentHandler middleware.InstrumentHandler
 	return r
 }
 
+// SpendRouter for spending credentials on spend channels
+func SpendRouter(svc *Service, instrumentHandler middleware.InstrumentHandlerDef) chi.Router {
+	r := chi.NewRouter()
+
+	r.Method(http.MethodPost, "/{merchantID}/{channel}", instrumentHandler("handleSpend", handleSpend(svc)))
+
+	return r
+}
+
 func handleSetOrderTrialDays(svc *Service) handlers.AppHandler {
 	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
 		ctx := r.Context()
@@ -359,7 +555,7 @@ func CancelOrder(service *Service) handlers.AppHandler {
 			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
 		}
 
-		if err := service.CancelOrderLegacy(oid); err != nil {
+		if err := service.CancelOrderLegacy(ctx, oid); err != nil {
 			return handlers.WrapError(err, "Error retrieving the order", http.StatusInternalServerError)
 		}
 
@@ -683,6 +879,201 @@ func createItemCreds(svc *Service) handlers.AppHandler {
 	}
 }
 
+func handleListOrderSeats(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		if err := svc.validateOrderMerchantAndCaveats(ctx, orderID); err != nil {
+			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
+		}
+
+		result, err := svc.ListOrderSeats(ctx, orderID)
+		if err != nil {
+			return handleOrderSeatErr(err, "failed to list seats")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleCreateOrderSeat(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		if err := svc.validateOrderMerchantAndCaveats(ctx, orderID); err != nil {
+			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
+		}
+
+		result, err := svc.CreateOrderSeat(ctx, orderID)
+		if err != nil {
+			return handleOrderSeatErr(err, "failed to create seat")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
+	})
+}
+
+type redeemOrderSeatRequest struct {
+	InviteToken string `json:"inviteToken" valid:"required"`
+}
+
+func handleRedeemOrderSeat(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "failed to read request body", http.StatusBadRequest)
+		}
+
+		req := &redeemOrderSeatRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+		}
+
+		if _, err := govalidator.ValidateStruct(req); err != nil {
+			return handlers.WrapValidationError(err)
+		}
+
+		if err := svc.validateOrderMerchantAndCaveats(ctx, orderID); err != nil {
+			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
+		}
+
+		result, err := svc.RedeemOrderSeat(ctx, orderID, req.InviteToken)
+		if err != nil {
+			return handleOrderSeatErr(err, "failed to redeem seat")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleRevokeOrderSeat(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		seatID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "seatID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"seatID": err.Error()})
+		}
+
+		if err := svc.validateOrderMerchantAndCaveats(ctx, orderID); err != nil {
+			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
+		}
+
+		if err := svc.RevokeOrderSeat(ctx, orderID, seatID); err != nil {
+			return handleOrderSeatErr(err, "failed to revoke seat")
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+func handleCreateOrderSeatCreds(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		lg := logging.Logger(ctx, "skus").With().Str("func", "handleCreateOrderSeatCreds").Logger()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "error reading body", http.StatusBadRequest)
+		}
+
+		req := &createItemCredsRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "error decoding body", http.StatusBadRequest)
+		}
+
+		if _, err := govalidator.ValidateStruct(req); err != nil {
+			return handlers.WrapValidationError(err)
+		}
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		seatID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "seatID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"seatID": err.Error()})
+		}
+
+		itemID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "itemID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"itemID": err.Error()})
+		}
+
+		reqID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "requestID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"requestID": err.Error()})
+		}
+
+		if err := svc.validateOrderMerchantAndCaveats(ctx, orderID); err != nil {
+			return handlers.WrapError(err, "Error validating auth merchant and caveats", http.StatusForbidden)
+		}
+
+		if err := svc.CreateOrderSeatCredentials(ctx, orderID, seatID, itemID, reqID, req.BlindedCreds); err != nil {
+			lg.Err(err).Msg("failed to create the seat credentials")
+
+			switch {
+			case errors.Is(err, model.ErrOrderNotFound), errors.Is(err, model.ErrOrderSeatNotFound):
+				return handlers.WrapError(err, "seat not found", http.StatusNotFound)
+
+			case errors.Is(err, model.ErrOrderSeatNotActive):
+				return handlers.WrapError(err, "seat is not active", http.StatusForbidden)
+
+			case errors.Is(err, errCredsAlreadySubmittedMismatch), errors.Is(err, ErrCredsAlreadyExist):
+				return handlers.WrapError(err, "Order credentials already exist", http.StatusConflict)
+
+			default:
+				return handlers.WrapError(err, "Error creating order creds", http.StatusBadRequest)
+			}
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+func handleOrderSeatErr(err error, msg string) *handlers.AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+	case errors.Is(err, model.ErrOrderNotFound), errors.Is(err, model.ErrOrderSeatNotFound):
+		return handlers.WrapError(err, "seat not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrOrderNotPaid):
+		return handlers.WrapError(err, "order not paid", http.StatusPaymentRequired)
+
+	case errors.Is(err, model.ErrOrderSeatsExhausted), errors.Is(err, model.ErrOrderSeatAlreadyRedeemed), errors.Is(err, model.ErrOrderSeatNotActive):
+		return handlers.WrapError(err, msg, http.StatusConflict)
+
+	default:
+		return handlers.WrapError(model.ErrSomethingWentWrong, msg, http.StatusInternalServerError)
+	}
+}
+
 // GetOrderCreds is the handler for fetching all order credentials associated with an order.
 // This endpoint handles the retrieval of all order credential types i.e. single-use, time-limited and time-limited-v2.
 func GetOrderCreds(service *Service) handlers.AppHandler {
@@ -856,6 +1247,12 @@ type VoteRequest struct {
 	Credentials []CredentialBinding `json:"credentials"`
 }
 
+// SpendRequest includes the payload to spend credentials on, and the credentials bound to it
+type SpendRequest struct {
+	Payload     string              `json:"payload" valid:"base64"`
+	Credentials []CredentialBinding `json:"credentials"`
+}
+
 // MakeVote is the handler for making a vote using credentials
 func MakeVote(service *Service) handlers.AppHandler {
 	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
@@ -933,7 +1330,7 @@ func MerchantTransactions(service *Service) handlers.AppHandler {
 
 		// Get Paginated Results
 		transactions, total, err := service.Datastore.GetPagedMerchantTransactions(
-			ctx, merchantID.UUID(), pagination)
+			ctx, merchantID.UUID().String(), pagination)
 		if err != nil {
 			return handlers.WrapError(err, "error getting transactions", http.StatusInternalServerError)
 		}
@@ -956,399 +1353,1398 @@ func MerchantTransactions(service *Service) handlers.AppHandler {
 	})
 }
 
-func handleVerifyCredV2(svc *Service, valid *validator.Validate) handlers.AppHandler {
+// handleListMerchantTransactions lists transactions of the authenticated merchant.
+func handleListMerchantTransactions(svc *Service) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		ctx := r.Context()
-
-		lg := logging.Logger(ctx, "skus").With().Str("func", "handleVerifyCredV2").Logger()
-
-		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		merchant, err := merchantFromCtx(r.Context())
 		if err != nil {
-			lg.Warn().Err(err).Msg("failed to read body")
-
-			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
 		}
 
-		req, err := parseVerifyCredRequestV2(data)
+		ctx, pagination, err := inputs.NewPagination(r.Context(), r.URL.String(), new(Transaction))
 		if err != nil {
-			lg.Warn().Err(err).Msg("failed to parse request")
-
-			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+			return handlers.WrapValidationError(err)
 		}
 
-		if err := validateVerifyCredRequestV2(valid, req); err != nil {
-			lg.Warn().Err(err).Msg("failed to validate request")
+		transactions, total, err := svc.Datastore.GetPagedMerchantTransactions(ctx, merchant, pagination)
+		if err != nil {
+			return handlers.WrapError(err, "error getting transactions", http.StatusInternalServerError)
+		}
 
-			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		response := &responses.PaginationResponse{
+			Page:    pagination.Page,
+			Items:   pagination.Items,
+			MaxPage: total/pagination.Items - 1, // 0 indexed
+			Ordered: pagination.RawOrder,
+			Data:    transactions,
 		}
 
-		aerr := svc.verifyCredential(ctx, req, w)
-		if aerr != nil {
-			lg.Err(aerr).Msg("failed to verify credential")
+		if err := response.Render(ctx, w, http.StatusOK); err != nil {
+			return handlers.WrapError(err, "error rendering response", http.StatusInternalServerError)
 		}
 
-		return aerr
+		return nil
 	}
 }
 
-func handleVerifyCredV1(svc *Service, valid *validator.Validate) handlers.AppHandler {
+// handleListMerchantKeyUses lists the audit log of the authenticated merchant's keys.
+func handleListMerchantKeyUses(svc *Service) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
 		ctx := r.Context()
 
-		lg := logging.Logger(ctx, "skus").With().Str("func", "handleVerifyCredV1").Logger()
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		filter, err := parseKeyUseFilter(r)
 		if err != nil {
-			lg.Warn().Err(err).Msg("failed to read body")
+			return handlers.ValidationError("request", map[string]interface{}{"query": err.Error()})
+		}
 
-			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		uses, err := svc.ListMerchantKeyUses(ctx, merchant, filter)
+		if err != nil {
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to list key uses", http.StatusInternalServerError)
 		}
 
-		req := &model.VerifyCredentialRequestV1{}
-		if err := json.Unmarshal(data, req); err != nil {
-			lg.Warn().Err(err).Msg("failed to parse request")
+		return handlers.RenderContent(ctx, &model.MerchantKeyUsesResponse{Uses: uses}, w, http.StatusOK)
+	}
+}
+
+func handleCreateWebhookEndpoint(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
+		req := &model.CreateWebhookEndpointRequest{}
+		if err := requestutils.ReadJSON(ctx, r.Body, req); err != nil {
 			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
 		}
 
-		if err := valid.StructCtx(ctx, req); err != nil {
-			lg.Warn().Err(err).Msg("failed to validate request")
+		result, err := svc.CreateMerchantWebhookEndpoint(ctx, merchant, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, errWebhookKeyRequired):
+				return handlers.WrapError(err, "Request must be signed with a merchant key", http.StatusForbidden)
 
-			return handlers.WrapError(err, "Error in request validation", http.StatusBadRequest)
-		}
+			case errors.Is(err, model.ErrWebhookEndpointInvalid):
+				return handlers.ValidationError("request", map[string]interface{}{"url": "must be an https url", "eventTypes": "must be known event types"})
 
-		aerr := svc.verifyCredential(ctx, req, w)
-		if aerr != nil {
-			lg.Err(aerr).Msg("failed to verify credential")
+			default:
+				return handlers.WrapError(model.ErrSomethingWentWrong, "failed to create webhook endpoint", http.StatusInternalServerError)
+			}
 		}
 
-		return aerr
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
 	}
 }
 
-func WebhookRouter(svc *Service) chi.Router {
-	r := chi.NewRouter()
+func handleListWebhookEndpoints(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-	r.Method(http.MethodPost, "/stripe", middleware.InstrumentHandler("HandleStripeWebhook", handleStripeWebhook(svc)))
-	r.Method(http.MethodPost, "/radom", middleware.InstrumentHandler("handleRadomWebhook", handleRadomWebhook(svc)))
-	r.Method(http.MethodPost, "/android", middleware.InstrumentHandler("handleWebhookPlayStore", handleWebhookPlayStore(svc)))
-	r.Method(http.MethodPost, "/ios", middleware.InstrumentHandler("handleWebhookAppStore", handleWebhookAppStore(svc)))
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-	return r
-}
+		result, err := svc.ListMerchantWebhookEndpoints(ctx, merchant)
+		if err != nil {
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to list webhook endpoints", http.StatusInternalServerError)
+		}
 
-func handleWebhookPlayStore(svc *Service) handlers.AppHandler {
-	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		return handleWebhookPlayStoreH(w, r, svc)
+		return handlers.RenderContent(ctx, &model.MerchantWebhookEndpointsResponse{Endpoints: result}, w, http.StatusOK)
 	}
 }
 
-func handleWebhookPlayStoreH(w http.ResponseWriter, r *http.Request, svc *Service) *handlers.AppError {
-	ctx := r.Context()
-
-	lg := logging.Logger(ctx, "skus").With().Str("func", "handleWebhookPlayStore").Logger()
-
-	if err := svc.gpsAuth.authenticate(ctx, r.Header.Get("Authorization")); err != nil {
-		if errors.Is(err, errGPSDisabled) {
-			lg.Warn().Msg("play store notifications disabled")
+func handleDisableWebhookEndpoint(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
 		}
 
-		lg.Err(err).Msg("invalid request")
+		id, err := uuid.FromString(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"id": err.Error()})
+		}
 
-		return handlers.WrapError(err, "invalid request", http.StatusUnauthorized)
-	}
+		if err := svc.DisableMerchantWebhookEndpoint(ctx, merchant, id); err != nil {
+			if errors.Is(err, model.ErrWebhookEndpointNotFound) {
+				return handlers.WrapError(err, "Webhook endpoint not found", http.StatusNotFound)
+			}
 
-	data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
-	if err != nil {
-		lg.Err(err).Msg("failed to read payload")
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to disable webhook endpoint", http.StatusInternalServerError)
+		}
 
 		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 	}
+}
 
-	ntf, err := parsePlayStoreDevNotification(data)
-	if err != nil {
-		lg.Err(err).Str("payload", string(data)).Msg("failed to parse play store notification")
-
-		return handlers.ValidationError("request", map[string]interface{}{"parse-payload": err.Error()})
-	}
-
-	if err := svc.processPlayStoreNotification(ctx, ntf); err != nil {
-		l := lg.With().Str("ntf_type", ntf.ntfType()).Int("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Str("ntf_package", ntf.pkg()).Logger()
-
-		switch {
-		case errors.Is(err, context.Canceled):
-			l.Warn().Err(err).Msg("failed to process play store notification")
+func handleListWebhookDeliveries(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-			// Should retry.
-			return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-		case errors.Is(err, model.ErrOrderNotFound), errors.Is(err, errNotFound):
-			l.Warn().Err(err).Msg("failed to process play store notification")
+		filter, err := parseWebhookDeliveryFilter(r)
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"query": err.Error()})
+		}
 
-			// Order was not found, so nothing can be done.
-			// It might be an issue for VPN:
-			// - user has not linked yet;
-			// - billing cycle comes through, subscription renews;
-			// - user links immediately after billing cycle (they get 1 month);
-			// - there might be a small gap between order's expiration and next successful renewal;
-			// - the grace period should handle it.
-			// A better option is to create orders when the user subscribes, similar to Leo.
-			// Or allow for 1 or 2 retry attempts for the notification, but this requires tracking.
+		result, err := svc.ListMerchantWebhookDeliveries(ctx, merchant, filter)
+		if err != nil {
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to list webhook deliveries", http.StatusInternalServerError)
+		}
 
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		return handlers.RenderContent(ctx, &model.MerchantWebhookDeliveriesResponse{Deliveries: result}, w, http.StatusOK)
+	}
+}
 
-		case errors.Is(err, model.ErrNoRowsChangedOrder):
-			l.Warn().Err(err).Msg("failed to process play store notification")
+func handleRedeliverWebhook(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-			// No rows have changed whilst processing.
-			// This could happen in theory, but not in practice.
-			// It would mean that we attempted to update with the same data as it's in the database.
-			// This could happen when trying to process the same event twice, which could happen
-			// if the App Store sends multiple notifications about the same event.
-			// (E.g. auto-renew and billing recovery).
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		id, err := uuid.FromString(chi.URLParamFromCtx(ctx, "id"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"id": err.Error()})
+		}
 
-		default:
-			l.Err(err).Msg("failed to process play store notification")
+		if err := svc.RedeliverMerchantWebhook(ctx, merchant, id); err != nil {
+			if errors.Is(err, model.ErrWebhookDeliveryNotFound) {
+				return handlers.WrapError(err, "Webhook delivery not found", http.StatusNotFound)
+			}
 
-			// Retry for all other errors for now.
-			return handlers.WrapError(model.ErrSomethingWentWrong, "something went wrong", http.StatusInternalServerError)
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to redeliver webhook", http.StatusInternalServerError)
 		}
-	}
 
-	msg := "skipped play store notification"
-	if ntf.shouldProcess() {
-		msg = "processed play store notification"
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 	}
-
-	lg.Info().Str("ntf_type", ntf.ntfType()).Int("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Str("ntf_package", ntf.pkg()).Msg(msg)
-
-	return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 }
 
-func handleWebhookAppStore(svc *Service) handlers.AppHandler {
+func handleSpend(svc *Service) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		return handleWebhookAppStoreH(w, r, svc)
-	}
-}
+		ctx := r.Context()
 
-func handleWebhookAppStoreH(w http.ResponseWriter, r *http.Request, svc *Service) *handlers.AppError {
-	ctx := r.Context()
+		req := SpendRequest{}
+		if err := requestutils.ReadJSON(ctx, r.Body, &req); err != nil {
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
 
-	lg := logging.Logger(ctx, "skus").With().Str("func", "handleWebhookAppStore").Logger()
+		if _, err := govalidator.ValidateStruct(req); err != nil {
+			return handlers.WrapValidationError(err)
+		}
 
-	data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
-	if err != nil {
-		lg.Err(err).Msg("failed to read request body")
+		merchID, name := chi.URLParamFromCtx(ctx, "merchantID"), chi.URLParamFromCtx(ctx, "channel")
 
-		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
-	}
+		if err := svc.Spend(ctx, merchID, name, req.Credentials, req.Payload); err != nil {
+			switch {
+			case errors.Is(err, model.ErrSpendChannelNotFound):
+				return handlers.WrapError(err, "Spend channel not found", http.StatusNotFound)
 
-	spayload := &struct {
-		SignedPayload string `json:"signedPayload"`
-	}{}
+			case errors.Is(err, model.ErrSpendPayloadInvalid):
+				return handlers.ValidationError("request", map[string]interface{}{"payload": "must conform to the payload schema of the channel"})
 
-	if err := json.Unmarshal(data, spayload); err != nil {
-		lg.Err(err).Str("data", string(data)).Msg("failed to unmarshal responseBodyV2")
+			case errors.Is(err, model.ErrSpendNoCredentials), errors.Is(err, model.ErrSpendCredsNotAllowed), errors.Is(err, model.ErrIssuerNotFound):
+				return handlers.ValidationError("request", map[string]interface{}{"credentials": err.Error()})
 
-		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
-	}
+			default:
+				lg := logging.Logger(ctx, "skus").With().Str("func", "handleSpend").Logger()
+				lg.Err(err).Msg("failed to spend credentials")
 
-	ntf, err := parseAppStoreSrvNotification(svc.assnCertVrf, spayload.SignedPayload)
-	if err != nil {
-		lg.Err(err).Str("payload", spayload.SignedPayload).Msg("failed to parse app store notification")
+				return handlers.WrapError(model.ErrSomethingWentWrong, "failed to spend credentials", http.StatusInternalServerError)
+			}
+		}
 
-		return handlers.ValidationError("request", map[string]interface{}{"parse-signed-payload": err.Error()})
+		w.WriteHeader(http.StatusOK)
+		return nil
 	}
+}
 
-	if err := svc.processAppStoreNotification(ctx, ntf); err != nil {
-		l := lg.With().Str("ntf_type", ntf.ntfType()).Str("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Str("ntf_package", ntf.pkg()).Logger()
-
-		switch {
-		case errors.Is(err, context.Canceled):
-			l.Warn().Err(err).Msg("failed to process app store notification")
-
-			// Should retry.
-			return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
-
-		case errors.Is(err, model.ErrOrderNotFound):
-			l.Warn().Err(err).Msg("failed to process app store notification")
-
-			// Order was not found, so nothing can be done.
-			// It might be an issue for VPN:
-			// - user has not linked yet;
-			// - billing cycle comes through, subscription renews;
-			// - user links immediately after billing cycle (they get 1 month);
-			// - there might be a small gap between order's expiration and next successful renewal;
-			// - the grace period should handle it.
-			// A better option is to create orders when the user subscribes, similar to Leo.
-			// Or allow for 1 or 2 retry attempts for the notification, but this requires tracking.
-			// (Which can easily be done by setting ntf.val.NotificationUUID in Redis with EX).
-
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+func handleCreateSpendChannel(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-		case errors.Is(err, model.ErrNoRowsChangedOrder):
-			l.Warn().Err(err).Msg("failed to process app store notification")
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-			// No rows have changed whilst processing.
-			// This could happen in theory, but not in practice.
-			// It would mean that we attempted to update with the same data as it's in the database.
-			// This could happen when trying to process the same event twice, which could happen
-			// if the App Store sends multiple notifications about the same event.
-			// (E.g. auto-renew and billing recovery).
+		req := &model.CreateSpendChannelRequest{}
+		if err := requestutils.ReadJSON(ctx, r.Body, req); err != nil {
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
 
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		result, err := svc.CreateSpendChannel(ctx, merchant, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, model.ErrSpendChannelInvalid):
+				return handlers.ValidationError("request", map[string]interface{}{
+					"name":          "must be lowercase letters, digits and dashes",
+					"payloadSchema": "must be an avro record schema",
+					"allowedSkus":   "must not be empty",
+				})
 
-		default:
-			l.Err(err).Msg("failed to process app store notification")
+			case errors.Is(err, model.ErrSpendChannelExists):
+				return handlers.WrapError(err, "Spend channel already exists", http.StatusConflict)
 
-			// Retry for all other errors for now.
-			return handlers.WrapError(model.ErrSomethingWentWrong, "something went wrong", http.StatusInternalServerError)
+			default:
+				return handlers.WrapError(model.ErrSomethingWentWrong, "failed to create spend channel", http.StatusInternalServerError)
+			}
 		}
-	}
 
-	msg := "skipped app store notification"
-	if ntf.shouldProcess() {
-		msg = "processed app store notification"
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
 	}
-
-	lg.Info().Str("ntf_type", ntf.ntfType()).Str("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Str("ntf_package", ntf.pkg()).Msg(msg)
-
-	return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 }
 
-// handleRadomWebhook handles Radom checkout session webhooks.
-func handleRadomWebhook(s *Service) handlers.AppHandler {
+func handleListSpendChannels(svc *Service) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		return handleRadomWebhookH(w, r, s)
-	}
-}
-
-func handleRadomWebhookH(w http.ResponseWriter, r *http.Request, svc *Service) *handlers.AppError {
-	ctx := r.Context()
-
-	l := logging.Logger(ctx, "skus").With().Str("func", "handleRadomWebhookH").Logger()
-
-	if err := svc.radomAuth.Authenticate(ctx, r.Header.Get("radom-verification-key")); err != nil {
-		l.Err(err).Msg("invalid request")
+		ctx := r.Context()
 
-		return handlers.WrapError(err, "invalid request", http.StatusUnauthorized)
-	}
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-	b, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
-	if err != nil {
-		l.Err(err).Msg("failed to read payload")
+		result, err := svc.ListSpendChannels(ctx, merchant)
+		if err != nil {
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to list spend channels", http.StatusInternalServerError)
+		}
 
-		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		return handlers.RenderContent(ctx, &model.SpendChannelsResponse{Channels: result}, w, http.StatusOK)
 	}
+}
 
-	ntf, err := radom.ParseNotification(b)
-	if err != nil {
-		l.Err(err).Msg("failed to parse radom event")
+func handleDisableSpendChannel(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-		return handlers.WrapError(err, "failed to parse radom event", http.StatusBadRequest)
-	}
+		merchant, err := merchantFromCtx(ctx)
+		if err != nil {
+			return handlers.WrapError(err, "Error getting auth merchant", http.StatusUnauthorized)
+		}
 
-	if err := svc.processRadomNotification(ctx, ntf); err != nil {
-		l.Err(err).Msg("failed to process radom notification")
+		if err := svc.DisableSpendChannel(ctx, merchant, chi.URLParamFromCtx(ctx, "name")); err != nil {
+			if errors.Is(err, model.ErrSpendChannelNotFound) {
+				return handlers.WrapError(err, "Spend channel not found", http.StatusNotFound)
+			}
 
-		return handlers.WrapError(model.ErrSomethingWentWrong, "something went wrong", http.StatusInternalServerError)
-	}
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to disable spend channel", http.StatusInternalServerError)
+		}
 
-	msg := "skipped radom notification"
-	if ntf.ShouldProcess() {
-		msg = "processed radom notification"
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 	}
-
-	l.Info().Str("ntf_type", ntf.NtfType()).Str("ntf_effect", ntf.Effect()).Msg(msg)
-
-	return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 }
 
-func handleStripeWebhook(svc *Service) handlers.AppHandler {
+func handleVerifyCredV2(svc *Service, valid *validator.Validate) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
 		ctx := r.Context()
 
-		lg := logging.Logger(ctx, "skus").With().Str("func", "handleStripeWebhook").Logger()
-
-		secret, err := appctx.GetStringFromContext(ctx, appctx.StripeWebhookSecretCTXKey)
-		if err != nil {
-			lg.Err(err).Msg("failed to get stripe_webhook_secret from context")
-
-			return handlers.WrapError(err, "error getting stripe_webhook_secret from context", http.StatusInternalServerError)
-		}
+		lg := logging.Logger(ctx, "skus").With().Str("func", "handleVerifyCredV2").Logger()
 
 		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
 		if err != nil {
-			lg.Err(err).Msg("failed to read request body")
+			lg.Warn().Err(err).Msg("failed to read body")
 
-			// Nothing can be done if failed to read the body.
-			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
 		}
 
-		event, err := webhook.ConstructEvent(data, r.Header.Get("Stripe-Signature"), secret)
+		req, err := parseVerifyCredRequestV2(data)
 		if err != nil {
-			lg.Err(err).Msg("failed to verify stripe signature")
+			lg.Warn().Err(err).Msg("failed to parse request")
 
-			return handlers.WrapError(err, "error verifying webhook signature", http.StatusBadRequest)
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
 		}
 
-		ntf, err := parseStripeNotification(&event)
-		if err != nil {
-			if errors.Is(err, errStripeSkipEvent) {
-				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
-			}
+		if err := validateVerifyCredRequestV2(valid, req); err != nil {
+			lg.Warn().Err(err).Msg("failed to validate request")
 
-			// Unlikely to be able to do anything about it.
-			// Consider responding with http.StatusOK.
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
 
-			return handlers.WrapError(err, "failed to parse event", http.StatusBadRequest)
+		aerr := svc.verifyCredential(ctx, req, w)
+		if aerr != nil {
+			lg.Err(aerr).Msg("failed to verify credential")
 		}
 
-		if err := svc.processStripeNotification(ctx, ntf); err != nil {
-			l := lg.With().Str("ntf_type", ntf.ntfType()).Str("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Logger()
+		return aerr
+	}
+}
 
-			switch {
-			case errors.Is(err, context.Canceled):
-				l.Warn().Err(err).Msg("failed to process stripe notification")
+func handleVerifyCredV1(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
 
-				// Should retry.
-				return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+		lg := logging.Logger(ctx, "skus").With().Str("func", "handleVerifyCredV1").Logger()
 
-			case errors.Is(err, model.ErrOrderNotFound):
-				l.Warn().Err(err).Msg("failed to process stripe notification")
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			lg.Warn().Err(err).Msg("failed to read body")
 
-				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
 
-			case errors.Is(err, model.ErrNoRowsChangedOrder):
-				l.Warn().Err(err).Msg("failed to process stripe notification")
+		req := &model.VerifyCredentialRequestV1{}
+		if err := json.Unmarshal(data, req); err != nil {
+			lg.Warn().Err(err).Msg("failed to parse request")
 
-				// No rows have changed whilst processing.
-				// This could happen in theory, but not in practice.
-				// It would mean that we attempted to update with the same data as it's in the database.
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
 
-				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		if err := valid.StructCtx(ctx, req); err != nil {
+			lg.Warn().Err(err).Msg("failed to validate request")
+
+			return handlers.WrapError(err, "Error in request validation", http.StatusBadRequest)
+		}
+
+		aerr := svc.verifyCredential(ctx, req, w)
+		if aerr != nil {
+			lg.Err(aerr).Msg("failed to verify credential")
+		}
+
+		return aerr
+	}
+}
+
+// handleVerifyCredBatch verifies many credentials of any type in one request.
+//
+// Credentials that cannot be parsed are reported individually, without failing the batch.
+func handleVerifyCredBatch(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		lg := logging.Logger(ctx, "skus").With().Str("func", "handleVerifyCredBatch").Logger()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			lg.Warn().Err(err).Msg("failed to read body")
+
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
+
+		req := &model.VerifyCredentialBatchRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			lg.Warn().Err(err).Msg("failed to parse request")
+
+			return handlers.WrapError(err, "Error in request body", http.StatusBadRequest)
+		}
+
+		if err := valid.StructCtx(ctx, req); err != nil {
+			lg.Warn().Err(err).Msg("failed to validate request")
+
+			return handlers.WrapError(err, "Error in request validation", http.StatusBadRequest)
+		}
+
+		results := make([]model.VerifyCredentialResult, len(req.Credentials))
+
+		idxs := make([]int, 0, len(req.Credentials))
+		creds := make([]credential, 0, len(req.Credentials))
+
+		for i, cred := range req.Credentials {
+			if cred == nil {
+				results[i] = newVerifyCredResultErr(handlers.WrapError(nil, "Error in request body", http.StatusBadRequest))
+				continue
+			}
+
+			copaque, err := parseVerifyCredOpaque(cred.Credential)
+			if err != nil {
+				results[i] = newVerifyCredResultErr(handlers.WrapError(err, "Error in request body", http.StatusBadRequest))
+				continue
+			}
+
+			cred.CredentialOpaque = copaque
+
+			if err := validateVerifyCredRequestV2(valid, cred); err != nil {
+				results[i] = newVerifyCredResultErr(handlers.WrapError(err, "Error in request body", http.StatusBadRequest))
+				continue
+			}
+
+			idxs = append(idxs, i)
+			creds = append(creds, cred)
+		}
+
+		vresults := svc.verifyCredentialBatch(ctx, creds)
+		for i := range vresults {
+			results[idxs[i]] = vresults[i]
+		}
+
+		return handlers.RenderContent(ctx, &model.VerifyCredentialBatchResponse{Results: results}, w, http.StatusOK)
+	}
+}
+
+func WebhookRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	// Each processor receives notifications at its name, e.g. /stripe.
+	for _, proc := range svc.payProcs {
+		r.Method(http.MethodPost, "/"+proc.Name(), middleware.InstrumentHandler(paymentWebhookMetricName(proc.Name()), handlePaymentWebhook(svc, proc)))
+	}
+
+	r.Method(
+		http.MethodPost,
+		"/inbox/{entryID}/replay",
+		middleware.InstrumentHandler("ReplayWebhook", middleware.SimpleTokenAuthorizedOnly(handleReplayWebhook(svc))),
+	)
+
+	return r
+}
+
+// CouponRouter handles management of promo codes.
+func CouponRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	if os.Getenv("ENV") != "local" {
+		r.Use(middleware.SimpleTokenAuthorizedOnly)
+	}
+
+	valid := validator.New()
+
+	r.Method(http.MethodPost, "/", middleware.InstrumentHandler("CreateCoupon", handleCreateCoupon(svc, valid)))
+	r.Method(http.MethodGet, "/{code}", middleware.InstrumentHandler("GetCoupon", handleGetCoupon(svc)))
+
+	return r
+}
+
+func handleCreateCoupon(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "failed to read request body", http.StatusBadRequest)
+		}
+
+		req := &model.CreateCouponRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+		}
+
+		if err := valid.StructCtx(ctx, req); err != nil {
+			verrs, ok := collectValidationErrors(err)
+			if !ok {
+				return handlers.ValidationError("request", map[string]interface{}{"request-body": err.Error()})
+			}
+
+			return handlers.ValidationError("request", verrs)
+		}
+
+		result, err := svc.CreateCoupon(ctx, req)
+		if err != nil {
+			return handleCouponErr(err, "failed to create coupon")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
+	})
+}
+
+func handleGetCoupon(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		result, err := svc.GetCoupon(ctx, chi.URLParamFromCtx(ctx, "code"))
+		if err != nil {
+			return handleCouponErr(err, "failed to get coupon")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleCouponErr(err error, msg string) *handlers.AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+	case errors.Is(err, model.ErrCouponNotFound):
+		return handlers.WrapError(err, "coupon not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrCouponInvalid):
+		return handlers.WrapError(err, msg, http.StatusBadRequest)
+
+	default:
+		return handlers.WrapError(model.ErrSomethingWentWrong, msg, http.StatusInternalServerError)
+	}
+}
+
+// ConsumerRouter handles operation of Kafka consumers.
+func ConsumerRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	if os.Getenv("ENV") != "local" {
+		r.Use(middleware.SimpleTokenAuthorizedOnly)
+	}
+
+	r.Method(http.MethodGet, "/signed-order-credentials", middleware.InstrumentHandler("SignedOrderCredsConsumerStatus", handleSignedOrderCredsConsumerStatus(svc)))
+	r.Method(http.MethodPost, "/signed-order-credentials/pause", middleware.InstrumentHandler("PauseSignedOrderCredsConsumer", handlePauseSignedOrderCredsConsumer(svc)))
+	r.Method(http.MethodPost, "/signed-order-credentials/resume", middleware.InstrumentHandler("ResumeSignedOrderCredsConsumer", handleResumeSignedOrderCredsConsumer(svc)))
+
+	return r
+}
+
+func handleSignedOrderCredsConsumerStatus(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		return renderSignedOrderCredsConsumerStatus(svc, w, r)
+	}
+}
+
+func handlePauseSignedOrderCredsConsumer(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		if err := svc.PauseSignedOrderCredsConsumer(r.Context()); err != nil {
+			return handlers.WrapError(err, "failed to pause consumer", http.StatusInternalServerError)
+		}
+
+		return renderSignedOrderCredsConsumerStatus(svc, w, r)
+	}
+}
+
+func handleResumeSignedOrderCredsConsumer(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		if err := svc.ResumeSignedOrderCredsConsumer(r.Context()); err != nil {
+			return handlers.WrapError(err, "failed to resume consumer", http.StatusInternalServerError)
+		}
+
+		return renderSignedOrderCredsConsumerStatus(svc, w, r)
+	}
+}
+
+func renderSignedOrderCredsConsumerStatus(svc *Service, w http.ResponseWriter, r *http.Request) *handlers.AppError {
+	ctx := r.Context()
+
+	result, err := svc.SignedOrderCredsConsumerStatus(ctx)
+	if err != nil {
+		return handlers.WrapError(err, "failed to get consumer status", http.StatusInternalServerError)
+	}
+
+	return handlers.RenderContent(ctx, &result, w, http.StatusOK)
+}
+
+// IssuerRouter handles operation of credential issuers.
+func IssuerRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	if os.Getenv("ENV") != "local" {
+		r.Use(middleware.SimpleTokenAuthorizedOnly)
+	}
+
+	valid := validator.New()
+
+	r.Method(http.MethodGet, "/keys", middleware.InstrumentHandler("ListIssuerKeyStates", handleListIssuerKeyStates(svc)))
+	r.Method(http.MethodPost, "/rotate", middleware.InstrumentHandler("RotateIssuerKeys", handleRotateIssuerKeys(svc, valid)))
+
+	return r
+}
+
+func handleListIssuerKeyStates(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		result, err := svc.ListIssuerKeyStates(ctx)
+		if err != nil {
+			return handlers.WrapError(model.ErrSomethingWentWrong, "failed to list issuer keys", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, &model.IssuerKeyStatesResponse{Issuers: result}, w, http.StatusOK)
+	}
+}
+
+func handleRotateIssuerKeys(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "failed to read request", http.StatusBadRequest)
+		}
+
+		req := &model.RotateIssuerRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+		}
+
+		if err := valid.StructCtx(ctx, req); err != nil {
+			return handlers.WrapError(err, "Error in request validation", http.StatusBadRequest)
+		}
+
+		result, err := svc.RotateIssuerKeys(ctx, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, model.ErrIssuerNotFound):
+				return handlers.WrapError(err, "issuer not found", http.StatusNotFound)
+
+			case errors.Is(err, model.ErrIssuerNotV3):
+				return handlers.WrapError(err, "issuer does not support key rotation", http.StatusConflict)
 
 			default:
-				l.Err(err).Msg("failed to process stripe notification")
+				return handlers.WrapError(model.ErrSomethingWentWrong, "failed to rotate issuer keys", http.StatusInternalServerError)
+			}
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	}
+}
+
+// TLV1MigrationRouter handles migration of skus from time limited v1 to time limited v2 credentials.
+func TLV1MigrationRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	if os.Getenv("ENV") != "local" {
+		r.Use(middleware.SimpleTokenAuthorizedOnly)
+	}
+
+	valid := validator.New()
+
+	r.Method(http.MethodGet, "/", middleware.InstrumentHandler("ListTLV1Migrations", handleListTLV1Migrations(svc)))
+	r.Method(http.MethodPut, "/", middleware.InstrumentHandler("UpsertTLV1Migration", handleUpsertTLV1Migration(svc, valid)))
+	r.Method(http.MethodDelete, "/{migrationID}", middleware.InstrumentHandler("DeleteTLV1Migration", handleDeleteTLV1Migration(svc)))
+	r.Method(http.MethodGet, "/report", middleware.InstrumentHandler("TLV1UsageReport", handleTLV1UsageReport(svc)))
+
+	return r
+}
+
+func handleListTLV1Migrations(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		result, err := svc.ListTLV1Migrations(ctx)
+		if err != nil {
+			return handleTLV1MigrationErr(err, "failed to list tlv1 migrations")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	}
+}
+
+func handleUpsertTLV1Migration(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "failed to read request", http.StatusBadRequest)
+		}
+
+		req := &model.TLV1MigrationRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+		}
+
+		if err := valid.StructCtx(ctx, req); err != nil {
+			return handlers.WrapError(err, "Error in request validation", http.StatusBadRequest)
+		}
+
+		result, err := svc.UpsertTLV1Migration(ctx, req)
+		if err != nil {
+			return handleTLV1MigrationErr(err, "failed to save tlv1 migration")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	}
+}
+
+func handleDeleteTLV1Migration(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		migID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "migrationID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"migrationID": err.Error()})
+		}
+
+		if err := svc.DeleteTLV1Migration(ctx, migID); err != nil {
+			return handleTLV1MigrationErr(err, "failed to delete tlv1 migration")
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	}
+}
+
+// handleTLV1UsageReport reports time limited v1 credentials presented within the last days, 30 by default.
+func handleTLV1UsageReport(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		days := tlv1UsageReportDays
+		if raw := r.URL.Query().Get("days"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				return handlers.ValidationError("request", map[string]interface{}{"days": "must be a positive integer"})
+			}
+
+			days = n
+		}
+
+		from := time.Now().UTC().AddDate(0, 0, -days)
+
+		result, err := svc.TLV1UsageReport(ctx, from)
+		if err != nil {
+			return handleTLV1MigrationErr(err, "failed to get tlv1 usage report")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	}
+}
+
+func handleTLV1MigrationErr(err error, msg string) *handlers.AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+	case errors.Is(err, model.ErrTLV1MigrationNotFound):
+		return handlers.WrapError(err, "tlv1 migration not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrTLV1MigrationInvalid):
+		return handlers.WrapError(err, msg, http.StatusBadRequest)
+
+	default:
+		return handlers.WrapError(model.ErrSomethingWentWrong, msg, http.StatusInternalServerError)
+	}
+}
+
+// SKUCatalogRouter handles management of the SKU catalog.
+func SKUCatalogRouter(svc *Service) chi.Router {
+	r := chi.NewRouter()
+
+	if os.Getenv("ENV") != "local" {
+		r.Use(middleware.SimpleTokenAuthorizedOnly)
+	}
+
+	valid := validator.New()
+
+	r.Method(http.MethodPost, "/", middleware.InstrumentHandler("CreateSKUCatalogEntry", handleCreateSKUCatalogEntry(svc, valid)))
+	r.Method(http.MethodGet, "/", middleware.InstrumentHandler("ListSKUCatalog", handleListSKUCatalog(svc)))
+	r.Method(http.MethodGet, "/{entryID}", middleware.InstrumentHandler("GetSKUCatalogEntry", handleGetSKUCatalogEntry(svc)))
+	r.Method(http.MethodPut, "/{entryID}", middleware.InstrumentHandler("UpdateSKUCatalogEntry", handleUpdateSKUCatalogEntry(svc, valid)))
+	r.Method(http.MethodDelete, "/{entryID}", middleware.InstrumentHandler("DeleteSKUCatalogEntry", handleDeleteSKUCatalogEntry(svc)))
+	r.Method(http.MethodGet, "/{entryID}/prices", middleware.InstrumentHandler("ListSKUPrices", handleListSKUPrices(svc)))
+	r.Method(http.MethodPut, "/{entryID}/prices", middleware.InstrumentHandler("SetSKUPrice", handleSetSKUPrice(svc, valid)))
+	r.Method(http.MethodDelete, "/{entryID}/prices/{priceID}", middleware.InstrumentHandler("DeleteSKUPrice", handleDeleteSKUPrice(svc)))
+
+	return r
+}
+
+func handleCreateSKUCatalogEntry(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		req, appErr := parseSKUCatalogEntryRequest(r, valid)
+		if appErr != nil {
+			return appErr
+		}
+
+		result, err := svc.CreateSKUCatalogEntry(ctx, req)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to create sku catalog entry")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
+	})
+}
+
+func handleListSKUCatalog(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		result, err := svc.ListSKUCatalog(ctx)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to list sku catalog")
+		}
+
+		if result == nil {
+			result = []model.SKUCatalogEntry{}
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleGetSKUCatalogEntry(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		result, err := svc.GetSKUCatalogEntry(ctx, entryID)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to get sku catalog entry")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleUpdateSKUCatalogEntry(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		req, appErr := parseSKUCatalogEntryRequest(r, valid)
+		if appErr != nil {
+			return appErr
+		}
+
+		result, err := svc.UpdateSKUCatalogEntry(ctx, entryID, req)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to update sku catalog entry")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleDeleteSKUCatalogEntry(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		if err := svc.DeleteSKUCatalogEntry(ctx, entryID); err != nil {
+			return handleSKUCatalogErr(err, "failed to delete sku catalog entry")
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+func handleListSKUPrices(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		result, err := svc.ListSKUPrices(ctx, entryID)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to list sku prices")
+		}
+
+		if result == nil {
+			result = []model.SKUPrice{}
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleSetSKUPrice(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			return handlers.WrapError(err, "failed to read request body", http.StatusBadRequest)
+		}
+
+		req := &model.SKUPriceRequest{}
+		if err := json.Unmarshal(data, req); err != nil {
+			return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+		}
+
+		if err := valid.StructCtx(ctx, req); err != nil {
+			verrs, ok := collectValidationErrors(err)
+			if !ok {
+				return handlers.ValidationError("request", map[string]interface{}{"request-body": err.Error()})
+			}
+
+			return handlers.ValidationError("request", verrs)
+		}
+
+		result, err := svc.SetSKUPrice(ctx, entryID, req)
+		if err != nil {
+			return handleSKUCatalogErr(err, "failed to set sku price")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleDeleteSKUPrice(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		priceID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "priceID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"priceID": err.Error()})
+		}
+
+		if err := svc.DeleteSKUPrice(ctx, entryID, priceID); err != nil {
+			return handleSKUCatalogErr(err, "failed to delete sku price")
+		}
 
-				// Retry for all other errors for now.
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+func parseSKUCatalogEntryRequest(r *http.Request, valid *validator.Validate) (*model.SKUCatalogEntryRequest, *handlers.AppError) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+	if err != nil {
+		return nil, handlers.WrapError(err, "failed to read request body", http.StatusBadRequest)
+	}
+
+	req := &model.SKUCatalogEntryRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+	}
+
+	if err := valid.StructCtx(r.Context(), req); err != nil {
+		verrs, ok := collectValidationErrors(err)
+		if !ok {
+			return nil, handlers.ValidationError("request", map[string]interface{}{"request-body": err.Error()})
+		}
+
+		return nil, handlers.ValidationError("request", verrs)
+	}
+
+	return req, nil
+}
+
+func handleSKUCatalogErr(err error, msg string) *handlers.AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+	case errors.Is(err, model.ErrSKUCatalogEntryNotFound):
+		return handlers.WrapError(err, "sku catalog entry not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrSKUPriceNotFound):
+		return handlers.WrapError(err, "sku price not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrSKUCatalogEntryInvalid), errors.Is(err, model.ErrSKUPriceInvalid):
+		return handlers.WrapError(err, msg, http.StatusBadRequest)
+
+	default:
+		return handlers.WrapError(model.ErrSomethingWentWrong, msg, http.StatusInternalServerError)
+	}
+}
+
+type portalSessCtxKey struct{}
+
+// PortalRouter serves the customer portal.
+//
+// Customers sign in with a code sent to their email, and manage their Stripe subscriptions with the session token.
+// Sign in is rate limited per IP here, and per email by the service.
+func PortalRouter(ctx context.Context, svc *Service, copts cors.Options) chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(NewCORSMwr(copts, http.MethodGet, http.MethodPost))
+
+	valid := validator.New()
+
+	r.Method(http.MethodPost, "/login", middleware.RateLimiter(ctx, 5)(middleware.InstrumentHandler("PortalLogin", handlePortalLogin(svc, valid))))
+	r.Method(http.MethodPost, "/login/verify", middleware.RateLimiter(ctx, 10)(middleware.InstrumentHandler("PortalVerify", handlePortalVerify(svc, valid))))
+
+	r.Group(func(r chi.Router) {
+		r.Use(newPortalAuthMwr(svc))
+
+		r.Method(http.MethodPost, "/logout", middleware.InstrumentHandler("PortalLogout", handlePortalLogout(svc)))
+		r.Method(http.MethodGet, "/orders", middleware.InstrumentHandler("ListPortalOrders", handleListPortalOrders(svc)))
+		r.Method(http.MethodGet, "/orders/{orderID}/payments", middleware.InstrumentHandler("ListPortalPayments", handleListPortalPayments(svc)))
+		r.Method(http.MethodPost, "/orders/{orderID}/cancel", middleware.InstrumentHandler("CancelPortalOrder", handleCancelPortalOrder(svc)))
+		r.Method(http.MethodPost, "/orders/{orderID}/resume", middleware.InstrumentHandler("ResumePortalOrder", handleResumePortalOrder(svc)))
+		r.Method(http.MethodPost, "/billing-portal", middleware.InstrumentHandler("CreatePortalBillingSession", handleCreatePortalBillingSession(svc, valid)))
+	})
+
+	return r
+}
+
+// newPortalAuthMwr authenticates requests with a portal session token passed as a bearer token.
+func newPortalAuthMwr(svc *Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			token := r.Header.Get("Authorization")
+			if len(token) <= 7 || !strings.EqualFold(token[:7], "bearer ") {
+				handlers.WrapError(model.ErrPortalSessionNotFound, "missing session token", http.StatusUnauthorized).ServeHTTP(w, r)
+				return
+			}
+
+			sess, err := svc.PortalSession(ctx, token[7:])
+			if err != nil {
+				handlePortalErr(err, "failed to authenticate").ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, portalSessCtxKey{}, sess)))
+		})
+	}
+}
+
+func portalSessionFromCtx(ctx context.Context) (*model.PortalSession, bool) {
+	sess, ok := ctx.Value(portalSessCtxKey{}).(*model.PortalSession)
+
+	return sess, ok
+}
+
+func handlePortalLogin(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		req := &model.PortalLoginRequest{}
+		if appErr := parsePortalRequest(r, valid, req); appErr != nil {
+			return appErr
+		}
+
+		if err := svc.PortalLogin(ctx, req); err != nil {
+			return handlePortalErr(err, "failed to send login code")
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusAccepted)
+	})
+}
+
+func handlePortalVerify(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		req := &model.PortalVerifyRequest{}
+		if appErr := parsePortalRequest(r, valid, req); appErr != nil {
+			return appErr
+		}
+
+		result, err := svc.PortalVerify(ctx, req)
+		if err != nil {
+			return handlePortalErr(err, "failed to verify login code")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
+	})
+}
+
+func handlePortalLogout(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		sess, ok := portalSessionFromCtx(ctx)
+		if !ok {
+			return handlePortalErr(model.ErrPortalSessionNotFound, "failed to log out")
+		}
+
+		if err := svc.PortalLogout(ctx, sess.ID); err != nil {
+			return handlePortalErr(err, "failed to log out")
+		}
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	})
+}
+
+func handleListPortalOrders(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		sess, ok := portalSessionFromCtx(ctx)
+		if !ok {
+			return handlePortalErr(model.ErrPortalSessionNotFound, "failed to list orders")
+		}
+
+		result, err := svc.ListPortalOrders(ctx, sess.Email)
+		if err != nil {
+			return handlePortalErr(err, "failed to list orders")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleListPortalPayments(svc *Service) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		sess, ok := portalSessionFromCtx(ctx)
+		if !ok {
+			return handlePortalErr(model.ErrPortalSessionNotFound, "failed to list payments")
+		}
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		result, err := svc.ListPortalPayments(ctx, sess.Email, orderID)
+		if err != nil {
+			return handlePortalErr(err, "failed to list payments")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleCancelPortalOrder(svc *Service) handlers.AppHandler {
+	return handlePortalOrderAction(svc.CancelPortalOrder, "failed to cancel order")
+}
+
+func handleResumePortalOrder(svc *Service) handlers.AppHandler {
+	return handlePortalOrderAction(svc.ResumePortalOrder, "failed to resume order")
+}
+
+func handlePortalOrderAction(fn func(ctx context.Context, email string, orderID uuid.UUID) (*model.PortalOrder, error), msg string) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		sess, ok := portalSessionFromCtx(ctx)
+		if !ok {
+			return handlePortalErr(model.ErrPortalSessionNotFound, msg)
+		}
+
+		orderID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "orderID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"orderID": err.Error()})
+		}
+
+		result, err := fn(ctx, sess.Email, orderID)
+		if err != nil {
+			return handlePortalErr(err, msg)
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusOK)
+	})
+}
+
+func handleCreatePortalBillingSession(svc *Service, valid *validator.Validate) handlers.AppHandler {
+	return handlers.AppHandler(func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		sess, ok := portalSessionFromCtx(ctx)
+		if !ok {
+			return handlePortalErr(model.ErrPortalSessionNotFound, "failed to create billing portal session")
+		}
+
+		req := &model.PortalBillingSessionRequest{}
+		if appErr := parsePortalRequest(r, valid, req); appErr != nil {
+			return appErr
+		}
+
+		result, err := svc.CreatePortalBillingSession(ctx, sess.Email, req)
+		if err != nil {
+			return handlePortalErr(err, "failed to create billing portal session")
+		}
+
+		return handlers.RenderContent(ctx, result, w, http.StatusCreated)
+	})
+}
+
+func parsePortalRequest(r *http.Request, valid *validator.Validate, req interface{}) *handlers.AppError {
+	data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+	if err != nil {
+		return handlers.WrapError(err, "failed to read request body", http.StatusBadRequest)
+	}
+
+	if err := json.Unmarshal(data, req); err != nil {
+		return handlers.WrapError(err, "failed to parse request", http.StatusBadRequest)
+	}
+
+	if err := valid.StructCtx(r.Context(), req); err != nil {
+		verrs, ok := collectValidationErrors(err)
+		if !ok {
+			return handlers.ValidationError("request", map[string]interface{}{"request-body": err.Error()})
+		}
+
+		return handlers.ValidationError("request", verrs)
+	}
+
+	return nil
+}
+
+func handlePortalErr(err error, msg string) *handlers.AppError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+	case errors.Is(err, model.ErrPortalSessionNotFound):
+		return handlers.WrapError(err, "invalid or expired session", http.StatusUnauthorized)
+
+	case errors.Is(err, model.ErrPortalLoginCodeInvalid):
+		return handlers.WrapError(err, msg, http.StatusUnauthorized)
+
+	case errors.Is(err, model.ErrPortalLoginLimited):
+		return handlers.WrapError(err, msg, http.StatusTooManyRequests)
+
+	case errors.Is(err, model.ErrPortalReturnURLInvalid):
+		return handlers.WrapError(err, msg, http.StatusBadRequest)
+
+	case errors.Is(err, model.ErrPortalCustomerAmbiguous):
+		return handlers.WrapError(err, "several customers found, orderId is required", http.StatusConflict)
+
+	case errors.Is(err, model.ErrOrderNotFound):
+		return handlers.WrapError(err, "order not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrPortalCustomerNotFound):
+		return handlers.WrapError(err, "customer not found", http.StatusNotFound)
+
+	case errors.Is(err, model.ErrPortalOrderNotManageable):
+		return handlers.WrapError(err, msg, http.StatusConflict)
+
+	default:
+		return handlers.WrapError(model.ErrSomethingWentWrong, msg, http.StatusInternalServerError)
+	}
+}
+
+// handleReplayWebhook schedules a stored notification for processing again.
+//
+// It works for entries in any status, including dead-lettered ones.
+func handleReplayWebhook(svc *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		entryID, err := uuid.FromString(chi.URLParamFromCtx(ctx, "entryID"))
+		if err != nil {
+			return handlers.ValidationError("request", map[string]interface{}{"entryID": err.Error()})
+		}
+
+		entry, err := svc.replayWebhookNotification(ctx, entryID)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return handlers.WrapError(model.ErrSomethingWentWrong, "request has been cancelled", model.StatusClientClosedConn)
+
+			case errors.Is(err, model.ErrWebhookInboxEntryNotFound):
+				return handlers.WrapError(err, "webhook inbox entry not found", http.StatusNotFound)
+
+			default:
 				return handlers.WrapError(model.ErrSomethingWentWrong, "something went wrong", http.StatusInternalServerError)
 			}
 		}
 
-		msg := "skipped stripe notification"
-		if ntf.shouldProcess() {
-			msg = "processed stripe notification"
+		return handlers.RenderContent(ctx, entry, w, http.StatusOK)
+	}
+}
+
+// paymentWebhookMetricName returns the name under which the webhook handler for the processor is instrumented.
+//
+// Names of the existing handlers are kept so that dashboards and alerts continue to work.
+func paymentWebhookMetricName(name string) string {
+	switch name {
+	case model.StripePaymentMethod:
+		return "HandleStripeWebhook"
+
+	case model.RadomPaymentMethod:
+		return "HandleRadomWebhook"
+
+	case model.WebhookVendorPlayStore:
+		return "HandleAndroidWebhook"
+
+	case model.WebhookVendorAppStore:
+		return "HandleIOSWebhook"
+
+	case model.PayPalPaymentMethod:
+		return "HandlePayPalWebhook"
+
+	default:
+		return "HandlePaymentWebhook_" + name
+	}
+}
+
+// handlePaymentWebhook stores authenticated notifications from proc.
+//
+// The notifications are processed asynchronously by RunNextWebhookInboxJob.
+func handlePaymentWebhook(svc *Service, proc PaymentProcessor) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		lg := logging.Logger(ctx, "skus").With().Str("func", "handlePaymentWebhook").Str("processor", proc.Name()).Logger()
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
+		if err != nil {
+			lg.Err(err).Msg("failed to read request body")
+
+			// Nothing can be done if failed to read the body.
+			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		}
+
+		payload, err := proc.AuthenticateNotification(ctx, r, data)
+		if err != nil {
+			if errors.Is(err, errPaymentNtfDisabled) {
+				lg.Warn().Msg("notifications disabled")
+
+				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+			}
+
+			lg.Err(err).Msg("invalid request")
+
+			return renderPaymentNtfErr(ctx, w, err, "invalid request", http.StatusUnauthorized)
+		}
+
+		ntf, err := proc.ParseNotification(payload)
+		if err != nil {
+			if errors.Is(err, errPaymentNtfSkip) {
+				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+			}
+
+			lg.Err(err).Str("payload", string(payload)).Msg("failed to parse notification")
+
+			return renderPaymentNtfErr(ctx, w, err, "failed to parse notification", http.StatusBadRequest)
+		}
+
+		l := lg.With().Str("ntf_type", ntf.ntfType()).Str("ntf_effect", ntf.effect()).Logger()
+
+		if !ntf.shouldProcess() {
+			l.Info().Msg("skipped notification")
+
+			return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+		}
+
+		if _, err := svc.storeWebhookNotification(ctx, proc.Name(), ntf, string(payload)); err != nil {
+			if errors.Is(err, model.ErrWebhookInboxEntryExists) {
+				l.Info().Str("event_id", ntf.eventID()).Msg("skipped redelivered notification")
+
+				return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+			}
+
+			l.Err(err).Msg("failed to store notification")
+
+			// Should retry.
+			return handlers.WrapError(model.ErrSomethingWentWrong, "something went wrong", http.StatusInternalServerError)
 		}
 
-		lg.Info().Str("ntf_type", ntf.ntfType()).Str("ntf_subtype", ntf.ntfSubType()).Str("ntf_effect", ntf.effect()).Msg(msg)
+		l.Info().Msg("stored notification")
+
+		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
+	}
+}
 
+// renderPaymentNtfErr responds to a notification which failed with err, with the status code the processor expects.
+func renderPaymentNtfErr(ctx context.Context, w http.ResponseWriter, err error, msg string, code int) *handlers.AppError {
+	code = paymentNtfErrCode(err, code)
+	if code == http.StatusOK {
 		return handlers.RenderContent(ctx, struct{}{}, w, http.StatusOK)
 	}
+
+	return handlers.WrapError(err, msg, code)
 }
 
 // handleSubmitReceipt was used for linking IAP subscriptions.
@@ -252,6 +252,18 @@ func (suite *ControllersTestSuite) BeforeTest(sn, tn string) {
 		orderRepo:     repository.NewOrder(),
 		orderItemRepo: repository.NewOrderItem(),
 		issuerRepo:    repository.NewIssuer(),
+		orderEvRepo:   repository.NewOrderEvent(),
+		orderDunRepo:  repository.NewOrderDunning(),
+		couponRepo:    repository.NewCoupon(),
+		catalog:       newSKUCatalog(repository.NewSKUCatalog(), "development"),
+		skuPriceRepo:  repository.NewSKUPrice(),
+		portalRepo:    repository.NewPortal(),
+		merchKeyRepo:  repository.NewMerchantKey(),
+		merchHookRepo: repository.NewMerchantWebhook(),
+		spendRepo:     repository.NewSpend(),
+		offsetRepo:    repository.NewConsumerOffset(),
+		issuerKeyRepo: repository.NewIssuerKey(),
+		tlv1MigRepo:   repository.NewTLV1Migration(),
 		Datastore:     pg,
 		cbClient:      suite.mockCB,
 		wallet: &wallet.Service{
@@ -260,14 +272,14 @@ func (suite *ControllersTestSuite) BeforeTest(sn, tn string) {
 		retry: backoff.Retry,
 	}
 
-	suite.orderh = handler.NewOrder(suite.service)
+	suite.orderh = handler.NewOrder(suite.service, handler.GeoProxy{})
 
 	// encrypt merchant key
 	cipher, nonce, err := cryptography.EncryptMessage(byteEncryptionKey, []byte("testing123"))
 	suite.Require().NoError(err)
 
 	// create key in db for our brave.com location
-	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]))
+	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]), nil)
 	suite.Require().NoError(err)
 }
 
@@ -1462,7 +1474,7 @@ func (suite *ControllersTestSuite) TestE2E_CreateOrderCreds_StoreSignedOrderCred
 	ctx = context.WithValue(ctx, appctx.SkusEnableStoreSignedOrderCredsConsumer, true)
 	ctx = context.WithValue(ctx, appctx.SkusNumberStoreSignedOrderCredsConsumer, 1)
 
-	skuService, err := InitService(ctx, suite.storage, nil, repository.NewOrder(), repository.NewOrderItem(), repository.NewIssuer(), repository.NewOrderPayHistory(), repository.NewTLV2())
+	skuService, err := InitService(ctx, suite.storage, nil, repository.NewOrder(), repository.NewOrderItem(), repository.NewIssuer(), repository.NewOrderPayHistory(), repository.NewTLV2(), repository.NewWebhookInbox(), repository.NewTransaction(), repository.NewOrderSeat(), repository.NewCoupon(), repository.NewOrderEvent(), repository.NewOrderDunning(), repository.NewStripeSubCancel(), repository.NewSKUCatalog(), repository.NewSKUPrice(), repository.NewPortal(), repository.NewMerchantKey(), repository.NewMerchantWebhook(), repository.NewSpend(), repository.NewConsumerOffset(), repository.NewIssuerKey(), repository.NewTLV1Migration())
 	suite.Require().NoError(err)
 
 	authMwr := NewAuthMwr(skuService)
@@ -1613,7 +1625,7 @@ func (suite *ControllersTestSuite) TestE2E_CreateOrderCreds_StoreSignedOrderCred
 	ctx = context.WithValue(ctx, appctx.SkusEnableStoreSignedOrderCredsConsumer, true)
 	ctx = context.WithValue(ctx, appctx.SkusNumberStoreSignedOrderCredsConsumer, 1)
 
-	skuService, err := InitService(ctx, suite.storage, nil, repository.NewOrder(), repository.NewOrderItem(), repository.NewIssuer(), repository.NewOrderPayHistory(), repository.NewTLV2())
+	skuService, err := InitService(ctx, suite.storage, nil, repository.NewOrder(), repository.NewOrderItem(), repository.NewIssuer(), repository.NewOrderPayHistory(), repository.NewTLV2(), repository.NewWebhookInbox(), repository.NewTransaction(), repository.NewOrderSeat(), repository.NewCoupon(), repository.NewOrderEvent(), repository.NewOrderDunning(), repository.NewStripeSubCancel(), repository.NewSKUCatalog(), repository.NewSKUPrice(), repository.NewPortal(), repository.NewMerchantKey(), repository.NewMerchantWebhook(), repository.NewSpend(), repository.NewConsumerOffset(), repository.NewIssuerKey(), repository.NewTLV1Migration())
 	suite.Require().NoError(err)
 
 	authMwr := NewAuthMwr(skuService)
@@ -1767,12 +1779,14 @@ func (suite *ControllersTestSuite) TestCreateOrder_RadomPayable() {
 
 	sessID := uuid.NewV4().String()
 
-	suite.service.radomClient = &mockRadomClient{
-		fnCreateCheckoutSession: func(ctx context.Context, creq *radom.CheckoutSessionRequest) (radom.CheckoutSessionResponse, error) {
-			return radom.CheckoutSessionResponse{
-				SessionID: sessID,
-			}, nil
-		}}
+	suite.service.payProcs.set(&radomProcessor{
+		cl: &mockRadomClient{
+			fnCreateCheckoutSession: func(ctx context.Context, creq *radom.CheckoutSessionRequest) (radom.CheckoutSessionResponse, error) {
+				return radom.CheckoutSessionResponse{
+					SessionID: sessID,
+				}, nil
+			}},
+	})
 
 	oreq := model.CreateOrderRequestNew{
 		Email:    "example@example.com",
@@ -1807,7 +1821,7 @@ func (suite *ControllersTestSuite) TestCreateOrder_RadomPayable() {
 
 	rw := httptest.NewRecorder()
 
-	oh := handlers.AppHandler(handler.NewOrder(suite.service).CreateNew)
+	oh := handlers.AppHandler(handler.NewOrder(suite.service, handler.GeoProxy{}).CreateNew)
 	svr := &http.Server{Addr: ":8080", Handler: oh}
 
 	svr.Handler.ServeHTTP(rw, req)
@@ -1841,26 +1855,30 @@ func (suite *ControllersTestSuite) TestWebhook_Radom() {
 
 	subID := uuid.NewV4()
 
-	suite.service.radomClient = &mockRadomClient{
-		fnGetSubscription: func(ctx context.Context, subID string) (*radom.SubscriptionResponse, error) {
-			return &radom.SubscriptionResponse{
-				ID:                subID,
-				NextBillingDateAt: "2023-06-12T09:38:13.604410Z",
-				Payments: []radom.Payment{
-					{
-						Date: "2023-06-12T09:38:13.604410Z",
+	radProc := &radomProcessor{
+		cl: &mockRadomClient{
+			fnGetSubscription: func(ctx context.Context, subID string) (*radom.SubscriptionResponse, error) {
+				return &radom.SubscriptionResponse{
+					ID:                subID,
+					NextBillingDateAt: "2023-06-12T09:38:13.604410Z",
+					Payments: []radom.Payment{
+						{
+							Date: "2023-06-12T09:38:13.604410Z",
+						},
 					},
-				},
-			}, nil
+				}, nil
+			},
 		},
+		auth: radom.NewMessageAuthenticator(radom.MessageAuthConfig{
+			Token:   []byte("test-token"),
+			Enabled: true,
+		}),
 	}
 
-	suite.service.radomAuth = radom.NewMessageAuthenticator(radom.MessageAuthConfig{
-		Token:   []byte("test-token"),
-		Enabled: true,
-	})
+	suite.service.payProcs.set(radProc)
 
 	suite.service.payHistRepo = repository.NewOrderPayHistory()
+	suite.service.webhookInboxRepo = repository.NewWebhookInbox()
 
 	event := &radom.Notification{
 		EventData: &radom.EventData{
@@ -1889,13 +1907,17 @@ func (suite *ControllersTestSuite) TestWebhook_Radom() {
 
 	rw := httptest.NewRecorder()
 
-	oh := handleRadomWebhook(suite.service)
+	oh := handlePaymentWebhook(suite.service, radProc)
 	svr := &http.Server{Addr: ":8080", Handler: oh}
 
 	svr.Handler.ServeHTTP(rw, req)
 
 	suite.Require().Equal(http.StatusOK, rw.Code)
 
+	attempted, err := suite.service.RunNextWebhookInboxJob(ctx)
+	suite.Require().NoError(err)
+	suite.Require().True(attempted)
+
 	order, err := suite.service.orderRepo.Get(ctx, suite.service.Datastore.RawDB(), res.ID)
 	suite.Require().NoError(err)
 
@@ -1918,7 +1940,7 @@ func (suite *ControllersTestSuite) TestOrder_Cancel() {
 
 	rw := httptest.NewRecorder()
 
-	oh := handler.NewOrder(suite.service)
+	oh := handler.NewOrder(suite.service, handler.GeoProxy{})
 
 	rtr := chi.NewRouter()
 	subr := chi.NewRouter()
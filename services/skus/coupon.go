@@ -0,0 +1,21 @@
+package skus
+
+import (
+	"context"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+// CreateCoupon creates a promo code which can be applied when creating an order.
+func (s *Service) CreateCoupon(ctx context.Context, req *model.CreateCouponRequest) (*model.Coupon, error) {
+	if !req.IsValid() {
+		return nil, model.ErrCouponInvalid
+	}
+
+	return s.couponRepo.Create(ctx, s.Datastore.RawDB(), req)
+}
+
+// GetCoupon returns the coupon with the code.
+func (s *Service) GetCoupon(ctx context.Context, code string) (*model.Coupon, error) {
+	return s.couponRepo.GetByCode(ctx, s.Datastore.RawDB(), code)
+}
@@ -0,0 +1,135 @@
+package skus
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	"github.com/brave-intl/bat-go/libs/handlers"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+// verifyCredentialBatch verifies creds, and returns a result for each of them in the same order.
+//
+// The merchant and caveat checks are the same as for a single credential.
+// Blinded-token credentials are redeemed in one call per type and issuer.
+// Bulk redemption is all or nothing.
+// When it is rejected, credentials from the group are redeemed one by one to find out which are invalid.
+// When the outcome is unknown, e.g. on a timeout, the group fails as a whole, as some credentials might have been redeemed.
+func (s *Service) verifyCredentialBatch(ctx context.Context, creds []credential) []model.VerifyCredentialResult {
+	result := make([]model.VerifyCredentialResult, len(creds))
+
+	decoded := make([]*cbr.CredentialRedemption, len(creds))
+	groups := make(map[credBatchGroup][]int)
+
+	var keys []credBatchGroup
+
+	for i := range creds {
+		if aerr := checkCredentialAuth(ctx, creds[i]); aerr != nil {
+			result[i] = newVerifyCredResultErr(aerr)
+			continue
+		}
+
+		switch kind := creds[i].GetType(); kind {
+		case timeLimited:
+			if aerr := s.checkTimeLimitedV1Credential(ctx, creds[i]); aerr != nil {
+				result[i] = newVerifyCredResultErr(aerr)
+				continue
+			}
+
+			result[i] = model.VerifyCredentialResult{Status: http.StatusOK, Verified: true}
+
+		case singleUse, timeLimitedV2:
+			dcred, aerr := decodeBlindedCred(ctx, creds[i])
+			if aerr != nil {
+				result[i] = newVerifyCredResultErr(aerr)
+				continue
+			}
+
+			key := credBatchGroup{kind: kind, issuer: dcred.Issuer}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+
+			decoded[i] = dcred
+			groups[key] = append(groups[key], i)
+
+		default:
+			result[i] = newVerifyCredResultErr(handlers.WrapError(nil, "Unknown credential type", http.StatusBadRequest))
+		}
+	}
+
+	for _, key := range keys {
+		idxs := groups[key]
+
+		bulk := make([]cbr.CredentialRedemption, 0, len(idxs))
+		for _, j := range idxs {
+			bulk = append(bulk, *decoded[j])
+		}
+
+		// The issuer is used as the payload, same as for a single credential.
+		err := s.cbClient.RedeemCredentials(ctx, bulk, key.issuer)
+		if err == nil {
+			for _, j := range idxs {
+				result[j] = newBlindedVerifyCredResult(key.kind, decoded[j], nil)
+			}
+
+			continue
+		}
+
+		if !isBulkRedeemRejected(err) {
+			for _, j := range idxs {
+				result[j] = newBlindedVerifyCredResult(key.kind, decoded[j], err)
+			}
+
+			continue
+		}
+
+		for _, j := range idxs {
+			result[j] = newBlindedVerifyCredResult(key.kind, decoded[j], s.tryRedeemBlindedCred(ctx, key.kind, decoded[j]))
+		}
+	}
+
+	return result
+}
+
+type credBatchGroup struct {
+	kind   string
+	issuer string
+}
+
+// isBulkRedeemRejected reports whether the bulk redemption was rejected, so none of the credentials were redeemed.
+func isBulkRedeemRejected(err error) bool {
+	msg := err.Error()
+
+	return msg == cbr.ErrDupRedeem.Error() || msg == cbr.ErrBadRequest.Error()
+}
+
+// newBlindedVerifyCredResult reports the outcome of a redemption the same way handleRedeemFnError does.
+func newBlindedVerifyCredResult(kind string, cred *cbr.CredentialRedemption, err error) model.VerifyCredentialResult {
+	if err == nil {
+		result := model.VerifyCredentialResult{Status: http.StatusOK, Verified: true}
+		if kind == timeLimitedV2 {
+			result.ID = cred.TokenPreimage
+		}
+
+		return result
+	}
+
+	msg := err.Error()
+
+	if kind == timeLimitedV2 && msg == cbr.ErrDupRedeem.Error() {
+		return model.VerifyCredentialResult{Status: http.StatusOK, Verified: true, ID: cred.TokenPreimage, Duplicate: true}
+	}
+
+	if msg == cbr.ErrDupRedeem.Error() || msg == cbr.ErrBadRequest.Error() {
+		return model.VerifyCredentialResult{Status: http.StatusForbidden, Error: "invalid credentials"}
+	}
+
+	return model.VerifyCredentialResult{Status: http.StatusInternalServerError, Error: "Error verifying credentials"}
+}
+
+func newVerifyCredResultErr(aerr *handlers.AppError) model.VerifyCredentialResult {
+	return model.VerifyCredentialResult{Status: aerr.Code, Error: aerr.Message}
+}
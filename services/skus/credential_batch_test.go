@@ -0,0 +1,245 @@
+package skus
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	mock_cbr "github.com/brave-intl/bat-go/libs/clients/cbr/mock"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+func TestService_verifyCredentialBatch(t *testing.T) {
+	newCred := func(t *testing.T, kind, merchID, sku, preimage string) credential {
+		issuer, err := encodeIssuerID(merchID, sku)
+		must.NoError(t, err)
+
+		raw, err := json.Marshal(&cbr.CredentialRedemption{Issuer: issuer, TokenPreimage: preimage, Signature: "signature"})
+		must.NoError(t, err)
+
+		result := &model.VerifyCredentialRequestV1{
+			Type:         kind,
+			SKU:          sku,
+			MerchantID:   merchID,
+			Presentation: base64.StdEncoding.EncodeToString(raw),
+		}
+
+		return result
+	}
+
+	dupErr := func() error { return cbr.ErrDupRedeem }
+
+	t.Run("mixed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.WithValue(context.Background(), merchantCtxKey{}, "brave.com")
+
+		cbClient := mock_cbr.NewMockClient(ctrl)
+
+		cbClient.EXPECT().RedeemCredentials(gomock.Any(), gomock.Len(2), "brave.com?sku=brave-search-premium").Return(nil)
+		cbClient.EXPECT().RedeemCredentials(gomock.Any(), gomock.Len(1), "brave.com?sku=brave-vpn-premium").Return(dupErr())
+		cbClient.EXPECT().RedeemCredentialV3(gomock.Any(), "brave.com?sku=brave-vpn-premium", "preimage_03", "signature", "brave.com?sku=brave-vpn-premium").Return(dupErr())
+
+		svc := &Service{cbClient: cbClient}
+
+		creds := []credential{
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_01"),
+			newCred(t, singleUse, "other.com", "brave-search-premium", "preimage_other"),
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_02"),
+			newCred(t, timeLimitedV2, "brave.com", "brave-vpn-premium", "preimage_03"),
+			newCred(t, "unknown", "brave.com", "brave-vpn-premium", "preimage_04"),
+		}
+
+		actual := svc.verifyCredentialBatch(ctx, creds)
+
+		exp := []model.VerifyCredentialResult{
+			{Status: http.StatusOK, Verified: true},
+			{Status: http.StatusForbidden, Error: "Verify request merchant does not match authentication"},
+			{Status: http.StatusOK, Verified: true},
+			{Status: http.StatusOK, Verified: true, ID: "preimage_03", Duplicate: true},
+			{Status: http.StatusBadRequest, Error: "Unknown credential type"},
+		}
+
+		should.Equal(t, exp, actual)
+	})
+
+	t.Run("bulk_failed_fallback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.WithValue(context.Background(), merchantCtxKey{}, "brave.com")
+
+		const issuer = "brave.com?sku=brave-search-premium"
+
+		cbClient := mock_cbr.NewMockClient(ctrl)
+
+		cbClient.EXPECT().RedeemCredentials(gomock.Any(), gomock.Len(2), issuer).Return(dupErr())
+		cbClient.EXPECT().RedeemCredential(gomock.Any(), issuer, "preimage_01", "signature", issuer).Return(nil)
+		cbClient.EXPECT().RedeemCredential(gomock.Any(), issuer, "preimage_02", "signature", issuer).Return(dupErr())
+
+		svc := &Service{cbClient: cbClient}
+
+		creds := []credential{
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_01"),
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_02"),
+		}
+
+		actual := svc.verifyCredentialBatch(ctx, creds)
+
+		exp := []model.VerifyCredentialResult{
+			{Status: http.StatusOK, Verified: true},
+			{Status: http.StatusForbidden, Error: "invalid credentials"},
+		}
+
+		should.Equal(t, exp, actual)
+	})
+
+	t.Run("tlv2_grouped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.WithValue(context.Background(), merchantCtxKey{}, "brave.com")
+
+		const issuer = "brave.com?sku=brave-vpn-premium"
+
+		cbClient := mock_cbr.NewMockClient(ctrl)
+
+		cbClient.EXPECT().RedeemCredentials(gomock.Any(), gomock.Len(2), issuer).Return(nil)
+
+		svc := &Service{cbClient: cbClient}
+
+		creds := []credential{
+			newCred(t, timeLimitedV2, "brave.com", "brave-vpn-premium", "preimage_01"),
+			newCred(t, timeLimitedV2, "brave.com", "brave-vpn-premium", "preimage_02"),
+		}
+
+		actual := svc.verifyCredentialBatch(ctx, creds)
+
+		exp := []model.VerifyCredentialResult{
+			{Status: http.StatusOK, Verified: true, ID: "preimage_01"},
+			{Status: http.StatusOK, Verified: true, ID: "preimage_02"},
+		}
+
+		should.Equal(t, exp, actual)
+	})
+
+	t.Run("bulk_unknown_no_fallback", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.WithValue(context.Background(), merchantCtxKey{}, "brave.com")
+
+		const issuer = "brave.com?sku=brave-search-premium"
+
+		cbClient := mock_cbr.NewMockClient(ctrl)
+
+		cbClient.EXPECT().RedeemCredentials(gomock.Any(), gomock.Len(2), issuer).Return(context.DeadlineExceeded)
+
+		svc := &Service{cbClient: cbClient}
+
+		creds := []credential{
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_01"),
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_02"),
+		}
+
+		actual := svc.verifyCredentialBatch(ctx, creds)
+
+		exp := []model.VerifyCredentialResult{
+			{Status: http.StatusInternalServerError, Error: "Error verifying credentials"},
+			{Status: http.StatusInternalServerError, Error: "Error verifying credentials"},
+		}
+
+		should.Equal(t, exp, actual)
+	})
+
+	t.Run("sku_caveat", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.WithValue(context.Background(), merchantCtxKey{}, "brave.com")
+		ctx = context.WithValue(ctx, caveatsCtxKey{}, map[string]string{"sku": "brave-vpn-premium"})
+
+		svc := &Service{cbClient: mock_cbr.NewMockClient(ctrl)}
+
+		creds := []credential{
+			newCred(t, singleUse, "brave.com", "brave-search-premium", "preimage_01"),
+		}
+
+		actual := svc.verifyCredentialBatch(ctx, creds)
+
+		exp := []model.VerifyCredentialResult{
+			{Status: http.StatusForbidden, Error: "Verify request sku does not match authentication"},
+		}
+
+		should.Equal(t, exp, actual)
+	})
+}
+
+func TestNewBlindedVerifyCredResult(t *testing.T) {
+	type tcGiven struct {
+		kind string
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   model.VerifyCredentialResult
+	}
+
+	tests := []testCase{
+		{
+			name:  "single_use_verified",
+			given: tcGiven{kind: singleUse},
+			exp:   model.VerifyCredentialResult{Status: http.StatusOK, Verified: true},
+		},
+
+		{
+			name:  "tlv2_verified",
+			given: tcGiven{kind: timeLimitedV2},
+			exp:   model.VerifyCredentialResult{Status: http.StatusOK, Verified: true, ID: "token_preimage"},
+		},
+
+		{
+			name:  "tlv2_duplicate",
+			given: tcGiven{kind: timeLimitedV2, err: cbr.ErrDupRedeem},
+			exp:   model.VerifyCredentialResult{Status: http.StatusOK, Verified: true, ID: "token_preimage", Duplicate: true},
+		},
+
+		{
+			name:  "single_use_duplicate",
+			given: tcGiven{kind: singleUse, err: cbr.ErrDupRedeem},
+			exp:   model.VerifyCredentialResult{Status: http.StatusForbidden, Error: "invalid credentials"},
+		},
+
+		{
+			name:  "bad_request",
+			given: tcGiven{kind: timeLimitedV2, err: cbr.ErrBadRequest},
+			exp:   model.VerifyCredentialResult{Status: http.StatusForbidden, Error: "invalid credentials"},
+		},
+
+		{
+			name:  "other_error",
+			given: tcGiven{kind: singleUse, err: model.Error("something_went_wrong")},
+			exp:   model.VerifyCredentialResult{Status: http.StatusInternalServerError, Error: "Error verifying credentials"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := newBlindedVerifyCredResult(tc.given.kind, &cbr.CredentialRedemption{TokenPreimage: "token_preimage"}, tc.given.err)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
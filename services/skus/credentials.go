@@ -168,9 +168,11 @@ func (s *Service) CreateIssuerV3(ctx context.Context, dbi sqlx.QueryerContext, m
 		return errInvalidIssuerResp
 	}
 
+	// Keys of the issuer are recorded by the next key check.
 	if _, err := s.issuerRepo.Create(ctx, dbi, model.IssuerNew{
 		MerchantID: issuerResp.Name,
 		PublicKey:  issuerResp.PublicKey,
+		Version:    3,
 	}); err != nil {
 		return fmt.Errorf("error creating new issuer: %w", err)
 	}
@@ -231,6 +233,11 @@ type TimeLimitedCreds struct {
 //
 // It handles only paid orders.
 func (s *Service) CreateOrderItemCredentials(ctx context.Context, orderID, itemID, requestID uuid.UUID, blindedCreds []string) error {
+	return s.createOrderItemCreds(ctx, orderID, itemID, requestID, uuid.Nil, blindedCreds)
+}
+
+// createOrderItemCreds creates credentials for the purchaser of the order, or for the seat if seatID is not uuid.Nil.
+func (s *Service) createOrderItemCreds(ctx context.Context, orderID, itemID, requestID, seatID uuid.UUID, blindedCreds []string) error {
 	order, err := s.getOrderFull(ctx, orderID)
 	if err != nil {
 		return fmt.Errorf("error retrieving order: %w", err)
@@ -245,12 +252,31 @@ func (s *Service) CreateOrderItemCredentials(ctx context.Context, orderID, itemI
 		return errItemDoesNotExist
 	}
 
+	// Time limited v1 items of a migrating sku mint time limited v2 batches.
+	mig, err := s.tlv1Migration(ctx, s.Datastore.RawDB(), order.MerchantID, item)
+	if err != nil {
+		return fmt.Errorf("error getting tlv1 migration: %w", err)
+	}
+
+	if mig != nil {
+		item = migratedTLV1Item(item, mig)
+
+		if err := s.CreateIssuerV3(ctx, s.Datastore.RawDB(), order.MerchantID, item, mig.IssuerConfig()); err != nil {
+			return fmt.Errorf("error creating issuer for merchantID %s and sku %s: %w", order.MerchantID, item.SKU, err)
+		}
+	}
+
+	// Seats are only supported for time-limited-v2 creds, as they are issued in batches per device.
+	if !uuid.Equal(seatID, uuid.Nil) && item.CredentialType != timeLimitedV2 {
+		return model.ErrUnsupportedCredType
+	}
+
 	nbcreds := len(blindedCreds)
 	if nbcreds == 0 {
 		return model.ErrTLV2InvalidCredNum
 	}
 
-	if err := s.doCredentialsExist(ctx, requestID, item, blindedCreds[0]); err != nil {
+	if err := s.doCredentialsExist(ctx, requestID, seatID, item, blindedCreds[0]); err != nil {
 		if errors.Is(err, errCredsAlreadySubmitted) {
 			return nil
 		}
@@ -262,7 +288,11 @@ func (s *Service) CreateOrderItemCredentials(ctx context.Context, orderID, itemI
 	// If yes, then truncate credentials to the desired number, 576.
 	creds := truncateTLV2BCreds(order, item, nbcreds, blindedCreds)
 
-	if err := checkNumBlindedCreds(order, item, len(creds)); err != nil {
+	if mig != nil {
+		if err := checkNumMigratedBlindedCreds(mig, len(creds)); err != nil {
+			return err
+		}
+	} else if err := checkNumBlindedCreds(order, item, len(creds)); err != nil {
 		return err
 	}
 
@@ -283,6 +313,10 @@ func (s *Service) CreateOrderItemCredentials(ctx context.Context, orderID, itemI
 		CredentialType: item.CredentialType,
 	}
 
+	if !uuid.Equal(seatID, uuid.Nil) {
+		metadata.SeatID = &seatID
+	}
+
 	associatedData, err := json.Marshal(metadata)
 	if err != nil {
 		return fmt.Errorf("error serializing associated data: %w", err)
@@ -307,7 +341,7 @@ func (s *Service) CreateOrderItemCredentials(ctx context.Context, orderID, itemI
 	return nil
 }
 
-func (s *Service) doCredentialsExist(ctx context.Context, requestID uuid.UUID, item *model.OrderItem, firstBCred string) error {
+func (s *Service) doCredentialsExist(ctx context.Context, requestID, seatID uuid.UUID, item *model.OrderItem, firstBCred string) error {
 	switch item.CredentialType {
 	case timeLimitedV2:
 		// NOTE: There was a possible race condition that would allow exceeding limits on the number of cred batches.
@@ -315,19 +349,19 @@ func (s *Service) doCredentialsExist(ctx context.Context, requestID uuid.UUID, i
 		// - checking the number of active batches before accepting a request to create creds;
 		// - checking the number of active batches before inserting the signed creds.
 
-		return s.doTLV2Exist(ctx, requestID, item, firstBCred)
+		return s.doTLV2Exist(ctx, requestID, seatID, item, firstBCred)
 	default:
 		return s.doCredsExist(ctx, item)
 	}
 }
 
-func (s *Service) doTLV2Exist(ctx context.Context, reqID uuid.UUID, item *model.OrderItem, firstBCred string) error {
+func (s *Service) doTLV2Exist(ctx context.Context, reqID, seatID uuid.UUID, item *model.OrderItem, firstBCred string) error {
 	now := time.Now()
 
-	return s.doTLV2ExistTxTime(ctx, s.Datastore.RawDB(), reqID, item, firstBCred, now, now)
+	return s.doTLV2ExistTxTime(ctx, s.Datastore.RawDB(), reqID, seatID, item, firstBCred, now, now)
 }
 
-func (s *Service) doTLV2ExistTxTime(ctx context.Context, dbi sqlx.QueryerContext, reqID uuid.UUID, item *model.OrderItem, firstBCred string, from, to time.Time) error {
+func (s *Service) doTLV2ExistTxTime(ctx context.Context, dbi sqlx.QueryerContext, reqID, seatID uuid.UUID, item *model.OrderItem, firstBCred string, from, to time.Time) error {
 	if item.CredentialType != timeLimitedV2 {
 		return model.ErrUnsupportedCredType
 	}
@@ -348,7 +382,7 @@ func (s *Service) doTLV2ExistTxTime(ctx context.Context, dbi sqlx.QueryerContext
 		return errCredsAlreadySubmittedMismatch
 	}
 
-	nact, err := s.tlv2Repo.UniqBatches(ctx, dbi, item.OrderID, item.ID, from, to)
+	nact, err := uniqTLV2Batches(ctx, s.tlv2Repo, dbi, item.OrderID, item.ID, seatID, from, to)
 	if err != nil {
 		return err
 	}
@@ -584,16 +618,70 @@ func encodeIssuerID(merchantID, sku string) (string, error) {
 }
 
 // SignedOrderCredentialsHandler handles requests for signing credentials.
+//
+// Offsets of handled messages are recorded in the same transaction as the results,
+// so that messages replayed after a restart of the consumer are skipped.
 type SignedOrderCredentialsHandler struct {
-	decoder   Decoder
-	datastore Datastore
-	tlv2Repo  tlv2Store
+	decoder     Decoder
+	datastore   Datastore
+	tlv2Repo    tlv2Store
+	seatRepo    orderSeatStore
+	orderEvRepo orderEventStore
+	offsetRepo  consumerOffsetStore
+	groupID     string
 }
 
 // Handle processes Kafka message of type SigningOrderResult.
 //
 // TODO(pavelb): Refactor this to not require real database for testing, and use deterministic time.
 func (h *SignedOrderCredentialsHandler) Handle(ctx context.Context, msg kafka.Message) error {
+	ctx, tx, rollback, commit, err := datastore.GetTx(ctx, h.datastore)
+	if err != nil {
+		return fmt.Errorf("failed to open tx: %w", err)
+	}
+	defer rollback()
+
+	handled, err := h.isHandled(ctx, tx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to get consumer offset: %w", err)
+	}
+
+	if handled {
+		return nil
+	}
+
+	now := time.Now()
+
+	if err := h.handleTx(ctx, tx, msg, now); err != nil {
+		return err
+	}
+
+	if err := h.offsetRepo.Set(ctx, tx, h.groupID, msg.Topic, msg.Partition, msg.Offset, now); err != nil {
+		return fmt.Errorf("error recording consumer offset: %w", err)
+	}
+
+	if err := commit(); err != nil {
+		return fmt.Errorf("error commiting signing order request outbox: %w", err)
+	}
+
+	return nil
+}
+
+// isHandled reports whether the message has been handled before, and locks the offset of its partition.
+func (h *SignedOrderCredentialsHandler) isHandled(ctx context.Context, dbi sqlx.QueryerContext, msg kafka.Message) (bool, error) {
+	last, err := h.offsetRepo.GetForUpdate(ctx, dbi, h.groupID, msg.Topic, msg.Partition)
+	if err != nil {
+		if errors.Is(err, model.ErrConsumerOffsetNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return msg.Offset <= last, nil
+}
+
+func (h *SignedOrderCredentialsHandler) handleTx(ctx context.Context, tx *sqlx.Tx, msg kafka.Message, now time.Time) error {
 	soresult, err := h.decoder.Decode(msg)
 	if err != nil {
 		return fmt.Errorf("error decoding message key %s partition %d offset %d: %w", string(msg.Key), msg.Partition, msg.Offset, err)
@@ -604,12 +692,6 @@ func (h *SignedOrderCredentialsHandler) Handle(ctx context.Context, msg kafka.Me
 		return fmt.Errorf("error getting uuid from signed order request: %w", err)
 	}
 
-	ctx, tx, rollback, commit, err := datastore.GetTx(ctx, h.datastore)
-	if err != nil {
-		return fmt.Errorf("failed to open tx: %w", err)
-	}
-	defer rollback()
-
 	// Check to see if the signing request has not been deleted whilst signing the request.
 	sor, err := h.datastore.GetSigningOrderRequestOutboxByRequestID(ctx, tx, requestID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -620,9 +702,28 @@ func (h *SignedOrderCredentialsHandler) Handle(ctx context.Context, msg kafka.Me
 		return nil
 	}
 
-	now := time.Now()
+	seatID, err := signingResultSeatID(soresult)
+	if err != nil {
+		return fmt.Errorf("error getting seat from signed order request: %w", err)
+	}
 
-	nact, err := h.tlv2Repo.UniqBatches(ctx, tx, sor.OrderID, sor.ItemID, now, now)
+	// The seat might have been revoked whilst signing the request.
+	if !uuid.Equal(seatID, uuid.Nil) {
+		seat, err := h.seatRepo.Get(ctx, tx, seatID)
+		if err != nil {
+			return fmt.Errorf("failed to get seat %s: %w", seatID, err)
+		}
+
+		if !seat.IsActive() {
+			if err := h.datastore.UpdateSigningOrderRequestOutboxTx(ctx, tx, requestID, now); err != nil {
+				return fmt.Errorf("error updating signing order request outbox: %w", err)
+			}
+
+			return nil
+		}
+	}
+
+	nact, err := uniqTLV2Batches(ctx, h.tlv2Repo, tx, sor.OrderID, sor.ItemID, seatID, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to get number of active batches: %w", err)
 	}
@@ -636,12 +737,12 @@ func (h *SignedOrderCredentialsHandler) Handle(ctx context.Context, msg kafka.Me
 		return fmt.Errorf("error inserting signed order credentials: %w", err)
 	}
 
-	if err := h.datastore.UpdateSigningOrderRequestOutboxTx(ctx, tx, requestID, now); err != nil {
-		return fmt.Errorf("error updating signing order request outbox: %w", err)
+	if err := h.orderEvRepo.Insert(ctx, tx, sor.OrderID, model.OrderEventCredsSigned, now); err != nil {
+		return fmt.Errorf("error recording credentials signed event: %w", err)
 	}
 
-	if err := commit(); err != nil {
-		return fmt.Errorf("error commiting signing order request outbox: %w", err)
+	if err := h.datastore.UpdateSigningOrderRequestOutboxTx(ctx, tx, requestID, now); err != nil {
+		return fmt.Errorf("error updating signing order request outbox: %w", err)
 	}
 
 	return nil
@@ -804,6 +905,35 @@ func doItemsHaveSUOrTlv2(items []model.OrderItem) (bool, bool) {
 	return hasSingleUse, hasTlv2
 }
 
+// uniqTLV2Batches returns the number of active batches for the purchaser, or for the seat if seatID is not uuid.Nil.
+//
+// Each seat has its own limit, so that sharing an order does not exhaust the purchaser's batches.
+func uniqTLV2Batches(ctx context.Context, repo tlv2Store, dbi sqlx.QueryerContext, orderID, itemID, seatID uuid.UUID, from, to time.Time) (int, error) {
+	if uuid.Equal(seatID, uuid.Nil) {
+		return repo.UniqBatches(ctx, dbi, orderID, itemID, from, to)
+	}
+
+	return repo.UniqSeatBatches(ctx, dbi, seatID, itemID, from, to)
+}
+
+// signingResultSeatID returns the seat for which creds have been signed, or uuid.Nil if they were signed for the purchaser.
+func signingResultSeatID(soresult *SigningOrderResult) (uuid.UUID, error) {
+	if len(soresult.Data) == 0 {
+		return uuid.Nil, nil
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(soresult.Data[0].AssociatedData, &metadata); err != nil {
+		return uuid.Nil, err
+	}
+
+	if metadata.SeatID == nil {
+		return uuid.Nil, nil
+	}
+
+	return *metadata.SeatID, nil
+}
+
 func checkTLV2BatchLimit(lim, nact int) error {
 	if nact >= lim {
 		return ErrCredsAlreadyExist
@@ -1,15 +1,20 @@
 package skus
 
 import (
+	"context"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
 	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
 
 	"github.com/brave-intl/bat-go/libs/datastore"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
 )
 
 func TestCheckTLV2BatchLimit(t *testing.T) {
@@ -253,3 +258,127 @@ func genNumStrings(n int) []string {
 
 	return result
 }
+
+func TestUniqTLV2Batches(t *testing.T) {
+	type tcGiven struct {
+		seatID uuid.UUID
+		repo   *repository.MockTLV2
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name: "purchaser",
+			given: tcGiven{
+				repo: &repository.MockTLV2{
+					FnUniqBatches: func(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID uuid.UUID, from, to time.Time) (int, error) {
+						return 1, nil
+					},
+
+					FnUniqSeatBatches: func(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error) {
+						return 2, nil
+					},
+				},
+			},
+			exp: 1,
+		},
+
+		{
+			name: "seat",
+			given: tcGiven{
+				seatID: uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+				repo: &repository.MockTLV2{
+					FnUniqBatches: func(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID uuid.UUID, from, to time.Time) (int, error) {
+						return 1, nil
+					},
+
+					FnUniqSeatBatches: func(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error) {
+						if !uuid.Equal(seatID, uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000"))) {
+							return 0, model.Error("unexpected_seat_id")
+						}
+
+						return 2, nil
+					},
+				},
+			},
+			exp: 2,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Now()
+
+			actual, err := uniqTLV2Batches(context.Background(), tc.given.repo, nil, uuid.NewV4(), uuid.NewV4(), tc.given.seatID, now, now)
+			must.Equal(t, nil, err)
+
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestSigningResultSeatID(t *testing.T) {
+	type tcExpected struct {
+		val     uuid.UUID
+		mustErr bool
+	}
+
+	type testCase struct {
+		name  string
+		given *SigningOrderResult
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "no_data",
+			given: &SigningOrderResult{},
+		},
+
+		{
+			name: "invalid_data",
+			given: &SigningOrderResult{
+				Data: []SignedOrder{{AssociatedData: []byte("not_json")}},
+			},
+			exp: tcExpected{mustErr: true},
+		},
+
+		{
+			name: "no_seat",
+			given: &SigningOrderResult{
+				Data: []SignedOrder{{AssociatedData: []byte(`{"orderId": "facade00-0000-4000-a000-000000000000"}`)}},
+			},
+		},
+
+		{
+			name: "seat",
+			given: &SigningOrderResult{
+				Data: []SignedOrder{{AssociatedData: []byte(`{"orderId": "facade00-0000-4000-a000-000000000000", "seatId": "decade00-0000-4000-a000-000000000000"}`)}},
+			},
+			exp: tcExpected{val: uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000"))},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := signingResultSeatID(tc.given)
+			if tc.exp.mustErr {
+				should.Error(t, err)
+				return
+			}
+
+			must.Equal(t, nil, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
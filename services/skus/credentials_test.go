@@ -99,16 +99,21 @@ func (suite *CredentialsTestSuite) TestSignedOrderCredentialsHandler_KafkaDuplic
 		binary, err := codec.BinaryFromNative(nil, native)
 		suite.Require().NoError(err)
 
+		// Each duplicate arrives on its own partition, as if read by different consumers.
 		kafkaMessages = append(kafkaMessages, kafka.Message{
-			Topic: kafkaUnsignedOrderCredsTopic,
-			Value: binary,
+			Topic:     kafkaUnsignedOrderCredsTopic,
+			Partition: i,
+			Value:     binary,
 		})
 	}
 
 	handler := &SignedOrderCredentialsHandler{
-		decoder:   &SigningOrderResultDecoder{codec: codec},
-		datastore: suite.storage,
-		tlv2Repo:  repository.NewTLV2(),
+		decoder:     &SigningOrderResultDecoder{codec: codec},
+		datastore:   suite.storage,
+		tlv2Repo:    repository.NewTLV2(),
+		orderEvRepo: repository.NewOrderEvent(),
+		offsetRepo:  repository.NewConsumerOffset(),
+		groupID:     test.RandomString(),
 	}
 
 	// Send them to handler with varied times and routines to mock different consumers.
@@ -174,15 +179,19 @@ func (suite *CredentialsTestSuite) TestSignedOrderCredentialsHandler_RequestDupl
 		suite.Require().NoError(err)
 
 		msgs = append(msgs, kafka.Message{
-			Topic: kafkaUnsignedOrderCredsTopic,
-			Value: binary,
+			Topic:     kafkaUnsignedOrderCredsTopic,
+			Partition: i,
+			Value:     binary,
 		})
 	}
 
 	handler := &SignedOrderCredentialsHandler{
-		decoder:   &SigningOrderResultDecoder{codec: codec},
-		datastore: suite.storage,
-		tlv2Repo:  repository.NewTLV2(),
+		decoder:     &SigningOrderResultDecoder{codec: codec},
+		datastore:   suite.storage,
+		tlv2Repo:    repository.NewTLV2(),
+		orderEvRepo: repository.NewOrderEvent(),
+		offsetRepo:  repository.NewConsumerOffset(),
+		groupID:     test.RandomString(),
 	}
 
 	// Send them to handler with varied times and routines to mock different consumers.
@@ -223,6 +232,71 @@ func (suite *CredentialsTestSuite) TestSignedOrderCredentialsHandler_RequestDupl
 	}
 }
 
+func (suite *CredentialsTestSuite) TestSignedOrderCredentialsHandler_ReplayedOffsets() {
+	ctx := context.WithValue(context.Background(), appctx.WhitelistSKUsCTXKey, []string{devFreeTimeLimitedV2})
+	order, issuer := createOrderAndIssuer(suite.T(), ctx, suite.storage, devFreeTimeLimitedV2)
+
+	reqIDs := []uuid.UUID{
+		uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+		uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+	}
+
+	for i := range reqIDs {
+		err := suite.storage.InsertSigningOrderRequestOutbox(ctx, reqIDs[i], order.ID, order.Items[0].ID, SigningOrderRequest{})
+		suite.Require().NoError(err)
+	}
+
+	now := time.Now().UTC()
+
+	codec, err := goavro.NewCodec(signingOrderResultSchema)
+	suite.Require().NoError(err)
+
+	msgs := make([]kafka.Message, 0, len(reqIDs))
+	for i := range reqIDs {
+		data, err := json.Marshal(suite.makeMsg(reqIDs[i], order.ID, order.Items[0].ID, issuer.ID, now.Add(time.Hour), now))
+		suite.Require().NoError(err)
+
+		native, _, err := codec.NativeFromTextual(data)
+		suite.Require().NoError(err)
+
+		binary, err := codec.BinaryFromNative(nil, native)
+		suite.Require().NoError(err)
+
+		msgs = append(msgs, kafka.Message{
+			Topic:  kafkaUnsignedOrderCredsTopic,
+			Offset: int64(i + 10),
+			Value:  binary,
+		})
+	}
+
+	handler := &SignedOrderCredentialsHandler{
+		decoder:     &SigningOrderResultDecoder{codec: codec},
+		datastore:   suite.storage,
+		tlv2Repo:    repository.NewTLV2(),
+		orderEvRepo: repository.NewOrderEvent(),
+		offsetRepo:  repository.NewConsumerOffset(),
+		groupID:     test.RandomString(),
+	}
+
+	suite.Require().NoError(handler.Handle(ctx, msgs[0]))
+
+	// The consumer restarts before committing the offset to Kafka, and reads the message again.
+	suite.Require().NoError(handler.Handle(ctx, msgs[0]))
+
+	suite.Require().NoError(handler.Handle(ctx, msgs[1]))
+
+	creds, err := suite.storage.GetTimeLimitedV2OrderCredsByOrder(order.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(creds)
+
+	suite.Assert().Len(creds.Credentials, 2)
+
+	last, err := repository.NewConsumerOffset().GetForUpdate(ctx, suite.storage.RawDB(), handler.groupID, kafkaUnsignedOrderCredsTopic, 0)
+	suite.Require().NoError(err)
+
+	suite.Assert().Equal(int64(11), last)
+}
+
 func TestCreateIssuer_NewIssuer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
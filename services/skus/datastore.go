@@ -13,6 +13,7 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	"github.com/segmentio/kafka-go"
 	"github.com/shopspring/decimal"
@@ -55,7 +56,7 @@ type Datastore interface {
 	// GetTransactions returns all the transactions for a specific order
 	GetTransactions(orderID uuid.UUID) (*[]Transaction, error)
 	// GetPagedMerchantTransactions returns all the transactions for a specific order
-	GetPagedMerchantTransactions(ctx context.Context, merchantID uuid.UUID, pagination *inputs.Pagination) (*[]Transaction, int, error)
+	GetPagedMerchantTransactions(ctx context.Context, merchantID string, pagination *inputs.Pagination) (*[]Transaction, int, error)
 	// GetSumForTransactions gets a decimal sum of for transactions for an order
 	GetSumForTransactions(orderID uuid.UUID) (decimal.Decimal, error)
 
@@ -65,8 +66,9 @@ type Datastore interface {
 	GetOrderCredsByItemID(orderID uuid.UUID, itemID uuid.UUID, isSigned bool) (*OrderCreds, error)
 	GetKeysByMerchant(merchant string, showExpired bool) (*[]Key, error)
 	GetKey(id uuid.UUID, showExpired bool) (*Key, error)
-	CreateKey(merchant string, name string, encryptedSecretKey string, nonce string) (*Key, error)
+	CreateKey(merchant string, name string, encryptedSecretKey string, nonce string, scopes []string) (*Key, error)
 	DeleteKey(id uuid.UUID, delaySeconds int) (*Key, error)
+	RotateKeyTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, overlapSeconds int, encryptedSecretKey string, nonce string) (*Key, error)
 	GetUncommittedVotesForUpdate(ctx context.Context) (*sqlx.Tx, []*VoteRecord, error)
 	CommitVote(ctx context.Context, vr VoteRecord, tx *sqlx.Tx) error
 	MarkVoteErrored(ctx context.Context, vr VoteRecord, tx *sqlx.Tx) error
@@ -114,16 +116,20 @@ type orderItemStore interface {
 	Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderItem, error)
 	FindByOrderID(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderItem, error)
 	InsertMany(ctx context.Context, dbi sqlx.ExtContext, items ...model.OrderItem) ([]model.OrderItem, error)
+	UpdatePlan(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error
 }
 
 type orderPayHistoryStore interface {
 	Insert(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	List(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]time.Time, error)
 }
 
 type issuerStore interface {
 	GetByMerchID(ctx context.Context, dbi sqlx.QueryerContext, merchID string) (*model.Issuer, error)
 	GetByPubKey(ctx context.Context, dbi sqlx.QueryerContext, pubKey string) (*model.Issuer, error)
 	Create(ctx context.Context, dbi sqlx.QueryerContext, req model.IssuerNew) (*model.Issuer, error)
+	GetNextDueKeyCheckForUpdate(ctx context.Context, dbi sqlx.QueryerContext, before time.Time) (*model.Issuer, error)
+	SetKeysCheckedAt(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
 }
 
 // VoteRecord - how the ac votes are stored in the queue
@@ -180,15 +186,20 @@ func newPostgres(databaseURL string, performMigration bool, migrationTrack strin
 }
 
 // CreateKey creates an encrypted key in the database based on the merchant
-func (pg *Postgres) CreateKey(merchant string, name string, encryptedSecretKey string, nonce string) (*Key, error) {
+func (pg *Postgres) CreateKey(merchant string, name string, encryptedSecretKey string, nonce string, scopes []string) (*Key, error) {
+	if scopes == nil {
+		scopes = []string{}
+	}
+
 	// interface and create an api key
 	var key Key
 	err := pg.RawDB().Get(&key, `
-			INSERT INTO api_keys (merchant_id, name, encrypted_secret_key, nonce)
-			VALUES ($1, $2, $3, $4)
-			RETURNING id, name, merchant_id, encrypted_secret_key, nonce, created_at, expiry
+			INSERT INTO api_keys (merchant_id, name, encrypted_secret_key, nonce, scopes)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, name, merchant_id, encrypted_secret_key, nonce, created_at, expiry, scopes, last_used_at, rotated_from_id,
+				rotate_every_seconds, rotation_overlap_seconds, next_rotation_at
 		`,
-		merchant, name, encryptedSecretKey, nonce)
+		merchant, name, encryptedSecretKey, nonce, pq.StringArray(scopes))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create key for merchant: %w", err)
@@ -203,7 +214,7 @@ func (pg *Postgres) DeleteKey(id uuid.UUID, delaySeconds int) (*Key, error) {
 			UPDATE api_keys
 			SET expiry=(current_timestamp + $2)
 			WHERE id=$1
-			RETURNING id, name, merchant_id, created_at, expiry
+			RETURNING id, name, merchant_id, created_at, expiry, scopes, last_used_at, rotated_from_id
 		`, id.String(), fmt.Sprintf("%vs", delaySeconds))
 
 	if err == sql.ErrNoRows {
@@ -215,6 +226,50 @@ func (pg *Postgres) DeleteKey(id uuid.UUID, delaySeconds int) (*Key, error) {
 	return &key, nil
 }
 
+// RotateKeyTx replaces an active key with a new one with the same name and scopes.
+//
+// The replaced key expires after overlapSeconds, unless it is due to expire earlier,
+// so that integrations can switch to the new key in the meantime.
+// A rotation schedule moves to the new key, which is next due one period from now.
+func (pg *Postgres) RotateKeyTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, overlapSeconds int, encryptedSecretKey string, nonce string) (*Key, error) {
+	var prev Key
+	if err := tx.GetContext(ctx, &prev, `
+			SELECT id, name, merchant_id, created_at, expiry, scopes, rotate_every_seconds, rotation_overlap_seconds
+			FROM api_keys
+			WHERE id = $1 AND (expiry IS NULL OR expiry > CURRENT_TIMESTAMP)
+			FOR UPDATE
+		`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get key for rotation: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+			UPDATE api_keys
+			SET expiry = LEAST(COALESCE(expiry, 'infinity'), current_timestamp + $2::interval), next_rotation_at = NULL
+			WHERE id = $1
+		`, id, fmt.Sprintf("%vs", overlapSeconds)); err != nil {
+		return nil, fmt.Errorf("failed to expire rotated key: %w", err)
+	}
+
+	var key Key
+	if err := tx.GetContext(ctx, &key, `
+			INSERT INTO api_keys (
+				merchant_id, name, encrypted_secret_key, nonce, scopes, rotated_from_id,
+				rotate_every_seconds, rotation_overlap_seconds, next_rotation_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7::integer, $8, current_timestamp + make_interval(secs => $7::integer))
+			RETURNING id, name, merchant_id, encrypted_secret_key, nonce, created_at, expiry, scopes, last_used_at, rotated_from_id,
+				rotate_every_seconds, rotation_overlap_seconds, next_rotation_at
+		`, prev.Merchant, prev.Name, encryptedSecretKey, nonce, prev.Scopes, id, prev.RotateEverySeconds, prev.RotationOverlapSeconds); err != nil {
+		return nil, fmt.Errorf("failed to create rotated key: %w", err)
+	}
+
+	return &key, nil
+}
+
 // GetKeysByMerchant returns a list of active API keys
 func (pg *Postgres) GetKeysByMerchant(merchant string, showExpired bool) (*[]Key, error) {
 	expiredQuery := "AND (expiry IS NULL or expiry > CURRENT_TIMESTAMP)"
@@ -226,7 +281,8 @@ func (pg *Postgres) GetKeysByMerchant(merchant string, showExpired bool) (*[]Key
 	err := pg.RawDB().Select(&keys, `
 			select
 				id, name, merchant_id, created_at, expiry,
-				encrypted_secret_key, nonce
+				encrypted_secret_key, nonce, scopes, last_used_at, rotated_from_id,
+				rotate_every_seconds, rotation_overlap_seconds, next_rotation_at
 			from api_keys
 			where
 			merchant_id = $1`+expiredQuery+" ORDER BY name, created_at",
@@ -250,7 +306,8 @@ func (pg *Postgres) GetKey(id uuid.UUID, showExpired bool) (*Key, error) {
 	err := pg.RawDB().Get(&key, `
 			select
 				id, name, merchant_id, created_at, expiry,
-				encrypted_secret_key, nonce
+				encrypted_secret_key, nonce, scopes, last_used_at, rotated_from_id,
+				rotate_every_seconds, rotation_overlap_seconds, next_rotation_at
 			from api_keys
 			where
 			id = $1`+expiredQuery,
@@ -377,7 +434,7 @@ func (pg *Postgres) GetOrderItem(ctx context.Context, itemID uuid.UUID) (*OrderI
 
 // GetPagedMerchantTransactions - get a paginated list of transactions for a merchant
 func (pg *Postgres) GetPagedMerchantTransactions(
-	ctx context.Context, merchantID uuid.UUID, pagination *inputs.Pagination) (*[]Transaction, int, error) {
+	ctx context.Context, merchantID string, pagination *inputs.Pagination) (*[]Transaction, int, error) {
 	var (
 		total int
 		err   error
@@ -918,15 +975,18 @@ type TimeAwareSubIssuedCreds struct {
 	BatchProof   string                    `json:"batchProof" db:"batch_proof"`
 	PublicKey    string                    `json:"publicKey" db:"public_key"`
 	RequestID    string                    `json:"-" db:"request_id"`
+	SeatID       *uuid.UUID                `json:"-" db:"seat_id"`
 }
 
 // GetTimeLimitedV2OrderCredsByOrder returns all the non expired time limited v2 order credentials for a given order.
+//
+// Credentials redeemed via seats are excluded.
 func (pg *Postgres) GetTimeLimitedV2OrderCredsByOrder(orderID uuid.UUID) (*TimeLimitedV2Creds, error) {
 	query := `
 		select order_id, item_id, issuer_id, blinded_creds, signed_creds, batch_proof, public_key,
 		valid_from, valid_to
 		from time_limited_v2_order_creds
-		where order_id = $1 and valid_to > now()
+		where order_id = $1 and seat_id is null and valid_to > now()
 	`
 
 	var timeAwareSubIssuedCreds []TimeAwareSubIssuedCreds
@@ -982,7 +1042,7 @@ func (pg *Postgres) GetTimeLimitedV2OrderCredsByOrderItem(itemID uuid.UUID) (*Ti
 		select order_id, item_id, issuer_id, blinded_creds, signed_creds, batch_proof, public_key,
 		valid_from, valid_to
 		from time_limited_v2_order_creds
-		where item_id = $1 and valid_to > now()
+		where item_id = $1 and seat_id is null and valid_to > now()
 	`
 
 	var timeAwareSubIssuedCreds []TimeAwareSubIssuedCreds
@@ -1016,11 +1076,11 @@ func (pg *Postgres) InsertTimeLimitedV2OrderCredsTx(ctx context.Context, tx *sql
 
 	// continue to insert the credential
 	query := `insert into time_limited_v2_order_creds(item_id, order_id, issuer_id, blinded_creds,
-                        signed_creds, batch_proof, public_key, valid_to, valid_from, request_id)
-                    values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) on conflict do nothing`
+                        signed_creds, batch_proof, public_key, valid_to, valid_from, request_id, seat_id)
+                    values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) on conflict do nothing`
 
 	_, err = tx.ExecContext(ctx, query, tlv2.ItemID, tlv2.OrderID, tlv2.IssuerID, blindedCredsJSON,
-		signedCredsJSON, tlv2.BatchProof, tlv2.PublicKey, tlv2.ValidTo, tlv2.ValidFrom, tlv2.RequestID)
+		signedCredsJSON, tlv2.BatchProof, tlv2.PublicKey, tlv2.ValidTo, tlv2.ValidFrom, tlv2.RequestID, tlv2.SeatID)
 	if err != nil {
 		return fmt.Errorf("error inserting row: %w", err)
 	}
@@ -1278,6 +1338,7 @@ func (pg *Postgres) InsertSignedOrderCredentialsTx(ctx context.Context, tx *sqlx
 				ValidTo:      validTo,
 				ValidFrom:    validFrom,
 				RequestID:    soResult.RequestID,
+				SeatID:       metadata.SeatID,
 			}
 
 			if err := pg.InsertTimeLimitedV2OrderCredsTx(ctx, tx, cred); err != nil {
@@ -97,7 +97,7 @@ func TestGetPagedMerchantTransactions(t *testing.T) {
 			SELECT (.+) as total
 			FROM transactions as t
 				INNER JOIN orders as o ON o.id = t.order_id
-			WHERE (.+)`).WithArgs(merchantID).WillReturnRows(countRows)
+			WHERE (.+)`).WithArgs(merchantID.String()).WillReturnRows(countRows)
 
 	transactionUUIDs := []uuid.UUID{uuid.NewV4(), uuid.NewV4(), uuid.NewV4()}
 	orderUUIDs := []uuid.UUID{uuid.NewV4(), uuid.NewV4(), uuid.NewV4()}
@@ -115,11 +115,11 @@ func TestGetPagedMerchantTransactions(t *testing.T) {
 			FROM transactions as t
 				INNER JOIN orders as o ON o.id = t.order_id
 			WHERE o.merchant_id = (.+)
-			 ORDER BY (.+) OFFSET (.+) FETCH NEXT (.+)`).WithArgs(merchantID).
+			 ORDER BY (.+) OFFSET (.+) FETCH NEXT (.+)`).WithArgs(merchantID.String()).
 		WillReturnRows(getRows)
 
 	// call function under test with inputs
-	transactions, c, err := pg.GetPagedMerchantTransactions(ctx, merchantID, pagination)
+	transactions, c, err := pg.GetPagedMerchantTransactions(ctx, merchantID.String(), pagination)
 
 	// test assertions
 	if err != nil {
@@ -0,0 +1,223 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	defaultDunningGracePeriod      = 7 * 24 * time.Hour
+	defaultDunningReminderInterval = 2 * 24 * time.Hour
+
+	subCancelRetryBase = time.Minute
+	subCancelRetryMax  = 6 * time.Hour
+)
+
+// dunningConfig controls recovery of failed renewal payments.
+//
+// During the grace period, a past due order remains paid, and reminders are recorded every reminderInterval.
+type dunningConfig struct {
+	gracePeriod      time.Duration
+	reminderInterval time.Duration
+}
+
+func newDunningConfig() (*dunningConfig, error) {
+	result := &dunningConfig{
+		gracePeriod:      defaultDunningGracePeriod,
+		reminderInterval: defaultDunningReminderInterval,
+	}
+
+	if raw := os.Getenv("SKUS_DUNNING_GRACE_PERIOD"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("skus: invalid dunning grace period: %w", err)
+		}
+
+		result.gracePeriod = val
+	}
+
+	if raw := os.Getenv("SKUS_DUNNING_REMINDER_INTERVAL"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("skus: invalid dunning reminder interval: %w", err)
+		}
+
+		result.reminderInterval = val
+	}
+
+	return result, nil
+}
+
+// RunNextDunningJob sends a reminder for, or cancels, the next order in dunning which is due.
+func (s *Service) RunNextDunningJob(ctx context.Context) (bool, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.processNextDunningTx(ctx, tx, time.Now()); err != nil {
+		if errors.Is(err, model.ErrOrderDunningNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// processNextDunningTx handles the next due dunning entry.
+//
+// Before the grace period ends, a payment reminder event is recorded and the next reminder is scheduled.
+// Once the grace period is over, the order is canceled, and so is its Stripe subscription, if any.
+func (s *Service) processNextDunningTx(ctx context.Context, dbi sqlx.ExtContext, now time.Time) error {
+	dun, err := s.orderDunRepo.GetNextDueForUpdate(ctx, dbi, now)
+	if err != nil {
+		return err
+	}
+
+	if !dun.HasGraceEndedAt(now) {
+		if err := s.orderEvRepo.Insert(ctx, dbi, dun.OrderID, model.OrderEventPaymentReminder, now); err != nil {
+			return err
+		}
+
+		return s.orderDunRepo.Remind(ctx, dbi, dun.ID, now.Add(s.dunningCfg.reminderInterval))
+	}
+
+	if dun.Vendor == model.WebhookVendorStripe {
+		ord, err := s.orderRepo.Get(ctx, dbi, dun.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if subID, ok := ord.StripeSubID(); ok && subID != "" {
+			if err := s.scheduleStripeSubCancelTx(ctx, dbi, ord.ID, subID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.cancelOrderTx(ctx, dbi, dun.OrderID)
+}
+
+// scheduleStripeSubCancelTx records that the Stripe subscription of the order is to be canceled.
+//
+// Stripe is not called within the transaction. RunNextStripeSubCancelJob cancels the subscription once the
+// transaction has been committed, and keeps retrying until it succeeds.
+func (s *Service) scheduleStripeSubCancelTx(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+	return s.subCancelRepo.Insert(ctx, dbi, orderID, subID)
+}
+
+// RunNextStripeSubCancelJob cancels the next pending Stripe subscription which is due.
+//
+// A failed attempt is retried later with a growing delay, so that it does not hold up the cancellations after it.
+func (s *Service) RunNextStripeSubCancelJob(ctx context.Context) (bool, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+
+	if err := s.processNextStripeSubCancelTx(ctx, tx, now); err != nil {
+		if errors.Is(err, model.ErrStripeSubCancelNotFound) {
+			return false, nil
+		}
+
+		// A failed attempt is recorded in the same transaction.
+		if cerr := tx.Commit(); cerr != nil {
+			return true, cerr
+		}
+
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// processNextStripeSubCancelTx cancels the next pending subscription, keeping the entry locked while Stripe is called.
+//
+// A subscription which no longer exists is considered canceled.
+func (s *Service) processNextStripeSubCancelTx(ctx context.Context, dbi sqlx.ExtContext, now time.Time) error {
+	sc, err := s.subCancelRepo.GetNextDueForUpdate(ctx, dbi, now)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.stripeCl.CancelSubscription(ctx, sc.SubID, nil); err != nil && !isErrStripeNotFound(err) {
+		if ferr := s.subCancelRepo.MarkAttemptFailed(ctx, dbi, sc.ID, err.Error(), now.Add(subCancelRetryDelay(sc.NumAttempts))); ferr != nil {
+			return ferr
+		}
+
+		return fmt.Errorf("failed to cancel stripe subscription: %w", err)
+	}
+
+	return s.subCancelRepo.MarkCanceled(ctx, dbi, sc.ID, now)
+}
+
+// subCancelRetryDelay returns the delay before the next attempt given the number of previous attempts.
+func subCancelRetryDelay(numAttempts int) time.Duration {
+	if numAttempts < 0 {
+		numAttempts = 0
+	}
+
+	result := subCancelRetryBase
+	for i := 0; i < numAttempts; i++ {
+		result *= 2
+
+		if result >= subCancelRetryMax {
+			return subCancelRetryMax
+		}
+	}
+
+	return result
+}
+
+// recordPayFailureTx puts the order into dunning after a failed renewal payment reported by vendor.
+//
+// The order stays paid until the end of the grace period, by which point it's either renewed or canceled.
+// Repeated failures within the same dunning period don't extend the grace period.
+func (s *Service) recordPayFailureTx(ctx context.Context, dbi sqlx.ExtContext, ord *model.Order, vendor string, now time.Time) error {
+	if err := s.orderEvRepo.Insert(ctx, dbi, ord.ID, model.OrderEventPaymentFailed, now); err != nil {
+		return err
+	}
+
+	// A failure reported for an order which is not active has no effect on access.
+	if ord.Status != model.OrderStatusPaid && ord.Status != model.OrderStatusPastDue {
+		return nil
+	}
+
+	dun, err := s.orderDunRepo.Start(ctx, dbi, ord.ID, vendor, now.Add(s.dunningCfg.gracePeriod), now.Add(s.dunningCfg.reminderInterval))
+	if err != nil {
+		return err
+	}
+
+	if err := s.orderRepo.SetStatus(ctx, dbi, ord.ID, model.OrderStatusPastDue); err != nil {
+		return err
+	}
+
+	// Access already paid for extends past the grace period.
+	if ord.ExpiresAt != nil && ord.ExpiresAt.After(dun.GraceEndsAt) {
+		return nil
+	}
+
+	return s.orderRepo.SetExpiresAt(ctx, dbi, ord.ID, dun.GraceEndsAt)
+}
@@ -0,0 +1,652 @@
+package skus
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v72"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+	"github.com/brave-intl/bat-go/services/skus/xstripe"
+)
+
+func TestNewDunningConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("SKUS_DUNNING_GRACE_PERIOD", "")
+		t.Setenv("SKUS_DUNNING_REMINDER_INTERVAL", "")
+
+		actual, err := newDunningConfig()
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 7*24*time.Hour, actual.gracePeriod)
+		should.Equal(t, 2*24*time.Hour, actual.reminderInterval)
+	})
+
+	t.Run("from_env", func(t *testing.T) {
+		t.Setenv("SKUS_DUNNING_GRACE_PERIOD", "72h")
+		t.Setenv("SKUS_DUNNING_REMINDER_INTERVAL", "24h")
+
+		actual, err := newDunningConfig()
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 72*time.Hour, actual.gracePeriod)
+		should.Equal(t, 24*time.Hour, actual.reminderInterval)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("SKUS_DUNNING_GRACE_PERIOD", "week")
+
+		_, err := newDunningConfig()
+		should.NotNil(t, err)
+	})
+}
+
+func TestService_processNextDunningTx(t *testing.T) {
+	type tcGiven struct {
+		now     time.Time
+		dunRepo *repository.MockOrderDunning
+		orepo   *repository.MockOrder
+		evRepo  *repository.MockOrderEvent
+		scRepo  *repository.MockStripeSubCancel
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "nothing_due",
+			given: tcGiven{
+				now:     time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{},
+				orepo:   &repository.MockOrder{},
+				evRepo:  &repository.MockOrderEvent{},
+				scRepo:  &repository.MockStripeSubCancel{},
+			},
+			exp: model.ErrOrderDunningNotFound,
+		},
+
+		{
+			name: "remind_event_error",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Vendor:      model.WebhookVendorStripe,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+				},
+				orepo: &repository.MockOrder{},
+				evRepo: &repository.MockOrderEvent{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				scRepo: &repository.MockStripeSubCancel{},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "remind",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Vendor:      model.WebhookVendorStripe,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+
+					FnRemind: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error {
+						if !next.Equal(time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC)) {
+							return model.Error("unexpected_next")
+						}
+
+						return nil
+					},
+
+					FnCancel: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_cancel")
+					},
+				},
+				orepo: &repository.MockOrder{},
+				evRepo: &repository.MockOrderEvent{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+						if typ != model.OrderEventPaymentReminder {
+							return model.Error("unexpected_type")
+						}
+
+						return nil
+					},
+				},
+				scRepo: &repository.MockStripeSubCancel{},
+			},
+		},
+
+		{
+			name: "cancel_order_error",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Vendor:      model.WebhookVendorStripe,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Metadata: datastore.Metadata{"stripeSubscriptionId": "sub_id"},
+						}
+
+						return result, nil
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				evRepo: &repository.MockOrderEvent{},
+				scRepo: &repository.MockStripeSubCancel{},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "cancel_stripe",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Vendor:      model.WebhookVendorStripe,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+
+					FnRemind: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error {
+						return model.Error("unexpected_remind")
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Metadata: datastore.Metadata{"stripeSubscriptionId": "sub_id"},
+						}
+
+						return result, nil
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status != model.OrderStatusCanceled {
+							return model.Error("unexpected_status")
+						}
+
+						return nil
+					},
+				},
+				evRepo: &repository.MockOrderEvent{},
+				scRepo: &repository.MockStripeSubCancel{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+						if !uuid.Equal(orderID, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_order_id")
+						}
+
+						if subID != "sub_id" {
+							return model.Error("unexpected_sub_id")
+						}
+
+						return nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "cancel_mobile",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 9, 0, 0, 0, 0, time.UTC),
+				dunRepo: &repository.MockOrderDunning{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Vendor:      model.WebhookVendorPlayStore,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						return nil, model.Error("unexpected_get")
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status != model.OrderStatusCanceled {
+							return model.Error("unexpected_status")
+						}
+
+						return nil
+					},
+				},
+				evRepo: &repository.MockOrderEvent{},
+				scRepo: &repository.MockStripeSubCancel{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+						return model.Error("unexpected_insert")
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				orderRepo:     tc.given.orepo,
+				orderEvRepo:   tc.given.evRepo,
+				orderDunRepo:  tc.given.dunRepo,
+				subCancelRepo: tc.given.scRepo,
+				couponRepo:    &repository.MockCoupon{},
+				dunningCfg:    &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
+			}
+
+			ctx := context.Background()
+
+			actual := svc.processNextDunningTx(ctx, nil, tc.given.now)
+			should.ErrorIs(t, actual, tc.exp)
+		})
+	}
+}
+
+func TestService_processNextStripeSubCancelTx(t *testing.T) {
+	type tcGiven struct {
+		now    time.Time
+		scRepo *repository.MockStripeSubCancel
+		scl    *xstripe.MockClient
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "nothing_due",
+			given: tcGiven{
+				now:    time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				scRepo: &repository.MockStripeSubCancel{},
+				scl: &xstripe.MockClient{
+					FnCancelSub: func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+						return nil, model.Error("unexpected_cancel_sub")
+					},
+				},
+			},
+			exp: model.ErrStripeSubCancelNotFound,
+		},
+
+		{
+			name: "error_retried_later",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				scRepo: &repository.MockStripeSubCancel{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error) {
+						result := &model.StripeSubCancel{
+							ID:          uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							SubID:       "sub_id",
+							NumAttempts: 2,
+						}
+
+						return result, nil
+					},
+
+					FnMarkAttemptFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+						if msg != "something_went_wrong" {
+							return model.Error("unexpected_msg")
+						}
+
+						if !next.Equal(time.Date(2024, time.January, 8, 0, 4, 0, 0, time.UTC)) {
+							return model.Error("unexpected_next")
+						}
+
+						return nil
+					},
+
+					FnMarkCanceled: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_mark_canceled")
+					},
+				},
+				scl: &xstripe.MockClient{
+					FnCancelSub: func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "not_found",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				scRepo: &repository.MockStripeSubCancel{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error) {
+						result := &model.StripeSubCancel{
+							ID:    uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							SubID: "sub_id",
+						}
+
+						return result, nil
+					},
+
+					FnMarkAttemptFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+						return model.Error("unexpected_mark_attempt_failed")
+					},
+				},
+				scl: &xstripe.MockClient{
+					FnCancelSub: func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+						return nil, &stripe.Error{HTTPStatusCode: http.StatusNotFound, Code: stripe.ErrorCodeResourceMissing}
+					},
+				},
+			},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+				scRepo: &repository.MockStripeSubCancel{
+					FnGetNextDueForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error) {
+						result := &model.StripeSubCancel{
+							ID:    uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							SubID: "sub_id",
+						}
+
+						return result, nil
+					},
+
+					FnMarkCanceled: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if !uuid.Equal(id, uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_id")
+						}
+
+						if !when.Equal(time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)) {
+							return model.Error("unexpected_when")
+						}
+
+						return nil
+					},
+				},
+				scl: &xstripe.MockClient{
+					FnCancelSub: func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+						if id != "sub_id" {
+							return nil, model.Error("unexpected_sub_id")
+						}
+
+						return &stripe.Subscription{ID: id}, nil
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{subCancelRepo: tc.given.scRepo, stripeCl: tc.given.scl}
+
+			actual := svc.processNextStripeSubCancelTx(context.Background(), nil, tc.given.now)
+			should.ErrorIs(t, actual, tc.exp)
+		})
+	}
+}
+
+func TestSubCancelRetryDelay(t *testing.T) {
+	type testCase struct {
+		name  string
+		given int
+		exp   time.Duration
+	}
+
+	tests := []testCase{
+		{
+			name: "first",
+			exp:  time.Minute,
+		},
+
+		{
+			name:  "third",
+			given: 2,
+			exp:   4 * time.Minute,
+		},
+
+		{
+			name:  "capped",
+			given: 15,
+			exp:   6 * time.Hour,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, subCancelRetryDelay(tc.given))
+		})
+	}
+}
+
+func TestService_recordPayFailureTx(t *testing.T) {
+	type tcGiven struct {
+		ord     *model.Order
+		now     time.Time
+		orepo   *repository.MockOrder
+		dunRepo *repository.MockOrderDunning
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "not_active",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Status: model.OrderStatusCanceled,
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("unexpected_status")
+					},
+				},
+				dunRepo: &repository.MockOrderDunning{
+					FnStart: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+						return nil, model.Error("unexpected_start")
+					},
+				},
+			},
+		},
+
+		{
+			name: "start_error",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Status: model.OrderStatusPaid,
+				},
+				now:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				orepo: &repository.MockOrder{},
+				dunRepo: &repository.MockOrderDunning{
+					FnStart: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "extends_expiry_to_grace_end",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:        uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Status:    model.OrderStatusPaid,
+					ExpiresAt: ptrTo(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status != model.OrderStatusPastDue {
+							return model.Error("unexpected_status")
+						}
+
+						return nil
+					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if !when.Equal(time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)) {
+							return model.Error("unexpected_expires_at")
+						}
+
+						return nil
+					},
+				},
+				dunRepo: &repository.MockOrderDunning{
+					FnStart: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+						if vendor != model.WebhookVendorStripe {
+							return nil, model.Error("unexpected_vendor")
+						}
+
+						if !nextReminderAt.Equal(time.Date(2024, time.January, 3, 0, 0, 0, 0, time.UTC)) {
+							return nil, model.Error("unexpected_next_reminder_at")
+						}
+
+						result := &model.OrderDunning{
+							OrderID:        orderID,
+							Vendor:         vendor,
+							GraceEndsAt:    graceEndsAt,
+							NextReminderAt: nextReminderAt,
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "keeps_grace_end_of_active_dunning",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:        uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Status:    model.OrderStatusPastDue,
+					ExpiresAt: ptrTo(time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2024, time.January, 4, 0, 0, 0, 0, time.UTC),
+				orepo: &repository.MockOrder{
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if !when.Equal(time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)) {
+							return model.Error("unexpected_expires_at")
+						}
+
+						return nil
+					},
+				},
+				dunRepo: &repository.MockOrderDunning{
+					FnStart: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+						result := &model.OrderDunning{
+							OrderID:     orderID,
+							Vendor:      vendor,
+							GraceEndsAt: time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "keeps_later_expiry",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:        uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Status:    model.OrderStatusPaid,
+					ExpiresAt: ptrTo(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				orepo: &repository.MockOrder{
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_expires_at")
+					},
+				},
+				dunRepo: &repository.MockOrderDunning{},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				orderRepo:    tc.given.orepo,
+				orderEvRepo:  &repository.MockOrderEvent{},
+				orderDunRepo: tc.given.dunRepo,
+				couponRepo:   &repository.MockCoupon{},
+				dunningCfg:   &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
+			}
+
+			ctx := context.Background()
+
+			actual := svc.recordPayFailureTx(ctx, nil, tc.given.ord, model.WebhookVendorStripe, tc.given.now)
+			should.ErrorIs(t, actual, tc.exp)
+		})
+	}
+}
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/go-chi/chi"
@@ -21,8 +23,30 @@ import (
 
 const (
 	reqBodyLimit10MB = 10 << 20
+
+	// reqHeaderGeoCountry carries the country the edge geolocated the client to.
+	//
+	// It is only trusted on requests which come through GeoProxy.
+	reqHeaderGeoCountry = "X-Geo-Country"
 )
 
+// GeoProxy identifies requests forwarded by the edge which geolocates clients.
+//
+// The edge sets Header to Secret on every request it forwards.
+// Without both configured, no request is trusted, and orders get the prices for all countries.
+type GeoProxy struct {
+	Header string
+	Secret string
+}
+
+func (x GeoProxy) isTrusted(r *http.Request) bool {
+	if x.Header == "" || x.Secret == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(x.Header)), []byte(x.Secret)) == 1
+}
+
 type orderService interface {
 	CreateOrderFromRequest(ctx context.Context, req model.CreateOrderRequest) (*model.Order, error)
 	CreateOrder(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error)
@@ -31,12 +55,14 @@ type orderService interface {
 
 type Order struct {
 	svc   orderService
+	geo   GeoProxy
 	valid *validator.Validate
 }
 
-func NewOrder(svc orderService) *Order {
+func NewOrder(svc orderService, geo GeoProxy) *Order {
 	result := &Order{
 		svc:   svc,
+		geo:   geo,
 		valid: validator.New(),
 	}
 
@@ -104,14 +130,27 @@ func (h *Order) CreateNew(w http.ResponseWriter, r *http.Request) *handlers.AppE
 		}
 	}
 
+	req.Country = h.geoCountry(r)
+
 	lg := logging.Logger(ctx, "skus").With().Str("func", "CreateOrderNew").Logger()
 
 	result, err := h.svc.CreateOrder(ctx, req)
 	if err != nil {
 		lg.Err(err).Msg("failed to create order")
 
-		if errors.Is(err, model.ErrInvalidOrderRequest) {
+		switch {
+		case errors.Is(err, model.ErrInvalidOrderRequest):
 			return handlers.WrapError(err, "Invalid order data supplied", http.StatusUnprocessableEntity)
+
+		case errors.Is(err, model.ErrCouponNotFound):
+			return handlers.WrapError(err, "Invalid coupon code", http.StatusNotFound)
+
+		case errors.Is(err, model.ErrCouponExpired),
+			errors.Is(err, model.ErrCouponExhausted),
+			errors.Is(err, model.ErrCouponNotEligible),
+			errors.Is(err, model.ErrCouponCurrencyMismatch),
+			errors.Is(err, model.ErrCouponNoStripeCoupon):
+			return handlers.WrapError(err, "Coupon cannot be applied", http.StatusUnprocessableEntity)
 		}
 
 		return handlers.WrapError(model.ErrSomethingWentWrong, "Couldn't finish creating order", http.StatusInternalServerError)
@@ -120,6 +159,20 @@ func (h *Order) CreateNew(w http.ResponseWriter, r *http.Request) *handlers.AppE
 	return handlers.RenderContent(ctx, result, w, http.StatusCreated)
 }
 
+// geoCountry returns the ISO 3166-1 alpha-2 country the edge reported for r, or an empty string if it's unknown.
+func (h *Order) geoCountry(r *http.Request) string {
+	if !h.geo.isTrusted(r) {
+		return ""
+	}
+
+	raw := strings.ToUpper(strings.TrimSpace(r.Header.Get(reqHeaderGeoCountry)))
+	if err := h.valid.Var(raw, "required,iso3166_1_alpha2"); err != nil {
+		return ""
+	}
+
+	return raw
+}
+
 func (h *Order) Cancel(w http.ResponseWriter, r *http.Request) *handlers.AppError {
 	ctx := r.Context()
 
@@ -234,7 +234,7 @@ func TestOrder_Create(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			h := handler.NewOrder(tc.given.svc)
+			h := handler.NewOrder(tc.given.svc, handler.GeoProxy{})
 
 			body := bytes.NewBufferString(tc.given.body)
 
@@ -275,8 +275,10 @@ func TestOrder_Create(t *testing.T) {
 
 func TestOrder_CreateNew(t *testing.T) {
 	type tcGiven struct {
-		svc  *mockOrderService
-		body string
+		svc     *mockOrderService
+		body    string
+		country string
+		secret  string
 	}
 
 	type tcExpected struct {
@@ -373,6 +375,49 @@ func TestOrder_CreateNew(t *testing.T) {
 			},
 		},
 
+		{
+			name: "coupon_expired",
+			given: tcGiven{
+				svc: &mockOrderService{
+					fnCreateOrder: func(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error) {
+						return nil, model.ErrCouponExpired
+					},
+				},
+				body: `{
+					"email": "you@example.com",
+					"currency": "USD",
+					"stripe_metadata": {
+						"success_uri": "https://example.com/success",
+						"cancel_uri": "https://example.com/cancel"
+					},
+					"payment_methods": ["stripe"],
+					"coupon_code": "SUMMER",
+					"items": [
+						{
+							"quantity": 1,
+							"sku": "sku",
+							"sku_variant": "sku_vnt",
+							"location": "location",
+							"description": "description",
+							"credential_type": "credential_type",
+							"credential_valid_duration": "P1M",
+							"stripe_metadata": {
+								"product_id": "product_id",
+								"item_id": "item_id"
+							}
+						}
+					]
+				}`,
+			},
+			exp: tcExpected{
+				err: handlers.WrapError(
+					model.ErrCouponExpired,
+					"Coupon cannot be applied",
+					http.StatusUnprocessableEntity,
+				),
+			},
+		},
+
 		{
 			name: "success",
 			given: tcGiven{
@@ -608,17 +653,157 @@ func TestOrder_CreateNew(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			name: "country_from_edge",
+			given: tcGiven{
+				svc: &mockOrderService{
+					fnCreateOrder: func(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error) {
+						if req.Country != "DE" {
+							return nil, model.Error("unexpected_country")
+						}
+
+						return &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}, nil
+					},
+				},
+				body: `{
+					"email": "you@example.com",
+					"currency": "EUR",
+					"country": "IN",
+					"items": [
+						{
+							"quantity": 1,
+							"sku": "sku",
+							"sku_variant": "sku_vnt",
+							"location": "location",
+							"description": "description",
+							"credential_type": "credential_type",
+							"credential_valid_duration": "P1M"
+						}
+					]
+				}`,
+				country: "de",
+				secret:  "edge_secret",
+			},
+			exp: tcExpected{result: &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}},
+		},
+
+		{
+			name: "country_not_from_body",
+			given: tcGiven{
+				svc: &mockOrderService{
+					fnCreateOrder: func(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error) {
+						if req.Country != "" {
+							return nil, model.Error("unexpected_country")
+						}
+
+						return &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}, nil
+					},
+				},
+				body: `{
+					"email": "you@example.com",
+					"currency": "EUR",
+					"country": "IN",
+					"items": [
+						{
+							"quantity": 1,
+							"sku": "sku",
+							"sku_variant": "sku_vnt",
+							"location": "location",
+							"description": "description",
+							"credential_type": "credential_type",
+							"credential_valid_duration": "P1M"
+						}
+					]
+				}`,
+				country: "XX",
+				secret:  "edge_secret",
+			},
+			exp: tcExpected{result: &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}},
+		},
+
+		{
+			name: "country_without_proxy_secret",
+			given: tcGiven{
+				svc: &mockOrderService{
+					fnCreateOrder: func(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error) {
+						if req.Country != "" {
+							return nil, model.Error("unexpected_country")
+						}
+
+						return &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}, nil
+					},
+				},
+				body: `{
+					"email": "you@example.com",
+					"currency": "EUR",
+					"items": [
+						{
+							"quantity": 1,
+							"sku": "sku",
+							"sku_variant": "sku_vnt",
+							"location": "location",
+							"description": "description",
+							"credential_type": "credential_type",
+							"credential_valid_duration": "P1M"
+						}
+					]
+				}`,
+				country: "DE",
+			},
+			exp: tcExpected{result: &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}},
+		},
+
+		{
+			name: "country_wrong_proxy_secret",
+			given: tcGiven{
+				svc: &mockOrderService{
+					fnCreateOrder: func(ctx context.Context, req *model.CreateOrderRequestNew) (*model.Order, error) {
+						if req.Country != "" {
+							return nil, model.Error("unexpected_country")
+						}
+
+						return &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}, nil
+					},
+				},
+				body: `{
+					"email": "you@example.com",
+					"currency": "EUR",
+					"items": [
+						{
+							"quantity": 1,
+							"sku": "sku",
+							"sku_variant": "sku_vnt",
+							"location": "location",
+							"description": "description",
+							"credential_type": "credential_type",
+							"credential_valid_duration": "P1M"
+						}
+					]
+				}`,
+				country: "DE",
+				secret:  "other",
+			},
+			exp: tcExpected{result: &model.Order{Location: datastore.NullString{NullString: sql.NullString{Valid: true, String: "location"}}, TotalPrice: mustDecimalFromString("1")}},
+		},
 	}
 
 	for i := range tests {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			h := handler.NewOrder(tc.given.svc)
+			h := handler.NewOrder(tc.given.svc, handler.GeoProxy{Header: "X-Edge-Secret", Secret: "edge_secret"})
 
 			body := bytes.NewBufferString(tc.given.body)
 
 			req := httptest.NewRequest(http.MethodPost, "http://localhost", body)
+			if tc.given.country != "" {
+				req.Header.Set("X-Geo-Country", tc.given.country)
+			}
+
+			if tc.given.secret != "" {
+				req.Header.Set("X-Edge-Secret", tc.given.secret)
+			}
 
 			rw := httptest.NewRecorder()
 			rw.Header().Set("content-type", "application/json")
@@ -745,7 +930,7 @@ func TestOrder_Cancel(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			h := handler.NewOrder(tc.given.svc)
+			h := handler.NewOrder(tc.given.svc, handler.GeoProxy{})
 
 			uri := "http://localhost/v1/orders-new/" + tc.given.oid.String()
 			req := httptest.NewRequest(http.MethodDelete, uri, nil)
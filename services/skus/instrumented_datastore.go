@@ -101,7 +101,7 @@ func (_d DatastoreWithPrometheus) CommitVote(ctx context.Context, vr VoteRecord,
 }
 
 // CreateKey implements Datastore
-func (_d DatastoreWithPrometheus) CreateKey(merchant string, name string, encryptedSecretKey string, nonce string) (kp1 *Key, err error) {
+func (_d DatastoreWithPrometheus) CreateKey(merchant string, name string, encryptedSecretKey string, nonce string, scopes []string) (kp1 *Key, err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -111,7 +111,7 @@ func (_d DatastoreWithPrometheus) CreateKey(merchant string, name string, encryp
 
 		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "CreateKey", result).Observe(time.Since(_since).Seconds())
 	}()
-	return _d.base.CreateKey(merchant, name, encryptedSecretKey, nonce)
+	return _d.base.CreateKey(merchant, name, encryptedSecretKey, nonce, scopes)
 }
 
 // CreateOrder implements Datastore
@@ -325,7 +325,7 @@ func (_d DatastoreWithPrometheus) GetOutboxMovAvgDurationSeconds() (i1 int64, er
 }
 
 // GetPagedMerchantTransactions implements Datastore
-func (_d DatastoreWithPrometheus) GetPagedMerchantTransactions(ctx context.Context, merchantID uuid.UUID, pagination *inputs.Pagination) (tap1 *[]Transaction, i1 int, err error) {
+func (_d DatastoreWithPrometheus) GetPagedMerchantTransactions(ctx context.Context, merchantID string, pagination *inputs.Pagination) (tap1 *[]Transaction, i1 int, err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -639,6 +639,20 @@ func (_d DatastoreWithPrometheus) RollbackTxAndHandle(tx *sqlx.Tx) (err error) {
 	return _d.base.RollbackTxAndHandle(tx)
 }
 
+// RotateKeyTx implements Datastore
+func (_d DatastoreWithPrometheus) RotateKeyTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, overlapSeconds int, encryptedSecretKey string, nonce string) (kp1 *Key, err error) {
+	_since := time.Now()
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+
+		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "RotateKeyTx", result).Observe(time.Since(_since).Seconds())
+	}()
+	return _d.base.RotateKeyTx(ctx, tx, id, overlapSeconds, encryptedSecretKey, nonce)
+}
+
 // SendSigningRequest implements Datastore
 func (_d DatastoreWithPrometheus) SendSigningRequest(ctx context.Context, signingRequestWriter SigningRequestWriter) (err error) {
 	_since := time.Now()
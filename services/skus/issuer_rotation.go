@@ -0,0 +1,283 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	// issuerKeyCheckInterval is how often keys of each version 3 issuer are checked.
+	issuerKeyCheckInterval = time.Hour
+
+	// issuerKeyRotateBefore is how long before an issuer runs out of keys the next ones are created.
+	issuerKeyRotateBefore = 14 * 24 * time.Hour
+
+	// issuerKeyAlertBefore is how long before an issuer runs out of keys an alert is raised.
+	issuerKeyAlertBefore = 3 * 24 * time.Hour
+)
+
+const errIssuerNoKeys = model.Error("issuer has no signing keys")
+
+var issuerKeyBufferGauge = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "skus_issuer_key_buffer_seconds",
+		Help: "Time until a version 3 issuer runs out of signing keys.",
+	},
+	[]string{"issuer"},
+)
+
+type issuerKeyStore interface {
+	Upsert(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, keys []model.IssuerKeyNew) error
+	List(ctx context.Context, dbi sqlx.QueryerContext, issuerID uuid.UUID) ([]model.IssuerKey, error)
+	RevokeAll(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, when time.Time) error
+	ListStates(ctx context.Context, dbi sqlx.QueryerContext) ([]model.IssuerKeyState, error)
+}
+
+// RunCheckIssuerKeysJob checks keys of the next version 3 issuer, and creates the next ones when needed.
+//
+// The check is recorded before talking to the challenge bypass server, so that the issuer is not locked while waiting
+// for it, and so that one failing issuer does not hold up the rest.
+func (s *Service) RunCheckIssuerKeysJob(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+
+	iss, err := s.claimNextDueKeyCheck(ctx, now)
+	if err != nil {
+		if errors.Is(err, model.ErrIssuerNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, s.checkIssuerKeys(ctx, s.Datastore.RawDB(), iss, now)
+}
+
+func (s *Service) claimNextDueKeyCheck(ctx context.Context, now time.Time) (*model.Issuer, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	iss, err := s.issuerRepo.GetNextDueKeyCheckForUpdate(ctx, tx, now.Add(-issuerKeyCheckInterval))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.issuerRepo.SetKeysCheckedAt(ctx, tx, iss.ID, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return iss, nil
+}
+
+func (s *Service) checkIssuerKeys(ctx context.Context, dbi sqlx.ExecerContext, iss *model.Issuer, now time.Time) error {
+	lg := logging.Logger(ctx, "skus").With().Str("func", "checkIssuerKeys").Str("issuer", iss.Name()).Logger()
+
+	keys, err := s.fetchIssuerKeys(ctx, iss.Name())
+	if err != nil {
+		return err
+	}
+
+	if err := s.issuerKeyRepo.Upsert(ctx, dbi, iss.ID, keys); err != nil {
+		return err
+	}
+
+	if len(keys) == 0 || lastIssuerKeyEnd(keys).Sub(now) < issuerKeyRotateBefore {
+		lg.Info().Msg("creating keys for the next period")
+
+		if err := s.rotateIssuerV3(ctx, iss.Name(), cbr.IssuerRotateRequest{Buffer: defaultBuffer}); err != nil {
+			return err
+		}
+
+		keys, err = s.fetchIssuerKeys(ctx, iss.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := s.issuerKeyRepo.Upsert(ctx, dbi, iss.ID, keys); err != nil {
+			return err
+		}
+	}
+
+	remaining := issuerKeyBuffer(keys, now)
+
+	issuerKeyBufferGauge.WithLabelValues(iss.Name()).Set(remaining.Seconds())
+
+	if len(keys) == 0 {
+		lg.Error().Msg("issuer has no signing keys")
+
+		return errIssuerNoKeys
+	}
+
+	if remaining < issuerKeyAlertBefore {
+		lg.Error().Dur("remaining", remaining).Msg("issuer is running out of keys")
+	}
+
+	return nil
+}
+
+// ListIssuerKeyStates returns the state of keys of version 3 issuers.
+func (s *Service) ListIssuerKeyStates(ctx context.Context) ([]model.IssuerKeyState, error) {
+	return s.issuerKeyRepo.ListStates(ctx, s.Datastore.RawDB())
+}
+
+// RotateIssuerKeys replaces keys of the issuer for the SKU straight away.
+//
+// Credentials signed with the replaced keys which are valid from now on are deleted,
+// so that clients fetch new batches signed with the new keys.
+func (s *Service) RotateIssuerKeys(ctx context.Context, req *model.RotateIssuerRequest) (*model.RotateIssuerResponse, error) {
+	name, err := encodeIssuerID(req.MerchantID, req.SKU)
+	if err != nil {
+		return nil, err
+	}
+
+	iss, err := s.issuerRepo.GetByMerchID(ctx, s.Datastore.RawDB(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	if iss.Version != 3 {
+		return nil, model.ErrIssuerNotV3
+	}
+
+	// Keys are replaced before touching credentials, so a failure leaves the issuer usable.
+	if err := s.rotateIssuerV3(ctx, name, cbr.IssuerRotateRequest{Buffer: defaultBuffer, Force: true}); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.fetchIssuerKeys(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+
+	if err := s.issuerKeyRepo.RevokeAll(ctx, tx, iss.ID, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.issuerKeyRepo.Upsert(ctx, tx, iss.ID, keys); err != nil {
+		return nil, err
+	}
+
+	ncreds, err := s.tlv2Repo.DeleteIssuerValidAfter(ctx, tx, iss.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.issuerRepo.SetKeysCheckedAt(ctx, tx, iss.ID, now); err != nil {
+		return nil, err
+	}
+
+	result, err := s.issuerKeyRepo.List(ctx, tx, iss.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	issuerKeyBufferGauge.WithLabelValues(name).Set(issuerKeyBuffer(keys, now).Seconds())
+
+	return &model.RotateIssuerResponse{Issuer: name, Keys: result, NumCredsInvalidated: ncreds}, nil
+}
+
+func (s *Service) fetchIssuerKeys(ctx context.Context, name string) ([]model.IssuerKeyNew, error) {
+	reqFn := func() (interface{}, error) {
+		return s.cbClient.GetIssuerV3(ctx, name)
+	}
+
+	resp, err := s.retry(ctx, reqFn, retryPolicy, canRetry(dontRetryCodes))
+	if err != nil {
+		return nil, fmt.Errorf("error getting issuer %s: %w", name, err)
+	}
+
+	issuerResp, ok := resp.(*cbr.IssuerResponse)
+	if !ok {
+		return nil, errInvalidIssuerResp
+	}
+
+	return newIssuerKeys(issuerResp.Keys), nil
+}
+
+func (s *Service) rotateIssuerV3(ctx context.Context, name string, req cbr.IssuerRotateRequest) error {
+	reqFn := func() (interface{}, error) {
+		return nil, s.cbClient.RotateIssuerV3(ctx, name, req)
+	}
+
+	if _, err := s.retry(ctx, reqFn, retryPolicy, canRetry(dontRetryCodes)); err != nil {
+		return fmt.Errorf("error rotating issuer %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// newIssuerKeys converts keys reported by the challenge bypass server, skipping those without a validity period.
+func newIssuerKeys(keys []cbr.IssuerKey) []model.IssuerKeyNew {
+	result := make([]model.IssuerKeyNew, 0, len(keys))
+
+	for i := range keys {
+		if keys[i].StartAt == nil || keys[i].EndAt == nil {
+			continue
+		}
+
+		result = append(result, model.IssuerKeyNew{
+			PublicKey: keys[i].PublicKey,
+			ValidFrom: keys[i].StartAt.UTC(),
+			ValidTo:   keys[i].EndAt.UTC(),
+		})
+	}
+
+	return result
+}
+
+// lastIssuerKeyEnd returns when the last of keys ends, or the zero time if there are none.
+func lastIssuerKeyEnd(keys []model.IssuerKeyNew) time.Time {
+	var result time.Time
+
+	for i := range keys {
+		if keys[i].ValidTo.After(result) {
+			result = keys[i].ValidTo
+		}
+	}
+
+	return result
+}
+
+// issuerKeyBuffer returns how long until keys run out, which is zero if there are none or all have ended.
+func issuerKeyBuffer(keys []model.IssuerKeyNew, now time.Time) time.Duration {
+	if len(keys) == 0 {
+		return 0
+	}
+
+	result := lastIssuerKeyEnd(keys).Sub(now)
+	if result < 0 {
+		return 0
+	}
+
+	return result
+}
@@ -0,0 +1,306 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/backoff"
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	mockcb "github.com/brave-intl/bat-go/libs/clients/cbr/mock"
+	"github.com/brave-intl/bat-go/libs/ptr"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestNewIssuerKeys(t *testing.T) {
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	type testCase struct {
+		name  string
+		given []cbr.IssuerKey
+		exp   []model.IssuerKeyNew
+	}
+
+	tests := []testCase{
+		{
+			name: "empty",
+			exp:  []model.IssuerKeyNew{},
+		},
+
+		{
+			name: "skips_incomplete",
+			given: []cbr.IssuerKey{
+				{PublicKey: "key_01", StartAt: &from},
+				{PublicKey: "key_02", EndAt: &to},
+				{PublicKey: "key_03", StartAt: &from, EndAt: &to},
+			},
+			exp: []model.IssuerKeyNew{
+				{PublicKey: "key_03", ValidFrom: from, ValidTo: to},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, newIssuerKeys(tc.given))
+		})
+	}
+}
+
+func TestLastIssuerKeyEnd(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		name  string
+		given []model.IssuerKeyNew
+		exp   time.Time
+	}
+
+	tests := []testCase{
+		{
+			name: "empty",
+		},
+
+		{
+			name: "unordered",
+			given: []model.IssuerKeyNew{
+				{ValidTo: now.Add(48 * time.Hour)},
+				{ValidTo: now.Add(72 * time.Hour)},
+				{ValidTo: now.Add(24 * time.Hour)},
+			},
+			exp: now.Add(72 * time.Hour),
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, lastIssuerKeyEnd(tc.given))
+		})
+	}
+}
+
+func TestIssuerKeyBuffer(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		name  string
+		given []model.IssuerKeyNew
+		exp   time.Duration
+	}
+
+	tests := []testCase{
+		{
+			name: "no_keys",
+		},
+
+		{
+			name:  "ended",
+			given: []model.IssuerKeyNew{{ValidFrom: now.Add(-48 * time.Hour), ValidTo: now.Add(-24 * time.Hour)}},
+		},
+
+		{
+			name: "last_key",
+			given: []model.IssuerKeyNew{
+				{ValidFrom: now, ValidTo: now.Add(24 * time.Hour)},
+				{ValidFrom: now.Add(24 * time.Hour), ValidTo: now.Add(48 * time.Hour)},
+			},
+			exp: 48 * time.Hour,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, issuerKeyBuffer(tc.given, now))
+		})
+	}
+}
+
+func TestService_checkIssuerKeys(t *testing.T) {
+	type tcGiven struct {
+		keys    [][]cbr.IssuerKey
+		getErr  error
+		rotates int
+	}
+
+	type tcExpected struct {
+		upserts int
+		err     error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	now := time.Now().UTC()
+
+	newKey := func(name string, from, to time.Time) cbr.IssuerKey {
+		return cbr.IssuerKey{PublicKey: name, StartAt: ptr.FromTime(from), EndAt: ptr.FromTime(to)}
+	}
+
+	tests := []testCase{
+		{
+			name:  "get_error",
+			given: tcGiven{getErr: model.Error("something went wrong")},
+			exp:   tcExpected{err: model.Error("something went wrong")},
+		},
+
+		{
+			name: "enough_keys",
+			given: tcGiven{
+				keys: [][]cbr.IssuerKey{
+					{newKey("key_01", now, now.Add(30*24*time.Hour))},
+				},
+			},
+			exp: tcExpected{upserts: 1},
+		},
+
+		{
+			name: "next_period",
+			given: tcGiven{
+				keys: [][]cbr.IssuerKey{
+					{newKey("key_01", now, now.Add(24*time.Hour))},
+					{newKey("key_01", now, now.Add(24*time.Hour)), newKey("key_02", now.Add(24*time.Hour), now.Add(30*24*time.Hour))},
+				},
+				rotates: 1,
+			},
+			exp: tcExpected{upserts: 2},
+		},
+
+		{
+			name: "no_keys",
+			given: tcGiven{
+				keys:    [][]cbr.IssuerKey{{}, {}},
+				rotates: 1,
+			},
+			exp: tcExpected{upserts: 2, err: errIssuerNoKeys},
+		},
+
+		{
+			name: "no_keys_rotated",
+			given: tcGiven{
+				keys: [][]cbr.IssuerKey{
+					{},
+					{newKey("key_01", now, now.Add(30*24*time.Hour))},
+				},
+				rotates: 1,
+			},
+			exp: tcExpected{upserts: 2},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			iss := &model.Issuer{ID: uuid.NewV4(), MerchantID: "brave.com?sku=brave-vpn-premium", Version: 3}
+
+			cb := mockcb.NewMockClient(ctrl)
+
+			var ngets int
+			cb.EXPECT().GetIssuerV3(gomock.Any(), iss.Name()).DoAndReturn(func(ctx context.Context, name string) (*cbr.IssuerResponse, error) {
+				if tc.given.getErr != nil {
+					return nil, tc.given.getErr
+				}
+
+				ngets++
+
+				return &cbr.IssuerResponse{Name: name, Keys: tc.given.keys[ngets-1]}, nil
+			}).AnyTimes()
+
+			cb.EXPECT().RotateIssuerV3(gomock.Any(), iss.Name(), cbr.IssuerRotateRequest{Buffer: defaultBuffer}).Return(nil).Times(tc.given.rotates)
+
+			var upserts int
+			keyRepo := &repository.MockIssuerKey{
+				FnUpsert: func(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, keys []model.IssuerKeyNew) error {
+					should.Equal(t, iss.ID, issuerID)
+
+					upserts++
+
+					return nil
+				},
+			}
+
+			svc := &Service{cbClient: cb, issuerKeyRepo: keyRepo, retry: backoff.Retry}
+
+			err := svc.checkIssuerKeys(context.Background(), nil, iss, now)
+			must.Equal(t, true, errors.Is(err, tc.exp.err))
+
+			should.Equal(t, tc.exp.upserts, upserts)
+		})
+	}
+}
+
+func TestService_RotateIssuerKeys(t *testing.T) {
+	type tcGiven struct {
+		iss    *model.Issuer
+		issErr error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "not_found",
+			given: tcGiven{issErr: model.ErrIssuerNotFound},
+			exp:   model.ErrIssuerNotFound,
+		},
+
+		{
+			name:  "not_v3",
+			given: tcGiven{iss: &model.Issuer{ID: uuid.NewV4(), Version: 1}},
+			exp:   model.ErrIssuerNotV3,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Nothing is rotated unless the issuer supports it.
+			cb := mockcb.NewMockClient(ctrl)
+
+			issRepo := &repository.MockIssuer{
+				FnGetByMerchID: func(ctx context.Context, dbi sqlx.QueryerContext, merchID string) (*model.Issuer, error) {
+					should.Equal(t, "brave.com?sku=brave-vpn-premium", merchID)
+
+					return tc.given.iss, tc.given.issErr
+				},
+			}
+
+			svc := &Service{Datastore: &Postgres{}, cbClient: cb, issuerRepo: issRepo, retry: backoff.Retry}
+
+			req := &model.RotateIssuerRequest{MerchantID: "brave.com", SKU: "brave-vpn-premium"}
+
+			_, err := svc.RotateIssuerKeys(context.Background(), req)
+			should.Equal(t, tc.exp, err)
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/brave-intl/bat-go/libs/cryptography"
@@ -29,6 +30,16 @@ const (
 	errMerchantMismatch model.Error = "Order merchant does not match authentication"
 	errLocationMismatch model.Error = "Order location does not match authentication"
 	errUnexpectedSKUCvt model.Error = "SKU caveat is not supported on order endpoints"
+	errInvalidKeyScope  model.Error = "invalid merchant key scope"
+)
+
+// Scopes restrict what a merchant key can be used for.
+//
+// A key without scopes can be used for everything, as keys created before scopes were introduced.
+const (
+	KeyScopeVerify           = "verify"
+	KeyScopeReadTransactions = "read_transactions"
+	KeyScopeManageOrders     = "manage_orders"
 )
 
 var (
@@ -40,6 +51,7 @@ var (
 
 type caveatsCtxKey struct{}
 type merchantCtxKey struct{}
+type merchantKeyCtxKey struct{}
 
 // Key represents a merchant's keys to validate skus
 type Key struct {
@@ -50,6 +62,41 @@ type Key struct {
 	Nonce              string     `json:"-" db:"nonce"`
 	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
 	Expiry             *time.Time `json:"expiry" db:"expiry"`
+
+	Scopes        pq.StringArray `json:"scopes" db:"scopes"`
+	LastUsedAt    *time.Time     `json:"lastUsedAt" db:"last_used_at"`
+	RotatedFromID *string        `json:"rotatedFromId,omitempty" db:"rotated_from_id"`
+
+	RotateEverySeconds     *int       `json:"rotateEverySeconds,omitempty" db:"rotate_every_seconds"`
+	RotationOverlapSeconds int        `json:"rotationOverlapSeconds,omitempty" db:"rotation_overlap_seconds"`
+	NextRotationAt         *time.Time `json:"nextRotationAt,omitempty" db:"next_rotation_at"`
+}
+
+// HasScope reports whether the key can be used for scope.
+func (key *Key) HasScope(scope string) bool {
+	if len(key.Scopes) == 0 {
+		return true
+	}
+
+	for i := range key.Scopes {
+		if key.Scopes[i] == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateKeyScopes(scopes []string) error {
+	for i := range scopes {
+		switch scopes[i] {
+		case KeyScopeVerify, KeyScopeReadTransactions, KeyScopeManageOrders:
+		default:
+			return errInvalidKeyScope
+		}
+	}
+
+	return nil
 }
 
 // InitEncryptionKeys copies the specified encryption key into memory once
@@ -138,10 +185,20 @@ func (s *Service) LookupVerifier(ctx context.Context, keyID string) (context.Con
 	}
 
 	ctx = context.WithValue(ctx, merchantCtxKey{}, key.Merchant)
+	ctx = context.WithValue(ctx, merchantKeyCtxKey{}, key)
 
 	return ctx, httpsignature.HMACKey(secretKeyStr), nil
 }
 
+// merchantKeyFromCtx returns the key a request has been signed with.
+//
+// There is no key for requests authorised with a simple token.
+func merchantKeyFromCtx(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(merchantKeyCtxKey{}).(*Key)
+
+	return key, ok
+}
+
 // caveatsFromCtx returns authorized caveats from ctx.
 func caveatsFromCtx(ctx context.Context) map[string]string {
 	caveats, ok := ctx.Value(caveatsCtxKey{}).(map[string]string)
@@ -183,7 +240,9 @@ func (s *Service) validateOrderMerchantAndCaveats(ctx context.Context, oid uuid.
 }
 
 // NewAuthMwr returns a handler that authorises requests via http signature or simple tokens.
-func NewAuthMwr(ks httpsignature.Keystore) func(http.Handler) http.Handler {
+//
+// Uses of merchant keys are recorded once the signature has been verified.
+func NewAuthMwr(svc *Service) func(http.Handler) http.Handler {
 	merchantVerifier := httpsignature.ParameterizedKeystoreVerifier{
 		SignatureParams: httpsignature.SignatureParams{
 			Algorithm: httpsignature.HS2019,
@@ -196,7 +255,7 @@ func NewAuthMwr(ks httpsignature.Keystore) func(http.Handler) http.Handler {
 				"content-type",
 			},
 		},
-		Keystore: ks,
+		Keystore: svc,
 		Opts:     crypto.Hash(0),
 	}
 
@@ -210,7 +269,7 @@ func NewAuthMwr(ks httpsignature.Keystore) func(http.Handler) http.Handler {
 				return
 			}
 
-			middleware.VerifyHTTPSignedOnly(merchantVerifier)(next).ServeHTTP(w, r)
+			middleware.VerifyHTTPSignedOnly(merchantVerifier)(newKeyUseMwr(svc)(next)).ServeHTTP(w, r)
 		})
 	}
 }
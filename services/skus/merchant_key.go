@@ -0,0 +1,449 @@
+package skus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	errKeyScopeNotAllowed model.Error = "merchant key is not allowed to access the resource"
+
+	// keyUseBufferSize is how many key uses are held before they are written, further uses are dropped.
+	keyUseBufferSize = 10000
+
+	keyUseFlushBatchSize = 500
+	keyUsePruneBatchSize = 1000
+
+	defaultKeyUseRetention = 90 * 24 * time.Hour
+
+	keyRotationRetryDelay = 10 * time.Minute
+)
+
+var keyUsesDroppedCounter = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "skus_merchant_key_uses_dropped_total",
+		Help: "Merchant key uses not recorded because the buffer was full.",
+	},
+)
+
+type merchantKeyStore interface {
+	SetLastUsedAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error
+	InsertUses(ctx context.Context, dbi sqlx.ExtContext, uses []model.MerchantKeyUse) error
+	DeleteUsesBefore(ctx context.Context, dbi sqlx.ExecerContext, before time.Time, limit int) (int64, error)
+	ListUses(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantKeyUseFilter) ([]model.MerchantKeyUse, error)
+	SetRotation(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID uuid.UUID, rot model.MerchantKeyRotation, now time.Time) error
+	ClaimDueRotation(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error)
+	SetNextRotationAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error
+}
+
+type merchantKeyConfig struct {
+	useRetention time.Duration
+}
+
+func newMerchantKeyConfig() (*merchantKeyConfig, error) {
+	result := &merchantKeyConfig{useRetention: defaultKeyUseRetention}
+
+	if raw := os.Getenv("SKUS_MERCHANT_KEY_USE_RETENTION"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("skus: invalid merchant key use retention: %w", err)
+		}
+
+		result.useRetention = val
+	}
+
+	return result, nil
+}
+
+// keyUseBuffer holds key uses in memory until they are written.
+//
+// Uses still in the buffer when the process exits are lost, which is acceptable for an audit of usage.
+type keyUseBuffer struct {
+	mu   sync.Mutex
+	uses []model.MerchantKeyUse
+	size int
+}
+
+func newKeyUseBuffer(size int) *keyUseBuffer {
+	return &keyUseBuffer{size: size}
+}
+
+// add appends the use, and reports false if the buffer is full.
+func (b *keyUseBuffer) add(use model.MerchantKeyUse) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.uses) >= b.size {
+		return false
+	}
+
+	b.uses = append(b.uses, use)
+
+	return true
+}
+
+// take removes and returns up to n of the oldest uses.
+func (b *keyUseBuffer) take(n int) []model.MerchantKeyUse {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > len(b.uses) {
+		n = len(b.uses)
+	}
+
+	result := make([]model.MerchantKeyUse, n)
+	copy(result, b.uses[:n])
+
+	b.uses = b.uses[n:]
+
+	return result
+}
+
+// putBack returns uses to the front of the buffer, as many as fit.
+func (b *keyUseBuffer) putBack(uses []model.MerchantKeyUse) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	free := b.size - len(b.uses)
+	if free <= 0 {
+		keyUsesDroppedCounter.Add(float64(len(uses)))
+		return
+	}
+
+	if len(uses) > free {
+		keyUsesDroppedCounter.Add(float64(len(uses) - free))
+		uses = uses[:free]
+	}
+
+	b.uses = append(append(make([]model.MerchantKeyUse, 0, len(uses)+len(b.uses)), uses...), b.uses...)
+}
+
+// WithKeyScope returns authMwr which additionally requires requests signed with a merchant key to have scope.
+//
+// Requests authorised with a simple token are not affected.
+func WithKeyScope(authMwr func(http.Handler) http.Handler, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMwr(requireKeyScope(scope)(next))
+	}
+}
+
+func requireKeyScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := merchantKeyFromCtx(r.Context()); ok && !key.HasScope(scope) {
+				ae := handlers.WrapError(errKeyScopeNotAllowed, "Merchant key is missing the "+scope+" scope", http.StatusForbidden)
+				ae.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newKeyUseMwr returns a handler that records uses of merchant keys after serving requests.
+//
+// Uses are buffered in memory and written in batches by RunFlushKeyUsesJob, so requests do not wait on the database.
+func newKeyUseMwr(svc *Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			ctx := r.Context()
+
+			key, ok := merchantKeyFromCtx(ctx)
+			if !ok {
+				return
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if err := svc.recordKeyUse(key, r.Method, r.URL.Path, status, time.Now().UTC()); err != nil {
+				lg := logging.Logger(ctx, "skus").With().Str("func", "newKeyUseMwr").Logger()
+				lg.Err(err).Str("key_id", key.ID).Msg("failed to record merchant key use")
+			}
+		})
+	}
+}
+
+// recordKeyUse buffers an audit record of the request.
+//
+// A failure to record does not affect the request, which has already been served.
+func (s *Service) recordKeyUse(key *Key, method, path string, status int, now time.Time) error {
+	keyID, err := uuid.FromString(key.ID)
+	if err != nil {
+		return err
+	}
+
+	use := model.MerchantKeyUse{
+		CreatedAt:  now,
+		KeyID:      keyID,
+		MerchantID: key.Merchant,
+		Method:     method,
+		Path:       path,
+		Status:     status,
+	}
+
+	if !s.keyUses.add(use) {
+		keyUsesDroppedCounter.Inc()
+	}
+
+	return nil
+}
+
+// RunFlushKeyUsesJob writes a batch of buffered key uses, and updates when each key was last used.
+//
+// A batch that fails to be written is put back to be retried on the next run.
+func (s *Service) RunFlushKeyUsesJob(ctx context.Context) (bool, error) {
+	uses := s.keyUses.take(keyUseFlushBatchSize)
+	if len(uses) == 0 {
+		return false, nil
+	}
+
+	if err := s.flushKeyUses(ctx, uses); err != nil {
+		s.keyUses.putBack(uses)
+
+		return true, err
+	}
+
+	return true, nil
+}
+
+func (s *Service) flushKeyUses(ctx context.Context, uses []model.MerchantKeyUse) error {
+	lastUsed := make(map[uuid.UUID]time.Time)
+	for i := range uses {
+		if uses[i].CreatedAt.After(lastUsed[uses[i].KeyID]) {
+			lastUsed[uses[i].KeyID] = uses[i].CreatedAt
+		}
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.merchKeyRepo.InsertUses(ctx, tx, uses); err != nil {
+		return err
+	}
+
+	for keyID, when := range lastUsed {
+		if err := s.merchKeyRepo.SetLastUsedAt(ctx, tx, keyID, when); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RunPruneKeyUsesJob deletes key uses older than the retention period, in batches.
+func (s *Service) RunPruneKeyUsesJob(ctx context.Context) (bool, error) {
+	before := time.Now().Add(-s.merchKeyCfg.useRetention)
+
+	for {
+		n, err := s.merchKeyRepo.DeleteUsesBefore(ctx, s.Datastore.RawDB(), before, keyUsePruneBatchSize)
+		if err != nil {
+			return false, err
+		}
+
+		if n < keyUsePruneBatchSize {
+			return true, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return true, err
+		}
+	}
+}
+
+// ListMerchantKeyUses returns audit records of uses of the merchant's keys.
+func (s *Service) ListMerchantKeyUses(ctx context.Context, merchID string, filter model.MerchantKeyUseFilter) ([]model.MerchantKeyUse, error) {
+	return s.merchKeyRepo.ListUses(ctx, s.Datastore.RawDB(), merchID, filter)
+}
+
+// RotateMerchantKey replaces the key with a new one, and keeps the old key valid for the overlap.
+//
+// The rotation is delivered to the webhook endpoints of the merchant, so that integrations can fetch the new key,
+// whether it was requested or scheduled.
+func (s *Service) RotateMerchantKey(ctx context.Context, merchID string, id uuid.UUID, overlap time.Duration) (*Key, error) {
+	prev, err := s.Datastore.GetKey(id, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrMerchantKeyNotFound
+		}
+
+		return nil, err
+	}
+
+	if prev.Merchant != merchID {
+		return nil, model.ErrMerchantKeyNotFound
+	}
+
+	encrypted, nonce, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := s.Datastore.RotateKeyTx(ctx, tx, id, int(overlap/time.Second), encrypted, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, model.ErrMerchantKeyNotFound
+	}
+
+	// Webhooks are signed with the new key straight away, as merchants can tell keys apart by the key id.
+	newID, err := uuid.FromString(result.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.merchHookRepo.ReplaceKey(ctx, tx, id, newID); err != nil {
+		return nil, err
+	}
+
+	if err := s.merchHookRepo.InsertKeyRotated(ctx, tx, merchID, id, newID, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetMerchantKeyRotation schedules the key to be rotated periodically, or turns scheduled rotation off.
+func (s *Service) SetMerchantKeyRotation(ctx context.Context, merchID string, id uuid.UUID, rot model.MerchantKeyRotation) error {
+	if err := rot.IsValid(); err != nil {
+		return err
+	}
+
+	return s.merchKeyRepo.SetRotation(ctx, s.Datastore.RawDB(), merchID, id, rot, time.Now())
+}
+
+// GetMerchantKey returns the active key of the merchant.
+func (s *Service) GetMerchantKey(_ context.Context, merchID string, id uuid.UUID) (*Key, error) {
+	result, err := s.Datastore.GetKey(id, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrMerchantKeyNotFound
+		}
+
+		return nil, err
+	}
+
+	if result.Merchant != merchID {
+		return nil, model.ErrMerchantKeyNotFound
+	}
+
+	return result, nil
+}
+
+// RunScheduledKeyRotationJob rotates the next key whose scheduled rotation is due.
+//
+// The key is claimed first, so the rotation runs without holding a lock. A failed rotation is retried later.
+func (s *Service) RunScheduledKeyRotationJob(ctx context.Context) (bool, error) {
+	dbi := s.Datastore.RawDB()
+
+	due, err := s.merchKeyRepo.ClaimDueRotation(ctx, dbi, time.Now())
+	if err != nil {
+		if errors.Is(err, model.ErrMerchantKeyRotationNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	overlap := time.Duration(due.OverlapSeconds) * time.Second
+
+	if _, err := s.RotateMerchantKey(ctx, due.MerchantID, due.KeyID, overlap); err != nil {
+		// The key has expired since it was claimed, so there is nothing to rotate.
+		if errors.Is(err, model.ErrMerchantKeyNotFound) {
+			return true, nil
+		}
+
+		if rerr := s.merchKeyRepo.SetNextRotationAt(ctx, dbi, due.KeyID, time.Now().Add(keyRotationRetryDelay)); rerr != nil {
+			return true, fmt.Errorf("failed to reschedule key rotation after %s: %w", err, rerr)
+		}
+
+		return true, err
+	}
+
+	return true, nil
+}
+
+// parseKeyUseFilter reads a filter from the query parameters keyId, before and limit.
+func parseKeyUseFilter(r *http.Request) (model.MerchantKeyUseFilter, error) {
+	var result model.MerchantKeyUseFilter
+
+	q := r.URL.Query()
+
+	if raw := q.Get("keyId"); raw != "" {
+		keyID, err := uuid.FromString(raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.KeyID = uuid.NullUUID{UUID: keyID, Valid: true}
+	}
+
+	if raw := q.Get("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.Before = before
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.Limit = limit
+	}
+
+	return result, nil
+}
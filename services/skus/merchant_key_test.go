@@ -0,0 +1,645 @@
+package skus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestKey_HasScope(t *testing.T) {
+	type tcGiven struct {
+		scopes []string
+		scope  string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "no_scopes",
+			given: tcGiven{scope: KeyScopeManageOrders},
+			exp:   true,
+		},
+
+		{
+			name:  "has_scope",
+			given: tcGiven{scopes: []string{KeyScopeVerify, KeyScopeManageOrders}, scope: KeyScopeManageOrders},
+			exp:   true,
+		},
+
+		{
+			name:  "missing_scope",
+			given: tcGiven{scopes: []string{KeyScopeVerify}, scope: KeyScopeReadTransactions},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			key := &Key{Scopes: tc.given.scopes}
+
+			should.Equal(t, tc.exp, key.HasScope(tc.given.scope))
+		})
+	}
+}
+
+func TestValidateKeyScopes(t *testing.T) {
+	type testCase struct {
+		name  string
+		given []string
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "empty",
+		},
+
+		{
+			name:  "valid",
+			given: []string{KeyScopeVerify, KeyScopeReadTransactions, KeyScopeManageOrders},
+		},
+
+		{
+			name:  "invalid",
+			given: []string{KeyScopeVerify, "admin"},
+			exp:   errInvalidKeyScope,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, validateKeyScopes(tc.given))
+		})
+	}
+}
+
+func TestRequireKeyScope(t *testing.T) {
+	type tcGiven struct {
+		ctx context.Context
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name:  "simple_token",
+			given: tcGiven{ctx: context.Background()},
+			exp:   http.StatusOK,
+		},
+
+		{
+			name: "key_without_scopes",
+			given: tcGiven{
+				ctx: context.WithValue(context.Background(), merchantKeyCtxKey{}, &Key{}),
+			},
+			exp: http.StatusOK,
+		},
+
+		{
+			name: "key_with_scope",
+			given: tcGiven{
+				ctx: context.WithValue(context.Background(), merchantKeyCtxKey{}, &Key{Scopes: []string{KeyScopeVerify}}),
+			},
+			exp: http.StatusOK,
+		},
+
+		{
+			name: "key_without_scope",
+			given: tcGiven{
+				ctx: context.WithValue(context.Background(), merchantKeyCtxKey{}, &Key{Scopes: []string{KeyScopeReadTransactions}}),
+			},
+			exp: http.StatusForbidden,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/credentials/subscription/verifications", nil).WithContext(tc.given.ctx)
+			rw := httptest.NewRecorder()
+
+			requireKeyScope(KeyScopeVerify)(next).ServeHTTP(rw, req)
+
+			should.Equal(t, tc.exp, rw.Code)
+		})
+	}
+}
+
+func TestParseKeyUseFilter(t *testing.T) {
+	type tcExpected struct {
+		val     model.MerchantKeyUseFilter
+		mustErr bool
+	}
+
+	type testCase struct {
+		name  string
+		given string
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "empty",
+			given: "/keys/uses",
+		},
+
+		{
+			name:  "all",
+			given: "/keys/uses?keyId=5ca1ab1e-0000-4000-a000-000000000000&before=2024-01-01T00:00:00Z&limit=10",
+			exp: tcExpected{
+				val: model.MerchantKeyUseFilter{
+					KeyID:  uuid.NullUUID{UUID: uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000")), Valid: true},
+					Before: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+					Limit:  10,
+				},
+			},
+		},
+
+		{
+			name:  "invalid_key_id",
+			given: "/keys/uses?keyId=invalid",
+			exp:   tcExpected{mustErr: true},
+		},
+
+		{
+			name:  "invalid_before",
+			given: "/keys/uses?before=yesterday",
+			exp:   tcExpected{mustErr: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseKeyUseFilter(httptest.NewRequest(http.MethodGet, tc.given, nil))
+			if tc.exp.mustErr {
+				must.Error(t, err)
+				return
+			}
+
+			must.NoError(t, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestNewMerchantKeyConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("SKUS_MERCHANT_KEY_USE_RETENTION", "")
+
+		actual, err := newMerchantKeyConfig()
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 90*24*time.Hour, actual.useRetention)
+	})
+
+	t.Run("from_env", func(t *testing.T) {
+		t.Setenv("SKUS_MERCHANT_KEY_USE_RETENTION", "720h")
+
+		actual, err := newMerchantKeyConfig()
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 720*time.Hour, actual.useRetention)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("SKUS_MERCHANT_KEY_USE_RETENTION", "month")
+
+		_, err := newMerchantKeyConfig()
+		should.NotNil(t, err)
+	})
+}
+
+func TestKeyUseBuffer(t *testing.T) {
+	buf := newKeyUseBuffer(3)
+
+	uses := []model.MerchantKeyUse{{Path: "/1"}, {Path: "/2"}, {Path: "/3"}, {Path: "/4"}}
+
+	should.True(t, buf.add(uses[0]))
+	should.True(t, buf.add(uses[1]))
+	should.True(t, buf.add(uses[2]))
+	should.False(t, buf.add(uses[3]))
+
+	taken := buf.take(2)
+	should.Equal(t, uses[:2], taken)
+
+	should.True(t, buf.add(uses[3]))
+
+	// Only as many as fit go back, in front of the newer uses.
+	buf.putBack(taken)
+	should.Equal(t, []model.MerchantKeyUse{uses[0], uses[2], uses[3]}, buf.take(10))
+
+	should.Len(t, buf.take(10), 0)
+
+	var empty *keyUseBuffer
+	should.False(t, empty.add(uses[0]))
+	should.Len(t, empty.take(10), 0)
+}
+
+func TestService_RunFlushKeyUsesJob(t *testing.T) {
+	type tcGiven struct {
+		uses []model.MerchantKeyUse
+		mock func(mock sqlmock.Sqlmock)
+		repo *repository.MockMerchantKey
+	}
+
+	type tcExpected struct {
+		val      bool
+		err      error
+		lastUsed map[uuid.UUID]time.Time
+		left     int
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	key01 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	key02 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	uses := []model.MerchantKeyUse{
+		{CreatedAt: now.Add(time.Minute), KeyID: key01},
+		{CreatedAt: now, KeyID: key01},
+		{CreatedAt: now, KeyID: key02},
+	}
+
+	tests := []testCase{
+		{
+			name: "empty",
+			given: tcGiven{
+				mock: func(mock sqlmock.Sqlmock) {},
+				repo: &repository.MockMerchantKey{},
+			},
+			exp: tcExpected{lastUsed: map[uuid.UUID]time.Time{}},
+		},
+
+		{
+			name: "insert_error",
+			given: tcGiven{
+				uses: uses,
+				mock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				},
+				repo: &repository.MockMerchantKey{
+					FnInsertUses: func(ctx context.Context, dbi sqlx.ExtContext, uses []model.MerchantKeyUse) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: tcExpected{
+				val:      true,
+				err:      model.Error("something_went_wrong"),
+				lastUsed: map[uuid.UUID]time.Time{},
+				left:     3,
+			},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				uses: uses,
+				mock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectCommit()
+				},
+				repo: &repository.MockMerchantKey{},
+			},
+			exp: tcExpected{
+				val:      true,
+				lastUsed: map[uuid.UUID]time.Time{key01: now.Add(time.Minute), key02: now},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			must.NoError(t, err)
+
+			tc.given.mock(mock)
+
+			lastUsed := make(map[uuid.UUID]time.Time)
+			tc.given.repo.FnSetLastUsedAt = func(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+				lastUsed[keyID] = when
+				return nil
+			}
+
+			buf := newKeyUseBuffer(keyUseBufferSize)
+			for j := range tc.given.uses {
+				must.True(t, buf.add(tc.given.uses[j]))
+			}
+
+			svc := &Service{
+				Datastore:    &Postgres{Postgres: datastore.Postgres{DB: sqlx.NewDb(db, "postgres")}},
+				merchKeyRepo: tc.given.repo,
+				keyUses:      buf,
+			}
+
+			actual, err := svc.RunFlushKeyUsesJob(context.Background())
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.val, actual)
+			should.Equal(t, tc.exp.lastUsed, lastUsed)
+			should.Len(t, buf.take(keyUseBufferSize), tc.exp.left)
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_RotateMerchantKey_NotFound(t *testing.T) {
+	type tcGiven struct {
+		key *Key
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	id := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+
+	tests := []testCase{
+		{
+			name:  "no_rows",
+			given: tcGiven{err: fmt.Errorf("failed to get key: %w", sql.ErrNoRows)},
+			exp:   model.ErrMerchantKeyNotFound,
+		},
+
+		{
+			name:  "other_error",
+			given: tcGiven{err: model.Error("something_went_wrong")},
+			exp:   model.Error("something_went_wrong"),
+		},
+
+		{
+			name:  "other_merchant",
+			given: tcGiven{key: &Key{ID: id.String(), Merchant: "other.com"}},
+			exp:   model.ErrMerchantKeyNotFound,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ds := NewMockDatastore(ctrl)
+			ds.EXPECT().GetKey(id, false).Return(tc.given.key, tc.given.err)
+
+			svc := &Service{Datastore: ds}
+
+			actual, err := svc.RotateMerchantKey(context.Background(), "brave.com", id, time.Minute)
+			should.ErrorIs(t, err, tc.exp)
+			should.Nil(t, actual)
+		})
+	}
+}
+
+func TestService_RotateMerchantKey(t *testing.T) {
+	type tcGiven struct {
+		rotated   *Key
+		rotateErr error
+		hookRepo  *repository.MockMerchantWebhook
+		mock      func(mock sqlmock.Sqlmock)
+	}
+
+	type tcExpected struct {
+		val *Key
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	id := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	newID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+
+	tests := []testCase{
+		{
+			name: "expired",
+			given: tcGiven{
+				hookRepo: &repository.MockMerchantWebhook{},
+				mock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				},
+			},
+			exp: tcExpected{err: model.ErrMerchantKeyNotFound},
+		},
+
+		{
+			name: "notify_error",
+			given: tcGiven{
+				rotated: &Key{ID: newID.String(), Merchant: "brave.com"},
+				hookRepo: &repository.MockMerchantWebhook{
+					FnInsertKeyRotated: func(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				mock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				},
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				rotated: &Key{ID: newID.String(), Merchant: "brave.com"},
+				hookRepo: &repository.MockMerchantWebhook{
+					FnReplaceKey: func(ctx context.Context, dbi sqlx.ExecerContext, oldID, nID uuid.UUID) error {
+						if !uuid.Equal(oldID, id) || !uuid.Equal(nID, newID) {
+							return model.Error("unexpected_key_ids")
+						}
+
+						return nil
+					},
+
+					FnInsertKeyRotated: func(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error {
+						if merchID != "brave.com" {
+							return model.Error("unexpected_merchant")
+						}
+
+						if !uuid.Equal(keyID, id) || !uuid.Equal(newKeyID, newID) {
+							return model.Error("unexpected_key_ids")
+						}
+
+						return nil
+					},
+				},
+				mock: func(mock sqlmock.Sqlmock) {
+					mock.ExpectBegin()
+					mock.ExpectCommit()
+				},
+			},
+			exp: tcExpected{val: &Key{ID: newID.String(), Merchant: "brave.com"}},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			db, mock, err := sqlmock.New()
+			must.NoError(t, err)
+
+			tc.given.mock(mock)
+
+			ds := NewMockDatastore(ctrl)
+			ds.EXPECT().GetKey(id, false).Return(&Key{ID: id.String(), Merchant: "brave.com"}, nil)
+			ds.EXPECT().RawDB().Return(sqlx.NewDb(db, "postgres"))
+			ds.EXPECT().RotateKeyTx(gomock.Any(), gomock.Any(), id, 60, gomock.Any(), gomock.Any()).Return(tc.given.rotated, tc.given.rotateErr)
+
+			svc := &Service{Datastore: ds, merchHookRepo: tc.given.hookRepo}
+
+			actual, err := svc.RotateMerchantKey(context.Background(), "brave.com", id, time.Minute)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.val, actual)
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestService_RunScheduledKeyRotationJob(t *testing.T) {
+	type tcGiven struct {
+		repo   *repository.MockMerchantKey
+		getErr error
+	}
+
+	type tcExpected struct {
+		val         bool
+		err         error
+		rescheduled bool
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	id := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+
+	claimed := func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error) {
+		return &model.MerchantKeyDueRotation{KeyID: id, MerchantID: "brave.com", OverlapSeconds: 60}, nil
+	}
+
+	tests := []testCase{
+		{
+			name:  "none_due",
+			given: tcGiven{repo: &repository.MockMerchantKey{}},
+		},
+
+		{
+			name: "claim_error",
+			given: tcGiven{
+				repo: &repository.MockMerchantKey{
+					FnClaimDueRotation: func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "key_expired",
+			given: tcGiven{
+				repo:   &repository.MockMerchantKey{FnClaimDueRotation: claimed},
+				getErr: fmt.Errorf("failed to get key: %w", sql.ErrNoRows),
+			},
+			exp: tcExpected{val: true},
+		},
+
+		{
+			name: "rotation_error",
+			given: tcGiven{
+				repo:   &repository.MockMerchantKey{FnClaimDueRotation: claimed},
+				getErr: model.Error("something_went_wrong"),
+			},
+			exp: tcExpected{val: true, err: model.Error("something_went_wrong"), rescheduled: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ds := NewMockDatastore(ctrl)
+			ds.EXPECT().RawDB().Return(nil).AnyTimes()
+			ds.EXPECT().GetKey(id, false).Return(nil, tc.given.getErr).AnyTimes()
+
+			var rescheduled bool
+			tc.given.repo.FnSetNextRotationAt = func(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+				rescheduled = keyID == id
+				return nil
+			}
+
+			svc := &Service{Datastore: ds, merchKeyRepo: tc.given.repo}
+
+			actual, err := svc.RunScheduledKeyRotationJob(context.Background())
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.val, actual)
+			should.Equal(t, tc.exp.rescheduled, rescheduled)
+		})
+	}
+}
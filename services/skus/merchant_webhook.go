@@ -0,0 +1,497 @@
+package skus
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/libs/backoff/retrypolicy"
+	"github.com/brave-intl/bat-go/libs/httpsignature"
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	merchantWebhookBatchSize = 50
+
+	// merchantWebhookTimeout limits a single request to a merchant endpoint.
+	merchantWebhookTimeout = 10 * time.Second
+
+	// merchantWebhookMaxAttempts is how many times a delivery is attempted before it fails.
+	//
+	// With the schedule below, attempts span about eight hours.
+	merchantWebhookMaxAttempts = 10
+
+	// merchantWebhookLease is how long a claimed delivery is not attempted by other workers.
+	//
+	// It covers an attempt with all of its retries.
+	merchantWebhookLease = 5 * time.Minute
+
+	errWebhookKeyRequired      model.Error = "webhook endpoints must be registered with a request signed by a merchant key"
+	errWebhookKeyNotActive     model.Error = "signing key is not active"
+	errWebhookAddrNotAllowed   model.Error = "endpoint address is not allowed"
+	errWebhookRequestFailed    model.Error = "request failed"
+	errWebhookRequestTimedOut  model.Error = "request timed out"
+	errWebhookRedirectRejected model.Error = "redirects are not followed"
+)
+
+var (
+	// merchantWebhookSignedHeaders are signed in requests to merchant endpoints.
+	//
+	// They are the same headers merchants sign in requests to skus.
+	merchantWebhookSignedHeaders = []string{"(request-target)", "host", "date", "digest", "content-length", "content-type"}
+
+	// merchantWebhookAttemptRetry retries a failed request a few times before the attempt is recorded as failed.
+	merchantWebhookAttemptRetry = func() (retrypolicy.Retry, error) {
+		return retrypolicy.New(
+			retrypolicy.WithInitialInterval(100*time.Millisecond),
+			retrypolicy.WithBackoffCoefficient(2.0),
+			retrypolicy.WithMaximumInterval(time.Second),
+			retrypolicy.WithExpirationInterval(5*time.Second),
+			retrypolicy.WithMaximumAttempts(3),
+		)
+	}
+
+	// merchantWebhookSchedule spaces out attempts of a delivery.
+	merchantWebhookSchedule = func() (retrypolicy.Retry, error) {
+		return retrypolicy.New(
+			retrypolicy.WithInitialInterval(time.Minute),
+			retrypolicy.WithBackoffCoefficient(2.0),
+			retrypolicy.WithMaximumInterval(6*time.Hour),
+			retrypolicy.WithExpirationInterval(24*time.Hour),
+			retrypolicy.WithMaximumAttempts(merchantWebhookMaxAttempts-1),
+		)
+	}
+)
+
+type merchantWebhookStore interface {
+	CreateEndpoint(ctx context.Context, dbi sqlx.QueryerContext, ep model.MerchantWebhookEndpoint) (*model.MerchantWebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.MerchantWebhookEndpoint, error)
+	DisableEndpoint(ctx context.Context, dbi sqlx.QueryerContext, merchID string, id uuid.UUID, when time.Time) error
+	ReplaceKey(ctx context.Context, dbi sqlx.ExecerContext, oldID, newID uuid.UUID) error
+	InsertKeyRotated(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error
+	GetUnqueuedEventsForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]uuid.UUID, error)
+	InsertDeliveries(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error
+	MarkEventsQueued(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error
+	ClaimNextDueDelivery(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.MerchantWebhookDueDelivery, error)
+	MarkDelivered(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, when time.Time) error
+	MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, msg string, when time.Time, next *time.Time) error
+	ListDeliveries(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantWebhookDeliveryFilter) ([]model.MerchantWebhookDelivery, error)
+	Redeliver(ctx context.Context, dbi sqlx.ExecerContext, merchID string, id uuid.UUID, when time.Time) error
+}
+
+// merchantWebhookError is returned when a merchant endpoint responds with a status other than 2xx.
+type merchantWebhookError struct {
+	code int
+}
+
+func (e *merchantWebhookError) Error() string {
+	return "unexpected status " + strconv.Itoa(e.code)
+}
+
+// newMerchantWebhookClient returns a client for requests to merchant endpoints.
+//
+// Endpoints are registered by merchants, so the client connects only to public addresses, and does not follow redirects.
+// Addresses are checked after the host has been resolved, so a hostname cannot point the request at an internal service.
+func newMerchantWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: merchantWebhookTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkMerchantWebhookAddr(address)
+		},
+	}
+
+	return &http.Client{
+		Timeout: merchantWebhookTimeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: merchantWebhookTimeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return errWebhookRedirectRejected
+		},
+	}
+}
+
+// checkMerchantWebhookAddr rejects the resolved address of an endpoint unless it is a public unicast address.
+func checkMerchantWebhookAddr(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return errWebhookAddrNotAllowed
+	}
+
+	if !isPublicAddr(addr.Unmap()) {
+		return errWebhookAddrNotAllowed
+	}
+
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	switch {
+	case !addr.IsValid(), addr.IsUnspecified(), addr.IsLoopback(), addr.IsPrivate(), addr.IsMulticast():
+		return false
+
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(), addr.IsInterfaceLocalMulticast():
+		return false
+
+	case sharedAddrSpace.Contains(addr):
+		return false
+
+	default:
+		return true
+	}
+}
+
+// sharedAddrSpace is the carrier-grade NAT range, which is not routable on the internet.
+var sharedAddrSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CreateMerchantWebhookEndpoint registers an endpoint of the merchant.
+//
+// Events delivered to the endpoint are signed with the key the request has been signed with.
+func (s *Service) CreateMerchantWebhookEndpoint(ctx context.Context, merchID string, req *model.CreateWebhookEndpointRequest) (*model.MerchantWebhookEndpoint, error) {
+	key, ok := merchantKeyFromCtx(ctx)
+	if !ok {
+		return nil, errWebhookKeyRequired
+	}
+
+	keyID, err := uuid.FromString(key.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	ep := model.MerchantWebhookEndpoint{
+		MerchantID: merchID,
+		URL:        req.URL,
+		KeyID:      keyID,
+		EventTypes: req.EventTypes,
+	}
+
+	return s.merchHookRepo.CreateEndpoint(ctx, s.Datastore.RawDB(), ep)
+}
+
+func (s *Service) ListMerchantWebhookEndpoints(ctx context.Context, merchID string) ([]model.MerchantWebhookEndpoint, error) {
+	return s.merchHookRepo.ListEndpoints(ctx, s.Datastore.RawDB(), merchID)
+}
+
+// DisableMerchantWebhookEndpoint stops deliveries to the endpoint, including those already pending.
+func (s *Service) DisableMerchantWebhookEndpoint(ctx context.Context, merchID string, id uuid.UUID) error {
+	return s.merchHookRepo.DisableEndpoint(ctx, s.Datastore.RawDB(), merchID, id, time.Now().UTC())
+}
+
+// ListMerchantWebhookDeliveries returns the delivery log of the merchant's endpoints.
+func (s *Service) ListMerchantWebhookDeliveries(ctx context.Context, merchID string, filter model.MerchantWebhookDeliveryFilter) ([]model.MerchantWebhookDelivery, error) {
+	return s.merchHookRepo.ListDeliveries(ctx, s.Datastore.RawDB(), merchID, filter)
+}
+
+// RedeliverMerchantWebhook schedules the delivery to be attempted again as soon as possible.
+func (s *Service) RedeliverMerchantWebhook(ctx context.Context, merchID string, id uuid.UUID) error {
+	return s.merchHookRepo.Redeliver(ctx, s.Datastore.RawDB(), merchID, id, time.Now().UTC())
+}
+
+// RunQueueMerchantWebhooksJob creates deliveries of a batch of recorded order events to merchant endpoints.
+func (s *Service) RunQueueMerchantWebhooksJob(ctx context.Context) (bool, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	ids, err := s.merchHookRepo.GetUnqueuedEventsForUpdate(ctx, tx, merchantWebhookBatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	if len(ids) == 0 {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+
+	if err := s.merchHookRepo.InsertDeliveries(ctx, tx, ids, now); err != nil {
+		return false, err
+	}
+
+	if err := s.merchHookRepo.MarkEventsQueued(ctx, tx, ids, now); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RunDeliverMerchantWebhooksJob attempts the next due delivery to a merchant endpoint.
+//
+// Events are marked as delivered only after the endpoint has accepted them, so an event might be delivered more than once.
+// Merchants should deduplicate by event id.
+func (s *Service) RunDeliverMerchantWebhooksJob(ctx context.Context) (bool, error) {
+	if err := s.processNextMerchantWebhook(ctx, s.Datastore.RawDB(), time.Now().UTC()); err != nil {
+		if errors.Is(err, model.ErrWebhookDeliveryNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// processNextMerchantWebhook claims the next due delivery, attempts it, and records the outcome.
+//
+// No transaction is held while the endpoint is called, the claim keeps other workers from attempting the delivery.
+// A failed attempt is scheduled to be retried with an exponential backoff, until the delivery runs out of attempts.
+func (s *Service) processNextMerchantWebhook(ctx context.Context, dbi sqlx.ExtContext, now time.Time) error {
+	dlv, err := s.merchHookRepo.ClaimNextDueDelivery(ctx, dbi, now, now.Add(merchantWebhookLease))
+	if err != nil {
+		return err
+	}
+
+	code, err := s.attemptMerchantWebhook(ctx, dlv)
+	if err == nil {
+		return s.merchHookRepo.MarkDelivered(ctx, dbi, dlv.ID, code, time.Now().UTC())
+	}
+
+	lg := logging.Logger(ctx, "skus").With().Str("func", "processNextMerchantWebhook").Logger()
+	lg.Warn().Err(err).Str("delivery_id", dlv.ID.String()).Int("status", code).Msg("failed to deliver merchant webhook")
+
+	when := time.Now().UTC()
+
+	var next *time.Time
+	if delay, ok := nextMerchantWebhookDelay(dlv.NumAttempts + 1); ok {
+		at := when.Add(delay)
+		next = &at
+	}
+
+	return s.merchHookRepo.MarkAttemptFailed(ctx, dbi, dlv.ID, code, merchantWebhookErrMsg(err), when, next)
+}
+
+// newMerchantWebhookPayload returns the body delivering the event of dlv.
+func newMerchantWebhookPayload(dlv *model.MerchantWebhookDelivery) model.MerchantWebhookPayload {
+	result := model.MerchantWebhookPayload{
+		ID:         dlv.EventID.String(),
+		Type:       dlv.EventType,
+		OccurredAt: dlv.OccurredAt.UTC().Format(time.RFC3339),
+	}
+
+	if dlv.OrderID != nil {
+		result.OrderID = dlv.OrderID.String()
+	}
+
+	if dlv.RotatedKeyID != nil {
+		result.KeyID = dlv.RotatedKeyID.String()
+	}
+
+	if dlv.NewKeyID != nil {
+		result.NewKeyID = dlv.NewKeyID.String()
+	}
+
+	return result
+}
+
+// merchantWebhookErrMsg describes a failed attempt to the merchant.
+//
+// Errors can carry details of the network and of internal services, so only known outcomes are described.
+func merchantWebhookErrMsg(err error) string {
+	var werr *merchantWebhookError
+	if errors.As(err, &werr) {
+		return werr.Error()
+	}
+
+	for _, known := range []error{errWebhookKeyNotActive, errWebhookAddrNotAllowed, errWebhookRedirectRejected} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+
+	var nerr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &nerr) && nerr.Timeout()) {
+		return errWebhookRequestTimedOut.Error()
+	}
+
+	return errWebhookRequestFailed.Error()
+}
+
+// attemptMerchantWebhook sends the event to the endpoint, retrying failed requests a few times.
+//
+// It returns the status of the last response, or zero if no response has been received.
+func (s *Service) attemptMerchantWebhook(ctx context.Context, dlv *model.MerchantWebhookDueDelivery) (int, error) {
+	key, err := s.Datastore.GetKey(dlv.KeyID, false)
+	if err != nil {
+		return 0, errWebhookKeyNotActive
+	}
+
+	secret, err := key.GetSecretKey()
+	if err != nil {
+		return 0, err
+	}
+
+	if secret == nil {
+		return 0, errWebhookKeyNotActive
+	}
+
+	body, err := json.Marshal(newMerchantWebhookPayload(&dlv.MerchantWebhookDelivery))
+	if err != nil {
+		return 0, err
+	}
+
+	policy, err := merchantWebhookAttemptRetry()
+	if err != nil {
+		return 0, err
+	}
+
+	var code int
+
+	_, err = s.retry(ctx, func() (interface{}, error) {
+		var err error
+		code, err = sendMerchantWebhook(ctx, s.merchHookClient, dlv.URL, key.ID, *secret, body)
+
+		return nil, err
+	}, policy, canRetryMerchantWebhook)
+
+	return code, err
+}
+
+// sendMerchantWebhook makes a request to the endpoint signed with the merchant key keyID.
+func sendMerchantWebhook(ctx context.Context, client *http.Client, uri, keyID, secret string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("content-length", strconv.Itoa(len(body)))
+	req.Header.Set("date", time.Now().UTC().Format(http.TimeFormat))
+
+	ps := httpsignature.ParameterizedSignator{
+		SignatureParams: httpsignature.SignatureParams{
+			Algorithm: httpsignature.HS2019,
+			KeyID:     keyID,
+			Headers:   merchantWebhookSignedHeaders,
+		},
+		Signator: httpsignature.HMACKey(secret),
+		Opts:     crypto.Hash(0),
+	}
+
+	if err := ps.SignRequest(req); err != nil {
+		return 0, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, &merchantWebhookError{code: resp.StatusCode}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// canRetryMerchantWebhook reports whether a failed request is worth retrying straight away.
+//
+// Client errors other than rate limiting, and endpoints the client refuses to connect to,
+// are not retried until the next scheduled attempt.
+func canRetryMerchantWebhook(err error) bool {
+	if errors.Is(err, errWebhookAddrNotAllowed) || errors.Is(err, errWebhookRedirectRejected) {
+		return false
+	}
+
+	var werr *merchantWebhookError
+	if !errors.As(err, &werr) {
+		return true
+	}
+
+	return werr.code == http.StatusTooManyRequests || werr.code >= http.StatusInternalServerError
+}
+
+// nextMerchantWebhookDelay returns the delay before the attempt following the given number of attempts.
+//
+// It returns false when there are no attempts left.
+func nextMerchantWebhookDelay(numAttempts int) (time.Duration, bool) {
+	if numAttempts <= 0 || numAttempts >= merchantWebhookMaxAttempts {
+		return 0, false
+	}
+
+	policy, err := merchantWebhookSchedule()
+	if err != nil {
+		return 0, false
+	}
+
+	var delay time.Duration
+	for i := 0; i < numAttempts; i++ {
+		if delay = policy.CalculateNextDelay(); delay == retrypolicy.Done {
+			return 0, false
+		}
+	}
+
+	return delay, true
+}
+
+// parseWebhookDeliveryFilter reads a filter from the query parameters endpointId, before and limit.
+func parseWebhookDeliveryFilter(r *http.Request) (model.MerchantWebhookDeliveryFilter, error) {
+	var result model.MerchantWebhookDeliveryFilter
+
+	q := r.URL.Query()
+
+	if raw := q.Get("endpointId"); raw != "" {
+		epID, err := uuid.FromString(raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.EndpointID = uuid.NullUUID{UUID: epID, Valid: true}
+	}
+
+	if raw := q.Get("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.Before = before
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return result, err
+		}
+
+		result.Limit = limit
+	}
+
+	return result, nil
+}
@@ -0,0 +1,573 @@
+package skus
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/httpsignature"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestSendMerchantWebhook(t *testing.T) {
+	type tcExpected struct {
+		code int
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given int
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "accepted",
+			given: http.StatusNoContent,
+			exp:   tcExpected{code: http.StatusNoContent},
+		},
+
+		{
+			name:  "rejected",
+			given: http.StatusInternalServerError,
+			exp: tcExpected{
+				code: http.StatusInternalServerError,
+				err:  &merchantWebhookError{code: http.StatusInternalServerError},
+			},
+		},
+	}
+
+	const (
+		keyID  = "5ca1ab1e-0000-4000-a000-000000000000"
+		secret = "secret"
+	)
+
+	payload := model.MerchantWebhookPayload{
+		ID:         "facade00-0000-4000-a000-000000000000",
+		Type:       model.OrderEventPaid,
+		OrderID:    "decade00-0000-4000-a000-000000000000",
+		OccurredAt: "2024-01-01T00:00:00Z",
+	}
+
+	body, err := json.Marshal(payload)
+	must.NoError(t, err)
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sp, err := httpsignature.SignatureParamsFromRequest(r)
+				must.NoError(t, err)
+
+				should.Equal(t, keyID, sp.KeyID)
+				should.Equal(t, merchantWebhookSignedHeaders, sp.Headers)
+
+				valid, err := sp.Verify(httpsignature.HMACKey(secret), crypto.Hash(0), r)
+				must.NoError(t, err)
+				should.True(t, valid)
+
+				var actual model.MerchantWebhookPayload
+				must.NoError(t, json.NewDecoder(r.Body).Decode(&actual))
+				should.Equal(t, payload, actual)
+
+				w.WriteHeader(tc.given)
+			}))
+			defer srv.Close()
+
+			code, err := sendMerchantWebhook(context.Background(), srv.Client(), srv.URL+"/hooks", keyID, secret, body)
+			should.Equal(t, tc.exp.err, err)
+			should.Equal(t, tc.exp.code, code)
+		})
+	}
+}
+
+func TestCanRetryMerchantWebhook(t *testing.T) {
+	type testCase struct {
+		name  string
+		given error
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "no_response",
+			given: errors.New("connection refused"),
+			exp:   true,
+		},
+
+		{
+			name:  "server_error",
+			given: &merchantWebhookError{code: http.StatusBadGateway},
+			exp:   true,
+		},
+
+		{
+			name:  "rate_limited",
+			given: &merchantWebhookError{code: http.StatusTooManyRequests},
+			exp:   true,
+		},
+
+		{
+			name:  "client_error",
+			given: &merchantWebhookError{code: http.StatusNotFound},
+		},
+
+		{
+			name:  "addr_not_allowed",
+			given: &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: errWebhookAddrNotAllowed}},
+		},
+
+		{
+			name:  "redirect",
+			given: &url.Error{Op: "Post", URL: "https://example.com", Err: errWebhookRedirectRejected},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, canRetryMerchantWebhook(tc.given))
+		})
+	}
+}
+
+func TestCheckMerchantWebhookAddr(t *testing.T) {
+	type testCase struct {
+		name  string
+		given string
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "public_v4",
+			given: "93.184.216.34:443",
+		},
+
+		{
+			name:  "public_v6",
+			given: "[2606:2800:220:1:248:1893:25c8:1946]:443",
+		},
+
+		{
+			name:  "loopback",
+			given: "127.0.0.1:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "loopback_v6",
+			given: "[::1]:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "private",
+			given: "10.0.0.1:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "private_mapped_v6",
+			given: "[::ffff:192.168.1.1]:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "link_local_metadata",
+			given: "169.254.169.254:80",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "shared_addr_space",
+			given: "100.100.1.1:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "unspecified",
+			given: "0.0.0.0:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+
+		{
+			name:  "unique_local_v6",
+			given: "[fd00::1]:443",
+			exp:   errWebhookAddrNotAllowed,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, checkMerchantWebhookAddr(tc.given))
+		})
+	}
+}
+
+func TestNewMerchantWebhookClient(t *testing.T) {
+	var called bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := sendMerchantWebhook(context.Background(), newMerchantWebhookClient(), srv.URL+"/hooks", "key_id", "secret", []byte("{}"))
+	should.ErrorIs(t, err, errWebhookAddrNotAllowed)
+	should.False(t, called)
+}
+
+func TestNewMerchantWebhookPayload(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *model.MerchantWebhookDelivery
+		exp   model.MerchantWebhookPayload
+	}
+
+	orderID := uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000"))
+	keyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	newKeyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+
+	tests := []testCase{
+		{
+			name: "order_event",
+			given: &model.MerchantWebhookDelivery{
+				EventID:    uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				EventType:  model.OrderEventPaid,
+				OrderID:    &orderID,
+				OccurredAt: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: model.MerchantWebhookPayload{
+				ID:         "facade00-0000-4000-a000-000000000000",
+				Type:       model.OrderEventPaid,
+				OrderID:    "decade00-0000-4000-a000-000000000000",
+				OccurredAt: "2024-01-01T00:00:00Z",
+			},
+		},
+
+		{
+			name: "key_rotated",
+			given: &model.MerchantWebhookDelivery{
+				EventID:      uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				EventType:    model.MerchantKeyEventRotated,
+				RotatedKeyID: &keyID,
+				NewKeyID:     &newKeyID,
+				OccurredAt:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: model.MerchantWebhookPayload{
+				ID:         "facade00-0000-4000-a000-000000000000",
+				Type:       model.MerchantKeyEventRotated,
+				KeyID:      "5ca1ab1e-0000-4000-a000-000000000000",
+				NewKeyID:   "5ca1ab1e-0000-4000-a000-000000000001",
+				OccurredAt: "2024-01-01T00:00:00Z",
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := newMerchantWebhookPayload(tc.given)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestMerchantWebhookErrMsg(t *testing.T) {
+	type testCase struct {
+		name  string
+		given error
+		exp   string
+	}
+
+	tests := []testCase{
+		{
+			name:  "unexpected_status",
+			given: &merchantWebhookError{code: http.StatusBadGateway},
+			exp:   "unexpected status 502",
+		},
+
+		{
+			name:  "key_not_active",
+			given: errWebhookKeyNotActive,
+			exp:   "signing key is not active",
+		},
+
+		{
+			name:  "addr_not_allowed",
+			given: &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: errWebhookAddrNotAllowed}},
+			exp:   "endpoint address is not allowed",
+		},
+
+		{
+			name:  "timeout",
+			given: &url.Error{Op: "Post", URL: "https://example.com", Err: context.DeadlineExceeded},
+			exp:   "request timed out",
+		},
+
+		{
+			name:  "internal_details",
+			given: errors.New("dial tcp 10.1.2.3:443: connect: connection refused"),
+			exp:   "request failed",
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, merchantWebhookErrMsg(tc.given))
+		})
+	}
+}
+
+func TestNextMerchantWebhookDelay(t *testing.T) {
+	type tcExpected struct {
+		min time.Duration
+		max time.Duration
+		ok  bool
+	}
+
+	type testCase struct {
+		name  string
+		given int
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_attempts",
+		},
+
+		{
+			name:  "first_attempt",
+			given: 1,
+			exp:   tcExpected{min: 48 * time.Second, max: time.Minute, ok: true},
+		},
+
+		{
+			name:  "third_attempt",
+			given: 3,
+			exp:   tcExpected{min: 192 * time.Second, max: 4 * time.Minute, ok: true},
+		},
+
+		{
+			name:  "last_attempt",
+			given: merchantWebhookMaxAttempts - 1,
+			exp:   tcExpected{min: 3 * time.Hour, max: 5 * time.Hour, ok: true},
+		},
+
+		{
+			name:  "no_attempts_left",
+			given: merchantWebhookMaxAttempts,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := nextMerchantWebhookDelay(tc.given)
+			should.Equal(t, tc.exp.ok, ok)
+
+			if !tc.exp.ok {
+				return
+			}
+
+			should.GreaterOrEqual(t, actual, tc.exp.min)
+			should.LessOrEqual(t, actual, tc.exp.max)
+		})
+	}
+}
+
+func TestParseWebhookDeliveryFilter(t *testing.T) {
+	type tcExpected struct {
+		val     model.MerchantWebhookDeliveryFilter
+		mustErr bool
+	}
+
+	type testCase struct {
+		name  string
+		given string
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "empty",
+			given: "/webhooks/deliveries",
+		},
+
+		{
+			name:  "all",
+			given: "/webhooks/deliveries?endpointId=5ca1ab1e-0000-4000-a000-000000000000&before=2024-01-01T00:00:00Z&limit=10",
+			exp: tcExpected{
+				val: model.MerchantWebhookDeliveryFilter{
+					EndpointID: uuid.NullUUID{UUID: uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000")), Valid: true},
+					Before:     time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+					Limit:      10,
+				},
+			},
+		},
+
+		{
+			name:  "invalid_endpoint_id",
+			given: "/webhooks/deliveries?endpointId=invalid",
+			exp:   tcExpected{mustErr: true},
+		},
+
+		{
+			name:  "invalid_limit",
+			given: "/webhooks/deliveries?limit=many",
+			exp:   tcExpected{mustErr: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseWebhookDeliveryFilter(httptest.NewRequest(http.MethodGet, tc.given, nil))
+			if tc.exp.mustErr {
+				must.Error(t, err)
+				return
+			}
+
+			must.NoError(t, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_CreateMerchantWebhookEndpoint(t *testing.T) {
+	type tcExpected struct {
+		val *model.MerchantWebhookEndpoint
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given context.Context
+		req   *model.CreateWebhookEndpointRequest
+		exp   tcExpected
+	}
+
+	key := &Key{ID: "5ca1ab1e-0000-4000-a000-000000000000", Merchant: "brave.com"}
+
+	tests := []testCase{
+		{
+			name:  "simple_token",
+			given: context.Background(),
+			req:   &model.CreateWebhookEndpointRequest{URL: "https://example.com/hooks"},
+			exp:   tcExpected{err: errWebhookKeyRequired},
+		},
+
+		{
+			name:  "invalid_url",
+			given: context.WithValue(context.Background(), merchantKeyCtxKey{}, key),
+			req:   &model.CreateWebhookEndpointRequest{URL: "http://example.com/hooks"},
+			exp:   tcExpected{err: model.ErrWebhookEndpointInvalid},
+		},
+
+		{
+			name:  "success",
+			given: context.WithValue(context.Background(), merchantKeyCtxKey{}, key),
+			req: &model.CreateWebhookEndpointRequest{
+				URL:        "https://example.com/hooks",
+				EventTypes: []string{model.OrderEventPaid},
+			},
+			exp: tcExpected{
+				val: &model.MerchantWebhookEndpoint{
+					MerchantID: "brave.com",
+					URL:        "https://example.com/hooks",
+					KeyID:      uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000")),
+					EventTypes: []string{model.OrderEventPaid},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				merchHookRepo: &repository.MockMerchantWebhook{},
+				Datastore:     &Postgres{},
+			}
+
+			actual, err := svc.CreateMerchantWebhookEndpoint(tc.given, "brave.com", tc.req)
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_processNextMerchantWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	dlvID := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	ds := NewMockDatastore(ctrl)
+	ds.EXPECT().GetKey(keyID, false).Return(nil, model.Error("something_went_wrong"))
+
+	var (
+		lease time.Time
+		msg   string
+		next  *time.Time
+	)
+
+	repo := &repository.MockMerchantWebhook{
+		FnClaimNextDueDelivery: func(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.MerchantWebhookDueDelivery, error) {
+			lease = leaseUntil
+
+			result := &model.MerchantWebhookDueDelivery{URL: "https://example.com/hooks", KeyID: keyID}
+			result.ID = dlvID
+
+			return result, nil
+		},
+
+		FnMarkAttemptFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, m string, when time.Time, n *time.Time) error {
+			should.Equal(t, dlvID, id)
+			msg, next = m, n
+
+			return nil
+		},
+	}
+
+	svc := &Service{Datastore: ds, merchHookRepo: repo}
+
+	must.NoError(t, svc.processNextMerchantWebhook(context.Background(), nil, now))
+
+	should.Equal(t, now.Add(merchantWebhookLease), lease)
+	should.Equal(t, "signing key is not active", msg)
+	should.NotNil(t, next)
+}
@@ -102,18 +102,18 @@ func (mr *MockDatastoreMockRecorder) CommitVote(ctx, vr, tx interface{}) *gomock
 }
 
 // CreateKey mocks base method.
-func (m *MockDatastore) CreateKey(merchant, name, encryptedSecretKey, nonce string) (*Key, error) {
+func (m *MockDatastore) CreateKey(merchant, name, encryptedSecretKey, nonce string, scopes []string) (*Key, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateKey", merchant, name, encryptedSecretKey, nonce)
+	ret := m.ctrl.Call(m, "CreateKey", merchant, name, encryptedSecretKey, nonce, scopes)
 	ret0, _ := ret[0].(*Key)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateKey indicates an expected call of CreateKey.
-func (mr *MockDatastoreMockRecorder) CreateKey(merchant, name, encryptedSecretKey, nonce interface{}) *gomock.Call {
+func (mr *MockDatastoreMockRecorder) CreateKey(merchant, name, encryptedSecretKey, nonce, scopes interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockDatastore)(nil).CreateKey), merchant, name, encryptedSecretKey, nonce)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockDatastore)(nil).CreateKey), merchant, name, encryptedSecretKey, nonce, scopes)
 }
 
 // CreateOrder mocks base method.
@@ -339,7 +339,7 @@ func (mr *MockDatastoreMockRecorder) GetOutboxMovAvgDurationSeconds() *gomock.Ca
 }
 
 // GetPagedMerchantTransactions mocks base method.
-func (m *MockDatastore) GetPagedMerchantTransactions(ctx context.Context, merchantID go_uuid.UUID, pagination *inputs.Pagination) (*[]Transaction, int, error) {
+func (m *MockDatastore) GetPagedMerchantTransactions(ctx context.Context, merchantID string, pagination *inputs.Pagination) (*[]Transaction, int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetPagedMerchantTransactions", ctx, merchantID, pagination)
 	ret0, _ := ret[0].(*[]Transaction)
@@ -678,6 +678,21 @@ func (mr *MockDatastoreMockRecorder) RollbackTxAndHandle(tx interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackTxAndHandle", reflect.TypeOf((*MockDatastore)(nil).RollbackTxAndHandle), tx)
 }
 
+// RotateKeyTx mocks base method.
+func (m *MockDatastore) RotateKeyTx(ctx context.Context, tx *sqlx.Tx, id go_uuid.UUID, overlapSeconds int, encryptedSecretKey, nonce string) (*Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateKeyTx", ctx, tx, id, overlapSeconds, encryptedSecretKey, nonce)
+	ret0, _ := ret[0].(*Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateKeyTx indicates an expected call of RotateKeyTx.
+func (mr *MockDatastoreMockRecorder) RotateKeyTx(ctx, tx, id, overlapSeconds, encryptedSecretKey, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateKeyTx", reflect.TypeOf((*MockDatastore)(nil).RotateKeyTx), ctx, tx, id, overlapSeconds, encryptedSecretKey, nonce)
+}
+
 // SendSigningRequest mocks base method.
 func (m *MockDatastore) SendSigningRequest(ctx context.Context, signingRequestWriter SigningRequestWriter) error {
 	m.ctrl.T.Helper()
@@ -1085,6 +1100,21 @@ func (mr *MockorderPayHistoryStoreMockRecorder) Insert(ctx, dbi, id, when interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockorderPayHistoryStore)(nil).Insert), ctx, dbi, id, when)
 }
 
+// List mocks base method.
+func (m *MockorderPayHistoryStore) List(ctx context.Context, dbi sqlx.QueryerContext, id go_uuid.UUID) ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, dbi, id)
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockorderPayHistoryStoreMockRecorder) List(ctx, dbi, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockorderPayHistoryStore)(nil).List), ctx, dbi, id)
+}
+
 // MockissuerStore is a mock of issuerStore interface.
 type MockissuerStore struct {
 	ctrl     *gomock.Controller
@@ -1153,6 +1183,35 @@ func (mr *MockissuerStoreMockRecorder) GetByPubKey(ctx, dbi, pubKey interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPubKey", reflect.TypeOf((*MockissuerStore)(nil).GetByPubKey), ctx, dbi, pubKey)
 }
 
+// GetNextDueKeyCheckForUpdate mocks base method.
+func (m *MockissuerStore) GetNextDueKeyCheckForUpdate(ctx context.Context, dbi sqlx.QueryerContext, before time.Time) (*model.Issuer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNextDueKeyCheckForUpdate", ctx, dbi, before)
+	ret0, _ := ret[0].(*model.Issuer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNextDueKeyCheckForUpdate indicates an expected call of GetNextDueKeyCheckForUpdate.
+func (mr *MockissuerStoreMockRecorder) GetNextDueKeyCheckForUpdate(ctx, dbi, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextDueKeyCheckForUpdate", reflect.TypeOf((*MockissuerStore)(nil).GetNextDueKeyCheckForUpdate), ctx, dbi, before)
+}
+
+// SetKeysCheckedAt mocks base method.
+func (m *MockissuerStore) SetKeysCheckedAt(ctx context.Context, dbi sqlx.ExecerContext, id go_uuid.UUID, when time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetKeysCheckedAt", ctx, dbi, id, when)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetKeysCheckedAt indicates an expected call of SetKeysCheckedAt.
+func (mr *MockissuerStoreMockRecorder) SetKeysCheckedAt(ctx, dbi, id, when interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKeysCheckedAt", reflect.TypeOf((*MockissuerStore)(nil).SetKeysCheckedAt), ctx, dbi, id, when)
+}
+
 // MockgetContext is a mock of getContext interface.
 type MockgetContext struct {
 	ctrl     *gomock.Controller
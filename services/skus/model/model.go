@@ -3,6 +3,7 @@ package model
 
 import (
 	"database/sql"
+	"encoding/json"
 	"net/url"
 	"sort"
 	"strconv"
@@ -35,6 +36,8 @@ const (
 	ErrInvalidMobileProduct  Error = "model: invalid mobile product"
 	ErrNoMatchOrderReceipt   Error = "model: order_id does not match receipt order"
 	ErrOrderExistsForReceipt Error = "model: order already exists for receipt"
+	ErrOrderPlanNotFound     Error = "model: order plan not found"
+	ErrOrderPlanChangeItems  Error = "model: plan change requires single-item order"
 
 	// The text of the following errors is preserved as is, in case anything depends on them.
 	ErrInvalidSKU              Error = "Invalid SKU Token provided in request"
@@ -45,6 +48,65 @@ const (
 
 	ErrTLV2InvalidCredNum Error = "model: invalid number of creds"
 
+	ErrWebhookInboxEntryNotFound Error = "model: webhook inbox entry not found"
+	ErrWebhookInboxEntryExists   Error = "model: webhook inbox entry already exists"
+
+	ErrTransactionAlreadyExists Error = "model: transaction already exists"
+
+	ErrOrderSeatNotFound        Error = "model: order seat not found"
+	ErrOrderSeatsExhausted      Error = "model: no seats available for order"
+	ErrOrderSeatNotActive       Error = "model: order seat is not active"
+	ErrOrderSeatAlreadyRedeemed Error = "model: order seat already redeemed"
+
+	ErrCouponNotFound         Error = "model: coupon not found"
+	ErrCouponExpired          Error = "model: coupon expired"
+	ErrCouponExhausted        Error = "model: coupon redemption limit reached"
+	ErrCouponNotEligible      Error = "model: coupon not eligible for order items"
+	ErrCouponCurrencyMismatch Error = "model: coupon currency does not match order currency"
+	ErrCouponNoStripeCoupon   Error = "model: coupon not available for stripe"
+	ErrCouponInvalid          Error = "model: invalid coupon"
+
+	ErrOrderDunningNotFound Error = "model: order dunning not found"
+
+	ErrStripeSubCancelNotFound Error = "model: stripe subscription cancellation not found"
+
+	ErrSKUCatalogEntryNotFound Error = "model: sku catalog entry not found"
+	ErrSKUCatalogEntryInvalid  Error = "model: invalid sku catalog entry"
+	ErrSKUPriceNotFound        Error = "model: sku price not found"
+	ErrSKUPriceInvalid         Error = "model: invalid sku price"
+
+	ErrPortalLoginCodeInvalid   Error = "model: invalid or expired login code"
+	ErrPortalSessionNotFound    Error = "model: portal session not found"
+	ErrPortalCustomerNotFound   Error = "model: portal customer not found"
+	ErrPortalOrderNotManageable Error = "model: order cannot be managed through the portal"
+	ErrPortalLoginLimited       Error = "model: too many portal login attempts"
+	ErrPortalReturnURLInvalid   Error = "model: portal return url not allowed"
+	ErrPortalCustomerAmbiguous  Error = "model: several customers found for the portal email"
+
+	ErrMerchantKeyNotFound         Error = "model: merchant key not found"
+	ErrMerchantKeyRotationNotFound Error = "model: merchant key rotation not found"
+	ErrMerchantKeyRotationInvalid  Error = "model: invalid merchant key rotation"
+
+	ErrWebhookEndpointNotFound Error = "model: webhook endpoint not found"
+	ErrWebhookEndpointInvalid  Error = "model: invalid webhook endpoint"
+	ErrWebhookDeliveryNotFound Error = "model: webhook delivery not found"
+
+	ErrSpendChannelNotFound Error = "model: spend channel not found"
+	ErrSpendChannelExists   Error = "model: spend channel already exists"
+	ErrSpendChannelInvalid  Error = "model: invalid spend channel"
+	ErrSpendPayloadInvalid  Error = "model: invalid spend payload"
+	ErrSpendNoCredentials   Error = "model: no credentials to spend"
+	ErrSpendCredsNotAllowed Error = "model: credentials cannot be spent on the channel"
+	ErrSpendRecordNotFound  Error = "model: spend record not found"
+
+	ErrConsumerOffsetNotFound Error = "model: consumer offset not found"
+
+	ErrIssuerNotV3 Error = "model: issuer is not a version 3 issuer"
+
+	ErrTLV1MigrationNotFound Error = "model: time limited v1 migration not found"
+	ErrTLV1MigrationInvalid  Error = "model: invalid time limited v1 migration"
+	ErrTLV1NotAccepted       Error = "model: time limited v1 credentials are no longer accepted"
+
 	// ErrInvalidCredType is returned when an invalid cred type has been detected.
 	ErrInvalidCredType Error = "invalid credential type on order"
 
@@ -61,14 +123,62 @@ const (
 	MerchID             = "brave.com"
 	StripePaymentMethod = "stripe"
 	RadomPaymentMethod  = "radom"
+	PayPalPaymentMethod = "paypal"
 
 	// OrderStatus* represent order statuses at runtime and in db.
 	OrderStatusCanceled = "canceled"
 	OrderStatusPaid     = "paid"
+	OrderStatusPastDue  = "past_due"
 	OrderStatusPending  = "pending"
+	OrderStatusRefunded = "refunded"
 
 	issuerBufferDefault  = 30
 	issuerOverlapDefault = 5
+
+	// WebhookInboxStatus* represent statuses of inbox entries.
+	WebhookInboxStatusPending   = "pending"
+	WebhookInboxStatusProcessed = "processed"
+	WebhookInboxStatusDead      = "dead"
+
+	// WebhookVendor* identify the sender of a notification stored in the inbox.
+	WebhookVendorStripe    = "stripe"
+	WebhookVendorRadom     = "radom"
+	WebhookVendorPayPal    = "paypal"
+	WebhookVendorPlayStore = "android"
+	WebhookVendorAppStore  = "ios"
+
+	// TransactionKind* represent kinds of transactions which reverse a payment.
+	TransactionKindRefund     = "refund"
+	TransactionKindChargeback = "chargeback"
+
+	TransactionStatusCompleted = "completed"
+
+	// OrderSeatStatus* represent statuses of order seats.
+	OrderSeatStatusPending = "pending"
+	OrderSeatStatusActive  = "active"
+	OrderSeatStatusRevoked = "revoked"
+
+	// OrderEvent* represent types of order lifecycle events.
+	OrderEventCreated         = "order.created"
+	OrderEventPaid            = "order.paid"
+	OrderEventRenewed         = "order.renewed"
+	OrderEventCanceled        = "order.canceled"
+	OrderEventPaymentFailed   = "order.payment_failed"
+	OrderEventPaymentReminder = "order.payment_reminder"
+	OrderEventExpired         = "order.expired"
+	OrderEventCredsSigned     = "order.credentials_signed"
+
+	// MerchantKeyEventRotated is recorded when a merchant key has been rotated, by request or on schedule.
+	MerchantKeyEventRotated = "key.rotated"
+
+	// WebhookDeliveryStatus* represent states of a delivery of an event to a merchant endpoint.
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+
+	// CouponDiscountType* represent kinds of coupon discounts.
+	CouponDiscountTypePercentage = "percentage"
+	CouponDiscountTypeFixed      = "fixed"
 )
 
 const (
@@ -118,6 +228,11 @@ func (o *Order) IsRadomPayable() bool {
 	return Slice[string](o.AllowedPaymentMethods).Contains(RadomPaymentMethod)
 }
 
+// IsPayPalPayable indicates whether the order is payable by PayPal.
+func (o *Order) IsPayPalPayable() bool {
+	return Slice[string](o.AllowedPaymentMethods).Contains(PayPalPaymentMethod)
+}
+
 func (o *Order) ShouldCreateTrialSessionStripe(now time.Time) bool {
 	return !o.IsPaidAt(now) && o.IsStripePayable()
 }
@@ -131,13 +246,14 @@ func (o *Order) IsPaid() bool {
 
 // IsPaidAt returns true if the order is paid.
 //
-// If canceled, it checks if expires_at is in the future.
+// If canceled or past due, it checks if expires_at is in the future.
+// A past due order stays paid until the end of its grace period.
 func (o *Order) IsPaidAt(now time.Time) bool {
 	switch o.Status {
 	case OrderStatusPaid:
 		// The order is paid if the status is paid.
 		return true
-	case OrderStatusCanceled:
+	case OrderStatusCanceled, OrderStatusPastDue:
 		// Check to make sure that expires_a is after now, if order is cancelled.
 		if o.ExpiresAt == nil {
 			return false
@@ -185,6 +301,20 @@ func (o *Order) NumIntervals() (int, error) {
 	return result, nil
 }
 
+// NumSeats returns the number of seats the order can be shared with.
+//
+// Orders without numSeats are not shareable.
+func (o *Order) NumSeats() int {
+	numRaw, ok := o.Metadata["numSeats"]
+	if !ok {
+		return 0
+	}
+
+	result, _ := numFromAny(numRaw)
+
+	return result
+}
+
 func (o *Order) NumPaymentFailed() int {
 	numRaw, ok := o.Metadata["numPaymentFailed"]
 	if !ok {
@@ -210,12 +340,25 @@ func (o *Order) StripeSubID() (string, bool) {
 	return sid, ok
 }
 
+func (o *Order) PayPalSubID() (string, bool) {
+	sid, ok := o.Metadata["paypalSubscriptionId"].(string)
+
+	return sid, ok
+}
+
 func (o *Order) StripeSessID() (string, bool) {
 	sessID, ok := o.Metadata["stripeCheckoutSessionId"].(string)
 
 	return sessID, ok
 }
 
+// StripeCouponID returns the Stripe coupon of the skus coupon applied to the order.
+func (o *Order) StripeCouponID() (string, bool) {
+	cid, ok := o.Metadata["stripeCouponId"].(string)
+
+	return cid, ok
+}
+
 func (o *Order) IsIOS() bool {
 	pp, ok := o.PaymentProc()
 	if !ok {
@@ -253,6 +396,15 @@ func (o *Order) IsStripe() bool {
 	return pp == StripePaymentMethod
 }
 
+func (o *Order) IsPayPal() bool {
+	pp, ok := o.PaymentProc()
+	if !ok {
+		return false
+	}
+
+	return pp == PayPalPaymentMethod
+}
+
 func (o *Order) PaymentProc() (string, bool) {
 	pp, ok := o.Metadata["paymentProcessor"].(string)
 
@@ -294,6 +446,11 @@ type OrderItem struct {
 	IssuerConfig *IssuerConfig `json:"-" db:"-"`
 }
 
+// IsDiscounted reports whether the subtotal is below the list price of the item.
+func (x *OrderItem) IsDiscounted() bool {
+	return x.Subtotal.LessThan(x.Price.Mul(decimal.NewFromInt(int64(x.Quantity))))
+}
+
 func (x *OrderItem) IsLeo() bool {
 	return x.SKUVnt == "brave-leo-premium" || x.SKUVnt == "brave-leo-premium-year"
 }
@@ -304,12 +461,53 @@ func (x *OrderItem) StripeItemID() (string, bool) {
 	return itemID, ok
 }
 
+// PriceCountry returns the country of the regional price the item was priced with, or an empty string.
+func (x *OrderItem) PriceCountry() string {
+	country, _ := x.Metadata["price_country"].(string)
+
+	return country
+}
+
 func (x *OrderItem) RadomProductID() (string, bool) {
 	itemID, ok := x.Metadata["radom_product_id"].(string)
 
 	return itemID, ok
 }
 
+func (x *OrderItem) PayPalPlanID() (string, bool) {
+	planID, ok := x.Metadata["paypal_plan_id"].(string)
+
+	return planID, ok
+}
+
+// PriceID returns the id of the price book entry the item has been priced from, if any.
+func (x *OrderItem) PriceID() (string, bool) {
+	priceID, ok := x.Metadata["price_id"].(string)
+
+	return priceID, ok
+}
+
+// SetPrice prices the item according to the price book entry.
+func (x *OrderItem) SetPrice(price *SKUPrice) {
+	x.Price = price.Amount
+	x.Currency = price.Currency
+	x.Subtotal = price.Amount.Mul(decimal.NewFromInt(int64(x.Quantity)))
+
+	if x.Metadata == nil {
+		x.Metadata = make(datastore.Metadata)
+	}
+
+	x.Metadata["price_id"] = price.ID.String()
+
+	if price.Country != "" {
+		x.Metadata["price_country"] = price.Country
+	}
+
+	if _, ok := x.StripeItemID(); ok && price.StripePriceID != nil {
+		x.Metadata["stripe_item_id"] = *price.StripePriceID
+	}
+}
+
 func (x *OrderItem) Issuer() string {
 	return x.SKU
 }
@@ -417,10 +615,18 @@ type CreateOrderRequestNew struct {
 	Currency       string                `json:"currency" validate:"required,iso4217"`
 	StripeMetadata *OrderStripeMetadata  `json:"stripe_metadata"`
 	RadomMetadata  *OrderRadomMetadata   `json:"radom_metadata"`
+	PayPalMetadata *OrderPayPalMetadata  `json:"paypal_metadata"`
 	PaymentMethods []string              `json:"payment_methods"`
 	Discounts      []string              `json:"discounts"`
 	Items          []OrderItemRequestNew `json:"items" validate:"required,gt=0,dive"`
 	Metadata       map[string]string     `json:"metadata"`
+	NumSeats       int                   `json:"num_seats" validate:"gte=0,lte=10"` // Optional; the number of devices the order can be shared with.
+	CouponCode     string                `json:"coupon_code"`                       // Optional.
+
+	// Country selects regional prices.
+	//
+	// It's set from where the edge geolocated the client, and never taken from the request body.
+	Country string `json:"-"`
 }
 
 // OrderItemRequestNew represents an item in an order request.
@@ -428,7 +634,7 @@ type OrderItemRequestNew struct {
 	Quantity                    int                 `json:"quantity" validate:"required,gte=1"`
 	SKU                         string              `json:"sku" validate:"required"`
 	SKUVnt                      string              `json:"sku_variant" validate:"required"`
-	Period                      string              `json:"period"` // Not used yet.
+	Period                      string              `json:"period"` // Billing period; used for proration of plan changes.
 	Location                    string              `json:"location" validate:"required"`
 	Description                 string              `json:"description" validate:"required"`
 	CredentialType              string              `json:"credential_type" validate:"required"`
@@ -440,6 +646,7 @@ type OrderItemRequestNew struct {
 	IssuanceInterval            *string             `json:"issuance_interval"`
 	StripeMetadata              *ItemStripeMetadata `json:"stripe_metadata"`
 	RadomMetadata               *ItemRadomMetadata  `json:"radom_metadata"`
+	PayPalMetadata              *ItemPayPalMetadata `json:"paypal_metadata"`
 }
 
 func (r *OrderItemRequestNew) TokenBufferOrDefault() int {
@@ -495,6 +702,10 @@ func (r *OrderItemRequestNew) Metadata() map[string]interface{} {
 		return r.RadomMetadata.Metadata()
 	}
 
+	if r.PayPalMetadata != nil {
+		return r.PayPalMetadata.Metadata()
+	}
+
 	return nil
 }
 
@@ -589,6 +800,49 @@ func (m *ItemRadomMetadata) Metadata() map[string]interface{} {
 	return result
 }
 
+// OrderPayPalMetadata holds data relevant to the order in PayPal.
+type OrderPayPalMetadata struct {
+	SuccessURI string `json:"success_uri" validate:"http_url"`
+	CancelURI  string `json:"cancel_uri" validate:"http_url"`
+}
+
+func (m *OrderPayPalMetadata) SuccessURL(oid string) (string, error) {
+	if m == nil {
+		return "", nil
+	}
+
+	return addURLParam(m.SuccessURI, "order_id", oid)
+}
+
+func (m *OrderPayPalMetadata) CancelURL(oid string) (string, error) {
+	if m == nil {
+		return "", nil
+	}
+
+	return addURLParam(m.CancelURI, "order_id", oid)
+}
+
+// ItemPayPalMetadata holds data about the billing plan in PayPal.
+type ItemPayPalMetadata struct {
+	PlanID string `json:"plan_id"`
+}
+
+// Metadata returns the contents of m as a map for datastore.Metadata.
+//
+// It can be called when m is nil.
+func (m *ItemPayPalMetadata) Metadata() map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{})
+	if m.PlanID != "" {
+		result["paypal_plan_id"] = m.PlanID
+	}
+
+	return result
+}
+
 // EnsureEqualPaymentMethods checks if the methods list equals the incoming list.
 //
 // This operation may change both slices due to sorting.
@@ -631,10 +885,12 @@ func (s Slice[T]) Contains(target T) bool {
 
 // Issuer represents a credential issuer.
 type Issuer struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	MerchantID string    `json:"merchantId" db:"merchant_id"`
-	PublicKey  string    `json:"publicKey" db:"public_key"`
-	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	ID            uuid.UUID  `json:"id" db:"id"`
+	MerchantID    string     `json:"merchantId" db:"merchant_id"`
+	PublicKey     string     `json:"publicKey" db:"public_key"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	Version       int        `json:"-" db:"version"`
+	KeysCheckedAt *time.Time `json:"-" db:"keys_checked_at"`
 }
 
 // Name returns the name of the issuer as known by the challenge bypass server.
@@ -646,6 +902,7 @@ func (x *Issuer) Name() string {
 type IssuerNew struct {
 	MerchantID string `db:"merchant_id"`
 	PublicKey  string `db:"public_key"`
+	Version    int    `db:"version"`
 }
 
 // IssuerConfig holds configuration of an issuer.
@@ -658,6 +915,640 @@ func (c *IssuerConfig) NumIntervals() int {
 	return c.Buffer + c.Overlap
 }
 
+// WebhookInboxEntry is a verified payment notification awaiting processing.
+type WebhookInboxEntry struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+	Vendor        string     `json:"vendor" db:"vendor"`
+	EventID       *string    `json:"eventId" db:"event_id"`
+	OrderKey      *string    `json:"orderKey" db:"order_key"`
+	Payload       string     `json:"-" db:"payload"`
+	Status        string     `json:"status" db:"status"`
+	NumAttempts   int        `json:"numAttempts" db:"num_attempts"`
+	LastError     *string    `json:"lastError" db:"last_error"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" db:"next_attempt_at"`
+	ProcessedAt   *time.Time `json:"processedAt" db:"processed_at"`
+}
+
+// WebhookInboxEntryNew is a request to store a notification in the inbox.
+//
+// EventID is the vendor's id of the notification, redeliveries of which are stored once.
+// Entries with the same OrderKey are processed in the order they were received.
+// Either can be empty when the vendor does not provide it.
+type WebhookInboxEntryNew struct {
+	Vendor   string `db:"vendor"`
+	EventID  string `db:"event_id"`
+	OrderKey string `db:"order_key"`
+	Payload  string `db:"payload"`
+}
+
+// Transaction includes information about a particular order. Status can be pending, failure, completed, or error.
+type Transaction struct {
+	ID                    uuid.UUID       `json:"id" db:"id"`
+	OrderID               uuid.UUID       `json:"orderId" db:"order_id"`
+	CreatedAt             time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt             time.Time       `json:"updatedAt" db:"updated_at"`
+	ExternalTransactionID string          `json:"externalTransactionId" db:"external_transaction_id"`
+	Status                string          `json:"status" db:"status"`
+	Currency              string          `json:"currency" db:"currency"`
+	Kind                  string          `json:"kind" db:"kind"`
+	Amount                decimal.Decimal `json:"amount" db:"amount"`
+}
+
+// TransactionNew represents a request to record a transaction.
+type TransactionNew struct {
+	OrderID               uuid.UUID       `db:"order_id"`
+	ExternalTransactionID string          `db:"external_transaction_id"`
+	Status                string          `db:"status"`
+	Currency              string          `db:"currency"`
+	Kind                  string          `db:"kind"`
+	Amount                decimal.Decimal `db:"amount"`
+}
+
+// OrderSeat allows another device to redeem its own credentials against a shared order.
+//
+// A seat is created with a single-use invite token.
+// Once redeemed, the seat is active, and the device uses the seat id to request credentials.
+type OrderSeat struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	OrderID     uuid.UUID  `json:"orderId" db:"order_id"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	InviteToken string     `json:"inviteToken,omitempty" db:"invite_token"`
+	Status      string     `json:"status" db:"status"`
+	RedeemedAt  *time.Time `json:"redeemedAt" db:"redeemed_at"`
+	RevokedAt   *time.Time `json:"revokedAt" db:"revoked_at"`
+}
+
+func (x *OrderSeat) IsActive() bool {
+	return x.Status == OrderSeatStatusActive
+}
+
+// OrderEvent is a change in the lifecycle of an order, stored until it has been published.
+type OrderEvent struct {
+	ID         uuid.UUID  `db:"id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	OrderID    uuid.UUID  `db:"order_id"`
+	Type       string     `db:"event_type"`
+	OccurredAt time.Time  `db:"occurred_at"`
+	SentAt     *time.Time `db:"sent_at"`
+}
+
+// OrderDunning tracks recovery of a failed renewal payment for an order.
+//
+// Reminders are due at NextReminderAt until the entry is resolved by a payment, or the grace period ends.
+type OrderDunning struct {
+	ID             uuid.UUID  `db:"id"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	OrderID        uuid.UUID  `db:"order_id"`
+	Vendor         string     `db:"vendor"`
+	GraceEndsAt    time.Time  `db:"grace_ends_at"`
+	NextReminderAt time.Time  `db:"next_reminder_at"`
+	NumReminders   int        `db:"num_reminders"`
+	ResolvedAt     *time.Time `db:"resolved_at"`
+	CanceledAt     *time.Time `db:"canceled_at"`
+}
+
+// HasGraceEndedAt reports whether the grace period is over at now.
+func (x *OrderDunning) HasGraceEndedAt(now time.Time) bool {
+	return !now.Before(x.GraceEndsAt)
+}
+
+// StripeSubCancel is a pending cancellation of the Stripe subscription of an order.
+//
+// It is recorded together with the local cancellation, and retried until Stripe confirms it.
+type StripeSubCancel struct {
+	ID          uuid.UUID  `db:"id"`
+	CreatedAt   time.Time  `db:"created_at"`
+	OrderID     uuid.UUID  `db:"order_id"`
+	SubID       string     `db:"sub_id"`
+	NumAttempts int        `db:"num_attempts"`
+	CanceledAt  *time.Time `db:"canceled_at"`
+}
+
+// Coupon is a promo code which discounts eligible items of an order.
+//
+// A percentage discount reduces the price by Amount percent.
+// A fixed discount reduces the price by Amount in Currency, but not below zero.
+type Coupon struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updatedAt" db:"updated_at"`
+	Code           string          `json:"code" db:"code"`
+	DiscountType   string          `json:"discountType" db:"discount_type"`
+	Amount         decimal.Decimal `json:"amount" db:"amount"`
+	Currency       *string         `json:"currency" db:"currency"`
+	SKUs           pq.StringArray  `json:"skus" db:"skus"`
+	MaxRedemptions *int            `json:"maxRedemptions" db:"max_redemptions"`
+	NumRedemptions int             `json:"numRedemptions" db:"num_redemptions"`
+	ExpiresAt      *time.Time      `json:"expiresAt" db:"expires_at"`
+	StripeCouponID *string         `json:"stripeCouponId" db:"stripe_coupon_id"`
+}
+
+// IsEligible reports whether the coupon applies to the SKU variant.
+//
+// A coupon without SKUs applies to all SKUs.
+func (x *Coupon) IsEligible(skuVnt string) bool {
+	if len(x.SKUs) == 0 {
+		return true
+	}
+
+	return Slice[string](x.SKUs).Contains(skuVnt)
+}
+
+func (x *Coupon) IsExpiredAt(now time.Time) bool {
+	return x.ExpiresAt != nil && !now.Before(*x.ExpiresAt)
+}
+
+func (x *Coupon) IsExhausted() bool {
+	return x.MaxRedemptions != nil && x.NumRedemptions >= *x.MaxRedemptions
+}
+
+// Validate checks that the coupon can be redeemed at now for an order in currency.
+func (x *Coupon) Validate(now time.Time, currency string) error {
+	if x.IsExpiredAt(now) {
+		return ErrCouponExpired
+	}
+
+	if x.IsExhausted() {
+		return ErrCouponExhausted
+	}
+
+	if x.DiscountType == CouponDiscountTypeFixed && (x.Currency == nil || *x.Currency != currency) {
+		return ErrCouponCurrencyMismatch
+	}
+
+	return nil
+}
+
+// Apply returns the discounted unit price.
+func (x *Coupon) Apply(price decimal.Decimal) decimal.Decimal {
+	switch x.DiscountType {
+	case CouponDiscountTypePercentage:
+		pct := decimal.NewFromInt(100)
+
+		return price.Mul(pct.Sub(x.Amount)).Div(pct).Round(2)
+
+	case CouponDiscountTypeFixed:
+		result := price.Sub(x.Amount)
+		if result.IsNegative() {
+			return decimal.Zero
+		}
+
+		return result
+
+	default:
+		return price
+	}
+}
+
+// CreateCouponRequest represents a request to create a coupon.
+type CreateCouponRequest struct {
+	Code           string          `json:"code" validate:"required"`
+	DiscountType   string          `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency"`
+	SKUs           []string        `json:"skus"`
+	MaxRedemptions *int            `json:"max_redemptions" validate:"omitempty,gt=0"`
+	ExpiresAt      *time.Time      `json:"expires_at"`
+	StripeCouponID string          `json:"stripe_coupon_id"`
+}
+
+// IsValid checks the constraints which cannot be expressed with validation tags.
+func (r *CreateCouponRequest) IsValid() bool {
+	if !r.Amount.IsPositive() {
+		return false
+	}
+
+	switch r.DiscountType {
+	case CouponDiscountTypePercentage:
+		return r.Amount.LessThanOrEqual(decimal.NewFromInt(100))
+
+	case CouponDiscountTypeFixed:
+		return r.Currency != ""
+
+	default:
+		return false
+	}
+}
+
+// SKUCatalogEntry represents a product variant which can be sold.
+type SKUCatalogEntry struct {
+	ID                          uuid.UUID       `json:"id" db:"id"`
+	CreatedAt                   time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt                   time.Time       `json:"updatedAt" db:"updated_at"`
+	SKU                         string          `json:"sku" db:"sku"`
+	SKUVnt                      string          `json:"skuVariant" db:"sku_variant"`
+	Location                    string          `json:"location" db:"location"`
+	Description                 string          `json:"description" db:"description"`
+	Period                      string          `json:"period" db:"period"`
+	Price                       decimal.Decimal `json:"price" db:"price"`
+	Currency                    string          `json:"currency" db:"currency"`
+	CredentialType              string          `json:"credentialType" db:"credential_type"`
+	CredentialValidDuration     string          `json:"credentialValidDuration" db:"credential_valid_duration"`
+	CredentialValidDurationEach *string         `json:"eachCredentialValidDuration" db:"each_credential_valid_duration"`
+	IssuanceInterval            *string         `json:"issuanceInterval" db:"issuance_interval"`
+	IssuerTokenBuffer           *int            `json:"issuerTokenBuffer" db:"issuer_token_buffer"`
+	IssuerTokenOverlap          *int            `json:"issuerTokenOverlap" db:"issuer_token_overlap"`
+	AllowedPaymentMethods       pq.StringArray  `json:"allowedPaymentMethods" db:"allowed_payment_methods"`
+	StripeProductID             *string         `json:"stripeProductId" db:"stripe_product_id"`
+	StripePriceID               *string         `json:"stripePriceId" db:"stripe_price_id"`
+	StoreProductIDs             pq.StringArray  `json:"storeProductIds" db:"store_product_ids"`
+}
+
+// OrderItemRequestNew returns a request for a single item of the variant.
+func (x *SKUCatalogEntry) OrderItemRequestNew() OrderItemRequestNew {
+	result := OrderItemRequestNew{
+		Quantity:                    1,
+		SKU:                         x.SKU,
+		SKUVnt:                      x.SKUVnt,
+		Period:                      x.Period,
+		Location:                    x.Location,
+		Description:                 x.Description,
+		CredentialType:              x.CredentialType,
+		CredentialValidDuration:     x.CredentialValidDuration,
+		Price:                       x.Price,
+		IssuerTokenBuffer:           x.IssuerTokenBuffer,
+		IssuerTokenOverlap:          x.IssuerTokenOverlap,
+		CredentialValidDurationEach: x.CredentialValidDurationEach,
+		IssuanceInterval:            x.IssuanceInterval,
+	}
+
+	if x.StripeProductID != nil && x.StripePriceID != nil {
+		result.StripeMetadata = &ItemStripeMetadata{
+			ProductID: *x.StripeProductID,
+			ItemID:    *x.StripePriceID,
+		}
+	}
+
+	return result
+}
+
+// SKUCatalogEntryRequest represents a request to create or update a catalog entry.
+type SKUCatalogEntryRequest struct {
+	SKU                         string          `json:"sku" validate:"required"`
+	SKUVnt                      string          `json:"sku_variant" validate:"required"`
+	Location                    string          `json:"location" validate:"required"`
+	Description                 string          `json:"description"`
+	Period                      string          `json:"period"`
+	Price                       decimal.Decimal `json:"price"`
+	Currency                    string          `json:"currency"`
+	CredentialType              string          `json:"credential_type" validate:"required,oneof=single-use time-limited time-limited-v2"`
+	CredentialValidDuration     string          `json:"credential_valid_duration"`
+	CredentialValidDurationEach *string         `json:"each_credential_valid_duration"`
+	IssuanceInterval            *string         `json:"issuance_interval"`
+	IssuerTokenBuffer           *int            `json:"issuer_token_buffer" validate:"omitempty,gt=0"`
+	IssuerTokenOverlap          *int            `json:"issuer_token_overlap" validate:"omitempty,gte=0"`
+	AllowedPaymentMethods       []string        `json:"allowed_payment_methods"`
+	StripeProductID             string          `json:"stripe_product_id"`
+	StripePriceID               string          `json:"stripe_price_id"`
+	StoreProductIDs             []string        `json:"store_product_ids"`
+}
+
+// IsValid checks the constraints which cannot be expressed with validation tags.
+func (r *SKUCatalogEntryRequest) IsValid() bool {
+	if r.Price.IsNegative() {
+		return false
+	}
+
+	// Stripe needs both the product and the price to create a session.
+	if (r.StripeProductID == "") != (r.StripePriceID == "") {
+		return false
+	}
+
+	if r.CredentialType == "time-limited-v2" {
+		return r.CredentialValidDurationEach != nil && r.IssuanceInterval != nil
+	}
+
+	return true
+}
+
+// SKUPrice represents the price of a catalog entry in a currency.
+//
+// A price with an empty Country applies to all countries without a regional price.
+type SKUPrice struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updatedAt" db:"updated_at"`
+	SKUCatalogID  uuid.UUID       `json:"skuCatalogId" db:"sku_catalog_id"`
+	Currency      string          `json:"currency" db:"currency"`
+	Country       string          `json:"country" db:"country"`
+	Amount        decimal.Decimal `json:"amount" db:"amount"`
+	StripePriceID *string         `json:"stripePriceId" db:"stripe_price_id"`
+}
+
+// SKUPriceRequest represents a request to set the price of a catalog entry.
+type SKUPriceRequest struct {
+	Currency      string          `json:"currency" validate:"required,iso4217"`
+	Country       string          `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+	Amount        decimal.Decimal `json:"amount"`
+	StripePriceID string          `json:"stripe_price_id"`
+}
+
+func (r *SKUPriceRequest) IsValid() bool {
+	return !r.Amount.IsNegative()
+}
+
+// PortalLoginCode represents a one-time code sent to a customer's email to sign in to the portal.
+type PortalLoginCode struct {
+	ID          uuid.UUID  `db:"id"`
+	CreatedAt   time.Time  `db:"created_at"`
+	Email       string     `db:"email"`
+	CodeHash    string     `db:"code_hash"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	NumAttempts int        `db:"num_attempts"`
+	UsedAt      *time.Time `db:"used_at"`
+}
+
+// PortalLoginStats counts login codes sent to an email, and failed attempts to use them.
+type PortalLoginStats struct {
+	NumCodes          int `db:"num_codes"`
+	NumFailedAttempts int `db:"num_failed_attempts"`
+}
+
+// PortalSession represents a signed in portal customer.
+type PortalSession struct {
+	ID        uuid.UUID `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type PortalLoginRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PortalVerifyRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type PortalSessionResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PortalOrder is the customer's view of an order paid through Stripe.
+type PortalOrder struct {
+	ID                uuid.UUID         `json:"id"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	Status            string            `json:"status"`
+	Currency          string            `json:"currency"`
+	TotalPrice        decimal.Decimal   `json:"totalPrice"`
+	LastPaidAt        *time.Time        `json:"lastPaidAt"`
+	ExpiresAt         *time.Time        `json:"expiresAt"`
+	NextBillingAt     *time.Time        `json:"nextBillingAt"`
+	CancelAtPeriodEnd bool              `json:"cancelAtPeriodEnd"`
+	Items             []PortalOrderItem `json:"items"`
+}
+
+type PortalOrderItem struct {
+	SKU         string          `json:"sku"`
+	SKUVnt      string          `json:"skuVariant"`
+	Description string          `json:"description"`
+	Quantity    int             `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+}
+
+type PortalPayment struct {
+	PaidAt time.Time `json:"paidAt"`
+}
+
+// PortalBillingSessionRequest requests a Stripe billing portal session.
+//
+// ReturnURL must be on one of the configured return hosts.
+// OrderID selects the customer when the email belongs to several Stripe customers.
+type PortalBillingSessionRequest struct {
+	ReturnURL string     `json:"return_url" validate:"required,http_url"`
+	OrderID   *uuid.UUID `json:"orderId"`
+}
+
+type PortalBillingSessionResponse struct {
+	URL string `json:"url"`
+}
+
+// MerchantKeyUse is an audit record of an authenticated request made with a merchant key.
+type MerchantKeyUse struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	KeyID      uuid.UUID `json:"keyId" db:"key_id"`
+	MerchantID string    `json:"merchantId" db:"merchant_id"`
+	Method     string    `json:"method" db:"method"`
+	Path       string    `json:"path" db:"path"`
+	Status     int       `json:"status" db:"status"`
+}
+
+// MerchantKeyUseFilter narrows down audit records of a merchant.
+//
+// Records are returned newest first, created before Before when it is set.
+type MerchantKeyUseFilter struct {
+	KeyID  uuid.NullUUID
+	Before time.Time
+	Limit  int
+}
+
+type MerchantKeyUsesResponse struct {
+	Uses []MerchantKeyUse `json:"uses"`
+}
+
+// MerchantKeyRotation schedules a key to be rotated every EverySeconds.
+//
+// The replaced key stays valid for OverlapSeconds. EverySeconds of zero turns scheduled rotation off.
+type MerchantKeyRotation struct {
+	EverySeconds   int `json:"everySeconds"`
+	OverlapSeconds int `json:"overlapSeconds"`
+}
+
+func (x *MerchantKeyRotation) IsValid() error {
+	if x.EverySeconds < 0 || x.OverlapSeconds < 0 {
+		return ErrMerchantKeyRotationInvalid
+	}
+
+	if x.EverySeconds > 0 && x.OverlapSeconds >= x.EverySeconds {
+		return ErrMerchantKeyRotationInvalid
+	}
+
+	return nil
+}
+
+// MerchantKeyDueRotation is a key whose scheduled rotation is due.
+type MerchantKeyDueRotation struct {
+	KeyID          uuid.UUID `db:"id"`
+	MerchantID     string    `db:"merchant_id"`
+	OverlapSeconds int       `db:"rotation_overlap_seconds"`
+}
+
+// MerchantWebhookEndpoint is a URL to which events about orders of the merchant are delivered.
+//
+// Requests are signed with the merchant key KeyID. An empty EventTypes subscribes to all events.
+type MerchantWebhookEndpoint struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	MerchantID string         `json:"merchantId" db:"merchant_id"`
+	URL        string         `json:"url" db:"url"`
+	KeyID      uuid.UUID      `json:"keyId" db:"key_id"`
+	EventTypes pq.StringArray `json:"eventTypes" db:"event_types"`
+	DisabledAt *time.Time     `json:"disabledAt,omitempty" db:"disabled_at"`
+}
+
+type CreateWebhookEndpointRequest struct {
+	URL        string   `json:"url" validate:"required"`
+	EventTypes []string `json:"eventTypes"`
+}
+
+// Validate checks that the endpoint uses HTTPS, and subscribes only to known events.
+func (r *CreateWebhookEndpointRequest) Validate() error {
+	uri, err := url.Parse(r.URL)
+	if err != nil {
+		return ErrWebhookEndpointInvalid
+	}
+
+	if uri.Scheme != "https" || uri.Host == "" {
+		return ErrWebhookEndpointInvalid
+	}
+
+	for i := range r.EventTypes {
+		switch r.EventTypes[i] {
+		case OrderEventPaid, OrderEventRenewed, OrderEventCanceled, OrderEventCredsSigned, MerchantKeyEventRotated:
+		default:
+			return ErrWebhookEndpointInvalid
+		}
+	}
+
+	return nil
+}
+
+type MerchantWebhookEndpointsResponse struct {
+	Endpoints []MerchantWebhookEndpoint `json:"endpoints"`
+}
+
+// MerchantWebhookDelivery tracks delivery of an event to a merchant endpoint.
+//
+// The event is either about an order, or about the rotation of a merchant key.
+type MerchantWebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+	EndpointID     uuid.UUID  `json:"endpointId" db:"endpoint_id"`
+	EventID        uuid.UUID  `json:"eventId" db:"event_id"`
+	EventType      string     `json:"eventType" db:"event_type"`
+	OrderID        *uuid.UUID `json:"orderId,omitempty" db:"order_id"`
+	RotatedKeyID   *uuid.UUID `json:"keyId,omitempty" db:"rotated_key_id"`
+	NewKeyID       *uuid.UUID `json:"newKeyId,omitempty" db:"new_key_id"`
+	OccurredAt     time.Time  `json:"occurredAt" db:"occurred_at"`
+	Status         string     `json:"status" db:"status"`
+	NumAttempts    int        `json:"numAttempts" db:"num_attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"lastAttemptAt,omitempty" db:"last_attempt_at"`
+	LastStatusCode *int       `json:"lastStatusCode,omitempty" db:"last_status_code"`
+	LastError      *string    `json:"lastError,omitempty" db:"last_error"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty" db:"delivered_at"`
+}
+
+// MerchantWebhookDueDelivery is a delivery with the details of the endpoint needed to attempt it.
+type MerchantWebhookDueDelivery struct {
+	MerchantWebhookDelivery
+	URL   string    `db:"url"`
+	KeyID uuid.UUID `db:"key_id"`
+}
+
+// MerchantWebhookDeliveryFilter narrows down deliveries to endpoints of a merchant.
+//
+// Deliveries are returned newest first, created before Before when it is set.
+type MerchantWebhookDeliveryFilter struct {
+	EndpointID uuid.NullUUID
+	Before     time.Time
+	Limit      int
+}
+
+type MerchantWebhookDeliveriesResponse struct {
+	Deliveries []MerchantWebhookDelivery `json:"deliveries"`
+}
+
+// MerchantWebhookPayload is the body of a request delivering an event to a merchant endpoint.
+//
+// ID identifies the event, and is the same for every delivery attempt.
+type MerchantWebhookPayload struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	OrderID    string `json:"orderId,omitempty"`
+	KeyID      string `json:"keyId,omitempty"`
+	NewKeyID   string `json:"newKeyId,omitempty"`
+	OccurredAt string `json:"occurredAt"`
+}
+
+// SpendChannel defines where credentials of a merchant are spent.
+//
+// Spends carry a payload described by the Avro record schema PayloadSchema.
+// Once the credentials have been redeemed, an event with the payload is published to Topic.
+type SpendChannel struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	MerchantID    string          `json:"merchantId" db:"merchant_id"`
+	Name          string          `json:"name" db:"name"`
+	Topic         string          `json:"topic" db:"topic"`
+	PayloadSchema json.RawMessage `json:"payloadSchema" db:"payload_schema"`
+	AllowedSKUs   pq.StringArray  `json:"allowedSkus" db:"allowed_skus"`
+	DisabledAt    *time.Time      `json:"disabledAt,omitempty" db:"disabled_at"`
+}
+
+// IsSKUAllowed reports whether credentials issued for sku can be spent on the channel.
+func (x *SpendChannel) IsSKUAllowed(sku string) bool {
+	for i := range x.AllowedSKUs {
+		if x.AllowedSKUs[i] == sku {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CreateSpendChannelRequest struct {
+	Name          string          `json:"name"`
+	PayloadSchema json.RawMessage `json:"payloadSchema"`
+	AllowedSKUs   []string        `json:"allowedSkus"`
+}
+
+// IsValid reports whether the name can be a part of a topic name, and the rest of the request is present.
+//
+// The schema itself is checked when it is compiled.
+func (r *CreateSpendChannelRequest) IsValid() bool {
+	if len(r.Name) == 0 || len(r.Name) > 64 {
+		return false
+	}
+
+	for _, c := range r.Name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+
+	return len(r.PayloadSchema) > 0 && len(r.AllowedSKUs) > 0
+}
+
+type SpendChannelsResponse struct {
+	Channels []SpendChannel `json:"channels"`
+}
+
+// SpendRecord is a spend of credentials queued for redemption.
+//
+// Event is published to Topic once the credentials have been redeemed.
+type SpendRecord struct {
+	ID          uuid.UUID `db:"id"`
+	CreatedAt   time.Time `db:"created_at"`
+	ChannelID   uuid.UUID `db:"channel_id"`
+	Topic       string    `db:"topic"`
+	Credentials string    `db:"credentials"`
+	Payload     string    `db:"payload"`
+	Event       []byte    `db:"spend_event"`
+	Redeemed    bool      `db:"redeemed"`
+	Erred       bool      `db:"erred"`
+	ErrCode     *string   `db:"errcode"`
+	Processed   bool      `db:"processed"`
+	NumAttempts int       `db:"num_attempts"`
+}
+
 type TLV2CredSubmissionReport struct {
 	Submitted     bool `db:"submitted"`
 	ReqIDMismatch bool `db:"req_id_mismatch"`
@@ -743,6 +1634,27 @@ type VerifyCredentialOpaque struct {
 	Version      float64 `json:"version" validate:"-"`
 }
 
+// VerifyCredentialBatchRequest is a request to verify many credentials in one call.
+type VerifyCredentialBatchRequest struct {
+	Credentials []*VerifyCredentialRequestV2 `json:"credentials" validate:"required,min=1,max=100"`
+}
+
+type VerifyCredentialBatchResponse struct {
+	Results []VerifyCredentialResult `json:"results"`
+}
+
+// VerifyCredentialResult is the outcome of verifying one credential from a batch.
+//
+// Status is what the single credential verification endpoint would respond with.
+// Duplicate reports a time limited v2 credential redeemed before, and the caller decides whether to accept it.
+type VerifyCredentialResult struct {
+	Status    int    `json:"status"`
+	Verified  bool   `json:"verified"`
+	ID        string `json:"id,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
 type SetTrialDaysRequest struct {
 	Email     string `json:"email"` // TODO: Make it required.
 	TrialDays int64  `json:"trialDays"`
@@ -780,3 +1692,118 @@ func numFromAny(raw any) (int, error) {
 		return 0, errInvalidNumConversion
 	}
 }
+
+// ConsumerStatus describes the state of a Kafka consumer.
+//
+// Paused is shared by all instances of the consumer group. Lag is the number of messages not yet read, by partition,
+// as of the last message read on the instance which reports it.
+type ConsumerStatus struct {
+	Topic  string        `json:"topic"`
+	Paused bool          `json:"paused"`
+	Lag    map[int]int64 `json:"lag"`
+}
+
+// IssuerKey is a signing key of a version 3 issuer, as last reported by the challenge bypass server.
+type IssuerKey struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	IssuerID  uuid.UUID  `json:"issuerId" db:"issuer_id"`
+	PublicKey string     `json:"publicKey" db:"public_key"`
+	ValidFrom time.Time  `json:"validFrom" db:"valid_from"`
+	ValidTo   time.Time  `json:"validTo" db:"valid_to"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+type IssuerKeyNew struct {
+	PublicKey string
+	ValidFrom time.Time
+	ValidTo   time.Time
+}
+
+// IssuerKeyState summarises the keys of a version 3 issuer.
+//
+// ValidUntil is the end of the last key which has not been revoked.
+type IssuerKeyState struct {
+	Issuer        string     `json:"issuer" db:"merchant_id"`
+	KeysCheckedAt *time.Time `json:"keysCheckedAt" db:"keys_checked_at"`
+	NumKeys       int        `json:"numKeys" db:"num_keys"`
+	ValidUntil    *time.Time `json:"validUntil" db:"valid_until"`
+}
+
+type IssuerKeyStatesResponse struct {
+	Issuers []IssuerKeyState `json:"issuers"`
+}
+
+type RotateIssuerRequest struct {
+	MerchantID string `json:"merchantId" validate:"required"`
+	SKU        string `json:"sku" validate:"required"`
+}
+
+type RotateIssuerResponse struct {
+	Issuer              string      `json:"issuer"`
+	Keys                []IssuerKey `json:"keys"`
+	NumCredsInvalidated int64       `json:"numCredsInvalidated"`
+}
+
+// TLV1Migration moves an sku from time limited v1 to time limited v2 credentials.
+//
+// Time limited v1 items of the sku can mint time limited v2 batches.
+// Time limited v1 credentials are accepted until V1AcceptedUntil, or indefinitely if it is not set.
+type TLV1Migration struct {
+	ID                          uuid.UUID  `json:"id" db:"id"`
+	CreatedAt                   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt                   time.Time  `json:"updatedAt" db:"updated_at"`
+	MerchantID                  string     `json:"merchantId" db:"merchant_id"`
+	SKU                         string     `json:"sku" db:"sku"`
+	CredentialValidDurationEach string     `json:"eachCredentialValidDuration" db:"each_credential_valid_duration"`
+	IssuerTokenBuffer           int        `json:"issuerTokenBuffer" db:"issuer_token_buffer"`
+	IssuerTokenOverlap          int        `json:"issuerTokenOverlap" db:"issuer_token_overlap"`
+	V1AcceptedUntil             *time.Time `json:"v1AcceptedUntil" db:"v1_accepted_until"`
+}
+
+// AcceptsV1 reports whether time limited v1 credentials are still accepted at now.
+func (x *TLV1Migration) AcceptsV1(now time.Time) bool {
+	return x.V1AcceptedUntil == nil || now.Before(*x.V1AcceptedUntil)
+}
+
+// IssuerConfig returns the configuration of the version 3 issuer for the sku.
+func (x *TLV1Migration) IssuerConfig() IssuerConfig {
+	return IssuerConfig{Buffer: x.IssuerTokenBuffer, Overlap: x.IssuerTokenOverlap}
+}
+
+// NumIntervals returns the number of intervals a batch covers.
+func (x *TLV1Migration) NumIntervals() int {
+	return x.IssuerTokenBuffer + x.IssuerTokenOverlap
+}
+
+type TLV1MigrationRequest struct {
+	MerchantID                  string     `json:"merchantId" validate:"required"`
+	SKU                         string     `json:"sku" validate:"required"`
+	CredentialValidDurationEach string     `json:"eachCredentialValidDuration" validate:"required"`
+	IssuerTokenBuffer           int        `json:"issuerTokenBuffer" validate:"gt=0"`
+	IssuerTokenOverlap          int        `json:"issuerTokenOverlap" validate:"gte=0"`
+	V1AcceptedUntil             *time.Time `json:"v1AcceptedUntil"`
+}
+
+// TLV1Presentation counts time limited v1 credentials presented for an sku on the day of LastPresentedAt.
+type TLV1Presentation struct {
+	MerchantID      string
+	SKU             string
+	NumPresented    int64
+	LastPresentedAt time.Time
+}
+
+// TLV1Usage summarises time limited v1 credentials presented for an sku.
+type TLV1Usage struct {
+	MerchantID      string     `json:"merchantId" db:"merchant_id"`
+	SKU             string     `json:"sku" db:"sku"`
+	NumPresented    int64      `json:"numPresented" db:"num_presented"`
+	LastPresentedAt time.Time  `json:"lastPresentedAt" db:"last_presented_at"`
+	Migrating       bool       `json:"migrating" db:"migrating"`
+	V1AcceptedUntil *time.Time `json:"v1AcceptedUntil" db:"v1_accepted_until"`
+}
+
+type TLV1UsageReport struct {
+	Since time.Time   `json:"since"`
+	Usage []TLV1Usage `json:"usage"`
+}
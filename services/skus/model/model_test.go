@@ -3,6 +3,7 @@ package model_test
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -660,6 +661,71 @@ func TestOrder_StripeSubID(t *testing.T) {
 	}
 }
 
+func TestOrder_PayPalSubID(t *testing.T) {
+	type tcExpected struct {
+		val string
+		ok  bool
+	}
+
+	type testCase struct {
+		name  string
+		given model.Order
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_metadata",
+		},
+
+		{
+			name: "no_field",
+			given: model.Order{
+				Metadata: datastore.Metadata{"key": "value"},
+			},
+		},
+
+		{
+			name: "not_string",
+			given: model.Order{
+				Metadata: datastore.Metadata{
+					"paypalSubscriptionId": 42,
+				},
+			},
+		},
+
+		{
+			name: "empty_string",
+			given: model.Order{
+				Metadata: datastore.Metadata{
+					"paypalSubscriptionId": "",
+				},
+			},
+			exp: tcExpected{ok: true},
+		},
+
+		{
+			name: "I-1",
+			given: model.Order{
+				Metadata: datastore.Metadata{
+					"paypalSubscriptionId": "I-1",
+				},
+			},
+			exp: tcExpected{val: "I-1", ok: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := tc.given.PayPalSubID()
+			should.Equal(t, tc.exp.ok, ok)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
 func TestOrder_StripeSessID(t *testing.T) {
 	type tcExpected struct {
 		val string
@@ -783,6 +849,55 @@ func TestOrder_NumPaymentFailed(t *testing.T) {
 	}
 }
 
+func TestOrder_NumSeats(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.Order
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name: "no_metadata",
+		},
+
+		{
+			name: "no_field",
+			given: model.Order{
+				Metadata: datastore.Metadata{"key": "value"},
+			},
+		},
+
+		{
+			name: "not_number",
+			given: model.Order{
+				Metadata: datastore.Metadata{
+					"numSeats": "something",
+				},
+			},
+		},
+
+		{
+			name: "set_float",
+			given: model.Order{
+				Metadata: datastore.Metadata{
+					"numSeats": float64(5),
+				},
+			},
+			exp: 5,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.NumSeats()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
 func TestOrder_IsIOS(t *testing.T) {
 	type testCase struct {
 		name  string
@@ -1290,6 +1405,29 @@ func TestOrder_IsPaidAt(t *testing.T) {
 			},
 		},
 
+		{
+			name: "true_past_due_grace_period",
+			given: tcGiven{
+				ord: &model.Order{
+					Status:    model.OrderStatusPastDue,
+					ExpiresAt: ptrTo(time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: true,
+		},
+
+		{
+			name: "false_past_due_grace_ended",
+			given: tcGiven{
+				ord: &model.Order{
+					Status:    model.OrderStatusPastDue,
+					ExpiresAt: ptrTo(time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				now: time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+
 		{
 			name: "false_pending",
 			given: tcGiven{
@@ -1440,6 +1578,54 @@ func TestOrderItem_RadomProductID(t *testing.T) {
 	}
 }
 
+func TestOrderItem_PayPalPlanID(t *testing.T) {
+	type tcExpected struct {
+		val string
+		ok  bool
+	}
+
+	type testCase struct {
+		name  string
+		given model.OrderItem
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_metadata",
+		},
+
+		{
+			name: "not_string",
+			given: model.OrderItem{
+				Metadata: datastore.Metadata{
+					"paypal_plan_id": 42,
+				},
+			},
+		},
+
+		{
+			name: "paypal_plan_id",
+			given: model.OrderItem{
+				Metadata: datastore.Metadata{
+					"paypal_plan_id": "P-1",
+				},
+			},
+			exp: tcExpected{val: "P-1", ok: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := tc.given.PayPalPlanID()
+			should.Equal(t, tc.exp.ok, ok)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
 func TestOrderItem_IsLeo(t *testing.T) {
 	type testCase struct {
 		name  string
@@ -1670,6 +1856,22 @@ func TestOrderItemRequestNew_Metadata(t *testing.T) {
 			},
 		},
 
+		{
+			name: "paypal_metadata",
+			given: tcGiven{
+				oreq: model.OrderItemRequestNew{
+					PayPalMetadata: &model.ItemPayPalMetadata{
+						PlanID: "P-1",
+					},
+				},
+			},
+			exp: tcExpected{
+				metadata: map[string]interface{}{
+					"paypal_plan_id": "P-1",
+				},
+			},
+		},
+
 		{
 			name: "no_metadata",
 			given: tcGiven{
@@ -1837,6 +2039,747 @@ func TestOrderItemRequestNew_IsTLV2(t *testing.T) {
 	}
 }
 
-func ptrTo[T any](v T) *T {
-	return &v
+func TestCoupon_IsEligible(t *testing.T) {
+	type tcGiven struct {
+		coupon model.Coupon
+		skuVnt string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "all_skus",
+			given: tcGiven{
+				skuVnt: "brave-vpn-premium",
+			},
+			exp: true,
+		},
+
+		{
+			name: "listed",
+			given: tcGiven{
+				coupon: model.Coupon{SKUs: pq.StringArray{"brave-leo-premium", "brave-vpn-premium"}},
+				skuVnt: "brave-vpn-premium",
+			},
+			exp: true,
+		},
+
+		{
+			name: "not_listed",
+			given: tcGiven{
+				coupon: model.Coupon{SKUs: pq.StringArray{"brave-leo-premium"}},
+				skuVnt: "brave-vpn-premium",
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.coupon.IsEligible(tc.given.skuVnt)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestCoupon_Validate(t *testing.T) {
+	type tcGiven struct {
+		coupon   model.Coupon
+		now      time.Time
+		currency string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "expired",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					ExpiresAt:    ptrTo(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				currency: "USD",
+			},
+			exp: model.ErrCouponExpired,
+		},
+
+		{
+			name: "exhausted",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType:   model.CouponDiscountTypePercentage,
+					MaxRedemptions: ptrTo(10),
+					NumRedemptions: 10,
+				},
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				currency: "USD",
+			},
+			exp: model.ErrCouponExhausted,
+		},
+
+		{
+			name: "fixed_currency_mismatch",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypeFixed,
+					Currency:     ptrTo("EUR"),
+				},
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				currency: "USD",
+			},
+			exp: model.ErrCouponCurrencyMismatch,
+		},
+
+		{
+			name: "valid_fixed",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType:   model.CouponDiscountTypeFixed,
+					Currency:       ptrTo("USD"),
+					MaxRedemptions: ptrTo(10),
+					NumRedemptions: 9,
+					ExpiresAt:      ptrTo(time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)),
+				},
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				currency: "USD",
+			},
+		},
+
+		{
+			name: "valid_percentage",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+				},
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				currency: "USD",
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.coupon.Validate(tc.given.now, tc.given.currency)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestCoupon_Apply(t *testing.T) {
+	type tcGiven struct {
+		coupon model.Coupon
+		price  decimal.Decimal
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   decimal.Decimal
+	}
+
+	tests := []testCase{
+		{
+			name: "percentage",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					Amount:       decimal.NewFromInt(25),
+				},
+				price: decimal.RequireFromString("9.99"),
+			},
+			exp: decimal.RequireFromString("7.49"),
+		},
+
+		{
+			name: "percentage_full",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					Amount:       decimal.NewFromInt(100),
+				},
+				price: decimal.RequireFromString("9.99"),
+			},
+			exp: decimal.Zero,
+		},
+
+		{
+			name: "fixed",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypeFixed,
+					Amount:       decimal.NewFromInt(2),
+				},
+				price: decimal.RequireFromString("9.99"),
+			},
+			exp: decimal.RequireFromString("7.99"),
+		},
+
+		{
+			name: "fixed_above_price",
+			given: tcGiven{
+				coupon: model.Coupon{
+					DiscountType: model.CouponDiscountTypeFixed,
+					Amount:       decimal.NewFromInt(20),
+				},
+				price: decimal.RequireFromString("9.99"),
+			},
+			exp: decimal.Zero,
+		},
+
+		{
+			name: "unknown_type",
+			given: tcGiven{
+				coupon: model.Coupon{
+					Amount: decimal.NewFromInt(2),
+				},
+				price: decimal.RequireFromString("9.99"),
+			},
+			exp: decimal.RequireFromString("9.99"),
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.coupon.Apply(tc.given.price)
+			should.True(t, tc.exp.Equal(actual))
+		})
+	}
+}
+
+func TestOrderItem_IsDiscounted(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.OrderItem
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "zero_value",
+		},
+
+		{
+			name: "full_price",
+			given: model.OrderItem{
+				Quantity: 2,
+				Price:    decimal.RequireFromString("9.99"),
+				Subtotal: decimal.RequireFromString("19.98"),
+			},
+		},
+
+		{
+			name: "discounted",
+			given: model.OrderItem{
+				Quantity: 2,
+				Price:    decimal.RequireFromString("9.99"),
+				Subtotal: decimal.RequireFromString("14.98"),
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.IsDiscounted()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestOrderDunning_HasGraceEndedAt(t *testing.T) {
+	type testCase struct {
+		name  string
+		given time.Time
+		exp   bool
+	}
+
+	graceEndsAt := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:  "before",
+			given: graceEndsAt.Add(-time.Second),
+		},
+
+		{
+			name:  "at",
+			given: graceEndsAt,
+			exp:   true,
+		},
+
+		{
+			name:  "after",
+			given: graceEndsAt.Add(time.Second),
+			exp:   true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			dun := &model.OrderDunning{GraceEndsAt: graceEndsAt}
+
+			should.Equal(t, tc.exp, dun.HasGraceEndedAt(tc.given))
+		})
+	}
+}
+
+func TestSKUCatalogEntry_OrderItemRequestNew(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.SKUCatalogEntry
+		exp   model.OrderItemRequestNew
+	}
+
+	tests := []testCase{
+		{
+			name: "without_stripe",
+			given: model.SKUCatalogEntry{
+				SKU:                     "brave-search-premium",
+				SKUVnt:                  "brave-search-premium-year",
+				Location:                "search.brave.com",
+				Description:             "Premium access to Search",
+				Period:                  "P1Y",
+				Price:                   decimal.RequireFromString("30"),
+				CredentialType:          "time-limited",
+				CredentialValidDuration: "P1M",
+			},
+			exp: model.OrderItemRequestNew{
+				Quantity:                1,
+				SKU:                     "brave-search-premium",
+				SKUVnt:                  "brave-search-premium-year",
+				Location:                "search.brave.com",
+				Description:             "Premium access to Search",
+				Period:                  "P1Y",
+				Price:                   decimal.RequireFromString("30"),
+				CredentialType:          "time-limited",
+				CredentialValidDuration: "P1M",
+			},
+		},
+
+		{
+			name: "with_stripe",
+			given: model.SKUCatalogEntry{
+				SKU:                         "brave-vpn-premium",
+				SKUVnt:                      "brave-vpn-premium",
+				Location:                    "vpn.brave.com",
+				Description:                 "brave-vpn-premium",
+				Period:                      "P1M",
+				Price:                       decimal.RequireFromString("9.99"),
+				CredentialType:              "time-limited-v2",
+				CredentialValidDuration:     "P1M",
+				CredentialValidDurationEach: ptrTo("P1D"),
+				IssuanceInterval:            ptrTo("P1D"),
+				IssuerTokenBuffer:           ptrTo(31),
+				IssuerTokenOverlap:          ptrTo(2),
+				StripeProductID:             ptrTo("prod_vpn"),
+				StripePriceID:               ptrTo("price_vpn"),
+			},
+			exp: model.OrderItemRequestNew{
+				Quantity:                    1,
+				SKU:                         "brave-vpn-premium",
+				SKUVnt:                      "brave-vpn-premium",
+				Location:                    "vpn.brave.com",
+				Description:                 "brave-vpn-premium",
+				Period:                      "P1M",
+				Price:                       decimal.RequireFromString("9.99"),
+				CredentialType:              "time-limited-v2",
+				CredentialValidDuration:     "P1M",
+				CredentialValidDurationEach: ptrTo("P1D"),
+				IssuanceInterval:            ptrTo("P1D"),
+				IssuerTokenBuffer:           ptrTo(31),
+				IssuerTokenOverlap:          ptrTo(2),
+				StripeMetadata: &model.ItemStripeMetadata{
+					ProductID: "prod_vpn",
+					ItemID:    "price_vpn",
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.OrderItemRequestNew())
+		})
+	}
+}
+
+func TestSKUCatalogEntryRequest_IsValid(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.SKUCatalogEntryRequest
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "negative_price",
+			given: model.SKUCatalogEntryRequest{
+				CredentialType: "time-limited",
+				Price:          decimal.RequireFromString("-1"),
+			},
+		},
+
+		{
+			name: "stripe_product_without_price",
+			given: model.SKUCatalogEntryRequest{
+				CredentialType:  "time-limited",
+				Price:           decimal.RequireFromString("9.99"),
+				StripeProductID: "prod_vpn",
+			},
+		},
+
+		{
+			name: "tlv2_missing_intervals",
+			given: model.SKUCatalogEntryRequest{
+				CredentialType: "time-limited-v2",
+				Price:          decimal.RequireFromString("9.99"),
+			},
+		},
+
+		{
+			name: "valid_free",
+			given: model.SKUCatalogEntryRequest{
+				CredentialType: "single-use",
+			},
+			exp: true,
+		},
+
+		{
+			name: "valid_tlv2",
+			given: model.SKUCatalogEntryRequest{
+				CredentialType:              "time-limited-v2",
+				Price:                       decimal.RequireFromString("9.99"),
+				CredentialValidDurationEach: ptrTo("P1D"),
+				IssuanceInterval:            ptrTo("P1D"),
+				StripeProductID:             "prod_vpn",
+				StripePriceID:               "price_vpn",
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.IsValid())
+		})
+	}
+}
+
+func TestOrderItem_SetPrice(t *testing.T) {
+	type tcGiven struct {
+		item  model.OrderItem
+		price *model.SKUPrice
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   model.OrderItem
+	}
+
+	tests := []testCase{
+		{
+			name: "no_metadata",
+			given: tcGiven{
+				item: model.OrderItem{
+					Currency: "USD",
+					Quantity: 2,
+					Price:    decimal.RequireFromString("9.99"),
+					Subtotal: decimal.RequireFromString("19.98"),
+				},
+				price: &model.SKUPrice{
+					ID:            uuid.Must(uuid.FromString("cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d")),
+					Currency:      "EUR",
+					Country:       "DE",
+					Amount:        decimal.RequireFromString("8.99"),
+					StripePriceID: ptrTo("price_eur"),
+				},
+			},
+			exp: model.OrderItem{
+				Currency: "EUR",
+				Quantity: 2,
+				Price:    decimal.RequireFromString("8.99"),
+				Subtotal: decimal.RequireFromString("17.98"),
+				Metadata: datastore.Metadata{
+					"price_id":      "cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d",
+					"price_country": "DE",
+				},
+			},
+		},
+
+		{
+			name: "stripe_item",
+			given: tcGiven{
+				item: model.OrderItem{
+					Currency: "USD",
+					Quantity: 1,
+					Price:    decimal.RequireFromString("9.99"),
+					Subtotal: decimal.RequireFromString("9.99"),
+					Metadata: datastore.Metadata{
+						"stripe_product_id": "prod_leo",
+						"stripe_item_id":    "price_usd",
+					},
+				},
+				price: &model.SKUPrice{
+					ID:            uuid.Must(uuid.FromString("cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d")),
+					Currency:      "INR",
+					Amount:        decimal.RequireFromString("499"),
+					StripePriceID: ptrTo("price_inr"),
+				},
+			},
+			exp: model.OrderItem{
+				Currency: "INR",
+				Quantity: 1,
+				Price:    decimal.RequireFromString("499"),
+				Subtotal: decimal.RequireFromString("499"),
+				Metadata: datastore.Metadata{
+					"stripe_product_id": "prod_leo",
+					"stripe_item_id":    "price_inr",
+					"price_id":          "cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d",
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tc.given.item.SetPrice(tc.given.price)
+
+			should.Equal(t, tc.exp.Currency, tc.given.item.Currency)
+			should.True(t, tc.exp.Price.Equal(tc.given.item.Price))
+			should.True(t, tc.exp.Subtotal.Equal(tc.given.item.Subtotal))
+			should.Equal(t, tc.exp.Metadata, tc.given.item.Metadata)
+		})
+	}
+}
+
+func TestCreateWebhookEndpointRequest_Validate(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.CreateWebhookEndpointRequest
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "empty_url",
+			given: model.CreateWebhookEndpointRequest{},
+			exp:   model.ErrWebhookEndpointInvalid,
+		},
+
+		{
+			name:  "http_url",
+			given: model.CreateWebhookEndpointRequest{URL: "http://example.com/hooks"},
+			exp:   model.ErrWebhookEndpointInvalid,
+		},
+
+		{
+			name: "unknown_event_type",
+			given: model.CreateWebhookEndpointRequest{
+				URL:        "https://example.com/hooks",
+				EventTypes: []string{model.OrderEventPaid, "order.deleted"},
+			},
+			exp: model.ErrWebhookEndpointInvalid,
+		},
+
+		{
+			name: "undeliverable_event_type",
+			given: model.CreateWebhookEndpointRequest{
+				URL:        "https://example.com/hooks",
+				EventTypes: []string{model.OrderEventCreated},
+			},
+			exp: model.ErrWebhookEndpointInvalid,
+		},
+
+		{
+			name:  "all_events",
+			given: model.CreateWebhookEndpointRequest{URL: "https://example.com/hooks"},
+		},
+
+		{
+			name: "some_events",
+			given: model.CreateWebhookEndpointRequest{
+				URL:        "https://example.com/hooks",
+				EventTypes: []string{model.OrderEventPaid, model.OrderEventRenewed, model.OrderEventCanceled, model.OrderEventCredsSigned, model.MerchantKeyEventRotated},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.Validate())
+		})
+	}
+}
+
+func TestSpendChannel_IsSKUAllowed(t *testing.T) {
+	type testCase struct {
+		name  string
+		given string
+		exp   bool
+	}
+
+	ch := &model.SpendChannel{AllowedSKUs: []string{"user-wallet-vote", "anon-card-vote"}}
+
+	tests := []testCase{
+		{
+			name:  "allowed",
+			given: "anon-card-vote",
+			exp:   true,
+		},
+
+		{
+			name:  "not_allowed",
+			given: "brave-vpn-premium",
+		},
+
+		{
+			name: "empty",
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, ch.IsSKUAllowed(tc.given))
+		})
+	}
+}
+
+func TestCreateSpendChannelRequest_IsValid(t *testing.T) {
+	type testCase struct {
+		name  string
+		given model.CreateSpendChannelRequest
+		exp   bool
+	}
+
+	schema := json.RawMessage(`{"type": "record", "name": "Attention", "fields": []}`)
+
+	tests := []testCase{
+		{
+			name: "empty",
+		},
+
+		{
+			name: "name_too_long",
+			given: model.CreateSpendChannelRequest{
+				Name:          strings.Repeat("a", 65),
+				PayloadSchema: schema,
+				AllowedSKUs:   []string{"user-wallet-vote"},
+			},
+		},
+
+		{
+			name: "name_invalid_chars",
+			given: model.CreateSpendChannelRequest{
+				Name:          "Attention.v1",
+				PayloadSchema: schema,
+				AllowedSKUs:   []string{"user-wallet-vote"},
+			},
+		},
+
+		{
+			name: "no_schema",
+			given: model.CreateSpendChannelRequest{
+				Name:        "attention",
+				AllowedSKUs: []string{"user-wallet-vote"},
+			},
+		},
+
+		{
+			name: "no_skus",
+			given: model.CreateSpendChannelRequest{
+				Name:          "attention",
+				PayloadSchema: schema,
+			},
+		},
+
+		{
+			name: "valid",
+			given: model.CreateSpendChannelRequest{
+				Name:          "attention-v1",
+				PayloadSchema: schema,
+				AllowedSKUs:   []string{"user-wallet-vote"},
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.IsValid())
+		})
+	}
+}
+
+func TestTLV1Migration_AcceptsV1(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	type testCase struct {
+		name  string
+		given *model.TLV1Migration
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "no_window",
+			given: &model.TLV1Migration{},
+			exp:   true,
+		},
+
+		{
+			name:  "within_window",
+			given: &model.TLV1Migration{V1AcceptedUntil: ptrTo(now.Add(time.Hour))},
+			exp:   true,
+		},
+
+		{
+			name:  "window_ended",
+			given: &model.TLV1Migration{V1AcceptedUntil: ptrTo(now)},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.AcceptsV1(now))
+		})
+	}
 }
@@ -43,26 +43,23 @@ func decodeAndUnmarshalSku(sku string) (*macaroon.Macaroon, error) {
 func (s *Service) CreateOrderItemFromMacaroon(ctx context.Context, sku string, quantity int) (*OrderItem, []string, *model.IssuerConfig, error) {
 	sublogger := logging.Logger(ctx, "CreateOrderItemFromMacaroon")
 
-	// validation prior to decoding/unmarshalling
-	valid, err := validateHardcodedSku(ctx, sku)
+	mac, err := decodeAndUnmarshalSku(sku)
+	if err != nil {
+		sublogger.Error().Err(err).Msg("failed to decode sku")
+		return nil, nil, nil, model.ErrInvalidSKU
+	}
+
+	valid, err := s.isValidSKU(ctx, sku, mac)
 	if err != nil {
 		sublogger.Error().Err(err).Msg("failed to validate sku")
 		return nil, nil, nil, fmt.Errorf("failed to validate sku: %w", err)
 	}
 
-	// perform validation
 	if !valid {
-		sublogger.Error().Err(err).Msg("invalid sku")
+		sublogger.Error().Msg("invalid sku")
 		return nil, nil, nil, model.ErrInvalidSKU
 	}
 
-	// read the macaroon, its valid
-	mac, err := decodeAndUnmarshalSku(sku)
-	if err != nil {
-		sublogger.Error().Err(err).Msg("failed to decode sku")
-		return nil, nil, nil, fmt.Errorf("failed to create order item from macaroon: %w", err)
-	}
-
 	caveats := mac.Caveats()
 	var allowedPaymentMethods []string
 	orderItem := OrderItem{}
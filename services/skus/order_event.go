@@ -0,0 +1,123 @@
+package skus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linkedin/goavro"
+	uuid "github.com/satori/go.uuid"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	orderEventBatchSize = 50
+
+	expiredOrdersBatchSize = 100
+
+	// expiredOrdersLookback limits how far back orders are checked for expiry.
+	//
+	// Without it, the first run would publish an event for every order which has ever expired.
+	expiredOrdersLookback = 7 * 24 * time.Hour
+)
+
+// RunSendOrderEventsJob publishes a batch of recorded order events to Kafka.
+//
+// Events are marked as sent only after they have been written, so an event might be published more than once.
+// Consumers should deduplicate by event id.
+func (s *Service) RunSendOrderEventsJob(ctx context.Context) (bool, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	events, err := s.orderEvRepo.GetUnsentForUpdate(ctx, tx, orderEventBatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	if len(events) == 0 {
+		return false, nil
+	}
+
+	msgs, err := newOrderEventMessages(s.codecs["orderEvent"], kafkaOrderEventsTopic, events)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.orderEvWriter.WriteMessages(ctx, msgs...); err != nil {
+		return false, fmt.Errorf("error writing order events: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(events))
+	for i := range events {
+		ids = append(ids, events[i].ID)
+	}
+
+	if err := s.orderEvRepo.MarkSent(ctx, tx, ids, time.Now()); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RunRecordExpiredOrdersJob records an expired event for paid orders which have recently passed their expiry time.
+func (s *Service) RunRecordExpiredOrdersJob(ctx context.Context) (bool, error) {
+	now := time.Now()
+
+	n, err := s.orderEvRepo.InsertExpired(ctx, s.Datastore.RawDB(), now.Add(-expiredOrdersLookback), now, expiredOrdersBatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// newOrderEventMessages encodes events into Kafka messages keyed by order id.
+//
+// Keying by order id preserves the order of events for the same order within a partition.
+func newOrderEventMessages(codec *goavro.Codec, topic string, events []model.OrderEvent) ([]kafka.Message, error) {
+	if codec == nil {
+		return nil, model.Error("order events: codec not found")
+	}
+
+	result := make([]kafka.Message, 0, len(events))
+
+	for i := range events {
+		textual, err := json.Marshal(OrderEventMessage{
+			ID:         events[i].ID.String(),
+			Type:       events[i].Type,
+			OrderID:    events[i].OrderID.String(),
+			OccurredAt: events[i].OccurredAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		native, _, err := codec.NativeFromTextual(textual)
+		if err != nil {
+			return nil, fmt.Errorf("error converting native from textual: %w", err)
+		}
+
+		binary, err := codec.BinaryFromNative(nil, native)
+		if err != nil {
+			return nil, fmt.Errorf("error converting binary from native: %w", err)
+		}
+
+		result = append(result, kafka.Message{
+			Topic: topic,
+			Key:   events[i].OrderID.Bytes(),
+			Value: binary,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package skus
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+func TestNewOrderEventMessages(t *testing.T) {
+	codec, err := goavro.NewCodec(orderEventSchema)
+	must.Equal(t, nil, err)
+
+	t.Run("no_codec", func(t *testing.T) {
+		_, err := newOrderEventMessages(nil, "topic", []model.OrderEvent{{}})
+		should.Equal(t, model.Error("order events: codec not found"), err)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		events := []model.OrderEvent{
+			{
+				ID:         uuid.Must(uuid.FromString("f100ded0-0000-4000-a000-000000000000")),
+				OrderID:    uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				Type:       model.OrderEventRenewed,
+				OccurredAt: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			{
+				ID:         uuid.Must(uuid.FromString("f100ded0-0000-4000-a000-000000000001")),
+				OrderID:    uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000001")),
+				Type:       model.OrderEventCanceled,
+				OccurredAt: time.Date(2024, time.January, 2, 0, 0, 1, 0, time.UTC),
+			},
+		}
+
+		actual, err := newOrderEventMessages(codec, "topic", events)
+		must.Equal(t, nil, err)
+
+		must.Equal(t, 2, len(actual))
+
+		should.Equal(t, "topic", actual[0].Topic)
+		should.Equal(t, events[0].OrderID.Bytes(), actual[0].Key)
+
+		native, _, err := codec.NativeFromBinary(actual[0].Value)
+		must.Equal(t, nil, err)
+
+		textual, err := codec.TextualFromNative(nil, native)
+		must.Equal(t, nil, err)
+
+		msg := &OrderEventMessage{}
+		must.Equal(t, nil, json.Unmarshal(textual, msg))
+
+		exp := &OrderEventMessage{
+			ID:         "f100ded0-0000-4000-a000-000000000000",
+			Type:       "order.renewed",
+			OrderID:    "facade00-0000-4000-a000-000000000000",
+			OccurredAt: "2024-01-01T00:00:01Z",
+		}
+
+		should.Equal(t, exp, msg)
+	})
+}
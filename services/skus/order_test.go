@@ -89,7 +89,7 @@ func (suite *OrderTestSuite) TestCreateOrderItemFromMacaroon() {
 	suite.Require().NoError(err)
 
 	// create key in db for our brave.com location
-	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]))
+	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]), nil)
 	suite.Require().NoError(err)
 
 	c := macarooncmd.Caveats{
@@ -144,7 +144,7 @@ func (suite *OrderTestSuite) TestCreateOrderItemFromMacaroon_WithBufferAndOverla
 	suite.Require().NoError(err)
 
 	// create key in db for our brave.com location
-	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]))
+	_, err = suite.service.Datastore.CreateKey("brave.com", "brave.com", hex.EncodeToString(cipher), hex.EncodeToString(nonce[:]), nil)
 	suite.Require().NoError(err)
 
 	expectedIC := &model.IssuerConfig{
@@ -0,0 +1,248 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	errPaymentCheckoutUnsupported = model.Error("payment processor: checkout not supported")
+	errPaymentSubUnsupported      = model.Error("payment processor: subscription lookup not supported")
+	errPaymentNtfSkip             = model.Error("payment processor: skip notification")
+	errPaymentNtfDisabled         = model.Error("payment processor: notifications disabled")
+	errPaymentNtfInvalid          = model.Error("payment processor: invalid notification")
+)
+
+// paymentNtfError is a notification error to which the processor's webhook responds with a particular status code.
+//
+// Processors use it to keep the responses which their webhooks have always sent.
+type paymentNtfError struct {
+	err  error
+	code int
+}
+
+func newPaymentNtfError(err error, code int) error {
+	return &paymentNtfError{err: err, code: code}
+}
+
+func (x *paymentNtfError) Error() string {
+	return x.err.Error()
+}
+
+func (x *paymentNtfError) Unwrap() error {
+	return x.err
+}
+
+// paymentNtfErrCode returns the status code carried by err, or code if there is none.
+func paymentNtfErrCode(err error, code int) int {
+	if nerr := new(paymentNtfError); errors.As(err, &nerr) {
+		return nerr.code
+	}
+
+	return code
+}
+
+// PaymentProcessor is a vendor through which Premium orders are paid.
+//
+// A processor creates checkout sessions, authenticates and parses its notifications,
+// and decides how they affect orders.
+// The decisions are applied through orderStateMachine, so processors don't change orders directly.
+type PaymentProcessor interface {
+	// Name identifies the processor in allowed payment methods, webhook paths and the webhook inbox.
+	Name() string
+
+	// CreateCheckoutSession creates a session in which the user pays for ord.
+	//
+	// It returns errPaymentCheckoutUnsupported if the processor does not sell through checkouts.
+	CreateCheckoutSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, coupon *model.Coupon) (*CheckoutSession, error)
+
+	// AuthenticateNotification checks that the request has been sent by the processor.
+	//
+	// It returns the payload to be stored in the webhook inbox.
+	// It returns errPaymentNtfDisabled if notifications should be acknowledged, but ignored.
+	// Failures are responded to with http.StatusUnauthorized, unless the error is a paymentNtfError.
+	AuthenticateNotification(ctx context.Context, r *http.Request, body []byte) ([]byte, error)
+
+	// ParseNotification parses a payload returned by AuthenticateNotification.
+	//
+	// It returns errPaymentNtfSkip for notifications the processor is not interested in.
+	// Failures are responded to with http.StatusBadRequest, unless the error is a paymentNtfError.
+	ParseNotification(payload []byte) (PaymentNotification, error)
+
+	// ProcessNotification decides how ntf affects its order, and applies the decision via sm.
+	ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntf PaymentNotification) error
+
+	// Subscription looks up the processor's subscription.
+	//
+	// It returns errPaymentSubUnsupported if subscriptions can't be looked up by id alone.
+	Subscription(ctx context.Context, subID string) (*PaymentSubscription, error)
+}
+
+// paymentNtfResolver is implemented by processors which need to call the vendor before a notification is processed.
+//
+// ResolveNotification is called before the transaction in which ntf is processed is opened,
+// so that rows are not held locked while the vendor responds.
+type paymentNtfResolver interface {
+	ResolveNotification(ctx context.Context, ntf PaymentNotification) error
+}
+
+// PaymentNotification is a notification parsed by a PaymentProcessor.
+type PaymentNotification interface {
+	shouldProcess() bool
+	ntfType() string
+	effect() string
+
+	// eventID returns the vendor's id of the notification, or an empty string if the vendor does not assign one.
+	eventID() string
+
+	// orderKey identifies the order the notification is about, or is empty if it can't be told before processing.
+	//
+	// Orders are only looked up during processing, so the key is the vendor's subscription or purchase.
+	orderKey() string
+}
+
+// CheckoutSession is a session created by a PaymentProcessor for paying an order.
+type CheckoutSession struct {
+	ID string
+
+	// MetadataKey is the order metadata key under which ID is recorded.
+	MetadataKey string
+
+	// URL is where the user completes the session, for processors which don't redirect by ID.
+	URL string
+
+	// URLMetadataKey is the order metadata key under which URL is recorded, if set.
+	URLMetadataKey string
+}
+
+// PaymentSubscription is a subscription as seen by a PaymentProcessor.
+type PaymentSubscription struct {
+	ID         string
+	ExpiresAt  time.Time
+	LastPaidAt time.Time
+}
+
+// orderStateMachine is the set of transitions which payment processors can apply to orders.
+//
+// Service implements it.
+type orderStateMachine interface {
+	getOrderTx(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
+	getOrderByExternalIDTx(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error)
+	getOrderFullTx(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
+	getOrderItemsTx(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]model.OrderItem, error)
+	setOrderMetadataTx(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error
+	renewOrderWithExpPaidTimeTx(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, expt, paidt time.Time) error
+	cancelOrderTx(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	refundOrderTx(ctx context.Context, dbi sqlx.ExtContext, req model.TransactionNew, full bool, now time.Time) error
+	changeOrderPlanTx(ctx context.Context, dbi sqlx.ExtContext, ord *model.Order, skuVnt string, expt, now time.Time) error
+	recordPayFailureTx(ctx context.Context, dbi sqlx.ExtContext, ord *model.Order, vendor string, now time.Time) error
+	scheduleStripeSubCancelTx(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error
+	incrementNumPaymentFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	resetNumPaymentFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+// paymentProcessors holds processors in the order of preference for checkout.
+type paymentProcessors []PaymentProcessor
+
+// get returns the processor with the given name.
+func (x paymentProcessors) get(name string) (PaymentProcessor, bool) {
+	for i := range x {
+		if x[i].Name() == name {
+			return x[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// set replaces the processor of the same name with proc, or adds proc if there is none.
+func (x *paymentProcessors) set(proc PaymentProcessor) {
+	for i := range *x {
+		if (*x)[i].Name() == proc.Name() {
+			(*x)[i] = proc
+			return
+		}
+	}
+
+	*x = append(*x, proc)
+}
+
+// createCheckoutSession creates a session with the first processor which is allowed for ord and supports checkouts.
+//
+// It returns nil if there is no such processor.
+func (s *Service) createCheckoutSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, coupon *model.Coupon) (*CheckoutSession, error) {
+	allowed := model.Slice[string](ord.AllowedPaymentMethods)
+
+	for _, proc := range s.payProcs {
+		if !allowed.Contains(proc.Name()) {
+			continue
+		}
+
+		result, err := proc.CreateCheckoutSession(ctx, req, ord, coupon)
+		if err != nil {
+			if errors.Is(err, errPaymentCheckoutUnsupported) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	return nil, nil
+}
+
+// processPaymentNotification applies ntf in a transaction, if it's worth processing.
+//
+// Processors which call the vendor do so before the transaction is opened.
+func (s *Service) processPaymentNotification(ctx context.Context, proc PaymentProcessor, ntf PaymentNotification) error {
+	if !ntf.shouldProcess() {
+		return nil
+	}
+
+	if rproc, ok := proc.(paymentNtfResolver); ok {
+		if err := rproc.ResolveNotification(ctx, ntf); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := proc.ProcessNotification(ctx, tx, s, ntf); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Service) getOrderTx(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+	return s.orderRepo.Get(ctx, dbi, id)
+}
+
+func (s *Service) getOrderByExternalIDTx(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+	return s.orderRepo.GetByExternalID(ctx, dbi, extID)
+}
+
+func (s *Service) getOrderItemsTx(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]model.OrderItem, error) {
+	return s.orderItemRepo.FindByOrderID(ctx, dbi, id)
+}
+
+func (s *Service) setOrderMetadataTx(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+	return s.orderRepo.AppendMetadata(ctx, dbi, id, key, val)
+}
+
+func (s *Service) incrementNumPaymentFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	return s.orderRepo.IncrementNumPayFailed(ctx, dbi, id)
+}
@@ -0,0 +1,669 @@
+package skus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestPaymentProcessors_set(t *testing.T) {
+	procs := paymentProcessors{&fakePaymentProcessor{name: "fake_a"}}
+
+	procs.set(&fakePaymentProcessor{name: "fake_b"})
+	must.Len(t, procs, 2)
+
+	procs.set(&fakePaymentProcessor{name: "fake_a", sessID: "replaced"})
+	must.Len(t, procs, 2)
+
+	actual, ok := procs.get("fake_a")
+	must.True(t, ok)
+	should.Equal(t, "replaced", actual.(*fakePaymentProcessor).sessID)
+
+	_, ok = procs.get("fake_c")
+	should.False(t, ok)
+}
+
+func TestService_createCheckoutSession(t *testing.T) {
+	type tcGiven struct {
+		procs   paymentProcessors
+		allowed []string
+	}
+
+	type tcExpected struct {
+		val *CheckoutSession
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "none_allowed",
+			given: tcGiven{
+				procs:   paymentProcessors{&fakePaymentProcessor{name: "fake_a", sessID: "sess_a"}},
+				allowed: []string{"fake_b"},
+			},
+		},
+
+		{
+			name: "first_allowed",
+			given: tcGiven{
+				procs: paymentProcessors{
+					&fakePaymentProcessor{name: "fake_a", sessID: "sess_a"},
+					&fakePaymentProcessor{name: "fake_b", sessID: "sess_b"},
+				},
+				allowed: []string{"fake_b", "fake_a"},
+			},
+			exp: tcExpected{val: &CheckoutSession{ID: "sess_a", MetadataKey: "fake_aCheckoutSessionId"}},
+		},
+
+		{
+			name: "unsupported_skipped",
+			given: tcGiven{
+				procs: paymentProcessors{
+					&fakePaymentProcessor{name: "fake_a", sessErr: errPaymentCheckoutUnsupported},
+					&fakePaymentProcessor{name: "fake_b", sessID: "sess_b"},
+				},
+				allowed: []string{"fake_a", "fake_b"},
+			},
+			exp: tcExpected{val: &CheckoutSession{ID: "sess_b", MetadataKey: "fake_bCheckoutSessionId"}},
+		},
+
+		{
+			name: "error",
+			given: tcGiven{
+				procs: paymentProcessors{
+					&fakePaymentProcessor{name: "fake_a", sessErr: model.Error("something went wrong")},
+					&fakePaymentProcessor{name: "fake_b", sessID: "sess_b"},
+				},
+				allowed: []string{"fake_a", "fake_b"},
+			},
+			exp: tcExpected{err: model.Error("something went wrong")},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{payProcs: tc.given.procs}
+
+			ord := &model.Order{ID: uuid.NewV4(), AllowedPaymentMethods: tc.given.allowed}
+
+			actual, err := svc.createCheckoutSession(context.Background(), &model.CreateOrderRequestNew{}, ord, nil)
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestPaymentNtfErrCode(t *testing.T) {
+	type testCase struct {
+		name  string
+		given error
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name:  "plain",
+			given: model.Error("something went wrong"),
+			exp:   http.StatusUnauthorized,
+		},
+
+		{
+			name:  "with_code",
+			given: newPaymentNtfError(model.Error("something went wrong"), http.StatusOK),
+			exp:   http.StatusOK,
+		},
+
+		{
+			name:  "wrapped",
+			given: fmt.Errorf("failed: %w", newPaymentNtfError(model.Error("something went wrong"), http.StatusBadRequest)),
+			exp:   http.StatusBadRequest,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, paymentNtfErrCode(tc.given, http.StatusUnauthorized))
+		})
+	}
+}
+
+func TestPaymentWebhookMetricName(t *testing.T) {
+	type testCase struct {
+		given string
+		exp   string
+	}
+
+	tests := []testCase{
+		{given: "stripe", exp: "HandleStripeWebhook"},
+		{given: "radom", exp: "HandleRadomWebhook"},
+		{given: "android", exp: "HandleAndroidWebhook"},
+		{given: "ios", exp: "HandleIOSWebhook"},
+		{given: "paypal", exp: "HandlePayPalWebhook"},
+		{given: "fake", exp: "HandlePaymentWebhook_fake"},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.given, func(t *testing.T) {
+			should.Equal(t, tc.exp, paymentWebhookMetricName(tc.given))
+		})
+	}
+}
+
+func TestHandlePaymentWebhook(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *fakePaymentProcessor
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name:  "skipped",
+			given: &fakePaymentProcessor{name: "fake"},
+			exp:   http.StatusOK,
+		},
+
+		{
+			name:  "disabled",
+			given: &fakePaymentProcessor{name: "fake", authErr: errPaymentNtfDisabled},
+			exp:   http.StatusOK,
+		},
+
+		{
+			name:  "auth_error",
+			given: &fakePaymentProcessor{name: "fake", authErr: model.Error("something went wrong")},
+			exp:   http.StatusUnauthorized,
+		},
+
+		{
+			name:  "auth_error_acknowledged",
+			given: &fakePaymentProcessor{name: "fake", authErr: newPaymentNtfError(model.Error("something went wrong"), http.StatusOK)},
+			exp:   http.StatusOK,
+		},
+
+		{
+			name:  "auth_error_with_code",
+			given: &fakePaymentProcessor{name: "fake", authErr: newPaymentNtfError(model.Error("something went wrong"), http.StatusBadRequest)},
+			exp:   http.StatusBadRequest,
+		},
+
+		{
+			name:  "parse_error",
+			given: &fakePaymentProcessor{name: "fake", parseErr: model.Error("something went wrong")},
+			exp:   http.StatusBadRequest,
+		},
+
+		{
+			name:  "parse_skip",
+			given: &fakePaymentProcessor{name: "fake", parseErr: errPaymentNtfSkip},
+			exp:   http.StatusOK,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/fake", strings.NewReader("renew"))
+			rw := httptest.NewRecorder()
+
+			h := handlePaymentWebhook(&Service{}, tc.given)
+
+			aerr := h(rw, req)
+			if aerr == nil {
+				should.Equal(t, tc.exp, rw.Code)
+				return
+			}
+
+			should.Equal(t, tc.exp, aerr.Code)
+		})
+	}
+}
+
+func TestHandlePaymentWebhook_Store(t *testing.T) {
+	type tcGiven struct {
+		inbox *repository.MockWebhookInbox
+	}
+
+	type tcExpected struct {
+		req  model.WebhookInboxEntryNew
+		code int
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error",
+			given: tcGiven{
+				inbox: &repository.MockWebhookInbox{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error) {
+						return nil, model.Error("something went wrong")
+					},
+				},
+			},
+			exp: tcExpected{code: http.StatusInternalServerError},
+		},
+
+		{
+			name: "redelivered",
+			given: tcGiven{
+				inbox: &repository.MockWebhookInbox{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error) {
+						return nil, model.ErrWebhookInboxEntryExists
+					},
+				},
+			},
+			exp: tcExpected{code: http.StatusOK},
+		},
+
+		{
+			name:  "stored",
+			given: tcGiven{inbox: &repository.MockWebhookInbox{}},
+			exp: tcExpected{
+				req: model.WebhookInboxEntryNew{
+					Vendor:   "fake",
+					EventID:  "evt_01",
+					OrderKey: "sub_01",
+					Payload:  "renew",
+				},
+				code: http.StatusOK,
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var actualReq model.WebhookInboxEntryNew
+
+			inbox := &repository.MockWebhookInbox{
+				FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error) {
+					actualReq = req
+
+					if tc.given.inbox.FnInsert != nil {
+						return tc.given.inbox.FnInsert(ctx, dbi, req)
+					}
+
+					return &model.WebhookInboxEntry{ID: uuid.NewV4(), Vendor: req.Vendor}, nil
+				},
+			}
+
+			svc := &Service{
+				Datastore:        &Postgres{Postgres: datastore.Postgres{DB: &sqlx.DB{}}},
+				webhookInboxRepo: inbox,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/fake", strings.NewReader("renew"))
+			rw := httptest.NewRecorder()
+
+			h := handlePaymentWebhook(svc, &fakePaymentProcessor{name: "fake", process: true})
+
+			aerr := h(rw, req)
+			if aerr != nil {
+				should.Equal(t, tc.exp.code, aerr.Code)
+				return
+			}
+
+			should.Equal(t, tc.exp.code, rw.Code)
+
+			if tc.exp.req.Vendor != "" {
+				should.Equal(t, tc.exp.req, actualReq)
+			}
+		})
+	}
+}
+
+func TestService_processPaymentNotification(t *testing.T) {
+	proc := &fakePaymentProcessor{name: "fake_a"}
+
+	// Notifications not worth processing don't reach the processor, and don't need a transaction.
+	svc := &Service{}
+
+	actual := svc.processPaymentNotification(context.Background(), proc, &fakePaymentNotification{action: "renew"})
+	must.Equal(t, nil, actual)
+
+	should.Equal(t, 0, proc.numProcessed)
+}
+
+func TestService_processPaymentNotification_VendorCalls(t *testing.T) {
+	type tcGiven struct {
+		resolveErr error
+		commit     bool
+	}
+
+	type tcExpected struct {
+		calls []string
+		err   error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "resolve_error",
+			given: tcGiven{resolveErr: model.Error("something_went_wrong")},
+			exp: tcExpected{
+				calls: []string{"resolve"},
+				err:   model.Error("something_went_wrong"),
+			},
+		},
+
+		{
+			name:  "success",
+			given: tcGiven{commit: true},
+			exp:   tcExpected{calls: []string{"resolve", "process"}},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			must.NoError(t, err)
+
+			defer func() { _ = db.Close() }()
+
+			if tc.given.commit {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			}
+
+			svc := &Service{
+				Datastore: &Postgres{Postgres: datastore.Postgres{DB: sqlx.NewDb(db, "postgres")}},
+			}
+
+			proc := &fakeVendorPaymentProcessor{
+				fakePaymentProcessor: fakePaymentProcessor{name: "fake_a"},
+				resolveErr:           tc.given.resolveErr,
+			}
+
+			actual := svc.processPaymentNotification(context.Background(), proc, &fakePaymentNotification{action: "skip", process: true})
+			must.Equal(t, tc.exp.err, actual)
+
+			should.Equal(t, tc.exp.calls, proc.calls)
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFakePaymentProcessor_ProcessNotification(t *testing.T) {
+	type tcGiven struct {
+		ord *model.Order
+		ntf *fakePaymentNotification
+	}
+
+	type tcExpected struct {
+		status    string
+		expiresAt time.Time
+		numPaid   int
+		err       error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	oid := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+	expt := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name: "renew",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPending},
+				ntf: &fakePaymentNotification{action: "renew", expt: expt},
+			},
+			exp: tcExpected{status: model.OrderStatusPaid, expiresAt: expt, numPaid: 1},
+		},
+
+		{
+			name: "cancel",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPaid},
+				ntf: &fakePaymentNotification{action: "cancel"},
+			},
+			exp: tcExpected{status: model.OrderStatusCanceled},
+		},
+
+		{
+			name: "pay_failure",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPaid, ExpiresAt: ptrTo(time.Now().Add(-time.Hour))},
+				ntf: &fakePaymentNotification{action: "pay_failure"},
+			},
+			exp: tcExpected{status: model.OrderStatusPastDue},
+		},
+
+		{
+			name: "order_not_found",
+			given: tcGiven{
+				ntf: &fakePaymentNotification{action: "renew", expt: expt},
+			},
+			exp: tcExpected{err: model.ErrOrderNotFound},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				status    string
+				expiresAt time.Time
+				numPaid   int
+			)
+
+			svc := &Service{
+				orderRepo: &repository.MockOrder{
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						if tc.given.ord == nil {
+							return nil, model.ErrOrderNotFound
+						}
+
+						return tc.given.ord, nil
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, val string) error {
+						should.Equal(t, oid, id)
+
+						status = val
+
+						return nil
+					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						expiresAt = when
+
+						return nil
+					},
+				},
+
+				payHistRepo: &repository.MockOrderPayHistory{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						numPaid++
+
+						return nil
+					},
+				},
+
+				orderEvRepo:  &repository.MockOrderEvent{},
+				orderDunRepo: &repository.MockOrderDunning{},
+				couponRepo:   &repository.MockCoupon{},
+				dunningCfg:   &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
+			}
+
+			proc := &fakePaymentProcessor{name: "fake_a"}
+
+			actual := proc.ProcessNotification(context.Background(), nil, svc, tc.given.ntf)
+			must.Equal(t, tc.exp.err, actual)
+
+			should.Equal(t, tc.exp.status, status)
+			should.Equal(t, tc.exp.numPaid, numPaid)
+
+			if !tc.exp.expiresAt.IsZero() {
+				should.Equal(t, tc.exp.expiresAt, expiresAt)
+			}
+		})
+	}
+}
+
+type fakePaymentNotification struct {
+	action  string
+	expt    time.Time
+	process bool
+	evtID   string
+	ordKey  string
+}
+
+func (x *fakePaymentNotification) shouldProcess() bool {
+	return x.process
+}
+
+func (x *fakePaymentNotification) ntfType() string {
+	return "fake"
+}
+
+func (x *fakePaymentNotification) effect() string {
+	return x.action
+}
+
+func (x *fakePaymentNotification) eventID() string {
+	return x.evtID
+}
+
+func (x *fakePaymentNotification) orderKey() string {
+	return x.ordKey
+}
+
+type fakePaymentProcessor struct {
+	name     string
+	sessID   string
+	sessErr  error
+	authErr  error
+	parseErr error
+	process  bool
+
+	numProcessed int
+}
+
+func (p *fakePaymentProcessor) Name() string {
+	return p.name
+}
+
+func (p *fakePaymentProcessor) CreateCheckoutSession(_ context.Context, _ *model.CreateOrderRequestNew, _ *model.Order, _ *model.Coupon) (*CheckoutSession, error) {
+	if p.sessErr != nil {
+		return nil, p.sessErr
+	}
+
+	return &CheckoutSession{ID: p.sessID, MetadataKey: p.name + "CheckoutSessionId"}, nil
+}
+
+func (p *fakePaymentProcessor) AuthenticateNotification(_ context.Context, _ *http.Request, body []byte) ([]byte, error) {
+	if p.authErr != nil {
+		return nil, p.authErr
+	}
+
+	return body, nil
+}
+
+func (p *fakePaymentProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	if p.parseErr != nil {
+		return nil, p.parseErr
+	}
+
+	return &fakePaymentNotification{action: string(payload), process: p.process, evtID: "evt_01", ordKey: "sub_01"}, nil
+}
+
+func (p *fakePaymentProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	p.numProcessed++
+
+	ntf, ok := ntfx.(*fakePaymentNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	ord, err := sm.getOrderByExternalIDTx(ctx, dbi, "fake_sub")
+	if err != nil {
+		return err
+	}
+
+	switch ntf.action {
+	case "renew":
+		return sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, ntf.expt, time.Now())
+
+	case "cancel":
+		return sm.cancelOrderTx(ctx, dbi, ord.ID)
+
+	case "pay_failure":
+		return sm.recordPayFailureTx(ctx, dbi, ord, p.name, time.Now())
+
+	default:
+		return nil
+	}
+}
+
+func (p *fakePaymentProcessor) Subscription(_ context.Context, subID string) (*PaymentSubscription, error) {
+	return &PaymentSubscription{ID: subID}, nil
+}
+
+// fakeVendorPaymentProcessor calls the vendor before processing notifications.
+type fakeVendorPaymentProcessor struct {
+	fakePaymentProcessor
+
+	resolveErr error
+
+	calls []string
+}
+
+func (p *fakeVendorPaymentProcessor) ResolveNotification(_ context.Context, _ PaymentNotification) error {
+	p.calls = append(p.calls, "resolve")
+
+	return p.resolveErr
+}
+
+func (p *fakeVendorPaymentProcessor) ProcessNotification(_ context.Context, _ sqlx.ExtContext, _ orderStateMachine, _ PaymentNotification) error {
+	p.calls = append(p.calls, "process")
+
+	return nil
+}
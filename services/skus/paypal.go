@@ -0,0 +1,318 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/paypal"
+)
+
+const (
+	errPayPalUnknownAction  = model.Error("skus: unknown paypal action")
+	errPayPalInvalidItems   = model.Error("skus: paypal orders must have exactly one item")
+	errPayPalPlanIDNotFound = model.Error("skus: paypal plan id not found in metadata")
+	errPayPalNotConfigured  = model.Error("skus: paypal is not configured")
+)
+
+type payPalNotification struct {
+	*paypal.Notification
+}
+
+func (x *payPalNotification) shouldProcess() bool {
+	return x.ShouldProcess()
+}
+
+func (x *payPalNotification) ntfType() string {
+	return x.NtfType()
+}
+
+func (x *payPalNotification) effect() string {
+	return x.Effect()
+}
+
+func (x *payPalNotification) eventID() string {
+	return x.ID
+}
+
+func (x *payPalNotification) orderKey() string {
+	subID, err := x.SubID()
+	if err != nil {
+		return ""
+	}
+
+	return subID
+}
+
+type payPalProcessor struct {
+	cl        payPalClient
+	webhookID string
+}
+
+func newPayPalProcessor(cl payPalClient, webhookID string) *payPalProcessor {
+	return &payPalProcessor{cl: cl, webhookID: webhookID}
+}
+
+func (p *payPalProcessor) Name() string {
+	return model.PayPalPaymentMethod
+}
+
+// CreateCheckoutSession creates a subscription pending approval.
+//
+// The user approves the subscription at the approval link, which is recorded alongside the subscription id.
+func (p *payPalProcessor) CreateCheckoutSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, _ *model.Coupon) (*CheckoutSession, error) {
+	sub, err := p.createSubscription(ctx, req, ord)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	link, err := sub.ApprovalLink()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	result := &CheckoutSession{
+		ID:             sub.ID,
+		MetadataKey:    "paypalSubscriptionId",
+		URL:            link,
+		URLMetadataKey: "paypalApprovalLink",
+	}
+
+	return result, nil
+}
+
+func (p *payPalProcessor) createSubscription(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order) (*paypal.Subscription, error) {
+	if len(ord.Items) != 1 {
+		return nil, errPayPalInvalidItems
+	}
+
+	item := &ord.Items[0]
+
+	planID, ok := item.PayPalPlanID()
+	if !ok {
+		return nil, errPayPalPlanIDNotFound
+	}
+
+	oid := ord.ID.String()
+
+	surl, err := req.PayPalMetadata.SuccessURL(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	curl, err := req.PayPalMetadata.CancelURL(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	reqx := &paypal.CreateSubscriptionRequest{
+		PlanID:     planID,
+		CustomID:   oid,
+		Plan:       payPalPlanOverride(item),
+		Subscriber: &paypal.Subscriber{EmailAddress: req.Email},
+		ApplicationContext: &paypal.ApplicationContext{
+			ShippingPreference: "NO_SHIPPING",
+			UserAction:         "SUBSCRIBE_NOW",
+			ReturnURL:          surl,
+			CancelURL:          curl,
+		},
+	}
+
+	if item.Quantity > 1 {
+		reqx.Quantity = strconv.Itoa(item.Quantity)
+	}
+
+	return p.cl.CreateSubscription(ctx, reqx)
+}
+
+// payPalPlanOverride overrides the price of the plan for a discounted or locally priced item.
+//
+// PayPal has no coupons or regional prices.
+// Plans are expected to have a single regular billing cycle, which is the first one.
+func payPalPlanOverride(item *model.OrderItem) *paypal.PlanOverride {
+	_, hasLocalPrice := item.PriceID()
+	if (!item.IsDiscounted() && !hasLocalPrice) || item.Quantity <= 0 {
+		return nil
+	}
+
+	price := item.Subtotal.Div(decimal.NewFromInt(int64(item.Quantity)))
+
+	result := &paypal.PlanOverride{
+		BillingCycles: []paypal.BillingCycleOverride{
+			{
+				Sequence: 1,
+				PricingScheme: paypal.PricingScheme{
+					FixedPrice: paypal.Money{CurrencyCode: item.Currency, Value: price.StringFixed(paypal.CurrencyExponent(item.Currency))},
+				},
+			},
+		},
+	}
+
+	return result
+}
+
+// AuthenticateNotification asks PayPal to verify the signature of the notification.
+func (p *payPalProcessor) AuthenticateNotification(ctx context.Context, r *http.Request, body []byte) ([]byte, error) {
+	if err := p.cl.VerifyWebhookSignature(ctx, paypal.NewVerifyWebhookSignatureRequest(r.Header, p.webhookID, body)); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (p *payPalProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	ntf, err := paypal.ParseNotification(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payPalNotification{Notification: ntf}, nil
+}
+
+func (p *payPalProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*payPalNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	subID, err := ntf.SubID()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ntf.IsNewSub():
+		oid, err := ntf.OrderID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderTx(ctx, dbi, oid)
+		if err != nil {
+			return err
+		}
+
+		if err := sm.setOrderMetadataTx(ctx, dbi, ord.ID, "externalID", subID); err != nil {
+			return err
+		}
+
+		sub, err := p.Subscription(ctx, subID)
+		if err != nil {
+			return err
+		}
+
+		// A subscription which has not been paid for yet is renewed when the payment completes.
+		if sub.LastPaidAt.IsZero() {
+			return nil
+		}
+
+		return renewOrderPayPal(ctx, dbi, sm, ord, sub.ExpiresAt, sub.LastPaidAt)
+
+	case ntf.ShouldRenew():
+		ord, err := sm.getOrderByExternalIDTx(ctx, dbi, subID)
+		if err != nil {
+			return err
+		}
+
+		sub, err := p.Subscription(ctx, subID)
+		if err != nil {
+			return err
+		}
+
+		paidAt := sub.LastPaidAt
+		if paidAt.IsZero() {
+			paidAt = time.Now()
+		}
+
+		return renewOrderPayPal(ctx, dbi, sm, ord, sub.ExpiresAt, paidAt)
+
+	case ntf.ShouldCancel():
+		ord, err := sm.getOrderByExternalIDTx(ctx, dbi, subID)
+		if err != nil {
+			return err
+		}
+
+		return sm.cancelOrderTx(ctx, dbi, ord.ID)
+
+	case ntf.ShouldRecordPayFailure():
+		ord, err := sm.getOrderByExternalIDTx(ctx, dbi, subID)
+		if err != nil {
+			return err
+		}
+
+		if err := sm.incrementNumPaymentFailed(ctx, dbi, ord.ID); err != nil {
+			return err
+		}
+
+		return sm.recordPayFailureTx(ctx, dbi, ord, model.WebhookVendorPayPal, time.Now())
+
+	default:
+		return errPayPalUnknownAction
+	}
+}
+
+// CancelSubscription cancels the subscription, so that it is not billed again.
+//
+// A subscription which does not exist or is no longer active needs no cancelling.
+func (p *payPalProcessor) CancelSubscription(ctx context.Context, subID string) error {
+	if err := p.cl.CancelSubscription(ctx, subID, "Cancelled by the customer"); err != nil {
+		if errors.Is(err, paypal.ErrSubNotCancellable) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Subscription returns the subscription with the next billing time as its expiry.
+//
+// LastPaidAt is zero if the subscription has not been paid for yet.
+func (p *payPalProcessor) Subscription(ctx context.Context, subID string) (*PaymentSubscription, error) {
+	psub, err := p.cl.GetSubscription(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	nxtB, err := psub.NextBillingTime()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaymentSubscription{
+		ID:         subID,
+		ExpiresAt:  nxtB,
+		LastPaidAt: psub.LastPaid(),
+	}
+
+	return result, nil
+}
+
+func renewOrderPayPal(ctx context.Context, dbi sqlx.ExecerContext, sm orderStateMachine, ord *model.Order, expt, paidt time.Time) error {
+	// Add 1-day leeway in case next billing cycle's webhook gets delayed.
+	expt = expt.Add(24 * time.Hour)
+
+	if err := sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt); err != nil {
+		return err
+	}
+
+	// Reset numPaymentFailed.
+	if err := sm.resetNumPaymentFailed(ctx, dbi, ord.ID); err != nil {
+		return err
+	}
+
+	// Skip updating payment processor if it's already PayPal.
+	if ord.IsPayPal() {
+		return nil
+	}
+
+	return sm.setOrderMetadataTx(ctx, dbi, ord.ID, "paymentProcessor", model.PayPalPaymentMethod)
+}
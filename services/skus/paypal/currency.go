@@ -0,0 +1,32 @@
+package paypal
+
+// currencyExponents holds the number of decimal places of currencies which do not have two.
+//
+// PayPal accepts no decimals for HUF and TWD, although ISO 4217 gives them two.
+var currencyExponents = map[string]int32{
+	"BHD": 3,
+	"CLP": 0,
+	"HUF": 0,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"PYG": 0,
+	"TND": 3,
+	"TWD": 0,
+	"UGX": 0,
+	"VND": 0,
+	"XAF": 0,
+	"XOF": 0,
+}
+
+// CurrencyExponent returns the number of decimal places PayPal accepts in amounts of the currency.
+func CurrencyExponent(currency string) int32 {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+
+	return 2
+}
@@ -0,0 +1,31 @@
+package paypal
+
+import (
+	"testing"
+
+	should "github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyExponent(t *testing.T) {
+	type testCase struct {
+		given string
+		exp   int32
+	}
+
+	tests := []testCase{
+		{given: "USD", exp: 2},
+		{given: "EUR", exp: 2},
+		{given: "JPY", exp: 0},
+		{given: "HUF", exp: 0},
+		{given: "TWD", exp: 0},
+		{given: "KWD", exp: 3},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.given, func(t *testing.T) {
+			should.Equal(t, tc.exp, CurrencyExponent(tc.given))
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package paypal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MockServer emulates the parts of the PayPal API which Client uses.
+//
+// It keeps subscriptions in memory, and verifies webhook signatures made for its webhook id.
+type MockServer struct {
+	*httptest.Server
+
+	clientID  string
+	secret    string
+	webhookID string
+	token     string
+
+	mu        sync.Mutex
+	subs      map[string]*Subscription
+	numTokens int
+}
+
+func NewMockServer(clientID, secret, webhookID string) *MockServer {
+	result := &MockServer{
+		clientID:  clientID,
+		secret:    secret,
+		webhookID: webhookID,
+		token:     "A21AA_mock_token",
+		subs:      make(map[string]*Subscription),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/oauth2/token", result.handleToken)
+	mux.HandleFunc("/v1/billing/subscriptions", result.withAuth(result.handleCreateSub))
+	mux.HandleFunc("/v1/billing/subscriptions/", result.withAuth(result.handleSub))
+	mux.HandleFunc("/v1/notifications/verify-webhook-signature", result.withAuth(result.handleVerify))
+
+	result.Server = httptest.NewServer(mux)
+
+	return result
+}
+
+// SetSubscription stores sub, replacing a subscription with the same id.
+func (s *MockServer) SetSubscription(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subs[sub.ID] = sub
+}
+
+// NumTokens returns the number of access tokens issued.
+func (s *MockServer) NumTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.numTokens
+}
+
+func (s *MockServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if id, secret, ok := r.BasicAuth(); !ok || id != s.clientID || secret != s.secret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.numTokens++
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, &tokenResponse{AccessToken: s.token, ExpiresIn: 32400})
+}
+
+func (s *MockServer) handleCreateSub(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &CreateSubscriptionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.PlanID == "" || req.ApplicationContext == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := "I-MOCK" + strconv.Itoa(len(s.subs)+1)
+
+	sub := &Subscription{
+		ID:       id,
+		Status:   "APPROVAL_PENDING",
+		PlanID:   req.PlanID,
+		CustomID: req.CustomID,
+		Links: []Link{
+			{Href: s.URL + "/webapps/billing/subscriptions?ba_token=BA-" + id, Rel: "approve", Method: "GET"},
+			{Href: s.URL + "/v1/billing/subscriptions/" + id, Rel: "self", Method: "GET"},
+		},
+	}
+
+	s.subs[id] = sub
+
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+func (s *MockServer) handleSub(w http.ResponseWriter, r *http.Request) {
+	if id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/v1/billing/subscriptions/"), "/cancel"); ok {
+		s.handleCancelSub(w, r, id)
+		return
+	}
+
+	s.handleGetSub(w, r)
+}
+
+func (s *MockServer) handleCancelSub(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if sub.Status != "ACTIVE" && sub.Status != "SUSPENDED" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	sub.Status = "CANCELLED"
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *MockServer) handleGetSub(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/billing/subscriptions/")
+
+	s.mu.Lock()
+	sub, ok := s.subs[id]
+	s.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sub)
+}
+
+func (s *MockServer) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &VerifyWebhookSignatureRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status := "FAILURE"
+	if req.WebhookID == s.webhookID && req.TransmissionID != "" && req.TransmissionSig != "" && len(req.WebhookEvent) > 0 {
+		status = "SUCCESS"
+	}
+
+	writeJSON(w, http.StatusOK, &verifyWebhookSignatureResponse{VerificationStatus: status})
+}
+
+func (s *MockServer) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,152 @@
+package paypal
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	ErrUnsupportedEvent     = Error("paypal: unsupported event")
+	ErrBraveOrderIDNotFound = Error("paypal: brave order id not found")
+	ErrNoResource           = Error("paypal: no resource")
+)
+
+const (
+	EventSubActivated     = "BILLING.SUBSCRIPTION.ACTIVATED"
+	EventSubCancelled     = "BILLING.SUBSCRIPTION.CANCELLED"
+	EventSubExpired       = "BILLING.SUBSCRIPTION.EXPIRED"
+	EventSubSuspended     = "BILLING.SUBSCRIPTION.SUSPENDED"
+	EventSubPaymentFailed = "BILLING.SUBSCRIPTION.PAYMENT.FAILED"
+	EventSaleCompleted    = "PAYMENT.SALE.COMPLETED"
+
+	eventPrefixSub  = "BILLING.SUBSCRIPTION."
+	eventPrefixSale = "PAYMENT.SALE."
+)
+
+type Notification struct {
+	ID           string          `json:"id"`
+	EventType    string          `json:"event_type"`
+	ResourceType string          `json:"resource_type"`
+	Resource     json.RawMessage `json:"resource"`
+
+	sub  *Subscription
+	sale *Sale
+}
+
+// Sale is a payment made under a subscription.
+type Sale struct {
+	ID                 string    `json:"id"`
+	State              string    `json:"state"`
+	BillingAgreementID string    `json:"billing_agreement_id"`
+	CreateTime         time.Time `json:"create_time"`
+}
+
+func (n *Notification) OrderID() (uuid.UUID, error) {
+	if n.sub == nil {
+		return uuid.Nil, ErrUnsupportedEvent
+	}
+
+	if n.sub.CustomID == "" {
+		return uuid.Nil, ErrBraveOrderIDNotFound
+	}
+
+	return uuid.FromString(n.sub.CustomID)
+}
+
+func (n *Notification) SubID() (string, error) {
+	switch {
+	case n.sub != nil:
+		return n.sub.ID, nil
+
+	case n.sale != nil && n.sale.BillingAgreementID != "":
+		return n.sale.BillingAgreementID, nil
+
+	default:
+		return "", ErrUnsupportedEvent
+	}
+}
+
+func (n *Notification) IsNewSub() bool {
+	return n.sub != nil && n.EventType == EventSubActivated
+}
+
+// ShouldRenew reports whether the notification is about a completed payment for a subscription.
+//
+// Sales made outside of subscriptions have no billing agreement, and are not relevant.
+func (n *Notification) ShouldRenew() bool {
+	return n.sale != nil && n.sale.BillingAgreementID != "" && n.EventType == EventSaleCompleted
+}
+
+// ShouldCancel reports whether the notification is about a subscription which has ended.
+//
+// A cancelled or suspended subscription is not billed again, but the order stays paid until the end of the period
+// it has been paid for, and expires then.
+func (n *Notification) ShouldCancel() bool {
+	return n.sub != nil && n.EventType == EventSubExpired
+}
+
+func (n *Notification) ShouldRecordPayFailure() bool {
+	return n.sub != nil && n.EventType == EventSubPaymentFailed
+}
+
+func (n *Notification) ShouldProcess() bool {
+	return n.IsNewSub() || n.ShouldRenew() || n.ShouldCancel() || n.ShouldRecordPayFailure()
+}
+
+func (n *Notification) Effect() string {
+	switch {
+	case n.IsNewSub():
+		return "new"
+
+	case n.ShouldRenew():
+		return "renew"
+
+	case n.ShouldCancel():
+		return "cancel"
+
+	case n.ShouldRecordPayFailure():
+		return "pay_failure"
+
+	default:
+		return "skip"
+	}
+}
+
+func (n *Notification) NtfType() string {
+	return n.EventType
+}
+
+// ParseNotification parses the notification and its resource for the events of interest.
+func ParseNotification(b []byte) (*Notification, error) {
+	ntf := &Notification{}
+	if err := json.Unmarshal(b, ntf); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(ntf.EventType, eventPrefixSub):
+		if len(ntf.Resource) == 0 {
+			return nil, ErrNoResource
+		}
+
+		ntf.sub = &Subscription{}
+		if err := json.Unmarshal(ntf.Resource, ntf.sub); err != nil {
+			return nil, err
+		}
+
+	case strings.HasPrefix(ntf.EventType, eventPrefixSale):
+		if len(ntf.Resource) == 0 {
+			return nil, ErrNoResource
+		}
+
+		ntf.sale = &Sale{}
+		if err := json.Unmarshal(ntf.Resource, ntf.sale); err != nil {
+			return nil, err
+		}
+	}
+
+	return ntf, nil
+}
@@ -0,0 +1,188 @@
+package paypal
+
+import (
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+)
+
+func TestParseNotification(t *testing.T) {
+	type tcExpected struct {
+		effect  string
+		subID   string
+		subErr  error
+		orderID uuid.UUID
+		ordErr  error
+		err     error
+	}
+
+	type testCase struct {
+		name  string
+		given string
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "activated",
+			given: `{
+				"id": "WH-1",
+				"event_type": "BILLING.SUBSCRIPTION.ACTIVATED",
+				"resource_type": "subscription",
+				"resource": {
+					"id": "I-1",
+					"status": "ACTIVE",
+					"plan_id": "P-1",
+					"custom_id": "facade00-0000-4000-a000-000000000000"
+				}
+			}`,
+			exp: tcExpected{
+				effect:  "new",
+				subID:   "I-1",
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+			},
+		},
+
+		{
+			name: "activated_no_custom_id",
+			given: `{
+				"event_type": "BILLING.SUBSCRIPTION.ACTIVATED",
+				"resource": {"id": "I-1"}
+			}`,
+			exp: tcExpected{
+				effect: "new",
+				subID:  "I-1",
+				ordErr: ErrBraveOrderIDNotFound,
+			},
+		},
+
+		{
+			name: "sale_completed",
+			given: `{
+				"event_type": "PAYMENT.SALE.COMPLETED",
+				"resource_type": "sale",
+				"resource": {
+					"id": "S-1",
+					"state": "completed",
+					"billing_agreement_id": "I-1",
+					"create_time": "2024-01-01T10:00:00Z"
+				}
+			}`,
+			exp: tcExpected{
+				effect: "renew",
+				subID:  "I-1",
+				ordErr: ErrUnsupportedEvent,
+			},
+		},
+
+		{
+			name: "sale_completed_no_agreement",
+			given: `{
+				"event_type": "PAYMENT.SALE.COMPLETED",
+				"resource": {"id": "S-1", "state": "completed"}
+			}`,
+			exp: tcExpected{
+				effect: "skip",
+				subErr: ErrUnsupportedEvent,
+				ordErr: ErrUnsupportedEvent,
+			},
+		},
+
+		{
+			name: "cancelled",
+			given: `{
+				"event_type": "BILLING.SUBSCRIPTION.CANCELLED",
+				"resource": {"id": "I-1", "status": "CANCELLED"}
+			}`,
+			exp: tcExpected{
+				effect: "skip",
+				subID:  "I-1",
+				ordErr: ErrBraveOrderIDNotFound,
+			},
+		},
+
+		{
+			name: "expired",
+			given: `{
+				"event_type": "BILLING.SUBSCRIPTION.EXPIRED",
+				"resource": {"id": "I-1", "status": "EXPIRED"}
+			}`,
+			exp: tcExpected{
+				effect: "cancel",
+				subID:  "I-1",
+				ordErr: ErrBraveOrderIDNotFound,
+			},
+		},
+
+		{
+			name: "suspended",
+			given: `{
+				"event_type": "BILLING.SUBSCRIPTION.SUSPENDED",
+				"resource": {"id": "I-1", "status": "SUSPENDED"}
+			}`,
+			exp: tcExpected{
+				effect: "skip",
+				subID:  "I-1",
+				ordErr: ErrBraveOrderIDNotFound,
+			},
+		},
+
+		{
+			name: "payment_failed",
+			given: `{
+				"event_type": "BILLING.SUBSCRIPTION.PAYMENT.FAILED",
+				"resource": {"id": "I-1", "status": "ACTIVE"}
+			}`,
+			exp: tcExpected{
+				effect: "pay_failure",
+				subID:  "I-1",
+				ordErr: ErrBraveOrderIDNotFound,
+			},
+		},
+
+		{
+			name: "unsupported",
+			given: `{
+				"event_type": "CHECKOUT.ORDER.APPROVED",
+				"resource": {"id": "O-1"}
+			}`,
+			exp: tcExpected{
+				effect: "skip",
+				subErr: ErrUnsupportedEvent,
+				ordErr: ErrUnsupportedEvent,
+			},
+		},
+
+		{
+			name:  "no_resource",
+			given: `{"event_type": "BILLING.SUBSCRIPTION.CANCELLED"}`,
+			exp:   tcExpected{err: ErrNoResource},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParseNotification([]byte(tc.given))
+			must.Equal(t, tc.exp.err, err)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, tc.exp.effect, actual.Effect())
+			should.Equal(t, tc.exp.effect != "skip", actual.ShouldProcess())
+
+			subID, err := actual.SubID()
+			should.Equal(t, tc.exp.subErr, err)
+			should.Equal(t, tc.exp.subID, subID)
+
+			oid, err := actual.OrderID()
+			should.Equal(t, tc.exp.ordErr, err)
+			should.Equal(t, tc.exp.orderID, oid)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package paypal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brave-intl/bat-go/libs/clients"
+)
+
+const (
+	ErrSubNoNextBillingTime    = Error("paypal: subscription has no next billing time")
+	ErrSubNoApprovalLink       = Error("paypal: subscription has no approval link")
+	ErrWebhookSignatureInvalid = Error("paypal: webhook signature is invalid")
+	ErrSubNotCancellable       = Error("paypal: subscription not found or no longer active")
+)
+
+// tokenLeeway is how long before its expiry an access token is refreshed.
+const tokenLeeway = time.Minute
+
+type Client struct {
+	client   *clients.SimpleHTTPClient
+	clientID string
+	secret   string
+
+	mu       sync.Mutex
+	token    string
+	tokenExp time.Time
+}
+
+func New(srvURL, clientID, secret string) (*Client, error) {
+	cl, err := clients.New(srvURL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{client: cl, clientID: clientID, secret: secret}, nil
+}
+
+type Subscriber struct {
+	EmailAddress string `json:"email_address,omitempty"`
+}
+
+type ApplicationContext struct {
+	ShippingPreference string `json:"shipping_preference,omitempty"`
+	UserAction         string `json:"user_action,omitempty"`
+	ReturnURL          string `json:"return_url"`
+	CancelURL          string `json:"cancel_url"`
+}
+
+type Money struct {
+	CurrencyCode string `json:"currency_code"`
+	Value        string `json:"value"`
+}
+
+type PricingScheme struct {
+	FixedPrice Money `json:"fixed_price"`
+}
+
+type BillingCycleOverride struct {
+	Sequence      int           `json:"sequence"`
+	PricingScheme PricingScheme `json:"pricing_scheme"`
+}
+
+// PlanOverride overrides the properties of the plan for the subscription.
+type PlanOverride struct {
+	BillingCycles []BillingCycleOverride `json:"billing_cycles"`
+}
+
+type CreateSubscriptionRequest struct {
+	PlanID             string              `json:"plan_id"`
+	CustomID           string              `json:"custom_id"`
+	Quantity           string              `json:"quantity,omitempty"`
+	Plan               *PlanOverride       `json:"plan,omitempty"`
+	Subscriber         *Subscriber         `json:"subscriber,omitempty"`
+	ApplicationContext *ApplicationContext `json:"application_context"`
+}
+
+type Link struct {
+	Href   string `json:"href"`
+	Rel    string `json:"rel"`
+	Method string `json:"method"`
+}
+
+type LastPayment struct {
+	Time time.Time `json:"time"`
+}
+
+type BillingInfo struct {
+	NextBillingTime     *time.Time   `json:"next_billing_time,omitempty"`
+	LastPayment         *LastPayment `json:"last_payment,omitempty"`
+	FailedPaymentsCount int          `json:"failed_payments_count"`
+}
+
+type Subscription struct {
+	ID          string       `json:"id"`
+	Status      string       `json:"status"`
+	PlanID      string       `json:"plan_id"`
+	CustomID    string       `json:"custom_id"`
+	BillingInfo *BillingInfo `json:"billing_info,omitempty"`
+	Links       []Link       `json:"links,omitempty"`
+}
+
+// ApprovalLink returns the link at which the subscriber approves the subscription.
+func (s *Subscription) ApprovalLink() (string, error) {
+	for i := range s.Links {
+		if s.Links[i].Rel == "approve" {
+			return s.Links[i].Href, nil
+		}
+	}
+
+	return "", ErrSubNoApprovalLink
+}
+
+func (s *Subscription) NextBillingTime() (time.Time, error) {
+	if s.BillingInfo == nil || s.BillingInfo.NextBillingTime == nil {
+		return time.Time{}, ErrSubNoNextBillingTime
+	}
+
+	return s.BillingInfo.NextBillingTime.UTC(), nil
+}
+
+// LastPaid returns the time of the last payment.
+//
+// It returns the zero time if the subscription has not been paid for yet.
+func (s *Subscription) LastPaid() time.Time {
+	if s.BillingInfo == nil || s.BillingInfo.LastPayment == nil {
+		return time.Time{}
+	}
+
+	return s.BillingInfo.LastPayment.Time.UTC()
+}
+
+func (c *Client) CreateSubscription(ctx context.Context, sreq *CreateSubscriptionRequest) (*Subscription, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/billing/subscriptions", sreq)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Subscription{}
+	if _, err := c.client.Do(ctx, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *Client) GetSubscription(ctx context.Context, subID string) (*Subscription, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/billing/subscriptions/"+subID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Subscription{}
+	if _, err := c.client.Do(ctx, req, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+type cancelSubscriptionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelSubscription cancels the subscription, so that it is not billed again.
+//
+// It returns ErrSubNotCancellable if the subscription does not exist, or has already been cancelled or expired.
+func (c *Client) CancelSubscription(ctx context.Context, subID, reason string) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/billing/subscriptions/"+subID+"/cancel", &cancelSubscriptionRequest{Reason: reason})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(ctx, req, nil)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity) {
+			return ErrSubNotCancellable
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+type VerifyWebhookSignatureRequest struct {
+	AuthAlgo         string `json:"auth_algo"`
+	CertURL          string `json:"cert_url"`
+	TransmissionID   string `json:"transmission_id"`
+	TransmissionSig  string `json:"transmission_sig"`
+	TransmissionTime string `json:"transmission_time"`
+	WebhookID        string `json:"webhook_id"`
+
+	// WebhookEvent is the notification exactly as received.
+	WebhookEvent json.RawMessage `json:"webhook_event"`
+}
+
+// NewVerifyWebhookSignatureRequest creates a request for verifying the notification body received with hdr.
+func NewVerifyWebhookSignatureRequest(hdr http.Header, webhookID string, body []byte) *VerifyWebhookSignatureRequest {
+	result := &VerifyWebhookSignatureRequest{
+		AuthAlgo:         hdr.Get("Paypal-Auth-Algo"),
+		CertURL:          hdr.Get("Paypal-Cert-Url"),
+		TransmissionID:   hdr.Get("Paypal-Transmission-Id"),
+		TransmissionSig:  hdr.Get("Paypal-Transmission-Sig"),
+		TransmissionTime: hdr.Get("Paypal-Transmission-Time"),
+		WebhookID:        webhookID,
+		WebhookEvent:     body,
+	}
+
+	return result
+}
+
+type verifyWebhookSignatureResponse struct {
+	VerificationStatus string `json:"verification_status"`
+}
+
+// VerifyWebhookSignature asks PayPal whether the notification has been signed by PayPal for the webhook.
+func (c *Client) VerifyWebhookSignature(ctx context.Context, vreq *VerifyWebhookSignatureRequest) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/notifications/verify-webhook-signature", vreq)
+	if err != nil {
+		return err
+	}
+
+	var resp verifyWebhookSignatureResponse
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+
+	if resp.VerificationStatus != "SUCCESS" {
+		return ErrWebhookSignatureInvalid
+	}
+
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.client.NewRequest(ctx, method, path, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"` // in seconds
+}
+
+// accessToken returns a cached OAuth access token, requesting a new one when the cached one is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token != "" && now.Before(c.tokenExp) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	uri := c.client.BaseURL.ResolveReference(&url.URL{Path: "/v1/oauth2/token"})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.SetBasicAuth(c.clientID, c.secret)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp tokenResponse
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return "", err
+	}
+
+	c.token = resp.AccessToken
+	c.tokenExp = now.Add(time.Duration(resp.ExpiresIn)*time.Second - tokenLeeway)
+
+	return c.token, nil
+}
+
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
@@ -0,0 +1,194 @@
+package paypal
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+)
+
+func TestSubscription_NextBillingTime(t *testing.T) {
+	type tcExpected struct {
+		val time.Time
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given Subscription
+		exp   tcExpected
+	}
+
+	nxtB := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.FixedZone("PST", -8*3600))
+
+	tests := []testCase{
+		{
+			name: "no_billing_info",
+			exp:  tcExpected{err: ErrSubNoNextBillingTime},
+		},
+
+		{
+			name:  "no_next_billing_time",
+			given: Subscription{BillingInfo: &BillingInfo{}},
+			exp:   tcExpected{err: ErrSubNoNextBillingTime},
+		},
+
+		{
+			name:  "next_billing_time",
+			given: Subscription{BillingInfo: &BillingInfo{NextBillingTime: &nxtB}},
+			exp:   tcExpected{val: time.Date(2024, time.February, 1, 18, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.given.NextBillingTime()
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestSubscription_LastPaid(t *testing.T) {
+	type testCase struct {
+		name  string
+		given Subscription
+		exp   time.Time
+	}
+
+	paidAt := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name: "no_billing_info",
+		},
+
+		{
+			name:  "no_last_payment",
+			given: Subscription{BillingInfo: &BillingInfo{}},
+		},
+
+		{
+			name:  "last_payment",
+			given: Subscription{BillingInfo: &BillingInfo{LastPayment: &LastPayment{Time: paidAt}}},
+			exp:   paidAt,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.LastPaid())
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv := NewMockServer("client_id", "secret", "webhook_id")
+	defer srv.Close()
+
+	ctx := context.Background()
+
+	{
+		cl, err := New(srv.URL, "client_id", "wrong_secret")
+		must.Equal(t, nil, err)
+
+		_, err = cl.GetSubscription(ctx, "I-MOCK1")
+		should.Error(t, err)
+	}
+
+	cl, err := New(srv.URL, "client_id", "secret")
+	must.Equal(t, nil, err)
+
+	sub, err := cl.CreateSubscription(ctx, &CreateSubscriptionRequest{
+		PlanID:     "P-1",
+		CustomID:   "facade00-0000-4000-a000-000000000000",
+		Subscriber: &Subscriber{EmailAddress: "customer@example.com"},
+		ApplicationContext: &ApplicationContext{
+			UserAction: "SUBSCRIBE_NOW",
+			ReturnURL:  "https://example.com/success",
+			CancelURL:  "https://example.com/cancel",
+		},
+	})
+	must.Equal(t, nil, err)
+
+	should.Equal(t, "APPROVAL_PENDING", sub.Status)
+	should.Equal(t, "facade00-0000-4000-a000-000000000000", sub.CustomID)
+
+	link, err := sub.ApprovalLink()
+	must.Equal(t, nil, err)
+	should.Contains(t, link, "ba_token=BA-"+sub.ID)
+
+	{
+		actual, err := cl.GetSubscription(ctx, sub.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, sub.ID, actual.ID)
+		should.Equal(t, "P-1", actual.PlanID)
+	}
+
+	{
+		_, err := cl.GetSubscription(ctx, "I-MISSING")
+		should.Error(t, err)
+	}
+
+	{
+		should.ErrorIs(t, cl.CancelSubscription(ctx, sub.ID, "reason"), ErrSubNotCancellable)
+
+		srv.SetSubscription(&Subscription{ID: sub.ID, Status: "ACTIVE"})
+		should.Equal(t, nil, cl.CancelSubscription(ctx, sub.ID, "reason"))
+
+		actual, err := cl.GetSubscription(ctx, sub.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, "CANCELLED", actual.Status)
+
+		should.ErrorIs(t, cl.CancelSubscription(ctx, sub.ID, "reason"), ErrSubNotCancellable)
+		should.ErrorIs(t, cl.CancelSubscription(ctx, "I-MISSING", "reason"), ErrSubNotCancellable)
+	}
+
+	{
+		hdr := http.Header{}
+		hdr.Set("Paypal-Transmission-Id", "transmission_id")
+		hdr.Set("Paypal-Transmission-Sig", "signature")
+
+		body := []byte(`{"id":"WH-1","event_type":"BILLING.SUBSCRIPTION.ACTIVATED"}`)
+
+		should.Equal(t, nil, cl.VerifyWebhookSignature(ctx, NewVerifyWebhookSignatureRequest(hdr, "webhook_id", body)))
+		should.Equal(t, ErrWebhookSignatureInvalid, cl.VerifyWebhookSignature(ctx, NewVerifyWebhookSignatureRequest(hdr, "other_webhook_id", body)))
+		should.Equal(t, ErrWebhookSignatureInvalid, cl.VerifyWebhookSignature(ctx, NewVerifyWebhookSignatureRequest(http.Header{}, "webhook_id", body)))
+	}
+
+	// The access token is requested once, and reused until it expires.
+	should.Equal(t, 1, srv.NumTokens())
+}
+
+func TestNewVerifyWebhookSignatureRequest(t *testing.T) {
+	hdr := http.Header{}
+	hdr.Set("PAYPAL-AUTH-ALGO", "SHA256withRSA")
+	hdr.Set("PAYPAL-CERT-URL", "https://api.paypal.com/v1/notifications/certs/CERT-1")
+	hdr.Set("PAYPAL-TRANSMISSION-ID", "transmission_id")
+	hdr.Set("PAYPAL-TRANSMISSION-SIG", "signature")
+	hdr.Set("PAYPAL-TRANSMISSION-TIME", "2024-01-01T10:00:00Z")
+
+	body := []byte(`{"id":"WH-1"}`)
+
+	exp := &VerifyWebhookSignatureRequest{
+		AuthAlgo:         "SHA256withRSA",
+		CertURL:          "https://api.paypal.com/v1/notifications/certs/CERT-1",
+		TransmissionID:   "transmission_id",
+		TransmissionSig:  "signature",
+		TransmissionTime: "2024-01-01T10:00:00Z",
+		WebhookID:        "webhook_id",
+		WebhookEvent:     body,
+	}
+
+	should.Equal(t, exp, NewVerifyWebhookSignatureRequest(hdr, "webhook_id", body))
+}
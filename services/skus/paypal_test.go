@@ -0,0 +1,544 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/paypal"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestPayPalProcessor_CreateCheckoutSession(t *testing.T) {
+	type tcGiven struct {
+		items []model.OrderItem
+	}
+
+	type tcExpected struct {
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_items",
+			exp:  tcExpected{err: errPayPalInvalidItems},
+		},
+
+		{
+			name: "many_items",
+			given: tcGiven{
+				items: []model.OrderItem{
+					{Metadata: datastore.Metadata{"paypal_plan_id": "P-1"}},
+					{Metadata: datastore.Metadata{"paypal_plan_id": "P-2"}},
+				},
+			},
+			exp: tcExpected{err: errPayPalInvalidItems},
+		},
+
+		{
+			name: "no_plan_id",
+			given: tcGiven{
+				items: []model.OrderItem{{Metadata: datastore.Metadata{"radom_product_id": "product_1"}}},
+			},
+			exp: tcExpected{err: errPayPalPlanIDNotFound},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				items: []model.OrderItem{{Quantity: 1, Metadata: datastore.Metadata{"paypal_plan_id": "P-1"}}},
+			},
+		},
+	}
+
+	srv := paypal.NewMockServer("client_id", "secret", "webhook_id")
+	defer srv.Close()
+
+	cl, err := paypal.New(srv.URL, "client_id", "secret")
+	must.Equal(t, nil, err)
+
+	proc := newPayPalProcessor(cl, "webhook_id")
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ord := &model.Order{ID: uuid.NewV4(), Items: tc.given.items}
+
+			req := &model.CreateOrderRequestNew{
+				Email: "customer@example.com",
+				PayPalMetadata: &model.OrderPayPalMetadata{
+					SuccessURI: "https://example.com/success",
+					CancelURI:  "https://example.com/cancel",
+				},
+			}
+
+			actual, err := proc.CreateCheckoutSession(context.Background(), req, ord, nil)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, "paypalSubscriptionId", actual.MetadataKey)
+			should.Equal(t, "paypalApprovalLink", actual.URLMetadataKey)
+			should.True(t, strings.HasSuffix(actual.URL, "ba_token=BA-"+actual.ID))
+
+			sub, err := cl.GetSubscription(context.Background(), actual.ID)
+			must.Equal(t, nil, err)
+
+			should.Equal(t, "P-1", sub.PlanID)
+			should.Equal(t, ord.ID.String(), sub.CustomID)
+		})
+	}
+}
+
+func TestPayPalPlanOverride(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *model.OrderItem
+		exp   *paypal.PlanOverride
+	}
+
+	tests := []testCase{
+		{
+			name: "full_price",
+			given: &model.OrderItem{
+				Quantity: 1,
+				Price:    decimal.RequireFromString("9.99"),
+				Subtotal: decimal.RequireFromString("9.99"),
+				Currency: "USD",
+			},
+		},
+
+		{
+			name: "discounted",
+			given: &model.OrderItem{
+				Quantity: 2,
+				Price:    decimal.RequireFromString("9.99"),
+				Subtotal: decimal.RequireFromString("15"),
+				Currency: "USD",
+			},
+			exp: &paypal.PlanOverride{
+				BillingCycles: []paypal.BillingCycleOverride{
+					{
+						Sequence: 1,
+						PricingScheme: paypal.PricingScheme{
+							FixedPrice: paypal.Money{CurrencyCode: "USD", Value: "7.50"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name: "local_price",
+			given: &model.OrderItem{
+				Quantity: 1,
+				Price:    decimal.RequireFromString("8.99"),
+				Subtotal: decimal.RequireFromString("8.99"),
+				Currency: "EUR",
+				Metadata: datastore.Metadata{"price_id": "price_1"},
+			},
+			exp: &paypal.PlanOverride{
+				BillingCycles: []paypal.BillingCycleOverride{
+					{
+						Sequence: 1,
+						PricingScheme: paypal.PricingScheme{
+							FixedPrice: paypal.Money{CurrencyCode: "EUR", Value: "8.99"},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			name: "zero_decimal_currency",
+			given: &model.OrderItem{
+				Quantity: 1,
+				Price:    decimal.RequireFromString("1500"),
+				Subtotal: decimal.RequireFromString("1200.4"),
+				Currency: "JPY",
+				Metadata: datastore.Metadata{"price_id": "price_1"},
+			},
+			exp: &paypal.PlanOverride{
+				BillingCycles: []paypal.BillingCycleOverride{
+					{
+						Sequence: 1,
+						PricingScheme: paypal.PricingScheme{
+							FixedPrice: paypal.Money{CurrencyCode: "JPY", Value: "1200"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, payPalPlanOverride(tc.given))
+		})
+	}
+}
+
+func TestService_CancelOrderLegacy_PayPal(t *testing.T) {
+	type tcGiven struct {
+		sub      *paypal.Subscription
+		withProc bool
+	}
+
+	type tcExpected struct {
+		status    string
+		subStatus string
+		err       error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "active",
+			given: tcGiven{
+				sub:      &paypal.Subscription{ID: "I-1", Status: "ACTIVE"},
+				withProc: true,
+			},
+			exp: tcExpected{status: OrderStatusCanceled, subStatus: "CANCELLED"},
+		},
+
+		{
+			name: "already_cancelled",
+			given: tcGiven{
+				sub:      &paypal.Subscription{ID: "I-1", Status: "CANCELLED"},
+				withProc: true,
+			},
+			exp: tcExpected{status: OrderStatusCanceled, subStatus: "CANCELLED"},
+		},
+
+		{
+			name:  "not_configured",
+			given: tcGiven{sub: &paypal.Subscription{ID: "I-1", Status: "ACTIVE"}},
+			exp:   tcExpected{subStatus: "ACTIVE", err: errPayPalNotConfigured},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			srv := paypal.NewMockServer("client_id", "secret", "webhook_id")
+			defer srv.Close()
+
+			srv.SetSubscription(tc.given.sub)
+
+			cl, err := paypal.New(srv.URL, "client_id", "secret")
+			must.Equal(t, nil, err)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			oid := uuid.NewV4()
+
+			var status string
+
+			ds := NewMockDatastore(ctrl)
+			ds.EXPECT().GetOrder(oid).Return(&Order{ID: oid, Metadata: datastore.Metadata{"paypalSubscriptionId": "I-1"}}, nil)
+			ds.EXPECT().UpdateOrder(oid, gomock.Any()).DoAndReturn(func(id uuid.UUID, val string) error {
+				status = val
+				return nil
+			}).AnyTimes()
+
+			svc := &Service{Datastore: ds}
+			if tc.given.withProc {
+				svc.payProcs = paymentProcessors{newPayPalProcessor(cl, "webhook_id")}
+			}
+
+			err = svc.CancelOrderLegacy(context.Background(), oid)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.status, status)
+
+			sub, err := cl.GetSubscription(context.Background(), "I-1")
+			must.Equal(t, nil, err)
+
+			should.Equal(t, tc.exp.subStatus, sub.Status)
+		})
+	}
+}
+
+func TestPayPalProcessor_AuthenticateNotification(t *testing.T) {
+	srv := paypal.NewMockServer("client_id", "secret", "webhook_id")
+	defer srv.Close()
+
+	cl, err := paypal.New(srv.URL, "client_id", "secret")
+	must.Equal(t, nil, err)
+
+	body := []byte(`{"id":"WH-1","event_type":"BILLING.SUBSCRIPTION.CANCELLED"}`)
+
+	r, err := http.NewRequest(http.MethodPost, "/v1/webhooks/paypal", nil)
+	must.Equal(t, nil, err)
+
+	r.Header.Set("Paypal-Transmission-Id", "transmission_id")
+	r.Header.Set("Paypal-Transmission-Sig", "signature")
+
+	{
+		actual, err := newPayPalProcessor(cl, "webhook_id").AuthenticateNotification(context.Background(), r, body)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, body, actual)
+	}
+
+	{
+		_, err := newPayPalProcessor(cl, "other_webhook_id").AuthenticateNotification(context.Background(), r, body)
+		should.Equal(t, paypal.ErrWebhookSignatureInvalid, err)
+	}
+}
+
+func TestPayPalProcessor_ProcessNotification(t *testing.T) {
+	type tcGiven struct {
+		ord *model.Order
+		sub *paypal.Subscription
+		ntf string
+	}
+
+	type tcExpected struct {
+		status    string
+		expiresAt time.Time
+		paidAt    time.Time
+		mdata     map[string]string
+		numFailed int
+		err       error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	oid := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+	nxtB := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	paidAt := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+
+	paidSub := &paypal.Subscription{
+		ID:       "I-1",
+		Status:   "ACTIVE",
+		CustomID: oid.String(),
+		BillingInfo: &paypal.BillingInfo{
+			NextBillingTime: &nxtB,
+			LastPayment:     &paypal.LastPayment{Time: paidAt},
+		},
+	}
+
+	tests := []testCase{
+		{
+			name: "new_sub",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPending},
+				sub: paidSub,
+				ntf: `{"event_type": "BILLING.SUBSCRIPTION.ACTIVATED", "resource": {"id": "I-1", "custom_id": "facade00-0000-4000-a000-000000000000"}}`,
+			},
+			exp: tcExpected{
+				status:    model.OrderStatusPaid,
+				expiresAt: nxtB.Add(24 * time.Hour),
+				paidAt:    paidAt,
+				mdata:     map[string]string{"externalID": "I-1", "paymentProcessor": "paypal"},
+			},
+		},
+
+		{
+			name: "new_sub_unpaid",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPending},
+				sub: &paypal.Subscription{
+					ID:          "I-1",
+					Status:      "ACTIVE",
+					BillingInfo: &paypal.BillingInfo{NextBillingTime: &nxtB},
+				},
+				ntf: `{"event_type": "BILLING.SUBSCRIPTION.ACTIVATED", "resource": {"id": "I-1", "custom_id": "facade00-0000-4000-a000-000000000000"}}`,
+			},
+			exp: tcExpected{
+				mdata: map[string]string{"externalID": "I-1"},
+			},
+		},
+
+		{
+			name: "renew",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPaid, Metadata: datastore.Metadata{"paymentProcessor": "paypal"}},
+				sub: paidSub,
+				ntf: `{"event_type": "PAYMENT.SALE.COMPLETED", "resource": {"id": "S-1", "billing_agreement_id": "I-1"}}`,
+			},
+			exp: tcExpected{
+				status:    model.OrderStatusPaid,
+				expiresAt: nxtB.Add(24 * time.Hour),
+				paidAt:    paidAt,
+				mdata:     map[string]string{},
+			},
+		},
+
+		{
+			name: "renew_order_not_found",
+			given: tcGiven{
+				sub: paidSub,
+				ntf: `{"event_type": "PAYMENT.SALE.COMPLETED", "resource": {"id": "S-1", "billing_agreement_id": "I-1"}}`,
+			},
+			exp: tcExpected{
+				mdata: map[string]string{},
+				err:   model.ErrOrderNotFound,
+			},
+		},
+
+		{
+			name: "cancel",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPaid},
+				ntf: `{"event_type": "BILLING.SUBSCRIPTION.EXPIRED", "resource": {"id": "I-1"}}`,
+			},
+			exp: tcExpected{
+				status: model.OrderStatusCanceled,
+				mdata:  map[string]string{},
+			},
+		},
+
+		{
+			name: "pay_failure",
+			given: tcGiven{
+				ord: &model.Order{ID: oid, Status: model.OrderStatusPaid, ExpiresAt: ptrTo(time.Now().Add(-time.Hour))},
+				ntf: `{"event_type": "BILLING.SUBSCRIPTION.PAYMENT.FAILED", "resource": {"id": "I-1"}}`,
+			},
+			exp: tcExpected{
+				status:    model.OrderStatusPastDue,
+				mdata:     map[string]string{},
+				numFailed: 1,
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			srv := paypal.NewMockServer("client_id", "secret", "webhook_id")
+			defer srv.Close()
+
+			if tc.given.sub != nil {
+				srv.SetSubscription(tc.given.sub)
+			}
+
+			cl, err := paypal.New(srv.URL, "client_id", "secret")
+			must.Equal(t, nil, err)
+
+			var (
+				status    string
+				expiresAt time.Time
+				paidAt    time.Time
+				numFailed int
+				mdata     = map[string]string{}
+			)
+
+			getOrder := func() (*model.Order, error) {
+				if tc.given.ord == nil {
+					return nil, model.ErrOrderNotFound
+				}
+
+				return tc.given.ord, nil
+			}
+
+			svc := &Service{
+				orderRepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						should.Equal(t, oid, id)
+
+						return getOrder()
+					},
+
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						should.Equal(t, "I-1", extID)
+
+						return getOrder()
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, val string) error {
+						status = val
+
+						return nil
+					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						expiresAt = when
+
+						return nil
+					},
+
+					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+						mdata[key] = val
+
+						return nil
+					},
+
+					FnIncrementNumPayFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+						numFailed++
+
+						return nil
+					},
+				},
+
+				payHistRepo: &repository.MockOrderPayHistory{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						paidAt = when
+
+						return nil
+					},
+				},
+
+				orderEvRepo:  &repository.MockOrderEvent{},
+				orderDunRepo: &repository.MockOrderDunning{},
+				couponRepo:   &repository.MockCoupon{},
+				dunningCfg:   &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
+			}
+
+			proc := newPayPalProcessor(cl, "webhook_id")
+
+			ntf, err := proc.ParseNotification([]byte(tc.given.ntf))
+			must.Equal(t, nil, err)
+
+			actual := proc.ProcessNotification(context.Background(), nil, svc, ntf)
+			must.True(t, errors.Is(actual, tc.exp.err))
+
+			should.Equal(t, tc.exp.status, status)
+			should.Equal(t, tc.exp.paidAt, paidAt)
+
+			// Payment failures extend expiry by the grace period, which is covered by the dunning tests.
+			if !tc.exp.expiresAt.IsZero() {
+				should.Equal(t, tc.exp.expiresAt, expiresAt)
+			}
+			should.Equal(t, tc.exp.mdata, mdata)
+			should.Equal(t, tc.exp.numFailed, numFailed)
+		})
+	}
+}
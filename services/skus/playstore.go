@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"google.golang.org/api/androidpublisher/v3"
 	"google.golang.org/api/idtoken"
 
+	"github.com/brave-intl/bat-go/libs/logging"
+
 	"github.com/brave-intl/bat-go/services/skus/model"
 )
 
@@ -120,6 +125,9 @@ type playStoreDevNotification struct {
 	// Only presense of these matters. The content is ignored.
 	OneTimeProductNtf *struct{} `json:"oneTimeProductNotification"`
 	TestNtf           *struct{} `json:"testNotification"`
+
+	// msgID is the id of the Pub/Sub message which delivered the notification.
+	msgID string
 }
 
 func (x *playStoreDevNotification) shouldProcess() bool {
@@ -190,11 +198,19 @@ func (x *playStoreDevNotification) effect() string {
 			return "cancel"
 		}
 
+		if x.SubscriptionNtf.shouldRecordPayFailure() {
+			return "record_payment_failure"
+		}
+
+		if x.SubscriptionNtf.shouldChangePlan() {
+			return "change_plan"
+		}
+
 		return "skip"
 
 	case x.VoidedPurchaseNtf != nil:
 		if x.VoidedPurchaseNtf.shouldProcess() {
-			return "cancel"
+			return "refund"
 		}
 
 		return "skip"
@@ -204,6 +220,16 @@ func (x *playStoreDevNotification) effect() string {
 	}
 }
 
+func (x *playStoreDevNotification) eventID() string {
+	return x.msgID
+}
+
+func (x *playStoreDevNotification) orderKey() string {
+	token, _ := x.purchaseToken()
+
+	return token
+}
+
 func (x *playStoreDevNotification) pkg() string {
 	return x.PackageName
 }
@@ -241,7 +267,7 @@ func parsePlayStoreDevNotification(raw []byte) (*playStoreDevNotification, error
 		return nil, fmt.Errorf("failed to decode message data: %w", err)
 	}
 
-	result := &playStoreDevNotification{}
+	result := &playStoreDevNotification{msgID: wrap.Message.MessageID}
 	if err := json.Unmarshal(data, result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
 	}
@@ -261,14 +287,10 @@ type playStoreSubscriptionNtf struct {
 func (x *playStoreSubscriptionNtf) shouldProcess() bool {
 	// Other interesting types.
 	//
-	// - 4 == purchased:
-	//     - can be used to create a new order or prepare to working in multi-device mode;
-	// - 5 == on hold;
-	// - 6 == in grace period;
 	// - 10 == paused;
 	// - 20 == pending purchase cancelled.
 
-	return x.shouldRenew() || x.shouldCancel()
+	return x.shouldRenew() || x.shouldCancel() || x.shouldRecordPayFailure() || x.shouldChangePlan()
 }
 
 // shouldRenew reports whether the ntf is about renewal.
@@ -311,10 +333,36 @@ func (x *playStoreSubscriptionNtf) shouldCancel() bool {
 	}
 }
 
+// shouldRecordPayFailure reports whether the ntf is about a failed renewal.
+func (x *playStoreSubscriptionNtf) shouldRecordPayFailure() bool {
+	switch x.Type {
+	// On hold.
+	case 5:
+		return true
+
+	// In grace period.
+	case 6:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// shouldChangePlan reports whether the ntf might be about a plan change.
+//
+// Switching plans creates a new purchase which replaces the previous one, and is announced as purchased.
+// Whether the purchase replaces another is only known from the purchase itself.
+func (x *playStoreSubscriptionNtf) shouldChangePlan() bool {
+	// Purchased.
+	return x.Type == 4
+}
+
 type playStoreVoidedPurchaseNtf struct {
 	ProductType   int    `json:"productType"`
 	RefundType    int    `json:"refundType"`
 	PurchaseToken string `json:"purchaseToken"`
+	OrderID       string `json:"orderId"`
 }
 
 // shouldProcess determines whether x should be processed.
@@ -333,6 +381,11 @@ func (x *playStoreVoidedPurchaseNtf) shouldProcess() bool {
 	}
 }
 
+// isFullRefund reports whether the whole purchase has been refunded, as opposed to some of its quantity.
+func (x *playStoreVoidedPurchaseNtf) isFullRefund() bool {
+	return x.RefundType == 1
+}
+
 func newReceiptDataGoogle(req model.ReceiptRequest, item *playStoreSubPurchase) model.ReceiptData {
 	result := model.ReceiptData{
 		Type:      req.Type,
@@ -343,3 +396,168 @@ func newReceiptDataGoogle(req model.ReceiptRequest, item *playStoreSubPurchase)
 
 	return result
 }
+
+type playStoreSubFetcher interface {
+	fetchSubPlayStore(ctx context.Context, pkgName, subID, token string) (*playStoreSubPurchase, error)
+}
+
+type playStoreProcessor struct {
+	auth    gpsMessageAuthenticator
+	subs    playStoreSubFetcher
+	catalog *skuCatalog
+}
+
+func newPlayStoreProcessor(auth gpsMessageAuthenticator, subs playStoreSubFetcher, catalog *skuCatalog) *playStoreProcessor {
+	return &playStoreProcessor{auth: auth, subs: subs, catalog: catalog}
+}
+
+func (p *playStoreProcessor) Name() string {
+	return model.WebhookVendorPlayStore
+}
+
+// CreateCheckoutSession is not supported, as orders are created from receipts.
+func (p *playStoreProcessor) CreateCheckoutSession(_ context.Context, _ *model.CreateOrderRequestNew, _ *model.Order, _ *model.Coupon) (*CheckoutSession, error) {
+	return nil, errPaymentCheckoutUnsupported
+}
+
+// AuthenticateNotification checks the token with which Pub/Sub pushes the notification.
+func (p *playStoreProcessor) AuthenticateNotification(ctx context.Context, r *http.Request, body []byte) ([]byte, error) {
+	if err := p.auth.authenticate(ctx, r.Header.Get("Authorization")); err != nil {
+		if errors.Is(err, errGPSDisabled) {
+			return nil, errPaymentNtfDisabled
+		}
+
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (p *playStoreProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	return parsePlayStoreDevNotification(payload)
+}
+
+func (p *playStoreProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*playStoreDevNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	extID, ok := ntf.purchaseToken()
+	if !ok {
+		return nil
+	}
+
+	return p.processTx(ctx, dbi, sm, ntf, extID)
+}
+
+func (p *playStoreProcessor) processTx(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntf *playStoreDevNotification, extID string) error {
+	// The new purchase is not known yet, its order is found by the purchase it replaces.
+	if ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldChangePlan() {
+		return p.replacePurchaseTx(ctx, dbi, sm, ntf)
+	}
+
+	ord, err := sm.getOrderByExternalIDTx(ctx, dbi, extID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	// Renewal.
+	case ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldRenew():
+		sub, err := p.subs.fetchSubPlayStore(ctx, ntf.PackageName, ntf.SubscriptionNtf.SubID, ntf.SubscriptionNtf.PurchaseToken)
+		if err != nil {
+			return err
+		}
+
+		expt := sub.expiresTime().Add(24 * time.Hour)
+		paidt := time.Now()
+
+		return sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt)
+
+	// Sub cancellation.
+	case ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldCancel():
+		return sm.cancelOrderTx(ctx, dbi, ord.ID)
+
+	// Payment failure.
+	case ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldRecordPayFailure():
+		return sm.recordPayFailureTx(ctx, dbi, ord, model.WebhookVendorPlayStore, time.Now())
+
+	// Voiding.
+	case ntf.VoidedPurchaseNtf != nil && ntf.VoidedPurchaseNtf.shouldProcess():
+		// Partial refunds are quantity-based, which subscriptions are not, and their amount is not reported.
+		if !ntf.VoidedPurchaseNtf.isFullRefund() {
+			return nil
+		}
+
+		extID := ntf.VoidedPurchaseNtf.OrderID
+		if extID == "" {
+			extID = ntf.VoidedPurchaseNtf.PurchaseToken
+		}
+
+		req := newOrderRefundTxn(ord, extID)
+
+		return sm.refundOrderTx(ctx, dbi, req, true, time.Now())
+
+	default:
+		return nil
+	}
+}
+
+// replacePurchaseTx moves the order of the replaced purchase over to the new one, and switches it to the new plan.
+//
+// A purchase which does not replace another is a new subscription, and its order is created from the receipt.
+func (p *playStoreProcessor) replacePurchaseTx(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntf *playStoreDevNotification) error {
+	sub, err := p.subs.fetchSubPlayStore(ctx, ntf.PackageName, ntf.SubscriptionNtf.SubID, ntf.SubscriptionNtf.PurchaseToken)
+	if err != nil {
+		return err
+	}
+
+	if sub.LinkedPurchaseToken == "" {
+		return nil
+	}
+
+	ord, err := sm.getOrderByExternalIDTx(ctx, dbi, sub.LinkedPurchaseToken)
+	if err != nil {
+		return err
+	}
+
+	expt := sub.expiresTime().Add(24 * time.Hour)
+
+	// The new purchase has been paid for, so it's applied even if the plan can't be changed.
+	if err := p.changeOrderPlan(ctx, dbi, sm, ord, ntf.SubscriptionNtf.SubID, expt); err != nil {
+		if !isErrOrderPlanChange(err) {
+			return err
+		}
+
+		logging.Logger(ctx, "skus").Warn().Err(err).Str("order_id", ord.ID.String()).Str("product_id", ntf.SubscriptionNtf.SubID).Msg("failed to change order plan on purchase replacement")
+	}
+
+	// Further notifications refer to the new purchase.
+	if err := sm.setOrderMetadataTx(ctx, dbi, ord.ID, "externalID", ntf.SubscriptionNtf.PurchaseToken); err != nil {
+		return err
+	}
+
+	return sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, time.Now())
+}
+
+func (p *playStoreProcessor) changeOrderPlan(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ord *model.Order, productID string, expt time.Time) error {
+	itemReq, err := p.catalog.itemReqByStoreProductID(ctx, dbi, productID)
+	if err != nil {
+		return err
+	}
+
+	items, err := sm.getOrderItemsTx(ctx, dbi, ord.ID)
+	if err != nil {
+		return err
+	}
+
+	ord.Items = items
+
+	return sm.changeOrderPlanTx(ctx, dbi, ord, itemReq.SKUVnt, expt, time.Now())
+}
+
+// Subscription is not supported, as Play Store subscriptions are looked up by package, product and purchase token.
+func (p *playStoreProcessor) Subscription(_ context.Context, _ string) (*PaymentSubscription, error) {
+	return nil, errPaymentSubUnsupported
+}
@@ -333,6 +333,7 @@ func TestParsePlayStoreDevNotification(t *testing.T) {
 				val: &playStoreDevNotification{
 					PackageName:    "com.some.thing",
 					EventTimeMilli: json.Number("1503349566168"),
+					msgID:          "136969346945",
 					SubscriptionNtf: &playStoreSubscriptionNtf{
 						Type:          4,
 						PurchaseToken: "PURCHASE_TOKEN",
@@ -350,6 +351,7 @@ func TestParsePlayStoreDevNotification(t *testing.T) {
 					PackageName:       "com.some.thing",
 					EventTimeMilli:    json.Number("1503349566168"),
 					OneTimeProductNtf: &struct{}{},
+					msgID:             "136969346945",
 				},
 			},
 		},
@@ -361,10 +363,12 @@ func TestParsePlayStoreDevNotification(t *testing.T) {
 				val: &playStoreDevNotification{
 					PackageName:    "com.some.thing",
 					EventTimeMilli: json.Number("1503349566168"),
+					msgID:          "136969346945",
 					VoidedPurchaseNtf: &playStoreVoidedPurchaseNtf{
 						ProductType:   1,
 						RefundType:    1,
 						PurchaseToken: "PURCHASE_TOKEN",
+						OrderID:       "GS.0000-0000-0000",
 					},
 				},
 			},
@@ -377,6 +381,7 @@ func TestParsePlayStoreDevNotification(t *testing.T) {
 				val: &playStoreDevNotification{
 					PackageName:    "com.some.thing",
 					EventTimeMilli: json.Number("1503349566168"),
+					msgID:          "136969346945",
 					TestNtf:        &struct{}{},
 				},
 			},
@@ -604,6 +609,22 @@ func TestPlayStoreDevNotification_effect(t *testing.T) {
 			exp: "cancel",
 		},
 
+		{
+			name: "subscription_record_pay_failure",
+			given: &playStoreDevNotification{
+				SubscriptionNtf: &playStoreSubscriptionNtf{Type: 6},
+			},
+			exp: "record_payment_failure",
+		},
+
+		{
+			name: "subscription_change_plan",
+			given: &playStoreDevNotification{
+				SubscriptionNtf: &playStoreSubscriptionNtf{Type: 4},
+			},
+			exp: "change_plan",
+		},
+
 		{
 			name: "subscription_skip",
 			given: &playStoreDevNotification{
@@ -613,11 +634,11 @@ func TestPlayStoreDevNotification_effect(t *testing.T) {
 		},
 
 		{
-			name: "voided_purchase_cancel",
+			name: "voided_purchase_refund",
 			given: &playStoreDevNotification{
 				VoidedPurchaseNtf: &playStoreVoidedPurchaseNtf{ProductType: 1},
 			},
-			exp: "cancel",
+			exp: "refund",
 		},
 
 		{
@@ -748,6 +769,18 @@ func TestPlayStoreSubscriptionNtf_shouldProcess(t *testing.T) {
 			exp:   true,
 		},
 
+		{
+			name:  "pay_failure",
+			given: &playStoreSubscriptionNtf{Type: 5},
+			exp:   true,
+		},
+
+		{
+			name:  "purchased",
+			given: &playStoreSubscriptionNtf{Type: 4},
+			exp:   true,
+		},
+
 		{
 			name:  "skip",
 			given: &playStoreSubscriptionNtf{Type: 20},
@@ -848,6 +881,42 @@ func TestPlayStoreSubscriptionNtf_shouldCancel(t *testing.T) {
 	}
 }
 
+func TestPlayStoreSubscriptionNtf_shouldRecordPayFailure(t *testing.T) {
+	type testCase struct {
+		name  string
+		given *playStoreSubscriptionNtf
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "on_hold",
+			given: &playStoreSubscriptionNtf{Type: 5},
+			exp:   true,
+		},
+
+		{
+			name:  "in_grace_period",
+			given: &playStoreSubscriptionNtf{Type: 6},
+			exp:   true,
+		},
+
+		{
+			name:  "renewed",
+			given: &playStoreSubscriptionNtf{Type: 2},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.shouldRecordPayFailure()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
 func TestPlayStoreVoidedPurchaseNtf_shouldProcess(t *testing.T) {
 	type testCase struct {
 		name  string
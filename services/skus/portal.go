@@ -0,0 +1,588 @@
+package skus
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stripe/stripe-go/v72"
+
+	"github.com/brave-intl/bat-go/libs/clients"
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	defaultPortalCodeTTL    = 10 * time.Minute
+	defaultPortalSessionTTL = 24 * time.Hour
+
+	portalCodeMaxAttempts    = 5
+	portalCodeResendInterval = time.Minute
+	portalSessionTokenLen    = 32
+
+	// An email can be sent at most portalLoginMaxCodes codes within portalLoginWindow.
+	// It is locked for the rest of the window after portalLoginMaxFailed wrong codes.
+	portalLoginWindow    = 24 * time.Hour
+	portalLoginMaxCodes  = 10
+	portalLoginMaxFailed = 10
+
+	errPortalMailerNotConfigured = model.Error("skus: portal mailer not configured")
+)
+
+type portalStore interface {
+	InsertLoginCode(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error
+	GetLatestLoginCodeForUpdate(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error)
+	GetLoginStats(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error)
+	IncrLoginCodeAttempts(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	MarkLoginCodeUsed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	InsertSession(ctx context.Context, dbi sqlx.QueryerContext, email, tokenHash string, expiresAt time.Time) (*model.PortalSession, error)
+	GetSession(ctx context.Context, dbi sqlx.QueryerContext, tokenHash string, now time.Time) (*model.PortalSession, error)
+	DeleteSession(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+type portalCodeSender interface {
+	SendLoginCode(ctx context.Context, email, code string) error
+}
+
+// portalConfig controls sign in to the customer portal.
+//
+// returnHosts lists hosts the billing portal may return customers to.
+type portalConfig struct {
+	codeTTL     time.Duration
+	sessionTTL  time.Duration
+	returnHosts []string
+}
+
+func newPortalConfig() (*portalConfig, error) {
+	result := &portalConfig{
+		codeTTL:    defaultPortalCodeTTL,
+		sessionTTL: defaultPortalSessionTTL,
+	}
+
+	if raw := os.Getenv("SKUS_PORTAL_CODE_TTL"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("skus: invalid portal code ttl: %w", err)
+		}
+
+		result.codeTTL = val
+	}
+
+	if raw := os.Getenv("SKUS_PORTAL_SESSION_TTL"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("skus: invalid portal session ttl: %w", err)
+		}
+
+		result.sessionTTL = val
+	}
+
+	if raw := os.Getenv("SKUS_PORTAL_RETURN_HOSTS"); raw != "" {
+		for _, host := range strings.Split(raw, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				result.returnHosts = append(result.returnHosts, host)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// isReturnURLAllowed reports whether raw is an https url on one of the return hosts.
+func (c *portalConfig) isReturnURLAllowed(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for i := range c.returnHosts {
+		if host == c.returnHosts[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newPortalCodeSender returns a sender that delivers login codes via the mailer service.
+//
+// Without the mailer configured, codes are logged in local and development, and cannot be sent elsewhere.
+func newPortalCodeSender(env string) (portalCodeSender, error) {
+	srvURL := os.Getenv("SKUS_PORTAL_MAILER_URL")
+	if srvURL == "" {
+		if env == "local" || env == "development" {
+			return portalLogSender{}, nil
+		}
+
+		return portalNoopSender{}, nil
+	}
+
+	return newPortalMailer(srvURL, os.Getenv("SKUS_PORTAL_MAILER_TOKEN"))
+}
+
+type portalMailer struct {
+	client *clients.SimpleHTTPClient
+}
+
+func newPortalMailer(srvURL, authToken string) (*portalMailer, error) {
+	cl, err := clients.New(srvURL, authToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &portalMailer{client: cl}, nil
+}
+
+type portalLoginCodeMessage struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+func (m *portalMailer) SendLoginCode(ctx context.Context, email, code string) error {
+	msg := &portalLoginCodeMessage{Email: email, Code: code}
+
+	req, err := m.client.NewRequest(ctx, http.MethodPost, "/v1/portal/login-code", msg, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.client.Do(ctx, req, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type portalLogSender struct{}
+
+func (portalLogSender) SendLoginCode(ctx context.Context, email, code string) error {
+	logging.Logger(ctx, "skus").Info().Str("email", email).Str("code", code).Msg("portal login code")
+
+	return nil
+}
+
+type portalNoopSender struct{}
+
+func (portalNoopSender) SendLoginCode(_ context.Context, _, _ string) error {
+	return errPortalMailerNotConfigured
+}
+
+// PortalLogin sends a one-time login code to the email.
+//
+// The outcome does not depend on whether the email belongs to a customer.
+// A new code is not sent while the previous one is younger than the resend interval.
+func (s *Service) PortalLogin(ctx context.Context, req *model.PortalLoginRequest) error {
+	email := normPortalEmail(req.Email)
+
+	code, err := newPortalLoginCode()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	sent, err := s.portalLoginTx(ctx, tx, email, code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !sent {
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return s.portalSender.SendLoginCode(ctx, email, code)
+}
+
+func (s *Service) portalLoginTx(ctx context.Context, dbi sqlx.ExtContext, email, code string, now time.Time) (bool, error) {
+	prev, err := s.portalRepo.GetLatestLoginCodeForUpdate(ctx, dbi, email)
+	if err != nil && !errors.Is(err, model.ErrPortalLoginCodeInvalid) {
+		return false, err
+	}
+
+	if prev != nil && now.Sub(prev.CreatedAt) < portalCodeResendInterval {
+		return false, nil
+	}
+
+	stats, err := s.portalRepo.GetLoginStats(ctx, dbi, email, now.Add(-portalLoginWindow))
+	if err != nil {
+		return false, err
+	}
+
+	if stats.NumCodes >= portalLoginMaxCodes || stats.NumFailedAttempts >= portalLoginMaxFailed {
+		return false, model.ErrPortalLoginLimited
+	}
+
+	if err := s.portalRepo.InsertLoginCode(ctx, dbi, email, hashPortalSecret(code), now.Add(s.portalCfg.codeTTL)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// PortalVerify exchanges a valid login code for a portal session token.
+func (s *Service) PortalVerify(ctx context.Context, req *model.PortalVerifyRequest) (*model.PortalSessionResponse, error) {
+	token, err := randomString(portalSessionTokenLen)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := s.portalVerifyTx(ctx, tx, normPortalEmail(req.Email), req.Code, token, time.Now())
+	if err != nil {
+		// A failed attempt must be recorded.
+		if errors.Is(err, model.ErrPortalLoginCodeInvalid) {
+			if cerr := tx.Commit(); cerr != nil {
+				return nil, cerr
+			}
+		}
+
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Service) portalVerifyTx(ctx context.Context, dbi sqlx.ExtContext, email, code, token string, now time.Time) (*model.PortalSessionResponse, error) {
+	lcode, err := s.portalRepo.GetLatestLoginCodeForUpdate(ctx, dbi, email)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.portalRepo.GetLoginStats(ctx, dbi, email, now.Add(-portalLoginWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.NumFailedAttempts >= portalLoginMaxFailed {
+		return nil, model.ErrPortalLoginLimited
+	}
+
+	if lcode.UsedAt != nil || !now.Before(lcode.ExpiresAt) || lcode.NumAttempts >= portalCodeMaxAttempts {
+		return nil, model.ErrPortalLoginCodeInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashPortalSecret(code)), []byte(lcode.CodeHash)) != 1 {
+		if err := s.portalRepo.IncrLoginCodeAttempts(ctx, dbi, lcode.ID); err != nil {
+			return nil, err
+		}
+
+		return nil, model.ErrPortalLoginCodeInvalid
+	}
+
+	if err := s.portalRepo.MarkLoginCodeUsed(ctx, dbi, lcode.ID, now); err != nil {
+		return nil, err
+	}
+
+	sess, err := s.portalRepo.InsertSession(ctx, dbi, email, hashPortalSecret(token), now.Add(s.portalCfg.sessionTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.PortalSessionResponse{
+		Token:     token,
+		ExpiresAt: sess.ExpiresAt,
+	}
+
+	return result, nil
+}
+
+// PortalSession returns the active session for the token.
+func (s *Service) PortalSession(ctx context.Context, token string) (*model.PortalSession, error) {
+	return s.portalRepo.GetSession(ctx, s.Datastore.RawDB(), hashPortalSecret(token), time.Now())
+}
+
+// PortalLogout ends the session.
+func (s *Service) PortalLogout(ctx context.Context, id uuid.UUID) error {
+	return s.portalRepo.DeleteSession(ctx, s.Datastore.RawDB(), id)
+}
+
+// ListPortalOrders returns orders paid through Stripe by customers with the email.
+func (s *Service) ListPortalOrders(ctx context.Context, email string) ([]model.PortalOrder, error) {
+	return s.listPortalOrders(ctx, s.Datastore.RawDB(), email)
+}
+
+func (s *Service) listPortalOrders(ctx context.Context, dbi sqlx.QueryerContext, email string) ([]model.PortalOrder, error) {
+	custs, err := s.stripeCl.FindCustomers(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.PortalOrder, 0)
+
+	for i := range custs {
+		subs, err := s.stripeCl.CustomerSubscriptions(ctx, custs[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range subs {
+			id, err := uuid.FromString(subs[j].Metadata["orderID"])
+			if err != nil {
+				continue
+			}
+
+			ord, err := s.getOrderFullTx(ctx, dbi, id)
+			if err != nil {
+				if errors.Is(err, model.ErrOrderNotFound) {
+					continue
+				}
+
+				return nil, err
+			}
+
+			result = append(result, newPortalOrder(ord, subs[j]))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+
+	return result, nil
+}
+
+// ListPortalPayments returns past payments for the order, most recent first.
+func (s *Service) ListPortalPayments(ctx context.Context, email string, orderID uuid.UUID) ([]model.PortalPayment, error) {
+	dbi := s.Datastore.RawDB()
+
+	if _, _, err := s.portalOrderSub(ctx, dbi, email, orderID); err != nil {
+		return nil, err
+	}
+
+	paid, err := s.payHistRepo.List(ctx, dbi, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.PortalPayment, 0, len(paid))
+	for i := range paid {
+		result = append(result, model.PortalPayment{PaidAt: paid[i]})
+	}
+
+	return result, nil
+}
+
+// CancelPortalOrder cancels the subscription for the order at the end of the current period.
+//
+// The order remains paid until then, and is canceled when Stripe notifies about the subscription having ended.
+func (s *Service) CancelPortalOrder(ctx context.Context, email string, orderID uuid.UUID) (*model.PortalOrder, error) {
+	return s.setPortalOrderCancelAtPeriodEnd(ctx, s.Datastore.RawDB(), email, orderID, true)
+}
+
+// ResumePortalOrder undoes a pending cancellation of the subscription for the order.
+func (s *Service) ResumePortalOrder(ctx context.Context, email string, orderID uuid.UUID) (*model.PortalOrder, error) {
+	return s.setPortalOrderCancelAtPeriodEnd(ctx, s.Datastore.RawDB(), email, orderID, false)
+}
+
+func (s *Service) setPortalOrderCancelAtPeriodEnd(ctx context.Context, dbi sqlx.QueryerContext, email string, orderID uuid.UUID, cancel bool) (*model.PortalOrder, error) {
+	ord, sub, err := s.portalOrderSub(ctx, dbi, email, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ord.Status == model.OrderStatusCanceled || sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil, model.ErrPortalOrderNotManageable
+	}
+
+	if sub.CancelAtPeriodEnd == cancel {
+		result := newPortalOrder(ord, sub)
+
+		return &result, nil
+	}
+
+	params := &stripe.SubscriptionParams{CancelAtPeriodEnd: &cancel}
+	params.Context = ctx
+
+	nsub, err := s.stripeCl.UpdateSubscription(ctx, sub.ID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newPortalOrder(ord, nsub)
+
+	return &result, nil
+}
+
+// CreatePortalBillingSession creates a Stripe billing portal session for the customer with the email.
+func (s *Service) CreatePortalBillingSession(ctx context.Context, email string, req *model.PortalBillingSessionRequest) (*model.PortalBillingSessionResponse, error) {
+	return s.createPortalBillingSession(ctx, s.Datastore.RawDB(), email, req)
+}
+
+func (s *Service) createPortalBillingSession(ctx context.Context, dbi sqlx.QueryerContext, email string, req *model.PortalBillingSessionRequest) (*model.PortalBillingSessionResponse, error) {
+	if !s.portalCfg.isReturnURLAllowed(req.ReturnURL) {
+		return nil, model.ErrPortalReturnURLInvalid
+	}
+
+	custID, err := s.portalCustomerID(ctx, dbi, email, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripe.BillingPortalSessionParams{
+		Customer:  &custID,
+		ReturnURL: &req.ReturnURL,
+	}
+	params.Context = ctx
+
+	sess, err := s.stripeCl.CreateBillingPortalSession(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PortalBillingSessionResponse{URL: sess.URL}, nil
+}
+
+// portalCustomerID returns the id of the Stripe customer with the email.
+//
+// When the email belongs to several customers, the order must be given, and its subscription selects the customer.
+func (s *Service) portalCustomerID(ctx context.Context, dbi sqlx.QueryerContext, email string, orderID *uuid.UUID) (string, error) {
+	if orderID != nil {
+		_, sub, err := s.portalOrderSub(ctx, dbi, email, *orderID)
+		if err != nil {
+			return "", err
+		}
+
+		return sub.Customer.ID, nil
+	}
+
+	custs, err := s.stripeCl.FindCustomers(ctx, email)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(custs) {
+	case 0:
+		return "", model.ErrPortalCustomerNotFound
+
+	case 1:
+		return custs[0].ID, nil
+
+	default:
+		return "", model.ErrPortalCustomerAmbiguous
+	}
+}
+
+// portalOrderSub returns the order and its Stripe subscription if the subscription belongs to the email.
+//
+// Orders that are not owned by email, or cannot be attributed to it, are reported as not found.
+func (s *Service) portalOrderSub(ctx context.Context, dbi sqlx.QueryerContext, email string, orderID uuid.UUID) (*model.Order, *stripe.Subscription, error) {
+	ord, err := s.getOrderFullTx(ctx, dbi, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subID, ok := ord.StripeSubID()
+	if !ok || subID == "" {
+		return nil, nil, model.ErrOrderNotFound
+	}
+
+	params := &stripe.SubscriptionParams{}
+	params.Context = ctx
+	params.AddExpand("customer")
+
+	sub, err := s.stripeCl.Subscription(ctx, subID, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sub.Customer == nil || !strings.EqualFold(sub.Customer.Email, email) {
+		return nil, nil, model.ErrOrderNotFound
+	}
+
+	return ord, sub, nil
+}
+
+func newPortalOrder(ord *model.Order, sub *stripe.Subscription) model.PortalOrder {
+	result := model.PortalOrder{
+		ID:                ord.ID,
+		CreatedAt:         ord.CreatedAt,
+		Status:            ord.Status,
+		Currency:          ord.Currency,
+		TotalPrice:        ord.TotalPrice,
+		LastPaidAt:        ord.LastPaidAt,
+		ExpiresAt:         ord.ExpiresAt,
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		Items:             make([]model.PortalOrderItem, 0, len(ord.Items)),
+	}
+
+	if isPortalSubRenewing(sub) && sub.CurrentPeriodEnd > 0 {
+		next := time.Unix(sub.CurrentPeriodEnd, 0).UTC()
+		result.NextBillingAt = &next
+	}
+
+	for i := range ord.Items {
+		result.Items = append(result.Items, model.PortalOrderItem{
+			SKU:         ord.Items[i].SKU,
+			SKUVnt:      ord.Items[i].SKUVnt,
+			Description: ord.Items[i].Description.String,
+			Quantity:    ord.Items[i].Quantity,
+			Price:       ord.Items[i].Price,
+		})
+	}
+
+	return result
+}
+
+func isPortalSubRenewing(sub *stripe.Subscription) bool {
+	if sub.CancelAtPeriodEnd {
+		return false
+	}
+
+	switch sub.Status {
+	case stripe.SubscriptionStatusActive, stripe.SubscriptionStatusTrialing, stripe.SubscriptionStatusPastDue:
+		return true
+	default:
+		return false
+	}
+}
+
+// newPortalLoginCode returns a random six-digit code.
+func newPortalLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// normPortalEmail returns email in the form login codes and sessions are stored with.
+func normPortalEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func hashPortalSecret(val string) string {
+	sum := sha256.Sum256([]byte(val))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,806 @@
+package skus
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v72"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+	"github.com/brave-intl/bat-go/services/skus/xstripe"
+)
+
+func TestService_portalLoginTx(t *testing.T) {
+	type tcGiven struct {
+		repo *repository.MockPortal
+		now  time.Time
+	}
+
+	type tcExpected struct {
+		sent bool
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_get_latest",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "no_previous_code",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnInsertLoginCode: func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+						if email != "you@example.com" {
+							return model.Error("unexpected_email")
+						}
+
+						if codeHash != hashPortalSecret("123456") {
+							return model.Error("unexpected_code_hash")
+						}
+
+						if !expiresAt.Equal(time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC)) {
+							return model.Error("unexpected_expires_at")
+						}
+
+						return nil
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: tcExpected{sent: true},
+		},
+
+		{
+			name: "throttled",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+						result := &model.PortalLoginCode{
+							CreatedAt: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+
+					FnInsertLoginCode: func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+						return model.Error("unexpected_insert")
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 30, 0, time.UTC),
+			},
+		},
+
+		{
+			name: "previous_code_old_enough",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+						result := &model.PortalLoginCode{
+							CreatedAt: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+						}
+
+						return result, nil
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 1, 0, 0, time.UTC),
+			},
+			exp: tcExpected{sent: true},
+		},
+
+		{
+			name: "error_insert",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnInsertLoginCode: func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "limited_codes",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLoginStats: func(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error) {
+						if !since.Equal(time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)) {
+							return model.PortalLoginStats{}, model.Error("unexpected_since")
+						}
+
+						return model.PortalLoginStats{NumCodes: portalLoginMaxCodes}, nil
+					},
+
+					FnInsertLoginCode: func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+						return model.Error("unexpected_insert")
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginLimited},
+		},
+
+		{
+			name: "locked",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLoginStats: func(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error) {
+						return model.PortalLoginStats{NumCodes: 1, NumFailedAttempts: portalLoginMaxFailed}, nil
+					},
+
+					FnInsertLoginCode: func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+						return model.Error("unexpected_insert")
+					},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginLimited},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				portalRepo: tc.given.repo,
+				portalCfg:  &portalConfig{codeTTL: defaultPortalCodeTTL, sessionTTL: defaultPortalSessionTTL},
+			}
+
+			actual, err := svc.portalLoginTx(context.Background(), nil, "you@example.com", "123456", tc.given.now)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.sent, actual)
+		})
+	}
+}
+
+func TestService_portalVerifyTx(t *testing.T) {
+	type tcGiven struct {
+		repo *repository.MockPortal
+		code string
+		now  time.Time
+	}
+
+	type tcExpected struct {
+		val *model.PortalSessionResponse
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	newCode := func(fn func(lcode *model.PortalLoginCode)) func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+		return func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+			result := &model.PortalLoginCode{
+				ID:        uuid.FromStringOrNil("c0c0a000-0000-4000-a000-000000000000"),
+				Email:     email,
+				CodeHash:  hashPortalSecret("123456"),
+				ExpiresAt: time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC),
+			}
+
+			fn(result)
+
+			return result, nil
+		}
+	}
+
+	tests := []testCase{
+		{
+			name: "no_code",
+			given: tcGiven{
+				repo: &repository.MockPortal{},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "used",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {
+						when := time.Date(2024, time.January, 1, 0, 1, 0, 0, time.UTC)
+						lcode.UsedAt = &when
+					}),
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "expired",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {}),
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "too_many_attempts",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {
+						lcode.NumAttempts = portalCodeMaxAttempts
+					}),
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "locked",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {}),
+					FnGetLoginStats: func(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error) {
+						return model.PortalLoginStats{NumCodes: 3, NumFailedAttempts: portalLoginMaxFailed}, nil
+					},
+					FnMarkLoginCodeUsed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_mark_used")
+					},
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginLimited},
+		},
+
+		{
+			name: "wrong_code_attempt_recorded",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {}),
+					FnIncrLoginCodeAttempts: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+						if !uuid.Equal(id, uuid.FromStringOrNil("c0c0a000-0000-4000-a000-000000000000")) {
+							return model.Error("unexpected_id")
+						}
+
+						return nil
+					},
+					FnMarkLoginCodeUsed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_mark_used")
+					},
+				},
+				code: "654321",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "error_mark_used",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {}),
+					FnMarkLoginCodeUsed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.ErrPortalLoginCodeInvalid
+					},
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{err: model.ErrPortalLoginCodeInvalid},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				repo: &repository.MockPortal{
+					FnGetLatestLoginCodeForUpdate: newCode(func(lcode *model.PortalLoginCode) {}),
+					FnInsertSession: func(ctx context.Context, dbi sqlx.QueryerContext, email, tokenHash string, expiresAt time.Time) (*model.PortalSession, error) {
+						if tokenHash != hashPortalSecret("token") {
+							return nil, model.Error("unexpected_token_hash")
+						}
+
+						result := &model.PortalSession{
+							Email:     email,
+							ExpiresAt: expiresAt,
+						}
+
+						return result, nil
+					},
+				},
+				code: "123456",
+				now:  time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC),
+			},
+			exp: tcExpected{
+				val: &model.PortalSessionResponse{
+					Token:     "token",
+					ExpiresAt: time.Date(2024, time.January, 2, 0, 5, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				portalRepo: tc.given.repo,
+				portalCfg:  &portalConfig{codeTTL: defaultPortalCodeTTL, sessionTTL: defaultPortalSessionTTL},
+			}
+
+			actual, err := svc.portalVerifyTx(context.Background(), nil, "you@example.com", tc.given.code, "token", tc.given.now)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_createPortalBillingSession(t *testing.T) {
+	type tcGiven struct {
+		req   *model.PortalBillingSessionRequest
+		custs []*stripe.Customer
+		sub   *stripe.Subscription
+	}
+
+	type tcExpected struct {
+		cust string
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	orderID := uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000")
+
+	tests := []testCase{
+		{
+			name: "return_url_not_allowed",
+			given: tcGiven{
+				req: &model.PortalBillingSessionRequest{ReturnURL: "https://evil.example.org/account"},
+			},
+			exp: tcExpected{err: model.ErrPortalReturnURLInvalid},
+		},
+
+		{
+			name: "no_customer",
+			given: tcGiven{
+				req: &model.PortalBillingSessionRequest{ReturnURL: "https://account.brave.com/"},
+			},
+			exp: tcExpected{err: model.ErrPortalCustomerNotFound},
+		},
+
+		{
+			name: "one_customer",
+			given: tcGiven{
+				req:   &model.PortalBillingSessionRequest{ReturnURL: "https://account.brave.com/"},
+				custs: []*stripe.Customer{{ID: "cus_01", Email: "you@example.com"}},
+			},
+			exp: tcExpected{cust: "cus_01"},
+		},
+
+		{
+			name: "several_customers_no_order",
+			given: tcGiven{
+				req: &model.PortalBillingSessionRequest{ReturnURL: "https://account.brave.com/"},
+				custs: []*stripe.Customer{
+					{ID: "cus_01", Email: "you@example.com"},
+					{ID: "cus_02", Email: "you@example.com"},
+				},
+			},
+			exp: tcExpected{err: model.ErrPortalCustomerAmbiguous},
+		},
+
+		{
+			name: "several_customers_order",
+			given: tcGiven{
+				req: &model.PortalBillingSessionRequest{ReturnURL: "https://account.brave.com/", OrderID: &orderID},
+				custs: []*stripe.Customer{
+					{ID: "cus_01", Email: "you@example.com"},
+					{ID: "cus_02", Email: "you@example.com"},
+				},
+				sub: &stripe.Subscription{
+					ID:       "sub_id",
+					Status:   stripe.SubscriptionStatusActive,
+					Customer: &stripe.Customer{ID: "cus_02", Email: "you@example.com"},
+				},
+			},
+			exp: tcExpected{cust: "cus_02"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var cust string
+
+			svc := &Service{
+				orderRepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Status:   model.OrderStatusPaid,
+							Metadata: datastore.Metadata{"stripeSubscriptionId": "sub_id"},
+						}
+
+						return result, nil
+					},
+				},
+				orderItemRepo: &repository.MockOrderItem{},
+				stripeCl: &xstripe.MockClient{
+					FnFindCustomers: func(ctx context.Context, email string) ([]*stripe.Customer, error) {
+						return tc.given.custs, nil
+					},
+
+					FnSubscription: func(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+						return tc.given.sub, nil
+					},
+
+					FnCreateBillingPortSess: func(ctx context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error) {
+						cust = *params.Customer
+
+						return &stripe.BillingPortalSession{URL: "https://billing.stripe.com/session"}, nil
+					},
+				},
+				portalCfg: &portalConfig{returnHosts: []string{"account.brave.com"}},
+			}
+
+			actual, err := svc.createPortalBillingSession(context.Background(), nil, "you@example.com", tc.given.req)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.cust, cust)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, "https://billing.stripe.com/session", actual.URL)
+		})
+	}
+}
+
+func TestPortalConfig_isReturnURLAllowed(t *testing.T) {
+	type testCase struct {
+		name  string
+		given string
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "allowed",
+			given: "https://account.brave.com/plans?intent=manage",
+			exp:   true,
+		},
+
+		{
+			name:  "allowed_case_insensitive",
+			given: "https://Account.Brave.com/",
+			exp:   true,
+		},
+
+		{
+			name:  "not_https",
+			given: "http://account.brave.com/",
+		},
+
+		{
+			name:  "userinfo",
+			given: "https://account.brave.com@evil.example.org/",
+		},
+
+		{
+			name:  "other_host",
+			given: "https://evil.example.org/",
+		},
+
+		{
+			name:  "suffix_host",
+			given: "https://evilaccount.brave.com/",
+		},
+
+		{
+			name:  "relative",
+			given: "/account",
+		},
+	}
+
+	cfg := &portalConfig{returnHosts: []string{"account.brave.com"}}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, cfg.isReturnURLAllowed(tc.given))
+		})
+	}
+}
+
+func TestService_setPortalOrderCancelAtPeriodEnd(t *testing.T) {
+	type tcGiven struct {
+		ord    *model.Order
+		sub    *stripe.Subscription
+		cancel bool
+	}
+
+	type tcExpected struct {
+		cancelAtPeriodEnd bool
+		updated           bool
+		err               error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	newOrder := func(status string, mdata datastore.Metadata) *model.Order {
+		return &model.Order{
+			ID:       uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+			Status:   status,
+			Metadata: mdata,
+		}
+	}
+
+	tests := []testCase{
+		{
+			name: "not_stripe",
+			given: tcGiven{
+				ord:    newOrder(model.OrderStatusPaid, datastore.Metadata{"paymentProcessor": "ios"}),
+				cancel: true,
+			},
+			exp: tcExpected{err: model.ErrOrderNotFound},
+		},
+
+		{
+			name: "other_customer",
+			given: tcGiven{
+				ord: newOrder(model.OrderStatusPaid, datastore.Metadata{"stripeSubscriptionId": "sub_id"}),
+				sub: &stripe.Subscription{
+					ID:       "sub_id",
+					Status:   stripe.SubscriptionStatusActive,
+					Customer: &stripe.Customer{Email: "them@example.com"},
+				},
+				cancel: true,
+			},
+			exp: tcExpected{err: model.ErrOrderNotFound},
+		},
+
+		{
+			name: "canceled",
+			given: tcGiven{
+				ord: newOrder(model.OrderStatusCanceled, datastore.Metadata{"stripeSubscriptionId": "sub_id"}),
+				sub: &stripe.Subscription{
+					ID:       "sub_id",
+					Status:   stripe.SubscriptionStatusActive,
+					Customer: &stripe.Customer{Email: "you@example.com"},
+				},
+				cancel: true,
+			},
+			exp: tcExpected{err: model.ErrPortalOrderNotManageable},
+		},
+
+		{
+			name: "cancel",
+			given: tcGiven{
+				ord: newOrder(model.OrderStatusPaid, datastore.Metadata{"stripeSubscriptionId": "sub_id"}),
+				sub: &stripe.Subscription{
+					ID:       "sub_id",
+					Status:   stripe.SubscriptionStatusActive,
+					Customer: &stripe.Customer{Email: "You@Example.com"},
+				},
+				cancel: true,
+			},
+			exp: tcExpected{cancelAtPeriodEnd: true, updated: true},
+		},
+
+		{
+			name: "already_canceling",
+			given: tcGiven{
+				ord: newOrder(model.OrderStatusPaid, datastore.Metadata{"stripeSubscriptionId": "sub_id"}),
+				sub: &stripe.Subscription{
+					ID:                "sub_id",
+					Status:            stripe.SubscriptionStatusActive,
+					CancelAtPeriodEnd: true,
+					Customer:          &stripe.Customer{Email: "you@example.com"},
+				},
+				cancel: true,
+			},
+			exp: tcExpected{cancelAtPeriodEnd: true},
+		},
+
+		{
+			name: "resume",
+			given: tcGiven{
+				ord: newOrder(model.OrderStatusPaid, datastore.Metadata{"stripeSubscriptionId": "sub_id"}),
+				sub: &stripe.Subscription{
+					ID:                "sub_id",
+					Status:            stripe.SubscriptionStatusActive,
+					CancelAtPeriodEnd: true,
+					Customer:          &stripe.Customer{Email: "you@example.com"},
+				},
+			},
+			exp: tcExpected{updated: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var updated bool
+
+			svc := &Service{
+				orderRepo: &repository.MockOrder{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						return tc.given.ord, nil
+					},
+				},
+				orderItemRepo: &repository.MockOrderItem{},
+				stripeCl: &xstripe.MockClient{
+					FnSubscription: func(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+						return tc.given.sub, nil
+					},
+
+					FnUpdateSub: func(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+						updated = true
+
+						result := *tc.given.sub
+						result.CancelAtPeriodEnd = *params.CancelAtPeriodEnd
+
+						return &result, nil
+					},
+				},
+			}
+
+			actual, err := svc.setPortalOrderCancelAtPeriodEnd(context.Background(), nil, "you@example.com", tc.given.ord.ID, tc.given.cancel)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.updated, updated)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, tc.given.ord.ID, actual.ID)
+			should.Equal(t, tc.exp.cancelAtPeriodEnd, actual.CancelAtPeriodEnd)
+		})
+	}
+}
+
+func TestNewPortalOrder(t *testing.T) {
+	type tcGiven struct {
+		ord *model.Order
+		sub *stripe.Subscription
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   model.PortalOrder
+	}
+
+	next := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	ord := &model.Order{
+		ID:         uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+		Status:     model.OrderStatusPaid,
+		Currency:   "USD",
+		TotalPrice: decimal.RequireFromString("9.99"),
+		Items: []model.OrderItem{
+			{
+				SKU:         "brave-vpn-premium",
+				SKUVnt:      "brave-vpn-premium",
+				Description: datastore.NullString{NullString: sql.NullString{String: "Brave VPN", Valid: true}},
+				Quantity:    1,
+				Price:       decimal.RequireFromString("9.99"),
+			},
+		},
+	}
+
+	items := []model.PortalOrderItem{
+		{
+			SKU:         "brave-vpn-premium",
+			SKUVnt:      "brave-vpn-premium",
+			Description: "Brave VPN",
+			Quantity:    1,
+			Price:       decimal.RequireFromString("9.99"),
+		},
+	}
+
+	tests := []testCase{
+		{
+			name: "active_renewing",
+			given: tcGiven{
+				ord: ord,
+				sub: &stripe.Subscription{Status: stripe.SubscriptionStatusActive, CurrentPeriodEnd: next.Unix()},
+			},
+			exp: model.PortalOrder{
+				ID:            ord.ID,
+				Status:        model.OrderStatusPaid,
+				Currency:      "USD",
+				TotalPrice:    decimal.RequireFromString("9.99"),
+				NextBillingAt: &next,
+				Items:         items,
+			},
+		},
+
+		{
+			name: "cancel_at_period_end",
+			given: tcGiven{
+				ord: ord,
+				sub: &stripe.Subscription{Status: stripe.SubscriptionStatusActive, CancelAtPeriodEnd: true, CurrentPeriodEnd: next.Unix()},
+			},
+			exp: model.PortalOrder{
+				ID:                ord.ID,
+				Status:            model.OrderStatusPaid,
+				Currency:          "USD",
+				TotalPrice:        decimal.RequireFromString("9.99"),
+				CancelAtPeriodEnd: true,
+				Items:             items,
+			},
+		},
+
+		{
+			name: "canceled",
+			given: tcGiven{
+				ord: ord,
+				sub: &stripe.Subscription{Status: stripe.SubscriptionStatusCanceled, CurrentPeriodEnd: next.Unix()},
+			},
+			exp: model.PortalOrder{
+				ID:         ord.ID,
+				Status:     model.OrderStatusPaid,
+				Currency:   "USD",
+				TotalPrice: decimal.RequireFromString("9.99"),
+				Items:      items,
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := newPortalOrder(tc.given.ord, tc.given.sub)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package skus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/radom"
+)
+
+const errRadomUnknownAction = model.Error("skus: unknown radom action")
+
+type radomNotification struct {
+	*radom.Notification
+}
+
+func (x *radomNotification) shouldProcess() bool {
+	return x.ShouldProcess()
+}
+
+func (x *radomNotification) ntfType() string {
+	return x.NtfType()
+}
+
+func (x *radomNotification) effect() string {
+	return x.Effect()
+}
+
+// eventID returns an empty string as Radom does not assign ids to notifications.
+func (x *radomNotification) eventID() string {
+	return ""
+}
+
+func (x *radomNotification) orderKey() string {
+	subID, err := x.SubID()
+	if err != nil {
+		return ""
+	}
+
+	return subID.String()
+}
+
+type radomProcessor struct {
+	cl      radomClient
+	gateway *radom.Gateway
+	auth    radomMessageAuthenticator
+}
+
+func newRadomProcessor(cl radomClient, gateway *radom.Gateway, auth radomMessageAuthenticator) *radomProcessor {
+	return &radomProcessor{cl: cl, gateway: gateway, auth: auth}
+}
+
+func (p *radomProcessor) Name() string {
+	return model.RadomPaymentMethod
+}
+
+func (p *radomProcessor) CreateCheckoutSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, _ *model.Coupon) (*CheckoutSession, error) {
+	sessID, err := p.createSession(ctx, req, ord)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkout session: %w", err)
+	}
+
+	return &CheckoutSession{ID: sessID, MetadataKey: "radomCheckoutSessionId"}, nil
+}
+
+func (p *radomProcessor) createSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order) (string, error) {
+	oid := ord.ID.String()
+
+	surl, err := req.RadomMetadata.SuccessURL(oid)
+	if err != nil {
+		return "", err
+	}
+
+	curl, err := req.RadomMetadata.CancelURL(oid)
+	if err != nil {
+		return "", err
+	}
+
+	items, err := orderItemsToRadomLineItems(ord.Items)
+	if err != nil {
+		return "", err
+	}
+
+	reqx := &radom.CheckoutSessionRequest{
+		LineItems:  items,
+		Gateway:    p.gateway,
+		SuccessURL: surl,
+		CancelURL:  curl,
+		Metadata: []radom.Metadata{
+			{
+				Key:   "brave_order_id",
+				Value: oid,
+			},
+		},
+		ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+	}
+
+	resp, err := p.cl.CreateCheckoutSession(ctx, reqx)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.SessionID, nil
+}
+
+// AuthenticateNotification checks the verification key which Radom sends with notifications.
+func (p *radomProcessor) AuthenticateNotification(ctx context.Context, r *http.Request, body []byte) ([]byte, error) {
+	if err := p.auth.Authenticate(ctx, r.Header.Get("radom-verification-key")); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (p *radomProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	ntf, err := radom.ParseNotification(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &radomNotification{Notification: ntf}, nil
+}
+
+// ProcessNotification processes ntf.
+//
+// Plan changes are not supported.
+// Radom sends no notification for switching the product of a subscription,
+// so switching plans means cancelling the subscription and paying for a new order.
+func (p *radomProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*radomNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	switch {
+	case ntf.IsNewSub():
+		oid, err := ntf.OrderID()
+		if err != nil {
+			return err
+		}
+
+		subID, err := ntf.SubID()
+		if err != nil {
+			return err
+		}
+
+		sub, err := p.Subscription(ctx, subID.String())
+		if err != nil {
+			return err
+		}
+
+		expAt := sub.ExpiresAt.Add(24 * time.Hour)
+
+		if err := sm.renewOrderWithExpPaidTimeTx(ctx, dbi, oid, expAt, sub.LastPaidAt); err != nil {
+			return err
+		}
+
+		if err := sm.setOrderMetadataTx(ctx, dbi, oid, "externalID", subID.String()); err != nil {
+			return err
+		}
+
+		return sm.setOrderMetadataTx(ctx, dbi, oid, "paymentProcessor", model.RadomPaymentMethod)
+
+	case ntf.ShouldRenew():
+		subID, err := ntf.SubID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderByExternalIDTx(ctx, dbi, subID.String())
+		if err != nil {
+			return err
+		}
+
+		sub, err := p.Subscription(ctx, subID.String())
+		if err != nil {
+			return err
+		}
+
+		expAt := sub.ExpiresAt.Add(24 * time.Hour)
+
+		return sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expAt, sub.LastPaidAt)
+
+	case ntf.ShouldCancel():
+		subID, err := ntf.SubID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderByExternalIDTx(ctx, dbi, subID.String())
+		if err != nil {
+			return err
+		}
+
+		return sm.cancelOrderTx(ctx, dbi, ord.ID)
+
+	default:
+		return errRadomUnknownAction
+	}
+}
+
+// Subscription returns the subscription with the next billing date as its expiry.
+func (p *radomProcessor) Subscription(ctx context.Context, subID string) (*PaymentSubscription, error) {
+	rsub, err := p.cl.GetSubscription(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	nxtB, err := rsub.NextBillingDate()
+	if err != nil {
+		return nil, err
+	}
+
+	paidAt, err := rsub.LastPaid()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaymentSubscription{
+		ID:         subID,
+		ExpiresAt:  nxtB,
+		LastPaidAt: paidAt,
+	}
+
+	return result, nil
+}
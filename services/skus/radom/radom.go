@@ -36,7 +36,14 @@ type Method struct {
 }
 
 type LineItem struct {
-	ProductID string `json:"productId"`
+	ProductID string        `json:"productId"`
+	ItemData  *LineItemData `json:"itemData,omitempty"`
+}
+
+// LineItemData overrides the properties of the product for the line item.
+type LineItemData struct {
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
 }
 
 type Metadata struct {
@@ -0,0 +1,193 @@
+package skus
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+// seatInviteTokenLength is the number of random bytes in an invite token.
+const seatInviteTokenLength = 32
+
+// CreateOrderSeat creates a seat with a new invite token for the paid order.
+//
+// The order must have been created with seats.
+// Revoked seats do not count towards the number of seats the order has.
+func (s *Service) CreateOrderSeat(ctx context.Context, orderID uuid.UUID) (*model.OrderSeat, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := s.createOrderSeatTx(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// createOrderSeatTx creates a seat while holding a lock on the order.
+//
+// The lock serialises concurrent requests, so that they can't create more seats than the order has.
+func (s *Service) createOrderSeatTx(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) (*model.OrderSeat, error) {
+	ord, err := s.orderRepo.GetForUpdate(ctx, dbi, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ord.IsPaid() {
+		return nil, model.ErrOrderNotPaid
+	}
+
+	nmax := ord.NumSeats()
+	if nmax == 0 {
+		return nil, model.ErrOrderSeatsExhausted
+	}
+
+	token, err := randomString(seatInviteTokenLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.seatRepo.Create(ctx, dbi, ord.ID, token, nmax)
+}
+
+// ListOrderSeats returns all seats of the order.
+//
+// Invite tokens are only included for pending seats.
+func (s *Service) ListOrderSeats(ctx context.Context, orderID uuid.UUID) ([]model.OrderSeat, error) {
+	dbi := s.Datastore.RawDB()
+
+	if _, err := s.orderRepo.Get(ctx, dbi, orderID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.seatRepo.List(ctx, dbi, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		if result[i].Status != model.OrderSeatStatusPending {
+			result[i].InviteToken = ""
+		}
+	}
+
+	return result, nil
+}
+
+// RedeemOrderSeat activates the seat of the order identified by the invite token.
+//
+// An invite token can only be redeemed once.
+func (s *Service) RedeemOrderSeat(ctx context.Context, orderID uuid.UUID, inviteToken string) (*model.OrderSeat, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := s.redeemOrderSeatTx(ctx, tx, orderID, inviteToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Service) redeemOrderSeatTx(ctx context.Context, dbi sqlx.ExtContext, orderID uuid.UUID, inviteToken string, now time.Time) (*model.OrderSeat, error) {
+	seat, err := s.seatRepo.GetByInviteToken(ctx, dbi, inviteToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !uuid.Equal(seat.OrderID, orderID) {
+		return nil, model.ErrOrderSeatNotFound
+	}
+
+	switch seat.Status {
+	case model.OrderSeatStatusActive:
+		return nil, model.ErrOrderSeatAlreadyRedeemed
+
+	case model.OrderSeatStatusRevoked:
+		return nil, model.ErrOrderSeatNotActive
+	}
+
+	if err := s.seatRepo.Redeem(ctx, dbi, seat.ID, now); err != nil {
+		return nil, err
+	}
+
+	result, err := s.seatRepo.Get(ctx, dbi, seat.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.InviteToken = ""
+
+	return result, nil
+}
+
+// RevokeOrderSeat revokes the seat, and deletes all credentials redeemed via it.
+func (s *Service) RevokeOrderSeat(ctx context.Context, orderID, seatID uuid.UUID) error {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.revokeOrderSeatTx(ctx, tx, orderID, seatID, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Service) revokeOrderSeatTx(ctx context.Context, dbi sqlx.ExtContext, orderID, seatID uuid.UUID, now time.Time) error {
+	seat, err := s.seatRepo.Get(ctx, dbi, seatID)
+	if err != nil {
+		return err
+	}
+
+	if !uuid.Equal(seat.OrderID, orderID) {
+		return model.ErrOrderSeatNotFound
+	}
+
+	if err := s.seatRepo.Revoke(ctx, dbi, seat.ID, now); err != nil {
+		return err
+	}
+
+	return s.tlv2Repo.DeleteSeat(ctx, dbi, seat.ID)
+}
+
+// CreateOrderSeatCredentials creates credentials for the active seat of the order.
+//
+// Each seat has its own limit on the number of active batches.
+func (s *Service) CreateOrderSeatCredentials(ctx context.Context, orderID, seatID, itemID, requestID uuid.UUID, blindedCreds []string) error {
+	seat, err := s.seatRepo.Get(ctx, s.Datastore.RawDB(), seatID)
+	if err != nil {
+		return err
+	}
+
+	if !uuid.Equal(seat.OrderID, orderID) {
+		return model.ErrOrderSeatNotFound
+	}
+
+	if !seat.IsActive() {
+		return model.ErrOrderSeatNotActive
+	}
+
+	return s.createOrderItemCreds(ctx, orderID, itemID, requestID, seat.ID, blindedCreds)
+}
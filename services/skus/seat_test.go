@@ -0,0 +1,544 @@
+package skus
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/cors"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/datastore"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestService_createOrderSeatTx(t *testing.T) {
+	type tcGiven struct {
+		orepo *repository.MockOrder
+		srepo *repository.MockOrderSeat
+	}
+
+	type tcExpected struct {
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "order_not_found",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnGetForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						return nil, model.ErrOrderNotFound
+					},
+				},
+				srepo: &repository.MockOrderSeat{},
+			},
+			exp: tcExpected{err: model.ErrOrderNotFound},
+		},
+
+		{
+			name: "order_not_paid",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnGetForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Status:   model.OrderStatusPending,
+							Metadata: datastore.Metadata{"numSeats": 2},
+						}
+
+						return result, nil
+					},
+				},
+				srepo: &repository.MockOrderSeat{},
+			},
+			exp: tcExpected{err: model.ErrOrderNotPaid},
+		},
+
+		{
+			name: "no_seats",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnGetForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:     id,
+							Status: model.OrderStatusPaid,
+						}
+
+						return result, nil
+					},
+				},
+				srepo: &repository.MockOrderSeat{},
+			},
+			exp: tcExpected{err: model.ErrOrderSeatsExhausted},
+		},
+
+		{
+			name: "seats_exhausted",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnGetForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Status:   model.OrderStatusPaid,
+							Metadata: datastore.Metadata{"numSeats": 2},
+						}
+
+						return result, nil
+					},
+				},
+				srepo: &repository.MockOrderSeat{
+					FnCreate: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error) {
+						return nil, model.ErrOrderSeatsExhausted
+					},
+				},
+			},
+			exp: tcExpected{err: model.ErrOrderSeatsExhausted},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnGetForUpdate: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+						result := &model.Order{
+							ID:       id,
+							Status:   model.OrderStatusPaid,
+							Metadata: datastore.Metadata{"numSeats": 2},
+						}
+
+						return result, nil
+					},
+				},
+				srepo: &repository.MockOrderSeat{
+					FnCreate: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error) {
+						if nmax != 2 {
+							return nil, model.Error("unexpected_nmax")
+						}
+
+						if inviteToken == "" {
+							return nil, model.Error("unexpected_invite_token")
+						}
+
+						result := &model.OrderSeat{
+							ID:          uuid.NewV4(),
+							OrderID:     orderID,
+							InviteToken: inviteToken,
+							Status:      model.OrderSeatStatusPending,
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{orderRepo: tc.given.orepo, seatRepo: tc.given.srepo}
+
+			oid := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+
+			actual, err := svc.createOrderSeatTx(context.Background(), nil, oid)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, oid, actual.OrderID)
+			should.Equal(t, model.OrderSeatStatusPending, actual.Status)
+			should.NotEmpty(t, actual.InviteToken)
+		})
+	}
+}
+
+func TestService_redeemOrderSeatTx(t *testing.T) {
+	type tcGiven struct {
+		orderID uuid.UUID
+		srepo   *repository.MockOrderSeat
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "token_not_found",
+			given: tcGiven{
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				srepo: &repository.MockOrderSeat{
+					FnGetByInviteToken: func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+						return nil, model.ErrOrderSeatNotFound
+					},
+				},
+			},
+			exp: model.ErrOrderSeatNotFound,
+		},
+
+		{
+			name: "another_order",
+			given: tcGiven{
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				srepo: &repository.MockOrderSeat{
+					FnGetByInviteToken: func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      uuid.NewV4(),
+							OrderID: uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusPending,
+						}
+
+						return result, nil
+					},
+				},
+			},
+			exp: model.ErrOrderSeatNotFound,
+		},
+
+		{
+			name: "already_redeemed",
+			given: tcGiven{
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				srepo: &repository.MockOrderSeat{
+					FnGetByInviteToken: func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      uuid.NewV4(),
+							OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusActive,
+						}
+
+						return result, nil
+					},
+				},
+			},
+			exp: model.ErrOrderSeatAlreadyRedeemed,
+		},
+
+		{
+			name: "revoked",
+			given: tcGiven{
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				srepo: &repository.MockOrderSeat{
+					FnGetByInviteToken: func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      uuid.NewV4(),
+							OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusRevoked,
+						}
+
+						return result, nil
+					},
+				},
+			},
+			exp: model.ErrOrderSeatNotActive,
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				orderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				srepo: &repository.MockOrderSeat{
+					FnGetByInviteToken: func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:          uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							InviteToken: inviteToken,
+							Status:      model.OrderSeatStatusPending,
+						}
+
+						return result, nil
+					},
+
+					FnRedeem: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if !uuid.Equal(id, uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_id")
+						}
+
+						return nil
+					},
+
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:          id,
+							OrderID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							InviteToken: "invite_token",
+							Status:      model.OrderSeatStatusActive,
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{seatRepo: tc.given.srepo}
+
+			now := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+			actual, err := svc.redeemOrderSeatTx(context.Background(), nil, tc.given.orderID, "invite_token", now)
+			must.ErrorIs(t, err, tc.exp)
+
+			if tc.exp != nil {
+				return
+			}
+
+			should.Equal(t, model.OrderSeatStatusActive, actual.Status)
+			should.Equal(t, "", actual.InviteToken)
+		})
+	}
+}
+
+func TestService_revokeOrderSeatTx(t *testing.T) {
+	type tcGiven struct {
+		srepo *repository.MockOrderSeat
+		trepo *repository.MockTLV2
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "seat_not_found",
+			given: tcGiven{
+				srepo: &repository.MockOrderSeat{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						return nil, model.ErrOrderSeatNotFound
+					},
+				},
+				trepo: &repository.MockTLV2{},
+			},
+			exp: model.ErrOrderSeatNotFound,
+		},
+
+		{
+			name: "another_order",
+			given: tcGiven{
+				srepo: &repository.MockOrderSeat{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      id,
+							OrderID: uuid.Must(uuid.FromString("decade00-0000-4000-a000-000000000000")),
+						}
+
+						return result, nil
+					},
+				},
+				trepo: &repository.MockTLV2{},
+			},
+			exp: model.ErrOrderSeatNotFound,
+		},
+
+		{
+			name: "already_revoked",
+			given: tcGiven{
+				srepo: &repository.MockOrderSeat{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      id,
+							OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusRevoked,
+						}
+
+						return result, nil
+					},
+
+					FnRevoke: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.ErrOrderSeatNotFound
+					},
+				},
+				trepo: &repository.MockTLV2{},
+			},
+			exp: model.ErrOrderSeatNotFound,
+		},
+
+		{
+			name: "delete_creds_error",
+			given: tcGiven{
+				srepo: &repository.MockOrderSeat{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      id,
+							OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusActive,
+						}
+
+						return result, nil
+					},
+				},
+				trepo: &repository.MockTLV2{
+					FnDeleteSeat: func(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				srepo: &repository.MockOrderSeat{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+						result := &model.OrderSeat{
+							ID:      id,
+							OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status:  model.OrderSeatStatusActive,
+						}
+
+						return result, nil
+					},
+				},
+				trepo: &repository.MockTLV2{
+					FnDeleteSeat: func(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error {
+						if !uuid.Equal(seatID, uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_seat_id")
+						}
+
+						return nil
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{seatRepo: tc.given.srepo, tlv2Repo: tc.given.trepo}
+
+			oid := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+			sid := uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))
+			now := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+			actual := svc.revokeOrderSeatTx(context.Background(), nil, oid, sid, now)
+			should.ErrorIs(t, actual, tc.exp)
+		})
+	}
+}
+
+func TestRouter_OrderSeatsRequireAuth(t *testing.T) {
+	authMwr := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+
+	metricsMwr := func(name string, h http.Handler) http.Handler {
+		return h
+	}
+
+	router := Router(&Service{}, authMwr, metricsMwr, cors.Options{})
+
+	const prefix = "/facade00-0000-4000-a000-000000000000/seats"
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodGet, path: prefix + "/"},
+		{method: http.MethodPost, path: prefix + "/"},
+		{method: http.MethodPost, path: prefix + "/redeem"},
+		{method: http.MethodDelete, path: prefix + "/decade00-0000-4000-a000-000000000000"},
+		{method: http.MethodPut, path: prefix + "/decade00-0000-4000-a000-000000000000/items/ad0be000-0000-4000-a000-000000000000/batches/request_id"},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.method+tc.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest(tc.method, tc.path, nil))
+
+			should.Equal(t, http.StatusUnauthorized, rw.Code)
+		})
+	}
+}
+
+func TestRouter_OrderSeatsOtherMerchant(t *testing.T) {
+	authMwr := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), merchantCtxKey{}, "other.com")
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	metricsMwr := func(name string, h http.Handler) http.Handler {
+		return h
+	}
+
+	dbi, _, err := sqlmock.New()
+	must.NoError(t, err)
+
+	svc := &Service{
+		Datastore: &Postgres{Postgres: datastore.Postgres{DB: sqlx.NewDb(dbi, "postgres")}},
+		orderRepo: &repository.MockOrder{
+			FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+				return &model.Order{ID: id, MerchantID: "brave.com", Status: model.OrderStatusPaid}, nil
+			},
+		},
+		seatRepo: &repository.MockOrderSeat{},
+	}
+
+	router := Router(svc, authMwr, metricsMwr, cors.Options{})
+
+	const prefix = "/facade00-0000-4000-a000-000000000000/seats"
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{method: http.MethodGet, path: prefix + "/"},
+		{method: http.MethodPost, path: prefix + "/"},
+		{method: http.MethodPost, path: prefix + "/redeem", body: `{"inviteToken": "invite_token"}`},
+		{method: http.MethodDelete, path: prefix + "/decade00-0000-4000-a000-000000000000"},
+		{
+			method: http.MethodPut,
+			path:   prefix + "/decade00-0000-4000-a000-000000000000/items/ad0be000-0000-4000-a000-000000000000/batches/f100ded0-0000-4000-a000-000000000000",
+			body:   `{"blindedCreds": ["Y3JlZF8wMQ=="]}`,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.method+tc.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			router.ServeHTTP(rw, httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body)))
+
+			should.Equal(t, http.StatusForbidden, rw.Code)
+		})
+	}
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/brave-intl/bat-go/services/wallet"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/paypal"
 	"github.com/brave-intl/bat-go/services/skus/radom"
 	"github.com/brave-intl/bat-go/services/skus/xstripe"
 )
@@ -52,6 +53,11 @@ import (
 var (
 	voteTopic = os.Getenv("ENV") + ".payment.vote"
 
+	// spendTopicPrefix is the prefix of topics of spend channels.
+	//
+	// Spend events are only published to topics under it.
+	spendTopicPrefix = os.Getenv("ENV") + ".payment.spend."
+
 	// TODO address in kafka refactor. Check topics are correct
 	// kafka topic for requesting order credentials are signed, write to by sku service
 	kafkaUnsignedOrderCredsTopic = os.Getenv("GRANT_CBP_SIGN_PRODUCER_TOPIC")
@@ -60,6 +66,9 @@ var (
 	kafkaSignedOrderCredsTopic      = os.Getenv("GRANT_CBP_SIGN_CONSUMER_TOPIC")
 	kafkaSignedOrderCredsDLQTopic   = os.Getenv("GRANT_CBP_SIGN_CONSUMER_TOPIC_DLQ")
 	kafkaSignedRequestReaderGroupID = os.Getenv("KAFKA_CONSUMER_GROUP_SIGNED_ORDER_CREDENTIALS")
+
+	// kafka topic which receives order lifecycle events, written to by sku service
+	kafkaOrderEventsTopic = os.Getenv("SKUS_ORDER_EVENTS_TOPIC")
 )
 
 const (
@@ -82,6 +91,11 @@ const (
 	timeLimited   = "time-limited"
 	timeLimitedV2 = "time-limited-v2"
 
+	// Offsets of signed order credentials are committed to Kafka in batches.
+	// Messages replayed after a restart are skipped using the offsets recorded in the database.
+	signedCredsCommitBatchSize = 100
+	signedCredsCommitInterval  = 5 * time.Second
+
 	errSetRetryAfter             = model.Error("set retry-after")
 	errClosingResource           = model.Error("error closing resource")
 	errGeminiClientNotConfigured = model.Error("service: gemini client not configured")
@@ -93,6 +107,7 @@ const (
 type orderStoreSvc interface {
 	Create(ctx context.Context, dbi sqlx.QueryerContext, oreq *model.OrderNew) (*model.Order, error)
 	Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
+	GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
 	GetByExternalID(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error)
 	SetStatus(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error
 	SetExpiresAt(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
@@ -103,12 +118,80 @@ type orderStoreSvc interface {
 	AppendMetadataInt64(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key string, val int64) error
 	GetExpiredStripeCheckoutSessionID(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) (string, error)
 	IncrementNumPayFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	SetTotalPrice(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, price decimal.Decimal) error
 }
 
 type tlv2Store interface {
 	GetCredSubmissionReport(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID, reqID uuid.UUID, firstBCred string) (model.TLV2CredSubmissionReport, error)
 	UniqBatches(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID uuid.UUID, from, to time.Time) (int, error)
 	DeleteLegacy(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID) error
+	DeleteValidAfter(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error
+	UniqSeatBatches(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error)
+	DeleteSeat(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error
+	DeleteIssuerValidAfter(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, from time.Time) (int64, error)
+}
+
+type orderSeatStore interface {
+	Create(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error)
+	Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error)
+	GetByInviteToken(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error)
+	List(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderSeat, error)
+	Redeem(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	Revoke(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+}
+
+type couponStore interface {
+	Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.CreateCouponRequest) (*model.Coupon, error)
+	GetByCode(ctx context.Context, dbi sqlx.QueryerContext, code string) (*model.Coupon, error)
+	RedeemForOrder(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, now time.Time) error
+}
+
+type orderEventStore interface {
+	Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error
+	InsertPaid(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error
+	InsertExpired(ctx context.Context, dbi sqlx.ExecerContext, from, to time.Time, limit int) (int64, error)
+	GetUnsentForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]model.OrderEvent, error)
+	MarkSent(ctx context.Context, dbi sqlx.ExecerContext, ids []uuid.UUID, when time.Time) error
+}
+
+type orderDunningStore interface {
+	Start(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error)
+	GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error)
+	Remind(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error
+	Resolve(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error
+	Cancel(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error
+}
+
+type stripeSubCancelStore interface {
+	Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error
+	GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error)
+	MarkCanceled(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error
+}
+
+type consumerOffsetStore interface {
+	GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string, partition int) (int64, error)
+	Set(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, partition int, offset int64, when time.Time) error
+	IsPaused(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string) (bool, error)
+	SetPaused(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, paused bool, when time.Time) error
+}
+
+type kafkaMessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type transactionStore interface {
+	Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error)
+}
+
+type webhookInboxStore interface {
+	Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error)
+	Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.WebhookInboxEntry, error)
+	ClaimNext(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.WebhookInboxEntry, error)
+	MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	MarkFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string, nextAt time.Time) error
+	MarkDead(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string) error
+	Reset(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
 }
 
 type vendorReceiptValidator interface {
@@ -125,7 +208,13 @@ type stripeClient interface {
 	Session(ctx context.Context, id string, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
 	CreateSession(ctx context.Context, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
 	Subscription(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	Charge(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error)
 	FindCustomer(ctx context.Context, email string) (*stripe.Customer, bool)
+	CancelSubscription(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error)
+	UpdateSubscription(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	CustomerSubscriptions(ctx context.Context, custID string) ([]*stripe.Subscription, error)
+	FindCustomers(ctx context.Context, email string) ([]*stripe.Customer, error)
+	CreateBillingPortalSession(ctx context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error)
 }
 
 // Service contains datastore
@@ -138,12 +227,35 @@ type radomMessageAuthenticator interface {
 	Authenticate(ctx context.Context, token string) error
 }
 
+type payPalClient interface {
+	CreateSubscription(ctx context.Context, sreq *paypal.CreateSubscriptionRequest) (*paypal.Subscription, error)
+	GetSubscription(ctx context.Context, subID string) (*paypal.Subscription, error)
+	CancelSubscription(ctx context.Context, subID, reason string) error
+	VerifyWebhookSignature(ctx context.Context, vreq *paypal.VerifyWebhookSignatureRequest) error
+}
+
 type Service struct {
 	orderRepo     orderStoreSvc
 	orderItemRepo orderItemStore
 	issuerRepo    issuerStore
 	payHistRepo   orderPayHistoryStore
 	tlv2Repo      tlv2Store
+	txnRepo       transactionStore
+	seatRepo      orderSeatStore
+	couponRepo    couponStore
+	orderEvRepo   orderEventStore
+	orderDunRepo  orderDunningStore
+	subCancelRepo stripeSubCancelStore
+	skuPriceRepo  skuPriceStore
+	portalRepo    portalStore
+	merchKeyRepo  merchantKeyStore
+	merchHookRepo merchantWebhookStore
+	spendRepo     spendStore
+	offsetRepo    consumerOffsetStore
+	issuerKeyRepo issuerKeyStore
+	tlv1MigRepo   tlv1MigrationStore
+
+	webhookInboxRepo webhookInboxStore
 
 	// TODO: Eventually remove it.
 	Datastore Datastore
@@ -156,21 +268,31 @@ type Service struct {
 	codecs           map[string]*goavro.Codec
 	kafkaWriter      *kafka.Writer
 	kafkaDialer      *kafka.Dialer
+	orderEvWriter    kafkaMessageWriter
+	spendWriter      kafkaMessageWriter
 	jobs             []srv.Job
 	pauseVoteUntil   time.Time
 	pauseVoteUntilMu sync.RWMutex
 	retry            backoff.RetryFunc
 
-	radomClient  radomClient
-	radomGateway *radom.Gateway
-	radomAuth    radomMessageAuthenticator
-
 	vendorReceiptValid vendorReceiptValidator
-	gpsAuth            gpsMessageAuthenticator
-	assnCertVrf        *assnCertVerifier
 
-	payProcCfg    *premiumPaymentProcConfig
-	newItemReqSet map[string]model.OrderItemRequestNew
+	payProcs   paymentProcessors
+	payProcCfg *premiumPaymentProcConfig
+	catalog    *skuCatalog
+	dunningCfg *dunningConfig
+
+	portalCfg    *portalConfig
+	portalSender portalCodeSender
+
+	merchHookClient *http.Client
+
+	merchKeyCfg *merchantKeyConfig
+	keyUses     *keyUseBuffer
+
+	tlv1Presented *tlv1PresentationBuffer
+
+	signedCredsCtl *kafkautils.ConsumerControl
 }
 
 // PauseWorker - pause worker until time specified
@@ -204,6 +326,8 @@ func (s *Service) InitKafka(ctx context.Context) error {
 
 	s.codecs, err = kafkautils.GenerateCodecs(map[string]string{
 		"vote":                       voteSchema,
+		"spendEvent":                 spendEventSchema,
+		"orderEvent":                 orderEventSchema,
 		kafkaUnsignedOrderCredsTopic: signingOrderRequestSchema,
 		kafkaSignedOrderCredsTopic:   signingOrderResultSchema,
 	})
@@ -211,6 +335,10 @@ func (s *Service) InitKafka(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to generate codecs kafka: %w", err)
 	}
+
+	s.orderEvWriter = s.kafkaWriter
+	s.spendWriter = s.kafkaWriter
+
 	return nil
 }
 
@@ -224,6 +352,22 @@ func InitService(
 	issuerRepo issuerStore,
 	payHistRepo orderPayHistoryStore,
 	tlv2repo tlv2Store,
+	webhookInboxRepo webhookInboxStore,
+	txnRepo transactionStore,
+	seatRepo orderSeatStore,
+	couponRepo couponStore,
+	orderEvRepo orderEventStore,
+	orderDunRepo orderDunningStore,
+	subCancelRepo stripeSubCancelStore,
+	skuCatalogRepo skuCatalogStore,
+	skuPriceRepo skuPriceStore,
+	portalRepo portalStore,
+	merchKeyRepo merchantKeyStore,
+	merchHookRepo merchantWebhookStore,
+	spendRepo spendStore,
+	offsetRepo consumerOffsetStore,
+	issuerKeyRepo issuerKeyStore,
+	tlv1MigRepo tlv1MigrationStore,
 ) (*Service, error) {
 	lg := logging.Logger(ctx, "payments").With().Str("func", "InitService").Logger()
 
@@ -362,12 +506,87 @@ func InitService(
 		disabled: disabled,
 	}
 
+	dunningCfg, err := newDunningConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	merchKeyCfg, err := newMerchantKeyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	portalCfg, err := newPortalConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	portalSender, err := newPortalCodeSender(env)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := newSKUCatalog(skuCatalogRepo, env)
+	stripeCl := xstripe.NewClient(scClient)
+
+	// The order of processors is the order of preference for checkout.
+	payProcs := paymentProcessors{
+		newStripeProcessor(stripeCl, catalog),
+		newRadomProcessor(radomCl, radomGateway, radomAuth),
+	}
+
+	if enabled, _ := strconv.ParseBool(os.Getenv("PAYPAL_ENABLED")); enabled {
+		srvURL := os.Getenv("PAYPAL_SERVER")
+		if srvURL == "" {
+			return nil, model.Error("skus: invalid paypal url")
+		}
+
+		clientID, secret := os.Getenv("PAYPAL_CLIENT_ID"), os.Getenv("PAYPAL_SECRET")
+		if clientID == "" || secret == "" {
+			return nil, model.Error("skus: paypal credentials not found")
+		}
+
+		webhookID := os.Getenv("PAYPAL_WEBHOOK_ID")
+		if webhookID == "" {
+			return nil, model.Error("skus: paypal webhook id not found")
+		}
+
+		payPalCl, err := paypal.New(srvURL, clientID, secret)
+		if err != nil {
+			return nil, err
+		}
+
+		payProcs = append(payProcs, newPayPalProcessor(payPalCl, webhookID))
+	}
+
+	payProcs = append(
+		payProcs,
+		newPlayStoreProcessor(newGPSNtfAuthenticator(gpsCfg, idv), rcptValidator, catalog),
+		newAppStoreProcessor(assnCertVrf, catalog),
+	)
+
 	service := &Service{
 		orderRepo:     orderRepo,
 		orderItemRepo: orderItemRepo,
 		issuerRepo:    issuerRepo,
 		payHistRepo:   payHistRepo,
 		tlv2Repo:      tlv2repo,
+		txnRepo:       txnRepo,
+		seatRepo:      seatRepo,
+		couponRepo:    couponRepo,
+		orderEvRepo:   orderEvRepo,
+		orderDunRepo:  orderDunRepo,
+		subCancelRepo: subCancelRepo,
+		skuPriceRepo:  skuPriceRepo,
+		portalRepo:    portalRepo,
+		merchKeyRepo:  merchKeyRepo,
+		merchHookRepo: merchHookRepo,
+		spendRepo:     spendRepo,
+		offsetRepo:    offsetRepo,
+		issuerKeyRepo: issuerKeyRepo,
+		tlv1MigRepo:   tlv1MigRepo,
+
+		webhookInboxRepo: webhookInboxRepo,
 
 		Datastore: datastore,
 
@@ -375,20 +594,28 @@ func InitService(
 		geminiClient:     geminiClient,
 		geminiConf:       geminiConf,
 		cbClient:         cbClient,
-		stripeCl:         xstripe.NewClient(scClient),
+		stripeCl:         stripeCl,
 		pauseVoteUntilMu: sync.RWMutex{},
 		retry:            backoff.Retry,
 
-		radomClient:  radomCl,
-		radomGateway: radomGateway,
-		radomAuth:    radomAuth,
-
 		vendorReceiptValid: rcptValidator,
-		gpsAuth:            newGPSNtfAuthenticator(gpsCfg, idv),
-		assnCertVrf:        assnCertVrf,
 
-		payProcCfg:    newPaymentProcessorConfig(env),
-		newItemReqSet: newOrderItemReqNewMobileSet(env),
+		payProcs:   payProcs,
+		payProcCfg: newPaymentProcessorConfig(env),
+		catalog:    catalog,
+		dunningCfg: dunningCfg,
+
+		portalCfg:    portalCfg,
+		portalSender: portalSender,
+
+		merchHookClient: newMerchantWebhookClient(),
+
+		merchKeyCfg: merchKeyCfg,
+		keyUses:     newKeyUseBuffer(keyUseBufferSize),
+
+		tlv1Presented: newTLV1PresentationBuffer(),
+
+		signedCredsCtl: kafkautils.NewConsumerControl(kafkaSignedOrderCredsTopic),
 	}
 
 	service.jobs = []srv.Job{
@@ -397,11 +624,85 @@ func InitService(
 			Cadence: 2 * time.Second,
 			Workers: 1,
 		},
+		{
+			Func:    service.RunNextSpendDrainJob,
+			Cadence: 100 * time.Millisecond,
+			Workers: 1,
+		},
 		{
 			Func:    service.RunSendSigningRequestJob,
 			Cadence: 100 * time.Millisecond,
 			Workers: 1,
 		},
+		{
+			Func:    service.RunNextWebhookInboxJob,
+			Cadence: 100 * time.Millisecond,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunRecordExpiredOrdersJob,
+			Cadence: time.Minute,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunNextDunningJob,
+			Cadence: 5 * time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunNextStripeSubCancelJob,
+			Cadence: 5 * time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunCheckIssuerKeysJob,
+			Cadence: time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunQueueMerchantWebhooksJob,
+			Cadence: time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunDeliverMerchantWebhooksJob,
+			Cadence: time.Second,
+			Workers: 2,
+		},
+		{
+			Func:    service.RunFlushKeyUsesJob,
+			Cadence: time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunPruneKeyUsesJob,
+			Cadence: time.Hour,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunScheduledKeyRotationJob,
+			Cadence: 10 * time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunFlushTLV1PresentationsJob,
+			Cadence: 10 * time.Second,
+			Workers: 1,
+		},
+		{
+			Func:    service.RunSyncConsumerPauseJob,
+			Cadence: 5 * time.Second,
+			Workers: 1,
+		},
+	}
+
+	// Events are recorded regardless, and are published once the topic has been configured.
+	if kafkaOrderEventsTopic != "" {
+		service.jobs = append(service.jobs, srv.Job{
+			Func:    service.RunSendOrderEventsJob,
+			Cadence: time.Second,
+			Workers: 1,
+		})
 	}
 
 	if err := service.InitKafka(ctx); err != nil {
@@ -675,7 +976,7 @@ func (s *Service) updateOrderStripeSession(ctx context.Context, dbi sqlx.ExtCont
 	expt := time.Unix(sub.CurrentPeriodEnd, 0).UTC()
 	paidt := time.Unix(sub.CurrentPeriodStart, 0).UTC()
 
-	return s.renewOrderStripe(ctx, dbi, ord, sub.ID, expt, paidt)
+	return renewOrderStripe(ctx, dbi, s, ord, sub.ID, expt, paidt)
 }
 
 func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID) error {
@@ -693,15 +994,73 @@ func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID) error {
 }
 
 func (s *Service) cancelOrderTx(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
-	return s.orderRepo.SetStatus(ctx, dbi, id, model.OrderStatusCanceled)
+	now := time.Now()
+
+	if err := s.orderRepo.SetStatus(ctx, dbi, id, model.OrderStatusCanceled); err != nil {
+		return err
+	}
+
+	if err := s.orderDunRepo.Cancel(ctx, dbi, id, now); err != nil {
+		return err
+	}
+
+	return s.orderEvRepo.Insert(ctx, dbi, id, model.OrderEventCanceled, now)
+}
+
+// refundOrderTx records the refund described by req, and revokes access granted by the order if the refund is full.
+//
+// On a full refund, the order moves to the refunded status, expires at now, and time-limited-v2 credentials valid
+// after now are deleted. A partial refund is only recorded.
+// A refund which has already been recorded is ignored.
+func (s *Service) refundOrderTx(ctx context.Context, dbi sqlx.ExtContext, req model.TransactionNew, full bool, now time.Time) error {
+	if _, err := s.txnRepo.Insert(ctx, dbi, req); err != nil {
+		if errors.Is(err, model.ErrTransactionAlreadyExists) {
+			return nil
+		}
+
+		return err
+	}
+
+	if !full {
+		return nil
+	}
+
+	if err := s.orderRepo.SetStatus(ctx, dbi, req.OrderID, model.OrderStatusRefunded); err != nil {
+		return err
+	}
+
+	if err := s.orderRepo.SetExpiresAt(ctx, dbi, req.OrderID, now); err != nil {
+		return err
+	}
+
+	if err := s.orderDunRepo.Cancel(ctx, dbi, req.OrderID, now); err != nil {
+		return err
+	}
+
+	return s.tlv2Repo.DeleteValidAfter(ctx, dbi, req.OrderID, now)
+}
+
+// newOrderRefundTxn returns a refund transaction for the full price of ord.
+//
+// It's used for the Play Store, which reports neither the amount nor the currency of a refund.
+// Only full refunds of subscription purchases are processed, and a purchase pays for one period at the order's price.
+func newOrderRefundTxn(ord *model.Order, extID string) model.TransactionNew {
+	return model.TransactionNew{
+		OrderID:               ord.ID,
+		ExternalTransactionID: extID,
+		Status:                model.TransactionStatusCompleted,
+		Currency:              ord.Currency,
+		Kind:                  model.TransactionKindRefund,
+		Amount:                ord.TotalPrice.Neg(),
+	}
 }
 
 func (s *Service) resetNumPaymentFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
 	return s.orderRepo.AppendMetadataInt(ctx, dbi, id, "numPaymentFailed", 0)
 }
 
-// CancelOrderLegacy cancels an order, propagates to stripe if needed.
-func (s *Service) CancelOrderLegacy(orderID uuid.UUID) error {
+// CancelOrderLegacy cancels an order, propagates to stripe or paypal if needed.
+func (s *Service) CancelOrderLegacy(ctx context.Context, orderID uuid.UUID) error {
 	// TODO: Refactor this later. Now here's a quick fix.
 	ord, err := s.Datastore.GetOrder(orderID)
 	if err != nil {
@@ -726,6 +1085,14 @@ func (s *Service) CancelOrderLegacy(orderID uuid.UUID) error {
 		return s.Datastore.UpdateOrder(orderID, OrderStatusCanceled)
 	}
 
+	if subID, ok := ord.PayPalSubID(); ok && subID != "" {
+		if err := s.cancelPayPalSub(ctx, subID); err != nil {
+			return fmt.Errorf("failed to cancel paypal subscription: %w", err)
+		}
+
+		return s.Datastore.UpdateOrder(orderID, OrderStatusCanceled)
+	}
+
 	if ord.IsIOS() || ord.IsAndroid() {
 		return s.Datastore.UpdateOrder(orderID, OrderStatusCanceled)
 	}
@@ -734,8 +1101,6 @@ func (s *Service) CancelOrderLegacy(orderID uuid.UUID) error {
 	params := &stripe.SubscriptionSearchParams{}
 	params.Query = fmt.Sprintf("status:'active' AND metadata['orderID']:'%s'", orderID.String())
 
-	ctx := context.TODO()
-
 	iter := sub.Search(params)
 	for iter.Next() {
 		sb := iter.Subscription()
@@ -756,6 +1121,20 @@ func (s *Service) CancelOrderLegacy(orderID uuid.UUID) error {
 	return s.Datastore.UpdateOrder(orderID, OrderStatusCanceled)
 }
 
+func (s *Service) cancelPayPalSub(ctx context.Context, subID string) error {
+	proc, ok := s.payProcs.get(model.PayPalPaymentMethod)
+	if !ok {
+		return errPayPalNotConfigured
+	}
+
+	pp, ok := proc.(*payPalProcessor)
+	if !ok {
+		return errPayPalNotConfigured
+	}
+
+	return pp.CancelSubscription(ctx, subID)
+}
+
 func (s *Service) setOrderTrialDays(ctx context.Context, orderID uuid.UUID, req *model.SetTrialDaysRequest, now time.Time) error {
 	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
 	if err != nil {
@@ -1145,6 +1524,15 @@ func (s *Service) uniqBatchesTxTime(ctx context.Context, dbi sqlx.QueryerContext
 		}
 	}
 
+	mig, err := s.tlv1Migration(ctx, dbi, ord.MerchantID, item)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if mig != nil {
+		item = migratedTLV1Item(item, mig)
+	}
+
 	if item.CredentialType != timeLimitedV2 {
 		return 0, 0, model.ErrUnsupportedCredType
 	}
@@ -1177,7 +1565,7 @@ func (s *Service) GetItemCredentials(ctx context.Context, orderID, itemID, reqID
 	case singleUse:
 		return s.GetSingleUseCreds(ctx, order.ID, itemID, reqID)
 	case timeLimited:
-		return s.GetTimeLimitedCreds(ctx, order, itemID, reqID)
+		return s.getTimeLimitedItemCreds(ctx, order, item, reqID)
 	case timeLimitedV2:
 		return s.GetTimeLimitedV2Creds(ctx, order.ID, itemID, reqID)
 	default:
@@ -1209,7 +1597,7 @@ func (s *Service) GetCredentials(ctx context.Context, orderID uuid.UUID) (interf
 	case singleUse:
 		return s.GetSingleUseCreds(ctx, order.ID, itemID, itemID)
 	case timeLimited:
-		return s.GetTimeLimitedCreds(ctx, order, itemID, itemID)
+		return s.getTimeLimitedItemCreds(ctx, order, &order.Items[0], itemID)
 	case timeLimitedV2:
 		return s.GetTimeLimitedV2Creds(ctx, order.ID, itemID, itemID)
 	default:
@@ -1489,6 +1877,23 @@ type credential interface {
 
 // verifyCredential - given a credential, verify it.
 func (s *Service) verifyCredential(ctx context.Context, cred credential, w http.ResponseWriter) *handlers.AppError {
+	if aerr := checkCredentialAuth(ctx, cred); aerr != nil {
+		return aerr
+	}
+
+	kind := cred.GetType()
+	switch kind {
+	case singleUse, timeLimitedV2:
+		return s.verifyBlindedTokenCredential(ctx, cred, w)
+	case timeLimited:
+		return s.verifyTimeLimitedV1Credential(ctx, cred, w)
+	default:
+		return handlers.WrapError(nil, "Unknown credential type", http.StatusBadRequest)
+	}
+}
+
+// checkCredentialAuth ensures that the credential belongs to the authenticated merchant, and satisfies the caveats.
+func checkCredentialAuth(ctx context.Context, cred credential) *handlers.AppError {
 	logger := logging.Logger(ctx, "verifyCredential")
 
 	merchant, err := merchantFromCtx(ctx)
@@ -1513,47 +1918,62 @@ func (s *Service) verifyCredential(ctx context.Context, cred credential, w http.
 		}
 	}
 
-	kind := cred.GetType()
-	switch kind {
-	case singleUse, timeLimitedV2:
-		return s.verifyBlindedTokenCredential(ctx, cred, w)
-	case timeLimited:
-		return s.verifyTimeLimitedV1Credential(ctx, cred, w)
-	default:
-		return handlers.WrapError(nil, "Unknown credential type", http.StatusBadRequest)
-	}
+	return nil
 }
 
 // verifyBlindedTokenCredential verifies a single use or time limited v2 credential.
 func (s *Service) verifyBlindedTokenCredential(ctx context.Context, req credential, w http.ResponseWriter) *handlers.AppError {
+	decodedCred, aerr := decodeBlindedCred(ctx, req)
+	if aerr != nil {
+		return aerr
+	}
+
+	return s.redeemBlindedCred(ctx, w, req.GetType(), decodedCred)
+}
+
+// decodeBlindedCred decodes the presentation of a single use or time limited v2 credential.
+func decodeBlindedCred(ctx context.Context, req credential) (*cbr.CredentialRedemption, *handlers.AppError) {
 	bytes, err := base64.StdEncoding.DecodeString(req.GetPresentation())
 	if err != nil {
-		return handlers.WrapError(err, "Error in decoding presentation", http.StatusBadRequest)
+		return nil, handlers.WrapError(err, "Error in decoding presentation", http.StatusBadRequest)
 	}
 
 	decodedCred := &cbr.CredentialRedemption{}
 	if err := json.Unmarshal(bytes, decodedCred); err != nil {
-		return handlers.WrapError(err, "Error in presentation formatting", http.StatusBadRequest)
+		return nil, handlers.WrapError(err, "Error in presentation formatting", http.StatusBadRequest)
 	}
 
 	// Ensure that the credential being redeemed (opaque to merchant) matches the outer credential details.
 	issuerID, err := encodeIssuerID(req.GetMerchantID(), req.GetSKU())
 	if err != nil {
-		return handlers.WrapError(err, "Error in outer merchantId or sku", http.StatusBadRequest)
+		return nil, handlers.WrapError(err, "Error in outer merchantId or sku", http.StatusBadRequest)
 	}
 
 	if issuerID != decodedCred.Issuer {
 		lg := logging.Logger(ctx, "skus").With().Str("func", "verifyBlindedTokenCredential").Logger()
 		lg.Err(model.Error("tlv2 issuer mismatch")).Str("issuer_id", issuerID).Str("decoded_issuer", decodedCred.Issuer).Msg("tlv2 issuer mismatch")
 
-		return handlers.WrapError(nil, "Error, outer merchant and sku don't match issuer", http.StatusBadRequest)
+		return nil, handlers.WrapError(nil, "Error, outer merchant and sku don't match issuer", http.StatusBadRequest)
 	}
 
-	return s.redeemBlindedCred(ctx, w, req.GetType(), decodedCred)
+	return decodedCred, nil
 }
 
 // verifyTimeLimitedV1Credential verifies a time limited v1 credential.
 func (s *Service) verifyTimeLimitedV1Credential(ctx context.Context, req credential, w http.ResponseWriter) *handlers.AppError {
+	if aerr := s.checkTimeLimitedV1Credential(ctx, req); aerr != nil {
+		return aerr
+	}
+
+	return handlers.RenderContent(ctx, "Credentials successfully verified", w, http.StatusOK)
+}
+
+// checkTimeLimitedV1Credential returns nil if the time limited v1 credential is valid.
+func (s *Service) checkTimeLimitedV1Credential(ctx context.Context, req credential) *handlers.AppError {
+	if aerr := s.checkTLV1Accepted(ctx, req); aerr != nil {
+		return aerr
+	}
+
 	data, err := base64.StdEncoding.DecodeString(req.GetPresentation())
 	if err != nil {
 		return handlers.WrapError(err, "Error in decoding presentation", http.StatusBadRequest)
@@ -1602,7 +2022,10 @@ func (s *Service) verifyTimeLimitedV1Credential(ctx context.Context, req credent
 				return handlers.WrapError(nil, "Credentials are not valid", http.StatusForbidden)
 			}
 
-			return handlers.RenderContent(ctx, "Credentials successfully verified", w, http.StatusOK)
+			// Only valid presentations count towards usage.
+			s.tlv1Presented.add(merchID, req.GetSKU(), now.UTC())
+
+			return nil
 		}
 	}
 
@@ -1614,8 +2037,6 @@ func (s *Service) RunSendSigningRequestJob(ctx context.Context) (bool, error) {
 	return true, s.Datastore.SendSigningRequest(ctx, s)
 }
 
-// TODO: Address in kafka refactor
-
 // RunStoreSignedOrderCredentials starts a signed order credentials consumer.
 // This function creates a new signed order credentials consumer and starts processing messages.
 // If the consumers errors we backoff, close the reader and restarts the consumer.
@@ -1627,9 +2048,13 @@ func (s *Service) RunStoreSignedOrderCredentials(ctx context.Context, backoff ti
 	}
 
 	handler := &SignedOrderCredentialsHandler{
-		decoder:   decoder,
-		datastore: s.Datastore,
-		tlv2Repo:  s.tlv2Repo,
+		decoder:     decoder,
+		datastore:   s.Datastore,
+		tlv2Repo:    s.tlv2Repo,
+		seatRepo:    s.seatRepo,
+		orderEvRepo: s.orderEvRepo,
+		offsetRepo:  s.offsetRepo,
+		groupID:     kafkaSignedRequestReaderGroupID,
 	}
 
 	errorHandler := &SigningOrderResultErrorHandler{
@@ -1651,7 +2076,13 @@ func (s *Service) RunStoreSignedOrderCredentials(ctx context.Context, backoff ti
 			}
 		}()
 
-		err = kafkautils.Consume(ctx, reader, handler, errorHandler)
+		opts := kafkautils.BatchOptions{
+			Size:     signedCredsCommitBatchSize,
+			Interval: signedCredsCommitInterval,
+			Control:  s.signedCredsCtl,
+		}
+
+		err = kafkautils.ConsumeBatch(ctx, reader, handler, errorHandler, opts)
 		if err != nil {
 			return fmt.Errorf("consumer error: %w", err)
 		}
@@ -1681,218 +2112,143 @@ func (s *Service) RunStoreSignedOrderCredentials(ctx context.Context, backoff ti
 	}
 }
 
-func (s *Service) processStripeNotification(ctx context.Context, ntf *stripeNotification) error {
-	if !ntf.shouldProcess() {
-		return nil
-	}
+// PauseSignedOrderCredsConsumer stops consumers of signed order credentials from reading messages.
+//
+// The state is stored for the consumer group, so that consumers on every instance pause, not only the one which
+// handled the request. Other instances pick it up with RunSyncConsumerPauseJob.
+func (s *Service) PauseSignedOrderCredsConsumer(ctx context.Context) error {
+	return s.setSignedOrderCredsConsumerPaused(ctx, true)
+}
 
-	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
+// ResumeSignedOrderCredsConsumer lets paused consumers of signed order credentials continue.
+func (s *Service) ResumeSignedOrderCredsConsumer(ctx context.Context) error {
+	return s.setSignedOrderCredsConsumerPaused(ctx, false)
+}
 
-	if err := s.processStripeNotificationTx(ctx, tx, ntf); err != nil {
+func (s *Service) setSignedOrderCredsConsumerPaused(ctx context.Context, paused bool) error {
+	if err := s.offsetRepo.SetPaused(ctx, s.Datastore.RawDB(), kafkaSignedRequestReaderGroupID, kafkaSignedOrderCredsTopic, paused, time.Now()); err != nil {
 		return err
 	}
 
-	return tx.Commit()
-}
+	s.applySignedOrderCredsConsumerPaused(paused)
 
-func (s *Service) processStripeNotificationTx(ctx context.Context, dbi sqlx.ExtContext, ntf *stripeNotification) error {
-	switch {
-	case ntf.shouldRenew():
-		subID, err := ntf.subID()
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		oid, err := ntf.orderID()
-		if err != nil {
-			return err
-		}
+func (s *Service) applySignedOrderCredsConsumerPaused(paused bool) {
+	if paused {
+		s.signedCredsCtl.Pause()
+		return
+	}
 
-		ord, err := s.orderRepo.Get(ctx, dbi, oid)
-		if err != nil {
-			return err
-		}
+	s.signedCredsCtl.Resume()
+}
 
-		expt, err := ntf.expiresTime()
-		if err != nil {
-			return err
-		}
+// RunSyncConsumerPauseJob applies the stored pause state to consumers of signed order credentials on this instance.
+func (s *Service) RunSyncConsumerPauseJob(ctx context.Context) (bool, error) {
+	paused, err := s.offsetRepo.IsPaused(ctx, s.Datastore.RawDB(), kafkaSignedRequestReaderGroupID, kafkaSignedOrderCredsTopic)
+	if err != nil {
+		return false, err
+	}
 
-		paidt := time.Now()
+	if paused != s.signedCredsCtl.IsPaused() {
+		s.applySignedOrderCredsConsumerPaused(paused)
+	}
 
-		return s.renewOrderStripe(ctx, dbi, ord, subID, expt, paidt)
+	return false, nil
+}
 
-	case ntf.shouldCancel():
-		oid, err := ntf.orderID()
-		if err != nil {
-			return err
-		}
+// SignedOrderCredsConsumerStatus returns the state of consumers of signed order credentials.
+//
+// Paused is the stored state of the consumer group, while Lag is as seen by consumers on this instance.
+func (s *Service) SignedOrderCredsConsumerStatus(ctx context.Context) (model.ConsumerStatus, error) {
+	paused, err := s.offsetRepo.IsPaused(ctx, s.Datastore.RawDB(), kafkaSignedRequestReaderGroupID, kafkaSignedOrderCredsTopic)
+	if err != nil {
+		return model.ConsumerStatus{}, err
+	}
 
-		// Reset numPaymentFailed.
-		if err := s.resetNumPaymentFailed(ctx, dbi, oid); err != nil {
-			return err
-		}
+	result := model.ConsumerStatus{
+		Topic:  kafkaSignedOrderCredsTopic,
+		Paused: paused,
+		Lag:    s.signedCredsCtl.Lag(),
+	}
 
-		return s.cancelOrderTx(ctx, dbi, oid)
+	return result, nil
+}
 
-	case ntf.shouldRecordPayFailure():
-		subID, err := ntf.subID()
-		if err != nil {
-			return err
-		}
-
-		oid, err := ntf.orderID()
-		if err != nil {
-			return err
-		}
-
-		ord, err := s.orderRepo.Get(ctx, dbi, oid)
-		if err != nil {
-			return err
-		}
+// validateReceipt validates receipt.
+func (s *Service) validateReceipt(ctx context.Context, req model.ReceiptRequest) (model.ReceiptData, error) {
+	switch req.Type {
+	case model.VendorApple:
+		return s.vendorReceiptValid.validateApple(ctx, req)
 
-		return s.recordPayFailureStripe(ctx, dbi, ord, subID)
+	case model.VendorGoogle:
+		return s.vendorReceiptValid.validateGoogle(ctx, req)
 
 	default:
-		return nil
+		return model.ReceiptData{}, model.ErrInvalidVendor
 	}
 }
 
-// processAppStoreNotification determines whether ntf is worth processing, and does it if it is.
+// CreateOrder creates a Premium order for the given req.
 //
-// More on ntf types https://developer.apple.com/documentation/appstoreservernotifications/notificationtype#4304524.
-func (s *Service) processAppStoreNotification(ctx context.Context, ntf *appStoreSrvNotification) error {
-	if !ntf.shouldProcess() {
-		return nil
-	}
-
-	txn, err := parseTxnInfo(ntf.pubKey, ntf.val.Data.SignedTransactionInfo)
-	if err != nil {
-		return err
-	}
-
-	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	if err := s.processAppStoreNotificationTx(ctx, tx, ntf, txn); err != nil {
-		return err
-	}
-
-	return tx.Commit()
-}
-
-func (s *Service) processAppStoreNotificationTx(ctx context.Context, dbi sqlx.ExtContext, ntf *appStoreSrvNotification, txn *appStoreTransaction) error {
-	ord, err := s.orderRepo.GetByExternalID(ctx, dbi, txn.OriginalTransactionId)
+// For AC and Search Captcha, see s.CreateOrderFromRequest.
+func (s *Service) CreateOrder(ctx context.Context, req *model.CreateOrderRequestNew) (*Order, error) {
+	items, err := createOrderItems(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	switch {
-	case ntf.shouldRenew():
-		expt := txn.expiresTime().Add(24 * time.Hour)
-		paidt := time.Now()
-
-		return s.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt)
-
-	case ntf.shouldCancel():
-		return s.cancelOrderTx(ctx, dbi, ord.ID)
-
-	default:
-		return nil
+	if err := s.applyPriceBook(ctx, s.Datastore.RawDB(), req.Currency, req.Country, items); err != nil {
+		return nil, err
 	}
-}
 
-func (s *Service) processPlayStoreNotification(ctx context.Context, ntf *playStoreDevNotification) error {
-	if !ntf.shouldProcess() {
-		return nil
-	}
+	if req.CouponCode != "" {
+		coupon, err := s.couponRepo.GetByCode(ctx, s.Datastore.RawDB(), req.CouponCode)
+		if err != nil {
+			return nil, err
+		}
 
-	extID, ok := ntf.purchaseToken()
-	if !ok {
-		return nil
+		if err := applyCoupon(coupon, req, items, time.Now()); err != nil {
+			return nil, err
+		}
 	}
 
-	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	ordNew, err := newOrderNewForReq(req, items, model.MerchID, model.OrderStatusPending)
 	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	if err := s.processPlayStoreNotificationTx(ctx, tx, ntf, extID); err != nil {
-		return err
+		return nil, err
 	}
 
-	return tx.Commit()
+	return s.createOrderPremium(ctx, req, ordNew, items)
 }
 
-func (s *Service) processPlayStoreNotificationTx(ctx context.Context, dbi sqlx.ExtContext, ntf *playStoreDevNotification, extID string) error {
-	ord, err := s.orderRepo.GetByExternalID(ctx, dbi, extID)
-	if err != nil {
+// applyCoupon discounts the subtotals of the items eligible for the coupon.
+//
+// Stripe applies its own coupon at checkout, so the coupon must map to one for Stripe-payable orders.
+func applyCoupon(coupon *model.Coupon, req *model.CreateOrderRequestNew, items []model.OrderItem, now time.Time) error {
+	if err := coupon.Validate(now, req.Currency); err != nil {
 		return err
 	}
 
-	switch {
-	// Renewal.
-	case ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldRenew():
-		sub, err := s.vendorReceiptValid.fetchSubPlayStore(ctx, ntf.PackageName, ntf.SubscriptionNtf.SubID, ntf.SubscriptionNtf.PurchaseToken)
-		if err != nil {
-			return err
-		}
-
-		expt := sub.expiresTime().Add(24 * time.Hour)
-		paidt := time.Now()
-
-		return s.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt)
-
-	// Sub cancellation.
-	case ntf.SubscriptionNtf != nil && ntf.SubscriptionNtf.shouldCancel():
-		return s.cancelOrderTx(ctx, dbi, ord.ID)
-
-	// Voiding.
-	case ntf.VoidedPurchaseNtf != nil && ntf.VoidedPurchaseNtf.shouldProcess():
-		return s.cancelOrderTx(ctx, dbi, ord.ID)
-
-	default:
-		return nil
+	if model.Slice[string](req.PaymentMethods).Contains(model.StripePaymentMethod) && coupon.StripeCouponID == nil {
+		return model.ErrCouponNoStripeCoupon
 	}
-}
-
-// validateReceipt validates receipt.
-func (s *Service) validateReceipt(ctx context.Context, req model.ReceiptRequest) (model.ReceiptData, error) {
-	switch req.Type {
-	case model.VendorApple:
-		return s.vendorReceiptValid.validateApple(ctx, req)
-
-	case model.VendorGoogle:
-		return s.vendorReceiptValid.validateGoogle(ctx, req)
 
-	default:
-		return model.ReceiptData{}, model.ErrInvalidVendor
-	}
-}
+	var napplied int
+	for i := range items {
+		if !coupon.IsEligible(items[i].SKUVnt) {
+			continue
+		}
 
-// CreateOrder creates a Premium order for the given req.
-//
-// For AC and Search Captcha, see s.CreateOrderFromRequest.
-func (s *Service) CreateOrder(ctx context.Context, req *model.CreateOrderRequestNew) (*Order, error) {
-	items, err := createOrderItems(req)
-	if err != nil {
-		return nil, err
+		items[i].Subtotal = coupon.Apply(items[i].Price).Mul(decimal.NewFromInt(int64(items[i].Quantity)))
+		napplied++
 	}
 
-	ordNew, err := newOrderNewForReq(req, items, model.MerchID, model.OrderStatusPending)
-	if err != nil {
-		return nil, err
+	if napplied == 0 {
+		return model.ErrCouponNotEligible
 	}
 
-	return s.createOrderPremium(ctx, req, ordNew, items)
+	return nil
 }
 
 func (s *Service) createOrderPremium(ctx context.Context, req *model.CreateOrderRequestNew, ordNew *model.OrderNew, items []model.OrderItem) (*model.Order, error) {
@@ -1912,6 +2268,15 @@ func (s *Service) createOrderPremium(ctx context.Context, req *model.CreateOrder
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
+	// The redemption is counted when the order is paid, see renewOrderWithExpPaidTimeTx.
+	var coupon *model.Coupon
+	if req.CouponCode != "" {
+		coupon, err = s.couponRepo.GetByCode(ctx, tx, req.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -1923,26 +2288,20 @@ func (s *Service) createOrderPremium(ctx context.Context, req *model.CreateOrder
 	defer func() { _ = tx2.Rollback() }()
 
 	if !order.IsPaid() {
-		switch {
-		case order.IsStripePayable():
-			ssid, err := s.createStripeSession(ctx, req, order)
-			if err != nil {
-				return nil, err
-			}
+		sess, err := s.createCheckoutSession(ctx, req, order, coupon)
+		if err != nil {
+			return nil, err
+		}
 
-			if err := s.orderRepo.AppendMetadata(ctx, tx2, order.ID, "stripeCheckoutSessionId", ssid); err != nil {
+		if sess != nil {
+			if err := s.orderRepo.AppendMetadata(ctx, tx2, order.ID, sess.MetadataKey, sess.ID); err != nil {
 				return nil, fmt.Errorf("failed to update order metadata: %w", err)
 			}
 
-		// Backporting this from the legacy method CreateOrderFromRequest.
-		case order.IsRadomPayable():
-			ssid, err := s.createRadomSession(ctx, req, order)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create checkout session: %w", err)
-			}
-
-			if err := s.orderRepo.AppendMetadata(ctx, tx2, order.ID, "radomCheckoutSessionId", ssid); err != nil {
-				return nil, fmt.Errorf("failed to update order metadata: %w", err)
+			if sess.URL != "" {
+				if err := s.orderRepo.AppendMetadata(ctx, tx2, order.ID, sess.URLMetadataKey, sess.URL); err != nil {
+					return nil, fmt.Errorf("failed to update order metadata: %w", err)
+				}
 			}
 		}
 	}
@@ -1951,6 +2310,18 @@ func (s *Service) createOrderPremium(ctx context.Context, req *model.CreateOrder
 		return nil, fmt.Errorf("failed to update order metadata: %w", err)
 	}
 
+	if req.NumSeats > 0 {
+		if err := s.orderRepo.AppendMetadataInt(ctx, tx2, order.ID, "numSeats", req.NumSeats); err != nil {
+			return nil, fmt.Errorf("failed to update order metadata: %w", err)
+		}
+	}
+
+	if coupon != nil {
+		if err := s.appendCouponMetadata(ctx, tx2, order.ID, coupon); err != nil {
+			return nil, fmt.Errorf("failed to update order metadata: %w", err)
+		}
+	}
+
 	if err := tx2.Commit(); err != nil {
 		return nil, err
 	}
@@ -1958,6 +2329,18 @@ func (s *Service) createOrderPremium(ctx context.Context, req *model.CreateOrder
 	return order, nil
 }
 
+func (s *Service) appendCouponMetadata(ctx context.Context, dbi sqlx.ExecerContext, oid uuid.UUID, coupon *model.Coupon) error {
+	if err := s.orderRepo.AppendMetadata(ctx, dbi, oid, "couponCode", coupon.Code); err != nil {
+		return err
+	}
+
+	if coupon.StripeCouponID != nil {
+		return s.orderRepo.AppendMetadata(ctx, dbi, oid, "stripeCouponId", *coupon.StripeCouponID)
+	}
+
+	return nil
+}
+
 func (s *Service) updateOrderIntervals(ctx context.Context, dbi sqlx.ExecerContext, oid uuid.UUID, items []model.OrderItem, nintvals int) error {
 	if nintvals > 0 {
 		if err := s.orderRepo.AppendMetadataInt(ctx, dbi, oid, "numIntervals", nintvals); err != nil {
@@ -2005,74 +2388,6 @@ func (s *Service) createOrderIssuers(ctx context.Context, dbi sqlx.QueryerContex
 	return numIntervals, nil
 }
 
-func (s *Service) createStripeSession(ctx context.Context, req *model.CreateOrderRequestNew, order *model.Order) (string, error) {
-	oid := order.ID.String()
-
-	surl, err := req.StripeMetadata.SuccessURL(oid)
-	if err != nil {
-		return "", err
-	}
-
-	curl, err := req.StripeMetadata.CancelURL(oid)
-	if err != nil {
-		return "", err
-	}
-
-	sreq := createStripeSessionRequest{
-		orderID:    oid,
-		email:      req.Email,
-		customerID: req.CustomerID,
-		successURL: surl,
-		cancelURL:  curl,
-		trialDays:  order.GetTrialDays(),
-		items:      buildStripeLineItems(order.Items),
-		discounts:  buildStripeDiscounts(req.Discounts),
-		metadata:   req.Metadata,
-	}
-
-	return createStripeSession(ctx, s.stripeCl, sreq)
-}
-
-func (s *Service) createRadomSession(ctx context.Context, req *model.CreateOrderRequestNew, order *model.Order) (string, error) {
-	oid := order.ID.String()
-
-	surl, err := req.RadomMetadata.SuccessURL(oid)
-	if err != nil {
-		return "", err
-	}
-
-	curl, err := req.RadomMetadata.CancelURL(oid)
-	if err != nil {
-		return "", err
-	}
-
-	items, err := orderItemsToRadomLineItems(order.Items)
-	if err != nil {
-		return "", err
-	}
-
-	reqx := &radom.CheckoutSessionRequest{
-		LineItems:  items,
-		Gateway:    s.radomGateway,
-		SuccessURL: surl,
-		CancelURL:  curl,
-		Metadata: []radom.Metadata{
-			{
-				Key:   "brave_order_id",
-				Value: oid,
-			},
-		},
-		ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
-	}
-
-	resp, err := s.radomClient.CreateCheckoutSession(ctx, reqx)
-	if err != nil {
-		return "", err
-	}
-
-	return resp.SessionID, nil
-}
-
 const errRadomProductIDNotFound = model.Error("product id not found in metadata")
 
 func orderItemsToRadomLineItems(orderItems []model.OrderItem) ([]radom.LineItem, error) {
@@ -2087,6 +2402,15 @@ func orderItemsToRadomLineItems(orderItems []model.OrderItem) ([]radom.LineItem,
 			ProductID: pid,
 		}
 
+		// Radom has no coupons or regional prices, so a discounted or locally priced item overrides the price of the product.
+		_, hasLocalPrice := orderItems[i].PriceID()
+		if (orderItems[i].IsDiscounted() || hasLocalPrice) && orderItems[i].Quantity > 0 {
+			item.ItemData = &radom.LineItemData{
+				Price:    orderItems[i].Subtotal.Div(decimal.NewFromInt(int64(orderItems[i].Quantity))).InexactFloat64(),
+				Currency: orderItems[i].Currency,
+			}
+		}
+
 		lineItems = append(lineItems, item)
 	}
 
@@ -2094,31 +2418,14 @@ func orderItemsToRadomLineItems(orderItems []model.OrderItem) ([]radom.LineItem,
 }
 
 func (s *Service) redeemBlindedCred(ctx context.Context, w http.ResponseWriter, kind string, cred *cbr.CredentialRedemption) *handlers.AppError {
-	var redeemFn func(ctx context.Context, issuer, preimage, signature, payload string) error
-
 	switch kind {
-	case singleUse:
-		redeemFn = s.cbClient.RedeemCredential
-	case timeLimitedV2:
-		redeemFn = s.cbClient.RedeemCredentialV3
+	case singleUse, timeLimitedV2:
 	default:
 		return handlers.WrapError(fmt.Errorf("credential type %s not suppoted", kind), "unknown credential type %s", http.StatusBadRequest)
 	}
 
-	// FIXME: we shouldn't be using the issuer as the payload, it ideally would be a unique request identifier
-	// to allow for more flexible idempotent behavior.
-	if err := redeemFn(ctx, cred.Issuer, cred.TokenPreimage, cred.Signature, cred.Issuer); err != nil {
-		if !shouldRetryRedeemFn(kind, cred.Issuer, err) {
-			return handleRedeemFnError(ctx, w, kind, cred, err)
-		}
-
-		// TODO: remove this as there should be no credentials in Production signed by brave-leo-premium-year.
-		//
-		// Fix for https://github.com/brave-intl/challenge-bypass-server/pull/371.
-		const leoa = "brave.com?sku=brave-leo-premium-year"
-		if err := redeemFn(ctx, leoa, cred.TokenPreimage, cred.Signature, cred.Issuer); err != nil {
-			return handleRedeemFnError(ctx, w, kind, cred, err)
-		}
+	if err := s.tryRedeemBlindedCred(ctx, kind, cred); err != nil {
+		return handleRedeemFnError(ctx, w, kind, cred, err)
 	}
 
 	// TODO(clD11): cleanup after quick fix
@@ -2142,6 +2449,17 @@ func (s *Service) createOrderTx(ctx context.Context, dbi sqlx.ExtContext, oreq *
 		return nil, err
 	}
 
+	if err := s.orderEvRepo.Insert(ctx, dbi, result.ID, model.OrderEventCreated, result.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// Free orders are paid as soon as they are created.
+	if result.Status == model.OrderStatusPaid {
+		if err := s.orderEvRepo.Insert(ctx, dbi, result.ID, model.OrderEventPaid, result.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+
 	return result, nil
 }
 
@@ -2179,7 +2497,16 @@ func (s *Service) renewOrderWithExpPaidTimeTx(ctx context.Context, dbi sqlx.Exec
 		return err
 	}
 
-	return nil
+	if err := s.orderDunRepo.Resolve(ctx, dbi, id, paidt); err != nil {
+		return err
+	}
+
+	// A coupon is counted once, when the order is first paid.
+	if err := s.couponRepo.RedeemForOrder(ctx, dbi, id, paidt); err != nil {
+		return err
+	}
+
+	return s.orderEvRepo.InsertPaid(ctx, dbi, id, paidt)
 }
 
 func (s *Service) getOrderFull(ctx context.Context, id uuid.UUID) (*model.Order, error) {
@@ -2277,7 +2604,7 @@ func (s *Service) createOrderWithReceipt(ctx context.Context, req model.ReceiptR
 
 	// 4. Create if missing.
 
-	return createOrderWithReceipt(ctx, s, s.newItemReqSet, s.payProcCfg, rcpt, paidt)
+	return createOrderWithReceipt(ctx, s.Datastore.RawDB(), s, s.catalog, s.payProcCfg, rcpt, paidt)
 }
 
 func (s *Service) checkOrderReceipt(ctx context.Context, req model.ReceiptRequest, orderID uuid.UUID) error {
@@ -2336,114 +2663,6 @@ func (s *Service) processSubmitReceipt(ctx context.Context, req model.ReceiptReq
 	return rcpt, nil
 }
 
-const errRadomUnknownAction = model.Error("skus: unknown radom action")
-
-func (s *Service) processRadomNotification(ctx context.Context, ntf *radom.Notification) error {
-	if !ntf.ShouldProcess() {
-		return nil
-	}
-
-	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	if err := s.processRadomNotificationTx(ctx, tx, ntf); err != nil {
-		return err
-	}
-
-	return tx.Commit()
-}
-
-func (s *Service) processRadomNotificationTx(ctx context.Context, dbi sqlx.ExtContext, ntf *radom.Notification) error {
-	switch {
-	case ntf.IsNewSub():
-		oid, err := ntf.OrderID()
-		if err != nil {
-			return err
-		}
-
-		subID, err := ntf.SubID()
-		if err != nil {
-			return err
-		}
-
-		rsub, err := s.radomClient.GetSubscription(ctx, subID.String())
-		if err != nil {
-			return err
-		}
-
-		nxtB, err := rsub.NextBillingDate()
-		if err != nil {
-			return err
-		}
-
-		expAt := nxtB.Add(24 * time.Hour)
-
-		paidAt, err := rsub.LastPaid()
-		if err != nil {
-			return err
-		}
-
-		if err := s.renewOrderWithExpPaidTimeTx(ctx, dbi, oid, expAt, paidAt); err != nil {
-			return err
-		}
-
-		if err := s.orderRepo.AppendMetadata(ctx, dbi, oid, "externalID", subID.String()); err != nil {
-			return err
-		}
-
-		return s.orderRepo.AppendMetadata(ctx, dbi, oid, "paymentProcessor", model.RadomPaymentMethod)
-
-	case ntf.ShouldRenew():
-		subID, err := ntf.SubID()
-		if err != nil {
-			return err
-		}
-
-		ord, err := s.orderRepo.GetByExternalID(ctx, dbi, subID.String())
-		if err != nil {
-			return err
-		}
-
-		rsub, err := s.radomClient.GetSubscription(ctx, subID.String())
-		if err != nil {
-			return err
-		}
-
-		nxtB, err := rsub.NextBillingDate()
-		if err != nil {
-			return err
-		}
-
-		expAt := nxtB.Add(24 * time.Hour)
-
-		paidAt, err := rsub.LastPaid()
-		if err != nil {
-			return err
-		}
-
-		return s.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expAt, paidAt)
-
-	case ntf.ShouldCancel():
-		subID, err := ntf.SubID()
-		if err != nil {
-			return err
-		}
-
-		ord, err := s.orderRepo.GetByExternalID(ctx, dbi, subID.String())
-		if err != nil {
-			return err
-		}
-
-		return s.cancelOrderTx(ctx, dbi, ord.ID)
-
-	default:
-		return errRadomUnknownAction
-	}
-}
-
 func checkOrderReceipt(ctx context.Context, dbi sqlx.QueryerContext, repo orderStoreSvc, orderID uuid.UUID, extID string) error {
 	ord, err := repo.GetByExternalID(ctx, dbi, extID)
 	if err != nil {
@@ -2475,8 +2694,9 @@ type paidOrderCreator interface {
 // That will eventually be refactored, and this will be promoted to a method once testing is possible without Datastore.
 func createOrderWithReceipt(
 	ctx context.Context,
+	dbi sqlx.QueryerContext,
 	svc paidOrderCreator,
-	itemReqSet map[string]model.OrderItemRequestNew,
+	catalog *skuCatalog,
 	ppcfg *premiumPaymentProcConfig,
 	rcpt model.ReceiptData,
 	paidt time.Time,
@@ -2487,7 +2707,7 @@ func createOrderWithReceipt(
 		- brave.leo.monthly -> brave-leo-premium
 		- brave.leo.yearly -> brave-leo-premium-year
 	*/
-	itemNew, err := newOrderItemReqForSubID(itemReqSet, rcpt.ProductID)
+	itemNew, err := catalog.itemReqByStoreProductID(ctx, dbi, rcpt.ProductID)
 	if err != nil {
 		return nil, err
 	}
@@ -2530,50 +2750,106 @@ func createOrderWithReceipt(
 	return order, nil
 }
 
-func (s *Service) recordPayFailureStripe(ctx context.Context, dbi sqlx.ExecerContext, ord *model.Order, subID string) error {
-	if shouldUpdateOrderStripeSubID(ord, subID) {
-		if err := s.orderRepo.AppendMetadata(ctx, dbi, ord.ID, "stripeSubscriptionId", subID); err != nil {
-			return err
-		}
+// changeOrderPlanTx switches ord to the plan identified by skuVnt.
+//
+// The order must have its items loaded, and have exactly one item which is updated in place.
+// The issuer for the new product is created if it does not exist.
+// Credentials issued under the previous plan are left to expire.
+//
+// The order expires at expt.
+// If the vendor has not provided expt, the remaining value of the previous plan is prorated into the new one.
+func (s *Service) changeOrderPlanTx(ctx context.Context, dbi sqlx.ExtContext, ord *model.Order, skuVnt string, expt, now time.Time) error {
+	if len(ord.Items) != 1 {
+		return model.ErrOrderPlanChangeItems
+	}
+
+	prev := &ord.Items[0]
+	if prev.SKUVnt == skuVnt {
+		return nil
 	}
 
-	if err := s.orderRepo.IncrementNumPayFailed(ctx, dbi, ord.ID); err != nil {
+	req, err := s.catalog.itemReqBySKUVnt(ctx, dbi, skuVnt)
+	if err != nil {
+		if errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+			return model.ErrOrderPlanNotFound
+		}
+
 		return err
 	}
 
-	// Skip updating payment processor if it's already Stripe.
-	if ord.IsStripe() {
-		return nil
+	if req.CredentialType != prev.CredentialType {
+		return model.ErrUnsupportedCredType
 	}
 
-	return s.orderRepo.AppendMetadata(ctx, dbi, ord.ID, "paymentProcessor", model.StripePaymentMethod)
-}
+	item, err := createOrderItem(&req)
+	if err != nil {
+		return err
+	}
 
-func (s *Service) renewOrderStripe(ctx context.Context, dbi sqlx.ExecerContext, ord *model.Order, subID string, expt, paidt time.Time) error {
-	if shouldUpdateOrderStripeSubID(ord, subID) {
-		if err := s.orderRepo.AppendMetadata(ctx, dbi, ord.ID, "stripeSubscriptionId", subID); err != nil {
-			return err
-		}
+	item.ID = prev.ID
+	item.OrderID = prev.OrderID
+	item.Currency = prev.Currency
+	item.Quantity = prev.Quantity
+	item.Subtotal = item.Price.Mul(decimal.NewFromInt(int64(item.Quantity)))
+
+	items := []model.OrderItem{*item}
+
+	// The new plan is priced for the region the order was priced for.
+	if err := s.applyPriceBook(ctx, dbi, prev.Currency, prev.PriceCountry(), items); err != nil {
+		return err
 	}
 
-	// Add 1-day leeway in case next billing cycle's webhook gets delayed.
-	expt = expt.Add(24 * time.Hour)
+	if err := s.orderItemRepo.UpdatePlan(ctx, dbi, &items[0]); err != nil {
+		return err
+	}
 
-	if err := s.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt); err != nil {
+	numIntervals, err := s.createOrderIssuers(ctx, dbi, ord.MerchantID, items)
+	if err != nil {
 		return err
 	}
 
-	// Reset numPaymentFailed.
-	if err := s.resetNumPaymentFailed(ctx, dbi, ord.ID); err != nil {
+	if err := s.updateOrderIntervals(ctx, dbi, ord.ID, items, numIntervals); err != nil {
 		return err
 	}
 
-	// Skip updating payment processor if it's already Stripe.
-	if ord.IsStripe() {
-		return nil
+	if err := s.orderRepo.SetTotalPrice(ctx, dbi, ord.ID, model.OrderItemList(items).TotalCost()); err != nil {
+		return err
+	}
+
+	if expt.IsZero() {
+		if ord.ExpiresAt == nil {
+			// Nothing to prorate, the current expiration time stays.
+			return nil
+		}
+
+		prevReq, err := s.catalog.itemReqBySKUVnt(ctx, dbi, prev.SKUVnt)
+		if err != nil {
+			if errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+				// Nothing to prorate, the current expiration time stays.
+				return nil
+			}
+
+			return err
+		}
+
+		expt, err = prorateExpiresAt(now, *ord.ExpiresAt, prev.Price, prevReq.Period, item.Price, req.Period)
+		if err != nil {
+			return err
+		}
 	}
 
-	return s.orderRepo.AppendMetadata(ctx, dbi, ord.ID, "paymentProcessor", model.StripePaymentMethod)
+	return s.orderRepo.SetExpiresAt(ctx, dbi, ord.ID, expt)
+}
+
+// isErrOrderPlanChange reports whether err means that the order can't be switched to the new plan.
+//
+// Such errors are specific to the plan change, and must not prevent a renewal from being applied.
+func isErrOrderPlanChange(err error) bool {
+	return errors.Is(err, model.ErrOrderPlanChangeItems) ||
+		errors.Is(err, model.ErrOrderPlanNotFound) ||
+		errors.Is(err, model.ErrUnsupportedCredType) ||
+		errors.Is(err, model.ErrInvalidMobileProduct) ||
+		errors.Is(err, model.ErrSKUCatalogEntryNotFound)
 }
 
 func (s *Service) recreateStripeSession(ctx context.Context, dbi sqlx.ExecerContext, ord *model.Order, oldSessID, email string) (string, error) {
@@ -2592,6 +2868,10 @@ func (s *Service) recreateStripeSession(ctx context.Context, dbi sqlx.ExecerCont
 		items:      buildStripeLineItems(ord.Items),
 	}
 
+	if cid, ok := ord.StripeCouponID(); ok {
+		req.discounts = buildStripeDiscounts([]string{cid})
+	}
+
 	if req.email == "" {
 		req.email = xstripe.CustomerEmailFromSession(oldSess)
 	}
@@ -2657,6 +2937,33 @@ func newOrderNewForReq(req *model.CreateOrderRequestNew, items []model.OrderItem
 	return result, nil
 }
 
+// prorateExpiresAt converts the time remaining until expt on the previous plan into time on the next plan.
+//
+// The value of the remaining time is calculated from the previous plan's price per period,
+// and then exchanged for time at the next plan's price per period.
+func prorateExpiresAt(now, expt time.Time, prevPrice decimal.Decimal, prevPeriod string, nextPrice decimal.Decimal, nextPeriod string) (time.Time, error) {
+	if !expt.After(now) || !prevPrice.IsPositive() || !nextPrice.IsPositive() {
+		return expt, nil
+	}
+
+	prevDur, err := durationFromISOAt(prevPeriod, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nextDur, err := durationFromISOAt(nextPeriod, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	remaining := decimal.NewFromInt(int64(expt.Sub(now)))
+
+	// remaining * (prevPrice / prevDur) / (nextPrice / nextDur).
+	result := remaining.Mul(prevPrice).Mul(decimal.NewFromInt(int64(nextDur))).Div(nextPrice.Mul(decimal.NewFromInt(int64(prevDur))))
+
+	return now.Add(time.Duration(result.IntPart())), nil
+}
+
 func createOrderItems(req *model.CreateOrderRequestNew) ([]model.OrderItem, error) {
 	result := make([]model.OrderItem, 0)
 
@@ -2745,6 +3052,20 @@ func durationFromISO(v string) (time.Duration, error) {
 	return time.Until(*durt), nil
 }
 
+func durationFromISOAt(v string, t time.Time) (time.Duration, error) {
+	dur, err := timeutils.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+
+	durt, err := dur.From(t)
+	if err != nil {
+		return 0, err
+	}
+
+	return durt.Sub(t), nil
+}
+
 type blindedCredVrfResult struct {
 	ID        string `json:"id"`
 	Duplicate bool   `json:"duplicate"`
@@ -2893,6 +3214,17 @@ func buildStripeLineItems(items []model.OrderItem) []*stripe.CheckoutSessionLine
 	return result
 }
 
+// stripeDiscounts returns the Stripe coupon of the skus coupon in place of any discounts in the request.
+//
+// Stripe allows a single discount per checkout session.
+func stripeDiscounts(discounts []string, coupon *model.Coupon) []string {
+	if coupon == nil || coupon.StripeCouponID == nil {
+		return discounts
+	}
+
+	return []string{*coupon.StripeCouponID}
+}
+
 func buildStripeDiscounts(discounts []string) []*stripe.CheckoutSessionDiscountParams {
 	var result []*stripe.CheckoutSessionDiscountParams
 
@@ -2923,6 +3255,31 @@ func handleRedeemFnError(ctx context.Context, w http.ResponseWriter, kind string
 	return handlers.WrapError(err, "Error verifying credentials", http.StatusInternalServerError)
 }
 
+// tryRedeemBlindedCred redeems a single use or time limited v2 credential.
+func (s *Service) tryRedeemBlindedCred(ctx context.Context, kind string, cred *cbr.CredentialRedemption) error {
+	redeemFn := s.cbClient.RedeemCredential
+	if kind == timeLimitedV2 {
+		redeemFn = s.cbClient.RedeemCredentialV3
+	}
+
+	// FIXME: we shouldn't be using the issuer as the payload, it ideally would be a unique request identifier
+	// to allow for more flexible idempotent behavior.
+	if err := redeemFn(ctx, cred.Issuer, cred.TokenPreimage, cred.Signature, cred.Issuer); err != nil {
+		if !shouldRetryRedeemFn(kind, cred.Issuer, err) {
+			return err
+		}
+
+		// TODO: remove this as there should be no credentials in Production signed by brave-leo-premium-year.
+		//
+		// Fix for https://github.com/brave-intl/challenge-bypass-server/pull/371.
+		const leoa = "brave.com?sku=brave-leo-premium-year"
+
+		return redeemFn(ctx, leoa, cred.TokenPreimage, cred.Signature, cred.Issuer)
+	}
+
+	return nil
+}
+
 func shouldRetryRedeemFn(kind, issuer string, err error) bool {
 	const leo = "brave.com?sku=brave-leo-premium"
 
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/awa/go-iap/appstore"
+	"github.com/golang/mock/gomock"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
@@ -27,6 +28,7 @@ import (
 	"github.com/brave-intl/bat-go/libs/datastore"
 	berrs "github.com/brave-intl/bat-go/libs/errors"
 	"github.com/brave-intl/bat-go/libs/handlers"
+	kafkautils "github.com/brave-intl/bat-go/libs/kafka"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
 	"github.com/brave-intl/bat-go/services/skus/radom"
@@ -355,13 +357,14 @@ func TestService_uniqBatchesTxTime(t *testing.T) {
 	}
 }
 
-func TestService_processPlayStoreNotificationTx(t *testing.T) {
+func TestPlayStoreProcessor_processTx(t *testing.T) {
 	type tcGiven struct {
-		extID string
-		ntf   *playStoreDevNotification
-		orepo *repository.MockOrder
-		prepo *repository.MockOrderPayHistory
-		pscl  *mockPSClient
+		extID  string
+		ntf    *playStoreDevNotification
+		orepo  *repository.MockOrder
+		oirepo *repository.MockOrderItem
+		prepo  *repository.MockOrderPayHistory
+		pscl   *mockPSClient
 	}
 
 	type testCase struct {
@@ -475,20 +478,204 @@ func TestService_processPlayStoreNotificationTx(t *testing.T) {
 		},
 
 		{
-			name: "void_should_cancel",
+			name: "sub_should_record_pay_failure",
+			given: tcGiven{
+				extID: "PURCHASE_TOKEN_01",
+				ntf: &playStoreDevNotification{
+					PackageName:    "com.brave.browser_nightly",
+					EventTimeMilli: json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+					SubscriptionNtf: &playStoreSubscriptionNtf{
+						Type:          6,
+						PurchaseToken: "PURCHASE_TOKEN_01",
+						SubID:         "nightly.bravevpn.monthly",
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						result := &model.Order{
+							ID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status: model.OrderStatusPaid,
+						}
+
+						return result, nil
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status == model.OrderStatusPastDue {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+				pscl:  &mockPSClient{},
+			},
+		},
+
+		{
+			name: "void_should_refund",
 			given: tcGiven{
 				extID: "PURCHASE_TOKEN_01",
 				ntf: &playStoreDevNotification{
 					PackageName:       "com.brave.browser_nightly",
 					EventTimeMilli:    json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
-					VoidedPurchaseNtf: &playStoreVoidedPurchaseNtf{ProductType: 1},
+					VoidedPurchaseNtf: &playStoreVoidedPurchaseNtf{ProductType: 1, RefundType: 1, OrderID: "GPA.0000-0000-0000-00000"},
+				},
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status == model.OrderStatusRefunded {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+				pscl:  &mockPSClient{},
+			},
+		},
+
+		{
+			name: "void_partial_ignored",
+			given: tcGiven{
+				extID: "PURCHASE_TOKEN_01",
+				ntf: &playStoreDevNotification{
+					PackageName:       "com.brave.browser_nightly",
+					EventTimeMilli:    json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+					VoidedPurchaseNtf: &playStoreVoidedPurchaseNtf{ProductType: 1, RefundType: 2, OrderID: "GPA.0000-0000-0000-00000"},
+				},
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("unexpected")
+					},
 				},
-				orepo: &repository.MockOrder{},
 				prepo: &repository.MockOrderPayHistory{},
 				pscl:  &mockPSClient{},
 			},
 		},
 
+		{
+			name: "sub_purchased_new",
+			given: tcGiven{
+				extID: "PURCHASE_TOKEN_01",
+				ntf: &playStoreDevNotification{
+					PackageName:    "com.brave.browser_nightly",
+					EventTimeMilli: json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+					SubscriptionNtf: &playStoreSubscriptionNtf{
+						Type:          4,
+						PurchaseToken: "PURCHASE_TOKEN_01",
+						SubID:         "nightly.bravevpn.yearly",
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						return nil, model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+				pscl: &mockPSClient{
+					fnVerifySubscription: func(ctx context.Context, pkgName, subID, token string) (*androidpublisher.SubscriptionPurchase, error) {
+						result := &androidpublisher.SubscriptionPurchase{
+							PaymentState:     ptrTo[int64](1),
+							ExpiryTimeMillis: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "sub_purchased_replaces_plan_error",
+			given: tcGiven{
+				extID: "PURCHASE_TOKEN_02",
+				ntf: &playStoreDevNotification{
+					PackageName:    "com.brave.browser_nightly",
+					EventTimeMilli: json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+					SubscriptionNtf: &playStoreSubscriptionNtf{
+						Type:          4,
+						PurchaseToken: "PURCHASE_TOKEN_02",
+						SubID:         "nightly.bravevpn.yearly",
+					},
+				},
+				orepo: &repository.MockOrder{
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						if extID != "PURCHASE_TOKEN_01" {
+							return nil, model.ErrOrderNotFound
+						}
+
+						result := &model.Order{
+							ID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status: model.OrderStatusPaid,
+						}
+
+						return result, nil
+					},
+
+					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+						if key == "externalID" && val == "PURCHASE_TOKEN_02" {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if when.Equal(time.Date(2025, time.July, 2, 0, 0, 0, 0, time.UTC)) {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				oirepo: &repository.MockOrderItem{
+					FnFindByOrderID: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderItem, error) {
+						result := []model.OrderItem{{SKUVnt: "brave-vpn-premium"}, {SKUVnt: "brave-leo-premium"}}
+
+						return result, nil
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+				pscl: &mockPSClient{
+					fnVerifySubscription: func(ctx context.Context, pkgName, subID, token string) (*androidpublisher.SubscriptionPurchase, error) {
+						result := &androidpublisher.SubscriptionPurchase{
+							PaymentState:        ptrTo[int64](1),
+							ExpiryTimeMillis:    time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+							LinkedPurchaseToken: "PURCHASE_TOKEN_01",
+						}
+
+						return result, nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "sub_purchased_replaces_fetch_error",
+			given: tcGiven{
+				extID: "PURCHASE_TOKEN_02",
+				ntf: &playStoreDevNotification{
+					PackageName:    "com.brave.browser_nightly",
+					EventTimeMilli: json.Number(strconv.FormatInt(time.Now().UnixMilli(), 10)),
+					SubscriptionNtf: &playStoreSubscriptionNtf{
+						Type:          4,
+						PurchaseToken: "PURCHASE_TOKEN_02",
+						SubID:         "nightly.bravevpn.yearly",
+					},
+				},
+				orepo: &repository.MockOrder{},
+				prepo: &repository.MockOrderPayHistory{},
+				pscl: &mockPSClient{
+					fnVerifySubscription: func(ctx context.Context, pkgName, subID, token string) (*androidpublisher.SubscriptionPurchase, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
 		{
 			name: "skip_sub",
 			given: tcGiven{
@@ -529,25 +716,35 @@ func TestService_processPlayStoreNotificationTx(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			svc := &Service{
-				orderRepo:          tc.given.orepo,
-				payHistRepo:        tc.given.prepo,
-				vendorReceiptValid: &receiptVerifier{playStoreCl: tc.given.pscl},
+				orderRepo:     tc.given.orepo,
+				orderItemRepo: tc.given.oirepo,
+				payHistRepo:   tc.given.prepo,
+				tlv2Repo:      &repository.MockTLV2{},
+				txnRepo:       &repository.MockTransaction{},
+				orderEvRepo:   &repository.MockOrderEvent{},
+				orderDunRepo:  &repository.MockOrderDunning{},
+				couponRepo:    &repository.MockCoupon{},
+				catalog:       newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				dunningCfg:    &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
 			}
 
 			ctx := context.Background()
 
-			err := svc.processPlayStoreNotificationTx(ctx, nil, tc.given.ntf, tc.given.extID)
+			proc := &playStoreProcessor{subs: &receiptVerifier{playStoreCl: tc.given.pscl}, catalog: svc.catalog}
+
+			err := proc.processTx(ctx, nil, svc, tc.given.ntf, tc.given.extID)
 			should.Equal(t, true, errors.Is(err, tc.exp))
 		})
 	}
 }
 
-func TestService_processAppStoreNotificationTx(t *testing.T) {
+func TestAppStoreProcessor_processTx(t *testing.T) {
 	type tcGiven struct {
-		ntf   *appStoreSrvNotification
-		txn   *appStoreTransaction
-		orepo *repository.MockOrder
-		prepo *repository.MockOrderPayHistory
+		ntf    *appStoreSrvNotification
+		txn    *appStoreTransaction
+		orepo  *repository.MockOrder
+		oirepo *repository.MockOrderItem
+		prepo  *repository.MockOrderPayHistory
 	}
 
 	type testCase struct {
@@ -600,111 +797,269 @@ func TestService_processAppStoreNotificationTx(t *testing.T) {
 		},
 
 		{
-			name: "should_cancel",
+			name: "should_renew_plan_change_error",
 			given: tcGiven{
 				ntf: &appStoreSrvNotification{
 					val: &appstore.SubscriptionNotificationV2DecodedPayload{
-						NotificationType: appstore.NotificationTypeV2DidChangeRenewalStatus,
-						Subtype:          appstore.SubTypeV2AutoRenewDisabled,
+						NotificationType: appstore.NotificationTypeV2DidRenew,
 					},
 				},
 				txn: &appStoreTransaction{
 					OriginalTransactionId: "123456789000001",
-					ExpiresDate:           1704067201000,
+					ProductId:             "bravevpn.yearly",
+					ExpiresDate:           1704067200000,
 				},
 
-				orepo: &repository.MockOrder{},
+				orepo: &repository.MockOrder{
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if when.Equal(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)) {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				oirepo: &repository.MockOrderItem{
+					FnFindByOrderID: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderItem, error) {
+						result := []model.OrderItem{{SKUVnt: "brave-vpn-premium"}, {SKUVnt: "brave-leo-premium"}}
+
+						return result, nil
+					},
+				},
 				prepo: &repository.MockOrderPayHistory{},
 			},
 		},
 
 		{
-			name: "anything_else",
+			name: "should_renew_get_items_error",
 			given: tcGiven{
 				ntf: &appStoreSrvNotification{
 					val: &appstore.SubscriptionNotificationV2DecodedPayload{
-						NotificationType: appstore.NotificationTypeV2PriceIncrease,
-						Subtype:          appstore.SubTypeV2Accepted,
+						NotificationType: appstore.NotificationTypeV2DidRenew,
 					},
 				},
 				txn: &appStoreTransaction{
 					OriginalTransactionId: "123456789000001",
-					ExpiresDate:           1704067201000,
+					ProductId:             "bravevpn.yearly",
+					ExpiresDate:           1704067200000,
 				},
 
 				orepo: &repository.MockOrder{},
-				prepo: &repository.MockOrderPayHistory{},
-			},
-		},
-	}
-
-	for i := range tests {
-		tc := tests[i]
-
-		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{
-				orderRepo:   tc.given.orepo,
-				payHistRepo: tc.given.prepo,
-			}
-
-			ctx := context.Background()
-
-			err := svc.processAppStoreNotificationTx(ctx, nil, tc.given.ntf, tc.given.txn)
-			should.Equal(t, true, errors.Is(err, tc.exp))
-		})
-	}
-}
-
-func TestService_renewOrderWithExpPaidTimeTx(t *testing.T) {
-	type tcGiven struct {
-		id    uuid.UUID
-		expt  time.Time
-		paidt time.Time
-		orepo *repository.MockOrder
-		prepo *repository.MockOrderPayHistory
-	}
-
-	type testCase struct {
-		name  string
-		given tcGiven
-		exp   error
-	}
-
-	tests := []testCase{
-		{
-			name: "set_status_failed",
-			given: tcGiven{
-				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
-				expt:  time.UnixMilli(1735689599000),
-				paidt: time.UnixMilli(1704067201000),
-				orepo: &repository.MockOrder{
-					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
-						return model.Error("something_went_wrong")
+				oirepo: &repository.MockOrderItem{
+					FnFindByOrderID: func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderItem, error) {
+						return nil, model.Error("something_went_wrong")
 					},
 				},
+				prepo: &repository.MockOrderPayHistory{},
 			},
 			exp: model.Error("something_went_wrong"),
 		},
 
 		{
-			name: "set_expires_at_failed",
+			name: "should_cancel",
 			given: tcGiven{
-				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
-				expt:  time.UnixMilli(1735689599000),
-				paidt: time.UnixMilli(1704067201000),
-				orepo: &repository.MockOrder{
-					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
-						return model.Error("something_went_wrong")
+				ntf: &appStoreSrvNotification{
+					val: &appstore.SubscriptionNotificationV2DecodedPayload{
+						NotificationType: appstore.NotificationTypeV2DidChangeRenewalStatus,
+						Subtype:          appstore.SubTypeV2AutoRenewDisabled,
 					},
 				},
+				txn: &appStoreTransaction{
+					OriginalTransactionId: "123456789000001",
+					ExpiresDate:           1704067201000,
+				},
+
+				orepo: &repository.MockOrder{},
+				prepo: &repository.MockOrderPayHistory{},
 			},
-			exp: model.Error("something_went_wrong"),
 		},
 
 		{
-			name: "set_last_paid_at_failed",
+			name: "should_refund",
 			given: tcGiven{
-				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+				ntf: &appStoreSrvNotification{
+					val: &appstore.SubscriptionNotificationV2DecodedPayload{
+						NotificationType: appstore.NotificationTypeV2Refund,
+					},
+				},
+				txn: &appStoreTransaction{
+					OriginalTransactionId: "123456789000001",
+					TransactionId:         "123456789000002",
+					ExpiresDate:           1704067201000,
+				},
+
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status == model.OrderStatusRefunded {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+			},
+		},
+
+		{
+			name: "should_record_pay_failure",
+			given: tcGiven{
+				ntf: &appStoreSrvNotification{
+					val: &appstore.SubscriptionNotificationV2DecodedPayload{
+						NotificationType: appstore.NotificationTypeV2DidFailToRenew,
+						Subtype:          appstore.SubTypeV2GracePeriod,
+					},
+					renewal: &appstore.JWSRenewalInfoDecodedPayload{IsInBillingRetryPeriod: true},
+				},
+				txn: &appStoreTransaction{
+					OriginalTransactionId: "123456789000001",
+					ExpiresDate:           1704067201000,
+				},
+
+				orepo: &repository.MockOrder{
+					FnGetByExternalID: func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
+						result := &model.Order{
+							ID:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Status: model.OrderStatusPaid,
+						}
+
+						return result, nil
+					},
+
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status == model.OrderStatusPastDue {
+							return nil
+						}
+
+						return model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+			},
+		},
+
+		{
+			name: "skip_pay_failure_not_in_billing_retry",
+			given: tcGiven{
+				ntf: &appStoreSrvNotification{
+					val: &appstore.SubscriptionNotificationV2DecodedPayload{
+						NotificationType: appstore.NotificationTypeV2DidFailToRenew,
+					},
+					renewal: &appstore.JWSRenewalInfoDecodedPayload{},
+				},
+				txn: &appStoreTransaction{
+					OriginalTransactionId: "123456789000001",
+					ExpiresDate:           1704067201000,
+				},
+
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("unexpected")
+					},
+				},
+				prepo: &repository.MockOrderPayHistory{},
+			},
+		},
+
+		{
+			name: "anything_else",
+			given: tcGiven{
+				ntf: &appStoreSrvNotification{
+					val: &appstore.SubscriptionNotificationV2DecodedPayload{
+						NotificationType: appstore.NotificationTypeV2PriceIncrease,
+						Subtype:          appstore.SubTypeV2Accepted,
+					},
+				},
+				txn: &appStoreTransaction{
+					OriginalTransactionId: "123456789000001",
+					ExpiresDate:           1704067201000,
+				},
+
+				orepo: &repository.MockOrder{},
+				prepo: &repository.MockOrderPayHistory{},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				orderRepo:     tc.given.orepo,
+				orderItemRepo: tc.given.oirepo,
+				payHistRepo:   tc.given.prepo,
+				tlv2Repo:      &repository.MockTLV2{},
+				txnRepo:       &repository.MockTransaction{},
+				orderEvRepo:   &repository.MockOrderEvent{},
+				orderDunRepo:  &repository.MockOrderDunning{},
+				couponRepo:    &repository.MockCoupon{},
+				catalog:       newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				dunningCfg:    &dunningConfig{gracePeriod: 7 * 24 * time.Hour, reminderInterval: 2 * 24 * time.Hour},
+			}
+
+			ctx := context.Background()
+
+			proc := &appStoreProcessor{catalog: svc.catalog}
+
+			err := proc.processTx(ctx, nil, svc, tc.given.ntf, tc.given.txn)
+			should.Equal(t, true, errors.Is(err, tc.exp))
+		})
+	}
+}
+
+func TestService_renewOrderWithExpPaidTimeTx(t *testing.T) {
+	type tcGiven struct {
+		id     uuid.UUID
+		expt   time.Time
+		paidt  time.Time
+		orepo  *repository.MockOrder
+		prepo  *repository.MockOrderPayHistory
+		evRepo *repository.MockOrderEvent
+		crepo  *repository.MockCoupon
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "set_status_failed",
+			given: tcGiven{
+				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+				expt:  time.UnixMilli(1735689599000),
+				paidt: time.UnixMilli(1704067201000),
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "set_expires_at_failed",
+			given: tcGiven{
+				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+				expt:  time.UnixMilli(1735689599000),
+				paidt: time.UnixMilli(1704067201000),
+				orepo: &repository.MockOrder{
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "set_last_paid_at_failed",
+			given: tcGiven{
+				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
 				expt:  time.UnixMilli(1735689599000),
 				paidt: time.UnixMilli(1704067201000),
 				orepo: &repository.MockOrder{
@@ -732,6 +1087,40 @@ func TestService_renewOrderWithExpPaidTimeTx(t *testing.T) {
 			exp: model.Error("something_went_wrong"),
 		},
 
+		{
+			name: "redeem_coupon_failed",
+			given: tcGiven{
+				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+				expt:  time.UnixMilli(1735689599000),
+				paidt: time.UnixMilli(1704067201000),
+				orepo: &repository.MockOrder{},
+				prepo: &repository.MockOrderPayHistory{},
+				crepo: &repository.MockCoupon{
+					FnRedeemForOrder: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, now time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "insert_event_failed",
+			given: tcGiven{
+				id:    uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000")),
+				expt:  time.UnixMilli(1735689599000),
+				paidt: time.UnixMilli(1704067201000),
+				orepo: &repository.MockOrder{},
+				prepo: &repository.MockOrderPayHistory{},
+				evRepo: &repository.MockOrderEvent{
+					FnInsertPaid: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
 		{
 			name: "success",
 			given: tcGiven{
@@ -785,6 +1174,19 @@ func TestService_renewOrderWithExpPaidTimeTx(t *testing.T) {
 							return model.Error("unexpected: expt")
 						}
 
+						return nil
+					},
+				},
+				evRepo: &repository.MockOrderEvent{
+					FnInsertPaid: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error {
+						if !uuid.Equal(orderID, uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected: id")
+						}
+
+						if !paidAt.Equal(time.UnixMilli(1704067201000)) {
+							return model.Error("unexpected: paidt")
+						}
+
 						return nil
 					},
 				},
@@ -797,8 +1199,15 @@ func TestService_renewOrderWithExpPaidTimeTx(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			svc := &Service{
-				orderRepo:   tc.given.orepo,
-				payHistRepo: tc.given.prepo,
+				orderRepo:    tc.given.orepo,
+				payHistRepo:  tc.given.prepo,
+				orderEvRepo:  tc.given.evRepo,
+				orderDunRepo: &repository.MockOrderDunning{},
+				couponRepo:   tc.given.crepo,
+			}
+
+			if tc.given.crepo == nil {
+				svc.couponRepo = &repository.MockCoupon{}
 			}
 
 			ctx := context.Background()
@@ -1509,11 +1918,11 @@ func TestNewOrderNewForReq(t *testing.T) {
 
 func TestCreateOrderWithReceipt(t *testing.T) {
 	type tcGiven struct {
-		svc   *mockPaidOrderCreator
-		set   map[string]model.OrderItemRequestNew
-		ppcfg *premiumPaymentProcConfig
-		rcpt  model.ReceiptData
-		paidt time.Time
+		svc     *mockPaidOrderCreator
+		catalog *skuCatalog
+		ppcfg   *premiumPaymentProcConfig
+		rcpt    model.ReceiptData
+		paidt   time.Time
 	}
 
 	type tcExpected struct {
@@ -1531,9 +1940,9 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 		{
 			name: "error_in_newOrderItemReqForSubID",
 			given: tcGiven{
-				svc:   &mockPaidOrderCreator{},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				svc:     &mockPaidOrderCreator{},
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "invalid",
@@ -1553,8 +1962,8 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 						return nil, model.Error("something_went_wrong")
 					},
 				},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "brave.leo.monthly",
@@ -1578,8 +1987,8 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 						return model.Error("something_went_wrong")
 					},
 				},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "brave.leo.monthly",
@@ -1603,8 +2012,8 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 						return model.Error("something_went_wrong")
 					},
 				},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "brave.leo.monthly",
@@ -1659,8 +2068,8 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 						return nil
 					},
 				},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "brave.leo.monthly",
@@ -1722,8 +2131,8 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 						return nil
 					},
 				},
-				set:   newOrderItemReqNewMobileSet("development"),
-				ppcfg: newPaymentProcessorConfig("development"),
+				catalog: newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+				ppcfg:   newPaymentProcessorConfig("development"),
 				rcpt: model.ReceiptData{
 					Type:      model.VendorGoogle,
 					ProductID: "brave.vpn.monthly",
@@ -1747,7 +2156,7 @@ func TestCreateOrderWithReceipt(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			actual, err := createOrderWithReceipt(context.Background(), tc.given.svc, tc.given.set, tc.given.ppcfg, tc.given.rcpt, tc.given.paidt)
+			actual, err := createOrderWithReceipt(context.Background(), nil, tc.given.svc, tc.given.catalog, tc.given.ppcfg, tc.given.rcpt, tc.given.paidt)
 			must.Equal(t, tc.exp.err, err)
 
 			if tc.exp.err != nil {
@@ -2013,7 +2422,7 @@ func TestService_doTLV2ExistTxTime(t *testing.T) {
 
 			ctx := context.Background()
 
-			actual := svc.doTLV2ExistTxTime(ctx, nil, tc.given.reqID, tc.given.item, tc.given.firstBCred, tc.given.from, tc.given.to)
+			actual := svc.doTLV2ExistTxTime(ctx, nil, tc.given.reqID, uuid.Nil, tc.given.item, tc.given.firstBCred, tc.given.from, tc.given.to)
 			should.Equal(t, tc.exp, actual)
 		})
 	}
@@ -2258,11 +2667,13 @@ func TestService_appendOrderMetadataTx(t *testing.T) {
 	}
 }
 
-func TestService_processStripeNotificationTx(t *testing.T) {
+func TestStripeProcessor_ProcessNotification(t *testing.T) {
 	type tcGiven struct {
-		ntf     *stripeNotification
-		ordRepo orderStoreSvc
-		phRepo  orderPayHistoryStore
+		ntf      *stripeNotification
+		ordRepo  orderStoreSvc
+		phRepo   orderPayHistoryStore
+		stripeCl *xstripe.MockClient
+		scRepo   *repository.MockStripeSubCancel
 	}
 
 	type testCase struct {
@@ -2807,69 +3218,372 @@ func TestService_processStripeNotificationTx(t *testing.T) {
 				phRepo: &repository.MockOrderPayHistory{},
 			},
 		},
-	}
-
-	for i := range tests {
-		tc := tests[i]
-
-		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{orderRepo: tc.given.ordRepo, payHistRepo: tc.given.phRepo}
-
-			ctx := context.Background()
-
-			actual := svc.processStripeNotificationTx(ctx, nil, tc.given.ntf)
-			should.Equal(t, tc.exp, actual)
-		})
-	}
-}
-
-func TestShouldUpdateOrderStripeSubID(t *testing.T) {
-	type tcGiven struct {
-		ord   *model.Order
-		subID string
-	}
-
-	type testCase struct {
-		name  string
-		given tcGiven
-		exp   bool
-	}
 
-	tests := []testCase{
 		{
-			name: "true_sub_id_not_set",
+			name: "refund_unresolved",
 			given: tcGiven{
-				ord:   &model.Order{},
-				subID: "sub_id",
+				ntf: &stripeNotification{
+					raw:    &stripe.Event{Type: "charge.refunded"},
+					charge: &stripe.Charge{ID: "ch_id"},
+				},
+				ordRepo:  &repository.MockOrder{},
+				phRepo:   &repository.MockOrderPayHistory{},
+				stripeCl: &xstripe.MockClient{},
 			},
-			exp: true,
+			exp: errStripeRefundUnresolved,
 		},
 
 		{
-			name: "true_sub_id_empty",
+			name: "refund_success",
 			given: tcGiven{
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "",
+				ntf: &stripeNotification{
+					raw:     &stripe.Event{Type: "charge.dispute.created"},
+					dispute: &stripe.Dispute{ID: "dp_id", Charge: &stripe.Charge{ID: "ch_id"}, Amount: 999, Currency: "usd"},
+					refund: &stripeRefund{
+						txn: model.TransactionNew{
+							OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							ExternalTransactionID: "dp_id",
+							Kind:                  model.TransactionKindChargeback,
+						},
+						subID: "sub_id",
+						full:  true,
 					},
 				},
-				subID: "sub_id",
-			},
-			exp: true,
-		},
+				ordRepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if !uuid.Equal(id, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_id")
+						}
 
-		{
-			name: "true_sub_id_different",
-			given: tcGiven{
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "old_sub_id",
+						if status != model.OrderStatusRefunded {
+							return model.Error("unexpected_status")
+						}
+
+						return nil
 					},
 				},
-				subID: "sub_id",
-			},
-			exp: true,
-		},
+				phRepo: &repository.MockOrderPayHistory{},
+				stripeCl: &xstripe.MockClient{
+					FnCharge: func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+						return nil, model.Error("unexpected_charge")
+					},
+
+					FnCancelSub: func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+						return nil, model.Error("unexpected_cancel_sub")
+					},
+				},
+				scRepo: &repository.MockStripeSubCancel{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+						if !uuid.Equal(orderID, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_order_id")
+						}
+
+						if subID != "sub_id" {
+							return model.Error("unexpected_sub_id")
+						}
+
+						return nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "refund_schedule_cancel_error",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:     &stripe.Event{Type: "charge.dispute.created"},
+					dispute: &stripe.Dispute{ID: "dp_id", Charge: &stripe.Charge{ID: "ch_id"}, Amount: 999, Currency: "usd"},
+					refund: &stripeRefund{
+						txn: model.TransactionNew{
+							OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							ExternalTransactionID: "dp_id",
+							Kind:                  model.TransactionKindChargeback,
+						},
+						subID: "sub_id",
+						full:  true,
+					},
+				},
+				ordRepo:  &repository.MockOrder{},
+				phRepo:   &repository.MockOrderPayHistory{},
+				stripeCl: &xstripe.MockClient{},
+				scRepo: &repository.MockStripeSubCancel{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "refund_partial_keeps_sub",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:    &stripe.Event{Type: "charge.refunded"},
+					charge: &stripe.Charge{ID: "ch_id"},
+					refund: &stripeRefund{
+						txn: model.TransactionNew{
+							OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							ExternalTransactionID: "re_id",
+							Kind:                  model.TransactionKindRefund,
+						},
+						subID: "sub_id",
+					},
+				},
+				ordRepo:  &repository.MockOrder{},
+				phRepo:   &repository.MockOrderPayHistory{},
+				stripeCl: &xstripe.MockClient{},
+				scRepo: &repository.MockStripeSubCancel{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+						return model.Error("unexpected_insert")
+					},
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.given.scRepo == nil {
+				tc.given.scRepo = &repository.MockStripeSubCancel{}
+			}
+
+			svc := &Service{
+				orderRepo:     tc.given.ordRepo,
+				payHistRepo:   tc.given.phRepo,
+				txnRepo:       &repository.MockTransaction{},
+				tlv2Repo:      &repository.MockTLV2{},
+				orderEvRepo:   &repository.MockOrderEvent{},
+				orderDunRepo:  &repository.MockOrderDunning{},
+				subCancelRepo: tc.given.scRepo,
+				couponRepo:    &repository.MockCoupon{},
+				catalog:       newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+			}
+
+			ctx := context.Background()
+
+			proc := &stripeProcessor{cl: tc.given.stripeCl, catalog: svc.catalog}
+
+			actual := proc.ProcessNotification(ctx, nil, svc, tc.given.ntf)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestStripeProcessor_ResolveNotification(t *testing.T) {
+	type tcGiven struct {
+		ntf *stripeNotification
+		cl  *xstripe.MockClient
+	}
+
+	type tcExpected struct {
+		val *stripeRefund
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	newCharge := func(amount int64) func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+		return func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+			if id != "ch_id" {
+				return nil, model.Error("unexpected_id")
+			}
+
+			result := &stripe.Charge{
+				ID:     id,
+				Amount: amount,
+				Invoice: &stripe.Invoice{
+					Subscription: &stripe.Subscription{ID: "sub_id"},
+					Lines: &stripe.InvoiceLineList{
+						Data: []*stripe.InvoiceLine{
+							{
+								Metadata: map[string]string{
+									"orderID": "facade00-0000-4000-a000-000000000000",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			return result, nil
+		}
+	}
+
+	tests := []testCase{
+		{
+			name: "not_refund",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:     &stripe.Event{Type: "invoice.paid"},
+					invoice: &stripe.Invoice{},
+				},
+				cl: &xstripe.MockClient{
+					FnCharge: func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+						return nil, model.Error("unexpected_charge")
+					},
+				},
+			},
+		},
+
+		{
+			name: "charge_id_error",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:     &stripe.Event{Type: "charge.dispute.created"},
+					dispute: &stripe.Dispute{ID: "dp_id"},
+				},
+				cl: &xstripe.MockClient{},
+			},
+			exp: tcExpected{err: errStripeNoDisputeCharge},
+		},
+
+		{
+			name: "charge_error",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:    &stripe.Event{Type: "charge.refunded"},
+					charge: &stripe.Charge{ID: "ch_id"},
+				},
+				cl: &xstripe.MockClient{
+					FnCharge: func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "no_invoice",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:    &stripe.Event{Type: "charge.refunded"},
+					charge: &stripe.Charge{ID: "ch_id"},
+				},
+				cl: &xstripe.MockClient{},
+			},
+			exp: tcExpected{err: errStripeNoChargeInvoice},
+		},
+
+		{
+			name: "partial_refund",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:    &stripe.Event{Type: "charge.refunded"},
+					charge: &stripe.Charge{ID: "ch_id", Currency: "usd", Amount: 999, AmountRefunded: 500},
+				},
+				cl: &xstripe.MockClient{FnCharge: newCharge(999)},
+			},
+			exp: tcExpected{
+				val: &stripeRefund{
+					txn: model.TransactionNew{
+						OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+						ExternalTransactionID: "ch_id",
+						Status:                model.TransactionStatusCompleted,
+						Currency:              "USD",
+						Kind:                  model.TransactionKindRefund,
+						Amount:                decimal.New(-500, -2),
+					},
+					subID: "sub_id",
+				},
+			},
+		},
+
+		{
+			name: "full_dispute",
+			given: tcGiven{
+				ntf: &stripeNotification{
+					raw:     &stripe.Event{Type: "charge.dispute.created"},
+					dispute: &stripe.Dispute{ID: "dp_id", Charge: &stripe.Charge{ID: "ch_id"}, Amount: 999, Currency: "usd"},
+				},
+				cl: &xstripe.MockClient{FnCharge: newCharge(999)},
+			},
+			exp: tcExpected{
+				val: &stripeRefund{
+					txn: model.TransactionNew{
+						OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+						ExternalTransactionID: "dp_id",
+						Status:                model.TransactionStatusCompleted,
+						Currency:              "USD",
+						Kind:                  model.TransactionKindChargeback,
+						Amount:                decimal.New(-999, -2),
+					},
+					subID: "sub_id",
+					full:  true,
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			proc := &stripeProcessor{cl: tc.given.cl}
+
+			actual := proc.ResolveNotification(context.Background(), tc.given.ntf)
+			must.Equal(t, tc.exp.err, actual)
+
+			should.Equal(t, tc.exp.val, tc.given.ntf.refund)
+		})
+	}
+}
+
+func TestShouldUpdateOrderStripeSubID(t *testing.T) {
+	type tcGiven struct {
+		ord   *model.Order
+		subID string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "true_sub_id_not_set",
+			given: tcGiven{
+				ord:   &model.Order{},
+				subID: "sub_id",
+			},
+			exp: true,
+		},
+
+		{
+			name: "true_sub_id_empty",
+			given: tcGiven{
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "",
+					},
+				},
+				subID: "sub_id",
+			},
+			exp: true,
+		},
+
+		{
+			name: "true_sub_id_different",
+			given: tcGiven{
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "old_sub_id",
+					},
+				},
+				subID: "sub_id",
+			},
+			exp: true,
+		},
 
 		{
 			name: "false_sub_id_same",
@@ -3719,9 +4433,13 @@ func TestService_updateOrderStripeSession(t *testing.T) {
 
 		t.Run(tc.name, func(t *testing.T) {
 			svc := &Service{
-				orderRepo:   tc.given.ordRepo,
-				payHistRepo: tc.given.payRepo,
-				stripeCl:    tc.given.cl,
+				orderRepo:    tc.given.ordRepo,
+				payHistRepo:  tc.given.payRepo,
+				stripeCl:     tc.given.cl,
+				orderEvRepo:  &repository.MockOrderEvent{},
+				orderDunRepo: &repository.MockOrderDunning{},
+				couponRepo:   &repository.MockCoupon{},
+				catalog:      newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
 			}
 
 			ctx := context.Background()
@@ -3732,7 +4450,7 @@ func TestService_updateOrderStripeSession(t *testing.T) {
 	}
 }
 
-func TestService_renewOrderStripe(t *testing.T) {
+func TestRenewOrderStripe(t *testing.T) {
 	type tcGiven struct {
 		ordRepo *repository.MockOrder
 		payRepo *repository.MockOrderPayHistory
@@ -3959,17 +4677,17 @@ func TestService_renewOrderStripe(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{orderRepo: tc.given.ordRepo, payHistRepo: tc.given.payRepo}
+			svc := &Service{orderRepo: tc.given.ordRepo, payHistRepo: tc.given.payRepo, orderEvRepo: &repository.MockOrderEvent{}, orderDunRepo: &repository.MockOrderDunning{}, couponRepo: &repository.MockCoupon{}}
 
 			ctx := context.Background()
 
-			actual := svc.renewOrderStripe(ctx, nil, tc.given.ord, tc.given.subID, tc.given.expt, tc.given.paidt)
+			actual := renewOrderStripe(ctx, nil, svc, tc.given.ord, tc.given.subID, tc.given.expt, tc.given.paidt)
 			should.Equal(t, tc.exp, actual)
 		})
 	}
 }
 
-func TestService_recordPayFailureStripe(t *testing.T) {
+func TestRecordPayFailureStripe(t *testing.T) {
 	type tcGiven struct {
 		ordRepo *repository.MockOrder
 		payRepo *repository.MockOrderPayHistory
@@ -4058,89 +4776,499 @@ func TestService_recordPayFailureStripe(t *testing.T) {
 							return model.Error("unexpected")
 						}
 
-						return nil
-					},
-				},
-				payRepo: &repository.MockOrderPayHistory{},
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "old_sub_id",
-						"paymentProcessor":     "stripe",
-					},
-				},
-				subID: "sub_id",
+						return nil
+					},
+				},
+				payRepo: &repository.MockOrderPayHistory{},
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "old_sub_id",
+						"paymentProcessor":     "stripe",
+					},
+				},
+				subID: "sub_id",
+			},
+		},
+
+		{
+			name: "success_should_update_sub_id_should_update_payment_proc",
+			given: tcGiven{
+				ordRepo: &repository.MockOrder{
+					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+						if key == "stripeSubscriptionId" && val == "sub_id" {
+							return nil
+						}
+
+						if key == "paymentProcessor" && val == "stripe" {
+							return nil
+						}
+
+						return model.Error("unexpected_metadata_string")
+					},
+				},
+				payRepo: &repository.MockOrderPayHistory{},
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "old_sub_id",
+					},
+				},
+				subID: "sub_id",
+			},
+		},
+
+		{
+			name: "success_no_update_sub_id_should_update_payment_proc",
+			given: tcGiven{
+				ordRepo: &repository.MockOrder{
+					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+						if key == "stripeSubscriptionId" {
+							return model.Error("unexpected")
+						}
+
+						if key == "paymentProcessor" && val == "stripe" {
+							return nil
+						}
+
+						return nil
+					},
+				},
+				payRepo: &repository.MockOrderPayHistory{},
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "sub_id",
+					},
+				},
+				subID: "sub_id",
+			},
+		},
+
+		{
+			name: "success_no_update_sub_id_no_update_payment_proc",
+			given: tcGiven{
+				ordRepo: &repository.MockOrder{
+					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
+						return model.Error("unexpected")
+					},
+				},
+				payRepo: &repository.MockOrderPayHistory{},
+				ord: &model.Order{
+					Metadata: datastore.Metadata{
+						"stripeSubscriptionId": "sub_id",
+						"paymentProcessor":     "stripe",
+					},
+				},
+				subID: "sub_id",
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{orderRepo: tc.given.ordRepo, payHistRepo: tc.given.payRepo, orderEvRepo: &repository.MockOrderEvent{}, orderDunRepo: &repository.MockOrderDunning{}, couponRepo: &repository.MockCoupon{}}
+
+			ctx := context.Background()
+
+			actual := recordPayFailureStripe(ctx, nil, svc, tc.given.ord, tc.given.subID)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestService_changeOrderPlanTx(t *testing.T) {
+	type tcGiven struct {
+		ord      *model.Order
+		skuVnt   string
+		expt     time.Time
+		now      time.Time
+		ordRepo  *repository.MockOrder
+		itemRepo *repository.MockOrderItem
+		prcRepo  *repository.MockSKUPrice
+	}
+
+	type tcExpected struct {
+		item *model.OrderItem
+		expt time.Time
+		err  error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "error_multiple_items",
+			given: tcGiven{
+				ord: &model.Order{
+					Items: []model.OrderItem{{SKUVnt: "brave-vpn-premium"}, {SKUVnt: "brave-leo-premium"}},
+				},
+				skuVnt:   "brave-vpn-premium-year",
+				ordRepo:  &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{},
+			},
+			exp: tcExpected{err: model.ErrOrderPlanChangeItems},
+		},
+
+		{
+			name: "same_plan",
+			given: tcGiven{
+				ord: &model.Order{
+					Items: []model.OrderItem{{SKUVnt: "brave-vpn-premium"}},
+				},
+				skuVnt:  "brave-vpn-premium",
+				ordRepo: &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{
+					FnUpdatePlan: func(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error {
+						return model.Error("unexpected")
+					},
+				},
+			},
+		},
+
+		{
+			name: "error_plan_not_found",
+			given: tcGiven{
+				ord: &model.Order{
+					Items: []model.OrderItem{{SKUVnt: "brave-vpn-premium"}},
+				},
+				skuVnt:   "brave-talk-premium",
+				ordRepo:  &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{},
+			},
+			exp: tcExpected{err: model.ErrOrderPlanNotFound},
+		},
+
+		{
+			name: "error_cred_type_mismatch",
+			given: tcGiven{
+				ord: &model.Order{
+					Items: []model.OrderItem{{SKUVnt: "brave-vpn-premium", CredentialType: "single-use"}},
+				},
+				skuVnt:   "brave-vpn-premium-year",
+				ordRepo:  &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{},
+			},
+			exp: tcExpected{err: model.ErrUnsupportedCredType},
+		},
+
+		{
+			name: "error_update_plan",
+			given: tcGiven{
+				ord: &model.Order{
+					Items: []model.OrderItem{{SKUVnt: "brave-vpn-premium", CredentialType: "time-limited-v2"}},
+				},
+				skuVnt:  "brave-vpn-premium-year",
+				ordRepo: &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{
+					FnUpdatePlan: func(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error {
+						return model.ErrOrderItemNotFound
+					},
+				},
+			},
+			exp: tcExpected{err: model.ErrOrderItemNotFound},
+		},
+
+		{
+			name: "success_vendor_expt",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:         uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					MerchantID: "brave.com",
+					Items: []model.OrderItem{
+						{
+							ID:             uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+							OrderID:        uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+							SKU:            "brave-vpn-premium",
+							SKUVnt:         "brave-vpn-premium",
+							Currency:       "USD",
+							Quantity:       1,
+							CredentialType: "time-limited-v2",
+						},
+					},
+				},
+				skuVnt:   "brave-vpn-premium-year",
+				expt:     time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				ordRepo:  &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{},
+			},
+			exp: tcExpected{
+				item: &model.OrderItem{
+					ID:       uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+					OrderID:  uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					SKU:      "brave-vpn-premium",
+					SKUVnt:   "brave-vpn-premium-year",
+					Currency: "USD",
+					Quantity: 1,
+					Price:    decimal.RequireFromString("99.99"),
+					Subtotal: decimal.RequireFromString("99.99"),
+				},
+				expt: time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+
+		{
+			name: "success_price_book",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:         uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					MerchantID: "brave.com",
+					Items: []model.OrderItem{
+						{
+							ID:             uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+							OrderID:        uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+							SKU:            "brave-vpn-premium",
+							SKUVnt:         "brave-vpn-premium",
+							Currency:       "INR",
+							Quantity:       1,
+							CredentialType: "time-limited-v2",
+							Metadata:       datastore.Metadata{"price_country": "IN"},
+						},
+					},
+				},
+				skuVnt:   "brave-vpn-premium-year",
+				expt:     time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				ordRepo:  &repository.MockOrder{},
+				itemRepo: &repository.MockOrderItem{},
+				prcRepo: &repository.MockSKUPrice{
+					FnFindBySKUVnt: func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error) {
+						if skuVnt != "brave-vpn-premium-year" || currency != "INR" || country != "IN" {
+							return nil, model.Error("unexpected")
+						}
+
+						result := &model.SKUPrice{
+							ID:       uuid.FromStringOrNil("cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d"),
+							Currency: "INR",
+							Country:  "IN",
+							Amount:   decimal.RequireFromString("4999"),
+						}
+
+						return result, nil
+					},
+				},
+			},
+			exp: tcExpected{
+				item: &model.OrderItem{
+					ID:       uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+					OrderID:  uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					SKU:      "brave-vpn-premium",
+					SKUVnt:   "brave-vpn-premium-year",
+					Currency: "INR",
+					Quantity: 1,
+					Price:    decimal.RequireFromString("4999"),
+					Subtotal: decimal.RequireFromString("4999"),
+				},
+				expt: time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+
+		{
+			name: "success_no_expires_at_to_prorate",
+			given: tcGiven{
+				ord: &model.Order{
+					ID:         uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					MerchantID: "brave.com",
+					Items: []model.OrderItem{
+						{
+							ID:             uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+							OrderID:        uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+							SKU:            "brave-vpn-premium",
+							SKUVnt:         "brave-vpn-premium-year",
+							Currency:       "USD",
+							Quantity:       1,
+							CredentialType: "time-limited-v2",
+						},
+					},
+				},
+				skuVnt:   "brave-leo-premium",
+				now:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				itemRepo: &repository.MockOrderItem{},
+				ordRepo: &repository.MockOrder{
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected")
+					},
+				},
+			},
+			exp: tcExpected{
+				item: &model.OrderItem{
+					ID:       uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"),
+					OrderID:  uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"),
+					SKU:      "brave-leo-premium",
+					SKUVnt:   "brave-leo-premium",
+					Currency: "USD",
+					Quantity: 1,
+					Price:    decimal.RequireFromString("14.99"),
+					Subtotal: decimal.RequireFromString("14.99"),
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				actualItem *model.OrderItem
+				actualExpt time.Time
+			)
+
+			if tc.given.itemRepo.FnUpdatePlan == nil {
+				tc.given.itemRepo.FnUpdatePlan = func(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error {
+					actualItem = item
+
+					return nil
+				}
+			}
+
+			if tc.given.ordRepo.FnSetExpiresAt == nil {
+				tc.given.ordRepo.FnSetExpiresAt = func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+					actualExpt = when
+
+					return nil
+				}
+			}
+
+			if tc.given.prcRepo == nil {
+				tc.given.prcRepo = &repository.MockSKUPrice{}
+			}
+
+			svc := &Service{
+				orderRepo:     tc.given.ordRepo,
+				orderItemRepo: tc.given.itemRepo,
+				issuerRepo:    &repository.MockIssuer{},
+				skuPriceRepo:  tc.given.prcRepo,
+				catalog:       newSKUCatalog(&repository.MockSKUCatalog{}, "development"),
+			}
+
+			ctx := context.Background()
+
+			err := svc.changeOrderPlanTx(ctx, nil, tc.given.ord, tc.given.skuVnt, tc.given.expt, tc.given.now)
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.expt, actualExpt)
+
+			if tc.exp.item == nil {
+				return
+			}
+
+			must.NotNil(t, actualItem)
+
+			should.Equal(t, tc.exp.item.ID, actualItem.ID)
+			should.Equal(t, tc.exp.item.OrderID, actualItem.OrderID)
+			should.Equal(t, tc.exp.item.SKU, actualItem.SKU)
+			should.Equal(t, tc.exp.item.SKUVnt, actualItem.SKUVnt)
+			should.Equal(t, tc.exp.item.Currency, actualItem.Currency)
+			should.Equal(t, tc.exp.item.Quantity, actualItem.Quantity)
+			should.True(t, tc.exp.item.Price.Equal(actualItem.Price))
+			should.True(t, tc.exp.item.Subtotal.Equal(actualItem.Subtotal))
+		})
+	}
+}
+
+func TestProrateExpiresAt(t *testing.T) {
+	type tcGiven struct {
+		now        time.Time
+		expt       time.Time
+		prevPrice  decimal.Decimal
+		prevPeriod string
+		nextPrice  decimal.Decimal
+		nextPeriod string
+	}
+
+	type tcExpected struct {
+		val time.Time
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "already_expired",
+			given: tcGiven{
+				now:        time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.NewFromInt(10),
+				prevPeriod: "P1M",
+				nextPrice:  decimal.NewFromInt(20),
+				nextPeriod: "P1M",
 			},
+			exp: tcExpected{val: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
 		},
 
 		{
-			name: "success_should_update_sub_id_should_update_payment_proc",
+			name: "zero_price",
 			given: tcGiven{
-				ordRepo: &repository.MockOrder{
-					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
-						if key == "stripeSubscriptionId" && val == "sub_id" {
-							return nil
-						}
-
-						if key == "paymentProcessor" && val == "stripe" {
-							return nil
-						}
-
-						return model.Error("unexpected_metadata_string")
-					},
-				},
-				payRepo: &repository.MockOrderPayHistory{},
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "old_sub_id",
-					},
-				},
-				subID: "sub_id",
+				now:        time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.Zero,
+				prevPeriod: "P1M",
+				nextPrice:  decimal.NewFromInt(20),
+				nextPeriod: "P1M",
 			},
+			exp: tcExpected{val: time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC)},
 		},
 
 		{
-			name: "success_no_update_sub_id_should_update_payment_proc",
+			name: "invalid_period",
 			given: tcGiven{
-				ordRepo: &repository.MockOrder{
-					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
-						if key == "stripeSubscriptionId" {
-							return model.Error("unexpected")
-						}
+				now:        time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.NewFromInt(10),
+				prevPeriod: "",
+				nextPrice:  decimal.NewFromInt(20),
+				nextPeriod: "P1M",
+			},
+			exp: tcExpected{err: errors.New("any")},
+		},
 
-						if key == "paymentProcessor" && val == "stripe" {
-							return nil
-						}
+		{
+			name: "same_price_same_period",
+			given: tcGiven{
+				now:        time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.NewFromInt(10),
+				prevPeriod: "P1M",
+				nextPrice:  decimal.NewFromInt(10),
+				nextPeriod: "P1M",
+			},
+			exp: tcExpected{val: time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC)},
+		},
 
-						return nil
-					},
-				},
-				payRepo: &repository.MockOrderPayHistory{},
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "sub_id",
-					},
-				},
-				subID: "sub_id",
+		{
+			name: "double_price_same_period",
+			given: tcGiven{
+				now:        time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.NewFromInt(10),
+				prevPeriod: "P1M",
+				nextPrice:  decimal.NewFromInt(20),
+				nextPeriod: "P1M",
 			},
+			exp: tcExpected{val: time.Date(2023, time.January, 6, 0, 0, 0, 0, time.UTC)},
 		},
 
 		{
-			name: "success_no_update_sub_id_no_update_payment_proc",
+			name: "monthly_to_yearly",
 			given: tcGiven{
-				ordRepo: &repository.MockOrder{
-					FnAppendMetadata: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key, val string) error {
-						return model.Error("unexpected")
-					},
-				},
-				payRepo: &repository.MockOrderPayHistory{},
-				ord: &model.Order{
-					Metadata: datastore.Metadata{
-						"stripeSubscriptionId": "sub_id",
-						"paymentProcessor":     "stripe",
-					},
-				},
-				subID: "sub_id",
+				now:        time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+				expt:       time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC),
+				prevPrice:  decimal.NewFromInt(10),
+				prevPeriod: "P1M",
+				nextPrice:  decimal.NewFromInt(365),
+				nextPeriod: "P1Y",
 			},
+			exp: tcExpected{val: time.Date(2023, time.January, 11, 0, 0, 0, 0, time.UTC)},
 		},
 	}
 
@@ -4148,21 +5276,25 @@ func TestService_recordPayFailureStripe(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{orderRepo: tc.given.ordRepo, payHistRepo: tc.given.payRepo}
+			actual, err := prorateExpiresAt(tc.given.now, tc.given.expt, tc.given.prevPrice, tc.given.prevPeriod, tc.given.nextPrice, tc.given.nextPeriod)
+			if tc.exp.err != nil {
+				should.Error(t, err)
+				return
+			}
 
-			ctx := context.Background()
+			must.Equal(t, nil, err)
 
-			actual := svc.recordPayFailureStripe(ctx, nil, tc.given.ord, tc.given.subID)
-			should.Equal(t, tc.exp, actual)
+			should.Equal(t, tc.exp.val, actual)
 		})
 	}
 }
 
-func TestService_createStripeSession(t *testing.T) {
+func TestStripeProcessor_createSession(t *testing.T) {
 	type tcGiven struct {
-		cl  *xstripe.MockClient
-		req *model.CreateOrderRequestNew
-		ord *model.Order
+		cl     *xstripe.MockClient
+		req    *model.CreateOrderRequestNew
+		ord    *model.Order
+		coupon *model.Coupon
 	}
 
 	type tcExpected struct {
@@ -4260,17 +5392,68 @@ func TestService_createStripeSession(t *testing.T) {
 				val: "cs_test_id",
 			},
 		},
+
+		{
+			name: "success_coupon",
+			given: tcGiven{
+				cl: &xstripe.MockClient{
+					FnCreateSession: func(ctx context.Context, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
+						if len(params.Discounts) != 1 || *params.Discounts[0].Coupon != "stripe_coupon_id" {
+							return nil, model.Error("unexpected")
+						}
+
+						result := &stripe.CheckoutSession{
+							ID:                 "cs_test_id_coupon",
+							PaymentMethodTypes: []string{"card"},
+							Mode:               stripe.CheckoutSessionModeSubscription,
+						}
+
+						return result, nil
+					},
+				},
+				req: &model.CreateOrderRequestNew{
+					Email:    "you@example.com",
+					Currency: "USD",
+					StripeMetadata: &model.OrderStripeMetadata{
+						SuccessURI: "https://example.com/success",
+						CancelURI:  "https://example.com/cancel",
+					},
+					PaymentMethods: []string{"stripe"},
+					Discounts:      []string{"discount_01"},
+				},
+				ord: &model.Order{
+					ID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					Items: []model.OrderItem{
+						{
+							ID:       uuid.Must(uuid.FromString("f100ded0-0000-4000-a000-000000000000")),
+							OrderID:  uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+							Quantity: 1,
+							Metadata: datastore.Metadata{
+								"stripe_item_id": "stripe_item_id",
+							},
+						},
+					},
+				},
+				coupon: &model.Coupon{
+					Code:           "SUMMER",
+					StripeCouponID: ptrTo("stripe_coupon_id"),
+				},
+			},
+			exp: tcExpected{
+				val: "cs_test_id_coupon",
+			},
+		},
 	}
 
 	for i := range tests {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{stripeCl: tc.given.cl}
+			proc := &stripeProcessor{cl: tc.given.cl}
 
 			ctx := context.Background()
 
-			actual, err := svc.createStripeSession(ctx, tc.given.req, tc.given.ord)
+			actual, err := proc.createSession(ctx, tc.given.req, tc.given.ord, tc.given.coupon)
 			must.Equal(t, tc.exp.err, err)
 
 			should.Equal(t, tc.exp.val, actual)
@@ -5090,7 +6273,7 @@ func TestBuildStripeDiscounts(t *testing.T) {
 	}
 }
 
-func TestService_createRadomSessID(t *testing.T) {
+func TestRadomProcessor_createSession(t *testing.T) {
 	type tcExpected struct {
 		sessionID string
 		mustErr   must.ErrorAssertionFunc
@@ -5232,12 +6415,10 @@ func TestService_createRadomSessID(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			s := Service{
-				radomClient: tc.given.radCl,
-			}
+			proc := &radomProcessor{cl: tc.given.radCl}
 			ctx := context.Background()
 
-			actual, err := s.createRadomSession(ctx, tc.given.req, tc.given.order)
+			actual, err := proc.createSession(ctx, tc.given.req, tc.given.order)
 			tc.exp.mustErr(t, err)
 
 			should.Equal(t, tc.exp.sessionID, actual)
@@ -5299,6 +6480,75 @@ func Test_orderItemsToLineItems(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			name: "success_discounted",
+			given: tcGiven{
+				orderItems: []OrderItem{
+					{
+						Currency: "USD",
+						Quantity: 2,
+						Price:    decimal.RequireFromString("9.99"),
+						Subtotal: decimal.RequireFromString("14.98"),
+						Metadata: map[string]interface{}{
+							"radom_product_id": "product_1",
+						},
+					},
+					{
+						Currency: "USD",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("4.99"),
+						Subtotal: decimal.RequireFromString("4.99"),
+						Metadata: map[string]interface{}{
+							"radom_product_id": "product_2",
+						},
+					},
+				},
+			},
+			exp: tcExpected{
+				lineItems: []radom.LineItem{
+					{
+						ProductID: "product_1",
+						ItemData: &radom.LineItemData{
+							Price:    7.49,
+							Currency: "USD",
+						},
+					},
+					{
+						ProductID: "product_2",
+					},
+				},
+			},
+		},
+
+		{
+			name: "local_price",
+			given: tcGiven{
+				orderItems: []model.OrderItem{
+					{
+						Currency: "INR",
+						Quantity: 1,
+						Price:    decimal.RequireFromString("499"),
+						Subtotal: decimal.RequireFromString("499"),
+						Metadata: map[string]interface{}{
+							"radom_product_id": "product_1",
+							"price_id":         "cfa3a1fb-3a59-4c3b-9a1f-0b2a2f4a5e6d",
+						},
+					},
+				},
+			},
+			exp: tcExpected{
+				lineItems: []radom.LineItem{
+					{
+						ProductID: "product_1",
+						ItemData: &radom.LineItemData{
+							Price:    499,
+							Currency: "INR",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for i := range tests {
@@ -5423,7 +6673,7 @@ func Test_newRadomGateway(t *testing.T) {
 	}
 }
 
-func TestService_processRadomEvent(t *testing.T) {
+func TestService_processPaymentNotification_Radom(t *testing.T) {
 	type tcGiven struct {
 		event *radom.Notification
 	}
@@ -5453,14 +6703,14 @@ func TestService_processRadomEvent(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			s := Service{}
 
-			actual := s.processRadomNotification(context.Background(), tc.given.event)
+			actual := s.processPaymentNotification(context.Background(), &radomProcessor{}, &radomNotification{Notification: tc.given.event})
 
 			should.ErrorIs(t, actual, tc.exp.err)
 		})
 	}
 }
 
-func TestService_processRadomEventTx(t *testing.T) {
+func TestRadomProcessor_ProcessNotification(t *testing.T) {
 	type tcGiven struct {
 		event           *radom.Notification
 		orderRepo       orderStoreSvc
@@ -5595,15 +6845,106 @@ func TestService_processRadomEventTx(t *testing.T) {
 			},
 		},
 
-		{
-			name: "unknown_action",
-			given: tcGiven{
-				event: &radom.Notification{},
-			},
-			exp: tcExpected{
-				shouldErr: func(t should.TestingT, err error, i ...interface{}) bool {
-					return should.ErrorIs(t, err, errRadomUnknownAction)
+		{
+			name: "unknown_action",
+			given: tcGiven{
+				event: &radom.Notification{},
+			},
+			exp: tcExpected{
+				shouldErr: func(t should.TestingT, err error, i ...interface{}) bool {
+					return should.ErrorIs(t, err, errRadomUnknownAction)
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{orderRepo: tc.given.orderRepo, payHistRepo: tc.given.orderPayHistory, orderEvRepo: &repository.MockOrderEvent{}, orderDunRepo: &repository.MockOrderDunning{}, couponRepo: &repository.MockCoupon{}}
+			proc := &radomProcessor{cl: tc.given.radomCl}
+
+			ctx := context.Background()
+
+			actual := proc.ProcessNotification(ctx, nil, svc, &radomNotification{Notification: tc.given.event})
+			tc.exp.shouldErr(t, actual)
+		})
+	}
+}
+
+func TestService_cancelOrderTx(t *testing.T) {
+	type tcGiven struct {
+		orepo  *repository.MockOrder
+		evRepo *repository.MockOrderEvent
+		id     uuid.UUID
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name: "error",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				evRepo: &repository.MockOrderEvent{},
+				id:     uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "error_insert_event",
+			given: tcGiven{
+				orepo: &repository.MockOrder{},
+				evRepo: &repository.MockOrderEvent{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+				id: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if !uuid.Equal(id, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_id")
+						}
+
+						if status != model.OrderStatusCanceled {
+							return model.Error("unexpected_status")
+						}
+
+						return nil
+					},
+				},
+				evRepo: &repository.MockOrderEvent{
+					FnInsert: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+						if !uuid.Equal(orderID, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
+							return model.Error("unexpected_id")
+						}
+
+						if typ != model.OrderEventCanceled {
+							return model.Error("unexpected_type")
+						}
+
+						return nil
+					},
 				},
+				id: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
 			},
 		},
 	}
@@ -5612,20 +6953,24 @@ func TestService_processRadomEventTx(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{orderRepo: tc.given.orderRepo, payHistRepo: tc.given.orderPayHistory, radomClient: tc.given.radomCl}
+			svc := &Service{orderRepo: tc.given.orepo, orderEvRepo: tc.given.evRepo, orderDunRepo: &repository.MockOrderDunning{}, couponRepo: &repository.MockCoupon{}}
 
 			ctx := context.Background()
 
-			actual := svc.processRadomNotificationTx(ctx, nil, tc.given.event)
-			tc.exp.shouldErr(t, actual)
+			actual := svc.cancelOrderTx(ctx, nil, tc.given.id)
+			should.ErrorIs(t, actual, tc.exp)
 		})
 	}
 }
 
-func TestService_cancelOrderTx(t *testing.T) {
+func TestService_refundOrderTx(t *testing.T) {
 	type tcGiven struct {
-		orepo *repository.MockOrder
-		id    uuid.UUID
+		req      model.TransactionNew
+		full     bool
+		now      time.Time
+		orepo    *repository.MockOrder
+		txnRepo  *repository.MockTransaction
+		tlv2Repo *repository.MockTLV2
 	}
 
 	type testCase struct {
@@ -5636,35 +6981,150 @@ func TestService_cancelOrderTx(t *testing.T) {
 
 	tests := []testCase{
 		{
-			name: "error",
+			name: "insert_error",
+			given: tcGiven{
+				req:   model.TransactionNew{OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))},
+				orepo: &repository.MockOrder{},
+				txnRepo: &repository.MockTransaction{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+						return nil, model.Error("something_went_wrong")
+					},
+				},
+				tlv2Repo: &repository.MockTLV2{},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "already_refunded",
 			given: tcGiven{
+				req: model.TransactionNew{OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))},
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						return model.Error("unexpected")
+					},
+				},
+				txnRepo: &repository.MockTransaction{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+						return nil, model.ErrTransactionAlreadyExists
+					},
+				},
+				tlv2Repo: &repository.MockTLV2{},
+			},
+		},
+
+		{
+			name: "set_status_error",
+			given: tcGiven{
+				full: true,
+				req:  model.TransactionNew{OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))},
 				orepo: &repository.MockOrder{
 					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
 						return model.Error("something_went_wrong")
 					},
 				},
-				id: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+				txnRepo:  &repository.MockTransaction{},
+				tlv2Repo: &repository.MockTLV2{},
 			},
 			exp: model.Error("something_went_wrong"),
 		},
 
 		{
-			name: "success",
+			name: "delete_creds_error",
 			given: tcGiven{
+				full:    true,
+				req:     model.TransactionNew{OrderID: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))},
+				orepo:   &repository.MockOrder{},
+				txnRepo: &repository.MockTransaction{},
+				tlv2Repo: &repository.MockTLV2{
+					FnDeleteValidAfter: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error {
+						return model.Error("something_went_wrong")
+					},
+				},
+			},
+			exp: model.Error("something_went_wrong"),
+		},
+
+		{
+			name: "partial",
+			given: tcGiven{
+				req: model.TransactionNew{
+					OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					ExternalTransactionID: "re_id",
+					Kind:                  model.TransactionKindRefund,
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
 				orepo: &repository.MockOrder{
 					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
-						if !uuid.Equal(id, uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))) {
-							return model.Error("unexpected_id")
+						return model.Error("unexpected_set_status")
+					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						return model.Error("unexpected_set_expires_at")
+					},
+				},
+				txnRepo: &repository.MockTransaction{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+						if req.ExternalTransactionID != "re_id" {
+							return nil, model.Error("unexpected_ext_id")
 						}
 
-						if status != model.OrderStatusCanceled {
+						return &model.Transaction{ID: uuid.NewV4()}, nil
+					},
+				},
+				tlv2Repo: &repository.MockTLV2{
+					FnDeleteValidAfter: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error {
+						return model.Error("unexpected_delete_valid_after")
+					},
+				},
+			},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				full: true,
+				req: model.TransactionNew{
+					OrderID:               uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+					ExternalTransactionID: "re_id",
+					Kind:                  model.TransactionKindRefund,
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				orepo: &repository.MockOrder{
+					FnSetStatus: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error {
+						if status != model.OrderStatusRefunded {
 							return model.Error("unexpected_status")
 						}
 
 						return nil
 					},
+
+					FnSetExpiresAt: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						if !when.Equal(time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)) {
+							return model.Error("unexpected_expires_at")
+						}
+
+						return nil
+					},
+				},
+				txnRepo: &repository.MockTransaction{
+					FnInsert: func(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+						if req.ExternalTransactionID != "re_id" {
+							return nil, model.Error("unexpected_ext_id")
+						}
+
+						return &model.Transaction{ID: uuid.NewV4()}, nil
+					},
+				},
+				tlv2Repo: &repository.MockTLV2{
+					FnDeleteValidAfter: func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error {
+						if !from.Equal(time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)) {
+							return model.Error("unexpected_from")
+						}
+
+						return nil
+					},
 				},
-				id: uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
 			},
 		},
 	}
@@ -5673,16 +7133,33 @@ func TestService_cancelOrderTx(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			svc := &Service{orderRepo: tc.given.orepo}
+			svc := &Service{orderRepo: tc.given.orepo, txnRepo: tc.given.txnRepo, tlv2Repo: tc.given.tlv2Repo, orderDunRepo: &repository.MockOrderDunning{}, couponRepo: &repository.MockCoupon{}}
 
 			ctx := context.Background()
 
-			actual := svc.cancelOrderTx(ctx, nil, tc.given.id)
+			actual := svc.refundOrderTx(ctx, nil, tc.given.req, tc.given.full, tc.given.now)
 			should.ErrorIs(t, actual, tc.exp)
 		})
 	}
 }
 
+func TestNewOrderRefundTxn(t *testing.T) {
+	ord := &model.Order{
+		ID:         uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000")),
+		Currency:   "USD",
+		TotalPrice: decimal.RequireFromString("9.99"),
+	}
+
+	actual := newOrderRefundTxn(ord, "GPA.0000-0000-0000-00000")
+
+	should.Equal(t, ord.ID, actual.OrderID)
+	should.Equal(t, "GPA.0000-0000-0000-00000", actual.ExternalTransactionID)
+	should.Equal(t, model.TransactionStatusCompleted, actual.Status)
+	should.Equal(t, "USD", actual.Currency)
+	should.Equal(t, model.TransactionKindRefund, actual.Kind)
+	should.True(t, decimal.RequireFromString("-9.99").Equal(actual.Amount))
+}
+
 func TestService_updateNumPaymentFailed(t *testing.T) {
 	type tcGiven struct {
 		orepo *repository.MockOrder
@@ -6100,3 +7577,268 @@ func (s *mockPaidOrderCreator) appendOrderMetadata(ctx context.Context, oid uuid
 
 	return s.fnAppendOrderMetadata(ctx, oid, mdata)
 }
+
+func TestApplyCoupon(t *testing.T) {
+	type tcGiven struct {
+		coupon *model.Coupon
+		req    *model.CreateOrderRequestNew
+		items  []model.OrderItem
+		now    time.Time
+	}
+
+	type tcExpected struct {
+		subtotals []decimal.Decimal
+		err       error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "expired",
+			given: tcGiven{
+				coupon: &model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					Amount:       decimal.NewFromInt(50),
+					ExpiresAt:    ptrTo(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				req: &model.CreateOrderRequestNew{Currency: "USD"},
+				items: []model.OrderItem{
+					{SKUVnt: "brave-vpn-premium", Quantity: 1, Price: decimal.NewFromInt(10), Subtotal: decimal.NewFromInt(10)},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				subtotals: []decimal.Decimal{decimal.NewFromInt(10)},
+				err:       model.ErrCouponExpired,
+			},
+		},
+
+		{
+			name: "stripe_no_stripe_coupon",
+			given: tcGiven{
+				coupon: &model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					Amount:       decimal.NewFromInt(50),
+				},
+				req: &model.CreateOrderRequestNew{Currency: "USD", PaymentMethods: []string{"stripe"}},
+				items: []model.OrderItem{
+					{SKUVnt: "brave-vpn-premium", Quantity: 1, Price: decimal.NewFromInt(10), Subtotal: decimal.NewFromInt(10)},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				subtotals: []decimal.Decimal{decimal.NewFromInt(10)},
+				err:       model.ErrCouponNoStripeCoupon,
+			},
+		},
+
+		{
+			name: "not_eligible",
+			given: tcGiven{
+				coupon: &model.Coupon{
+					DiscountType: model.CouponDiscountTypePercentage,
+					Amount:       decimal.NewFromInt(50),
+					SKUs:         pq.StringArray{"brave-leo-premium"},
+				},
+				req: &model.CreateOrderRequestNew{Currency: "USD", PaymentMethods: []string{"radom"}},
+				items: []model.OrderItem{
+					{SKUVnt: "brave-vpn-premium", Quantity: 1, Price: decimal.NewFromInt(10), Subtotal: decimal.NewFromInt(10)},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				subtotals: []decimal.Decimal{decimal.NewFromInt(10)},
+				err:       model.ErrCouponNotEligible,
+			},
+		},
+
+		{
+			name: "applied_eligible_only",
+			given: tcGiven{
+				coupon: &model.Coupon{
+					DiscountType:   model.CouponDiscountTypeFixed,
+					Amount:         decimal.NewFromInt(3),
+					Currency:       ptrTo("USD"),
+					SKUs:           pq.StringArray{"brave-vpn-premium"},
+					StripeCouponID: ptrTo("stripe_coupon_id"),
+				},
+				req: &model.CreateOrderRequestNew{Currency: "USD", PaymentMethods: []string{"stripe"}},
+				items: []model.OrderItem{
+					{SKUVnt: "brave-vpn-premium", Quantity: 2, Price: decimal.NewFromInt(10), Subtotal: decimal.NewFromInt(20)},
+					{SKUVnt: "brave-leo-premium", Quantity: 1, Price: decimal.NewFromInt(15), Subtotal: decimal.NewFromInt(15)},
+				},
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				subtotals: []decimal.Decimal{decimal.NewFromInt(14), decimal.NewFromInt(15)},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := applyCoupon(tc.given.coupon, tc.given.req, tc.given.items, tc.given.now)
+			must.Equal(t, tc.exp.err, err)
+
+			for j := range tc.exp.subtotals {
+				should.True(t, tc.exp.subtotals[j].Equal(tc.given.items[j].Subtotal))
+			}
+		})
+	}
+}
+
+func TestStripeDiscounts(t *testing.T) {
+	type tcGiven struct {
+		discounts []string
+		coupon    *model.Coupon
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   []string
+	}
+
+	tests := []testCase{
+		{
+			name: "no_coupon",
+			given: tcGiven{
+				discounts: []string{"discount_01"},
+			},
+			exp: []string{"discount_01"},
+		},
+
+		{
+			name: "coupon_without_stripe_coupon",
+			given: tcGiven{
+				discounts: []string{"discount_01"},
+				coupon:    &model.Coupon{Code: "SUMMER"},
+			},
+			exp: []string{"discount_01"},
+		},
+
+		{
+			name: "coupon",
+			given: tcGiven{
+				discounts: []string{"discount_01"},
+				coupon:    &model.Coupon{Code: "SUMMER", StripeCouponID: ptrTo("stripe_coupon_id")},
+			},
+			exp: []string{"stripe_coupon_id"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := stripeDiscounts(tc.given.discounts, tc.given.coupon)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestService_RunSyncConsumerPauseJob(t *testing.T) {
+	type tcGiven struct {
+		paused bool
+		stored bool
+		err    error
+	}
+
+	type tcExpected struct {
+		paused bool
+		err    error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "pauses",
+			given: tcGiven{stored: true},
+			exp:   tcExpected{paused: true},
+		},
+
+		{
+			name:  "resumes",
+			given: tcGiven{paused: true},
+		},
+
+		{
+			name:  "unchanged",
+			given: tcGiven{paused: true, stored: true},
+			exp:   tcExpected{paused: true},
+		},
+
+		{
+			name:  "error_keeps_state",
+			given: tcGiven{paused: true, err: model.Error("something_went_wrong")},
+			exp:   tcExpected{paused: true, err: model.Error("something_went_wrong")},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ds := NewMockDatastore(ctrl)
+			ds.EXPECT().RawDB().Return(nil).AnyTimes()
+
+			repo := &repository.MockConsumerOffset{
+				FnIsPaused: func(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string) (bool, error) {
+					return tc.given.stored, tc.given.err
+				},
+			}
+
+			svc := &Service{Datastore: ds, offsetRepo: repo, signedCredsCtl: kafkautils.NewConsumerControl("topic")}
+			if tc.given.paused {
+				svc.signedCredsCtl.Pause()
+			}
+
+			actual, err := svc.RunSyncConsumerPauseJob(context.Background())
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.False(t, actual)
+			should.Equal(t, tc.exp.paused, svc.signedCredsCtl.IsPaused())
+		})
+	}
+}
+
+func TestService_PauseSignedOrderCredsConsumer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ds := NewMockDatastore(ctrl)
+	ds.EXPECT().RawDB().Return(nil).AnyTimes()
+
+	var stored bool
+	repo := &repository.MockConsumerOffset{
+		FnSetPaused: func(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, paused bool, when time.Time) error {
+			stored = paused
+			return nil
+		},
+	}
+
+	svc := &Service{Datastore: ds, offsetRepo: repo, signedCredsCtl: kafkautils.NewConsumerControl("topic")}
+
+	must.NoError(t, svc.PauseSignedOrderCredsConsumer(context.Background()))
+	should.True(t, stored)
+	should.True(t, svc.signedCredsCtl.IsPaused())
+
+	must.NoError(t, svc.ResumeSignedOrderCredsConsumer(context.Background()))
+	should.False(t, stored)
+	should.False(t, svc.signedCredsCtl.IsPaused())
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/shopspring/decimal"
+	"gopkg.in/macaroon.v2"
 
 	appctx "github.com/brave-intl/bat-go/libs/context"
 
@@ -62,6 +65,14 @@ const (
 	devBraveLeoYearlyPremiumTimeLimitedV2 = "MDAyMGxvY2F0aW9uIGxlby5icmF2ZS5zb2Z0d2FyZQowMDI2aWRlbnRpZmllciBicmF2ZS1sZW8tcHJlbWl1bS15ZWFyCjAwMjNjaWQgc2t1PWJyYXZlLWxlby1wcmVtaXVtLXllYXIKMDAxNWNpZCBwcmljZT0xMzUuMDAKMDAxNWNpZCBjdXJyZW5jeT1VU0QKMDAyNmNpZCBkZXNjcmlwdGlvbj1icmF2ZS1sZW8tcHJlbWl1bQowMDI4Y2lkIGNyZWRlbnRpYWxfdHlwZT10aW1lLWxpbWl0ZWQtdjIKMDAyNmNpZCBjcmVkZW50aWFsX3ZhbGlkX2R1cmF0aW9uPVAxWQowMDJiY2lkIGVhY2hfY3JlZGVudGlhbF92YWxpZF9kdXJhdGlvbj1QMUQKMDAxZWNpZCBpc3N1ZXJfdG9rZW5fYnVmZmVyPTMKMDAxZmNpZCBpc3N1ZXJfdG9rZW5fb3ZlcmxhcD0wCjAwMjdjaWQgYWxsb3dlZF9wYXltZW50X21ldGhvZHM9c3RyaXBlCjAxMTVjaWQgbWV0YWRhdGE9IHsgInN0cmlwZV9wcm9kdWN0X2lkIjogInByb2RfT3RaQ1hPQ0lPM0FKRTYiLCAic3RyaXBlX2l0ZW1faWQiOiAicHJpY2VfMU82cmU4SG9mMjBicGhHNnRxZE5FRUFwIiwgInN0cmlwZV9zdWNjZXNzX3VyaSI6ICJodHRwczovL2FjY291bnQuYnJhdmUuc29mdHdhcmUvYWNjb3VudC8/aW50ZW50PXByb3Zpc2lvbiIsICJzdHJpcGVfY2FuY2VsX3VyaSI6ICJodHRwczovL2FjY291bnQuYnJhdmUuc29mdHdhcmUvcGxhbnMvP2ludGVudD1jaGVja291dCIgfQowMDJmc2lnbmF0dXJlIJqPHPzXhI1n/pi0lhN2iYFN12qtfKCL0rmPhOK16jB+Cg=="
 )
 
+var skuMapFallbackCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "skus_sku_map_fallback_total",
+		Help: "SKU tokens accepted from the hardcoded list because their signature did not verify.",
+	},
+	[]string{"env"},
+)
+
 var skuMap = map[string]map[string]bool{
 	"production": {
 		prodUserWalletVote:                          true,
@@ -107,25 +118,70 @@ var skuMap = map[string]map[string]bool{
 	},
 }
 
-// temporary, until we can validate macaroon signatures
-func validateHardcodedSku(ctx context.Context, sku string) (bool, error) {
-	// check sku white list from environment
-	whitelistSKUs, ok := ctx.Value(appctx.WhitelistSKUsCTXKey).([]string)
-	if ok {
-		for _, whitelistSKU := range whitelistSKUs {
-			if sku == whitelistSKU {
-				return true, nil
-			}
+// isValidSKU reports whether the SKU token is allowed.
+//
+// Tokens from the configured whitelist are accepted as they are.
+// Any other token must be signed with one of the active keys of the merchant named in its location.
+// The hardcoded tokens for the environment are only checked when the signature does not verify.
+func (s *Service) isValidSKU(ctx context.Context, sku string, mac *macaroon.Macaroon) (bool, error) {
+	if whitelistSKUs, ok := ctx.Value(appctx.WhitelistSKUsCTXKey).([]string); ok {
+		if model.Slice[string](whitelistSKUs).Contains(sku) {
+			return true, nil
 		}
 	}
 
-	// check hardcoded based on environment (non whitelisted)
 	env, err := appctx.GetStringFromContext(ctx, appctx.EnvironmentCTXKey)
 	if err != nil {
 		return false, fmt.Errorf("failed to get environment: %w", err)
 	}
-	valid, ok := skuMap[env][sku]
-	return valid && ok, nil
+
+	valid, err := s.isSignedSKU(mac)
+	if err != nil {
+		return false, err
+	}
+
+	if valid {
+		return true, nil
+	}
+
+	// TODO: Remove the skuMap fallback by 2027-01-31.
+	// By then every hardcoded token must verify against a merchant key; skus_sku_map_fallback_total shows the remaining use.
+	if skuMap[env][sku] {
+		skuMapFallbackCounter.WithLabelValues(env).Inc()
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *Service) isSignedSKU(mac *macaroon.Macaroon) (bool, error) {
+	merchID := mac.Location()
+	if merchID == "" {
+		return false, nil
+	}
+
+	keys, err := s.Datastore.GetKeysByMerchant(merchID, false)
+	if err != nil {
+		return false, fmt.Errorf("failed to get merchant keys: %w", err)
+	}
+
+	if keys == nil {
+		return false, nil
+	}
+
+	for i := range *keys {
+		secret, err := (*keys)[i].GetSecretKey()
+		if err != nil {
+			return false, fmt.Errorf("failed to get merchant secret: %w", err)
+		}
+
+		if _, err := mac.VerifySignature([]byte(*secret), nil); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
 }
 
 func newOrderItemReqForSubID(set map[string]model.OrderItemRequestNew, subID string) (model.OrderItemRequestNew, error) {
@@ -142,6 +198,17 @@ func newOrderItemReqForSubID(set map[string]model.OrderItemRequestNew, subID str
 	return result, nil
 }
 
+// skuVntByStripePriceID returns the SKU variant from set which is sold at the given Stripe price.
+func skuVntByStripePriceID(set map[string]model.OrderItemRequestNew, priceID string) (string, bool) {
+	for k := range set {
+		if set[k].StripeMetadata != nil && set[k].StripeMetadata.ItemID == priceID {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
 func skuVntByMobileName(subID string) (string, error) {
 	switch subID {
 	// Android Leo Monthly.
@@ -242,6 +309,7 @@ func newOrderItemReqNewMobileSet(env string) map[string]model.OrderItemRequestNe
 		Quantity: 1,
 		SKU:      "brave-leo-premium",
 		SKUVnt:   "brave-leo-premium",
+		Period:   "P1M",
 		// Location depends on env.
 		Description:                 "Premium access to Leo",
 		CredentialType:              "time-limited-v2",
@@ -257,6 +325,7 @@ func newOrderItemReqNewMobileSet(env string) map[string]model.OrderItemRequestNe
 		Quantity: 1,
 		SKU:      "brave-leo-premium",
 		SKUVnt:   "brave-leo-premium-year",
+		Period:   "P1Y",
 		// Location depends on env.
 		Description:                 "Premium access to Leo Yearly",
 		CredentialType:              "time-limited-v2",
@@ -272,6 +341,7 @@ func newOrderItemReqNewMobileSet(env string) map[string]model.OrderItemRequestNe
 		Quantity: 1,
 		SKU:      "brave-vpn-premium",
 		SKUVnt:   "brave-vpn-premium",
+		Period:   "P1M",
 		// Location depends on env.
 		Description:                 "brave-vpn-premium",
 		CredentialType:              "time-limited-v2",
@@ -287,6 +357,7 @@ func newOrderItemReqNewMobileSet(env string) map[string]model.OrderItemRequestNe
 		Quantity: 1,
 		SKU:      "brave-vpn-premium",
 		SKUVnt:   "brave-vpn-premium-year",
+		Period:   "P1Y",
 		// Location depends on env.
 		Description:                 "brave-vpn-premium-year",
 		CredentialType:              "time-limited-v2",
@@ -1,12 +1,19 @@
 package skus
 
 import (
+	"context"
+	"encoding/hex"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/shopspring/decimal"
 	should "github.com/stretchr/testify/assert"
 	must "github.com/stretchr/testify/require"
 
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/cryptography"
+	macarooncmd "github.com/brave-intl/bat-go/tools/macaroon/cmd"
+
 	"github.com/brave-intl/bat-go/services/skus/model"
 )
 
@@ -229,6 +236,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -256,6 +264,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -283,6 +292,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -310,6 +320,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -337,6 +348,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -364,6 +376,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -391,6 +404,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -418,6 +432,7 @@ func TestNewOrderItemReqForSubID(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -480,6 +495,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-leo-premium",
 						SKUVnt:                      "brave-leo-premium",
+						Period:                      "P1M",
 						Location:                    "leo.brave.software",
 						Description:                 "Premium access to Leo",
 						CredentialType:              "time-limited-v2",
@@ -516,6 +532,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-leo-premium",
 						SKUVnt:                      "brave-leo-premium-year",
+						Period:                      "P1Y",
 						Location:                    "leo.bravesoftware.com",
 						Description:                 "Premium access to Leo Yearly",
 						CredentialType:              "time-limited-v2",
@@ -552,6 +569,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-leo-premium",
 						SKUVnt:                      "brave-leo-premium",
+						Period:                      "P1M",
 						Location:                    "leo.brave.com",
 						Description:                 "Premium access to Leo",
 						CredentialType:              "time-limited-v2",
@@ -589,6 +607,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-vpn-premium",
 						SKUVnt:                      "brave-vpn-premium",
+						Period:                      "P1M",
 						Location:                    "vpn.brave.software",
 						Description:                 "brave-vpn-premium",
 						CredentialType:              "time-limited-v2",
@@ -625,6 +644,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-vpn-premium",
 						SKUVnt:                      "brave-vpn-premium-year",
+						Period:                      "P1Y",
 						Location:                    "vpn.bravesoftware.com",
 						Description:                 "brave-vpn-premium-year",
 						CredentialType:              "time-limited-v2",
@@ -661,6 +681,7 @@ func TestNewCreateOrderReqNewMobile(t *testing.T) {
 						Quantity:                    1,
 						SKU:                         "brave-vpn-premium",
 						SKUVnt:                      "brave-vpn-premium",
+						Period:                      "P1M",
 						Location:                    "vpn.brave.com",
 						Description:                 "brave-vpn-premium",
 						CredentialType:              "time-limited-v2",
@@ -705,6 +726,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.brave.com",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -723,6 +745,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.brave.com",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -741,6 +764,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.brave.com",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -759,6 +783,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.brave.com",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -783,6 +808,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.bravesoftware.com",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -801,6 +827,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.bravesoftware.com",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -819,6 +846,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.bravesoftware.com",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -837,6 +865,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.bravesoftware.com",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -861,6 +890,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -879,6 +909,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -897,6 +928,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -915,6 +947,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -939,6 +972,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium",
+					Period:                      "P1M",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo",
 					CredentialType:              "time-limited-v2",
@@ -957,6 +991,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-leo-premium",
 					SKUVnt:                      "brave-leo-premium-year",
+					Period:                      "P1Y",
 					Location:                    "leo.brave.software",
 					Description:                 "Premium access to Leo Yearly",
 					CredentialType:              "time-limited-v2",
@@ -975,6 +1010,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium",
+					Period:                      "P1M",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium",
 					CredentialType:              "time-limited-v2",
@@ -993,6 +1029,7 @@ func TestNewOrderItemReqNewMobileSet(t *testing.T) {
 					Quantity:                    1,
 					SKU:                         "brave-vpn-premium",
 					SKUVnt:                      "brave-vpn-premium-year",
+					Period:                      "P1Y",
 					Location:                    "vpn.brave.software",
 					Description:                 "brave-vpn-premium-year",
 					CredentialType:              "time-limited-v2",
@@ -1127,3 +1164,214 @@ func TestPaymentProcessorConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestSKUVntByStripePriceID(t *testing.T) {
+	type tcExpected struct {
+		val string
+		ok  bool
+	}
+
+	type testCase struct {
+		name  string
+		given string
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "unknown",
+			given: "price_unknown",
+		},
+
+		{
+			name:  "leo_yearly",
+			given: "price_1PqvBPBSm1mtrN9nYgXdiP2h",
+			exp:   tcExpected{val: "brave-leo-premium-year", ok: true},
+		},
+
+		{
+			name:  "vpn_monthly",
+			given: "price_1L0VHmBSm1mtrN9nT5DPmUZb",
+			exp:   tcExpected{val: "brave-vpn-premium", ok: true},
+		},
+	}
+
+	set := newOrderItemReqNewMobileSet("production")
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := skuVntByStripePriceID(set, tc.given)
+			should.Equal(t, tc.exp.ok, ok)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_isValidSKU(t *testing.T) {
+	oldEncryptionKey := EncryptionKey
+	defer func() {
+		EncryptionKey = oldEncryptionKey
+		InitEncryptionKeys()
+	}()
+
+	EncryptionKey = "MTIzNDU2Nzg5MDEyMzQ1Njc4OTAxMjM0"
+	InitEncryptionKeys()
+
+	cipher, nonce, err := cryptography.EncryptMessage(byteEncryptionKey, []byte("testing123"))
+	must.NoError(t, err)
+
+	leoKeys := &[]Key{{ID: "key_id", Merchant: "leo.brave.com", EncryptedSecretKey: hex.EncodeToString(cipher), Nonce: hex.EncodeToString(nonce[:])}}
+
+	signed := func(location, secret string) string {
+		tkn := macarooncmd.Token{
+			ID: "id", Version: 2, Location: location,
+			FirstPartyCaveats: []macarooncmd.Caveats{{"sku": "brave-leo-premium"}},
+		}
+
+		result, err := tkn.Generate(secret)
+		must.NoError(t, err)
+
+		return result
+	}
+
+	type tcGiven struct {
+		env       string
+		whitelist []string
+		sku       string
+		keys      func(ds *MockDatastore)
+	}
+
+	type tcExpected struct {
+		val bool
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "whitelisted",
+			given: tcGiven{
+				env:       "production",
+				whitelist: []string{signed("leo.brave.com", "other")},
+				sku:       signed("leo.brave.com", "other"),
+				keys:      func(ds *MockDatastore) {},
+			},
+			exp: tcExpected{val: true},
+		},
+
+		{
+			name: "hardcoded_production_token_fallback",
+			given: tcGiven{
+				env: "production",
+				sku: prodBraveLeoPremiumTimeLimitedV2,
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.com", false).Return(leoKeys, nil)
+				},
+			},
+			exp: tcExpected{val: true},
+		},
+
+		{
+			name: "hardcoded_production_token_keys_error",
+			given: tcGiven{
+				env: "production",
+				sku: prodBraveLeoPremiumTimeLimitedV2,
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.com", false).Return(nil, model.Error("something_went_wrong"))
+				},
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+
+		{
+			name: "hardcoded_token_other_env",
+			given: tcGiven{
+				env: "production",
+				sku: devBraveLeoPremiumTimeLimitedV2,
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.software", false).Return(nil, nil)
+				},
+			},
+		},
+
+		{
+			name: "signed_by_token_merchant",
+			given: tcGiven{
+				env: "production",
+				sku: signed("leo.brave.com", "testing123"),
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.com", false).Return(leoKeys, nil)
+				},
+			},
+			exp: tcExpected{val: true},
+		},
+
+		{
+			name: "signed_by_other_merchant",
+			given: tcGiven{
+				env: "production",
+				sku: signed("vpn.brave.com", "testing123"),
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("vpn.brave.com", false).Return(nil, nil)
+				},
+			},
+		},
+
+		{
+			name: "wrong_signature",
+			given: tcGiven{
+				env: "production",
+				sku: signed("leo.brave.com", "other"),
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.com", false).Return(leoKeys, nil)
+				},
+			},
+		},
+
+		{
+			name: "keys_error",
+			given: tcGiven{
+				env: "production",
+				sku: signed("leo.brave.com", "testing123"),
+				keys: func(ds *MockDatastore) {
+					ds.EXPECT().GetKeysByMerchant("leo.brave.com", false).Return(nil, model.Error("something_went_wrong"))
+				},
+			},
+			exp: tcExpected{err: model.Error("something_went_wrong")},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ds := NewMockDatastore(ctrl)
+			tc.given.keys(ds)
+
+			svc := &Service{Datastore: ds}
+
+			ctx := context.WithValue(context.Background(), appctx.EnvironmentCTXKey, tc.given.env)
+			if tc.given.whitelist != nil {
+				ctx = context.WithValue(ctx, appctx.WhitelistSKUsCTXKey, tc.given.whitelist)
+			}
+
+			mac, err := decodeAndUnmarshalSku(tc.given.sku)
+			must.NoError(t, err)
+
+			actual, err := svc.isValidSKU(ctx, tc.given.sku, mac)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
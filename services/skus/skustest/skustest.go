@@ -31,7 +31,7 @@ func Migrate(t *testing.T) {
 }
 
 func CleanDB(t *testing.T, dbi *sqlx.DB) {
-	_, err := dbi.Exec("TRUNCATE TABLE vote_drain, api_keys, transactions, signing_order_request_outbox, time_limited_v2_order_creds, order_items, order_creds, order_cred_issuers, orders")
+	_, err := dbi.Exec("TRUNCATE TABLE tlv1_presentations, tlv1_migrations, issuer_keys, kafka_consumer_offsets, kafka_consumer_pauses, vote_drain, spend_drain, spend_channels, merchant_webhook_deliveries, merchant_webhook_endpoints, api_key_audit, api_keys, transactions, order_events_outbox, order_dunning, signing_order_request_outbox, time_limited_v2_order_creds, order_seats, order_items, order_creds, order_cred_issuers, orders, webhook_inbox, coupons, sku_prices, sku_catalog, portal_login_codes, portal_sessions")
 	must.Equal(t, nil, err)
 }
 
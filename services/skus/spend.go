@@ -0,0 +1,429 @@
+package skus
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/linkedin/goavro"
+	uuid "github.com/satori/go.uuid"
+	kafka "github.com/segmentio/kafka-go"
+
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	// maxSpendPayloadSchemaSize limits the size of a payload schema of a spend channel.
+	maxSpendPayloadSchemaSize = 16 * 1024
+
+	spendErrCodeInvalidCreds = "invalid_credentials"
+	spendErrCodeInvalidTopic = "invalid_topic"
+	spendErrCodeUnknown      = "unknown"
+	spendErrCodeMaxAttempts  = "max_attempts"
+	spendErrCodeDupRedeem    = "cbr_dup_redeem"
+
+	// spendMaxAttempts is how many times a spend is attempted before it is set aside as erred.
+	//
+	// With the delays below, attempts span about seven hours.
+	spendMaxAttempts = 20
+
+	spendRetryBase = 10 * time.Second
+	spendRetryMax  = 30 * time.Minute
+)
+
+type spendStore interface {
+	CreateChannel(ctx context.Context, dbi sqlx.QueryerContext, ch model.SpendChannel) (*model.SpendChannel, error)
+	GetChannel(ctx context.Context, dbi sqlx.QueryerContext, merchID, name string) (*model.SpendChannel, error)
+	ListChannels(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.SpendChannel, error)
+	DisableChannel(ctx context.Context, dbi sqlx.ExecerContext, merchID, name string, when time.Time) error
+	InsertRecord(ctx context.Context, dbi sqlx.ExecerContext, rec model.SpendRecord) error
+	GetNextUncommittedForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.SpendRecord, error)
+	MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error
+	MarkRedeemed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	MarkErrored(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error
+	MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+// SpendEvent is published to the topic of a spend channel once credentials have been redeemed.
+//
+// Payload is encoded with the payload schema of the channel.
+type SpendEvent struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	MerchantID string
+	Channel    string
+	SKU        string
+	Tally      int64
+	Payload    []byte
+}
+
+// CodecEncode encodes the event with the spend event codec.
+func (ev *SpendEvent) CodecEncode(codec *goavro.Codec) ([]byte, error) {
+	if codec == nil {
+		return nil, model.Error("spend events: codec not found")
+	}
+
+	return codec.BinaryFromNative(nil, map[string]interface{}{
+		"id":         ev.ID.String(),
+		"createdAt":  ev.CreatedAt.Format(time.RFC3339),
+		"merchantId": ev.MerchantID,
+		"channel":    ev.Channel,
+		"sku":        ev.SKU,
+		"tally":      ev.Tally,
+		"payload":    ev.Payload,
+	})
+}
+
+// CreateSpendChannel defines a spend channel of the merchant.
+//
+// The topic of the channel is derived from the merchant and the name, so that channels cannot publish to topics of other services.
+func (s *Service) CreateSpendChannel(ctx context.Context, merchID string, req *model.CreateSpendChannelRequest) (*model.SpendChannel, error) {
+	if !req.IsValid() || len(req.PayloadSchema) > maxSpendPayloadSchemaSize {
+		return nil, model.ErrSpendChannelInvalid
+	}
+
+	if _, err := newSpendPayloadCodec(req.PayloadSchema); err != nil {
+		return nil, err
+	}
+
+	ch := model.SpendChannel{
+		MerchantID:    merchID,
+		Name:          req.Name,
+		Topic:         spendTopic(merchID, req.Name),
+		PayloadSchema: req.PayloadSchema,
+		AllowedSKUs:   req.AllowedSKUs,
+	}
+
+	return s.spendRepo.CreateChannel(ctx, s.Datastore.RawDB(), ch)
+}
+
+func (s *Service) ListSpendChannels(ctx context.Context, merchID string) ([]model.SpendChannel, error) {
+	return s.spendRepo.ListChannels(ctx, s.Datastore.RawDB(), merchID)
+}
+
+// DisableSpendChannel stops accepting spends on the channel.
+func (s *Service) DisableSpendChannel(ctx context.Context, merchID, name string) error {
+	return s.spendRepo.DisableChannel(ctx, s.Datastore.RawDB(), merchID, name, time.Now().UTC())
+}
+
+// Spend queues credentials to be redeemed on the channel of the merchant.
+//
+// The payload text is a base64 encoded JSON document which conforms to the payload schema of the channel.
+// Credentials are bound to the payload text, and either all of them are accepted, or none.
+func (s *Service) Spend(ctx context.Context, merchID, name string, credentials []CredentialBinding, payloadText string) error {
+	ch, err := s.spendRepo.GetChannel(ctx, s.Datastore.RawDB(), merchID, name)
+	if err != nil {
+		return err
+	}
+
+	codec, err := newSpendPayloadCodec(ch.PayloadSchema)
+	if err != nil {
+		return err
+	}
+
+	payload, err := encodeSpendPayload(codec, payloadText)
+	if err != nil {
+		return err
+	}
+
+	requestCredentials, err := generateCredentialRedemptions(context.WithValue(ctx, appctx.DatastoreCTXKey, s.Datastore), credentials)
+	if err != nil {
+		return fmt.Errorf("error generating credential redemptions: %w", err)
+	}
+
+	credsByIssuer, err := groupSpendCredentials(ch, requestCredentials)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+
+	for issuer, creds := range credsByIssuer {
+		_, sku, err := decodeIssuerID(issuer)
+		if err != nil {
+			return fmt.Errorf("failed to decode issuer name for sku: %w", err)
+		}
+
+		ev := &SpendEvent{
+			ID:         uuid.NewV4(),
+			CreatedAt:  now,
+			MerchantID: ch.MerchantID,
+			Channel:    ch.Name,
+			SKU:        sku,
+			Tally:      int64(len(creds)),
+			Payload:    payload,
+		}
+
+		evBinary, err := ev.CodecEncode(s.codecs["spendEvent"])
+		if err != nil {
+			return fmt.Errorf("failed to encode avro codec: %w", err)
+		}
+
+		rcSerial, err := json.Marshal(creds)
+		if err != nil {
+			return fmt.Errorf("failed to encode request credentials for spend drain: %w", err)
+		}
+
+		rec := model.SpendRecord{
+			ChannelID:   ch.ID,
+			Credentials: string(rcSerial),
+			Payload:     payloadText,
+			Event:       evBinary,
+		}
+
+		if err := s.spendRepo.InsertRecord(ctx, tx, rec); err != nil {
+			return fmt.Errorf("datastore failure spend_drain: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RunNextSpendDrainJob redeems the credentials of the next queued spend, and publishes its event.
+//
+// Credentials are marked as redeemed separately from publishing, so a spend is never redeemed twice.
+// The event might be published more than once, and consumers should deduplicate by event id.
+//
+// A spend which fails is retried later, so that it does not hold up those queued after it.
+// After spendMaxAttempts it is set aside as erred, keeping whether it has been redeemed.
+func (s *Service) RunNextSpendDrainJob(ctx context.Context) (bool, error) {
+	if s.IsPaused() {
+		return false, nil
+	}
+
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().UTC()
+
+	rec, err := s.spendRepo.GetNextUncommittedForUpdate(ctx, tx, now)
+	if err != nil {
+		if errors.Is(err, model.ErrSpendRecordNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if err := s.drainSpendRecord(ctx, tx, rec); err != nil {
+		if ferr := s.recordSpendAttemptFailed(ctx, tx, rec, err, now); ferr != nil {
+			return true, ferr
+		}
+
+		// The outcome of redemption is kept regardless.
+		if cerr := tx.Commit(); cerr != nil {
+			return true, cerr
+		}
+
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// recordSpendAttemptFailed defers the next attempt of the spend, or sets it aside once it has run out of attempts.
+func (s *Service) recordSpendAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, rec *model.SpendRecord, cause error, now time.Time) error {
+	if err := s.spendRepo.MarkAttemptFailed(ctx, dbi, rec.ID, cause.Error(), now.Add(spendRetryDelay(rec.NumAttempts))); err != nil {
+		return err
+	}
+
+	if rec.NumAttempts+1 < spendMaxAttempts {
+		return nil
+	}
+
+	return s.spendRepo.MarkErrored(ctx, dbi, rec.ID, spendErrCodeMaxAttempts)
+}
+
+func (s *Service) drainSpendRecord(ctx context.Context, dbi sqlx.ExecerContext, rec *model.SpendRecord) error {
+	lg := logging.Logger(ctx, "skus").With().Str("func", "drainSpendRecord").Str("spend_id", rec.ID.String()).Logger()
+
+	// Topics are derived when channels are created, so one outside the prefix has been altered since.
+	if !isSpendTopicAllowed(rec.Topic) {
+		lg.Error().Str("topic", rec.Topic).Msg("spend topic not allowed")
+
+		return s.spendRepo.MarkErrored(ctx, dbi, rec.ID, spendErrCodeInvalidTopic)
+	}
+
+	if !rec.Redeemed {
+		var creds []cbr.CredentialRedemption
+		if err := json.Unmarshal([]byte(rec.Credentials), &creds); err != nil {
+			lg.Err(err).Msg("failed to decode credentials")
+
+			return s.spendRepo.MarkErrored(ctx, dbi, rec.ID, spendErrCodeInvalidCreds)
+		}
+
+		if err := s.cbClient.RedeemCredentials(ctx, creds, rec.Payload); err != nil {
+			code, retry := spendErrCode(err)
+			if retry {
+				return fmt.Errorf("failed to redeem credentials: %w", err)
+			}
+
+			// A previous attempt might have redeemed the credentials without learning the outcome.
+			if code != spendErrCodeDupRedeem || rec.NumAttempts == 0 {
+				lg.Err(err).Str("errcode", code).Msg("failed to redeem credentials")
+
+				return s.spendRepo.MarkErrored(ctx, dbi, rec.ID, code)
+			}
+
+			lg.Warn().Err(err).Int("num_attempts", rec.NumAttempts).Msg("credentials already redeemed on retry")
+		}
+
+		if err := s.spendRepo.MarkRedeemed(ctx, dbi, rec.ID); err != nil {
+			return err
+		}
+	}
+
+	msg := kafka.Message{
+		Topic: rec.Topic,
+		Key:   rec.ID.Bytes(),
+		Value: rec.Event,
+	}
+
+	if err := s.spendWriter.WriteMessages(ctx, msg); err != nil {
+		if strings.Contains(err.Error(), "expired") {
+			// Pause the worker for 30 minutes, expired cert.
+			s.PauseWorker(time.Now().Add(30 * time.Minute))
+		}
+
+		return fmt.Errorf("failed to write spend event: %w", err)
+	}
+
+	return s.spendRepo.MarkProcessed(ctx, dbi, rec.ID)
+}
+
+// groupSpendCredentials groups credentials by issuer, after checking that all of them can be spent on the channel.
+func groupSpendCredentials(ch *model.SpendChannel, creds []cbr.CredentialRedemption) (map[string][]cbr.CredentialRedemption, error) {
+	if len(creds) == 0 {
+		return nil, model.ErrSpendNoCredentials
+	}
+
+	result := make(map[string][]cbr.CredentialRedemption)
+
+	for i := range creds {
+		merchID, sku, err := decodeIssuerID(creds[i].Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode issuer name for sku: %w", err)
+		}
+
+		if merchID != ch.MerchantID || !ch.IsSKUAllowed(sku) {
+			return nil, model.ErrSpendCredsNotAllowed
+		}
+
+		result[creds[i].Issuer] = append(result[creds[i].Issuer], creds[i])
+	}
+
+	return result, nil
+}
+
+// newSpendPayloadCodec compiles the payload schema of a spend channel, which must describe a record.
+func newSpendPayloadCodec(schema []byte) (*goavro.Codec, error) {
+	var parsed struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(schema, &parsed); err != nil || parsed.Type != "record" {
+		return nil, model.ErrSpendChannelInvalid
+	}
+
+	codec, err := goavro.NewCodec(string(schema))
+	if err != nil {
+		return nil, model.ErrSpendChannelInvalid
+	}
+
+	return codec, nil
+}
+
+// encodeSpendPayload checks that the base64 encoded JSON payload conforms to the schema, and encodes it with codec.
+func encodeSpendPayload(codec *goavro.Codec, payloadText string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(payloadText)
+	if err != nil {
+		return nil, model.ErrSpendPayloadInvalid
+	}
+
+	native, _, err := codec.NativeFromTextual(raw)
+	if err != nil {
+		return nil, model.ErrSpendPayloadInvalid
+	}
+
+	result, err := codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return nil, model.ErrSpendPayloadInvalid
+	}
+
+	return result, nil
+}
+
+// spendTopic returns the Kafka topic of the channel.
+//
+// Characters of the merchant id which are not allowed in topic names are replaced.
+func spendTopic(merchID, name string) string {
+	merch := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+
+		return '-'
+	}, merchID)
+
+	return spendTopicPrefix + merch + "." + name
+}
+
+// isSpendTopicAllowed reports whether events can be published to the topic.
+func isSpendTopicAllowed(topic string) bool {
+	return len(topic) > len(spendTopicPrefix) && strings.HasPrefix(topic, spendTopicPrefix)
+}
+
+// spendRetryDelay returns the delay before the next attempt given the number of previous attempts.
+func spendRetryDelay(numAttempts int) time.Duration {
+	if numAttempts < 0 {
+		numAttempts = 0
+	}
+
+	result := spendRetryBase
+	for i := 0; i < numAttempts; i++ {
+		result *= 2
+
+		if result >= spendRetryMax {
+			return spendRetryMax
+		}
+	}
+
+	return result
+}
+
+// spendErrCode returns the drain code of a redemption error, and whether redemption should be retried.
+func spendErrCode(err error) (string, bool) {
+	var eb *errorutils.ErrorBundle
+	if errors.As(err, &eb) {
+		if c, ok := eb.Data().(errorutils.DrainCodified); ok {
+			code, retry := c.DrainCode()
+
+			return strings.ToLower(code), retry
+		}
+	}
+
+	return spendErrCodeUnknown, false
+}
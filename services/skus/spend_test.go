@@ -0,0 +1,614 @@
+package skus
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	kafka "github.com/segmentio/kafka-go"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/clients/cbr"
+	mockcb "github.com/brave-intl/bat-go/libs/clients/cbr/mock"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+const testSpendSchema = `{
+	"type": "record",
+	"name": "Attention",
+	"fields": [
+		{"name": "publisher", "type": "string"},
+		{"name": "seconds", "type": "long"}
+	]
+}`
+
+type fakeSpendWriter struct {
+	fnWriteMessages func(ctx context.Context, msgs ...kafka.Message) error
+}
+
+func (w *fakeSpendWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.fnWriteMessages == nil {
+		return nil
+	}
+
+	return w.fnWriteMessages(ctx, msgs...)
+}
+
+func TestNewSpendPayloadCodec(t *testing.T) {
+	type testCase struct {
+		name  string
+		given string
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "invalid_json",
+			given: `{"type":`,
+			exp:   model.ErrSpendChannelInvalid,
+		},
+
+		{
+			name:  "not_record",
+			given: `{"type": "string"}`,
+			exp:   model.ErrSpendChannelInvalid,
+		},
+
+		{
+			name:  "invalid_record",
+			given: `{"type": "record", "name": "Attention", "fields": [{"name": "seconds", "type": "unknown"}]}`,
+			exp:   model.ErrSpendChannelInvalid,
+		},
+
+		{
+			name:  "valid",
+			given: testSpendSchema,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := newSpendPayloadCodec([]byte(tc.given))
+			must.Equal(t, tc.exp, err)
+
+			if tc.exp != nil {
+				return
+			}
+
+			should.NotNil(t, actual)
+		})
+	}
+}
+
+func TestEncodeSpendPayload(t *testing.T) {
+	codec, err := newSpendPayloadCodec([]byte(testSpendSchema))
+	must.Equal(t, nil, err)
+
+	type testCase struct {
+		name  string
+		given string
+		exp   error
+	}
+
+	tests := []testCase{
+		{
+			name:  "invalid_base64",
+			given: "not base64!",
+			exp:   model.ErrSpendPayloadInvalid,
+		},
+
+		{
+			name:  "invalid_json",
+			given: base64.StdEncoding.EncodeToString([]byte(`{"publisher":`)),
+			exp:   model.ErrSpendPayloadInvalid,
+		},
+
+		{
+			name:  "missing_field",
+			given: base64.StdEncoding.EncodeToString([]byte(`{"publisher": "brave.com"}`)),
+			exp:   model.ErrSpendPayloadInvalid,
+		},
+
+		{
+			name:  "wrong_type",
+			given: base64.StdEncoding.EncodeToString([]byte(`{"publisher": "brave.com", "seconds": "ten"}`)),
+			exp:   model.ErrSpendPayloadInvalid,
+		},
+
+		{
+			name:  "valid",
+			given: base64.StdEncoding.EncodeToString([]byte(`{"publisher": "brave.com", "seconds": 10}`)),
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := encodeSpendPayload(codec, tc.given)
+			must.Equal(t, tc.exp, err)
+
+			if tc.exp != nil {
+				return
+			}
+
+			native, _, err := codec.NativeFromBinary(actual)
+			must.Equal(t, nil, err)
+
+			should.Equal(t, map[string]interface{}{"publisher": "brave.com", "seconds": int64(10)}, native)
+		})
+	}
+}
+
+func TestGroupSpendCredentials(t *testing.T) {
+	type tcGiven struct {
+		ch    *model.SpendChannel
+		creds []cbr.CredentialRedemption
+	}
+
+	type tcExpected struct {
+		val map[string]int
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	ch := &model.SpendChannel{MerchantID: "brave.com", Name: "attention", AllowedSKUs: []string{"user-wallet-vote", "anon-card-vote"}}
+
+	tests := []testCase{
+		{
+			name:  "no_credentials",
+			given: tcGiven{ch: ch},
+			exp:   tcExpected{err: model.ErrSpendNoCredentials},
+		},
+
+		{
+			name: "wrong_merchant",
+			given: tcGiven{
+				ch: ch,
+				creds: []cbr.CredentialRedemption{
+					{Issuer: "other.com?sku=user-wallet-vote"},
+				},
+			},
+			exp: tcExpected{err: model.ErrSpendCredsNotAllowed},
+		},
+
+		{
+			name: "sku_not_allowed",
+			given: tcGiven{
+				ch: ch,
+				creds: []cbr.CredentialRedemption{
+					{Issuer: "brave.com?sku=user-wallet-vote"},
+					{Issuer: "brave.com?sku=brave-vpn-premium"},
+				},
+			},
+			exp: tcExpected{err: model.ErrSpendCredsNotAllowed},
+		},
+
+		{
+			name: "grouped",
+			given: tcGiven{
+				ch: ch,
+				creds: []cbr.CredentialRedemption{
+					{Issuer: "brave.com?sku=user-wallet-vote"},
+					{Issuer: "brave.com?sku=anon-card-vote"},
+					{Issuer: "brave.com?sku=user-wallet-vote"},
+				},
+			},
+			exp: tcExpected{
+				val: map[string]int{"brave.com?sku=user-wallet-vote": 2, "brave.com?sku=anon-card-vote": 1},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := groupSpendCredentials(tc.given.ch, tc.given.creds)
+			must.Equal(t, tc.exp.err, err)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			must.Len(t, actual, len(tc.exp.val))
+
+			for issuer, n := range tc.exp.val {
+				should.Len(t, actual[issuer], n)
+			}
+		})
+	}
+}
+
+func TestSpendTopic(t *testing.T) {
+	type tcGiven struct {
+		merchID string
+		name    string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   string
+	}
+
+	tests := []testCase{
+		{
+			name:  "simple",
+			given: tcGiven{merchID: "brave_com", name: "attention"},
+			exp:   spendTopicPrefix + "brave_com.attention",
+		},
+
+		{
+			name:  "replaced",
+			given: tcGiven{merchID: "brave.com", name: "attention"},
+			exp:   spendTopicPrefix + "brave-com.attention",
+		},
+
+		{
+			name:  "url",
+			given: tcGiven{merchID: "https://example.com/shop", name: "votes"},
+			exp:   spendTopicPrefix + "https---example-com-shop.votes",
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, spendTopic(tc.given.merchID, tc.given.name))
+		})
+	}
+}
+
+func TestIsSpendTopicAllowed(t *testing.T) {
+	type testCase struct {
+		name  string
+		given string
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name:  "channel",
+			given: spendTopic("brave.com", "attention"),
+			exp:   true,
+		},
+
+		{
+			name:  "prefix_only",
+			given: spendTopicPrefix,
+		},
+
+		{
+			name:  "other_service",
+			given: voteTopic,
+		},
+
+		{
+			name: "empty",
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, isSpendTopicAllowed(tc.given))
+		})
+	}
+}
+
+func TestSpendRetryDelay(t *testing.T) {
+	type testCase struct {
+		name  string
+		given int
+		exp   time.Duration
+	}
+
+	tests := []testCase{
+		{
+			name: "first",
+			exp:  10 * time.Second,
+		},
+
+		{
+			name:  "third",
+			given: 2,
+			exp:   40 * time.Second,
+		},
+
+		{
+			name:  "capped",
+			given: 15,
+			exp:   30 * time.Minute,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, spendRetryDelay(tc.given))
+		})
+	}
+}
+
+func TestService_recordSpendAttemptFailed(t *testing.T) {
+	type tcExpected struct {
+		next    time.Time
+		errored string
+	}
+
+	type testCase struct {
+		name  string
+		given int
+		exp   tcExpected
+	}
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name: "first_attempt",
+			exp:  tcExpected{next: now.Add(10 * time.Second)},
+		},
+
+		{
+			name:  "out_of_attempts",
+			given: spendMaxAttempts - 1,
+			exp:   tcExpected{next: now.Add(30 * time.Minute), errored: spendErrCodeMaxAttempts},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				msg     string
+				next    time.Time
+				errored string
+			)
+
+			repo := &repository.MockSpend{
+				FnMarkAttemptFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, m string, n time.Time) error {
+					msg, next = m, n
+
+					return nil
+				},
+
+				FnMarkErrored: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error {
+					errored = code
+
+					return nil
+				},
+			}
+
+			svc := &Service{spendRepo: repo}
+
+			rec := &model.SpendRecord{ID: uuid.NewV4(), NumAttempts: tc.given}
+
+			err := svc.recordSpendAttemptFailed(context.Background(), nil, rec, errors.New("broker unavailable"), now)
+			must.NoError(t, err)
+
+			should.Equal(t, "broker unavailable", msg)
+			should.Equal(t, tc.exp.next, next)
+			should.Equal(t, tc.exp.errored, errored)
+		})
+	}
+}
+
+func TestSpendErrCode(t *testing.T) {
+	type tcExpected struct {
+		code  string
+		retry bool
+	}
+
+	type testCase struct {
+		name  string
+		given error
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "plain",
+			given: errors.New("something went wrong"),
+			exp:   tcExpected{code: spendErrCodeUnknown},
+		},
+
+		{
+			name:  "dup_redeem",
+			given: errorutils.New(errors.New("conflict"), "duplicate", errorutils.Codified{ErrCode: "cbr_dup_redeem"}),
+			exp:   tcExpected{code: "cbr_dup_redeem"},
+		},
+
+		{
+			name:  "server_err",
+			given: errorutils.New(errors.New("unavailable"), "server error", errorutils.Codified{ErrCode: "CBR_SERVER_ERR", Retry: true}),
+			exp:   tcExpected{code: "cbr_server_err", retry: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			code, retry := spendErrCode(tc.given)
+
+			should.Equal(t, tc.exp.code, code)
+			should.Equal(t, tc.exp.retry, retry)
+		})
+	}
+}
+
+func TestService_drainSpendRecord(t *testing.T) {
+	type tcGiven struct {
+		rec       *model.SpendRecord
+		redeemErr error
+		writeErr  error
+	}
+
+	type tcExpected struct {
+		errored   string
+		redeemed  bool
+		processed bool
+		mustErr   bool
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	id := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	creds := `[{"issuer": "brave.com?sku=user-wallet-vote", "t": "t", "signature": "s"}]`
+	topic := spendTopic("brave.com", "attention")
+
+	tests := []testCase{
+		{
+			name: "invalid_topic",
+			given: tcGiven{
+				rec: &model.SpendRecord{ID: id, Topic: voteTopic, Credentials: creds},
+			},
+			exp: tcExpected{errored: spendErrCodeInvalidTopic},
+		},
+
+		{
+			name: "invalid_credentials",
+			given: tcGiven{
+				rec: &model.SpendRecord{ID: id, Topic: topic, Credentials: "not json"},
+			},
+			exp: tcExpected{errored: spendErrCodeInvalidCreds},
+		},
+
+		{
+			name: "redeem_dup",
+			given: tcGiven{
+				rec:       &model.SpendRecord{ID: id, Topic: topic, Credentials: creds},
+				redeemErr: errorutils.New(errors.New("conflict"), "duplicate", errorutils.Codified{ErrCode: "cbr_dup_redeem"}),
+			},
+			exp: tcExpected{errored: "cbr_dup_redeem"},
+		},
+
+		{
+			name: "redeem_dup_on_retry",
+			given: tcGiven{
+				rec:       &model.SpendRecord{ID: id, Topic: topic, Credentials: creds, NumAttempts: 1},
+				redeemErr: errorutils.New(errors.New("conflict"), "duplicate", errorutils.Codified{ErrCode: "cbr_dup_redeem"}),
+			},
+			exp: tcExpected{redeemed: true, processed: true},
+		},
+
+		{
+			name: "redeem_retry",
+			given: tcGiven{
+				rec:       &model.SpendRecord{ID: id, Topic: topic, Credentials: creds},
+				redeemErr: errorutils.New(errors.New("unavailable"), "server error", errorutils.Codified{ErrCode: "cbr_server_err", Retry: true}),
+			},
+			exp: tcExpected{mustErr: true},
+		},
+
+		{
+			name: "write_failed",
+			given: tcGiven{
+				rec:      &model.SpendRecord{ID: id, Topic: topic, Credentials: creds},
+				writeErr: errors.New("broker unavailable"),
+			},
+			exp: tcExpected{redeemed: true, mustErr: true},
+		},
+
+		{
+			name: "already_redeemed",
+			given: tcGiven{
+				rec: &model.SpendRecord{ID: id, Topic: topic, Credentials: creds, Redeemed: true},
+			},
+			exp: tcExpected{processed: true},
+		},
+
+		{
+			name: "success",
+			given: tcGiven{
+				rec: &model.SpendRecord{ID: id, Topic: topic, Credentials: creds},
+			},
+			exp: tcExpected{redeemed: true, processed: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cb := mockcb.NewMockClient(ctrl)
+			cb.EXPECT().RedeemCredentials(gomock.Any(), gomock.Any(), gomock.Any()).Return(tc.given.redeemErr).AnyTimes()
+
+			var (
+				errored   string
+				redeemed  bool
+				processed bool
+			)
+
+			repo := &repository.MockSpend{
+				FnMarkErrored: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error {
+					errored = code
+
+					return nil
+				},
+
+				FnMarkRedeemed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+					redeemed = true
+
+					return nil
+				},
+
+				FnMarkProcessed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+					processed = true
+
+					return nil
+				},
+			}
+
+			writer := &fakeSpendWriter{
+				fnWriteMessages: func(ctx context.Context, msgs ...kafka.Message) error {
+					for i := range msgs {
+						if msgs[i].Topic != tc.given.rec.Topic {
+							return model.Error("unexpected_topic")
+						}
+					}
+
+					return tc.given.writeErr
+				},
+			}
+
+			svc := &Service{cbClient: cb, spendRepo: repo, spendWriter: writer}
+
+			err := svc.drainSpendRecord(context.Background(), nil, tc.given.rec)
+			should.Equal(t, tc.exp.mustErr, err != nil)
+
+			should.Equal(t, tc.exp.errored, errored)
+			should.Equal(t, tc.exp.redeemed, redeemed)
+			should.Equal(t, tc.exp.processed, processed)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type ConsumerOffset struct{}
+
+func NewConsumerOffset() *ConsumerOffset { return &ConsumerOffset{} }
+
+// GetForUpdate returns the last offset of the partition processed by the consumer group, and locks it.
+func (r *ConsumerOffset) GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string, partition int) (int64, error) {
+	const q = `SELECT last_offset FROM kafka_consumer_offsets
+	WHERE group_id = $1 AND topic = $2 AND partition = $3
+	FOR UPDATE`
+
+	var result int64
+	if err := sqlx.GetContext(ctx, dbi, &result, q, groupID, topic, partition); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, model.ErrConsumerOffsetNotFound
+		}
+
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// Set records offset as the last processed in the partition.
+//
+// The recorded offset never goes back.
+func (r *ConsumerOffset) Set(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, partition int, offset int64, when time.Time) error {
+	const q = `INSERT INTO kafka_consumer_offsets (group_id, topic, partition, last_offset, updated_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (group_id, topic, partition) DO UPDATE
+	SET last_offset = GREATEST(kafka_consumer_offsets.last_offset, EXCLUDED.last_offset), updated_at = EXCLUDED.updated_at`
+
+	if _, err := dbi.ExecContext(ctx, q, groupID, topic, partition, offset, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsPaused reports whether consumers of the group have been paused on the topic.
+func (r *ConsumerOffset) IsPaused(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string) (bool, error) {
+	const q = `SELECT paused FROM kafka_consumer_pauses WHERE group_id = $1 AND topic = $2`
+
+	var result bool
+	if err := sqlx.GetContext(ctx, dbi, &result, q, groupID, topic); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return result, nil
+}
+
+// SetPaused records whether consumers of the group should be paused on the topic.
+func (r *ConsumerOffset) SetPaused(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, paused bool, when time.Time) error {
+	const q = `INSERT INTO kafka_consumer_pauses (group_id, topic, paused, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (group_id, topic) DO UPDATE
+	SET paused = EXCLUDED.paused, updated_at = EXCLUDED.updated_at`
+
+	if _, err := dbi.ExecContext(ctx, q, groupID, topic, paused, when); err != nil {
+		return err
+	}
+
+	return nil
+}
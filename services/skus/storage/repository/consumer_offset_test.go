@@ -0,0 +1,130 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestConsumerOffset(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE kafka_consumer_offsets;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewConsumerOffset()
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	{
+		_, err := repo.GetForUpdate(ctx, tx, "group", "topic", 0)
+		should.ErrorIs(t, err, model.ErrConsumerOffsetNotFound)
+	}
+
+	must.Equal(t, nil, repo.Set(ctx, tx, "group", "topic", 0, 10, now))
+	must.Equal(t, nil, repo.Set(ctx, tx, "group", "topic", 1, 3, now))
+	must.Equal(t, nil, repo.Set(ctx, tx, "other", "topic", 0, 100, now))
+
+	{
+		actual, err := repo.GetForUpdate(ctx, tx, "group", "topic", 0)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(10), actual)
+	}
+
+	{
+		must.Equal(t, nil, repo.Set(ctx, tx, "group", "topic", 0, 12, now.Add(time.Minute)))
+
+		actual, err := repo.GetForUpdate(ctx, tx, "group", "topic", 0)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(12), actual)
+	}
+
+	{
+		// A replayed offset does not move the recorded one back.
+		must.Equal(t, nil, repo.Set(ctx, tx, "group", "topic", 0, 11, now.Add(2*time.Minute)))
+
+		actual, err := repo.GetForUpdate(ctx, tx, "group", "topic", 0)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(12), actual)
+	}
+
+	{
+		actual, err := repo.GetForUpdate(ctx, tx, "group", "topic", 1)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(3), actual)
+	}
+}
+
+func TestConsumerOffset_Paused(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE kafka_consumer_pauses;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewConsumerOffset()
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	{
+		actual, err := repo.IsPaused(ctx, tx, "group", "topic")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, false, actual)
+	}
+
+	must.Equal(t, nil, repo.SetPaused(ctx, tx, "group", "topic", true, now))
+
+	{
+		actual, err := repo.IsPaused(ctx, tx, "group", "topic")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, true, actual)
+	}
+
+	{
+		actual, err := repo.IsPaused(ctx, tx, "other", "topic")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, false, actual)
+	}
+
+	must.Equal(t, nil, repo.SetPaused(ctx, tx, "group", "topic", false, now.Add(time.Minute)))
+
+	{
+		actual, err := repo.IsPaused(ctx, tx, "group", "topic")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, false, actual)
+	}
+}
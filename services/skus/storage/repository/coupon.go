@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type Coupon struct{}
+
+func NewCoupon() *Coupon { return &Coupon{} }
+
+func (r *Coupon) Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.CreateCouponRequest) (*model.Coupon, error) {
+	const q = `INSERT INTO coupons (code, discount_type, amount, currency, skus, max_redemptions, expires_at, stripe_coupon_id)
+	VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''))
+	RETURNING id, created_at, updated_at, code, discount_type, amount, currency, skus, max_redemptions, num_redemptions, expires_at, stripe_coupon_id`
+
+	skus := req.SKUs
+	if skus == nil {
+		skus = []string{}
+	}
+
+	result := &model.Coupon{}
+	if err := sqlx.GetContext(
+		ctx,
+		dbi,
+		result,
+		q,
+		req.Code,
+		req.DiscountType,
+		req.Amount,
+		req.Currency,
+		pq.StringArray(skus),
+		req.MaxRedemptions,
+		req.ExpiresAt,
+		req.StripeCouponID,
+	); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *Coupon) GetByCode(ctx context.Context, dbi sqlx.QueryerContext, code string) (*model.Coupon, error) {
+	const q = `SELECT id, created_at, updated_at, code, discount_type, amount, currency, skus, max_redemptions, num_redemptions, expires_at, stripe_coupon_id
+	FROM coupons WHERE code = $1`
+
+	result := &model.Coupon{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrCouponNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RedeemForOrder counts one redemption of the coupon applied to the order, and marks the order as having redeemed it.
+//
+// It does nothing if the order has no coupon, or the redemption has already been counted.
+// The order has been paid at the discounted price, so it must not fail on the limit. Several orders may have been
+// created while one redemption was left, so the count stops at max_redemptions instead.
+func (r *Coupon) RedeemForOrder(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, now time.Time) error {
+	const q = `WITH ord AS (
+		UPDATE orders
+		SET metadata = metadata || jsonb_build_object('couponRedeemedAt', $2::text), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND metadata->>'couponCode' IS NOT NULL AND metadata->>'couponRedeemedAt' IS NULL
+		RETURNING metadata->>'couponCode' AS code
+	)
+	UPDATE coupons SET num_redemptions = LEAST(num_redemptions + 1, max_redemptions), updated_at = now()
+	FROM ord WHERE coupons.code = ord.code`
+
+	_, err := dbi.ExecContext(ctx, q, orderID, now.UTC().Format(time.RFC3339))
+
+	return err
+}
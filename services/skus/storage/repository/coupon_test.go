@@ -0,0 +1,190 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestCoupon_CreateGetByCode(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE coupons;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewCoupon()
+
+	{
+		_, err := repo.GetByCode(ctx, tx, "SUMMER")
+		should.Equal(t, model.ErrCouponNotFound, err)
+	}
+
+	req := &model.CreateCouponRequest{
+		Code:           "SUMMER",
+		DiscountType:   model.CouponDiscountTypeFixed,
+		Amount:         decimal.RequireFromString("2.5"),
+		Currency:       "USD",
+		SKUs:           []string{"brave-vpn-premium"},
+		StripeCouponID: "stripe_coupon_id",
+	}
+
+	created, err := repo.Create(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	actual, err := repo.GetByCode(ctx, tx, "SUMMER")
+	must.Equal(t, nil, err)
+
+	should.Equal(t, created.ID, actual.ID)
+	should.Equal(t, model.CouponDiscountTypeFixed, actual.DiscountType)
+	should.True(t, req.Amount.Equal(actual.Amount))
+	should.Equal(t, []string{"brave-vpn-premium"}, []string(actual.SKUs))
+	should.Nil(t, actual.MaxRedemptions)
+	should.Nil(t, actual.ExpiresAt)
+
+	must.NotNil(t, actual.Currency)
+	should.Equal(t, "USD", *actual.Currency)
+
+	must.NotNil(t, actual.StripeCouponID)
+	should.Equal(t, "stripe_coupon_id", *actual.StripeCouponID)
+}
+
+func TestCoupon_RedeemForOrder(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE coupons, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewCoupon()
+
+	maxRedemptions := 1
+
+	{
+		req := &model.CreateCouponRequest{
+			Code:           "SUMMER",
+			DiscountType:   model.CouponDiscountTypePercentage,
+			Amount:         decimal.NewFromInt(20),
+			MaxRedemptions: &maxRedemptions,
+		}
+
+		_, err := repo.Create(ctx, tx, req)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		const q = `INSERT INTO orders (id, merchant_id, status, currency, total_price, metadata)
+		VALUES
+			('facade00-0000-4000-a000-000000000000', 'brave.com', 'pending', 'USD', 7.99, '{"couponCode": "SUMMER"}'),
+			('decade00-0000-4000-a000-000000000000', 'brave.com', 'pending', 'USD', 7.99, '{"couponCode": "SUMMER"}'),
+			('ad0be000-0000-4000-a000-000000000000', 'brave.com', 'pending', 'USD', 9.99, '{}')`
+
+		_, err := tx.ExecContext(ctx, q)
+		must.Equal(t, nil, err)
+	}
+
+	now := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+	oid1 := uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000")
+	oid2 := uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000")
+	oid3 := uuid.FromStringOrNil("ad0be000-0000-4000-a000-000000000000")
+
+	// The first payment counts the redemption, and the renewals don't.
+	for i := 0; i < 2; i++ {
+		err := repo.RedeemForOrder(ctx, tx, oid1, now)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		actual, err := repo.GetByCode(ctx, tx, "SUMMER")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 1, actual.NumRedemptions)
+	}
+
+	// Both orders were created while one redemption was left, so the second payment must not fail on the limit.
+	{
+		err := repo.RedeemForOrder(ctx, tx, oid2, now)
+		must.Equal(t, nil, err)
+
+		actual, err := repo.GetByCode(ctx, tx, "SUMMER")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 1, actual.NumRedemptions)
+
+		ord, err := repository.NewOrder().Get(ctx, tx, oid2)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, "2024-01-01T00:00:01Z", ord.Metadata["couponRedeemedAt"])
+	}
+
+	// A coupon without a limit counts every redemption.
+	{
+		req := &model.CreateCouponRequest{
+			Code:         "WINTER",
+			DiscountType: model.CouponDiscountTypePercentage,
+			Amount:       decimal.NewFromInt(10),
+		}
+
+		_, err := repo.Create(ctx, tx, req)
+		must.Equal(t, nil, err)
+
+		const q = `INSERT INTO orders (id, merchant_id, status, currency, total_price, metadata)
+		VALUES
+			('c0ffee00-0000-4000-a000-000000000000', 'brave.com', 'pending', 'USD', 8.99, '{"couponCode": "WINTER"}'),
+			('beef0000-0000-4000-a000-000000000000', 'brave.com', 'pending', 'USD', 8.99, '{"couponCode": "WINTER"}')`
+
+		_, err = tx.ExecContext(ctx, q)
+		must.Equal(t, nil, err)
+
+		for _, id := range []string{"c0ffee00-0000-4000-a000-000000000000", "beef0000-0000-4000-a000-000000000000"} {
+			err := repo.RedeemForOrder(ctx, tx, uuid.FromStringOrNil(id), now)
+			must.Equal(t, nil, err)
+		}
+
+		actual, err := repo.GetByCode(ctx, tx, "WINTER")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 2, actual.NumRedemptions)
+	}
+
+	// An order without a coupon is ignored.
+	{
+		err := repo.RedeemForOrder(ctx, tx, oid3, now)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		ord, err := repository.NewOrder().Get(ctx, tx, oid1)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, "2024-01-01T00:00:01Z", ord.Metadata["couponRedeemedAt"])
+	}
+}
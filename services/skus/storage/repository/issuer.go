@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
 )
@@ -15,7 +17,7 @@ type Issuer struct{}
 func NewIssuer() *Issuer { return &Issuer{} }
 
 func (r *Issuer) GetByMerchID(ctx context.Context, dbi sqlx.QueryerContext, merchID string) (*model.Issuer, error) {
-	const q = `SELECT id, created_at, merchant_id, public_key
+	const q = `SELECT id, created_at, merchant_id, public_key, version, keys_checked_at
 	FROM order_cred_issuers WHERE merchant_id = $1`
 
 	result := &model.Issuer{}
@@ -31,7 +33,7 @@ func (r *Issuer) GetByMerchID(ctx context.Context, dbi sqlx.QueryerContext, merc
 }
 
 func (r *Issuer) GetByPubKey(ctx context.Context, dbi sqlx.QueryerContext, pubKey string) (*model.Issuer, error) {
-	const q = `SELECT id, created_at, merchant_id, public_key
+	const q = `SELECT id, created_at, merchant_id, public_key, version, keys_checked_at
 	FROM order_cred_issuers WHERE public_key = $1`
 
 	result := &model.Issuer{}
@@ -46,15 +48,53 @@ func (r *Issuer) GetByPubKey(ctx context.Context, dbi sqlx.QueryerContext, pubKe
 	return result, nil
 }
 
+// Create creates an issuer of req.Version, or version 1 when it is not set.
 func (r *Issuer) Create(ctx context.Context, dbi sqlx.QueryerContext, req model.IssuerNew) (*model.Issuer, error) {
-	const q = `INSERT INTO order_cred_issuers (merchant_id, public_key)
-	VALUES ($1, $2)
-	RETURNING id, created_at, merchant_id, public_key`
+	const q = `INSERT INTO order_cred_issuers (merchant_id, public_key, version)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at, merchant_id, public_key, version, keys_checked_at`
+
+	version := req.Version
+	if version == 0 {
+		version = 1
+	}
 
 	result := &model.Issuer{}
-	if err := dbi.QueryRowxContext(ctx, q, req.MerchantID, req.PublicKey).StructScan(result); err != nil {
+	if err := dbi.QueryRowxContext(ctx, q, req.MerchantID, req.PublicKey, version).StructScan(result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// GetNextDueKeyCheckForUpdate returns a version 3 issuer whose keys have not been checked since before, and locks it.
+func (r *Issuer) GetNextDueKeyCheckForUpdate(ctx context.Context, dbi sqlx.QueryerContext, before time.Time) (*model.Issuer, error) {
+	const q = `SELECT id, created_at, merchant_id, public_key, version, keys_checked_at
+	FROM order_cred_issuers
+	WHERE version = 3 AND (keys_checked_at IS NULL OR keys_checked_at < $1)
+	ORDER BY keys_checked_at NULLS FIRST
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED`
+
+	result := &model.Issuer{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, before); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrIssuerNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetKeysCheckedAt records when the keys of the issuer were last checked.
+func (r *Issuer) SetKeysCheckedAt(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_cred_issuers SET keys_checked_at = $2 WHERE id = $1`
+
+	if _, err := dbi.ExecContext(ctx, q, id, when); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type IssuerKey struct{}
+
+func NewIssuerKey() *IssuerKey { return &IssuerKey{} }
+
+// Upsert records keys of the issuer, and updates validity of the known ones.
+//
+// Revoked keys stay revoked.
+func (r *IssuerKey) Upsert(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, keys []model.IssuerKeyNew) error {
+	const q = `INSERT INTO issuer_keys (issuer_id, public_key, valid_from, valid_to)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (issuer_id, public_key) DO UPDATE
+	SET valid_from = EXCLUDED.valid_from, valid_to = EXCLUDED.valid_to`
+
+	for i := range keys {
+		if _, err := dbi.ExecContext(ctx, q, issuerID, keys[i].PublicKey, keys[i].ValidFrom, keys[i].ValidTo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List returns keys of the issuer which have not been revoked, in order of validity.
+func (r *IssuerKey) List(ctx context.Context, dbi sqlx.QueryerContext, issuerID uuid.UUID) ([]model.IssuerKey, error) {
+	const q = `SELECT id, created_at, issuer_id, public_key, valid_from, valid_to, revoked_at
+	FROM issuer_keys
+	WHERE issuer_id = $1 AND revoked_at IS NULL
+	ORDER BY valid_from`
+
+	result := make([]model.IssuerKey, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, issuerID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RevokeAll revokes all keys of the issuer.
+func (r *IssuerKey) RevokeAll(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, when time.Time) error {
+	const q = `UPDATE issuer_keys SET revoked_at = $2 WHERE issuer_id = $1 AND revoked_at IS NULL`
+
+	if _, err := dbi.ExecContext(ctx, q, issuerID, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListStates returns the state of keys of version 3 issuers, starting with those which run out first.
+func (r *IssuerKey) ListStates(ctx context.Context, dbi sqlx.QueryerContext) ([]model.IssuerKeyState, error) {
+	const q = `SELECT i.merchant_id, i.keys_checked_at, COUNT(k.id) AS num_keys, MAX(k.valid_to) AS valid_until
+	FROM order_cred_issuers i
+	LEFT JOIN issuer_keys k ON k.issuer_id = i.id AND k.revoked_at IS NULL
+	WHERE i.version = 3
+	GROUP BY i.id
+	ORDER BY valid_until NULLS FIRST, i.merchant_id`
+
+	result := make([]model.IssuerKeyState, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestIssuerKey(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE issuer_keys, order_cred_issuers;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	issRepo := repository.NewIssuer()
+	repo := repository.NewIssuerKey()
+
+	iss, err := issRepo.Create(ctx, tx, model.IssuerNew{MerchantID: "brave.com?sku=brave-vpn-premium", PublicKey: "public_key", Version: 3})
+	must.Equal(t, nil, err)
+
+	// Issuers of other versions are not reported.
+	_, err = issRepo.Create(ctx, tx, model.IssuerNew{MerchantID: "brave.com?sku=user-wallet-vote", PublicKey: "public_key_v1"})
+	must.Equal(t, nil, err)
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	{
+		actual, err := repo.ListStates(ctx, tx)
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, iss.MerchantID, actual[0].Issuer)
+		should.Equal(t, 0, actual[0].NumKeys)
+		should.Nil(t, actual[0].ValidUntil)
+	}
+
+	keys := []model.IssuerKeyNew{
+		{PublicKey: "key_02", ValidFrom: now.Add(24 * time.Hour), ValidTo: now.Add(48 * time.Hour)},
+		{PublicKey: "key_01", ValidFrom: now, ValidTo: now.Add(24 * time.Hour)},
+	}
+
+	must.Equal(t, nil, repo.Upsert(ctx, tx, iss.ID, keys))
+
+	// Known keys are not duplicated.
+	must.Equal(t, nil, repo.Upsert(ctx, tx, iss.ID, keys))
+
+	{
+		actual, err := repo.List(ctx, tx, iss.ID)
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 2)
+		should.Equal(t, "key_01", actual[0].PublicKey)
+		should.Equal(t, "key_02", actual[1].PublicKey)
+	}
+
+	{
+		actual, err := repo.ListStates(ctx, tx)
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, 2, actual[0].NumKeys)
+		must.NotNil(t, actual[0].ValidUntil)
+		should.Equal(t, now.Add(48*time.Hour), actual[0].ValidUntil.UTC())
+	}
+
+	must.Equal(t, nil, repo.RevokeAll(ctx, tx, iss.ID, now))
+
+	{
+		// Revoked keys stay revoked when reported again.
+		must.Equal(t, nil, repo.Upsert(ctx, tx, iss.ID, keys[:1]))
+
+		actual, err := repo.List(ctx, tx, iss.ID)
+		must.Equal(t, nil, err)
+
+		should.Len(t, actual, 0)
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const defaultMerchantKeyUseLimit = 100
+
+type MerchantKey struct{}
+
+func NewMerchantKey() *MerchantKey { return &MerchantKey{} }
+
+// SetLastUsedAt records when the key was used, unless a later use has already been recorded.
+func (r *MerchantKey) SetLastUsedAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+	const q = `UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)`
+
+	_, err := dbi.ExecContext(ctx, q, keyID, when)
+
+	return err
+}
+
+// InsertUses writes the audit records in one statement.
+func (r *MerchantKey) InsertUses(ctx context.Context, dbi sqlx.ExtContext, uses []model.MerchantKeyUse) error {
+	if len(uses) == 0 {
+		return nil
+	}
+
+	const q = `INSERT INTO api_key_audit (created_at, key_id, merchant_id, method, path, status)
+	VALUES (:created_at, :key_id, :merchant_id, :method, :path, :status)`
+
+	_, err := sqlx.NamedExecContext(ctx, dbi, q, uses)
+
+	return err
+}
+
+// DeleteUsesBefore deletes up to limit audit records created before the given time, and returns how many it deleted.
+func (r *MerchantKey) DeleteUsesBefore(ctx context.Context, dbi sqlx.ExecerContext, before time.Time, limit int) (int64, error) {
+	const q = `DELETE FROM api_key_audit WHERE id IN (
+		SELECT id FROM api_key_audit WHERE created_at < $1 LIMIT $2
+	)`
+
+	result, err := dbi.ExecContext(ctx, q, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ListUses returns audit records of the merchant, newest first.
+func (r *MerchantKey) ListUses(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantKeyUseFilter) ([]model.MerchantKeyUse, error) {
+	const q = `SELECT id, created_at, key_id, merchant_id, method, path, status
+	FROM api_key_audit
+	WHERE merchant_id = $1 AND ($2::uuid IS NULL OR key_id = $2) AND ($3::timestamptz IS NULL OR created_at < $3)
+	ORDER BY created_at DESC
+	LIMIT $4`
+
+	var before *time.Time
+	if !filter.Before.IsZero() {
+		before = &filter.Before
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > defaultMerchantKeyUseLimit {
+		limit = defaultMerchantKeyUseLimit
+	}
+
+	result := make([]model.MerchantKeyUse, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, merchID, filter.KeyID, before, limit); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetRotation schedules the active key of the merchant to be rotated every rot.EverySeconds from now.
+//
+// EverySeconds of zero turns scheduled rotation off.
+func (r *MerchantKey) SetRotation(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID uuid.UUID, rot model.MerchantKeyRotation, now time.Time) error {
+	const q = `UPDATE api_keys
+	SET rotate_every_seconds = NULLIF($3::integer, 0),
+		rotation_overlap_seconds = $4::integer,
+		next_rotation_at = CASE WHEN $3::integer > 0 THEN $5::timestamptz + make_interval(secs => $3::integer) ELSE NULL END
+	WHERE id = $1 AND merchant_id = $2 AND (expiry IS NULL OR expiry > $5::timestamptz)`
+
+	result, err := dbi.ExecContext(ctx, q, keyID, merchID, rot.EverySeconds, rot.OverlapSeconds, now)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return model.ErrMerchantKeyNotFound
+	}
+
+	return nil
+}
+
+// ClaimDueRotation takes the active key whose rotation is due the longest, and clears its next rotation time.
+//
+// A claimed key is not returned again, so keys can be rotated outside of a transaction, and without holding locks.
+func (r *MerchantKey) ClaimDueRotation(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error) {
+	const q = `UPDATE api_keys SET next_rotation_at = NULL
+	WHERE id = (
+		SELECT id FROM api_keys
+		WHERE next_rotation_at <= $1 AND (expiry IS NULL OR expiry > $1)
+		ORDER BY next_rotation_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, merchant_id, rotation_overlap_seconds`
+
+	result := &model.MerchantKeyDueRotation{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrMerchantKeyRotationNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetNextRotationAt reschedules the rotation of the key, e.g. after a failed attempt.
+func (r *MerchantKey) SetNextRotationAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+	const q = `UPDATE api_keys SET next_rotation_at = $2 WHERE id = $1 AND rotate_every_seconds IS NOT NULL`
+
+	_, err := dbi.ExecContext(ctx, q, keyID, when)
+
+	return err
+}
@@ -0,0 +1,207 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestMerchantKey_Uses(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE api_key_audit, api_keys;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	qs := []string{
+		`INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'key_01', 'brave.com', 'secret', 'nonce');`,
+
+		`INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000001', 'key_02', 'brave.com', 'secret', 'nonce');`,
+
+		`INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000002', 'key_03', 'other.com', 'secret', 'nonce');`,
+	}
+
+	for i := range qs {
+		_, err := tx.ExecContext(ctx, qs[i])
+		must.Equal(t, nil, err)
+	}
+
+	key01 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	key02 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+	key03 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000002"))
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := repository.NewMerchantKey()
+
+	uses := []model.MerchantKeyUse{
+		{CreatedAt: now, KeyID: key01, MerchantID: "brave.com", Method: "POST", Path: "/v1/credentials", Status: 200},
+		{CreatedAt: now.Add(time.Minute), KeyID: key02, MerchantID: "brave.com", Method: "GET", Path: "/v2/credentials/keys", Status: 200},
+		{CreatedAt: now.Add(2 * time.Minute), KeyID: key01, MerchantID: "brave.com", Method: "DELETE", Path: "/v1/orders/x", Status: 403},
+		{CreatedAt: now.Add(3 * time.Minute), KeyID: key03, MerchantID: "other.com", Method: "POST", Path: "/v1/credentials", Status: 200},
+	}
+
+	must.Equal(t, nil, repo.InsertUses(ctx, tx, uses))
+
+	{
+		actual, err := repo.ListUses(ctx, tx, "brave.com", model.MerchantKeyUseFilter{})
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 3)
+		should.Equal(t, "DELETE", actual[0].Method)
+		should.Equal(t, 403, actual[0].Status)
+		should.Equal(t, "GET", actual[1].Method)
+		should.Equal(t, "POST", actual[2].Method)
+	}
+
+	{
+		filter := model.MerchantKeyUseFilter{KeyID: uuid.NullUUID{UUID: key01, Valid: true}, Limit: 1}
+
+		actual, err := repo.ListUses(ctx, tx, "brave.com", filter)
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, "DELETE", actual[0].Method)
+	}
+
+	{
+		filter := model.MerchantKeyUseFilter{Before: now.Add(2 * time.Minute)}
+
+		actual, err := repo.ListUses(ctx, tx, "brave.com", filter)
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 2)
+		should.Equal(t, "GET", actual[0].Method)
+	}
+
+	{
+		must.Equal(t, nil, repo.SetLastUsedAt(ctx, tx, key01, now.Add(time.Hour)))
+
+		// An earlier use does not overwrite a later one.
+		must.Equal(t, nil, repo.SetLastUsedAt(ctx, tx, key01, now))
+
+		var actual time.Time
+		must.Equal(t, nil, tx.GetContext(ctx, &actual, `SELECT last_used_at FROM api_keys WHERE id = $1`, key01))
+
+		should.Equal(t, now.Add(time.Hour), actual.UTC())
+	}
+
+	{
+		n, err := repo.DeleteUsesBefore(ctx, tx, now.Add(2*time.Minute), 1)
+		must.Equal(t, nil, err)
+		should.Equal(t, int64(1), n)
+
+		n, err = repo.DeleteUsesBefore(ctx, tx, now.Add(2*time.Minute), 10)
+		must.Equal(t, nil, err)
+		should.Equal(t, int64(1), n)
+
+		var actual int
+		must.Equal(t, nil, tx.GetContext(ctx, &actual, `SELECT COUNT(*) FROM api_key_audit`))
+
+		should.Equal(t, 2, actual)
+	}
+}
+
+func TestMerchantKey_Rotation(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE api_key_audit, api_keys;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	qs := []string{
+		`INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'key_01', 'brave.com', 'secret', 'nonce');`,
+
+		`INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce, expiry)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000001', 'key_02', 'brave.com', 'secret', 'nonce', '2023-01-01');`,
+	}
+
+	for i := range qs {
+		_, err := tx.ExecContext(ctx, qs[i])
+		must.Equal(t, nil, err)
+	}
+
+	key01 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	key02 := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := repository.NewMerchantKey()
+
+	rot := model.MerchantKeyRotation{EverySeconds: 3600, OverlapSeconds: 60}
+
+	{
+		err := repo.SetRotation(ctx, tx, "other.com", key01, rot, now)
+		should.ErrorIs(t, err, model.ErrMerchantKeyNotFound)
+
+		err = repo.SetRotation(ctx, tx, "brave.com", key02, rot, now)
+		should.ErrorIs(t, err, model.ErrMerchantKeyNotFound)
+	}
+
+	must.Equal(t, nil, repo.SetRotation(ctx, tx, "brave.com", key01, rot, now))
+
+	{
+		_, err := repo.ClaimDueRotation(ctx, tx, now.Add(time.Minute))
+		should.ErrorIs(t, err, model.ErrMerchantKeyRotationNotFound)
+	}
+
+	{
+		actual, err := repo.ClaimDueRotation(ctx, tx, now.Add(time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, key01, actual.KeyID)
+		should.Equal(t, "brave.com", actual.MerchantID)
+		should.Equal(t, 60, actual.OverlapSeconds)
+
+		// A claimed key is not returned again.
+		_, err = repo.ClaimDueRotation(ctx, tx, now.Add(time.Hour))
+		should.ErrorIs(t, err, model.ErrMerchantKeyRotationNotFound)
+	}
+
+	{
+		must.Equal(t, nil, repo.SetNextRotationAt(ctx, tx, key01, now.Add(2*time.Hour)))
+
+		actual, err := repo.ClaimDueRotation(ctx, tx, now.Add(2*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, key01, actual.KeyID)
+	}
+
+	{
+		must.Equal(t, nil, repo.SetRotation(ctx, tx, "brave.com", key01, model.MerchantKeyRotation{}, now))
+		must.Equal(t, nil, repo.SetNextRotationAt(ctx, tx, key01, now))
+
+		_, err := repo.ClaimDueRotation(ctx, tx, now.Add(24*time.Hour))
+		should.ErrorIs(t, err, model.ErrMerchantKeyRotationNotFound)
+	}
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const defaultWebhookDeliveryLimit = 100
+
+type MerchantWebhook struct{}
+
+func NewMerchantWebhook() *MerchantWebhook { return &MerchantWebhook{} }
+
+func (r *MerchantWebhook) CreateEndpoint(ctx context.Context, dbi sqlx.QueryerContext, ep model.MerchantWebhookEndpoint) (*model.MerchantWebhookEndpoint, error) {
+	const q = `INSERT INTO merchant_webhook_endpoints (merchant_id, url, key_id, event_types)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at, merchant_id, url, key_id, event_types, disabled_at`
+
+	eventTypes := ep.EventTypes
+	if eventTypes == nil {
+		eventTypes = pq.StringArray{}
+	}
+
+	result := &model.MerchantWebhookEndpoint{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, ep.MerchantID, ep.URL, ep.KeyID, eventTypes); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListEndpoints returns enabled endpoints of the merchant.
+func (r *MerchantWebhook) ListEndpoints(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.MerchantWebhookEndpoint, error) {
+	const q = `SELECT id, created_at, merchant_id, url, key_id, event_types, disabled_at
+	FROM merchant_webhook_endpoints
+	WHERE merchant_id = $1 AND disabled_at IS NULL
+	ORDER BY created_at`
+
+	result := make([]model.MerchantWebhookEndpoint, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, merchID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DisableEndpoint disables the endpoint of the merchant, and fails its pending deliveries.
+func (r *MerchantWebhook) DisableEndpoint(ctx context.Context, dbi sqlx.QueryerContext, merchID string, id uuid.UUID, when time.Time) error {
+	const q = `WITH disabled AS (
+		UPDATE merchant_webhook_endpoints SET disabled_at = $3
+		WHERE id = $1 AND merchant_id = $2 AND disabled_at IS NULL
+		RETURNING id
+	), failed AS (
+		UPDATE merchant_webhook_deliveries SET status = 'failed', next_attempt_at = NULL, last_error = 'endpoint disabled'
+		WHERE endpoint_id IN (SELECT id FROM disabled) AND status = 'pending'
+	)
+	SELECT id FROM disabled`
+
+	var result uuid.UUID
+	if err := sqlx.GetContext(ctx, dbi, &result, q, id, merchID, when); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrWebhookEndpointNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceKey makes endpoints signed with the key oldID be signed with newID.
+func (r *MerchantWebhook) ReplaceKey(ctx context.Context, dbi sqlx.ExecerContext, oldID, newID uuid.UUID) error {
+	const q = `UPDATE merchant_webhook_endpoints SET key_id = $2 WHERE key_id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, oldID, newID)
+
+	return err
+}
+
+// InsertKeyRotated records that the merchant key keyID has been rotated to newKeyID, and creates deliveries of the event
+// to the enabled endpoints of the merchant subscribed to it, due at when.
+func (r *MerchantWebhook) InsertKeyRotated(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error {
+	const q = `WITH ev AS (
+		INSERT INTO merchant_key_events (merchant_id, event_type, key_id, new_key_id, occurred_at)
+		VALUES ($1, 'key.rotated', $2, $3, $4)
+		RETURNING id
+	)
+	INSERT INTO merchant_webhook_deliveries (endpoint_id, key_event_id, next_attempt_at)
+	SELECT w.id, ev.id, $4
+	FROM ev, merchant_webhook_endpoints AS w
+	WHERE w.merchant_id = $1 AND w.disabled_at IS NULL
+		AND (cardinality(w.event_types) = 0 OR 'key.rotated' = ANY(w.event_types))`
+
+	if _, err := dbi.ExecContext(ctx, q, merchID, keyID, newKeyID, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUnqueuedEventsForUpdate returns ids of up to limit order events for which deliveries have not been created, and locks them.
+func (r *MerchantWebhook) GetUnqueuedEventsForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]uuid.UUID, error) {
+	const q = `SELECT id
+	FROM order_events_outbox
+	WHERE webhooks_queued_at IS NULL
+	ORDER BY created_at
+	FOR UPDATE SKIP LOCKED
+	LIMIT $1`
+
+	result := make([]uuid.UUID, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, limit); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// InsertDeliveries creates deliveries of the events to the enabled endpoints subscribed to them, due at when.
+//
+// Only events about payments, cancellations and signed credentials are delivered.
+func (r *MerchantWebhook) InsertDeliveries(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error {
+	const q = `INSERT INTO merchant_webhook_deliveries (endpoint_id, event_id, next_attempt_at)
+	SELECT w.id, e.id, $2
+	FROM order_events_outbox AS e
+	JOIN orders AS o ON o.id = e.order_id
+	JOIN merchant_webhook_endpoints AS w ON w.merchant_id = o.merchant_id
+	WHERE e.id = ANY($1::uuid[]) AND w.disabled_at IS NULL
+		AND e.event_type IN ('order.paid', 'order.renewed', 'order.canceled', 'order.credentials_signed')
+		AND (cardinality(w.event_types) = 0 OR e.event_type = ANY(w.event_types))
+	ON CONFLICT DO NOTHING`
+
+	if _, err := dbi.ExecContext(ctx, q, pq.Array(uuidsToStrings(eventIDs)), when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *MerchantWebhook) MarkEventsQueued(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_events_outbox SET webhooks_queued_at = $2 WHERE id = ANY($1::uuid[])`
+
+	if _, err := dbi.ExecContext(ctx, q, pq.Array(uuidsToStrings(eventIDs)), when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClaimNextDueDelivery returns the pending delivery which has been due at now for the longest, and leases it until leaseUntil.
+//
+// The delivery is not returned again before the lease ends, so it can be attempted outside of a transaction.
+// If the outcome of the attempt is not recorded by then, e.g. because the process has stopped, the delivery is attempted again.
+// A key rotation event is signed with the rotated key, which the merchant already has.
+func (r *MerchantWebhook) ClaimNextDueDelivery(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.MerchantWebhookDueDelivery, error) {
+	const q = `WITH claimed AS (
+		UPDATE merchant_webhook_deliveries SET next_attempt_at = $2
+		WHERE id = (
+			SELECT id FROM merchant_webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *
+	)
+	SELECT d.id, d.created_at, d.endpoint_id, COALESCE(d.event_id, d.key_event_id) AS event_id,
+		COALESCE(e.event_type, k.event_type) AS event_type, e.order_id, k.key_id AS rotated_key_id, k.new_key_id,
+		COALESCE(e.occurred_at, k.occurred_at) AS occurred_at,
+		d.status, d.num_attempts, d.next_attempt_at, d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at,
+		w.url, COALESCE(k.key_id, w.key_id) AS key_id
+	FROM claimed AS d
+	JOIN merchant_webhook_endpoints AS w ON w.id = d.endpoint_id
+	LEFT JOIN order_events_outbox AS e ON e.id = d.event_id
+	LEFT JOIN merchant_key_events AS k ON k.id = d.key_event_id`
+
+	result := &model.MerchantWebhookDueDelivery{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now, leaseUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrWebhookDeliveryNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *MerchantWebhook) MarkDelivered(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, when time.Time) error {
+	const q = `UPDATE merchant_webhook_deliveries
+	SET status = 'delivered', num_attempts = num_attempts + 1, next_attempt_at = NULL,
+		last_attempt_at = $3, last_status_code = $2, last_error = NULL, delivered_at = $3
+	WHERE id = $1`
+
+	if _, err := dbi.ExecContext(ctx, q, id, statusCode, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarkAttemptFailed records a failed attempt, and schedules the next one at next.
+//
+// A zero statusCode means no response was received. When next is nil, the delivery is failed.
+func (r *MerchantWebhook) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, msg string, when time.Time, next *time.Time) error {
+	const q = `UPDATE merchant_webhook_deliveries
+	SET status = CASE WHEN $5::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		num_attempts = num_attempts + 1, next_attempt_at = $5,
+		last_attempt_at = $4, last_status_code = NULLIF($2, 0), last_error = $3
+	WHERE id = $1`
+
+	if _, err := dbi.ExecContext(ctx, q, id, statusCode, msg, when, next); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListDeliveries returns deliveries to endpoints of the merchant, newest first.
+func (r *MerchantWebhook) ListDeliveries(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantWebhookDeliveryFilter) ([]model.MerchantWebhookDelivery, error) {
+	const q = `SELECT d.id, d.created_at, d.endpoint_id, COALESCE(d.event_id, d.key_event_id) AS event_id,
+		COALESCE(e.event_type, k.event_type) AS event_type, e.order_id, k.key_id AS rotated_key_id, k.new_key_id,
+		COALESCE(e.occurred_at, k.occurred_at) AS occurred_at,
+		d.status, d.num_attempts, d.next_attempt_at, d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at
+	FROM merchant_webhook_deliveries AS d
+	JOIN merchant_webhook_endpoints AS w ON w.id = d.endpoint_id
+	LEFT JOIN order_events_outbox AS e ON e.id = d.event_id
+	LEFT JOIN merchant_key_events AS k ON k.id = d.key_event_id
+	WHERE w.merchant_id = $1 AND ($2::uuid IS NULL OR d.endpoint_id = $2) AND ($3::timestamptz IS NULL OR d.created_at < $3)
+	ORDER BY d.created_at DESC
+	LIMIT $4`
+
+	var before *time.Time
+	if !filter.Before.IsZero() {
+		before = &filter.Before
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > defaultWebhookDeliveryLimit {
+		limit = defaultWebhookDeliveryLimit
+	}
+
+	result := make([]model.MerchantWebhookDelivery, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, merchID, filter.EndpointID, before, limit); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Redeliver schedules the delivery to an enabled endpoint of the merchant to be attempted at when.
+//
+// The count of attempts is reset, so the delivery is retried as if it were new.
+func (r *MerchantWebhook) Redeliver(ctx context.Context, dbi sqlx.ExecerContext, merchID string, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE merchant_webhook_deliveries AS d
+	SET status = 'pending', num_attempts = 0, next_attempt_at = $3, delivered_at = NULL
+	FROM merchant_webhook_endpoints AS w
+	WHERE d.id = $1 AND w.id = d.endpoint_id AND w.merchant_id = $2 AND w.disabled_at IS NULL`
+
+	result, err := dbi.ExecContext(ctx, q, id, merchID, when)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return model.ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+func uuidsToStrings(ids []uuid.UUID) []string {
+	result := make([]string, 0, len(ids))
+	for i := range ids {
+		result = append(result, ids[i].String())
+	}
+
+	return result
+}
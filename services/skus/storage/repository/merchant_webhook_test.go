@@ -0,0 +1,246 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestMerchantWebhook_Deliveries(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE merchant_webhook_deliveries, merchant_webhook_endpoints, api_keys, order_events_outbox, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	{
+		const q = `INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'key_01', 'brave.com', 'secret', 'nonce');`
+
+		_, err := tx.ExecContext(ctx, q)
+		must.Equal(t, nil, err)
+	}
+
+	keyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewMerchantWebhook()
+
+	epAll, err := repo.CreateEndpoint(ctx, tx, model.MerchantWebhookEndpoint{MerchantID: "brave.com", URL: "https://example.com/all", KeyID: keyID})
+	must.Equal(t, nil, err)
+
+	epCanceled, err := repo.CreateEndpoint(ctx, tx, model.MerchantWebhookEndpoint{
+		MerchantID: "brave.com",
+		URL:        "https://example.com/canceled",
+		KeyID:      keyID,
+		EventTypes: []string{model.OrderEventCanceled},
+	})
+	must.Equal(t, nil, err)
+
+	_, err = repo.CreateEndpoint(ctx, tx, model.MerchantWebhookEndpoint{MerchantID: "other.com", URL: "https://example.com/other", KeyID: keyID})
+	must.Equal(t, nil, err)
+
+	{
+		actual, err := repo.ListEndpoints(ctx, tx, "brave.com")
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 2)
+		should.Equal(t, epAll.ID, actual[0].ID)
+		should.Equal(t, epCanceled.ID, actual[1].ID)
+	}
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	evRepo := repository.NewOrderEvent()
+	must.Equal(t, nil, evRepo.Insert(ctx, tx, ord.ID, model.OrderEventCreated, now))
+	must.Equal(t, nil, evRepo.Insert(ctx, tx, ord.ID, model.OrderEventPaid, now))
+
+	eventIDs, err := repo.GetUnqueuedEventsForUpdate(ctx, tx, 10)
+	must.Equal(t, nil, err)
+	must.Len(t, eventIDs, 2)
+
+	must.Equal(t, nil, repo.InsertDeliveries(ctx, tx, eventIDs, now))
+	must.Equal(t, nil, repo.MarkEventsQueued(ctx, tx, eventIDs, now))
+
+	{
+		actual, err := repo.GetUnqueuedEventsForUpdate(ctx, tx, 10)
+		must.Equal(t, nil, err)
+		should.Len(t, actual, 0)
+	}
+
+	// Only the paid event is delivered, and only to the endpoint subscribed to all events.
+	due, err := repo.ClaimNextDueDelivery(ctx, tx, now, now.Add(5*time.Minute))
+	must.Equal(t, nil, err)
+
+	should.Equal(t, epAll.ID, due.EndpointID)
+	should.Equal(t, "https://example.com/all", due.URL)
+	should.Equal(t, keyID, due.KeyID)
+	should.Equal(t, model.OrderEventPaid, due.EventType)
+	must.NotNil(t, due.OrderID)
+	should.Equal(t, ord.ID, *due.OrderID)
+	should.Nil(t, due.RotatedKeyID)
+
+	// A claimed delivery is not returned again while it is leased.
+	{
+		_, err := repo.ClaimNextDueDelivery(ctx, tx, now.Add(time.Minute), now.Add(6*time.Minute))
+		should.Equal(t, model.ErrWebhookDeliveryNotFound, err)
+	}
+
+	next := now.Add(time.Minute)
+	must.Equal(t, nil, repo.MarkAttemptFailed(ctx, tx, due.ID, 500, "unexpected status", now, &next))
+
+	{
+		_, err := repo.ClaimNextDueDelivery(ctx, tx, now, now.Add(5*time.Minute))
+		should.Equal(t, model.ErrWebhookDeliveryNotFound, err)
+	}
+
+	{
+		actual, err := repo.ListDeliveries(ctx, tx, "brave.com", model.MerchantWebhookDeliveryFilter{})
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, model.WebhookDeliveryStatusPending, actual[0].Status)
+		should.Equal(t, 1, actual[0].NumAttempts)
+		must.NotNil(t, actual[0].LastStatusCode)
+		should.Equal(t, 500, *actual[0].LastStatusCode)
+	}
+
+	must.Equal(t, nil, repo.MarkAttemptFailed(ctx, tx, due.ID, 0, "timeout", next, nil))
+
+	{
+		actual, err := repo.ListDeliveries(ctx, tx, "brave.com", model.MerchantWebhookDeliveryFilter{})
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, model.WebhookDeliveryStatusFailed, actual[0].Status)
+		should.Equal(t, 2, actual[0].NumAttempts)
+		should.Nil(t, actual[0].LastStatusCode)
+	}
+
+	should.Equal(t, model.ErrWebhookDeliveryNotFound, repo.Redeliver(ctx, tx, "other.com", due.ID, next))
+	must.Equal(t, nil, repo.Redeliver(ctx, tx, "brave.com", due.ID, next))
+
+	{
+		actual, err := repo.ClaimNextDueDelivery(ctx, tx, next, next.Add(5*time.Minute))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 0, actual.NumAttempts)
+	}
+
+	must.Equal(t, nil, repo.MarkDelivered(ctx, tx, due.ID, 204, next))
+
+	{
+		actual, err := repo.ListDeliveries(ctx, tx, "brave.com", model.MerchantWebhookDeliveryFilter{EndpointID: uuid.NullUUID{UUID: epAll.ID, Valid: true}})
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, model.WebhookDeliveryStatusDelivered, actual[0].Status)
+		should.NotNil(t, actual[0].DeliveredAt)
+	}
+
+	should.Equal(t, model.ErrWebhookEndpointNotFound, repo.DisableEndpoint(ctx, tx, "other.com", epAll.ID, next))
+	must.Equal(t, nil, repo.DisableEndpoint(ctx, tx, "brave.com", epAll.ID, next))
+	should.Equal(t, model.ErrWebhookEndpointNotFound, repo.DisableEndpoint(ctx, tx, "brave.com", epAll.ID, next))
+
+	{
+		actual, err := repo.ListEndpoints(ctx, tx, "brave.com")
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, epCanceled.ID, actual[0].ID)
+	}
+}
+
+func TestMerchantWebhook_InsertKeyRotated(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE merchant_webhook_deliveries, merchant_key_events, merchant_webhook_endpoints, api_keys;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	{
+		const q = `INSERT INTO api_keys (id, name, merchant_id, encrypted_secret_key, nonce)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'key_01', 'brave.com', 'secret', 'nonce'),
+			('5ca1ab1e-0000-4000-a000-000000000001', 'key_01', 'brave.com', 'secret', 'nonce');`
+
+		_, err := tx.ExecContext(ctx, q)
+		must.Equal(t, nil, err)
+	}
+
+	keyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000000"))
+	newKeyID := uuid.Must(uuid.FromString("5ca1ab1e-0000-4000-a000-000000000001"))
+
+	repo := repository.NewMerchantWebhook()
+
+	epAll, err := repo.CreateEndpoint(ctx, tx, model.MerchantWebhookEndpoint{MerchantID: "brave.com", URL: "https://example.com/all", KeyID: keyID})
+	must.Equal(t, nil, err)
+
+	_, err = repo.CreateEndpoint(ctx, tx, model.MerchantWebhookEndpoint{
+		MerchantID: "brave.com",
+		URL:        "https://example.com/canceled",
+		KeyID:      keyID,
+		EventTypes: []string{model.OrderEventCanceled},
+	})
+	must.Equal(t, nil, err)
+
+	must.Equal(t, nil, repo.ReplaceKey(ctx, tx, keyID, newKeyID))
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	must.Equal(t, nil, repo.InsertKeyRotated(ctx, tx, "brave.com", keyID, newKeyID, now))
+
+	// The event is delivered only to the endpoint subscribed to all events, and is signed with the rotated key.
+	due, err := repo.ClaimNextDueDelivery(ctx, tx, now, now.Add(5*time.Minute))
+	must.Equal(t, nil, err)
+
+	should.Equal(t, epAll.ID, due.EndpointID)
+	should.Equal(t, model.MerchantKeyEventRotated, due.EventType)
+	should.Equal(t, keyID, due.KeyID)
+	should.Nil(t, due.OrderID)
+	must.NotNil(t, due.RotatedKeyID)
+	should.Equal(t, keyID, *due.RotatedKeyID)
+	must.NotNil(t, due.NewKeyID)
+	should.Equal(t, newKeyID, *due.NewKeyID)
+
+	{
+		_, err := repo.ClaimNextDueDelivery(ctx, tx, now, now.Add(5*time.Minute))
+		should.Equal(t, model.ErrWebhookDeliveryNotFound, err)
+	}
+
+	{
+		actual, err := repo.ListDeliveries(ctx, tx, "brave.com", model.MerchantWebhookDeliveryFilter{})
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, due.EventID, actual[0].EventID)
+		should.Equal(t, model.MerchantKeyEventRotated, actual[0].EventType)
+	}
+}
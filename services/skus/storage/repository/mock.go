@@ -6,6 +6,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 
 	"github.com/brave-intl/bat-go/libs/datastore"
 
@@ -14,6 +15,7 @@ import (
 
 type MockOrder struct {
 	FnGet                               func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
+	FnGetForUpdate                      func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error)
 	FnGetByExternalID                   func(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error)
 	FnCreate                            func(ctx context.Context, dbi sqlx.QueryerContext, oreq *model.OrderNew) (*model.Order, error)
 	FnSetStatus                         func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, status string) error
@@ -25,6 +27,7 @@ type MockOrder struct {
 	FnAppendMetadataInt64               func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, key string, val int64) error
 	FnGetExpiredStripeCheckoutSessionID func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) (string, error)
 	FnIncrementNumPayFailed             func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	FnSetTotalPrice                     func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, price decimal.Decimal) error
 }
 
 func (r *MockOrder) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
@@ -39,6 +42,18 @@ func (r *MockOrder) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UU
 	return r.FnGet(ctx, dbi, id)
 }
 
+func (r *MockOrder) GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+	if r.FnGetForUpdate == nil {
+		result := &model.Order{
+			ID: uuid.NewV4(),
+		}
+
+		return result, nil
+	}
+
+	return r.FnGetForUpdate(ctx, dbi, id)
+}
+
 func (r *MockOrder) GetByExternalID(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
 	if r.FnGetByExternalID == nil {
 		result := &model.Order{
@@ -142,10 +157,19 @@ func (r *MockOrder) IncrementNumPayFailed(ctx context.Context, dbi sqlx.ExecerCo
 	return r.FnIncrementNumPayFailed(ctx, dbi, id)
 }
 
+func (r *MockOrder) SetTotalPrice(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, price decimal.Decimal) error {
+	if r.FnSetTotalPrice == nil {
+		return nil
+	}
+
+	return r.FnSetTotalPrice(ctx, dbi, id, price)
+}
+
 type MockOrderItem struct {
 	FnGet           func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderItem, error)
 	FnFindByOrderID func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderItem, error)
 	FnInsertMany    func(ctx context.Context, dbi sqlx.ExtContext, items ...model.OrderItem) ([]model.OrderItem, error)
+	FnUpdatePlan    func(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error
 }
 
 func (r *MockOrderItem) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderItem, error) {
@@ -172,10 +196,21 @@ func (r *MockOrderItem) InsertMany(ctx context.Context, dbi sqlx.ExtContext, ite
 	return r.FnInsertMany(ctx, dbi, items...)
 }
 
+func (r *MockOrderItem) UpdatePlan(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error {
+	if r.FnUpdatePlan == nil {
+		return nil
+	}
+
+	return r.FnUpdatePlan(ctx, dbi, item)
+}
+
 type MockIssuer struct {
 	FnGetByMerchID func(ctx context.Context, dbi sqlx.QueryerContext, merchID string) (*model.Issuer, error)
 	FnGetByPubKey  func(ctx context.Context, dbi sqlx.QueryerContext, pubKey string) (*model.Issuer, error)
 	FnCreate       func(ctx context.Context, dbi sqlx.QueryerContext, req model.IssuerNew) (*model.Issuer, error)
+
+	FnGetNextDueKeyCheckForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, before time.Time) (*model.Issuer, error)
+	FnSetKeysCheckedAt            func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
 }
 
 func (r *MockIssuer) GetByMerchID(ctx context.Context, dbi sqlx.QueryerContext, merchID string) (*model.Issuer, error) {
@@ -223,8 +258,25 @@ func (r *MockIssuer) Create(ctx context.Context, dbi sqlx.QueryerContext, req mo
 	return r.FnCreate(ctx, dbi, req)
 }
 
+func (r *MockIssuer) GetNextDueKeyCheckForUpdate(ctx context.Context, dbi sqlx.QueryerContext, before time.Time) (*model.Issuer, error) {
+	if r.FnGetNextDueKeyCheckForUpdate == nil {
+		return nil, model.ErrIssuerNotFound
+	}
+
+	return r.FnGetNextDueKeyCheckForUpdate(ctx, dbi, before)
+}
+
+func (r *MockIssuer) SetKeysCheckedAt(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnSetKeysCheckedAt == nil {
+		return nil
+	}
+
+	return r.FnSetKeysCheckedAt(ctx, dbi, id, when)
+}
+
 type MockOrderPayHistory struct {
 	FnInsert func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	FnList   func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]time.Time, error)
 }
 
 func (r *MockOrderPayHistory) Insert(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
@@ -235,10 +287,22 @@ func (r *MockOrderPayHistory) Insert(ctx context.Context, dbi sqlx.ExecerContext
 	return r.FnInsert(ctx, dbi, id, when)
 }
 
+func (r *MockOrderPayHistory) List(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]time.Time, error) {
+	if r.FnList == nil {
+		return nil, nil
+	}
+
+	return r.FnList(ctx, dbi, id)
+}
+
 type MockTLV2 struct {
 	FnGetCredSubmissionReport func(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID, reqID uuid.UUID, firstBCred string) (model.TLV2CredSubmissionReport, error)
 	FnUniqBatches             func(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID uuid.UUID, from, to time.Time) (int, error)
 	FnDeleteLegacy            func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID) error
+	FnDeleteValidAfter        func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error
+	FnUniqSeatBatches         func(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error)
+	FnDeleteSeat              func(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error
+	FnDeleteIssuerValidAfter  func(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, from time.Time) (int64, error)
 }
 
 func (r *MockTLV2) GetCredSubmissionReport(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID, reqID uuid.UUID, firstBCred string) (model.TLV2CredSubmissionReport, error) {
@@ -264,3 +328,1014 @@ func (r *MockTLV2) DeleteLegacy(ctx context.Context, dbi sqlx.ExecerContext, ord
 
 	return r.FnDeleteLegacy(ctx, dbi, orderID)
 }
+
+func (r *MockTLV2) DeleteValidAfter(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error {
+	if r.FnDeleteValidAfter == nil {
+		return nil
+	}
+
+	return r.FnDeleteValidAfter(ctx, dbi, orderID, from)
+}
+
+func (r *MockTLV2) UniqSeatBatches(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error) {
+	if r.FnUniqSeatBatches == nil {
+		return 0, nil
+	}
+
+	return r.FnUniqSeatBatches(ctx, dbi, seatID, itemID, from, to)
+}
+
+func (r *MockTLV2) DeleteSeat(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error {
+	if r.FnDeleteSeat == nil {
+		return nil
+	}
+
+	return r.FnDeleteSeat(ctx, dbi, seatID)
+}
+
+func (r *MockTLV2) DeleteIssuerValidAfter(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, from time.Time) (int64, error) {
+	if r.FnDeleteIssuerValidAfter == nil {
+		return 0, nil
+	}
+
+	return r.FnDeleteIssuerValidAfter(ctx, dbi, issuerID, from)
+}
+
+type MockWebhookInbox struct {
+	FnInsert        func(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error)
+	FnGet           func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.WebhookInboxEntry, error)
+	FnClaimNext     func(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.WebhookInboxEntry, error)
+	FnMarkProcessed func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	FnMarkFailed    func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string, nextAt time.Time) error
+	FnMarkDead      func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string) error
+	FnReset         func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+}
+
+func (r *MockWebhookInbox) Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error) {
+	if r.FnInsert == nil {
+		result := &model.WebhookInboxEntry{
+			ID:      uuid.NewV4(),
+			Vendor:  req.Vendor,
+			Payload: req.Payload,
+			Status:  model.WebhookInboxStatusPending,
+		}
+
+		return result, nil
+	}
+
+	return r.FnInsert(ctx, dbi, req)
+}
+
+func (r *MockWebhookInbox) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.WebhookInboxEntry, error) {
+	if r.FnGet == nil {
+		result := &model.WebhookInboxEntry{
+			ID:     id,
+			Status: model.WebhookInboxStatusDead,
+		}
+
+		return result, nil
+	}
+
+	return r.FnGet(ctx, dbi, id)
+}
+
+func (r *MockWebhookInbox) ClaimNext(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.WebhookInboxEntry, error) {
+	if r.FnClaimNext == nil {
+		return nil, model.ErrWebhookInboxEntryNotFound
+	}
+
+	return r.FnClaimNext(ctx, dbi, now, leaseUntil)
+}
+
+func (r *MockWebhookInbox) MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnMarkProcessed == nil {
+		return nil
+	}
+
+	return r.FnMarkProcessed(ctx, dbi, id, when)
+}
+
+func (r *MockWebhookInbox) MarkFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string, nextAt time.Time) error {
+	if r.FnMarkFailed == nil {
+		return nil
+	}
+
+	return r.FnMarkFailed(ctx, dbi, id, lastErr, nextAt)
+}
+
+func (r *MockWebhookInbox) MarkDead(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string) error {
+	if r.FnMarkDead == nil {
+		return nil
+	}
+
+	return r.FnMarkDead(ctx, dbi, id, lastErr)
+}
+
+func (r *MockWebhookInbox) Reset(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnReset == nil {
+		return nil
+	}
+
+	return r.FnReset(ctx, dbi, id, when)
+}
+
+type MockTransaction struct {
+	FnInsert func(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error)
+}
+
+func (r *MockTransaction) Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+	if r.FnInsert == nil {
+		result := &model.Transaction{
+			ID:                    uuid.NewV4(),
+			OrderID:               req.OrderID,
+			ExternalTransactionID: req.ExternalTransactionID,
+			Status:                req.Status,
+			Currency:              req.Currency,
+			Kind:                  req.Kind,
+			Amount:                req.Amount,
+		}
+
+		return result, nil
+	}
+
+	return r.FnInsert(ctx, dbi, req)
+}
+
+type MockOrderSeat struct {
+	FnCreate           func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error)
+	FnGet              func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error)
+	FnGetByInviteToken func(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error)
+	FnList             func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderSeat, error)
+	FnRedeem           func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	FnRevoke           func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+}
+
+func (r *MockOrderSeat) Create(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error) {
+	if r.FnCreate == nil {
+		result := &model.OrderSeat{
+			ID:          uuid.NewV4(),
+			OrderID:     orderID,
+			InviteToken: inviteToken,
+			Status:      model.OrderSeatStatusPending,
+		}
+
+		return result, nil
+	}
+
+	return r.FnCreate(ctx, dbi, orderID, inviteToken, nmax)
+}
+
+func (r *MockOrderSeat) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+	if r.FnGet == nil {
+		result := &model.OrderSeat{
+			ID:     id,
+			Status: model.OrderSeatStatusActive,
+		}
+
+		return result, nil
+	}
+
+	return r.FnGet(ctx, dbi, id)
+}
+
+func (r *MockOrderSeat) GetByInviteToken(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+	if r.FnGetByInviteToken == nil {
+		result := &model.OrderSeat{
+			ID:          uuid.NewV4(),
+			InviteToken: inviteToken,
+			Status:      model.OrderSeatStatusPending,
+		}
+
+		return result, nil
+	}
+
+	return r.FnGetByInviteToken(ctx, dbi, inviteToken)
+}
+
+func (r *MockOrderSeat) List(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderSeat, error) {
+	if r.FnList == nil {
+		return []model.OrderSeat{}, nil
+	}
+
+	return r.FnList(ctx, dbi, orderID)
+}
+
+func (r *MockOrderSeat) Redeem(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnRedeem == nil {
+		return nil
+	}
+
+	return r.FnRedeem(ctx, dbi, id, when)
+}
+
+func (r *MockOrderSeat) Revoke(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnRevoke == nil {
+		return nil
+	}
+
+	return r.FnRevoke(ctx, dbi, id, when)
+}
+
+type MockCoupon struct {
+	FnCreate         func(ctx context.Context, dbi sqlx.QueryerContext, req *model.CreateCouponRequest) (*model.Coupon, error)
+	FnGetByCode      func(ctx context.Context, dbi sqlx.QueryerContext, code string) (*model.Coupon, error)
+	FnRedeemForOrder func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, now time.Time) error
+}
+
+func (r *MockCoupon) Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.CreateCouponRequest) (*model.Coupon, error) {
+	if r.FnCreate == nil {
+		result := &model.Coupon{
+			ID:           uuid.NewV4(),
+			Code:         req.Code,
+			DiscountType: req.DiscountType,
+			Amount:       req.Amount,
+		}
+
+		return result, nil
+	}
+
+	return r.FnCreate(ctx, dbi, req)
+}
+
+func (r *MockCoupon) GetByCode(ctx context.Context, dbi sqlx.QueryerContext, code string) (*model.Coupon, error) {
+	if r.FnGetByCode == nil {
+		return nil, model.ErrCouponNotFound
+	}
+
+	return r.FnGetByCode(ctx, dbi, code)
+}
+
+func (r *MockCoupon) RedeemForOrder(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, now time.Time) error {
+	if r.FnRedeemForOrder == nil {
+		return nil
+	}
+
+	return r.FnRedeemForOrder(ctx, dbi, orderID, now)
+}
+
+type MockOrderEvent struct {
+	FnInsert             func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error
+	FnInsertPaid         func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error
+	FnInsertExpired      func(ctx context.Context, dbi sqlx.ExecerContext, from, to time.Time, limit int) (int64, error)
+	FnGetUnsentForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]model.OrderEvent, error)
+	FnMarkSent           func(ctx context.Context, dbi sqlx.ExecerContext, ids []uuid.UUID, when time.Time) error
+}
+
+func (r *MockOrderEvent) Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+	if r.FnInsert == nil {
+		return nil
+	}
+
+	return r.FnInsert(ctx, dbi, orderID, typ, when)
+}
+
+func (r *MockOrderEvent) InsertPaid(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error {
+	if r.FnInsertPaid == nil {
+		return nil
+	}
+
+	return r.FnInsertPaid(ctx, dbi, orderID, paidAt)
+}
+
+func (r *MockOrderEvent) InsertExpired(ctx context.Context, dbi sqlx.ExecerContext, from, to time.Time, limit int) (int64, error) {
+	if r.FnInsertExpired == nil {
+		return 0, nil
+	}
+
+	return r.FnInsertExpired(ctx, dbi, from, to, limit)
+}
+
+func (r *MockOrderEvent) GetUnsentForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]model.OrderEvent, error) {
+	if r.FnGetUnsentForUpdate == nil {
+		return []model.OrderEvent{}, nil
+	}
+
+	return r.FnGetUnsentForUpdate(ctx, dbi, limit)
+}
+
+func (r *MockOrderEvent) MarkSent(ctx context.Context, dbi sqlx.ExecerContext, ids []uuid.UUID, when time.Time) error {
+	if r.FnMarkSent == nil {
+		return nil
+	}
+
+	return r.FnMarkSent(ctx, dbi, ids, when)
+}
+
+type MockOrderDunning struct {
+	FnStart               func(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error)
+	FnGetNextDueForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error)
+	FnRemind              func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error
+	FnResolve             func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error
+	FnCancel              func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error
+}
+
+func (r *MockOrderDunning) Start(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+	if r.FnStart == nil {
+		result := &model.OrderDunning{
+			ID:             uuid.NewV4(),
+			OrderID:        orderID,
+			Vendor:         vendor,
+			GraceEndsAt:    graceEndsAt,
+			NextReminderAt: nextReminderAt,
+		}
+
+		return result, nil
+	}
+
+	return r.FnStart(ctx, dbi, orderID, vendor, graceEndsAt, nextReminderAt)
+}
+
+func (r *MockOrderDunning) GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+	if r.FnGetNextDueForUpdate == nil {
+		return nil, model.ErrOrderDunningNotFound
+	}
+
+	return r.FnGetNextDueForUpdate(ctx, dbi, now)
+}
+
+func (r *MockOrderDunning) Remind(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error {
+	if r.FnRemind == nil {
+		return nil
+	}
+
+	return r.FnRemind(ctx, dbi, id, next)
+}
+
+func (r *MockOrderDunning) Resolve(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error {
+	if r.FnResolve == nil {
+		return nil
+	}
+
+	return r.FnResolve(ctx, dbi, orderID, when)
+}
+
+func (r *MockOrderDunning) Cancel(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error {
+	if r.FnCancel == nil {
+		return nil
+	}
+
+	return r.FnCancel(ctx, dbi, orderID, when)
+}
+
+type MockStripeSubCancel struct {
+	FnInsert              func(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error
+	FnGetNextDueForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error)
+	FnMarkCanceled        func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	FnMarkAttemptFailed   func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error
+}
+
+func (r *MockStripeSubCancel) Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+	if r.FnInsert == nil {
+		return nil
+	}
+
+	return r.FnInsert(ctx, dbi, orderID, subID)
+}
+
+func (r *MockStripeSubCancel) GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error) {
+	if r.FnGetNextDueForUpdate == nil {
+		return nil, model.ErrStripeSubCancelNotFound
+	}
+
+	return r.FnGetNextDueForUpdate(ctx, dbi, now)
+}
+
+func (r *MockStripeSubCancel) MarkCanceled(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnMarkCanceled == nil {
+		return nil
+	}
+
+	return r.FnMarkCanceled(ctx, dbi, id, when)
+}
+
+func (r *MockStripeSubCancel) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+	if r.FnMarkAttemptFailed == nil {
+		return nil
+	}
+
+	return r.FnMarkAttemptFailed(ctx, dbi, id, msg, next)
+}
+
+type MockSKUCatalog struct {
+	FnCreate              func(ctx context.Context, dbi sqlx.QueryerContext, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error)
+	FnGet                 func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.SKUCatalogEntry, error)
+	FnGetBySKUVnt         func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error)
+	FnGetByStoreProductID func(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error)
+	FnGetByStripePriceID  func(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error)
+	FnList                func(ctx context.Context, dbi sqlx.QueryerContext) ([]model.SKUCatalogEntry, error)
+	FnUpdate              func(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error)
+	FnDelete              func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+func (r *MockSKUCatalog) Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	if r.FnCreate == nil {
+		result := &model.SKUCatalogEntry{
+			ID:             uuid.NewV4(),
+			SKU:            req.SKU,
+			SKUVnt:         req.SKUVnt,
+			CredentialType: req.CredentialType,
+		}
+
+		return result, nil
+	}
+
+	return r.FnCreate(ctx, dbi, req)
+}
+
+func (r *MockSKUCatalog) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.SKUCatalogEntry, error) {
+	if r.FnGet == nil {
+		return nil, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return r.FnGet(ctx, dbi, id)
+}
+
+func (r *MockSKUCatalog) GetBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error) {
+	if r.FnGetBySKUVnt == nil {
+		return nil, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return r.FnGetBySKUVnt(ctx, dbi, skuVnt)
+}
+
+func (r *MockSKUCatalog) GetByStoreProductID(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error) {
+	if r.FnGetByStoreProductID == nil {
+		return nil, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return r.FnGetByStoreProductID(ctx, dbi, productID)
+}
+
+func (r *MockSKUCatalog) GetByStripePriceID(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error) {
+	if r.FnGetByStripePriceID == nil {
+		return nil, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return r.FnGetByStripePriceID(ctx, dbi, priceID)
+}
+
+func (r *MockSKUCatalog) List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.SKUCatalogEntry, error) {
+	if r.FnList == nil {
+		return nil, nil
+	}
+
+	return r.FnList(ctx, dbi)
+}
+
+func (r *MockSKUCatalog) Update(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	if r.FnUpdate == nil {
+		return nil, model.ErrSKUCatalogEntryNotFound
+	}
+
+	return r.FnUpdate(ctx, dbi, id, req)
+}
+
+func (r *MockSKUCatalog) Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnDelete == nil {
+		return nil
+	}
+
+	return r.FnDelete(ctx, dbi, id)
+}
+
+type MockSKUPrice struct {
+	FnUpsert          func(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID, req *model.SKUPriceRequest) (*model.SKUPrice, error)
+	FnListByCatalogID func(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID) ([]model.SKUPrice, error)
+	FnFindBySKUVnt    func(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error)
+	FnDelete          func(ctx context.Context, dbi sqlx.ExecerContext, catalogID, id uuid.UUID) error
+}
+
+func (r *MockSKUPrice) Upsert(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID, req *model.SKUPriceRequest) (*model.SKUPrice, error) {
+	if r.FnUpsert == nil {
+		result := &model.SKUPrice{
+			ID:           uuid.NewV4(),
+			SKUCatalogID: catalogID,
+			Currency:     req.Currency,
+			Country:      req.Country,
+			Amount:       req.Amount,
+		}
+
+		return result, nil
+	}
+
+	return r.FnUpsert(ctx, dbi, catalogID, req)
+}
+
+func (r *MockSKUPrice) ListByCatalogID(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID) ([]model.SKUPrice, error) {
+	if r.FnListByCatalogID == nil {
+		return nil, nil
+	}
+
+	return r.FnListByCatalogID(ctx, dbi, catalogID)
+}
+
+func (r *MockSKUPrice) FindBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error) {
+	if r.FnFindBySKUVnt == nil {
+		return nil, model.ErrSKUPriceNotFound
+	}
+
+	return r.FnFindBySKUVnt(ctx, dbi, skuVnt, currency, country)
+}
+
+func (r *MockSKUPrice) Delete(ctx context.Context, dbi sqlx.ExecerContext, catalogID, id uuid.UUID) error {
+	if r.FnDelete == nil {
+		return nil
+	}
+
+	return r.FnDelete(ctx, dbi, catalogID, id)
+}
+
+type MockPortal struct {
+	FnInsertLoginCode             func(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error
+	FnGetLatestLoginCodeForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error)
+	FnGetLoginStats               func(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error)
+	FnIncrLoginCodeAttempts       func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	FnMarkLoginCodeUsed           func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error
+	FnInsertSession               func(ctx context.Context, dbi sqlx.QueryerContext, email, tokenHash string, expiresAt time.Time) (*model.PortalSession, error)
+	FnGetSession                  func(ctx context.Context, dbi sqlx.QueryerContext, tokenHash string, now time.Time) (*model.PortalSession, error)
+	FnDeleteSession               func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+func (r *MockPortal) InsertLoginCode(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+	if r.FnInsertLoginCode == nil {
+		return nil
+	}
+
+	return r.FnInsertLoginCode(ctx, dbi, email, codeHash, expiresAt)
+}
+
+func (r *MockPortal) GetLatestLoginCodeForUpdate(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+	if r.FnGetLatestLoginCodeForUpdate == nil {
+		return nil, model.ErrPortalLoginCodeInvalid
+	}
+
+	return r.FnGetLatestLoginCodeForUpdate(ctx, dbi, email)
+}
+
+func (r *MockPortal) GetLoginStats(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error) {
+	if r.FnGetLoginStats == nil {
+		return model.PortalLoginStats{}, nil
+	}
+
+	return r.FnGetLoginStats(ctx, dbi, email, since)
+}
+
+func (r *MockPortal) IncrLoginCodeAttempts(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnIncrLoginCodeAttempts == nil {
+		return nil
+	}
+
+	return r.FnIncrLoginCodeAttempts(ctx, dbi, id)
+}
+
+func (r *MockPortal) MarkLoginCodeUsed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	if r.FnMarkLoginCodeUsed == nil {
+		return nil
+	}
+
+	return r.FnMarkLoginCodeUsed(ctx, dbi, id, when)
+}
+
+func (r *MockPortal) InsertSession(ctx context.Context, dbi sqlx.QueryerContext, email, tokenHash string, expiresAt time.Time) (*model.PortalSession, error) {
+	if r.FnInsertSession == nil {
+		result := &model.PortalSession{
+			ID:        uuid.NewV4(),
+			Email:     email,
+			ExpiresAt: expiresAt,
+		}
+
+		return result, nil
+	}
+
+	return r.FnInsertSession(ctx, dbi, email, tokenHash, expiresAt)
+}
+
+func (r *MockPortal) GetSession(ctx context.Context, dbi sqlx.QueryerContext, tokenHash string, now time.Time) (*model.PortalSession, error) {
+	if r.FnGetSession == nil {
+		return nil, model.ErrPortalSessionNotFound
+	}
+
+	return r.FnGetSession(ctx, dbi, tokenHash, now)
+}
+
+func (r *MockPortal) DeleteSession(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnDeleteSession == nil {
+		return nil
+	}
+
+	return r.FnDeleteSession(ctx, dbi, id)
+}
+
+type MockMerchantKey struct {
+	FnSetLastUsedAt     func(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error
+	FnInsertUses        func(ctx context.Context, dbi sqlx.ExtContext, uses []model.MerchantKeyUse) error
+	FnDeleteUsesBefore  func(ctx context.Context, dbi sqlx.ExecerContext, before time.Time, limit int) (int64, error)
+	FnListUses          func(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantKeyUseFilter) ([]model.MerchantKeyUse, error)
+	FnSetRotation       func(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID uuid.UUID, rot model.MerchantKeyRotation, now time.Time) error
+	FnClaimDueRotation  func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error)
+	FnSetNextRotationAt func(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error
+}
+
+func (r *MockMerchantKey) SetLastUsedAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+	if r.FnSetLastUsedAt == nil {
+		return nil
+	}
+
+	return r.FnSetLastUsedAt(ctx, dbi, keyID, when)
+}
+
+func (r *MockMerchantKey) InsertUses(ctx context.Context, dbi sqlx.ExtContext, uses []model.MerchantKeyUse) error {
+	if r.FnInsertUses == nil {
+		return nil
+	}
+
+	return r.FnInsertUses(ctx, dbi, uses)
+}
+
+func (r *MockMerchantKey) DeleteUsesBefore(ctx context.Context, dbi sqlx.ExecerContext, before time.Time, limit int) (int64, error) {
+	if r.FnDeleteUsesBefore == nil {
+		return 0, nil
+	}
+
+	return r.FnDeleteUsesBefore(ctx, dbi, before, limit)
+}
+
+func (r *MockMerchantKey) ListUses(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantKeyUseFilter) ([]model.MerchantKeyUse, error) {
+	if r.FnListUses == nil {
+		return []model.MerchantKeyUse{}, nil
+	}
+
+	return r.FnListUses(ctx, dbi, merchID, filter)
+}
+
+func (r *MockMerchantKey) SetRotation(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID uuid.UUID, rot model.MerchantKeyRotation, now time.Time) error {
+	if r.FnSetRotation == nil {
+		return nil
+	}
+
+	return r.FnSetRotation(ctx, dbi, merchID, keyID, rot, now)
+}
+
+func (r *MockMerchantKey) ClaimDueRotation(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.MerchantKeyDueRotation, error) {
+	if r.FnClaimDueRotation == nil {
+		return nil, model.ErrMerchantKeyRotationNotFound
+	}
+
+	return r.FnClaimDueRotation(ctx, dbi, now)
+}
+
+func (r *MockMerchantKey) SetNextRotationAt(ctx context.Context, dbi sqlx.ExecerContext, keyID uuid.UUID, when time.Time) error {
+	if r.FnSetNextRotationAt == nil {
+		return nil
+	}
+
+	return r.FnSetNextRotationAt(ctx, dbi, keyID, when)
+}
+
+type MockMerchantWebhook struct {
+	FnCreateEndpoint             func(ctx context.Context, dbi sqlx.QueryerContext, ep model.MerchantWebhookEndpoint) (*model.MerchantWebhookEndpoint, error)
+	FnListEndpoints              func(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.MerchantWebhookEndpoint, error)
+	FnDisableEndpoint            func(ctx context.Context, dbi sqlx.QueryerContext, merchID string, id uuid.UUID, when time.Time) error
+	FnReplaceKey                 func(ctx context.Context, dbi sqlx.ExecerContext, oldID, newID uuid.UUID) error
+	FnInsertKeyRotated           func(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error
+	FnGetUnqueuedEventsForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]uuid.UUID, error)
+	FnInsertDeliveries           func(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error
+	FnMarkEventsQueued           func(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error
+	FnClaimNextDueDelivery       func(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.MerchantWebhookDueDelivery, error)
+	FnMarkDelivered              func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, when time.Time) error
+	FnMarkAttemptFailed          func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, msg string, when time.Time, next *time.Time) error
+	FnListDeliveries             func(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantWebhookDeliveryFilter) ([]model.MerchantWebhookDelivery, error)
+	FnRedeliver                  func(ctx context.Context, dbi sqlx.ExecerContext, merchID string, id uuid.UUID, when time.Time) error
+}
+
+func (r *MockMerchantWebhook) CreateEndpoint(ctx context.Context, dbi sqlx.QueryerContext, ep model.MerchantWebhookEndpoint) (*model.MerchantWebhookEndpoint, error) {
+	if r.FnCreateEndpoint == nil {
+		return &ep, nil
+	}
+
+	return r.FnCreateEndpoint(ctx, dbi, ep)
+}
+
+func (r *MockMerchantWebhook) ListEndpoints(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.MerchantWebhookEndpoint, error) {
+	if r.FnListEndpoints == nil {
+		return []model.MerchantWebhookEndpoint{}, nil
+	}
+
+	return r.FnListEndpoints(ctx, dbi, merchID)
+}
+
+func (r *MockMerchantWebhook) DisableEndpoint(ctx context.Context, dbi sqlx.QueryerContext, merchID string, id uuid.UUID, when time.Time) error {
+	if r.FnDisableEndpoint == nil {
+		return nil
+	}
+
+	return r.FnDisableEndpoint(ctx, dbi, merchID, id, when)
+}
+
+func (r *MockMerchantWebhook) ReplaceKey(ctx context.Context, dbi sqlx.ExecerContext, oldID, newID uuid.UUID) error {
+	if r.FnReplaceKey == nil {
+		return nil
+	}
+
+	return r.FnReplaceKey(ctx, dbi, oldID, newID)
+}
+
+func (r *MockMerchantWebhook) InsertKeyRotated(ctx context.Context, dbi sqlx.ExecerContext, merchID string, keyID, newKeyID uuid.UUID, when time.Time) error {
+	if r.FnInsertKeyRotated == nil {
+		return nil
+	}
+
+	return r.FnInsertKeyRotated(ctx, dbi, merchID, keyID, newKeyID, when)
+}
+
+func (r *MockMerchantWebhook) GetUnqueuedEventsForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]uuid.UUID, error) {
+	if r.FnGetUnqueuedEventsForUpdate == nil {
+		return []uuid.UUID{}, nil
+	}
+
+	return r.FnGetUnqueuedEventsForUpdate(ctx, dbi, limit)
+}
+
+func (r *MockMerchantWebhook) InsertDeliveries(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error {
+	if r.FnInsertDeliveries == nil {
+		return nil
+	}
+
+	return r.FnInsertDeliveries(ctx, dbi, eventIDs, when)
+}
+
+func (r *MockMerchantWebhook) MarkEventsQueued(ctx context.Context, dbi sqlx.ExecerContext, eventIDs []uuid.UUID, when time.Time) error {
+	if r.FnMarkEventsQueued == nil {
+		return nil
+	}
+
+	return r.FnMarkEventsQueued(ctx, dbi, eventIDs, when)
+}
+
+func (r *MockMerchantWebhook) ClaimNextDueDelivery(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.MerchantWebhookDueDelivery, error) {
+	if r.FnClaimNextDueDelivery == nil {
+		return nil, model.ErrWebhookDeliveryNotFound
+	}
+
+	return r.FnClaimNextDueDelivery(ctx, dbi, now, leaseUntil)
+}
+
+func (r *MockMerchantWebhook) MarkDelivered(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, when time.Time) error {
+	if r.FnMarkDelivered == nil {
+		return nil
+	}
+
+	return r.FnMarkDelivered(ctx, dbi, id, statusCode, when)
+}
+
+func (r *MockMerchantWebhook) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, statusCode int, msg string, when time.Time, next *time.Time) error {
+	if r.FnMarkAttemptFailed == nil {
+		return nil
+	}
+
+	return r.FnMarkAttemptFailed(ctx, dbi, id, statusCode, msg, when, next)
+}
+
+func (r *MockMerchantWebhook) ListDeliveries(ctx context.Context, dbi sqlx.QueryerContext, merchID string, filter model.MerchantWebhookDeliveryFilter) ([]model.MerchantWebhookDelivery, error) {
+	if r.FnListDeliveries == nil {
+		return []model.MerchantWebhookDelivery{}, nil
+	}
+
+	return r.FnListDeliveries(ctx, dbi, merchID, filter)
+}
+
+func (r *MockMerchantWebhook) Redeliver(ctx context.Context, dbi sqlx.ExecerContext, merchID string, id uuid.UUID, when time.Time) error {
+	if r.FnRedeliver == nil {
+		return nil
+	}
+
+	return r.FnRedeliver(ctx, dbi, merchID, id, when)
+}
+
+type MockSpend struct {
+	FnCreateChannel               func(ctx context.Context, dbi sqlx.QueryerContext, ch model.SpendChannel) (*model.SpendChannel, error)
+	FnGetChannel                  func(ctx context.Context, dbi sqlx.QueryerContext, merchID, name string) (*model.SpendChannel, error)
+	FnListChannels                func(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.SpendChannel, error)
+	FnDisableChannel              func(ctx context.Context, dbi sqlx.ExecerContext, merchID, name string, when time.Time) error
+	FnInsertRecord                func(ctx context.Context, dbi sqlx.ExecerContext, rec model.SpendRecord) error
+	FnGetNextUncommittedForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.SpendRecord, error)
+	FnMarkAttemptFailed           func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error
+	FnMarkRedeemed                func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	FnMarkErrored                 func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error
+	FnMarkProcessed               func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+}
+
+func (r *MockSpend) CreateChannel(ctx context.Context, dbi sqlx.QueryerContext, ch model.SpendChannel) (*model.SpendChannel, error) {
+	if r.FnCreateChannel == nil {
+		return &ch, nil
+	}
+
+	return r.FnCreateChannel(ctx, dbi, ch)
+}
+
+func (r *MockSpend) GetChannel(ctx context.Context, dbi sqlx.QueryerContext, merchID, name string) (*model.SpendChannel, error) {
+	if r.FnGetChannel == nil {
+		return &model.SpendChannel{MerchantID: merchID, Name: name}, nil
+	}
+
+	return r.FnGetChannel(ctx, dbi, merchID, name)
+}
+
+func (r *MockSpend) ListChannels(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.SpendChannel, error) {
+	if r.FnListChannels == nil {
+		return []model.SpendChannel{}, nil
+	}
+
+	return r.FnListChannels(ctx, dbi, merchID)
+}
+
+func (r *MockSpend) DisableChannel(ctx context.Context, dbi sqlx.ExecerContext, merchID, name string, when time.Time) error {
+	if r.FnDisableChannel == nil {
+		return nil
+	}
+
+	return r.FnDisableChannel(ctx, dbi, merchID, name, when)
+}
+
+func (r *MockSpend) InsertRecord(ctx context.Context, dbi sqlx.ExecerContext, rec model.SpendRecord) error {
+	if r.FnInsertRecord == nil {
+		return nil
+	}
+
+	return r.FnInsertRecord(ctx, dbi, rec)
+}
+
+func (r *MockSpend) GetNextUncommittedForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.SpendRecord, error) {
+	if r.FnGetNextUncommittedForUpdate == nil {
+		return nil, model.ErrSpendRecordNotFound
+	}
+
+	return r.FnGetNextUncommittedForUpdate(ctx, dbi, now)
+}
+
+func (r *MockSpend) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+	if r.FnMarkAttemptFailed == nil {
+		return nil
+	}
+
+	return r.FnMarkAttemptFailed(ctx, dbi, id, msg, next)
+}
+
+func (r *MockSpend) MarkRedeemed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnMarkRedeemed == nil {
+		return nil
+	}
+
+	return r.FnMarkRedeemed(ctx, dbi, id)
+}
+
+func (r *MockSpend) MarkErrored(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error {
+	if r.FnMarkErrored == nil {
+		return nil
+	}
+
+	return r.FnMarkErrored(ctx, dbi, id, code)
+}
+
+func (r *MockSpend) MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnMarkProcessed == nil {
+		return nil
+	}
+
+	return r.FnMarkProcessed(ctx, dbi, id)
+}
+
+type MockConsumerOffset struct {
+	FnGetForUpdate func(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string, partition int) (int64, error)
+	FnSet          func(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, partition int, offset int64, when time.Time) error
+	FnIsPaused     func(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string) (bool, error)
+	FnSetPaused    func(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, paused bool, when time.Time) error
+}
+
+func (r *MockConsumerOffset) GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string, partition int) (int64, error) {
+	if r.FnGetForUpdate == nil {
+		return 0, model.ErrConsumerOffsetNotFound
+	}
+
+	return r.FnGetForUpdate(ctx, dbi, groupID, topic, partition)
+}
+
+func (r *MockConsumerOffset) Set(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, partition int, offset int64, when time.Time) error {
+	if r.FnSet == nil {
+		return nil
+	}
+
+	return r.FnSet(ctx, dbi, groupID, topic, partition, offset, when)
+}
+
+func (r *MockConsumerOffset) IsPaused(ctx context.Context, dbi sqlx.QueryerContext, groupID, topic string) (bool, error) {
+	if r.FnIsPaused == nil {
+		return false, nil
+	}
+
+	return r.FnIsPaused(ctx, dbi, groupID, topic)
+}
+
+func (r *MockConsumerOffset) SetPaused(ctx context.Context, dbi sqlx.ExecerContext, groupID, topic string, paused bool, when time.Time) error {
+	if r.FnSetPaused == nil {
+		return nil
+	}
+
+	return r.FnSetPaused(ctx, dbi, groupID, topic, paused, when)
+}
+
+type MockIssuerKey struct {
+	FnUpsert     func(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, keys []model.IssuerKeyNew) error
+	FnList       func(ctx context.Context, dbi sqlx.QueryerContext, issuerID uuid.UUID) ([]model.IssuerKey, error)
+	FnRevokeAll  func(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, when time.Time) error
+	FnListStates func(ctx context.Context, dbi sqlx.QueryerContext) ([]model.IssuerKeyState, error)
+}
+
+func (r *MockIssuerKey) Upsert(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, keys []model.IssuerKeyNew) error {
+	if r.FnUpsert == nil {
+		return nil
+	}
+
+	return r.FnUpsert(ctx, dbi, issuerID, keys)
+}
+
+func (r *MockIssuerKey) List(ctx context.Context, dbi sqlx.QueryerContext, issuerID uuid.UUID) ([]model.IssuerKey, error) {
+	if r.FnList == nil {
+		return []model.IssuerKey{}, nil
+	}
+
+	return r.FnList(ctx, dbi, issuerID)
+}
+
+func (r *MockIssuerKey) RevokeAll(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, when time.Time) error {
+	if r.FnRevokeAll == nil {
+		return nil
+	}
+
+	return r.FnRevokeAll(ctx, dbi, issuerID, when)
+}
+
+func (r *MockIssuerKey) ListStates(ctx context.Context, dbi sqlx.QueryerContext) ([]model.IssuerKeyState, error) {
+	if r.FnListStates == nil {
+		return []model.IssuerKeyState{}, nil
+	}
+
+	return r.FnListStates(ctx, dbi)
+}
+
+type MockTLV1Migration struct {
+	FnUpsert             func(ctx context.Context, dbi sqlx.QueryerContext, req *model.TLV1MigrationRequest) (*model.TLV1Migration, error)
+	FnGet                func(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error)
+	FnList               func(ctx context.Context, dbi sqlx.QueryerContext) ([]model.TLV1Migration, error)
+	FnDelete             func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	FnRecordPresentation func(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error
+	FnListUsage          func(ctx context.Context, dbi sqlx.QueryerContext, from time.Time) ([]model.TLV1Usage, error)
+}
+
+func (r *MockTLV1Migration) Upsert(ctx context.Context, dbi sqlx.QueryerContext, req *model.TLV1MigrationRequest) (*model.TLV1Migration, error) {
+	if r.FnUpsert == nil {
+		return &model.TLV1Migration{}, nil
+	}
+
+	return r.FnUpsert(ctx, dbi, req)
+}
+
+func (r *MockTLV1Migration) Get(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+	if r.FnGet == nil {
+		return nil, model.ErrTLV1MigrationNotFound
+	}
+
+	return r.FnGet(ctx, dbi, merchID, sku)
+}
+
+func (r *MockTLV1Migration) List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.TLV1Migration, error) {
+	if r.FnList == nil {
+		return []model.TLV1Migration{}, nil
+	}
+
+	return r.FnList(ctx, dbi)
+}
+
+func (r *MockTLV1Migration) Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	if r.FnDelete == nil {
+		return nil
+	}
+
+	return r.FnDelete(ctx, dbi, id)
+}
+
+func (r *MockTLV1Migration) RecordPresentation(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error {
+	if r.FnRecordPresentation == nil {
+		return nil
+	}
+
+	return r.FnRecordPresentation(ctx, dbi, p)
+}
+
+func (r *MockTLV1Migration) ListUsage(ctx context.Context, dbi sqlx.QueryerContext, from time.Time) ([]model.TLV1Usage, error) {
+	if r.FnListUsage == nil {
+		return []model.TLV1Usage{}, nil
+	}
+
+	return r.FnListUsage(ctx, dbi, from)
+}
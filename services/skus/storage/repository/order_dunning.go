@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type OrderDunning struct{}
+
+func NewOrderDunning() *OrderDunning { return &OrderDunning{} }
+
+// Start begins dunning for the order, and returns the active entry.
+//
+// If the order is already in dunning, the existing entry is returned unchanged.
+func (r *OrderDunning) Start(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, vendor string, graceEndsAt, nextReminderAt time.Time) (*model.OrderDunning, error) {
+	const q = `INSERT INTO order_dunning (order_id, vendor, grace_ends_at, next_reminder_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (order_id) WHERE resolved_at IS NULL AND canceled_at IS NULL DO UPDATE SET updated_at = now()
+	RETURNING id, created_at, updated_at, order_id, vendor, grace_ends_at, next_reminder_at, num_reminders, resolved_at, canceled_at`
+
+	result := &model.OrderDunning{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, orderID, vendor, graceEndsAt, nextReminderAt); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetNextDueForUpdate returns the active entry which is due for a reminder or cancellation at now, and locks it.
+//
+// Entries locked by other workers are skipped.
+func (r *OrderDunning) GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.OrderDunning, error) {
+	const q = `SELECT id, created_at, updated_at, order_id, vendor, grace_ends_at, next_reminder_at, num_reminders, resolved_at, canceled_at
+	FROM order_dunning
+	WHERE resolved_at IS NULL AND canceled_at IS NULL AND LEAST(next_reminder_at, grace_ends_at) <= $1
+	ORDER BY LEAST(next_reminder_at, grace_ends_at)
+	FOR UPDATE SKIP LOCKED
+	LIMIT 1`
+
+	result := &model.OrderDunning{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrOrderDunningNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Remind records a reminder, and schedules the next one at next.
+func (r *OrderDunning) Remind(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, next time.Time) error {
+	const q = `UPDATE order_dunning
+	SET num_reminders = num_reminders + 1, next_reminder_at = $2, updated_at = now()
+	WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, next)
+}
+
+// Resolve ends active dunning for the order after a successful payment.
+//
+// It's not an error if the order is not in dunning.
+func (r *OrderDunning) Resolve(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_dunning
+	SET resolved_at = $2, updated_at = now()
+	WHERE order_id = $1 AND resolved_at IS NULL AND canceled_at IS NULL`
+
+	if _, err := dbi.ExecContext(ctx, q, orderID, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Cancel ends active dunning for the order without a payment.
+//
+// It's not an error if the order is not in dunning.
+func (r *OrderDunning) Cancel(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_dunning
+	SET canceled_at = $2, updated_at = now()
+	WHERE order_id = $1 AND resolved_at IS NULL AND canceled_at IS NULL`
+
+	if _, err := dbi.ExecContext(ctx, q, orderID, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *OrderDunning) execUpdate(ctx context.Context, dbi sqlx.ExecerContext, q string, args ...interface{}) error {
+	result, err := dbi.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrOrderDunningNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestOrderDunning_Start(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_dunning, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewOrderDunning()
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	first, err := repo.Start(ctx, tx, ord.ID, model.WebhookVendorStripe, now.Add(7*24*time.Hour), now.Add(48*time.Hour))
+	must.Equal(t, nil, err)
+
+	should.Equal(t, ord.ID, first.OrderID)
+	should.Equal(t, model.WebhookVendorStripe, first.Vendor)
+	should.True(t, now.Add(7*24*time.Hour).Equal(first.GraceEndsAt))
+
+	// A repeated failure does not extend the grace period.
+	{
+		later := now.Add(24 * time.Hour)
+
+		actual, err := repo.Start(ctx, tx, ord.ID, model.WebhookVendorStripe, later.Add(7*24*time.Hour), later.Add(48*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, first.ID, actual.ID)
+		should.True(t, first.GraceEndsAt.Equal(actual.GraceEndsAt))
+	}
+
+	// A failure after the previous dunning has been resolved starts a new one.
+	{
+		must.Equal(t, nil, repo.Resolve(ctx, tx, ord.ID, now.Add(72*time.Hour)))
+
+		later := now.Add(30 * 24 * time.Hour)
+
+		actual, err := repo.Start(ctx, tx, ord.ID, model.WebhookVendorStripe, later.Add(7*24*time.Hour), later.Add(48*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.NotEqual(t, first.ID, actual.ID)
+		should.True(t, later.Add(7*24*time.Hour).Equal(actual.GraceEndsAt))
+	}
+}
+
+func TestOrderDunning_GetNextDueForUpdate(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_dunning, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	orepo := repository.NewOrder()
+
+	ord1, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	ord2, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	repo := repository.NewOrderDunning()
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	dun1, err := repo.Start(ctx, tx, ord1.ID, model.WebhookVendorStripe, now.Add(7*24*time.Hour), now.Add(48*time.Hour))
+	must.Equal(t, nil, err)
+
+	_, err = repo.Start(ctx, tx, ord2.ID, model.WebhookVendorPlayStore, now.Add(7*24*time.Hour), now.Add(72*time.Hour))
+	must.Equal(t, nil, err)
+
+	{
+		_, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(24*time.Hour))
+		should.Equal(t, model.ErrOrderDunningNotFound, err)
+	}
+
+	{
+		actual, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(48*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, dun1.ID, actual.ID)
+	}
+
+	// After a reminder, the next one is due later.
+	{
+		must.Equal(t, nil, repo.Remind(ctx, tx, dun1.ID, now.Add(96*time.Hour)))
+
+		actual, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(72*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, ord2.ID, actual.OrderID)
+	}
+
+	// Canceled entries are no longer due.
+	{
+		must.Equal(t, nil, repo.Cancel(ctx, tx, ord2.ID, now.Add(72*time.Hour)))
+
+		_, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(72*time.Hour))
+		should.Equal(t, model.ErrOrderDunningNotFound, err)
+	}
+
+	// The end of the grace period is due even if the next reminder is not.
+	{
+		actual, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(7*24*time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, dun1.ID, actual.ID)
+		should.Equal(t, 1, actual.NumReminders)
+	}
+}
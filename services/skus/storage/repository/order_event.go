@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type OrderEvent struct{}
+
+func NewOrderEvent() *OrderEvent { return &OrderEvent{} }
+
+// Insert stores the event in the outbox.
+//
+// The same event for an order is stored only once.
+func (r *OrderEvent) Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, typ string, when time.Time) error {
+	const q = `INSERT INTO order_events_outbox (order_id, event_type, occurred_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING`
+
+	if _, err := dbi.ExecContext(ctx, q, orderID, typ, when); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertPaid stores a paid event for the payment made at paidAt.
+//
+// A payment is a renewal if the order has been paid before paidAt.
+func (r *OrderEvent) InsertPaid(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, paidAt time.Time) error {
+	const q = `INSERT INTO order_events_outbox (order_id, event_type, occurred_at)
+	SELECT $1, CASE
+		WHEN EXISTS (SELECT 1 FROM order_payment_history WHERE order_id = $1 AND last_paid < $2) THEN 'order.renewed'
+		ELSE 'order.paid'
+	END, $2
+	ON CONFLICT DO NOTHING`
+
+	if _, err := dbi.ExecContext(ctx, q, orderID, paidAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertExpired stores expired events for up to limit paid or past due orders which expired within (from, to].
+func (r *OrderEvent) InsertExpired(ctx context.Context, dbi sqlx.ExecerContext, from, to time.Time, limit int) (int64, error) {
+	const q = `INSERT INTO order_events_outbox (order_id, event_type, occurred_at)
+	SELECT o.id, 'order.expired', o.expires_at
+	FROM orders AS o
+	WHERE o.status IN ('paid', 'past_due') AND o.expires_at > $1 AND o.expires_at <= $2
+		AND NOT EXISTS (
+			SELECT 1 FROM order_events_outbox AS e
+			WHERE e.order_id = o.id AND e.event_type = 'order.expired' AND e.occurred_at = o.expires_at
+		)
+	LIMIT $3
+	ON CONFLICT DO NOTHING`
+
+	result, err := dbi.ExecContext(ctx, q, from, to, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetUnsentForUpdate returns up to limit unsent events in order of creation, and locks them.
+func (r *OrderEvent) GetUnsentForUpdate(ctx context.Context, dbi sqlx.QueryerContext, limit int) ([]model.OrderEvent, error) {
+	const q = `SELECT id, created_at, order_id, event_type, occurred_at, sent_at
+	FROM order_events_outbox
+	WHERE sent_at IS NULL
+	ORDER BY created_at
+	FOR UPDATE SKIP LOCKED
+	LIMIT $1`
+
+	result := make([]model.OrderEvent, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, limit); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *OrderEvent) MarkSent(ctx context.Context, dbi sqlx.ExecerContext, ids []uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_events_outbox SET sent_at = $2 WHERE id = ANY($1::uuid[])`
+
+	sids := make([]string, 0, len(ids))
+	for i := range ids {
+		sids = append(sids, ids[i].String())
+	}
+
+	if _, err := dbi.ExecContext(ctx, q, pq.Array(sids), when); err != nil {
+		return err
+	}
+
+	return nil
+}
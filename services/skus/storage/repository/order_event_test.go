@@ -0,0 +1,147 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestOrderEvent_InsertPaid(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_events_outbox, order_payment_history, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	phRepo := repository.NewOrderPayHistory()
+	repo := repository.NewOrderEvent()
+
+	paidAt1 := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+	paidAt2 := time.Date(2024, time.February, 1, 0, 0, 1, 0, time.UTC)
+
+	for _, paidAt := range []time.Time{paidAt1, paidAt2} {
+		must.Equal(t, nil, phRepo.Insert(ctx, tx, ord.ID, paidAt))
+		must.Equal(t, nil, repo.InsertPaid(ctx, tx, ord.ID, paidAt))
+	}
+
+	// The same payment is recorded only once.
+	must.Equal(t, nil, repo.InsertPaid(ctx, tx, ord.ID, paidAt2))
+
+	actual, err := repo.GetUnsentForUpdate(ctx, tx, 10)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, 2, len(actual))
+
+	should.Equal(t, model.OrderEventPaid, actual[0].Type)
+	should.True(t, paidAt1.Equal(actual[0].OccurredAt))
+
+	should.Equal(t, model.OrderEventRenewed, actual[1].Type)
+	should.True(t, paidAt2.Equal(actual[1].OccurredAt))
+}
+
+func TestOrderEvent_InsertExpired(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_events_outbox, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	orepo := repository.NewOrder()
+
+	now := time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+	// Expired within the window.
+	ord1, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, nil, orepo.SetStatus(ctx, tx, ord1.ID, model.OrderStatusPaid))
+	must.Equal(t, nil, orepo.SetExpiresAt(ctx, tx, ord1.ID, now.Add(-time.Hour)))
+
+	// Expired before the window.
+	ord2, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, nil, orepo.SetStatus(ctx, tx, ord2.ID, model.OrderStatusPaid))
+	must.Equal(t, nil, orepo.SetExpiresAt(ctx, tx, ord2.ID, now.Add(-48*time.Hour)))
+
+	// Not expired yet.
+	ord3, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, nil, orepo.SetStatus(ctx, tx, ord3.ID, model.OrderStatusPaid))
+	must.Equal(t, nil, orepo.SetExpiresAt(ctx, tx, ord3.ID, now.Add(time.Hour)))
+
+	// Past due at the end of the grace period.
+	ord4, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, nil, orepo.SetStatus(ctx, tx, ord4.ID, model.OrderStatusPastDue))
+	must.Equal(t, nil, orepo.SetExpiresAt(ctx, tx, ord4.ID, now.Add(-2*time.Hour)))
+
+	repo := repository.NewOrderEvent()
+
+	{
+		n, err := repo.InsertExpired(ctx, tx, now.Add(-24*time.Hour), now, 10)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(2), n)
+	}
+
+	// An expiry is recorded only once.
+	{
+		n, err := repo.InsertExpired(ctx, tx, now.Add(-24*time.Hour), now, 10)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, int64(0), n)
+	}
+
+	actual, err := repo.GetUnsentForUpdate(ctx, tx, 10)
+	must.Equal(t, nil, err)
+
+	must.Equal(t, 2, len(actual))
+
+	should.ElementsMatch(t, []uuid.UUID{ord1.ID, ord4.ID}, []uuid.UUID{actual[0].OrderID, actual[1].OrderID})
+	should.Equal(t, model.OrderEventExpired, actual[0].Type)
+	should.Equal(t, model.OrderEventExpired, actual[1].Type)
+
+	{
+		err := repo.MarkSent(ctx, tx, []uuid.UUID{actual[0].ID, actual[1].ID}, now)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		actual, err := repo.GetUnsentForUpdate(ctx, tx, 10)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 0, len(actual))
+	}
+}
@@ -21,3 +21,15 @@ func (r *OrderPayHistory) Insert(ctx context.Context, dbi sqlx.ExecerContext, id
 
 	return nil
 }
+
+// List returns the times the order has been paid at, most recent first.
+func (r *OrderPayHistory) List(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]time.Time, error) {
+	const q = `SELECT last_paid FROM order_payment_history WHERE order_id = $1 ORDER BY last_paid DESC`
+
+	var result []time.Time
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, id); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -79,3 +79,46 @@ func (r *OrderItem) InsertMany(ctx context.Context, dbi sqlx.ExtContext, items .
 
 	return result, nil
 }
+
+// UpdatePlan switches the item identified by item.ID to the product described by item.
+//
+// Quantity, currency and credential type are preserved.
+func (r *OrderItem) UpdatePlan(ctx context.Context, dbi sqlx.ExecerContext, item *model.OrderItem) error {
+	const q = `UPDATE order_items
+	SET
+		sku = $2, sku_variant = $3, price = $4, subtotal = $5, location = $6, description = $7,
+		metadata = $8, valid_for = $9, valid_for_iso = $10, each_credential_valid_for_iso = $11, issuance_interval = $12,
+		updated_at = now()
+	WHERE id = $1`
+
+	result, err := dbi.ExecContext(
+		ctx,
+		q,
+		item.ID,
+		item.SKU,
+		item.SKUVnt,
+		item.Price,
+		item.Subtotal,
+		item.Location,
+		item.Description,
+		item.Metadata,
+		item.ValidFor,
+		item.ValidForISO,
+		item.EachCredentialValidForISO,
+		item.IssuanceIntervalISO,
+	)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrOrderItemNotFound
+	}
+
+	return nil
+}
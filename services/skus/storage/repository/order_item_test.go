@@ -155,6 +155,79 @@ func TestOrderItem_InsertMany(t *testing.T) {
 	}
 }
 
+func TestOrderItem_UpdatePlan(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_items, orders;")
+	}()
+
+	ctx := context.TODO()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	orepo := repository.NewOrder()
+	iorepo := repository.NewOrderItem()
+
+	order, err := createOrderForTest(ctx, tx, orepo)
+	must.Equal(t, nil, err)
+
+	items, err := iorepo.InsertMany(ctx, tx, model.OrderItem{
+		OrderID:        order.ID,
+		SKU:            "sku_01",
+		SKUVnt:         "sku_vnt_01",
+		Quantity:       2,
+		Price:          mustDecimalFromString("3"),
+		Currency:       "USD",
+		Subtotal:       mustDecimalFromString("6"),
+		CredentialType: "time-limited-v2",
+	})
+	must.Equal(t, nil, err)
+	must.Equal(t, 1, len(items))
+
+	{
+		err := iorepo.UpdatePlan(ctx, tx, &model.OrderItem{ID: uuid.NewV4(), SKU: "sku_02", SKUVnt: "sku_vnt_02"})
+		should.Equal(t, model.ErrOrderItemNotFound, err)
+	}
+
+	vfISO := "P1M"
+
+	next := &model.OrderItem{
+		ID:          items[0].ID,
+		SKU:         "sku_02",
+		SKUVnt:      "sku_vnt_02",
+		Price:       mustDecimalFromString("5"),
+		Subtotal:    mustDecimalFromString("10"),
+		ValidForISO: &vfISO,
+		Metadata:    datastore.Metadata{"stripe_item_id": "price_02"},
+	}
+
+	{
+		err := iorepo.UpdatePlan(ctx, tx, next)
+		must.Equal(t, nil, err)
+	}
+
+	actual, err := iorepo.Get(ctx, tx, items[0].ID)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, order.ID, actual.OrderID)
+	should.Equal(t, "sku_02", actual.SKU)
+	should.Equal(t, "sku_vnt_02", actual.SKUVnt)
+	should.Equal(t, 2, actual.Quantity)
+	should.Equal(t, "USD", actual.Currency)
+	should.Equal(t, "time-limited-v2", actual.CredentialType)
+	should.Equal(t, "5", actual.Price.String())
+	should.Equal(t, &vfISO, actual.ValidForISO)
+
+	itemID, ok := actual.StripeItemID()
+	should.True(t, ok)
+	should.Equal(t, "price_02", itemID)
+}
+
 func setupDBI() (*sqlx.DB, error) {
 	pg, err := datastore.NewPostgres("", false, "")
 	if err != nil {
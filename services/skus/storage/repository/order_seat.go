@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type OrderSeat struct{}
+
+func NewOrderSeat() *OrderSeat { return &OrderSeat{} }
+
+// Create creates a pending seat for the order, unless the order already has nmax seats which have not been revoked.
+//
+// The count is only reliable if the caller holds a lock on the order, see Order.GetForUpdate.
+func (r *OrderSeat) Create(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID, inviteToken string, nmax int) (*model.OrderSeat, error) {
+	const q = `INSERT INTO order_seats (order_id, invite_token)
+	SELECT $1, $2
+	WHERE (SELECT COUNT(*) FROM order_seats WHERE order_id = $1 AND status != 'revoked') < $3
+	RETURNING id, order_id, created_at, updated_at, invite_token, status, redeemed_at, revoked_at`
+
+	result := &model.OrderSeat{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, orderID, inviteToken, nmax); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrOrderSeatsExhausted
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *OrderSeat) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.OrderSeat, error) {
+	const q = `SELECT id, order_id, created_at, updated_at, invite_token, status, redeemed_at, revoked_at
+	FROM order_seats WHERE id = $1`
+
+	result := &model.OrderSeat{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrOrderSeatNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *OrderSeat) GetByInviteToken(ctx context.Context, dbi sqlx.QueryerContext, inviteToken string) (*model.OrderSeat, error) {
+	const q = `SELECT id, order_id, created_at, updated_at, invite_token, status, redeemed_at, revoked_at
+	FROM order_seats WHERE invite_token = $1`
+
+	result := &model.OrderSeat{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, inviteToken); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrOrderSeatNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *OrderSeat) List(ctx context.Context, dbi sqlx.QueryerContext, orderID uuid.UUID) ([]model.OrderSeat, error) {
+	const q = `SELECT id, order_id, created_at, updated_at, invite_token, status, redeemed_at, revoked_at
+	FROM order_seats WHERE order_id = $1
+	ORDER BY created_at`
+
+	result := make([]model.OrderSeat, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, orderID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Redeem activates the pending seat.
+func (r *OrderSeat) Redeem(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_seats
+	SET status = 'active', redeemed_at = $2, updated_at = now()
+	WHERE id = $1 AND status = 'pending'`
+
+	return r.execUpdate(ctx, dbi, q, id, when)
+}
+
+// Revoke revokes the seat unless it has already been revoked.
+func (r *OrderSeat) Revoke(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE order_seats
+	SET status = 'revoked', revoked_at = $2, updated_at = now()
+	WHERE id = $1 AND status != 'revoked'`
+
+	return r.execUpdate(ctx, dbi, q, id, when)
+}
+
+func (r *OrderSeat) execUpdate(ctx context.Context, dbi sqlx.ExecerContext, q string, args ...interface{}) error {
+	result, err := dbi.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrOrderSeatNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestOrderSeat_Create(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_seats, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewOrderSeat()
+
+	seat1, err := repo.Create(ctx, tx, ord.ID, "invite_token_01", 2)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, ord.ID, seat1.OrderID)
+	should.Equal(t, "invite_token_01", seat1.InviteToken)
+	should.Equal(t, model.OrderSeatStatusPending, seat1.Status)
+
+	{
+		_, err := repo.Create(ctx, tx, ord.ID, "invite_token_02", 2)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		_, err := repo.Create(ctx, tx, ord.ID, "invite_token_03", 2)
+		should.Equal(t, model.ErrOrderSeatsExhausted, err)
+	}
+
+	// A revoked seat frees up a slot.
+	{
+		err := repo.Revoke(ctx, tx, seat1.ID, time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC))
+		must.Equal(t, nil, err)
+	}
+
+	{
+		_, err := repo.Create(ctx, tx, ord.ID, "invite_token_03", 2)
+		must.Equal(t, nil, err)
+	}
+
+	actual, err := repo.List(ctx, tx, ord.ID)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, 3, len(actual))
+}
+
+func TestOrderSeat_RedeemRevoke(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE order_seats, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewOrderSeat()
+
+	seat, err := repo.Create(ctx, tx, ord.ID, "invite_token_01", 1)
+	must.Equal(t, nil, err)
+
+	{
+		_, err := repo.GetByInviteToken(ctx, tx, "invite_token_02")
+		should.Equal(t, model.ErrOrderSeatNotFound, err)
+	}
+
+	now := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+	{
+		err := repo.Redeem(ctx, tx, seat.ID, now)
+		must.Equal(t, nil, err)
+	}
+
+	// An invite token can only be redeemed once.
+	{
+		err := repo.Redeem(ctx, tx, seat.ID, now)
+		should.Equal(t, model.ErrOrderSeatNotFound, err)
+	}
+
+	{
+		actual, err := repo.GetByInviteToken(ctx, tx, "invite_token_01")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.OrderSeatStatusActive, actual.Status)
+
+		must.NotNil(t, actual.RedeemedAt)
+		should.True(t, now.Equal(*actual.RedeemedAt))
+	}
+
+	{
+		err := repo.Revoke(ctx, tx, seat.ID, now)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		err := repo.Revoke(ctx, tx, seat.ID, now)
+		should.Equal(t, model.ErrOrderSeatNotFound, err)
+	}
+
+	{
+		actual, err := repo.Get(ctx, tx, seat.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.OrderSeatStatusRevoked, actual.Status)
+		should.NotNil(t, actual.RevokedAt)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type Portal struct{}
+
+func NewPortal() *Portal { return &Portal{} }
+
+func (r *Portal) InsertLoginCode(ctx context.Context, dbi sqlx.ExecerContext, email, codeHash string, expiresAt time.Time) error {
+	const q = `INSERT INTO portal_login_codes (email, code_hash, expires_at) VALUES ($1, $2, $3)`
+
+	if _, err := dbi.ExecContext(ctx, q, email, codeHash, expiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLatestLoginCodeForUpdate returns the most recent login code sent to email.
+func (r *Portal) GetLatestLoginCodeForUpdate(ctx context.Context, dbi sqlx.QueryerContext, email string) (*model.PortalLoginCode, error) {
+	const q = `SELECT id, created_at, email, code_hash, expires_at, num_attempts, used_at
+	FROM portal_login_codes
+	WHERE email = $1
+	ORDER BY created_at DESC
+	LIMIT 1
+	FOR UPDATE`
+
+	result := &model.PortalLoginCode{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrPortalLoginCodeInvalid
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetLoginStats counts login codes sent to email since, and failed attempts to use them.
+func (r *Portal) GetLoginStats(ctx context.Context, dbi sqlx.QueryerContext, email string, since time.Time) (model.PortalLoginStats, error) {
+	const q = `SELECT COUNT(1) AS num_codes, COALESCE(SUM(num_attempts), 0) AS num_failed_attempts
+	FROM portal_login_codes
+	WHERE email = $1 AND created_at > $2`
+
+	var result model.PortalLoginStats
+	if err := sqlx.GetContext(ctx, dbi, &result, q, email, since); err != nil {
+		return model.PortalLoginStats{}, err
+	}
+
+	return result, nil
+}
+
+func (r *Portal) IncrLoginCodeAttempts(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `UPDATE portal_login_codes SET num_attempts = num_attempts + 1 WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, model.ErrPortalLoginCodeInvalid, q, id)
+}
+
+func (r *Portal) MarkLoginCodeUsed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE portal_login_codes SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+
+	return r.execUpdate(ctx, dbi, model.ErrPortalLoginCodeInvalid, q, id, when)
+}
+
+func (r *Portal) InsertSession(ctx context.Context, dbi sqlx.QueryerContext, email, tokenHash string, expiresAt time.Time) (*model.PortalSession, error) {
+	const q = `INSERT INTO portal_sessions (email, token_hash, expires_at) VALUES ($1, $2, $3)
+	RETURNING id, created_at, email, expires_at`
+
+	result := &model.PortalSession{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, email, tokenHash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetSession returns the session for the token which has not expired by now.
+func (r *Portal) GetSession(ctx context.Context, dbi sqlx.QueryerContext, tokenHash string, now time.Time) (*model.PortalSession, error) {
+	const q = `SELECT id, created_at, email, expires_at FROM portal_sessions WHERE token_hash = $1 AND expires_at > $2`
+
+	result := &model.PortalSession{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, tokenHash, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrPortalSessionNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *Portal) DeleteSession(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `DELETE FROM portal_sessions WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, model.ErrPortalSessionNotFound, q, id)
+}
+
+func (r *Portal) execUpdate(ctx context.Context, dbi sqlx.ExecerContext, errNotFound error, q string, args ...interface{}) error {
+	result, err := dbi.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
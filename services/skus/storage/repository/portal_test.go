@@ -0,0 +1,136 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestPortal_LoginCode(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE portal_login_codes;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewPortal()
+
+	{
+		_, err := repo.GetLatestLoginCodeForUpdate(ctx, tx, "you@example.com")
+		should.Equal(t, model.ErrPortalLoginCodeInvalid, err)
+	}
+
+	expiresAt := time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC)
+
+	must.Equal(t, nil, repo.InsertLoginCode(ctx, tx, "you@example.com", "code_hash", expiresAt))
+
+	lcode, err := repo.GetLatestLoginCodeForUpdate(ctx, tx, "you@example.com")
+	must.Equal(t, nil, err)
+
+	should.Equal(t, "you@example.com", lcode.Email)
+	should.Equal(t, "code_hash", lcode.CodeHash)
+	should.True(t, expiresAt.Equal(lcode.ExpiresAt))
+	should.Equal(t, 0, lcode.NumAttempts)
+	should.Nil(t, lcode.UsedAt)
+
+	must.Equal(t, nil, repo.IncrLoginCodeAttempts(ctx, tx, lcode.ID))
+
+	usedAt := time.Date(2024, time.January, 1, 0, 5, 0, 0, time.UTC)
+	must.Equal(t, nil, repo.MarkLoginCodeUsed(ctx, tx, lcode.ID, usedAt))
+
+	// A code can only be used once.
+	should.Equal(t, model.ErrPortalLoginCodeInvalid, repo.MarkLoginCodeUsed(ctx, tx, lcode.ID, usedAt))
+
+	actual, err := repo.GetLatestLoginCodeForUpdate(ctx, tx, "you@example.com")
+	must.Equal(t, nil, err)
+
+	should.Equal(t, 1, actual.NumAttempts)
+	must.NotNil(t, actual.UsedAt)
+	should.True(t, usedAt.Equal(*actual.UsedAt))
+
+	should.Equal(t, model.ErrPortalLoginCodeInvalid, repo.IncrLoginCodeAttempts(ctx, tx, uuid.NewV4()))
+
+	{
+		actual, err := repo.GetLoginStats(ctx, tx, "you@example.com", time.Now().Add(-time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.PortalLoginStats{NumCodes: 1, NumFailedAttempts: 1}, actual)
+	}
+
+	{
+		actual, err := repo.GetLoginStats(ctx, tx, "you@example.com", time.Now().Add(time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.PortalLoginStats{}, actual)
+	}
+}
+
+func TestPortal_Session(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE portal_sessions;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewPortal()
+
+	expiresAt := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	sess, err := repo.InsertSession(ctx, tx, "you@example.com", "token_hash", expiresAt)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, "you@example.com", sess.Email)
+	should.True(t, expiresAt.Equal(sess.ExpiresAt))
+
+	{
+		actual, err := repo.GetSession(ctx, tx, "token_hash", expiresAt.Add(-time.Hour))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, sess.ID, actual.ID)
+	}
+
+	{
+		_, err := repo.GetSession(ctx, tx, "token_hash", expiresAt)
+		should.Equal(t, model.ErrPortalSessionNotFound, err)
+	}
+
+	{
+		_, err := repo.GetSession(ctx, tx, "other_hash", expiresAt.Add(-time.Hour))
+		should.Equal(t, model.ErrPortalSessionNotFound, err)
+	}
+
+	must.Equal(t, nil, repo.DeleteSession(ctx, tx, sess.ID))
+
+	should.Equal(t, model.ErrPortalSessionNotFound, repo.DeleteSession(ctx, tx, sess.ID))
+
+	{
+		_, err := repo.GetSession(ctx, tx, "token_hash", expiresAt.Add(-time.Hour))
+		should.Equal(t, model.ErrPortalSessionNotFound, err)
+	}
+}
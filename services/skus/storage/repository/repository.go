@@ -9,6 +9,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 
 	"github.com/brave-intl/bat-go/libs/datastore"
 
@@ -39,6 +40,27 @@ func (r *Order) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID)
 	return result, nil
 }
 
+// GetForUpdate retrieves the order for the given id, and locks it until the end of the transaction.
+func (r *Order) GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.Order, error) {
+	const q = `SELECT
+		id, created_at, currency, updated_at, total_price,
+		merchant_id, location, status, allowed_payment_methods,
+		metadata, valid_for, last_paid_at, expires_at, trial_days
+	FROM orders WHERE id = $1
+	FOR UPDATE`
+
+	result := &model.Order{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrOrderNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // GetByExternalID retrieves the order by extID in metadata.externalID.
 func (r *Order) GetByExternalID(ctx context.Context, dbi sqlx.QueryerContext, extID string) (*model.Order, error) {
 	const q = `SELECT
@@ -150,6 +172,13 @@ func (r *Order) SetExpiresAt(ctx context.Context, dbi sqlx.ExecerContext, id uui
 	return r.execUpdate(ctx, dbi, q, id, when)
 }
 
+// SetTotalPrice sets total_price.
+func (r *Order) SetTotalPrice(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, price decimal.Decimal) error {
+	const q = `UPDATE orders SET updated_at = CURRENT_TIMESTAMP, total_price = $2 WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, price)
+}
+
 // UpdateMetadata _sets_ metadata to data.
 func (r *Order) UpdateMetadata(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, data datastore.Metadata) error {
 	const q = `UPDATE orders SET metadata = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
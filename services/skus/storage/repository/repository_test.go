@@ -1246,3 +1246,36 @@ func nowPlusIntervalPg(ctx context.Context, dbi sqlx.QueryerContext, v string) (
 
 	return result, nil
 }
+
+func TestOrder_GetForUpdate(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE orders;")
+	}()
+
+	ctx := context.Background()
+
+	const q = `INSERT INTO orders (id, merchant_id, status, currency, total_price, created_at, updated_at)
+	VALUES ('facade00-0000-4000-a000-000000000000', 'brave.com', 'paid', 'USD', 9.99, '2024-01-01 00:00:01', '2024-01-01 00:00:01');`
+
+	_, err = dbi.ExecContext(ctx, q)
+	must.Equal(t, nil, err)
+
+	tx, err := dbi.BeginTxx(ctx, nil)
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewOrder()
+
+	actual, err := repo.GetForUpdate(ctx, tx, uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"))
+	must.Equal(t, nil, err)
+
+	should.Equal(t, uuid.FromStringOrNil("facade00-0000-4000-a000-000000000000"), actual.ID)
+	should.Equal(t, model.OrderStatusPaid, actual.Status)
+
+	_, err = repo.GetForUpdate(ctx, tx, uuid.FromStringOrNil("decade00-0000-4000-a000-000000000000"))
+	should.ErrorIs(t, err, model.ErrOrderNotFound)
+}
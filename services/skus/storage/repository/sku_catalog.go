@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const skuCatalogColumns = `id, created_at, updated_at, sku, sku_variant, location, description, period, price, currency,
+	credential_type, credential_valid_duration, each_credential_valid_duration, issuance_interval, issuer_token_buffer, issuer_token_overlap,
+	allowed_payment_methods, stripe_product_id, stripe_price_id, store_product_ids`
+
+type SKUCatalog struct{}
+
+func NewSKUCatalog() *SKUCatalog { return &SKUCatalog{} }
+
+func (r *SKUCatalog) Create(ctx context.Context, dbi sqlx.QueryerContext, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	const q = `INSERT INTO sku_catalog (
+		sku, sku_variant, location, description, period, price, currency,
+		credential_type, credential_valid_duration, each_credential_valid_duration, issuance_interval, issuer_token_buffer, issuer_token_overlap,
+		allowed_payment_methods, stripe_product_id, stripe_price_id, store_product_ids
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'USD'), $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), NULLIF($16, ''), $17)
+	RETURNING ` + skuCatalogColumns
+
+	return r.getOne(ctx, dbi, q, skuCatalogArgs(req)...)
+}
+
+func (r *SKUCatalog) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.SKUCatalogEntry, error) {
+	const q = `SELECT ` + skuCatalogColumns + ` FROM sku_catalog WHERE id = $1`
+
+	return r.getOne(ctx, dbi, q, id)
+}
+
+func (r *SKUCatalog) GetBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt string) (*model.SKUCatalogEntry, error) {
+	const q = `SELECT ` + skuCatalogColumns + ` FROM sku_catalog WHERE sku_variant = $1`
+
+	return r.getOne(ctx, dbi, q, skuVnt)
+}
+
+// GetByStoreProductID returns the entry sold under the App Store or Play Store product id.
+func (r *SKUCatalog) GetByStoreProductID(ctx context.Context, dbi sqlx.QueryerContext, productID string) (*model.SKUCatalogEntry, error) {
+	const q = `SELECT ` + skuCatalogColumns + ` FROM sku_catalog WHERE store_product_ids @> ARRAY[$1::text] LIMIT 1`
+
+	return r.getOne(ctx, dbi, q, productID)
+}
+
+func (r *SKUCatalog) GetByStripePriceID(ctx context.Context, dbi sqlx.QueryerContext, priceID string) (*model.SKUCatalogEntry, error) {
+	const q = `SELECT ` + skuCatalogColumns + ` FROM sku_catalog WHERE stripe_price_id = $1`
+
+	return r.getOne(ctx, dbi, q, priceID)
+}
+
+func (r *SKUCatalog) List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.SKUCatalogEntry, error) {
+	const q = `SELECT ` + skuCatalogColumns + ` FROM sku_catalog ORDER BY sku, sku_variant`
+
+	var result []model.SKUCatalogEntry
+	if err := sqlx.SelectContext(ctx, dbi, &result, q); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *SKUCatalog) Update(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, req *model.SKUCatalogEntryRequest) (*model.SKUCatalogEntry, error) {
+	const q = `UPDATE sku_catalog SET
+		sku = $1, sku_variant = $2, location = $3, description = $4, period = $5, price = $6, currency = COALESCE(NULLIF($7, ''), 'USD'),
+		credential_type = $8, credential_valid_duration = $9, each_credential_valid_duration = $10, issuance_interval = $11,
+		issuer_token_buffer = $12, issuer_token_overlap = $13, allowed_payment_methods = $14,
+		stripe_product_id = NULLIF($15, ''), stripe_price_id = NULLIF($16, ''), store_product_ids = $17, updated_at = now()
+	WHERE id = $18
+	RETURNING ` + skuCatalogColumns
+
+	return r.getOne(ctx, dbi, q, append(skuCatalogArgs(req), id)...)
+}
+
+func (r *SKUCatalog) Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `DELETE FROM sku_catalog WHERE id = $1`
+
+	result, err := dbi.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrSKUCatalogEntryNotFound
+	}
+
+	return nil
+}
+
+func (r *SKUCatalog) getOne(ctx context.Context, dbi sqlx.QueryerContext, q string, args ...interface{}) (*model.SKUCatalogEntry, error) {
+	result := &model.SKUCatalogEntry{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrSKUCatalogEntryNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func skuCatalogArgs(req *model.SKUCatalogEntryRequest) []interface{} {
+	return []interface{}{
+		req.SKU,
+		req.SKUVnt,
+		req.Location,
+		req.Description,
+		req.Period,
+		req.Price,
+		req.Currency,
+		req.CredentialType,
+		req.CredentialValidDuration,
+		req.CredentialValidDurationEach,
+		req.IssuanceInterval,
+		req.IssuerTokenBuffer,
+		req.IssuerTokenOverlap,
+		pq.StringArray(nonNilStrings(req.AllowedPaymentMethods)),
+		req.StripeProductID,
+		req.StripePriceID,
+		pq.StringArray(nonNilStrings(req.StoreProductIDs)),
+	}
+}
+
+func nonNilStrings(vals []string) []string {
+	if vals == nil {
+		return []string{}
+	}
+
+	return vals
+}
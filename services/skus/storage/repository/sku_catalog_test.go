@@ -0,0 +1,159 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestSKUCatalog_CreateGet(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE sku_catalog;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewSKUCatalog()
+
+	{
+		_, err := repo.GetBySKUVnt(ctx, tx, "brave-search-premium")
+		should.Equal(t, model.ErrSKUCatalogEntryNotFound, err)
+	}
+
+	req := &model.SKUCatalogEntryRequest{
+		SKU:                     "brave-search-premium",
+		SKUVnt:                  "brave-search-premium",
+		Location:                "search.brave.com",
+		Description:             "Premium access to Search",
+		Period:                  "P1M",
+		Price:                   decimal.RequireFromString("3"),
+		CredentialType:          "time-limited",
+		CredentialValidDuration: "P1M",
+		AllowedPaymentMethods:   []string{"stripe"},
+		StripeProductID:         "prod_search",
+		StripePriceID:           "price_search",
+		StoreProductIDs:         []string{"brave.search.monthly", "bravesearch.monthly"},
+	}
+
+	created, err := repo.Create(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, "USD", created.Currency)
+	should.Equal(t, []string{"stripe"}, []string(created.AllowedPaymentMethods))
+
+	{
+		actual, err := repo.Get(ctx, tx, created.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, "brave-search-premium", actual.SKUVnt)
+		should.True(t, req.Price.Equal(actual.Price))
+	}
+
+	{
+		actual, err := repo.GetBySKUVnt(ctx, tx, "brave-search-premium")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, created.ID, actual.ID)
+	}
+
+	{
+		actual, err := repo.GetByStoreProductID(ctx, tx, "bravesearch.monthly")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, created.ID, actual.ID)
+	}
+
+	{
+		_, err := repo.GetByStoreProductID(ctx, tx, "bravevpn.monthly")
+		should.Equal(t, model.ErrSKUCatalogEntryNotFound, err)
+	}
+
+	{
+		actual, err := repo.GetByStripePriceID(ctx, tx, "price_search")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, created.ID, actual.ID)
+	}
+}
+
+func TestSKUCatalog_ListUpdateDelete(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE sku_catalog;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewSKUCatalog()
+
+	req := &model.SKUCatalogEntryRequest{
+		SKU:            "brave-search-premium",
+		SKUVnt:         "brave-search-premium-year",
+		Location:       "search.brave.com",
+		Price:          decimal.RequireFromString("30"),
+		CredentialType: "time-limited",
+	}
+
+	created, err := repo.Create(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	{
+		actual, err := repo.List(ctx, tx)
+		must.Equal(t, nil, err)
+
+		should.Len(t, actual, 1)
+	}
+
+	req.Price = decimal.RequireFromString("25")
+	req.StoreProductIDs = []string{"brave.search.yearly"}
+
+	{
+		actual, err := repo.Update(ctx, tx, created.ID, req)
+		must.Equal(t, nil, err)
+
+		should.True(t, req.Price.Equal(actual.Price))
+		should.Equal(t, []string{"brave.search.yearly"}, []string(actual.StoreProductIDs))
+	}
+
+	{
+		_, err := repo.Update(ctx, tx, uuid.NewV4(), req)
+		should.Equal(t, model.ErrSKUCatalogEntryNotFound, err)
+	}
+
+	must.Equal(t, nil, repo.Delete(ctx, tx, created.ID))
+
+	{
+		_, err := repo.Get(ctx, tx, created.ID)
+		should.Equal(t, model.ErrSKUCatalogEntryNotFound, err)
+	}
+
+	{
+		err := repo.Delete(ctx, tx, created.ID)
+		should.Equal(t, model.ErrSKUCatalogEntryNotFound, err)
+	}
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type SKUPrice struct{}
+
+func NewSKUPrice() *SKUPrice { return &SKUPrice{} }
+
+// Upsert sets the price of the catalog entry for the currency and country.
+func (r *SKUPrice) Upsert(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID, req *model.SKUPriceRequest) (*model.SKUPrice, error) {
+	const q = `INSERT INTO sku_prices (sku_catalog_id, currency, country, amount, stripe_price_id)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	ON CONFLICT (sku_catalog_id, currency, country) DO UPDATE SET amount = EXCLUDED.amount, stripe_price_id = EXCLUDED.stripe_price_id, updated_at = now()
+	RETURNING id, created_at, updated_at, sku_catalog_id, currency, country, amount, stripe_price_id`
+
+	result := &model.SKUPrice{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, catalogID, req.Currency, req.Country, req.Amount, req.StripePriceID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *SKUPrice) ListByCatalogID(ctx context.Context, dbi sqlx.QueryerContext, catalogID uuid.UUID) ([]model.SKUPrice, error) {
+	const q = `SELECT id, created_at, updated_at, sku_catalog_id, currency, country, amount, stripe_price_id
+	FROM sku_prices WHERE sku_catalog_id = $1 ORDER BY currency, country`
+
+	var result []model.SKUPrice
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, catalogID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// FindBySKUVnt returns the price of the variant in the currency.
+//
+// The price for the country takes precedence over the one for all countries.
+func (r *SKUPrice) FindBySKUVnt(ctx context.Context, dbi sqlx.QueryerContext, skuVnt, currency, country string) (*model.SKUPrice, error) {
+	const q = `SELECT p.id, p.created_at, p.updated_at, p.sku_catalog_id, p.currency, p.country, p.amount, p.stripe_price_id
+	FROM sku_prices AS p
+	JOIN sku_catalog AS c ON c.id = p.sku_catalog_id
+	WHERE c.sku_variant = $1 AND p.currency = $2 AND p.country IN ($3, '')
+	ORDER BY p.country DESC
+	LIMIT 1`
+
+	result := &model.SKUPrice{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, skuVnt, currency, country); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrSKUPriceNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *SKUPrice) Delete(ctx context.Context, dbi sqlx.ExecerContext, catalogID, id uuid.UUID) error {
+	const q = `DELETE FROM sku_prices WHERE sku_catalog_id = $1 AND id = $2`
+
+	result, err := dbi.ExecContext(ctx, q, catalogID, id)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrSKUPriceNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestSKUPrice_UpsertFindBySKUVnt(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE sku_prices, sku_catalog;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	entry, err := repository.NewSKUCatalog().Create(ctx, tx, &model.SKUCatalogEntryRequest{
+		SKU:            "brave-leo-premium",
+		SKUVnt:         "brave-leo-premium",
+		Location:       "leo.brave.com",
+		Price:          decimal.RequireFromString("15"),
+		CredentialType: "time-limited",
+	})
+	must.Equal(t, nil, err)
+
+	repo := repository.NewSKUPrice()
+
+	{
+		_, err := repo.FindBySKUVnt(ctx, tx, "brave-leo-premium", "EUR", "DE")
+		should.Equal(t, model.ErrSKUPriceNotFound, err)
+	}
+
+	eur, err := repo.Upsert(ctx, tx, entry.ID, &model.SKUPriceRequest{Currency: "EUR", Amount: decimal.RequireFromString("14.99")})
+	must.Equal(t, nil, err)
+
+	should.Nil(t, eur.StripePriceID)
+
+	{
+		actual, err := repo.FindBySKUVnt(ctx, tx, "brave-leo-premium", "EUR", "DE")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, eur.ID, actual.ID)
+	}
+
+	// The regional price takes precedence.
+	de, err := repo.Upsert(ctx, tx, entry.ID, &model.SKUPriceRequest{Currency: "EUR", Country: "DE", Amount: decimal.RequireFromString("12.99"), StripePriceID: "price_eur_de"})
+	must.Equal(t, nil, err)
+
+	{
+		actual, err := repo.FindBySKUVnt(ctx, tx, "brave-leo-premium", "EUR", "DE")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, de.ID, actual.ID)
+		should.Equal(t, "price_eur_de", *actual.StripePriceID)
+	}
+
+	{
+		actual, err := repo.FindBySKUVnt(ctx, tx, "brave-leo-premium", "EUR", "FR")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, eur.ID, actual.ID)
+	}
+
+	// Setting the price again updates it.
+	{
+		actual, err := repo.Upsert(ctx, tx, entry.ID, &model.SKUPriceRequest{Currency: "EUR", Amount: decimal.RequireFromString("13.99")})
+		must.Equal(t, nil, err)
+
+		should.Equal(t, eur.ID, actual.ID)
+		should.True(t, decimal.RequireFromString("13.99").Equal(actual.Amount))
+	}
+
+	{
+		actual, err := repo.ListByCatalogID(ctx, tx, entry.ID)
+		must.Equal(t, nil, err)
+
+		should.Len(t, actual, 2)
+	}
+
+	must.Equal(t, nil, repo.Delete(ctx, tx, entry.ID, de.ID))
+
+	{
+		err := repo.Delete(ctx, tx, entry.ID, uuid.NewV4())
+		should.Equal(t, model.ErrSKUPriceNotFound, err)
+	}
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type Spend struct{}
+
+func NewSpend() *Spend { return &Spend{} }
+
+// CreateChannel stores the channel, unless the merchant has an enabled channel with the same name.
+func (r *Spend) CreateChannel(ctx context.Context, dbi sqlx.QueryerContext, ch model.SpendChannel) (*model.SpendChannel, error) {
+	const q = `INSERT INTO spend_channels (merchant_id, name, topic, payload_schema, allowed_skus)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT DO NOTHING
+	RETURNING id, created_at, merchant_id, name, topic, payload_schema, allowed_skus, disabled_at`
+
+	result := &model.SpendChannel{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, ch.MerchantID, ch.Name, ch.Topic, string(ch.PayloadSchema), pq.Array(ch.AllowedSKUs)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrSpendChannelExists
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetChannel returns the enabled channel of the merchant.
+func (r *Spend) GetChannel(ctx context.Context, dbi sqlx.QueryerContext, merchID, name string) (*model.SpendChannel, error) {
+	const q = `SELECT id, created_at, merchant_id, name, topic, payload_schema, allowed_skus, disabled_at
+	FROM spend_channels
+	WHERE merchant_id = $1 AND name = $2 AND disabled_at IS NULL`
+
+	result := &model.SpendChannel{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, merchID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrSpendChannelNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListChannels returns enabled channels of the merchant.
+func (r *Spend) ListChannels(ctx context.Context, dbi sqlx.QueryerContext, merchID string) ([]model.SpendChannel, error) {
+	const q = `SELECT id, created_at, merchant_id, name, topic, payload_schema, allowed_skus, disabled_at
+	FROM spend_channels
+	WHERE merchant_id = $1 AND disabled_at IS NULL
+	ORDER BY name`
+
+	result := make([]model.SpendChannel, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, merchID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DisableChannel disables the enabled channel of the merchant.
+//
+// Spends already queued are still processed.
+func (r *Spend) DisableChannel(ctx context.Context, dbi sqlx.ExecerContext, merchID, name string, when time.Time) error {
+	const q = `UPDATE spend_channels SET disabled_at = $3 WHERE merchant_id = $1 AND name = $2 AND disabled_at IS NULL`
+
+	result, err := dbi.ExecContext(ctx, q, merchID, name, when)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return model.ErrSpendChannelNotFound
+	}
+
+	return nil
+}
+
+func (r *Spend) InsertRecord(ctx context.Context, dbi sqlx.ExecerContext, rec model.SpendRecord) error {
+	const q = `INSERT INTO spend_drain (channel_id, credentials, payload, spend_event) VALUES ($1, $2, $3, $4)`
+
+	_, err := dbi.ExecContext(ctx, q, rec.ChannelID, rec.Credentials, rec.Payload, rec.Event)
+
+	return err
+}
+
+// GetNextUncommittedForUpdate returns the oldest spend which has been neither processed nor erred, and is due at now, and locks it.
+func (r *Spend) GetNextUncommittedForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.SpendRecord, error) {
+	const q = `SELECT d.id, d.created_at, d.channel_id, c.topic, d.credentials, d.payload, d.spend_event,
+		d.redeemed, d.erred, d.errcode, d.processed, d.num_attempts
+	FROM spend_drain AS d
+	JOIN spend_channels AS c ON c.id = d.channel_id
+	WHERE d.processed = false AND d.erred = false AND d.next_attempt_at <= $1
+	ORDER BY d.created_at
+	FOR UPDATE OF d SKIP LOCKED
+	LIMIT 1`
+
+	result := &model.SpendRecord{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrSpendRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MarkAttemptFailed records a failed attempt to drain the spend, and defers the next one until next.
+func (r *Spend) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+	const q = `UPDATE spend_drain SET num_attempts = num_attempts + 1, next_attempt_at = $3, last_error = $2 WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id, msg, next)
+
+	return err
+}
+
+func (r *Spend) MarkRedeemed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `UPDATE spend_drain SET redeemed = true WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id)
+
+	return err
+}
+
+func (r *Spend) MarkErrored(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, code string) error {
+	const q = `UPDATE spend_drain SET erred = true, errcode = $2 WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id, code)
+
+	return err
+}
+
+func (r *Spend) MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `UPDATE spend_drain SET processed = true WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id)
+
+	return err
+}
@@ -0,0 +1,169 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestSpend_Channels(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE spend_drain, spend_channels;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewSpend()
+
+	ch := model.SpendChannel{
+		MerchantID:    "brave.com",
+		Name:          "search-ads",
+		Topic:         "test.payment.spend.brave-com.search-ads",
+		PayloadSchema: []byte(`{"type":"record","name":"ad","fields":[{"name":"id","type":"string"}]}`),
+		AllowedSKUs:   []string{"search-ads-credit"},
+	}
+
+	created, err := repo.CreateChannel(ctx, tx, ch)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, ch.Topic, created.Topic)
+	should.JSONEq(t, string(ch.PayloadSchema), string(created.PayloadSchema))
+	should.Equal(t, ch.AllowedSKUs, created.AllowedSKUs)
+
+	{
+		_, err := repo.CreateChannel(ctx, tx, ch)
+		should.Equal(t, model.ErrSpendChannelExists, err)
+	}
+
+	{
+		actual, err := repo.GetChannel(ctx, tx, "brave.com", "search-ads")
+		must.Equal(t, nil, err)
+		should.Equal(t, created.ID, actual.ID)
+	}
+
+	{
+		_, err := repo.GetChannel(ctx, tx, "other.com", "search-ads")
+		should.Equal(t, model.ErrSpendChannelNotFound, err)
+	}
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	should.Equal(t, model.ErrSpendChannelNotFound, repo.DisableChannel(ctx, tx, "other.com", "search-ads", now))
+	must.Equal(t, nil, repo.DisableChannel(ctx, tx, "brave.com", "search-ads", now))
+
+	{
+		actual, err := repo.ListChannels(ctx, tx, "brave.com")
+		must.Equal(t, nil, err)
+		should.Len(t, actual, 0)
+	}
+
+	// The name can be reused once the channel has been disabled.
+	{
+		_, err := repo.CreateChannel(ctx, tx, ch)
+		must.Equal(t, nil, err)
+
+		actual, err := repo.ListChannels(ctx, tx, "brave.com")
+		must.Equal(t, nil, err)
+		should.Len(t, actual, 1)
+	}
+}
+
+func TestSpend_Drain(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE spend_drain, spend_channels;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewSpend()
+
+	ch, err := repo.CreateChannel(ctx, tx, model.SpendChannel{
+		MerchantID:    "brave.com",
+		Name:          "search-ads",
+		Topic:         "test.payment.spend.brave-com.search-ads",
+		PayloadSchema: []byte(`{"type":"record","name":"ad","fields":[{"name":"id","type":"string"}]}`),
+		AllowedSKUs:   []string{"search-ads-credit"},
+	})
+	must.Equal(t, nil, err)
+
+	for _, payload := range []string{"payload_01", "payload_02"} {
+		rec := model.SpendRecord{
+			ChannelID:   ch.ID,
+			Credentials: `[]`,
+			Payload:     payload,
+			Event:       []byte("event"),
+		}
+
+		must.Equal(t, nil, repo.InsertRecord(ctx, tx, rec))
+	}
+
+	// Spends are due from when they are queued.
+	now := time.Now()
+
+	first, err := repo.GetNextUncommittedForUpdate(ctx, tx, now)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, ch.Topic, first.Topic)
+	should.Equal(t, []byte("event"), first.Event)
+	should.False(t, first.Redeemed)
+
+	must.Equal(t, nil, repo.MarkRedeemed(ctx, tx, first.ID))
+
+	{
+		actual, err := repo.GetNextUncommittedForUpdate(ctx, tx, now)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, first.ID, actual.ID)
+		should.True(t, actual.Redeemed)
+	}
+
+	// A failed spend does not hold up the next one until it is due again.
+	must.Equal(t, nil, repo.MarkAttemptFailed(ctx, tx, first.ID, "broker unavailable", now.Add(time.Minute)))
+
+	second, err := repo.GetNextUncommittedForUpdate(ctx, tx, now)
+	must.Equal(t, nil, err)
+
+	should.NotEqual(t, first.ID, second.ID)
+
+	{
+		actual, err := repo.GetNextUncommittedForUpdate(ctx, tx, now.Add(time.Minute))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, first.ID, actual.ID)
+		should.Equal(t, 1, actual.NumAttempts)
+	}
+
+	must.Equal(t, nil, repo.MarkProcessed(ctx, tx, first.ID))
+
+	must.Equal(t, nil, repo.MarkErrored(ctx, tx, second.ID, "cbr_dup_redeem"))
+
+	{
+		_, err := repo.GetNextUncommittedForUpdate(ctx, tx, now.Add(time.Hour))
+		should.Equal(t, model.ErrSpendRecordNotFound, err)
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type StripeSubCancel struct{}
+
+func NewStripeSubCancel() *StripeSubCancel { return &StripeSubCancel{} }
+
+// Insert records a pending cancellation of the subscription.
+//
+// It's not an error if the cancellation has already been recorded.
+func (r *StripeSubCancel) Insert(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, subID string) error {
+	const q = `INSERT INTO stripe_sub_cancellations (order_id, sub_id) VALUES ($1, $2) ON CONFLICT (sub_id) DO NOTHING`
+
+	if _, err := dbi.ExecContext(ctx, q, orderID, subID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetNextDueForUpdate returns the pending cancellation which is due at now, and locks it.
+//
+// Cancellations locked by other workers are skipped.
+func (r *StripeSubCancel) GetNextDueForUpdate(ctx context.Context, dbi sqlx.QueryerContext, now time.Time) (*model.StripeSubCancel, error) {
+	const q = `SELECT id, created_at, order_id, sub_id, num_attempts, canceled_at
+	FROM stripe_sub_cancellations
+	WHERE canceled_at IS NULL AND next_attempt_at <= $1
+	ORDER BY next_attempt_at
+	FOR UPDATE SKIP LOCKED
+	LIMIT 1`
+
+	result := &model.StripeSubCancel{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrStripeSubCancelNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MarkCanceled records that Stripe has canceled the subscription.
+func (r *StripeSubCancel) MarkCanceled(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE stripe_sub_cancellations SET canceled_at = $2 WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id, when)
+
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt to cancel the subscription, and defers the next one until next.
+func (r *StripeSubCancel) MarkAttemptFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, msg string, next time.Time) error {
+	const q = `UPDATE stripe_sub_cancellations SET num_attempts = num_attempts + 1, next_attempt_at = $3, last_error = $2 WHERE id = $1`
+
+	_, err := dbi.ExecContext(ctx, q, id, msg, next)
+
+	return err
+}
@@ -0,0 +1,78 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestStripeSubCancel_GetNextDueForUpdate(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE stripe_sub_cancellations, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	ord, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewStripeSubCancel()
+
+	{
+		_, err := repo.GetNextDueForUpdate(ctx, tx, time.Now().Add(time.Hour))
+		must.ErrorIs(t, err, model.ErrStripeSubCancelNotFound)
+	}
+
+	must.Equal(t, nil, repo.Insert(ctx, tx, ord.ID, "sub_id"))
+
+	// Recording the same subscription again is not an error.
+	must.Equal(t, nil, repo.Insert(ctx, tx, ord.ID, "sub_id"))
+
+	now := time.Now().Add(time.Hour)
+
+	first, err := repo.GetNextDueForUpdate(ctx, tx, now)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, ord.ID, first.OrderID)
+	should.Equal(t, "sub_id", first.SubID)
+	should.Equal(t, 0, first.NumAttempts)
+
+	// A failed attempt defers the cancellation.
+	{
+		must.Equal(t, nil, repo.MarkAttemptFailed(ctx, tx, first.ID, "something_went_wrong", now.Add(time.Minute)))
+
+		_, err := repo.GetNextDueForUpdate(ctx, tx, now)
+		must.ErrorIs(t, err, model.ErrStripeSubCancelNotFound)
+
+		actual, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(time.Minute))
+		must.Equal(t, nil, err)
+
+		should.Equal(t, first.ID, actual.ID)
+		should.Equal(t, 1, actual.NumAttempts)
+	}
+
+	// A canceled subscription is no longer due.
+	{
+		must.Equal(t, nil, repo.MarkCanceled(ctx, tx, first.ID, now))
+
+		_, err := repo.GetNextDueForUpdate(ctx, tx, now.Add(time.Hour))
+		must.ErrorIs(t, err, model.ErrStripeSubCancelNotFound)
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type TLV1Migration struct{}
+
+func NewTLV1Migration() *TLV1Migration { return &TLV1Migration{} }
+
+// Upsert creates the migration for the merchant and sku, or updates the existing one.
+func (r *TLV1Migration) Upsert(ctx context.Context, dbi sqlx.QueryerContext, req *model.TLV1MigrationRequest) (*model.TLV1Migration, error) {
+	const q = `INSERT INTO tlv1_migrations (merchant_id, sku, each_credential_valid_duration, issuer_token_buffer, issuer_token_overlap, v1_accepted_until)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (merchant_id, sku) DO UPDATE
+	SET each_credential_valid_duration = EXCLUDED.each_credential_valid_duration,
+		issuer_token_buffer = EXCLUDED.issuer_token_buffer,
+		issuer_token_overlap = EXCLUDED.issuer_token_overlap,
+		v1_accepted_until = EXCLUDED.v1_accepted_until,
+		updated_at = now()
+	RETURNING id, created_at, updated_at, merchant_id, sku, each_credential_valid_duration, issuer_token_buffer, issuer_token_overlap, v1_accepted_until`
+
+	result := &model.TLV1Migration{}
+	if err := sqlx.GetContext(
+		ctx,
+		dbi,
+		result,
+		q,
+		req.MerchantID,
+		req.SKU,
+		req.CredentialValidDurationEach,
+		req.IssuerTokenBuffer,
+		req.IssuerTokenOverlap,
+		req.V1AcceptedUntil,
+	); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *TLV1Migration) Get(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+	const q = `SELECT id, created_at, updated_at, merchant_id, sku, each_credential_valid_duration, issuer_token_buffer, issuer_token_overlap, v1_accepted_until
+	FROM tlv1_migrations
+	WHERE merchant_id = $1 AND sku = $2`
+
+	result := &model.TLV1Migration{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, merchID, sku); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrTLV1MigrationNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *TLV1Migration) List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.TLV1Migration, error) {
+	const q = `SELECT id, created_at, updated_at, merchant_id, sku, each_credential_valid_duration, issuer_token_buffer, issuer_token_overlap, v1_accepted_until
+	FROM tlv1_migrations
+	ORDER BY merchant_id, sku`
+
+	result := make([]model.TLV1Migration, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *TLV1Migration) Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `DELETE FROM tlv1_migrations WHERE id = $1`
+
+	result, err := dbi.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return model.ErrTLV1MigrationNotFound
+	}
+
+	return nil
+}
+
+// RecordPresentation adds the time limited v1 credentials presented for the merchant and sku on the day of the last one.
+func (r *TLV1Migration) RecordPresentation(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error {
+	const q = `INSERT INTO tlv1_presentations (merchant_id, sku, day, num_presented, last_presented_at)
+	VALUES ($1, $2, $3::date, $4, $3)
+	ON CONFLICT (merchant_id, sku, day) DO UPDATE
+	SET num_presented = tlv1_presentations.num_presented + EXCLUDED.num_presented,
+		last_presented_at = GREATEST(tlv1_presentations.last_presented_at, EXCLUDED.last_presented_at)`
+
+	if _, err := dbi.ExecContext(ctx, q, p.MerchantID, p.SKU, p.LastPresentedAt, p.NumPresented); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListUsage returns time limited v1 credentials presented since the day of from, by merchant and sku.
+//
+// The skus presented most often go first.
+func (r *TLV1Migration) ListUsage(ctx context.Context, dbi sqlx.QueryerContext, from time.Time) ([]model.TLV1Usage, error) {
+	const q = `SELECT p.merchant_id, p.sku, SUM(p.num_presented) AS num_presented, MAX(p.last_presented_at) AS last_presented_at,
+		m.id IS NOT NULL AS migrating, m.v1_accepted_until
+	FROM tlv1_presentations p
+	LEFT JOIN tlv1_migrations m ON m.merchant_id = p.merchant_id AND m.sku = p.sku
+	WHERE p.day >= $1::date
+	GROUP BY p.merchant_id, p.sku, m.id, m.v1_accepted_until
+	ORDER BY num_presented DESC, p.merchant_id, p.sku`
+
+	result := make([]model.TLV1Usage, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, from); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
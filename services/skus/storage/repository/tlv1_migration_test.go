@@ -0,0 +1,106 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestTLV1Migration(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE tlv1_presentations, tlv1_migrations;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewTLV1Migration()
+
+	{
+		_, err := repo.Get(ctx, tx, "brave.com", "brave-vpn-premium")
+		should.ErrorIs(t, err, model.ErrTLV1MigrationNotFound)
+	}
+
+	req := &model.TLV1MigrationRequest{
+		MerchantID:                  "brave.com",
+		SKU:                         "brave-vpn-premium",
+		CredentialValidDurationEach: "P1D",
+		IssuerTokenBuffer:           30,
+		IssuerTokenOverlap:          5,
+	}
+
+	mig1, err := repo.Upsert(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	should.Nil(t, mig1.V1AcceptedUntil)
+
+	until := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	req.V1AcceptedUntil = &until
+
+	// The existing migration is updated.
+	mig2, err := repo.Upsert(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, mig1.ID, mig2.ID)
+	must.NotNil(t, mig2.V1AcceptedUntil)
+	should.Equal(t, until, mig2.V1AcceptedUntil.UTC())
+
+	{
+		actual, err := repo.Get(ctx, tx, "brave.com", "brave-vpn-premium")
+		must.Equal(t, nil, err)
+
+		should.Equal(t, mig2.ID, actual.ID)
+	}
+
+	now := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+
+	presented := func(sku string, num int64, when time.Time) model.TLV1Presentation {
+		return model.TLV1Presentation{MerchantID: "brave.com", SKU: sku, NumPresented: num, LastPresentedAt: when}
+	}
+
+	must.Equal(t, nil, repo.RecordPresentation(ctx, tx, presented("brave-vpn-premium", 1, now.AddDate(0, 0, -1))))
+	must.Equal(t, nil, repo.RecordPresentation(ctx, tx, presented("brave-vpn-premium", 1, now.Add(time.Hour))))
+	must.Equal(t, nil, repo.RecordPresentation(ctx, tx, presented("brave-vpn-premium", 2, now)))
+	must.Equal(t, nil, repo.RecordPresentation(ctx, tx, presented("brave-search-premium", 1, now.AddDate(0, 0, -10))))
+
+	{
+		actual, err := repo.ListUsage(ctx, tx, now.AddDate(0, 0, -1))
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 1)
+		should.Equal(t, "brave-vpn-premium", actual[0].SKU)
+		should.Equal(t, int64(4), actual[0].NumPresented)
+		should.Equal(t, now.Add(time.Hour), actual[0].LastPresentedAt.UTC())
+		should.True(t, actual[0].Migrating)
+	}
+
+	{
+		actual, err := repo.ListUsage(ctx, tx, now.AddDate(0, 0, -30))
+		must.Equal(t, nil, err)
+
+		must.Len(t, actual, 2)
+		should.Equal(t, "brave-search-premium", actual[1].SKU)
+		should.False(t, actual[1].Migrating)
+	}
+
+	must.Equal(t, nil, repo.Delete(ctx, tx, mig2.ID))
+	should.ErrorIs(t, repo.Delete(ctx, tx, mig2.ID), model.ErrTLV1MigrationNotFound)
+	should.ErrorIs(t, repo.Delete(ctx, tx, uuid.NewV4()), model.ErrTLV1MigrationNotFound)
+}
@@ -29,9 +29,12 @@ func (r *TLV2) GetCredSubmissionReport(ctx context.Context, dbi sqlx.QueryerCont
 	return result, nil
 }
 
+// UniqBatches returns the number of the purchaser's batches active in the given period.
+//
+// Batches redeemed via the order's seats are counted separately, see UniqSeatBatches.
 func (r *TLV2) UniqBatches(ctx context.Context, dbi sqlx.QueryerContext, orderID, itemID uuid.UUID, from, to time.Time) (int, error) {
 	const q = `SELECT COUNT(DISTINCT request_id) FROM time_limited_v2_order_creds
-	WHERE order_id=$1 AND item_id=$2 AND valid_to > $4 AND valid_from < $3;`
+	WHERE order_id=$1 AND item_id=$2 AND seat_id IS NULL AND valid_to > $4 AND valid_from < $3;`
 
 	var result int
 	if err := sqlx.GetContext(ctx, dbi, &result, q, orderID, itemID, from, to); err != nil {
@@ -41,6 +44,19 @@ func (r *TLV2) UniqBatches(ctx context.Context, dbi sqlx.QueryerContext, orderID
 	return result, nil
 }
 
+// UniqSeatBatches returns the number of the seat's batches active in the given period.
+func (r *TLV2) UniqSeatBatches(ctx context.Context, dbi sqlx.QueryerContext, seatID, itemID uuid.UUID, from, to time.Time) (int, error) {
+	const q = `SELECT COUNT(DISTINCT request_id) FROM time_limited_v2_order_creds
+	WHERE seat_id=$1 AND item_id=$2 AND valid_to > $4 AND valid_from < $3;`
+
+	var result int
+	if err := sqlx.GetContext(ctx, dbi, &result, q, seatID, itemID, from, to); err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
 // DeleteLegacy deletes creds where request_id matches the item_id.
 //
 // Most of the time, there will be only one such set of creds for a given period of time
@@ -54,3 +70,35 @@ func (r *TLV2) DeleteLegacy(ctx context.Context, dbi sqlx.ExecerContext, orderID
 
 	return err
 }
+
+// DeleteValidAfter deletes the order's creds that are still valid after from.
+//
+// This revokes the creds issued for the period which has not been used yet.
+func (r *TLV2) DeleteValidAfter(ctx context.Context, dbi sqlx.ExecerContext, orderID uuid.UUID, from time.Time) error {
+	const q = `DELETE FROM time_limited_v2_order_creds WHERE order_id=$1 AND valid_to > $2;`
+
+	_, err := dbi.ExecContext(ctx, q, orderID, from)
+
+	return err
+}
+
+// DeleteIssuerValidAfter deletes creds of the issuer which are valid after from, and returns the number deleted.
+func (r *TLV2) DeleteIssuerValidAfter(ctx context.Context, dbi sqlx.ExecerContext, issuerID uuid.UUID, from time.Time) (int64, error) {
+	const q = `DELETE FROM time_limited_v2_order_creds WHERE issuer_id=$1 AND valid_to > $2;`
+
+	result, err := dbi.ExecContext(ctx, q, issuerID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteSeat deletes all creds redeemed via the seat.
+func (r *TLV2) DeleteSeat(ctx context.Context, dbi sqlx.ExecerContext, seatID uuid.UUID) error {
+	const q = `DELETE FROM time_limited_v2_order_creds WHERE seat_id=$1;`
+
+	_, err := dbi.ExecContext(ctx, q, seatID)
+
+	return err
+}
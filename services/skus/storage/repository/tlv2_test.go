@@ -539,6 +539,64 @@ func TestTLV2_DeleteLegacy(t *testing.T) {
 	}
 }
 
+func TestTLV2_DeleteValidAfter(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE time_limited_v2_order_creds, order_cred_issuers, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	qs := []string{
+		`INSERT INTO order_cred_issuers (id, merchant_id, public_key, created_at)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'brave.com', 'public_key_01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO orders (id, merchant_id, status, currency, total_price, created_at, updated_at)
+			VALUES ('c0c0a000-0000-4000-a000-000000000000', 'brave.com', 'paid', 'USD', 9.99, '2024-01-01 00:00:01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO order_items (id, order_id, sku, sku_variant, credential_type, currency, quantity, price, subtotal, created_at, updated_at)
+			VALUES ('ad0be000-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'brave-vpn-premium', 'brave-vpn-premium', 'time-limited-v2', 'USD', 1, 9.99, 9.99, '2024-01-01 00:00:01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds)
+			VALUES ('decade00-0000-4000-a000-000000000000', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000000', '2024-01-01 00:00:01', '2024-01-02 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_01", "cred_02", "cred_03"]', '["scred_01", "scred_02", "scred_03"]');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds)
+			VALUES ('decade00-0000-4000-a000-000000000001', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000000', '2024-01-02 00:00:01', '2024-01-03 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_01", "cred_02", "cred_03"]', '["scred_01", "scred_02", "scred_03"]');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds)
+			VALUES ('decade00-0000-4000-a000-000000000002', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000000', '2024-01-03 00:00:01', '2024-01-04 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_01", "cred_02", "cred_03"]', '["scred_01", "scred_02", "scred_03"]');`,
+	}
+
+	for i := range qs {
+		_, err := tx.ExecContext(ctx, qs[i])
+		must.Equal(t, nil, err)
+	}
+
+	repo := repository.NewTLV2()
+
+	orderID := uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))
+	itemID := uuid.Must(uuid.FromString("ad0be000-0000-4000-a000-000000000000"))
+	reqID := uuid.Must(uuid.FromString("f100ded0-0000-4000-a000-000000000000"))
+
+	{
+		err := repo.DeleteValidAfter(ctx, tx, orderID, time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC))
+		must.Equal(t, nil, err)
+	}
+
+	// Only the creds which expired before the given time remain.
+	actual, err := countTLV2ByItemReqID(ctx, tx, itemID, reqID)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, 1, actual)
+}
+
 func countTLV2ByItemReqID(ctx context.Context, dbi sqlx.QueryerContext, itemID, reqID uuid.UUID) (int, error) {
 	const q = `SELECT COUNT(*) FROM time_limited_v2_order_creds WHERE item_id=$1 AND request_id=$2;`
 
@@ -549,3 +607,88 @@ func countTLV2ByItemReqID(ctx context.Context, dbi sqlx.QueryerContext, itemID,
 
 	return num, nil
 }
+
+func TestTLV2_UniqSeatBatches_DeleteSeat(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE time_limited_v2_order_creds, order_seats, order_cred_issuers, order_items, orders;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	qs := []string{
+		`INSERT INTO order_cred_issuers (id, merchant_id, public_key, created_at)
+			VALUES ('5ca1ab1e-0000-4000-a000-000000000000', 'brave.com', 'public_key_01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO orders (id, merchant_id, status, currency, total_price, created_at, updated_at)
+			VALUES ('c0c0a000-0000-4000-a000-000000000000', 'brave.com', 'paid', 'USD', 9.99, '2024-01-01 00:00:01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO order_items (id, order_id, sku, sku_variant, credential_type, currency, quantity, price, subtotal, created_at, updated_at)
+			VALUES ('ad0be000-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'brave-vpn-premium', 'brave-vpn-premium', 'time-limited-v2', 'USD', 1, 9.99, 9.99, '2024-01-01 00:00:01', '2024-01-01 00:00:01');`,
+
+		`INSERT INTO order_seats (id, order_id, invite_token, status)
+			VALUES ('5ea70000-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'invite_token_01', 'active');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds)
+			VALUES ('decade00-0000-4000-a000-000000000000', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000000', '2024-01-01 00:00:01', '2024-01-02 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_01", "cred_02", "cred_03"]', '["scred_01", "scred_02", "scred_03"]');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds, seat_id)
+			VALUES ('decade00-0000-4000-a000-000000000001', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000001', '2024-01-01 00:00:01', '2024-01-02 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_04", "cred_05", "cred_06"]', '["scred_04", "scred_05", "scred_06"]', '5ea70000-0000-4000-a000-000000000000');`,
+
+		`INSERT INTO time_limited_v2_order_creds (id, issuer_id, order_id, item_id, request_id, valid_from, valid_to, created_at, batch_proof, public_key, blinded_creds, signed_creds, seat_id)
+			VALUES ('decade00-0000-4000-a000-000000000002', '5ca1ab1e-0000-4000-a000-000000000000', 'c0c0a000-0000-4000-a000-000000000000', 'ad0be000-0000-4000-a000-000000000000', 'f100ded0-0000-4000-a000-000000000002', '2024-01-01 00:00:01', '2024-01-02 00:00:01', '2024-01-01 00:00:01', 'proof_01', 'public_key_01', '["cred_07", "cred_08", "cred_09"]', '["scred_07", "scred_08", "scred_09"]', '5ea70000-0000-4000-a000-000000000000');`,
+	}
+
+	for i := range qs {
+		_, err := tx.ExecContext(ctx, qs[i])
+		must.Equal(t, nil, err)
+	}
+
+	repo := repository.NewTLV2()
+
+	orderID := uuid.Must(uuid.FromString("c0c0a000-0000-4000-a000-000000000000"))
+	itemID := uuid.Must(uuid.FromString("ad0be000-0000-4000-a000-000000000000"))
+	seatID := uuid.Must(uuid.FromString("5ea70000-0000-4000-a000-000000000000"))
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	{
+		actual, err := repo.UniqBatches(ctx, tx, orderID, itemID, now, now)
+		must.Equal(t, nil, err)
+
+		// The seat's batches are not counted towards the purchaser's.
+		should.Equal(t, 1, actual)
+	}
+
+	{
+		actual, err := repo.UniqSeatBatches(ctx, tx, seatID, itemID, now, now)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 2, actual)
+	}
+
+	{
+		err := repo.DeleteSeat(ctx, tx, seatID)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		actual, err := repo.UniqSeatBatches(ctx, tx, seatID, itemID, now, now)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 0, actual)
+	}
+
+	{
+		actual, err := repo.UniqBatches(ctx, tx, orderID, itemID, now, now)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, 1, actual)
+	}
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type Transaction struct{}
+
+func NewTransaction() *Transaction { return &Transaction{} }
+
+// Insert records a transaction.
+//
+// An existing transaction with the same external id is left intact, and model.ErrTransactionAlreadyExists is returned.
+func (r *Transaction) Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.TransactionNew) (*model.Transaction, error) {
+	const q = `INSERT INTO transactions (order_id, external_transaction_id, status, currency, kind, amount)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (external_transaction_id) DO NOTHING
+	RETURNING id, order_id, created_at, updated_at, external_transaction_id, status, currency, kind, amount`
+
+	result := &model.Transaction{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, req.OrderID, req.ExternalTransactionID, req.Status, req.Currency, req.Kind, req.Amount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrTransactionAlreadyExists
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
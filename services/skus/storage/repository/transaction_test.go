@@ -0,0 +1,60 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestTransaction_Insert(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE transactions, order_items, orders;")
+	}()
+
+	ctx := context.TODO()
+
+	tx, err := dbi.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelReadUncommitted})
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	order, err := createOrderForTest(ctx, tx, repository.NewOrder())
+	must.Equal(t, nil, err)
+
+	repo := repository.NewTransaction()
+
+	req := model.TransactionNew{
+		OrderID:               order.ID,
+		ExternalTransactionID: "re_01",
+		Status:                model.TransactionStatusCompleted,
+		Currency:              "USD",
+		Kind:                  model.TransactionKindRefund,
+		Amount:                mustDecimalFromString("-9.99"),
+	}
+
+	actual, err := repo.Insert(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	should.Equal(t, order.ID, actual.OrderID)
+	should.Equal(t, "re_01", actual.ExternalTransactionID)
+	should.Equal(t, model.TransactionStatusCompleted, actual.Status)
+	should.Equal(t, "USD", actual.Currency)
+	should.Equal(t, model.TransactionKindRefund, actual.Kind)
+	should.True(t, mustDecimalFromString("-9.99").Equal(actual.Amount))
+
+	{
+		_, err := repo.Insert(ctx, tx, req)
+		should.Equal(t, model.ErrTransactionAlreadyExists, err)
+	}
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+type WebhookInbox struct{}
+
+func NewWebhookInbox() *WebhookInbox { return &WebhookInbox{} }
+
+// Insert stores the notification.
+//
+// It returns model.ErrWebhookInboxEntryExists if the vendor has already delivered an event with the same id.
+func (r *WebhookInbox) Insert(ctx context.Context, dbi sqlx.QueryerContext, req model.WebhookInboxEntryNew) (*model.WebhookInboxEntry, error) {
+	const q = `INSERT INTO webhook_inbox (vendor, event_id, order_key, payload)
+	VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+	ON CONFLICT (vendor, event_id) DO NOTHING
+	RETURNING id, created_at, updated_at, vendor, event_id, order_key, payload, status, num_attempts, last_error, next_attempt_at, processed_at`
+
+	result := &model.WebhookInboxEntry{}
+	if err := dbi.QueryRowxContext(ctx, q, req.Vendor, req.EventID, req.OrderKey, req.Payload).StructScan(result); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrWebhookInboxEntryExists
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *WebhookInbox) Get(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) (*model.WebhookInboxEntry, error) {
+	const q = `SELECT id, created_at, updated_at, vendor, event_id, order_key, payload, status, num_attempts, last_error, next_attempt_at, processed_at
+	FROM webhook_inbox WHERE id = $1`
+
+	result := &model.WebhookInboxEntry{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrWebhookInboxEntryNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ClaimNext leases the oldest pending entry that is due at now until leaseUntil, and returns it.
+//
+// An entry is not claimed while an earlier received entry for the same order is pending,
+// so that notifications about an order are applied in the order they were received.
+// Entries claimed concurrently by other workers are skipped.
+// If the claiming worker fails to record the outcome, the entry becomes available again after leaseUntil.
+func (r *WebhookInbox) ClaimNext(ctx context.Context, dbi sqlx.QueryerContext, now, leaseUntil time.Time) (*model.WebhookInboxEntry, error) {
+	const q = `UPDATE webhook_inbox
+	SET next_attempt_at = $2, updated_at = now()
+	WHERE id = (
+		SELECT id FROM webhook_inbox AS w
+		WHERE w.status = 'pending' AND w.next_attempt_at <= $1 AND NOT EXISTS (
+			SELECT 1 FROM webhook_inbox AS e
+			WHERE e.order_key = w.order_key AND e.status = 'pending' AND (e.created_at, e.id) < (w.created_at, w.id)
+		)
+		ORDER BY w.next_attempt_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, created_at, updated_at, vendor, event_id, order_key, payload, status, num_attempts, last_error, next_attempt_at, processed_at`
+
+	result := &model.WebhookInboxEntry{}
+	if err := sqlx.GetContext(ctx, dbi, result, q, now, leaseUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrWebhookInboxEntryNotFound
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *WebhookInbox) MarkProcessed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE webhook_inbox
+	SET status = 'processed', num_attempts = num_attempts + 1, processed_at = $2, updated_at = now()
+	WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, when)
+}
+
+// MarkFailed records a failed attempt, and schedules the next one for nextAt.
+func (r *WebhookInbox) MarkFailed(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string, nextAt time.Time) error {
+	const q = `UPDATE webhook_inbox
+	SET num_attempts = num_attempts + 1, last_error = $2, next_attempt_at = $3, updated_at = now()
+	WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, lastErr, nextAt)
+}
+
+// MarkDead records the final failed attempt, after which the entry is no longer picked up.
+func (r *WebhookInbox) MarkDead(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string) error {
+	const q = `UPDATE webhook_inbox
+	SET status = 'dead', num_attempts = num_attempts + 1, last_error = $2, updated_at = now()
+	WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, lastErr)
+}
+
+// Reset makes the entry pending again, so that it gets processed at when.
+//
+// The number of attempts is reset, regardless of the current status.
+func (r *WebhookInbox) Reset(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+	const q = `UPDATE webhook_inbox
+	SET status = 'pending', num_attempts = 0, next_attempt_at = $2, processed_at = NULL, updated_at = now()
+	WHERE id = $1`
+
+	return r.execUpdate(ctx, dbi, q, id, when)
+}
+
+func (r *WebhookInbox) execUpdate(ctx context.Context, dbi sqlx.ExecerContext, q string, args ...interface{}) error {
+	result, err := dbi.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	numAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numAffected == 0 {
+		return model.ErrWebhookInboxEntryNotFound
+	}
+
+	return nil
+}
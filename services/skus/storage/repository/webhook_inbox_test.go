@@ -0,0 +1,273 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestWebhookInbox_ClaimNext(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE webhook_inbox;")
+	}()
+
+	type tcGiven struct {
+		now time.Time
+
+		fnBefore func(ctx context.Context, dbi sqlx.ExtContext) error
+	}
+
+	type tcExpected struct {
+		payload string
+		err     error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "empty",
+			given: tcGiven{
+				now:      time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				fnBefore: func(ctx context.Context, dbi sqlx.ExtContext) error { return nil },
+			},
+			exp: tcExpected{err: model.ErrWebhookInboxEntryNotFound},
+		},
+
+		{
+			name: "not_due_processed_dead",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				fnBefore: func(ctx context.Context, dbi sqlx.ExtContext) error {
+					const q = `INSERT INTO webhook_inbox (vendor, payload, status, next_attempt_at)
+					VALUES
+						('stripe', 'payload_01', 'pending', '2024-01-01 00:00:02'),
+						('stripe', 'payload_02', 'processed', '2024-01-01 00:00:00'),
+						('stripe', 'payload_03', 'dead', '2024-01-01 00:00:00');`
+
+					_, err := dbi.ExecContext(ctx, q)
+
+					return err
+				},
+			},
+			exp: tcExpected{err: model.ErrWebhookInboxEntryNotFound},
+		},
+
+		{
+			name: "oldest_due",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				fnBefore: func(ctx context.Context, dbi sqlx.ExtContext) error {
+					const q = `INSERT INTO webhook_inbox (vendor, payload, status, next_attempt_at)
+					VALUES
+						('radom', 'payload_01', 'pending', '2024-01-01 00:00:01'),
+						('radom', 'payload_02', 'pending', '2024-01-01 00:00:00'),
+						('radom', 'payload_03', 'pending', '2024-01-01 00:00:02');`
+
+					_, err := dbi.ExecContext(ctx, q)
+
+					return err
+				},
+			},
+			exp: tcExpected{payload: "payload_02"},
+		},
+
+		{
+			name: "earlier_for_same_order_pending",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				fnBefore: func(ctx context.Context, dbi sqlx.ExtContext) error {
+					const q = `INSERT INTO webhook_inbox (created_at, vendor, order_key, payload, status, next_attempt_at)
+					VALUES
+						('2024-01-01 00:00:00', 'stripe', 'sub_01', 'payload_01', 'pending', '2024-01-01 00:00:02'),
+						('2024-01-01 00:00:01', 'stripe', 'sub_01', 'payload_02', 'pending', '2024-01-01 00:00:00'),
+						('2024-01-01 00:00:01', 'stripe', 'sub_02', 'payload_03', 'pending', '2024-01-01 00:00:01');`
+
+					_, err := dbi.ExecContext(ctx, q)
+
+					return err
+				},
+			},
+			exp: tcExpected{payload: "payload_03"},
+		},
+
+		{
+			name: "earlier_for_same_order_processed_dead",
+			given: tcGiven{
+				now: time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+				fnBefore: func(ctx context.Context, dbi sqlx.ExtContext) error {
+					const q = `INSERT INTO webhook_inbox (created_at, vendor, order_key, payload, status, next_attempt_at)
+					VALUES
+						('2024-01-01 00:00:00', 'stripe', 'sub_01', 'payload_01', 'processed', '2024-01-01 00:00:00'),
+						('2024-01-01 00:00:00', 'stripe', 'sub_01', 'payload_02', 'dead', '2024-01-01 00:00:00'),
+						('2024-01-01 00:00:01', 'stripe', 'sub_01', 'payload_03', 'pending', '2024-01-01 00:00:01');`
+
+					_, err := dbi.ExecContext(ctx, q)
+
+					return err
+				},
+			},
+			exp: tcExpected{payload: "payload_03"},
+		},
+	}
+
+	repo := repository.NewWebhookInbox()
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			tx, err := dbi.BeginTxx(ctx, nil)
+			must.Equal(t, nil, err)
+
+			t.Cleanup(func() { _ = tx.Rollback() })
+
+			{
+				err := tc.given.fnBefore(ctx, tx)
+				must.Equal(t, nil, err)
+			}
+
+			leaseUntil := tc.given.now.Add(5 * time.Minute)
+
+			actual, err := repo.ClaimNext(ctx, tx, tc.given.now, leaseUntil)
+			must.Equal(t, tc.exp.err, err)
+
+			if tc.exp.err != nil {
+				return
+			}
+
+			should.Equal(t, tc.exp.payload, actual.Payload)
+			should.Equal(t, model.WebhookInboxStatusPending, actual.Status)
+			should.True(t, leaseUntil.Equal(actual.NextAttemptAt))
+		})
+	}
+}
+
+func TestWebhookInbox_Insert(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE webhook_inbox;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, nil)
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewWebhookInbox()
+
+	req := model.WebhookInboxEntryNew{Vendor: "stripe", EventID: "evt_01", OrderKey: "sub_01", Payload: "payload_01"}
+
+	entry, err := repo.Insert(ctx, tx, req)
+	must.Equal(t, nil, err)
+
+	must.NotNil(t, entry.EventID)
+	should.Equal(t, "evt_01", *entry.EventID)
+
+	must.NotNil(t, entry.OrderKey)
+	should.Equal(t, "sub_01", *entry.OrderKey)
+
+	{
+		_, err := repo.Insert(ctx, tx, req)
+		should.Equal(t, model.ErrWebhookInboxEntryExists, err)
+	}
+
+	{
+		actual, err := repo.Insert(ctx, tx, model.WebhookInboxEntryNew{Vendor: "android", EventID: "evt_01", Payload: "payload_02"})
+		must.Equal(t, nil, err)
+
+		should.NotEqual(t, entry.ID, actual.ID)
+		should.Nil(t, actual.OrderKey)
+	}
+
+	// Entries without an event id are not deduplicated.
+	for i := 0; i < 2; i++ {
+		actual, err := repo.Insert(ctx, tx, model.WebhookInboxEntryNew{Vendor: "radom", Payload: "payload_03"})
+		must.Equal(t, nil, err)
+
+		should.Nil(t, actual.EventID)
+	}
+}
+
+func TestWebhookInbox_MarkFailedReset(t *testing.T) {
+	dbi, err := setupDBI()
+	must.Equal(t, nil, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE webhook_inbox;")
+	}()
+
+	ctx := context.Background()
+
+	tx, err := dbi.BeginTxx(ctx, nil)
+	must.Equal(t, nil, err)
+
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	repo := repository.NewWebhookInbox()
+
+	entry, err := repo.Insert(ctx, tx, model.WebhookInboxEntryNew{Vendor: "android", Payload: "payload_01"})
+	must.Equal(t, nil, err)
+
+	should.Equal(t, model.WebhookInboxStatusPending, entry.Status)
+	should.Equal(t, 0, entry.NumAttempts)
+
+	nextAt := time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+	{
+		err := repo.MarkFailed(ctx, tx, entry.ID, "model: order not found", nextAt)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		err := repo.MarkDead(ctx, tx, entry.ID, "model: order not found")
+		must.Equal(t, nil, err)
+	}
+
+	{
+		actual, err := repo.Get(ctx, tx, entry.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.WebhookInboxStatusDead, actual.Status)
+		should.Equal(t, 2, actual.NumAttempts)
+
+		must.NotNil(t, actual.LastError)
+		should.Equal(t, "model: order not found", *actual.LastError)
+	}
+
+	{
+		err := repo.Reset(ctx, tx, entry.ID, nextAt)
+		must.Equal(t, nil, err)
+	}
+
+	{
+		actual, err := repo.Get(ctx, tx, entry.ID)
+		must.Equal(t, nil, err)
+
+		should.Equal(t, model.WebhookInboxStatusPending, actual.Status)
+		should.Equal(t, 0, actual.NumAttempts)
+		should.True(t, nextAt.Equal(actual.NextAttemptAt))
+	}
+}
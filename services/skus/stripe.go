@@ -1,13 +1,23 @@
 package skus
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/webhook"
+
+	appctx "github.com/brave-intl/bat-go/libs/context"
 
 	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/xstripe"
 )
 
 const (
@@ -17,12 +27,28 @@ const (
 	errStripeNoInvoiceLines   = model.Error("stripe: no invoice lines")
 	errStripeOrderIDMissing   = model.Error("stripe: order_id missing")
 	errStripeInvalidSubPeriod = model.Error("stripe: invalid subscription period")
+	errStripeNoSubItems       = model.Error("stripe: no subscription items")
+	errStripeNoDisputeCharge  = model.Error("stripe: no dispute charge")
+	errStripeNoChargeInvoice  = model.Error("stripe: no charge invoice")
+	errStripeRefundUnresolved = model.Error("stripe: refund not resolved")
 )
 
 type stripeNotification struct {
 	raw     *stripe.Event
 	invoice *stripe.Invoice
 	sub     *stripe.Subscription
+	charge  *stripe.Charge
+	dispute *stripe.Dispute
+
+	// refund is only set for refunds and chargebacks, by stripeProcessor.ResolveNotification.
+	refund *stripeRefund
+}
+
+// stripeRefund is a refund or a chargeback matched to the order paid for by the charge.
+type stripeRefund struct {
+	txn   model.TransactionNew
+	subID string
+	full  bool
 }
 
 func parseStripeNotification(raw *stripe.Event) (*stripeNotification, error) {
@@ -41,7 +67,7 @@ func parseStripeNotification(raw *stripe.Event) (*stripeNotification, error) {
 
 		return result, nil
 
-	case "customer.subscription.deleted":
+	case "customer.subscription.deleted", "customer.subscription.updated":
 		val, err := parseStripeEventData[stripe.Subscription](raw.Data.Raw)
 		if err != nil {
 			return nil, err
@@ -51,13 +77,33 @@ func parseStripeNotification(raw *stripe.Event) (*stripeNotification, error) {
 
 		return result, nil
 
+	case "charge.refunded":
+		val, err := parseStripeEventData[stripe.Charge](raw.Data.Raw)
+		if err != nil {
+			return nil, err
+		}
+
+		result.charge = val
+
+		return result, nil
+
+	case "charge.dispute.created":
+		val, err := parseStripeEventData[stripe.Dispute](raw.Data.Raw)
+		if err != nil {
+			return nil, err
+		}
+
+		result.dispute = val
+
+		return result, nil
+
 	default:
 		return nil, errStripeSkipEvent
 	}
 }
 
 func (x *stripeNotification) shouldProcess() bool {
-	return x.shouldRenew() || x.shouldCancel() || x.shouldRecordPayFailure()
+	return x.shouldRenew() || x.shouldCancel() || x.shouldRecordPayFailure() || x.shouldChangePlan() || x.shouldRefund()
 }
 
 func (x *stripeNotification) shouldRenew() bool {
@@ -72,10 +118,47 @@ func (x *stripeNotification) shouldRecordPayFailure() bool {
 	return x.invoice != nil && x.raw.Type == "invoice.payment_failed"
 }
 
+// shouldChangePlan reports whether the ntf might be about a plan change.
+//
+// The event is sent on any change to the subscription, so the price has to be compared with the order's.
+func (x *stripeNotification) shouldChangePlan() bool {
+	return x.sub != nil && x.raw.Type == "customer.subscription.updated"
+}
+
+// shouldRefund reports whether the ntf is about a refund or a chargeback.
+func (x *stripeNotification) shouldRefund() bool {
+	switch {
+	case x.charge != nil && x.raw.Type == "charge.refunded":
+		return true
+
+	case x.dispute != nil && x.raw.Type == "charge.dispute.created":
+		return true
+
+	default:
+		return false
+	}
+}
+
 func (x *stripeNotification) ntfType() string {
 	return x.raw.Type
 }
 
+func (x *stripeNotification) eventID() string {
+	return x.raw.ID
+}
+
+// orderKey returns the subscription id.
+//
+// Refunds and disputes don't reference the subscription until they are resolved, so they are not ordered.
+func (x *stripeNotification) orderKey() string {
+	subID, err := x.subID()
+	if err != nil {
+		return ""
+	}
+
+	return subID
+}
+
 func (x *stripeNotification) ntfSubType() string {
 	switch {
 	case x.invoice != nil && x.sub == nil:
@@ -84,6 +167,12 @@ func (x *stripeNotification) ntfSubType() string {
 	case x.sub != nil && x.invoice == nil:
 		return "subscription"
 
+	case x.charge != nil:
+		return "charge"
+
+	case x.dispute != nil:
+		return "dispute"
+
 	default:
 		return "unknown"
 	}
@@ -100,6 +189,12 @@ func (x *stripeNotification) effect() string {
 	case x.shouldRecordPayFailure():
 		return "record_payment_failure"
 
+	case x.shouldChangePlan():
+		return "change_plan"
+
+	case x.shouldRefund():
+		return "refund"
+
 	default:
 		return "skip"
 	}
@@ -125,16 +220,7 @@ func (x *stripeNotification) subID() (string, error) {
 func (x *stripeNotification) orderID() (uuid.UUID, error) {
 	switch {
 	case x.invoice != nil:
-		if x.invoice.Lines == nil || len(x.invoice.Lines.Data) == 0 {
-			return uuid.Nil, errStripeNoInvoiceLines
-		}
-
-		id, ok := x.invoice.Lines.Data[0].Metadata["orderID"]
-		if !ok {
-			return uuid.Nil, errStripeOrderIDMissing
-		}
-
-		return uuid.FromString(id)
+		return stripeOrderIDFromInvoice(x.invoice)
 
 	case x.sub != nil:
 		id, ok := x.sub.Metadata["orderID"]
@@ -149,7 +235,28 @@ func (x *stripeNotification) orderID() (uuid.UUID, error) {
 	}
 }
 
+// priceID returns the id of the subscription's price.
+func (x *stripeNotification) priceID() (string, error) {
+	if x.sub == nil {
+		return "", errStripeUnsupportedEvent
+	}
+
+	if x.sub.Items == nil || len(x.sub.Items.Data) == 0 || x.sub.Items.Data[0].Price == nil {
+		return "", errStripeNoSubItems
+	}
+
+	return x.sub.Items.Data[0].Price.ID, nil
+}
+
 func (x *stripeNotification) expiresTime() (time.Time, error) {
+	if x.sub != nil && x.invoice == nil {
+		if x.sub.CurrentPeriodEnd == 0 {
+			return time.Time{}, errStripeInvalidSubPeriod
+		}
+
+		return time.Unix(x.sub.CurrentPeriodEnd, 0).UTC(), nil
+	}
+
 	if x.invoice == nil {
 		return time.Time{}, errStripeUnsupportedEvent
 	}
@@ -166,6 +273,100 @@ func (x *stripeNotification) expiresTime() (time.Time, error) {
 	return time.Unix(sub.Period.End, 0).UTC(), nil
 }
 
+// chargeID returns the id of the charge which has been refunded or disputed.
+func (x *stripeNotification) chargeID() (string, error) {
+	switch {
+	case x.charge != nil:
+		return x.charge.ID, nil
+
+	case x.dispute != nil:
+		if x.dispute.Charge == nil {
+			return "", errStripeNoDisputeCharge
+		}
+
+		return x.dispute.Charge.ID, nil
+
+	default:
+		return "", errStripeUnsupportedEvent
+	}
+}
+
+// refundTxn returns the transaction which records the refund or the chargeback against the order.
+//
+// The amount is negative as the money goes back to the customer.
+func (x *stripeNotification) refundTxn(oid uuid.UUID) (model.TransactionNew, error) {
+	switch {
+	case x.charge != nil:
+		result := model.TransactionNew{
+			OrderID:               oid,
+			ExternalTransactionID: x.charge.ID,
+			Status:                model.TransactionStatusCompleted,
+			Currency:              strings.ToUpper(string(x.charge.Currency)),
+			Kind:                  model.TransactionKindRefund,
+			Amount:                newStripeAmount(-x.charge.AmountRefunded, x.charge.Currency),
+		}
+
+		// Each refund of the charge is recorded separately.
+		if x.charge.Refunds != nil && len(x.charge.Refunds.Data) > 0 && x.charge.Refunds.Data[0] != nil {
+			result.ExternalTransactionID = x.charge.Refunds.Data[0].ID
+			result.Amount = newStripeAmount(-x.charge.Refunds.Data[0].Amount, x.charge.Currency)
+		}
+
+		return result, nil
+
+	case x.dispute != nil:
+		result := model.TransactionNew{
+			OrderID:               oid,
+			ExternalTransactionID: x.dispute.ID,
+			Status:                model.TransactionStatusCompleted,
+			Currency:              strings.ToUpper(string(x.dispute.Currency)),
+			Kind:                  model.TransactionKindChargeback,
+			Amount:                newStripeAmount(-x.dispute.Amount, x.dispute.Currency),
+		}
+
+		return result, nil
+
+	default:
+		return model.TransactionNew{}, errStripeUnsupportedEvent
+	}
+}
+
+// isFullRefund reports whether the whole of chrg has been refunded, or is disputed.
+func (x *stripeNotification) isFullRefund(chrg *stripe.Charge) bool {
+	switch {
+	case x.charge != nil:
+		return x.charge.Refunded
+
+	case x.dispute != nil:
+		return x.dispute.Amount >= chrg.Amount
+
+	default:
+		return false
+	}
+}
+
+// newStripeAmount converts an amount in the smallest unit of the currency, as Stripe reports it.
+func newStripeAmount(amount int64, cur stripe.Currency) decimal.Decimal {
+	return decimal.New(amount, -xstripe.CurrencyExponent(string(cur)))
+}
+
+func stripeOrderIDFromInvoice(inv *stripe.Invoice) (uuid.UUID, error) {
+	if inv == nil {
+		return uuid.Nil, errStripeNoChargeInvoice
+	}
+
+	if inv.Lines == nil || len(inv.Lines.Data) == 0 {
+		return uuid.Nil, errStripeNoInvoiceLines
+	}
+
+	id, ok := inv.Lines.Data[0].Metadata["orderID"]
+	if !ok {
+		return uuid.Nil, errStripeOrderIDMissing
+	}
+
+	return uuid.FromString(id)
+}
+
 func parseStripeEventData[T any](data []byte) (*T, error) {
 	var result T
 	if err := json.Unmarshal(data, &result); err != nil {
@@ -174,3 +375,330 @@ func parseStripeEventData[T any](data []byte) (*T, error) {
 
 	return &result, nil
 }
+
+type stripeProcessor struct {
+	cl      stripeClient
+	catalog *skuCatalog
+}
+
+func newStripeProcessor(cl stripeClient, catalog *skuCatalog) *stripeProcessor {
+	return &stripeProcessor{cl: cl, catalog: catalog}
+}
+
+func (p *stripeProcessor) Name() string {
+	return model.StripePaymentMethod
+}
+
+func (p *stripeProcessor) CreateCheckoutSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, coupon *model.Coupon) (*CheckoutSession, error) {
+	sessID, err := p.createSession(ctx, req, ord, coupon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckoutSession{ID: sessID, MetadataKey: "stripeCheckoutSessionId"}, nil
+}
+
+func (p *stripeProcessor) createSession(ctx context.Context, req *model.CreateOrderRequestNew, ord *model.Order, coupon *model.Coupon) (string, error) {
+	oid := ord.ID.String()
+
+	surl, err := req.StripeMetadata.SuccessURL(oid)
+	if err != nil {
+		return "", err
+	}
+
+	curl, err := req.StripeMetadata.CancelURL(oid)
+	if err != nil {
+		return "", err
+	}
+
+	sreq := createStripeSessionRequest{
+		orderID:    oid,
+		email:      req.Email,
+		customerID: req.CustomerID,
+		successURL: surl,
+		cancelURL:  curl,
+		trialDays:  ord.GetTrialDays(),
+		items:      buildStripeLineItems(ord.Items),
+		discounts:  buildStripeDiscounts(stripeDiscounts(req.Discounts, coupon)),
+		metadata:   req.Metadata,
+	}
+
+	return createStripeSession(ctx, p.cl, sreq)
+}
+
+// AuthenticateNotification verifies the signature of the event with the webhook secret.
+func (p *stripeProcessor) AuthenticateNotification(ctx context.Context, r *http.Request, body []byte) ([]byte, error) {
+	secret, err := appctx.GetStringFromContext(ctx, appctx.StripeWebhookSecretCTXKey)
+	if err != nil {
+		return nil, newPaymentNtfError(err, http.StatusInternalServerError)
+	}
+
+	if _, err := webhook.ConstructEvent(body, r.Header.Get("Stripe-Signature"), secret); err != nil {
+		return nil, newPaymentNtfError(err, http.StatusBadRequest)
+	}
+
+	return body, nil
+}
+
+func (p *stripeProcessor) ParseNotification(payload []byte) (PaymentNotification, error) {
+	event := &stripe.Event{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+
+	ntf, err := parseStripeNotification(event)
+	if err != nil {
+		if errors.Is(err, errStripeSkipEvent) {
+			return nil, errPaymentNtfSkip
+		}
+
+		return nil, err
+	}
+
+	return ntf, nil
+}
+
+func (p *stripeProcessor) ProcessNotification(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*stripeNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	switch {
+	case ntf.shouldRenew():
+		subID, err := ntf.subID()
+		if err != nil {
+			return err
+		}
+
+		oid, err := ntf.orderID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderTx(ctx, dbi, oid)
+		if err != nil {
+			return err
+		}
+
+		expt, err := ntf.expiresTime()
+		if err != nil {
+			return err
+		}
+
+		paidt := time.Now()
+
+		return renewOrderStripe(ctx, dbi, sm, ord, subID, expt, paidt)
+
+	case ntf.shouldCancel():
+		oid, err := ntf.orderID()
+		if err != nil {
+			return err
+		}
+
+		// Reset numPaymentFailed.
+		if err := sm.resetNumPaymentFailed(ctx, dbi, oid); err != nil {
+			return err
+		}
+
+		return sm.cancelOrderTx(ctx, dbi, oid)
+
+	case ntf.shouldRecordPayFailure():
+		subID, err := ntf.subID()
+		if err != nil {
+			return err
+		}
+
+		oid, err := ntf.orderID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderTx(ctx, dbi, oid)
+		if err != nil {
+			return err
+		}
+
+		return recordPayFailureStripe(ctx, dbi, sm, ord, subID)
+
+	case ntf.shouldChangePlan():
+		oid, err := ntf.orderID()
+		if err != nil {
+			return err
+		}
+
+		priceID, err := ntf.priceID()
+		if err != nil {
+			return err
+		}
+
+		ord, err := sm.getOrderFullTx(ctx, dbi, oid)
+		if err != nil {
+			return err
+		}
+
+		return p.changeOrderPlan(ctx, dbi, sm, ord, ntf, priceID)
+
+	case ntf.shouldRefund():
+		if ntf.refund == nil {
+			return errStripeRefundUnresolved
+		}
+
+		if err := sm.refundOrderTx(ctx, dbi, ntf.refund.txn, ntf.refund.full, time.Now()); err != nil {
+			return err
+		}
+
+		// The subscription of an order refunded in full is canceled once the refund has been committed.
+		if !ntf.refund.full || ntf.refund.subID == "" {
+			return nil
+		}
+
+		return sm.scheduleStripeSubCancelTx(ctx, dbi, ntf.refund.txn.OrderID, ntf.refund.subID)
+
+	default:
+		return nil
+	}
+}
+
+// ResolveNotification matches a refund or a chargeback to its order.
+//
+// The order is only known to the charge's invoice, which is fetched before the notification is processed, so that
+// Stripe is not called while the order is locked.
+func (p *stripeProcessor) ResolveNotification(ctx context.Context, ntfx PaymentNotification) error {
+	ntf, ok := ntfx.(*stripeNotification)
+	if !ok {
+		return errPaymentNtfInvalid
+	}
+
+	if !ntf.shouldRefund() {
+		return nil
+	}
+
+	chargeID, err := ntf.chargeID()
+	if err != nil {
+		return err
+	}
+
+	params := &stripe.ChargeParams{}
+	params.AddExpand("invoice")
+
+	chrg, err := p.cl.Charge(ctx, chargeID, params)
+	if err != nil {
+		return err
+	}
+
+	oid, err := stripeOrderIDFromInvoice(chrg.Invoice)
+	if err != nil {
+		return err
+	}
+
+	txn, err := ntf.refundTxn(oid)
+	if err != nil {
+		return err
+	}
+
+	ntf.refund = &stripeRefund{txn: txn, full: ntf.isFullRefund(chrg)}
+	if chrg.Invoice.Subscription != nil {
+		ntf.refund.subID = chrg.Invoice.Subscription.ID
+	}
+
+	return nil
+}
+
+func (p *stripeProcessor) changeOrderPlan(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ord *model.Order, ntf *stripeNotification, priceID string) error {
+	// Most updates to the subscription don't touch the price.
+	if len(ord.Items) == 1 {
+		if itemID, ok := ord.Items[0].StripeItemID(); ok && itemID == priceID {
+			return nil
+		}
+	}
+
+	skuVnt, err := p.catalog.skuVntByStripePriceID(ctx, dbi, priceID)
+	if err != nil {
+		if errors.Is(err, model.ErrSKUCatalogEntryNotFound) {
+			return model.ErrOrderPlanNotFound
+		}
+
+		return err
+	}
+
+	expt, err := ntf.expiresTime()
+	if err != nil && !errors.Is(err, errStripeInvalidSubPeriod) {
+		return err
+	}
+
+	if !expt.IsZero() {
+		// Add 1-day leeway in case next billing cycle's webhook gets delayed.
+		expt = expt.Add(24 * time.Hour)
+	}
+
+	return sm.changeOrderPlanTx(ctx, dbi, ord, skuVnt, expt, time.Now())
+}
+
+// Subscription returns the current period of the subscription.
+//
+// As with the checkout session check in updateOrderStripeSession, the start of the period is taken as the time of payment.
+func (p *stripeProcessor) Subscription(ctx context.Context, subID string) (*PaymentSubscription, error) {
+	sub, err := p.cl.Subscription(ctx, subID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaymentSubscription{
+		ID:         sub.ID,
+		ExpiresAt:  time.Unix(sub.CurrentPeriodEnd, 0).UTC(),
+		LastPaidAt: time.Unix(sub.CurrentPeriodStart, 0).UTC(),
+	}
+
+	return result, nil
+}
+
+func recordPayFailureStripe(ctx context.Context, dbi sqlx.ExtContext, sm orderStateMachine, ord *model.Order, subID string) error {
+	if shouldUpdateOrderStripeSubID(ord, subID) {
+		if err := sm.setOrderMetadataTx(ctx, dbi, ord.ID, "stripeSubscriptionId", subID); err != nil {
+			return err
+		}
+	}
+
+	if err := sm.incrementNumPaymentFailed(ctx, dbi, ord.ID); err != nil {
+		return err
+	}
+
+	if err := sm.recordPayFailureTx(ctx, dbi, ord, model.WebhookVendorStripe, time.Now()); err != nil {
+		return err
+	}
+
+	// Skip updating payment processor if it's already Stripe.
+	if ord.IsStripe() {
+		return nil
+	}
+
+	return sm.setOrderMetadataTx(ctx, dbi, ord.ID, "paymentProcessor", model.StripePaymentMethod)
+}
+
+func renewOrderStripe(ctx context.Context, dbi sqlx.ExecerContext, sm orderStateMachine, ord *model.Order, subID string, expt, paidt time.Time) error {
+	if shouldUpdateOrderStripeSubID(ord, subID) {
+		if err := sm.setOrderMetadataTx(ctx, dbi, ord.ID, "stripeSubscriptionId", subID); err != nil {
+			return err
+		}
+	}
+
+	// Add 1-day leeway in case next billing cycle's webhook gets delayed.
+	expt = expt.Add(24 * time.Hour)
+
+	if err := sm.renewOrderWithExpPaidTimeTx(ctx, dbi, ord.ID, expt, paidt); err != nil {
+		return err
+	}
+
+	// Reset numPaymentFailed.
+	if err := sm.resetNumPaymentFailed(ctx, dbi, ord.ID); err != nil {
+		return err
+	}
+
+	// Skip updating payment processor if it's already Stripe.
+	if ord.IsStripe() {
+		return nil
+	}
+
+	return sm.setOrderMetadataTx(ctx, dbi, ord.ID, "paymentProcessor", model.StripePaymentMethod)
+}
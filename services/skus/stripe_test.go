@@ -8,9 +8,12 @@ import (
 	"time"
 
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	should "github.com/stretchr/testify/assert"
 	must "github.com/stretchr/testify/require"
 	"github.com/stripe/stripe-go/v72"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
 )
 
 func TestParseStripeNotification(t *testing.T) {
@@ -19,7 +22,7 @@ func TestParseStripeNotification(t *testing.T) {
 	// It's been discovered that SKUs uses v72 which corresponds to the API version 2020-08-27.
 	// Stripe sends us webhooks in the 2020-03-02 format (v70 and v71).
 	//
-	// So the code in handleStripeWebhook has been unreliable all this time.
+	// So the code handling Stripe webhooks has been unreliable all this time.
 	//
 	// This test makes sure that the data we need can still be obtained despite the version mismatch.
 	// After the version has been updated, this test should still pass.
@@ -277,6 +280,389 @@ func TestStripeNotification_shouldCancel(t *testing.T) {
 	}
 }
 
+func TestStripeNotification_shouldChangePlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		given *stripeNotification
+		exp   bool
+	}{
+		{
+			name: "no_sub_correct_type",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+			},
+		},
+
+		{
+			name: "sub_wrong_type",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.deleted"},
+				sub: &stripe.Subscription{},
+			},
+		},
+
+		{
+			name: "change_plan",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{},
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.shouldChangePlan()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestStripeNotification_priceID(t *testing.T) {
+	type tcExpected struct {
+		val string
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given *stripeNotification
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "no_sub",
+			given: &stripeNotification{
+				raw:     &stripe.Event{Type: "invoice.paid"},
+				invoice: &stripe.Invoice{},
+			},
+			exp: tcExpected{err: errStripeUnsupportedEvent},
+		},
+
+		{
+			name: "no_items",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{},
+			},
+			exp: tcExpected{err: errStripeNoSubItems},
+		},
+
+		{
+			name: "no_price",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{
+					Items: &stripe.SubscriptionItemList{
+						Data: []*stripe.SubscriptionItem{&stripe.SubscriptionItem{}},
+					},
+				},
+			},
+			exp: tcExpected{err: errStripeNoSubItems},
+		},
+
+		{
+			name: "valid",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{
+					Items: &stripe.SubscriptionItemList{
+						Data: []*stripe.SubscriptionItem{
+							&stripe.SubscriptionItem{
+								Price: &stripe.Price{ID: "price_id"},
+							},
+						},
+					},
+				},
+			},
+			exp: tcExpected{val: "price_id"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.given.priceID()
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestStripeNotification_shouldRefund(t *testing.T) {
+	tests := []struct {
+		name  string
+		given *stripeNotification
+		exp   bool
+	}{
+		{
+			name: "no_charge_correct_type",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "charge.refunded"},
+			},
+		},
+
+		{
+			name: "charge_wrong_type",
+			given: &stripeNotification{
+				raw:    &stripe.Event{Type: "charge.succeeded"},
+				charge: &stripe.Charge{},
+			},
+		},
+
+		{
+			name: "refund",
+			given: &stripeNotification{
+				raw:    &stripe.Event{Type: "charge.refunded"},
+				charge: &stripe.Charge{},
+			},
+			exp: true,
+		},
+
+		{
+			name: "chargeback",
+			given: &stripeNotification{
+				raw:     &stripe.Event{Type: "charge.dispute.created"},
+				dispute: &stripe.Dispute{},
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.given.shouldRefund()
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
+
+func TestStripeNotification_chargeID(t *testing.T) {
+	type tcExpected struct {
+		val string
+		err error
+	}
+
+	tests := []struct {
+		name  string
+		given *stripeNotification
+		exp   tcExpected
+	}{
+		{
+			name:  "unsupported",
+			given: &stripeNotification{raw: &stripe.Event{Type: "invoice.paid"}, invoice: &stripe.Invoice{}},
+			exp:   tcExpected{err: errStripeUnsupportedEvent},
+		},
+
+		{
+			name:  "charge",
+			given: &stripeNotification{charge: &stripe.Charge{ID: "ch_id"}},
+			exp:   tcExpected{val: "ch_id"},
+		},
+
+		{
+			name:  "dispute_no_charge",
+			given: &stripeNotification{dispute: &stripe.Dispute{ID: "dp_id"}},
+			exp:   tcExpected{err: errStripeNoDisputeCharge},
+		},
+
+		{
+			name:  "dispute",
+			given: &stripeNotification{dispute: &stripe.Dispute{ID: "dp_id", Charge: &stripe.Charge{ID: "ch_id"}}},
+			exp:   tcExpected{val: "ch_id"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.given.chargeID()
+			should.Equal(t, tc.exp.err, err)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestStripeNotification_refundTxn(t *testing.T) {
+	oid := uuid.Must(uuid.FromString("facade00-0000-4000-a000-000000000000"))
+
+	type tcExpected struct {
+		val model.TransactionNew
+		err error
+	}
+
+	tests := []struct {
+		name  string
+		given *stripeNotification
+		exp   tcExpected
+	}{
+		{
+			name:  "unsupported",
+			given: &stripeNotification{invoice: &stripe.Invoice{}},
+			exp:   tcExpected{err: errStripeUnsupportedEvent},
+		},
+
+		{
+			name: "charge_no_refunds",
+			given: &stripeNotification{
+				charge: &stripe.Charge{ID: "ch_id", Currency: "usd", AmountRefunded: 999},
+			},
+			exp: tcExpected{
+				val: model.TransactionNew{
+					OrderID:               oid,
+					ExternalTransactionID: "ch_id",
+					Status:                model.TransactionStatusCompleted,
+					Currency:              "USD",
+					Kind:                  model.TransactionKindRefund,
+					Amount:                decimal.New(-999, -2),
+				},
+			},
+		},
+
+		{
+			name: "charge_latest_refund",
+			given: &stripeNotification{
+				charge: &stripe.Charge{
+					ID:             "ch_id",
+					Currency:       "usd",
+					AmountRefunded: 999,
+					Refunds: &stripe.RefundList{
+						Data: []*stripe.Refund{{ID: "re_id", Amount: 500}},
+					},
+				},
+			},
+			exp: tcExpected{
+				val: model.TransactionNew{
+					OrderID:               oid,
+					ExternalTransactionID: "re_id",
+					Status:                model.TransactionStatusCompleted,
+					Currency:              "USD",
+					Kind:                  model.TransactionKindRefund,
+					Amount:                decimal.New(-500, -2),
+				},
+			},
+		},
+
+		{
+			name: "charge_zero_decimal",
+			given: &stripeNotification{
+				charge: &stripe.Charge{ID: "ch_id", Currency: "jpy", AmountRefunded: 1500},
+			},
+			exp: tcExpected{
+				val: model.TransactionNew{
+					OrderID:               oid,
+					ExternalTransactionID: "ch_id",
+					Status:                model.TransactionStatusCompleted,
+					Currency:              "JPY",
+					Kind:                  model.TransactionKindRefund,
+					Amount:                decimal.New(-1500, 0),
+				},
+			},
+		},
+
+		{
+			name: "dispute",
+			given: &stripeNotification{
+				dispute: &stripe.Dispute{ID: "dp_id", Currency: "eur", Amount: 1000},
+			},
+			exp: tcExpected{
+				val: model.TransactionNew{
+					OrderID:               oid,
+					ExternalTransactionID: "dp_id",
+					Status:                model.TransactionStatusCompleted,
+					Currency:              "EUR",
+					Kind:                  model.TransactionKindChargeback,
+					Amount:                decimal.New(-1000, -2),
+				},
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.given.refundTxn(oid)
+			should.Equal(t, tc.exp.err, err)
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestStripeNotification_isFullRefund(t *testing.T) {
+	type tcGiven struct {
+		ntf  *stripeNotification
+		chrg *stripe.Charge
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   bool
+	}
+
+	tests := []testCase{
+		{
+			name: "unsupported",
+			given: tcGiven{
+				ntf:  &stripeNotification{invoice: &stripe.Invoice{}},
+				chrg: &stripe.Charge{Amount: 999},
+			},
+		},
+
+		{
+			name: "charge_partial",
+			given: tcGiven{
+				ntf:  &stripeNotification{charge: &stripe.Charge{Amount: 999, AmountRefunded: 500}},
+				chrg: &stripe.Charge{Amount: 999},
+			},
+		},
+
+		{
+			name: "charge_full",
+			given: tcGiven{
+				ntf:  &stripeNotification{charge: &stripe.Charge{Amount: 999, AmountRefunded: 999, Refunded: true}},
+				chrg: &stripe.Charge{Amount: 999},
+			},
+			exp: true,
+		},
+
+		{
+			name: "dispute_partial",
+			given: tcGiven{
+				ntf:  &stripeNotification{dispute: &stripe.Dispute{Amount: 500}},
+				chrg: &stripe.Charge{Amount: 999},
+			},
+		},
+
+		{
+			name: "dispute_full",
+			given: tcGiven{
+				ntf:  &stripeNotification{dispute: &stripe.Dispute{Amount: 999}},
+				chrg: &stripe.Charge{Amount: 999},
+			},
+			exp: true,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, tc.given.ntf.isFullRefund(tc.given.chrg))
+		})
+	}
+}
+
 func TestStripeNotification_shouldRecordPayFailure(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -447,6 +833,24 @@ func TestStripeNotification_effect(t *testing.T) {
 			exp: "record_payment_failure",
 		},
 
+		{
+			name: "change_plan",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{},
+			},
+			exp: "change_plan",
+		},
+
+		{
+			name: "refund",
+			given: &stripeNotification{
+				raw:    &stripe.Event{Type: "charge.refunded"},
+				charge: &stripe.Charge{},
+			},
+			exp: "refund",
+		},
+
 		{
 			name: "skip",
 			given: &stripeNotification{
@@ -775,6 +1179,28 @@ func TestStripeNotification_expiresTime(t *testing.T) {
 				val: time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC),
 			},
 		},
+
+		{
+			name: "sub_zero_period",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{},
+			},
+			exp: tcExpected{
+				err: errStripeInvalidSubPeriod,
+			},
+		},
+
+		{
+			name: "sub_valid",
+			given: &stripeNotification{
+				raw: &stripe.Event{Type: "customer.subscription.updated"},
+				sub: &stripe.Subscription{CurrentPeriodEnd: 1722470400},
+			},
+			exp: tcExpected{
+				val: time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
 	}
 
 	for i := range tests {
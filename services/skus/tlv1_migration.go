@@ -0,0 +1,249 @@
+package skus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/libs/handlers"
+	timeutils "github.com/brave-intl/bat-go/libs/time"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+// tlv1UsageReportDays is the default period covered by the time limited v1 usage report.
+const tlv1UsageReportDays = 30
+
+type tlv1PresentationKey struct {
+	merchID string
+	sku     string
+	day     string
+}
+
+// tlv1PresentationBuffer counts presented time limited v1 credentials in memory until they are written.
+//
+// There is one entry per merchant, sku and day, so the buffer stays small however many credentials are presented.
+type tlv1PresentationBuffer struct {
+	mu      sync.Mutex
+	entries map[tlv1PresentationKey]model.TLV1Presentation
+}
+
+func newTLV1PresentationBuffer() *tlv1PresentationBuffer {
+	return &tlv1PresentationBuffer{entries: make(map[tlv1PresentationKey]model.TLV1Presentation)}
+}
+
+// add counts a credential presented for the merchant and sku at now.
+func (b *tlv1PresentationBuffer) add(merchID, sku string, now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.merge(model.TLV1Presentation{MerchantID: merchID, SKU: sku, NumPresented: 1, LastPresentedAt: now})
+}
+
+// take removes and returns all counts.
+func (b *tlv1PresentationBuffer) take() []model.TLV1Presentation {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := make([]model.TLV1Presentation, 0, len(b.entries))
+	for k := range b.entries {
+		result = append(result, b.entries[k])
+	}
+
+	b.entries = make(map[tlv1PresentationKey]model.TLV1Presentation)
+
+	return result
+}
+
+// putBack adds counts which could not be written back to the buffer.
+func (b *tlv1PresentationBuffer) putBack(ps []model.TLV1Presentation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range ps {
+		b.merge(ps[i])
+	}
+}
+
+func (b *tlv1PresentationBuffer) merge(p model.TLV1Presentation) {
+	key := tlv1PresentationKey{merchID: p.MerchantID, sku: p.SKU, day: p.LastPresentedAt.Format("2006-01-02")}
+
+	cur, ok := b.entries[key]
+	if !ok {
+		b.entries[key] = p
+		return
+	}
+
+	cur.NumPresented += p.NumPresented
+	if p.LastPresentedAt.After(cur.LastPresentedAt) {
+		cur.LastPresentedAt = p.LastPresentedAt
+	}
+
+	b.entries[key] = cur
+}
+
+type tlv1MigrationStore interface {
+	Upsert(ctx context.Context, dbi sqlx.QueryerContext, req *model.TLV1MigrationRequest) (*model.TLV1Migration, error)
+	Get(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error)
+	List(ctx context.Context, dbi sqlx.QueryerContext) ([]model.TLV1Migration, error)
+	Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	RecordPresentation(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error
+	ListUsage(ctx context.Context, dbi sqlx.QueryerContext, from time.Time) ([]model.TLV1Usage, error)
+}
+
+// UpsertTLV1Migration starts or updates the migration of an sku from time limited v1 to time limited v2 credentials.
+func (s *Service) UpsertTLV1Migration(ctx context.Context, req *model.TLV1MigrationRequest) (*model.TLV1Migration, error) {
+	if _, err := timeutils.ParseDuration(req.CredentialValidDurationEach); err != nil {
+		return nil, model.ErrTLV1MigrationInvalid
+	}
+
+	return s.tlv1MigRepo.Upsert(ctx, s.Datastore.RawDB(), req)
+}
+
+func (s *Service) ListTLV1Migrations(ctx context.Context) ([]model.TLV1Migration, error) {
+	return s.tlv1MigRepo.List(ctx, s.Datastore.RawDB())
+}
+
+// DeleteTLV1Migration stops the migration.
+//
+// Batches minted during the migration stay valid, but no new ones can be minted.
+func (s *Service) DeleteTLV1Migration(ctx context.Context, id uuid.UUID) error {
+	return s.tlv1MigRepo.Delete(ctx, s.Datastore.RawDB(), id)
+}
+
+// RunFlushTLV1PresentationsJob writes the counts of presented time limited v1 credentials.
+//
+// Counts which fail to be written are kept for the next run.
+func (s *Service) RunFlushTLV1PresentationsJob(ctx context.Context) (bool, error) {
+	ps := s.tlv1Presented.take()
+
+	for i := range ps {
+		if err := s.tlv1MigRepo.RecordPresentation(ctx, s.Datastore.RawDB(), ps[i]); err != nil {
+			s.tlv1Presented.putBack(ps[i:])
+
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// TLV1UsageReport returns merchants and skus which have presented time limited v1 credentials since from.
+func (s *Service) TLV1UsageReport(ctx context.Context, from time.Time) (*model.TLV1UsageReport, error) {
+	usage, err := s.tlv1MigRepo.ListUsage(ctx, s.Datastore.RawDB(), from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TLV1UsageReport{Since: from, Usage: usage}, nil
+}
+
+// tlv1Migration returns the migration for the item, or nil if the item is not time limited v1 or its sku is not migrating.
+func (s *Service) tlv1Migration(ctx context.Context, dbi sqlx.QueryerContext, merchID string, item *model.OrderItem) (*model.TLV1Migration, error) {
+	if item.CredentialType != timeLimited {
+		return nil, nil
+	}
+
+	result, err := s.tlv1MigRepo.Get(ctx, dbi, merchID, item.SKU)
+	if err != nil {
+		if errors.Is(err, model.ErrTLV1MigrationNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getTimeLimitedItemCreds returns time limited v2 credentials if the request is for a batch minted during a migration.
+//
+// Otherwise, it returns time limited v1 credentials.
+func (s *Service) getTimeLimitedItemCreds(ctx context.Context, order *Order, item *OrderItem, reqID uuid.UUID) (interface{}, int, error) {
+	ok, err := s.hasMigratedTLV1Batch(ctx, order.MerchantID, item, reqID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if ok {
+		return s.GetTimeLimitedV2Creds(ctx, order.ID, item.ID, reqID)
+	}
+
+	return s.GetTimeLimitedCreds(ctx, order, item.ID, reqID)
+}
+
+func (s *Service) hasMigratedTLV1Batch(ctx context.Context, merchID string, item *OrderItem, reqID uuid.UUID) (bool, error) {
+	dbi := s.Datastore.RawDB()
+
+	mig, err := s.tlv1Migration(ctx, dbi, merchID, item)
+	if err != nil {
+		return false, err
+	}
+
+	if mig == nil {
+		return false, nil
+	}
+
+	if _, err := s.Datastore.GetSigningOrderRequestOutboxByRequestID(ctx, dbi, reqID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// checkTLV1Accepted rejects a time limited v1 credential if its sku no longer accepts them.
+func (s *Service) checkTLV1Accepted(ctx context.Context, req credential) *handlers.AppError {
+	mig, err := s.tlv1MigRepo.Get(ctx, s.Datastore.RawDB(), req.GetMerchantID(), req.GetSKU())
+	if err != nil {
+		if errors.Is(err, model.ErrTLV1MigrationNotFound) {
+			return nil
+		}
+
+		return handlers.WrapError(err, "failed to get tlv1 migration", http.StatusInternalServerError)
+	}
+
+	if !mig.AcceptsV1(time.Now().UTC()) {
+		return handlers.WrapError(model.ErrTLV1NotAccepted, "Time limited v1 credentials are no longer accepted", http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// migratedTLV1Item returns a copy of the time limited v1 item which mints time limited v2 credentials.
+func migratedTLV1Item(item *model.OrderItem, mig *model.TLV1Migration) *model.OrderItem {
+	result := *item
+	result.CredentialType = timeLimitedV2
+	result.EachCredentialValidForISO = &mig.CredentialValidDurationEach
+
+	return &result
+}
+
+// checkNumMigratedBlindedCreds checks the number of blinded credentials for a time limited v1 item of a migrating sku.
+//
+// Orders with such items have no numPerInterval and numIntervals, so the limit comes from the migration.
+func checkNumMigratedBlindedCreds(mig *model.TLV1Migration, ncreds int) error {
+	// Two credentials per interval, the same as for time limited v2 orders.
+	if ncreds > 2*mig.NumIntervals() {
+		return errInvalidNCredsTlv2
+	}
+
+	return nil
+}
@@ -0,0 +1,343 @@
+package skus
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/ptr"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestMigratedTLV1Item(t *testing.T) {
+	item := &model.OrderItem{
+		ID:             uuid.Must(uuid.FromString("ad0be000-0000-4000-a000-000000000000")),
+		SKU:            "brave-vpn-premium",
+		CredentialType: timeLimited,
+	}
+
+	mig := &model.TLV1Migration{CredentialValidDurationEach: "P1D", IssuerTokenBuffer: 30, IssuerTokenOverlap: 5}
+
+	actual := migratedTLV1Item(item, mig)
+
+	should.Equal(t, timeLimitedV2, actual.CredentialType)
+	should.Equal(t, ptr.FromString("P1D"), actual.EachCredentialValidForISO)
+	should.Equal(t, item.ID, actual.ID)
+
+	// The original item is not changed.
+	should.Equal(t, timeLimited, item.CredentialType)
+	should.Nil(t, item.EachCredentialValidForISO)
+}
+
+func TestCheckNumMigratedBlindedCreds(t *testing.T) {
+	type testCase struct {
+		name  string
+		given int
+		exp   error
+	}
+
+	mig := &model.TLV1Migration{IssuerTokenBuffer: 30, IssuerTokenOverlap: 5}
+
+	tests := []testCase{
+		{
+			name:  "within_limit",
+			given: 70,
+		},
+
+		{
+			name:  "over_limit",
+			given: 71,
+			exp:   errInvalidNCredsTlv2,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, checkNumMigratedBlindedCreds(mig, tc.given))
+		})
+	}
+}
+
+func TestService_tlv1Migration(t *testing.T) {
+	type tcGiven struct {
+		item *model.OrderItem
+		repo *repository.MockTLV1Migration
+	}
+
+	type tcExpected struct {
+		val *model.TLV1Migration
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	mig := &model.TLV1Migration{MerchantID: "brave.com", SKU: "brave-vpn-premium"}
+
+	tests := []testCase{
+		{
+			name: "not_tlv1",
+			given: tcGiven{
+				item: &model.OrderItem{SKU: "brave-vpn-premium", CredentialType: timeLimitedV2},
+				repo: &repository.MockTLV1Migration{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+						return mig, nil
+					},
+				},
+			},
+		},
+
+		{
+			name: "not_migrating",
+			given: tcGiven{
+				item: &model.OrderItem{SKU: "brave-vpn-premium", CredentialType: timeLimited},
+				repo: &repository.MockTLV1Migration{},
+			},
+		},
+
+		{
+			name: "error",
+			given: tcGiven{
+				item: &model.OrderItem{SKU: "brave-vpn-premium", CredentialType: timeLimited},
+				repo: &repository.MockTLV1Migration{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+						return nil, model.Error("something went wrong")
+					},
+				},
+			},
+			exp: tcExpected{err: model.Error("something went wrong")},
+		},
+
+		{
+			name: "migrating",
+			given: tcGiven{
+				item: &model.OrderItem{SKU: "brave-vpn-premium", CredentialType: timeLimited},
+				repo: &repository.MockTLV1Migration{
+					FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+						if merchID != "brave.com" || sku != "brave-vpn-premium" {
+							return nil, model.ErrTLV1MigrationNotFound
+						}
+
+						return mig, nil
+					},
+				},
+			},
+			exp: tcExpected{val: mig},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{tlv1MigRepo: tc.given.repo}
+
+			actual, err := svc.tlv1Migration(context.Background(), nil, "brave.com", tc.given.item)
+			must.Equal(t, tc.exp.err, err)
+
+			should.Equal(t, tc.exp.val, actual)
+		})
+	}
+}
+
+func TestService_checkTLV1Accepted(t *testing.T) {
+	type tcGiven struct {
+		mig *model.TLV1Migration
+		err error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   int
+	}
+
+	now := time.Now()
+
+	tests := []testCase{
+		{
+			name: "not_migrating",
+		},
+
+		{
+			name:  "within_window",
+			given: tcGiven{mig: &model.TLV1Migration{V1AcceptedUntil: ptr.FromTime(now.Add(time.Hour))}},
+		},
+
+		{
+			name:  "get_error",
+			given: tcGiven{err: model.Error("something went wrong")},
+			exp:   http.StatusInternalServerError,
+		},
+
+		{
+			name:  "window_ended",
+			given: tcGiven{mig: &model.TLV1Migration{V1AcceptedUntil: ptr.FromTime(now.Add(-time.Hour))}},
+			exp:   http.StatusForbidden,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &repository.MockTLV1Migration{
+				FnRecordPresentation: func(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error {
+					t.Fatal("presentations must not be recorded before verification")
+
+					return nil
+				},
+
+				FnGet: func(ctx context.Context, dbi sqlx.QueryerContext, merchID, sku string) (*model.TLV1Migration, error) {
+					should.Equal(t, "brave.com", merchID)
+					should.Equal(t, "brave-vpn-premium", sku)
+
+					if tc.given.err != nil {
+						return nil, tc.given.err
+					}
+
+					if tc.given.mig == nil {
+						return nil, model.ErrTLV1MigrationNotFound
+					}
+
+					return tc.given.mig, nil
+				},
+			}
+
+			svc := &Service{Datastore: &Postgres{}, tlv1MigRepo: repo}
+
+			req := &model.VerifyCredentialRequestV1{Type: timeLimited, MerchantID: "brave.com", SKU: "brave-vpn-premium"}
+
+			aerr := svc.checkTLV1Accepted(context.Background(), req)
+			if tc.exp == 0 {
+				should.Nil(t, aerr)
+				return
+			}
+
+			must.NotNil(t, aerr)
+			should.Equal(t, tc.exp, aerr.Code)
+		})
+	}
+}
+
+func TestTLV1PresentationBuffer(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+
+	buf := newTLV1PresentationBuffer()
+	buf.add("brave.com", "brave-vpn-premium", now)
+	buf.add("brave.com", "brave-vpn-premium", now.Add(time.Hour))
+	buf.add("brave.com", "brave-vpn-premium", now.AddDate(0, 0, 1))
+	buf.add("brave.com", "brave-search-premium", now)
+
+	actual := buf.take()
+	sort.Slice(actual, func(i, j int) bool {
+		if actual[i].SKU != actual[j].SKU {
+			return actual[i].SKU < actual[j].SKU
+		}
+
+		return actual[i].LastPresentedAt.Before(actual[j].LastPresentedAt)
+	})
+
+	should.Equal(t, []model.TLV1Presentation{
+		{MerchantID: "brave.com", SKU: "brave-search-premium", NumPresented: 1, LastPresentedAt: now},
+		{MerchantID: "brave.com", SKU: "brave-vpn-premium", NumPresented: 2, LastPresentedAt: now.Add(time.Hour)},
+		{MerchantID: "brave.com", SKU: "brave-vpn-premium", NumPresented: 1, LastPresentedAt: now.AddDate(0, 0, 1)},
+	}, actual)
+
+	should.Len(t, buf.take(), 0)
+
+	buf.add("brave.com", "brave-vpn-premium", now)
+	buf.putBack([]model.TLV1Presentation{{MerchantID: "brave.com", SKU: "brave-vpn-premium", NumPresented: 3, LastPresentedAt: now.Add(time.Hour)}})
+
+	should.Equal(t, []model.TLV1Presentation{
+		{MerchantID: "brave.com", SKU: "brave-vpn-premium", NumPresented: 4, LastPresentedAt: now.Add(time.Hour)},
+	}, buf.take())
+
+	var empty *tlv1PresentationBuffer
+	empty.add("brave.com", "brave-vpn-premium", now)
+	should.Len(t, empty.take(), 0)
+}
+
+func TestService_RunFlushTLV1PresentationsJob(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+
+	type tcGiven struct {
+		err error
+	}
+
+	type tcExpected struct {
+		recorded int
+		left     int64
+		err      error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "recorded",
+			exp:  tcExpected{recorded: 1},
+		},
+
+		{
+			name:  "error_keeps_counts",
+			given: tcGiven{err: model.Error("something went wrong")},
+			exp:   tcExpected{recorded: 1, left: 2, err: model.Error("something went wrong")},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var recorded int
+
+			repo := &repository.MockTLV1Migration{
+				FnRecordPresentation: func(ctx context.Context, dbi sqlx.ExecerContext, p model.TLV1Presentation) error {
+					should.Equal(t, int64(2), p.NumPresented)
+
+					recorded++
+
+					return tc.given.err
+				},
+			}
+
+			buf := newTLV1PresentationBuffer()
+			buf.add("brave.com", "brave-vpn-premium", now)
+			buf.add("brave.com", "brave-vpn-premium", now)
+
+			svc := &Service{Datastore: &Postgres{}, tlv1MigRepo: repo, tlv1Presented: buf}
+
+			actual, err := svc.RunFlushTLV1PresentationsJob(context.Background())
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.False(t, actual)
+			should.Equal(t, tc.exp.recorded, recorded)
+
+			var left int64
+			for _, p := range buf.take() {
+				left += p.NumPresented
+			}
+
+			should.Equal(t, tc.exp.left, left)
+		})
+	}
+}
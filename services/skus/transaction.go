@@ -1,21 +1,8 @@
 package skus
 
 import (
-	"time"
-
-	uuid "github.com/satori/go.uuid"
-	"github.com/shopspring/decimal"
+	"github.com/brave-intl/bat-go/services/skus/model"
 )
 
 // Transaction includes information about a particular order. Status can be pending, failure, completed, or error.
-type Transaction struct {
-	ID                    uuid.UUID       `json:"id" db:"id"`
-	OrderID               uuid.UUID       `json:"orderId" db:"order_id"`
-	CreatedAt             time.Time       `json:"createdAt" db:"created_at"`
-	UpdatedAt             time.Time       `json:"updatedAt" db:"updated_at"`
-	ExternalTransactionID string          `json:"externalTransactionId" db:"external_transaction_id"`
-	Status                string          `json:"status" db:"status"`
-	Currency              string          `json:"currency" db:"currency"`
-	Kind                  string          `json:"kind" db:"kind"`
-	Amount                decimal.Decimal `json:"amount" db:"amount"`
-}
+type Transaction = model.Transaction
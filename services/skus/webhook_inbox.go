@@ -0,0 +1,159 @@
+package skus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/brave-intl/bat-go/libs/logging"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+)
+
+const (
+	errWebhookInboxUnknownVendor = model.Error("webhook inbox: unknown vendor")
+
+	// webhookInboxLease is how long a claimed entry is hidden from other workers.
+	webhookInboxLease = 5 * time.Minute
+
+	webhookInboxRetryBase   = 30 * time.Second
+	webhookInboxRetryMax    = 6 * time.Hour
+	webhookInboxMaxAttempts = 12
+)
+
+// storeWebhookNotification durably stores a verified notification for asynchronous processing.
+//
+// It returns model.ErrWebhookInboxEntryExists if ntf is a redelivery of a stored notification.
+func (s *Service) storeWebhookNotification(ctx context.Context, vendor string, ntf PaymentNotification, payload string) (*model.WebhookInboxEntry, error) {
+	req := model.WebhookInboxEntryNew{
+		Vendor:   vendor,
+		EventID:  ntf.eventID(),
+		OrderKey: ntf.orderKey(),
+		Payload:  payload,
+	}
+
+	return s.webhookInboxRepo.Insert(ctx, s.Datastore.RawDB(), req)
+}
+
+// replayWebhookNotification schedules the entry for immediate processing, regardless of its status.
+func (s *Service) replayWebhookNotification(ctx context.Context, id uuid.UUID) (*model.WebhookInboxEntry, error) {
+	tx, err := s.Datastore.RawDB().BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.webhookInboxRepo.Reset(ctx, tx, id, time.Now()); err != nil {
+		return nil, err
+	}
+
+	result, err := s.webhookInboxRepo.Get(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RunNextWebhookInboxJob processes the next due notification from the inbox.
+//
+// Failed attempts are retried with exponential backoff.
+// An entry is dead-lettered when it can't be parsed, or when it has failed webhookInboxMaxAttempts times.
+func (s *Service) RunNextWebhookInboxJob(ctx context.Context) (bool, error) {
+	dbi := s.Datastore.RawDB()
+	now := time.Now()
+
+	entry, err := s.webhookInboxRepo.ClaimNext(ctx, dbi, now, now.Add(webhookInboxLease))
+	if err != nil {
+		if errors.Is(err, model.ErrWebhookInboxEntryNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	lg := logging.Logger(ctx, "skus").With().Str("func", "RunNextWebhookInboxJob").Str("entry_id", entry.ID.String()).Str("vendor", entry.Vendor).Int("num_attempts", entry.NumAttempts+1).Logger()
+
+	process, err := s.newWebhookInboxProcessFn(entry)
+	if err != nil {
+		lg.Err(err).Msg("failed to parse webhook notification")
+
+		return true, s.webhookInboxRepo.MarkDead(ctx, dbi, entry.ID, err.Error())
+	}
+
+	perr := process(ctx)
+	if perr != nil {
+		lg.Warn().Err(perr).Msg("failed to process webhook notification")
+	}
+
+	return true, s.recordWebhookInboxOutcome(ctx, dbi, entry, perr, time.Now())
+}
+
+// recordWebhookInboxOutcome updates the entry according to the result of processing it.
+func (s *Service) recordWebhookInboxOutcome(ctx context.Context, dbi sqlx.ExecerContext, entry *model.WebhookInboxEntry, perr error, now time.Time) error {
+	switch {
+	// No rows changed means the same event has already been applied.
+	case perr == nil, errors.Is(perr, model.ErrNoRowsChangedOrder):
+		return s.webhookInboxRepo.MarkProcessed(ctx, dbi, entry.ID, now)
+
+	// The order was not found, so nothing can be done.
+	// A renewal might arrive before the user has linked their subscription, the grace period handles the gap.
+	case errors.Is(perr, model.ErrOrderNotFound), errors.Is(perr, errNotFound):
+		return s.webhookInboxRepo.MarkProcessed(ctx, dbi, entry.ID, now)
+
+	case entry.NumAttempts+1 >= webhookInboxMaxAttempts:
+		return s.webhookInboxRepo.MarkDead(ctx, dbi, entry.ID, perr.Error())
+
+	default:
+		nextAt := now.Add(webhookInboxRetryDelay(entry.NumAttempts))
+
+		return s.webhookInboxRepo.MarkFailed(ctx, dbi, entry.ID, perr.Error(), nextAt)
+	}
+}
+
+// newWebhookInboxProcessFn parses the entry's payload and returns a function which processes it.
+//
+// The payload has been authenticated when it was received, so it's only parsed here.
+// The App Store is an exception, as parsing and verification are inseparable for it.
+func (s *Service) newWebhookInboxProcessFn(entry *model.WebhookInboxEntry) (func(ctx context.Context) error, error) {
+	proc, ok := s.payProcs.get(entry.Vendor)
+	if !ok {
+		return nil, errWebhookInboxUnknownVendor
+	}
+
+	ntf, err := proc.ParseNotification([]byte(entry.Payload))
+	if err != nil {
+		if errors.Is(err, errPaymentNtfSkip) {
+			return func(_ context.Context) error { return nil }, nil
+		}
+
+		return nil, err
+	}
+
+	return func(ctx context.Context) error { return s.processPaymentNotification(ctx, proc, ntf) }, nil
+}
+
+// webhookInboxRetryDelay returns the delay before the next attempt given the number of previous attempts.
+func webhookInboxRetryDelay(numAttempts int) time.Duration {
+	if numAttempts < 0 {
+		numAttempts = 0
+	}
+
+	result := webhookInboxRetryBase
+	for i := 0; i < numAttempts; i++ {
+		result *= 2
+
+		if result >= webhookInboxRetryMax {
+			return webhookInboxRetryMax
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,263 @@
+package skus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/services/skus/model"
+	"github.com/brave-intl/bat-go/services/skus/storage/repository"
+)
+
+func TestService_recordWebhookInboxOutcome(t *testing.T) {
+	type tcGiven struct {
+		entry *model.WebhookInboxEntry
+		perr  error
+		now   time.Time
+	}
+
+	type tcExpected struct {
+		status  string
+		lastErr string
+		nextAt  time.Time
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "processed",
+			given: tcGiven{
+				entry: &model.WebhookInboxEntry{ID: uuid.NewV4()},
+				now:   time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{status: model.WebhookInboxStatusProcessed},
+		},
+
+		{
+			name: "processed_no_rows_changed",
+			given: tcGiven{
+				entry: &model.WebhookInboxEntry{ID: uuid.NewV4()},
+				perr:  model.ErrNoRowsChangedOrder,
+				now:   time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{status: model.WebhookInboxStatusProcessed},
+		},
+
+		{
+			name: "processed_order_not_found",
+			given: tcGiven{
+				entry: &model.WebhookInboxEntry{ID: uuid.NewV4(), NumAttempts: 2},
+				perr:  model.ErrOrderNotFound,
+				now:   time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{status: model.WebhookInboxStatusProcessed},
+		},
+
+		{
+			name: "retry",
+			given: tcGiven{
+				entry: &model.WebhookInboxEntry{ID: uuid.NewV4(), NumAttempts: 2},
+				perr:  model.Error("something went wrong"),
+				now:   time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				status:  model.WebhookInboxStatusPending,
+				lastErr: "something went wrong",
+				nextAt:  time.Date(2024, time.January, 1, 0, 2, 1, 0, time.UTC),
+			},
+		},
+
+		{
+			name: "dead_max_attempts",
+			given: tcGiven{
+				entry: &model.WebhookInboxEntry{ID: uuid.NewV4(), NumAttempts: webhookInboxMaxAttempts - 1},
+				perr:  model.Error("something went wrong"),
+				now:   time.Date(2024, time.January, 1, 0, 0, 1, 0, time.UTC),
+			},
+			exp: tcExpected{
+				status:  model.WebhookInboxStatusDead,
+				lastErr: "something went wrong",
+			},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := &model.WebhookInboxEntry{Status: model.WebhookInboxStatusPending}
+
+			svc := &Service{
+				webhookInboxRepo: &repository.MockWebhookInbox{
+					FnMarkProcessed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, when time.Time) error {
+						should.Equal(t, tc.given.entry.ID, id)
+						should.Equal(t, tc.given.now, when)
+
+						actual.Status = model.WebhookInboxStatusProcessed
+
+						return nil
+					},
+
+					FnMarkFailed: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string, nextAt time.Time) error {
+						should.Equal(t, tc.given.entry.ID, id)
+
+						actual.LastError = &lastErr
+						actual.NextAttemptAt = nextAt
+
+						return nil
+					},
+
+					FnMarkDead: func(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID, lastErr string) error {
+						should.Equal(t, tc.given.entry.ID, id)
+
+						actual.Status = model.WebhookInboxStatusDead
+						actual.LastError = &lastErr
+
+						return nil
+					},
+				},
+			}
+
+			err := svc.recordWebhookInboxOutcome(context.Background(), nil, tc.given.entry, tc.given.perr, tc.given.now)
+			must.Equal(t, nil, err)
+
+			should.Equal(t, tc.exp.status, actual.Status)
+			should.Equal(t, tc.exp.nextAt, actual.NextAttemptAt)
+
+			if tc.exp.lastErr == "" {
+				should.Nil(t, actual.LastError)
+				return
+			}
+
+			must.NotNil(t, actual.LastError)
+			should.Equal(t, tc.exp.lastErr, *actual.LastError)
+		})
+	}
+}
+
+func TestService_newWebhookInboxProcessFn(t *testing.T) {
+	type tcExpected struct {
+		err     error
+		mustErr bool
+	}
+
+	type testCase struct {
+		name  string
+		given *model.WebhookInboxEntry
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "unknown_vendor",
+			given: &model.WebhookInboxEntry{Vendor: "something_else", Payload: "{}"},
+			exp:   tcExpected{err: errWebhookInboxUnknownVendor, mustErr: true},
+		},
+
+		{
+			name:  "stripe_invalid_payload",
+			given: &model.WebhookInboxEntry{Vendor: model.WebhookVendorStripe, Payload: "not_json"},
+			exp:   tcExpected{mustErr: true},
+		},
+
+		{
+			name:  "stripe_skip_event",
+			given: &model.WebhookInboxEntry{Vendor: model.WebhookVendorStripe, Payload: `{"type": "customer.created"}`},
+		},
+
+		{
+			name:  "radom_invalid_payload",
+			given: &model.WebhookInboxEntry{Vendor: model.WebhookVendorRadom, Payload: "not_json"},
+			exp:   tcExpected{mustErr: true},
+		},
+
+		{
+			name:  "radom_should_not_process",
+			given: &model.WebhookInboxEntry{Vendor: model.WebhookVendorRadom, Payload: "{}"},
+		},
+
+		{
+			name:  "app_store_invalid_payload",
+			given: &model.WebhookInboxEntry{Vendor: model.WebhookVendorAppStore, Payload: "not_jws"},
+			exp:   tcExpected{mustErr: true},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{
+				payProcs: paymentProcessors{&stripeProcessor{}, &radomProcessor{}, &playStoreProcessor{}, &appStoreProcessor{}},
+			}
+
+			fn, err := svc.newWebhookInboxProcessFn(tc.given)
+			if tc.exp.mustErr {
+				must.Error(t, err)
+
+				if tc.exp.err != nil {
+					should.ErrorIs(t, err, tc.exp.err)
+				}
+
+				return
+			}
+
+			must.Equal(t, nil, err)
+
+			should.Equal(t, nil, fn(context.Background()))
+		})
+	}
+}
+
+func TestWebhookInboxRetryDelay(t *testing.T) {
+	type testCase struct {
+		name  string
+		given int
+		exp   time.Duration
+	}
+
+	tests := []testCase{
+		{
+			name:  "negative",
+			given: -1,
+			exp:   30 * time.Second,
+		},
+
+		{
+			name:  "first",
+			given: 0,
+			exp:   30 * time.Second,
+		},
+
+		{
+			name:  "third",
+			given: 2,
+			exp:   2 * time.Minute,
+		},
+
+		{
+			name:  "capped",
+			given: 100,
+			exp:   6 * time.Hour,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual := webhookInboxRetryDelay(tc.given)
+			should.Equal(t, tc.exp, actual)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package xstripe
+
+import "strings"
+
+// currencyExponents holds the number of decimal places of currencies which do not have two in Stripe amounts.
+//
+// Stripe represents ISK and UGX as two-decimal for backwards compatibility, although they have none.
+var currencyExponents = map[string]int32{
+	"BHD": 3,
+	"BIF": 0,
+	"CLP": 0,
+	"DJF": 0,
+	"GNF": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KMF": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MGA": 0,
+	"OMR": 3,
+	"PYG": 0,
+	"RWF": 0,
+	"TND": 3,
+	"VND": 0,
+	"VUV": 0,
+	"XAF": 0,
+	"XOF": 0,
+	"XPF": 0,
+}
+
+// CurrencyExponent returns the number of decimal places in the smallest unit in which Stripe expresses amounts of the currency.
+//
+// The currency code is case-insensitive, as Stripe reports it in lower case.
+func CurrencyExponent(currency string) int32 {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+
+	return 2
+}
@@ -0,0 +1,31 @@
+package xstripe
+
+import (
+	"testing"
+
+	should "github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyExponent(t *testing.T) {
+	type testCase struct {
+		given string
+		exp   int32
+	}
+
+	tests := []testCase{
+		{given: "usd", exp: 2},
+		{given: "EUR", exp: 2},
+		{given: "jpy", exp: 0},
+		{given: "KRW", exp: 0},
+		{given: "isk", exp: 2},
+		{given: "kwd", exp: 3},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.given, func(t *testing.T) {
+			should.Equal(t, tc.exp, CurrencyExponent(tc.given))
+		})
+	}
+}
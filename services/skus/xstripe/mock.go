@@ -10,7 +10,14 @@ type MockClient struct {
 	FnSession       func(ctx context.Context, id string, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
 	FnCreateSession func(ctx context.Context, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error)
 	FnSubscription  func(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	FnCancelSub     func(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error)
+	FnCharge        func(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error)
 	FnFindCustomer  func(ctx context.Context, email string) (*stripe.Customer, bool)
+
+	FnUpdateSub             func(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error)
+	FnCustomerSubs          func(ctx context.Context, custID string) ([]*stripe.Subscription, error)
+	FnFindCustomers         func(ctx context.Context, email string) ([]*stripe.Customer, error)
+	FnCreateBillingPortSess func(ctx context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error)
 }
 
 func (c *MockClient) Session(ctx context.Context, id string, params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
@@ -62,6 +69,31 @@ func (c *MockClient) Subscription(ctx context.Context, id string, params *stripe
 	return c.FnSubscription(ctx, id, params)
 }
 
+func (c *MockClient) CancelSubscription(ctx context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+	if c.FnCancelSub == nil {
+		result := &stripe.Subscription{
+			ID:     id,
+			Status: stripe.SubscriptionStatusCanceled,
+		}
+
+		return result, nil
+	}
+
+	return c.FnCancelSub(ctx, id, params)
+}
+
+func (c *MockClient) Charge(ctx context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+	if c.FnCharge == nil {
+		result := &stripe.Charge{
+			ID: id,
+		}
+
+		return result, nil
+	}
+
+	return c.FnCharge(ctx, id, params)
+}
+
 func (c *MockClient) FindCustomer(ctx context.Context, email string) (*stripe.Customer, bool) {
 	if c.FnFindCustomer == nil {
 		result := &stripe.Customer{
@@ -74,3 +106,51 @@ func (c *MockClient) FindCustomer(ctx context.Context, email string) (*stripe.Cu
 
 	return c.FnFindCustomer(ctx, email)
 }
+
+func (c *MockClient) UpdateSubscription(ctx context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	if c.FnUpdateSub == nil {
+		result := &stripe.Subscription{
+			ID: id,
+		}
+
+		if params != nil && params.CancelAtPeriodEnd != nil {
+			result.CancelAtPeriodEnd = *params.CancelAtPeriodEnd
+		}
+
+		return result, nil
+	}
+
+	return c.FnUpdateSub(ctx, id, params)
+}
+
+func (c *MockClient) CustomerSubscriptions(ctx context.Context, custID string) ([]*stripe.Subscription, error) {
+	if c.FnCustomerSubs == nil {
+		return nil, nil
+	}
+
+	return c.FnCustomerSubs(ctx, custID)
+}
+
+func (c *MockClient) FindCustomers(ctx context.Context, email string) ([]*stripe.Customer, error) {
+	if c.FnFindCustomers == nil {
+		result := []*stripe.Customer{{ID: "cus_id", Email: email}}
+
+		return result, nil
+	}
+
+	return c.FnFindCustomers(ctx, email)
+}
+
+func (c *MockClient) CreateBillingPortalSession(ctx context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error) {
+	if c.FnCreateBillingPortSess == nil {
+		result := &stripe.BillingPortalSession{
+			ID:       "bps_id",
+			Customer: *params.Customer,
+			URL:      "https://billing.stripe.com/session/bps_id",
+		}
+
+		return result, nil
+	}
+
+	return c.FnCreateBillingPortSess(ctx, params)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/stripe/stripe-go/v72/customer"
 )
 
+const maxListItems = 100
+
 type Client struct {
 	cl *client.API
 }
@@ -28,6 +30,39 @@ func (c *Client) Subscription(_ context.Context, id string, params *stripe.Subsc
 	return c.cl.Subscriptions.Get(id, params)
 }
 
+func (c *Client) UpdateSubscription(_ context.Context, id string, params *stripe.SubscriptionParams) (*stripe.Subscription, error) {
+	return c.cl.Subscriptions.Update(id, params)
+}
+
+// CustomerSubscriptions returns subscriptions of the customer in any status, up to a reasonable limit.
+func (c *Client) CustomerSubscriptions(_ context.Context, custID string) ([]*stripe.Subscription, error) {
+	params := &stripe.SubscriptionListParams{
+		Customer: custID,
+		Status:   "all",
+	}
+
+	iter := c.cl.Subscriptions.List(params)
+
+	var result []*stripe.Subscription
+	for iter.Next() && len(result) < maxListItems {
+		result = append(result, iter.Subscription())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) CancelSubscription(_ context.Context, id string, params *stripe.SubscriptionCancelParams) (*stripe.Subscription, error) {
+	return c.cl.Subscriptions.Cancel(id, params)
+}
+
+func (c *Client) Charge(_ context.Context, id string, params *stripe.ChargeParams) (*stripe.Charge, error) {
+	return c.cl.Charges.Get(id, params)
+}
+
 func (c *Client) FindCustomer(ctx context.Context, email string) (*stripe.Customer, bool) {
 	iter := c.Customers(ctx, &stripe.CustomerListParams{Email: &email})
 
@@ -38,6 +73,28 @@ func (c *Client) FindCustomer(ctx context.Context, email string) (*stripe.Custom
 	return nil, false
 }
 
+// FindCustomers returns all customers with the given email.
+//
+// Stripe allows more than one customer with the same email address.
+func (c *Client) FindCustomers(ctx context.Context, email string) ([]*stripe.Customer, error) {
+	iter := c.Customers(ctx, &stripe.CustomerListParams{Email: &email})
+
+	var result []*stripe.Customer
+	for iter.Next() && len(result) < maxListItems {
+		result = append(result, iter.Customer())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) CreateBillingPortalSession(_ context.Context, params *stripe.BillingPortalSessionParams) (*stripe.BillingPortalSession, error) {
+	return c.cl.BillingPortalSessions.New(params)
+}
+
 func (c *Client) Customers(_ context.Context, params *stripe.CustomerListParams) *customer.Iter {
 	return c.cl.Customers.List(params)
 }
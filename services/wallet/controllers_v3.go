@@ -4,9 +4,9 @@ import (
 	"crypto"
 	"crypto/ed25519"
 	"database/sql"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -20,8 +20,10 @@ import (
 	"github.com/brave-intl/bat-go/libs/inputs"
 	"github.com/brave-intl/bat-go/libs/logging"
 	"github.com/brave-intl/bat-go/libs/middleware"
+	"github.com/brave-intl/bat-go/libs/responses"
 	walletutils "github.com/brave-intl/bat-go/libs/wallet"
 	"github.com/brave-intl/bat-go/libs/wallet/provider/uphold"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
 	"github.com/brave-intl/bat-go/services/wallet/model"
 	"github.com/go-chi/chi"
 	uuid "github.com/satori/go.uuid"
@@ -159,370 +161,78 @@ func CreateBraveWalletV3(w http.ResponseWriter, r *http.Request) *handlers.AppEr
 	return handlers.RenderContent(ctx, infoToResponseV3(info), w, http.StatusCreated)
 }
 
-// LinkBitFlyerDepositAccountV3 - produces an http handler for the service s which handles deposit account linking of uphold wallets
-func LinkBitFlyerDepositAccountV3(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+// LinkDepositAccountV3 returns a handler which links a rewards wallet to an account with the custodian in the url.
+func LinkDepositAccountV3(s *Service) handlers.AppHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		var (
-			ctx = r.Context()
-			id  = new(inputs.ID)
-			blr = new(BitFlyerLinkingRequest)
-		)
-
-		l := logging.Logger(ctx, "wallet.CreateBitflyerWalletV3")
-
-		// check if we have disabled bitflyer
-		if disableBitflyer, ok := ctx.Value(appctx.DisableBitflyerLinkingCTXKey).(bool); ok && disableBitflyer {
-			return handlers.ValidationError(
-				"Connecting Brave Rewards to Bitflyer is temporarily unavailable.  Please try again later",
-				nil,
-			)
-		}
-
-		if err := inputs.DecodeAndValidateString(ctx, id, chi.URLParam(r, "paymentID")); err != nil {
-			l.Warn().Err(err).Msg("failed to decode and validate paymentID from url")
-			return handlers.ValidationError(
-				"error validating paymentID url parameter",
-				map[string]interface{}{
-					"paymentID": err.Error(),
-				},
-			)
-		}
-
-		// validate payment id matches what was in the http signature
-		signatureID, err := middleware.GetKeyID(r.Context())
-		if err != nil {
-			return handlers.ValidationError(
-				"error validating paymentID url parameter",
-				map[string]interface{}{
-					"paymentID": err.Error(),
-				},
-			)
-		}
-
-		if id.String() != signatureID {
-			return handlers.ValidationError(
-				"paymentId from URL does not match paymentId in http signature",
-				map[string]interface{}{
-					"paymentID": "does not match http signature id",
-				},
-			)
-		}
+		ctx := r.Context()
 
-		// read post body
-		if err := inputs.DecodeAndValidateReader(ctx, blr, r.Body); err != nil {
-			return blr.HandleErrors(err)
+		c, ok := s.custodians.get(chi.URLParam(r, "custodian"))
+		if !ok {
+			return handlers.WrapError(errCustodianNotFound, "custodian not found", http.StatusNotFound)
 		}
 
-		country, err := s.LinkBitFlyerWallet(ctx, *id.UUID(), blr.DepositID, blr.AccountHash)
-		if err != nil {
-			l.Error().Err(err).Str("paymentID", id.String()).Msg("failed to link bitflyer wallet")
-			return handlers.WrapError(err, "error linking wallet", http.StatusBadRequest)
+		if c.IsDisabled(ctx) {
+			return handlers.ValidationError(fmt.Sprintf("Connecting Brave Rewards to %s is temporarily unavailable. Please try again later", capitalize(c.Name())), nil)
 		}
 
-		return handlers.RenderContent(ctx, LinkDepositAccountResponse{
-			GeoCountry: country,
-		}, w, http.StatusOK)
-	}
-}
-
-// LinkZebPayDepositAccountV3 returns a handler which handles deposit account linking of zebpay wallets.
-func LinkZebPayDepositAccountV3(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		ctx := r.Context()
-
-		// Check whether it's disabled.
-		if disable, ok := ctx.Value(appctx.DisableZebPayLinkingCTXKey).(bool); ok && disable {
-			const msg = "Connecting Brave Rewards to ZebPay is temporarily unavailable. Please try again later"
-			return handlers.ValidationError(msg, nil)
-		}
+		l := logging.Logger(ctx, "wallet.LinkDepositAccountV3").With().Str("custodian", c.Name()).Logger()
 
 		id := &inputs.ID{}
-		l := logging.Logger(ctx, "wallet.LinkZebPayDepositAccountV3")
-
 		if err := inputs.DecodeAndValidateString(ctx, id, chi.URLParam(r, "paymentID")); err != nil {
-			l.Warn().Str("paymentID", err.Error()).Msg("failed to decode and validate paymentID from url")
-
-			const msg = "error validating paymentID url parameter"
-			return handlers.ValidationError(msg, map[string]interface{}{"paymentID": err.Error()})
-		}
+			l.Warn().Err(err).Msg("failed to decode and validate paymentID from url")
 
-		// Check that payment id matches what was in the http signature.
-		signatureID, err := middleware.GetKeyID(ctx)
-		if err != nil {
 			const msg = "error validating paymentID url parameter"
 			return handlers.ValidationError(msg, map[string]interface{}{"paymentID": err.Error()})
 		}
 
-		if id.String() != signatureID {
-			const msg = "paymentId from URL does not match paymentId in http signature"
-			return handlers.ValidationError(msg, map[string]interface{}{
-				"paymentID": "does not match http signature id",
-			})
-		}
-
-		zplReq := &ZebPayLinkingRequest{}
-		if err := inputs.DecodeAndValidateReader(ctx, zplReq, r.Body); err != nil {
-			return HandleErrorsZebPay(err)
-		}
-
-		country, err := s.LinkZebPayWallet(ctx, *id.UUID(), zplReq.VerificationToken)
-		if err != nil {
-			l.Error().Err(err).Str("paymentID", id.String()).Msg("failed to link wallet")
-			switch {
-			case errors.Is(err, errorutils.ErrInvalidCountry):
-				return handlers.WrapError(err, "region not supported", http.StatusBadRequest)
-			case errors.Is(err, errZPInvalidKYC):
-				return handlers.WrapError(err, "KYC required", http.StatusForbidden)
-			default:
-				return handlers.WrapError(err, err.Error(), http.StatusBadRequest)
+		switch c.Auth() {
+		case linking.AuthHTTPSignature:
+			// Check that payment id matches what was in the http signature.
+			signatureID, err := middleware.GetKeyID(ctx)
+			if err != nil {
+				const msg = "error validating paymentID url parameter"
+				return handlers.ValidationError(msg, map[string]interface{}{"paymentID": err.Error()})
 			}
-		}
 
-		return handlers.RenderContent(ctx, LinkDepositAccountResponse{
-			GeoCountry: country,
-		}, w, http.StatusOK)
-	}
-}
-
-// LinkGeminiDepositAccountV3 returns an HTTP handler which is responsible for linking a Gemini wallet.
-// This endpoint expects a walletID as part of the URL and takes a verification token which encodes the
-// linking information as well as a recipientID. The recipientID is synonymous with a wallets depositID.
-func LinkGeminiDepositAccountV3(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		var (
-			ctx = r.Context()
-			id  = new(inputs.ID)
-			glr = new(GeminiLinkingRequest)
-		)
-
-		l := logging.Logger(ctx, "wallet.LinkGeminiDepositAccountV3")
-
-		// check if we have disabled Gemini
-		if disableGemini, ok := ctx.Value(appctx.DisableGeminiLinkingCTXKey).(bool); ok && disableGemini {
-			return handlers.ValidationError(
-				"Connecting Brave Rewards to Gemini is temporarily unavailable.  Please try again later",
-				nil,
-			)
-		}
-
-		// get payment id
-		if err := inputs.DecodeAndValidateString(ctx, id, chi.URLParam(r, "paymentID")); err != nil {
-			l.Warn().Str("paymentID", id.String()).Err(err).
-				Msg("failed to decode and validate paymentID from url")
-			return handlers.ValidationError(
-				"error validating paymentID url parameter",
-				map[string]interface{}{
-					"paymentID": err.Error(),
-				},
-			)
-		}
-
-		// validate payment id matches what was in the http signature
-		signatureID, err := middleware.GetKeyID(ctx)
-		if err != nil {
-			l.Warn().Str("paymentID", id.String()).
-				Err(err).Msg("could not get http signing key id from context")
-			return handlers.ValidationError(
-				"error validating paymentID url parameter",
-				map[string]interface{}{
-					"paymentID": err.Error(),
-				},
-			)
-		}
-
-		if id.String() != signatureID {
-			l.Warn().Str("paymentID", id.String()).
-				Msg("id does not match signature id")
-			return handlers.ValidationError(
-				"paymentId from URL does not match paymentId in http signature",
-				map[string]interface{}{
+			if id.String() != signatureID {
+				const msg = "paymentId from URL does not match paymentId in http signature"
+				return handlers.ValidationError(msg, map[string]interface{}{
 					"paymentID": "does not match http signature id",
-				},
-			)
-		}
-
-		// read post body
-		if err := inputs.DecodeAndValidateReader(ctx, glr, r.Body); err != nil {
-			l.Warn().Str("paymentID", id.String()).
-				Err(err).Msg("could not validate request")
-			return glr.HandleErrors(err)
-		}
-
-		country, err := s.LinkGeminiWallet(ctx, *id.UUID(), glr.VerificationToken, glr.DepositID)
-		if err != nil {
-			l.Error().Str("paymentID", id.String()).
-				Err(err).Msg("error linking gemini wallet")
-
-			if errors.Is(err, errorutils.ErrInvalidCountry) {
-				return handlers.WrapError(err, "region not supported", http.StatusBadRequest)
+				})
 			}
 
-			return handlers.WrapError(err, "error linking wallet", http.StatusBadRequest)
-		}
-
-		return handlers.RenderContent(ctx, LinkDepositAccountResponse{
-			GeoCountry: country,
-		}, w, http.StatusOK)
-	}
-}
-
-// LinkUpholdDepositAccountV3 - produces an http handler for the service s which handles deposit account linking of uphold wallets
-func LinkUpholdDepositAccountV3(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		var (
-			ctx = r.Context()
-			id  = new(inputs.ID)
-			cuw = new(LinkUpholdDepositAccountRequest)
-		)
-		// get logger from context
-		l := logging.Logger(ctx, "wallet.LinkUpholdDepositAccountV3")
-
-		// check if we have disabled uphold
-		if disableUphold, ok := ctx.Value(appctx.DisableUpholdLinkingCTXKey).(bool); ok && disableUphold {
-			return handlers.ValidationError(
-				"Connecting Brave Rewards to Uphold is temporarily unavailable.  Please try again later",
-				nil,
-			)
-		}
-
-		// get payment id
-		if err := inputs.DecodeAndValidateString(ctx, id, chi.URLParam(r, "paymentID")); err != nil {
-			l.Warn().Str("paymentID", err.Error()).Msg("failed to decode and validate paymentID from url")
-			return handlers.ValidationError(
-				"error validating paymentID url parameter",
-				map[string]interface{}{
-					"paymentID": err.Error(),
-				},
-			)
-		}
-
-		if err := inputs.DecodeAndValidateReader(ctx, cuw, r.Body); err != nil {
-			return cuw.HandleErrors(err)
-		}
-
-		wallet, err := s.GetWallet(ctx, *id.UUID())
-		if err != nil {
-			l.Err(err).Msg("failed to get wallet")
-
-			return handlers.WrapError(err, "unable to get or create wallets", http.StatusServiceUnavailable)
-		}
-
-		if wallet == nil {
-			l.Err(model.ErrWalletNotFound).Msg("wallet not found")
-
-			return handlers.WrapError(err, "unable to find wallet", http.StatusNotFound)
-		}
-
-		var aa uuid.UUID
-
-		if cuw.AnonymousAddress != "" {
-			aa, err = uuid.FromString(cuw.AnonymousAddress)
-			if err != nil {
-				return handlers.WrapError(err, "error parsing anonymous address", http.StatusBadRequest)
+		case linking.AuthDApp:
+			o := r.Header.Get("Origin")
+			if !isAllowedOrigin(o, s.dappConf.AllowedOrigins) {
+				l.Error().Err(errOriginForbidden).Str("origin", strOr(o, "empty")).Msg("error linking wallet")
+				return handlers.WrapError(errOriginForbidden, "request origin forbidden", http.StatusForbidden)
 			}
 		}
 
-		publicKey, err := hex.DecodeString(wallet.PublicKey)
+		b, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
 		if err != nil {
-			l.Warn().Err(err).Msg("unable to decode wallet public key")
-			return handlers.WrapError(errors.New("unable to decode wallet public key"),
-				"unable to decode wallet public key for creation request validation",
-				http.StatusInternalServerError)
-		}
-		uwallet := uphold.Wallet{
-			Info:    *wallet,
-			PrivKey: ed25519.PrivateKey{},
-			PubKey:  httpsignature.Ed25519PubKey([]byte(publicKey)),
+			return handlers.WrapError(err, "error reading body", http.StatusBadRequest)
 		}
 
-		country, err := s.LinkUpholdWallet(ctx, uwallet, cuw.SignedLinkingRequest, &aa)
+		acc, err := s.LinkCustodian(ctx, c, &linking.Request{PaymentID: *id.UUID(), Body: b})
 		if err != nil {
-			l.Error().Err(err).Str("paymentID", id.String()).
-				Msg("failed to link wallet")
+			l.Error().Err(err).Str("paymentID", id.String()).Msg("failed to link wallet")
+
 			if errors.Is(err, errorutils.ErrInvalidCountry) {
 				return handlers.WrapError(err, "region not supported", http.StatusBadRequest)
 			}
+
 			return handlers.WrapError(err, "error linking wallet", http.StatusBadRequest)
 		}
 
-		// render the wallet
 		return handlers.RenderContent(ctx, LinkDepositAccountResponse{
-			GeoCountry: country,
+			GeoCountry: acc.Country,
 		}, w, http.StatusOK)
 	}
 }
 
 const errOriginForbidden model.Error = "request origin forbidden"
 
-type linkSolanaAddrRequest struct {
-	SolanaPublicKey string `json:"solanaPublicKey" valid:"length(32|44)"`
-	Message         string `json:"message" valid:"required"`
-	SolanaSignature string `json:"solanaSignature" valid:"required"`
-}
-
-func LinkSolanaAddress(s *Service) handlers.AppHandler {
-	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
-		ctx := r.Context()
-
-		if dis, ok := ctx.Value(appctx.DisableSolanaLinkingCTXKey).(bool); ok && dis {
-			return handlers.ValidationError("Connecting Brave Rewards to Solana is temporarily unavailable. Please try again later", nil)
-		}
-
-		l := logging.Logger(ctx, "wallet")
-
-		o := r.Header.Get("Origin")
-		if !isAllowedOrigin(o, s.dappConf.AllowedOrigins) {
-			l.Error().Err(errOriginForbidden).Str("origin", strOr(o, "empty")).Msg("error linking solana address")
-			return handlers.WrapError(errOriginForbidden, "request origin forbidden", http.StatusForbidden)
-		}
-
-		var paymentID inputs.ID
-		if err := inputs.DecodeAndValidateString(ctx, &paymentID, chi.URLParam(r, "paymentID")); err != nil {
-			return handlers.WrapError(err, "invalid paymentID", http.StatusBadRequest)
-		}
-
-		b, err := io.ReadAll(io.LimitReader(r.Body, reqBodyLimit10MB))
-		if err != nil {
-			return handlers.WrapError(err, "error reading body", http.StatusBadRequest)
-		}
-
-		var solReq linkSolanaAddrRequest
-		if err := json.Unmarshal(b, &solReq); err != nil {
-			return handlers.WrapError(err, "error decoding body", http.StatusBadRequest)
-		}
-
-		if _, err := govalidator.ValidateStruct(solReq); err != nil {
-			return handlers.WrapValidationError(err)
-		}
-
-		if err := s.LinkSolanaAddress(ctx, *paymentID.UUID(), solReq); err != nil {
-			l.Error().Err(err).Msg("error linking solana address")
-
-			var solErr *walletutils.LinkSolanaAddressError
-			switch {
-			case errors.Is(err, model.ErrWalletNotWhitelisted):
-				return handlers.WrapError(model.ErrWalletNotWhitelisted, "rewards wallet not whitelisted", http.StatusForbidden)
-			case errors.Is(err, model.ErrChallengeNotFound):
-				return handlers.WrapError(model.ErrChallengeNotFound, "linking challenge not found", http.StatusNotFound)
-			case errors.Is(err, model.ErrChallengeExpired):
-				return handlers.WrapError(model.ErrChallengeExpired, "linking challenge expired", http.StatusUnauthorized)
-			case errors.Is(err, model.ErrWalletNotFound):
-				return handlers.WrapError(model.ErrWalletNotFound, "rewards wallet not found", http.StatusNotFound)
-			case errors.Is(err, errDisabledRegion):
-				return handlers.WrapError(errDisabledRegion, "region is currently disabled for linking", http.StatusBadRequest)
-			case errors.Is(err, ErrTooManyCardsLinked):
-				return handlers.WrapError(ErrTooManyCardsLinked, "too many wallets linked", http.StatusConflict)
-			case errors.As(err, &solErr):
-				return handlers.WrapError(solErr, "invalid solana linking message", http.StatusUnauthorized)
-			default:
-				return handlers.WrapError(model.ErrInternalServer, "internal server error", http.StatusInternalServerError)
-			}
-		}
-
-		return handlers.RenderContent(ctx, nil, w, http.StatusOK)
-	}
-}
-
 type challengeRequest struct {
 	PaymentID uuid.UUID `json:"paymentId"`
 }
@@ -721,6 +431,7 @@ func GetLinkingInfoV3(s *Service) func(w http.ResponseWriter, r *http.Request) *
 			// default to a uuid that doesn't exist
 			providerLinkingID = uuid.NewV4().String()
 			custodianID       = r.URL.Query().Get("custodianId")
+			country           = strings.ToUpper(r.URL.Query().Get("country"))
 		)
 		// get logger from context
 		logger := logging.Logger(ctx, "wallet.GetLinkingInfoV3")
@@ -749,7 +460,7 @@ func GetLinkingInfoV3(s *Service) func(w http.ResponseWriter, r *http.Request) *
 			}
 		}
 
-		info, err := s.GetLinkingInfo(ctx, providerLinkingID, custodianID)
+		info, err := s.GetLinkingInfo(ctx, providerLinkingID, custodianID, country)
 		if err != nil {
 			logger.Error().Err(err).Str("custodianId", custodianID).Msg("failed to get linking info")
 			return handlers.WrapError(err, "error getting linking info", http.StatusBadRequest)
@@ -759,6 +470,250 @@ func GetLinkingInfoV3(s *Service) func(w http.ResponseWriter, r *http.Request) *
 	}
 }
 
+// GetLinkingEventsV3 produces an http handler for the service s which lists the linking event log.
+//
+// The events can be narrowed down with the walletId, linkingId, custodian, country and outcome query parameters.
+func GetLinkingEventsV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		q := r.URL.Query()
+
+		filter := model.LinkingEventFilter{
+			Custodian: q.Get("custodian"),
+			Country:   q.Get("country"),
+			Outcome:   q.Get("outcome"),
+		}
+
+		for param, dst := range map[string]**uuid.UUID{"walletId": &filter.WalletID, "linkingId": &filter.LinkingID} {
+			if q.Get(param) == "" {
+				continue
+			}
+
+			id, err := uuid.FromString(q.Get(param))
+			if err != nil {
+				return handlers.ValidationError("error validating "+param+" query parameter", map[string]interface{}{
+					param: err.Error(),
+				})
+			}
+
+			*dst = &id
+		}
+
+		ctx, p, err := inputs.NewPagination(r.Context(), r.URL.String(), new(model.LinkingEvent))
+		if err != nil {
+			return handlers.WrapValidationError(err)
+		}
+
+		events, total, err := s.ListLinkingEvents(ctx, filter, p)
+		if err != nil {
+			return handlers.WrapError(err, "error getting linking events", http.StatusInternalServerError)
+		}
+
+		var maxPage int
+		if total > 0 {
+			maxPage = (total - 1) / p.Items // 0 indexed
+		}
+
+		resp := &responses.PaginationResponse{
+			Page:    p.Page,
+			Items:   p.Items,
+			MaxPage: maxPage,
+			Ordered: p.RawOrder,
+			Data:    events,
+		}
+
+		if err := resp.Render(ctx, w, http.StatusOK); err != nil {
+			return handlers.WrapError(err, "error rendering response", http.StatusInternalServerError)
+		}
+
+		return nil
+	}
+}
+
+// linkingLimitRequest is the body of the requests creating and updating linking limits.
+//
+// The scope of a limit, which is the custodian, country and linking id, cannot be changed by an update.
+type linkingLimitRequest struct {
+	Custodian             string     `json:"custodian" valid:"alphanum,lowercase"`
+	Country               string     `json:"country" valid:"ISO3166Alpha2"`
+	LinkingID             *uuid.UUID `json:"linkingId"`
+	MaxSlots              int        `json:"maxSlots"`
+	RelinkCooldownSeconds int        `json:"relinkCooldownSeconds"`
+}
+
+func (req *linkingLimitRequest) validate() *handlers.AppError {
+	if _, err := govalidator.ValidateStruct(req); err != nil {
+		return handlers.WrapValidationError(err)
+	}
+
+	errs := map[string]interface{}{}
+
+	if req.MaxSlots < 0 {
+		errs["maxSlots"] = "must not be negative"
+	}
+
+	if req.RelinkCooldownSeconds < 0 {
+		errs["relinkCooldownSeconds"] = "must not be negative"
+	}
+
+	if req.LinkingID != nil && req.Country != "" {
+		errs["country"] = "must be empty for a linking id exception"
+	}
+
+	if len(errs) > 0 {
+		return handlers.ValidationError("request body", errs)
+	}
+
+	return nil
+}
+
+func decodeLinkingLimitRequest(r *http.Request) (*linkingLimitRequest, *handlers.AppError) {
+	req := &linkingLimitRequest{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, reqBodyLimit10MB)).Decode(req); err != nil {
+		return nil, handlers.WrapError(err, "error decoding body", http.StatusBadRequest)
+	}
+
+	req.Country = strings.ToUpper(req.Country)
+
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func linkingLimitIDFromURL(r *http.Request) (uuid.UUID, *handlers.AppError) {
+	id, err := uuid.FromString(chi.URLParam(r, "linkingLimitID"))
+	if err != nil {
+		return uuid.Nil, handlers.ValidationError("linkingLimitID url parameter", map[string]interface{}{
+			"linkingLimitID": err.Error(),
+		})
+	}
+
+	return id, nil
+}
+
+// ListLinkingLimitsV3 produces an http handler for the service s which lists the linking limits,
+// optionally only those of the custodian query parameter.
+func ListLinkingLimitsV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		limits, err := s.ListLinkingLimits(ctx, r.URL.Query().Get("custodian"))
+		if err != nil {
+			return handlers.WrapError(err, "error getting linking limits", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, limits, w, http.StatusOK)
+	}
+}
+
+// CreateLinkingLimitV3 produces an http handler for the service s which adds a linking limit for a custodian,
+// a country of a custodian, or a single account.
+func CreateLinkingLimitV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		req, appErr := decodeLinkingLimitRequest(r)
+		if appErr != nil {
+			return appErr
+		}
+
+		if req.Custodian == "" {
+			return handlers.ValidationError("request body", map[string]interface{}{
+				"custodian": "must not be empty",
+			})
+		}
+
+		lim, err := s.CreateLinkingLimit(ctx, &model.LinkingLimit{
+			Custodian:             req.Custodian,
+			Country:               req.Country,
+			LinkingID:             req.LinkingID,
+			MaxSlots:              req.MaxSlots,
+			RelinkCooldownSeconds: req.RelinkCooldownSeconds,
+		})
+		if err != nil {
+			if errors.Is(err, model.ErrLinkingLimitExists) {
+				return handlers.WrapError(err, "linking limit already exists", http.StatusConflict)
+			}
+
+			return handlers.WrapError(err, "error creating linking limit", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, lim, w, http.StatusCreated)
+	}
+}
+
+// UpdateLinkingLimitV3 produces an http handler for the service s which changes the slots and relink cooldown
+// of a linking limit.
+func UpdateLinkingLimitV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		id, appErr := linkingLimitIDFromURL(r)
+		if appErr != nil {
+			return appErr
+		}
+
+		req, appErr := decodeLinkingLimitRequest(r)
+		if appErr != nil {
+			return appErr
+		}
+
+		lim, err := s.UpdateLinkingLimit(ctx, id, req.MaxSlots, req.RelinkCooldownSeconds)
+		if err != nil {
+			if errors.Is(err, model.ErrLinkingLimitNotFound) {
+				return handlers.WrapError(err, "linking limit not found", http.StatusNotFound)
+			}
+
+			return handlers.WrapError(err, "error updating linking limit", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, lim, w, http.StatusOK)
+	}
+}
+
+// DeleteLinkingLimitV3 produces an http handler for the service s which removes a linking limit.
+func DeleteLinkingLimitV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		id, appErr := linkingLimitIDFromURL(r)
+		if appErr != nil {
+			return appErr
+		}
+
+		if err := s.DeleteLinkingLimit(r.Context(), id); err != nil {
+			if errors.Is(err, model.ErrLinkingLimitNotFound) {
+				return handlers.WrapError(err, "linking limit not found", http.StatusNotFound)
+			}
+
+			return handlers.WrapError(err, "error deleting linking limit", http.StatusInternalServerError)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		return nil
+	}
+}
+
+// GetLinkingLimitHistoryV3 produces an http handler for the service s which returns the audit trail
+// of a linking limit.
+func GetLinkingLimitHistoryV3(s *Service) handlers.AppHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		ctx := r.Context()
+
+		id, appErr := linkingLimitIDFromURL(r)
+		if appErr != nil {
+			return appErr
+		}
+
+		changes, err := s.GetLinkingLimitHistory(ctx, id)
+		if err != nil {
+			return handlers.WrapError(err, "error getting linking limit history", http.StatusInternalServerError)
+		}
+
+		return handlers.RenderContent(ctx, changes, w, http.StatusOK)
+	}
+}
+
 // DisconnectCustodianLinkV3 - produces an http handler for the service s which handles disconnect
 // state for a deposit account linking
 func DisconnectCustodianLinkV3(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
@@ -847,3 +802,10 @@ func strOr(a string, b string) string {
 	}
 	return a
 }
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
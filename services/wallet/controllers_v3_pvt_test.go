@@ -31,6 +31,7 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -207,7 +208,7 @@ func TestLinkBitFlyerWalletV3(t *testing.T) {
 		)
 		mockReputation = mockreputation.NewMockClient(mockCtrl)
 		s, mock        = initSvcWithMockDB(t)
-		handler        = LinkBitFlyerDepositAccountV3(s)
+		handler        = LinkDepositAccountV3(s)
 		rw             = httptest.NewRecorder()
 	)
 
@@ -244,6 +245,9 @@ func TestLinkBitFlyerWalletV3(t *testing.T) {
 
 	mock.ExpectExec("^insert into (.+)").WithArgs(idFrom, true).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
@@ -264,7 +268,7 @@ func TestLinkBitFlyerWalletV3(t *testing.T) {
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/bitflyer/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -309,7 +313,7 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 				}`, linkingInfo, idTo)),
 		)
 
-		handler = LinkGeminiDepositAccountV3(s)
+		handler = LinkDepositAccountV3(s)
 		rw      = httptest.NewRecorder()
 	)
 
@@ -376,6 +380,7 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 
 	// linking limit checks
 	mock.ExpectQuery("^select wc1.custodian, wc1.linking_id from wallet_custodian (.+)").WithArgs(linkingID).WillReturnRows(custLinks)
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID, 4).WillReturnRows(max)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(open)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(sqlmock.NewRows([]string{"wallet_id"}).AddRow(uuid.NewV4().String()))
@@ -383,6 +388,9 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 	var lastUnlink = sqlmock.NewRows([]string{"last_unlinking"}).AddRow(time.Now())
 	mock.ExpectQuery("^select max(.+)").WithArgs(linkingID).WillReturnRows(lastUnlink)
 
+	// relink cooldown check
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
+
 	// updates the link to the wallet_custodian record in wallets
 	mock.ExpectExec("^update wallet_custodian (.+)").WithArgs(idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -397,13 +405,16 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 
 	mock.ExpectExec("^insert into (.+)").WithArgs(idFrom, true).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/gemini/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -423,6 +434,9 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 	handler = DisconnectCustodianLinkV3(s)
 	rw = httptest.NewRecorder()
 
+	// looks up the active link to record the unlinking
+	mockSQLActiveCustodianLink(mock, "gemini")
+
 	// create transaction
 	mock.ExpectBegin()
 
@@ -432,6 +446,9 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 	// updates the disconnected date on the record, and returns no error and one changed row
 	mock.ExpectExec("^update wallet_custodian(.+)").WithArgs(idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the unlinking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction because we are done disconnecting
 	mock.ExpectCommit()
 
@@ -477,6 +494,7 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 
 	// linking limit checks
 	mock.ExpectQuery("^select wc1.custodian, wc1.linking_id from wallet_custodian (.+)").WithArgs(linkingID).WillReturnRows(custLinks)
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID, 4).WillReturnRows(max)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(open)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(sqlmock.NewRows([]string{"wallet_id"}).AddRow(uuid.NewV4().String()))
@@ -484,6 +502,9 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 	lastUnlink = sqlmock.NewRows([]string{"last_unlinking"}).AddRow(time.Now())
 	mock.ExpectQuery("^select max(.+)").WithArgs(linkingID).WillReturnRows(lastUnlink)
 
+	// relink cooldown check
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
+
 	// updates the link to the wallet_custodian record in wallets
 	mock.ExpectExec("^update wallet_custodian (.+)").WithArgs(idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -496,13 +517,16 @@ func TestLinkGeminiWalletV3RelinkBadRegion(t *testing.T) {
 	// updates the link to the wallet_custodian record in wallets
 	mock.ExpectExec("^update wallets (.+)").WithArgs(idTo, linkingID, "gemini", idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
 	r = r.WithContext(ctx)
 
 	router = chi.NewRouter()
-	router.Post("/v3/wallet/gemini/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b = rw.Body.Bytes()
@@ -541,7 +565,7 @@ func TestLinkGeminiWalletV3FirstLinking(t *testing.T) {
 				}`, linkingInfo, idTo)),
 		)
 
-		handler = LinkGeminiDepositAccountV3(s)
+		handler = LinkDepositAccountV3(s)
 		rw      = httptest.NewRecorder()
 	)
 
@@ -599,6 +623,7 @@ func TestLinkGeminiWalletV3FirstLinking(t *testing.T) {
 
 	// linking limit checks
 	mock.ExpectQuery("^select wc1.custodian, wc1.linking_id from wallet_custodian (.+)").WithArgs(linkingID).WillReturnRows(custLinks)
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID, 4).WillReturnRows(max)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(open)
 	mock.ExpectQuery("^select (.+)").WithArgs(linkingID).WillReturnRows(sqlmock.NewRows([]string{"wallet_id"}).AddRow(uuid.NewV4().String()))
@@ -606,6 +631,9 @@ func TestLinkGeminiWalletV3FirstLinking(t *testing.T) {
 	var lastUnlink = sqlmock.NewRows([]string{"last_unlinking"}).AddRow(time.Now())
 	mock.ExpectQuery("^select max(.+)").WithArgs(linkingID).WillReturnRows(lastUnlink)
 
+	// relink cooldown check
+	mockSQLLinkingLimit(mock, "gemini", linkingID)
+
 	// updates the link to the wallet_custodian record in wallets
 	mock.ExpectExec("^update wallet_custodian (.+)").WithArgs(idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -620,13 +648,16 @@ func TestLinkGeminiWalletV3FirstLinking(t *testing.T) {
 
 	mock.ExpectExec("^insert into (.+)").WithArgs(idFrom, true).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/gemini/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -655,14 +686,19 @@ func TestLinkZebPayWalletV3_InvalidKyc(t *testing.T) {
 		ctx       = middleware.AddKeyID(context.Background(), idFrom.String())
 		accountID = uuid.NewV4()
 		idTo      = accountID
-		s, _      = initSvcWithMockDB(t)
-		handler   = LinkZebPayDepositAccountV3(s)
+		s, mock   = initSvcWithMockDB(t)
+		handler   = LinkDepositAccountV3(s)
 		rw        = httptest.NewRecorder()
 	)
 
 	ctx = context.WithValue(ctx, appctx.NoUnlinkPriorToDurationCTXKey, "-P1D")
 	ctx = context.WithValue(ctx, appctx.ZebPayLinkingKeyCTXKey, base64.StdEncoding.EncodeToString(secret))
 
+	// records the rejection in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").
+		WithArgs(idFrom, "zebpay", uuid.NewV5(ClaimNamespace, accountID.String()), "IN", "rejected", "kyc_failed").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
 	linkingInfo, err := jwt.Signed(sig).Claims(map[string]interface{}{
 		"accountId":   accountID,
 		"depositId":   idTo,
@@ -687,7 +723,7 @@ func TestLinkZebPayWalletV3_InvalidKyc(t *testing.T) {
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/zebpay/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -696,6 +732,8 @@ func TestLinkZebPayWalletV3_InvalidKyc(t *testing.T) {
 	var l LinkDepositAccountResponse
 	err = json.Unmarshal(b, &l)
 	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestLinkZebPayWalletV3(t *testing.T) {
@@ -721,7 +759,7 @@ func TestLinkZebPayWalletV3(t *testing.T) {
 
 		s, mock = initSvcWithMockDB(t)
 
-		handler = LinkZebPayDepositAccountV3(s)
+		handler = LinkDepositAccountV3(s)
 		rw      = httptest.NewRecorder()
 	)
 
@@ -788,13 +826,16 @@ func TestLinkZebPayWalletV3(t *testing.T) {
 
 	mock.ExpectExec("^insert into (.+)").WithArgs(idFrom, true).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/zebpay/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -838,7 +879,7 @@ func TestLinkGeminiWalletV3(t *testing.T) {
 		)
 		s, mock = initSvcWithMockDB(t)
 
-		handler = LinkGeminiDepositAccountV3(s)
+		handler = LinkDepositAccountV3(s)
 		rw      = httptest.NewRecorder()
 	)
 
@@ -905,13 +946,16 @@ func TestLinkGeminiWalletV3(t *testing.T) {
 
 	mock.ExpectExec("^insert into (.+)").WithArgs(idFrom, true).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the linking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction
 	mock.ExpectCommit()
 
 	r = r.WithContext(ctx)
 
 	router := chi.NewRouter()
-	router.Post("/v3/wallet/gemini/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
+	router.Post("/v3/wallet/{custodian}/{paymentID}/claim", handlers.AppHandler(handler).ServeHTTP)
 	router.ServeHTTP(rw, r)
 
 	b := rw.Body.Bytes()
@@ -946,6 +990,9 @@ func TestDisconnectCustodianLinkV3(t *testing.T) {
 		w       = httptest.NewRecorder()
 	)
 
+	// looks up the active link to record the unlinking
+	mockSQLActiveCustodianLink(mock, "gemini")
+
 	// create transaction
 	mock.ExpectBegin()
 
@@ -955,6 +1002,9 @@ func TestDisconnectCustodianLinkV3(t *testing.T) {
 	// updates the disconnected date on the record, and returns no error and one changed row
 	mock.ExpectExec("^update wallet_custodian(.+)").WithArgs(idFrom).WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// records the unlinking in the linking event log
+	mock.ExpectExec("^insert into wallet_linking_events (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+
 	// commit transaction because we are done disconnecting
 	mock.ExpectCommit()
 
@@ -971,6 +1021,90 @@ func TestDisconnectCustodianLinkV3(t *testing.T) {
 	}
 }
 
+func TestCreateLinkingLimitV3(t *testing.T) {
+	type tcGiven struct {
+		body   string
+		dbErr  error
+		insert bool
+	}
+
+	type tcExpected struct {
+		code int
+		msg  string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "created",
+			given: tcGiven{body: `{"custodian": "gemini", "country": "us", "maxSlots": 2, "relinkCooldownSeconds": 86400}`, insert: true},
+			exp:   tcExpected{code: http.StatusCreated, msg: `"country":"US"`},
+		},
+
+		{
+			name:  "invalid_country",
+			given: tcGiven{body: `{"custodian": "gemini", "country": "USA", "maxSlots": 2}`},
+			exp:   tcExpected{code: http.StatusBadRequest, msg: "Error validating request body"},
+		},
+
+		{
+			name:  "negative_slots",
+			given: tcGiven{body: `{"custodian": "gemini", "maxSlots": -1}`},
+			exp:   tcExpected{code: http.StatusBadRequest, msg: "must not be negative"},
+		},
+
+		{
+			name:  "country_exception",
+			given: tcGiven{body: `{"custodian": "gemini", "country": "US", "linkingId": "` + uuid.NewV4().String() + `", "maxSlots": 8}`},
+			exp:   tcExpected{code: http.StatusBadRequest, msg: "must be empty for a linking id exception"},
+		},
+
+		{
+			name:  "no_custodian",
+			given: tcGiven{body: `{"maxSlots": 8}`},
+			exp:   tcExpected{code: http.StatusBadRequest, msg: "must not be empty"},
+		},
+
+		{
+			name:  "exists",
+			given: tcGiven{body: `{"custodian": "gemini", "maxSlots": 8}`, insert: true, dbErr: &pq.Error{Code: "23505"}},
+			exp:   tcExpected{code: http.StatusConflict, msg: "linking limit already exists"},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := initSvcWithMockDB(t)
+
+			if tc.given.insert {
+				exp := mock.ExpectQuery("^insert into linking_limits (.+)")
+				if tc.given.dbErr != nil {
+					exp.WillReturnError(tc.given.dbErr)
+				} else {
+					exp.WillReturnRows(sqlmock.NewRows([]string{"id", "custodian", "country", "max_slots", "relink_cooldown_seconds"}).
+						AddRow(uuid.NewV4().String(), "gemini", "US", 2, 86400))
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/v3/wallet/linking-limits", bytes.NewBufferString(tc.given.body))
+			rw := httptest.NewRecorder()
+
+			handlers.AppHandler(CreateLinkingLimitV3(s)).ServeHTTP(rw, req)
+
+			require.Equal(t, tc.exp.code, rw.Code, rw.Body.String())
+			assert.Contains(t, rw.Body.String(), tc.exp.msg)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestIsAllowedOrigin(t *testing.T) {
 	type tcGiven struct {
 		origin         string
@@ -1039,6 +1173,21 @@ func mockSQLCustodianLink(mock sqlmock.Sqlmock, custodian string) {
 		WillReturnRows(clRow)
 }
 
+func mockSQLLinkingLimit(mock sqlmock.Sqlmock, custodian string, linkingID uuid.UUID) {
+	limRow := sqlmock.NewRows([]string{"id", "custodian", "max_slots", "relink_cooldown_seconds"}).
+		AddRow(uuid.NewV4().String(), custodian, 4, 0)
+	mock.ExpectQuery("^select (.+) from linking_limits (.+)").
+		WithArgs(custodian, "US", linkingID).
+		WillReturnRows(limRow)
+}
+
+func mockSQLActiveCustodianLink(mock sqlmock.Sqlmock, custodian string) {
+	clRow := sqlmock.NewRows([]string{"wallet_id", "custodian", "linking_id", "created_at", "disconnected_at", "linked_at"}).
+		AddRow(uuid.NewV4().String(), custodian, uuid.NewV4().String(), time.Now(), nil, time.Now())
+	mock.ExpectQuery("^select(.+) from wallet_custodian(.+)").
+		WillReturnRows(clRow)
+}
+
 func initSvcWithMockDB(t *testing.T) (*Service, sqlmock.Sqlmock) {
 	db, mock, _ := sqlmock.New()
 	datastore := Datastore(
@@ -1108,3 +1257,8 @@ func (m *mockMtc) LinkFailureSolanaRegion(_ string)                    {}
 func (m *mockMtc) LinkFailureSolanaChl(_ string)                       {}
 func (m *mockMtc) LinkFailureSolanaMsg(_ string)                       {}
 func (m *mockMtc) LinkSuccessSolana(_ string)                          {}
+func (m *mockMtc) LinkFailureEthereumWhitelist(_ string)               {}
+func (m *mockMtc) LinkFailureEthereumRegion(_ string)                  {}
+func (m *mockMtc) LinkFailureEthereumChl(_ string)                     {}
+func (m *mockMtc) LinkFailureEthereumMsg(_ string)                     {}
+func (m *mockMtc) LinkSuccessEthereum(_ string)                        {}
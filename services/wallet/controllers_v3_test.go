@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -251,7 +252,7 @@ func (suite *WalletControllersTestSuite) claimCardV3(
 
 	// V3 Handler
 
-	handler := wallet.LinkUpholdDepositAccountV3(service)
+	handler := wallet.LinkDepositAccountV3(service)
 
 	req, err := http.NewRequest("POST", "/v3/wallet/{paymentID}/claim", bytes.NewBuffer(body))
 	suite.Require().NoError(err, "wallet claim request could not be created")
@@ -263,6 +264,7 @@ func (suite *WalletControllersTestSuite) claimCardV3(
 	repClient.EXPECT().IsLinkingReputable(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 
 	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("custodian", "uphold")
 	rctx.URLParams.Add("paymentID", info.ID)
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 	req = req.WithContext(context.WithValue(req.Context(), appctx.NoUnlinkPriorToDurationCTXKey, "-P1D"))
@@ -529,7 +531,7 @@ func (suite *WalletControllersTestSuite) TestLinkSolanaAddress_Success() {
 	repClient := mock_reputation.NewMockClient(ctrl)
 	repClient.EXPECT().GetReputationSummary(gomock.Any(), paymentID).Return(reputation.RepSummaryResponse{GeoCountry: "US"}, nil)
 
-	mtc := metric.New()
+	mtc := newMetric()
 
 	s, err := wallet.InitService(pg, nil, chlRep, allowList, repClient, nil, nil, nil, mtc, nil, dac)
 	suite.Require().NoError(err)
@@ -598,6 +600,96 @@ func (suite *WalletControllersTestSuite) TestLinkSolanaAddress_Options() {
 	suite.Require().Equal("Content-Type", rw.Header().Get("Access-Control-Allow-Headers"))
 }
 
+func (suite *WalletControllersTestSuite) TestLinkEthereumAddress_Success() {
+	viper.Set("enable-link-drain-flag", "true")
+
+	pg, _, err := wallet.NewPostgres()
+	suite.Require().NoError(err)
+
+	chlRep := storage.NewChallenge()
+	allowList := storage.NewAllowList()
+
+	// The linking typed data is signed ahead of time for this wallet, nonce and address on Base (chain 8453).
+	const (
+		paymentID = "2a8d6d6c-3f7d-4e4b-9a39-5f1c3f0c6e21"
+		nonce     = "9c2f4e1a-6b0d-4c5e-8f3a-1d7b2e9c4a60"
+		address   = "0xBBceaAaE039873aB8ba5F5162e331541BF15A95E"
+		rewSig    = "NFLGEA9xUbAhDZCYW9UsKCFOoAw7Ry6RB84lkwnIBpZEQcPexNMh0x-YGgGj0vDhSA_IAWIq3oAzUCZYMds1DQ=="
+		ethSig    = "0xbc51bc5c6a0dc1fbdc4747720bc09708a9d7664b6927144af2610d508d0131724a7eeeba5e1c158110fc8626c567a9f39ab100f4404b84f7038366effcca4cc21c"
+	)
+
+	w := &walletutils.Info{
+		ID:          paymentID,
+		Provider:    "brave",
+		PublicKey:   "ea4a6c63e29c520abef5507b132ec5f9954776aebebe7b92421eea691446d22c",
+		AltCurrency: ptrTo(altcurrency.BAT),
+	}
+	err = pg.InsertWallet(context.TODO(), w)
+	suite.Require().NoError(err)
+
+	whitelistWallet(suite.T(), pg, w.ID)
+
+	chl := model.Challenge{PaymentID: uuid.Must(uuid.FromString(paymentID)), CreatedAt: time.Now(), Nonce: nonce}
+
+	err = chlRep.Upsert(context.TODO(), pg.RawDB(), chl)
+	suite.Require().NoError(err)
+
+	dac := wallet.DAppConfig{
+		AllowedOrigins: []string{"https://my-dapp.com"},
+	}
+
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+
+	repClient := mock_reputation.NewMockClient(ctrl)
+	repClient.EXPECT().GetReputationSummary(gomock.Any(), chl.PaymentID).Return(reputation.RepSummaryResponse{GeoCountry: "US"}, nil)
+
+	s, err := wallet.InitService(pg, nil, chlRep, allowList, repClient, nil, nil, nil, newMetric(), nil, dac)
+	suite.Require().NoError(err)
+
+	cr := custodian.Regions{Other: map[string]custodian.GeoAllowBlockMap{
+		"ethereum": {Allow: []string{"US"}},
+	}}
+	s.SetCustodianRegions(cr)
+
+	body := struct {
+		Address          string `json:"address"`
+		SignatureType    string `json:"signatureType"`
+		ChainID          int64  `json:"chainId"`
+		RewardsSignature string `json:"rewardsSignature"`
+		Signature        string `json:"signature"`
+	}{
+		Address:          address,
+		SignatureType:    "eip712",
+		ChainID:          8453,
+		RewardsSignature: rewSig,
+		Signature:        ethSig,
+	}
+
+	b, err := json.Marshal(body)
+	suite.Require().NoError(err)
+
+	r := httptest.NewRequest(http.MethodPost, "/v3/wallet/ethereum/"+w.ID+"/connect", bytes.NewBuffer(b))
+	r.Header.Set("origin", "https://my-dapp.com")
+
+	rw := httptest.NewRecorder()
+
+	svr := &http.Server{Addr: ":8080", Handler: setupRouter(s)}
+	svr.Handler.ServeHTTP(rw, r)
+	suite.Require().Equal(http.StatusOK, rw.Code, rw.Body.String())
+
+	actual, err := pg.GetWallet(context.TODO(), chl.PaymentID)
+	suite.Require().NoError(err)
+
+	suite.Require().Equal(address, actual.UserDepositDestination)
+
+	_, actualErr := chlRep.Get(context.TODO(), pg.RawDB(), chl.PaymentID)
+	suite.Assert().ErrorIs(actualErr, model.ErrChallengeNotFound)
+}
+
+// newMetric returns the metrics shared by the tests, as they can only be registered once.
+var newMetric = sync.OnceValue(metric.New)
+
 func whitelistWallet(t *testing.T, pg wallet.Datastore, Id string) {
 	const q = `insert into allow_list (payment_id, created_at) values($1, $2)`
 	_, err := pg.RawDB().Exec(q, Id, time.Now())
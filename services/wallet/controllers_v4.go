@@ -2,9 +2,12 @@ package wallet
 
 import (
 	"crypto"
+	"crypto/ed25519"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/brave-intl/bat-go/libs/clients"
@@ -16,12 +19,14 @@ import (
 	"github.com/brave-intl/bat-go/libs/middleware"
 	"github.com/brave-intl/bat-go/services/wallet/model"
 	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
 )
 
 var (
 	errGeoCountryFormat  = errors.New("error geo country format must be ISO3166Alpha2")
 	errGeoAlreadySet     = errors.New("error geo country has already been set for rewards wallet")
 	errPaymentIDMismatch = errors.New("error payment id does not match http signature key id")
+	errPublicKeyFormat   = errors.New("error public key must be a hex encoded ed25519 public key")
 )
 
 // V4Request contains the fields for making v4 wallet requests.
@@ -169,6 +174,93 @@ func UpdateWalletV4(s *Service) func(w http.ResponseWriter, r *http.Request) *ha
 	}
 }
 
+// RotateKeyV4Request contains the fields for rotating the key of a rewards wallet.
+type RotateKeyV4Request struct {
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// RotateKeyV4Response contains the fields for key rotation responses.
+type RotateKeyV4Response struct {
+	PaymentID             string    `json:"paymentId"`
+	PublicKey             string    `json:"publicKey"`
+	PreviousKeyValidUntil time.Time `json:"previousKeyValidUntil"`
+}
+
+// RotateWalletKeyV4 replaces the public key of a brave rewards wallet. The request must be signed with the current key
+// of the wallet and carry the new hex encoded ed25519 public key, the current unix time and the base64 encoded signature
+// made with the current key over "<paymentID>:<publicKey>:<timestamp>". The previous key continues to be accepted for a
+// short overlap period.
+func RotateWalletKeyV4(s *Service) func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+	return func(w http.ResponseWriter, r *http.Request) *handlers.AppError {
+		logger := logging.Logger(r.Context(), "wallet.RotateWalletKeyV4")
+
+		paymentID := chi.URLParam(r, "paymentID")
+		if paymentID == "" {
+			logger.Error().Err(errorutils.ErrBadRequest).Msg("error rotating wallet key")
+			return handlers.ValidationError("error validating paymentID url parameter",
+				map[string]interface{}{"paymentID": errorutils.ErrBadRequest.Error()})
+		}
+
+		keyID, err := middleware.GetKeyID(r.Context())
+		if err != nil {
+			logger.Error().Err(err).Msg("error rotating wallet key")
+			return handlers.ValidationError("error retrieving keyID from signature",
+				map[string]interface{}{"keyID": err.Error()})
+		}
+
+		if paymentID != keyID {
+			logger.Error().Err(errPaymentIDMismatch).Msg("error rotating wallet key")
+			return handlers.WrapError(errPaymentIDMismatch, "error rotating wallet key", http.StatusForbidden)
+		}
+
+		walletID, err := uuid.FromString(paymentID)
+		if err != nil {
+			logger.Error().Err(err).Msg("error rotating wallet key")
+			return handlers.ValidationError("error validating paymentID url parameter",
+				map[string]interface{}{"paymentID": err.Error()})
+		}
+
+		var request RotateKeyV4Request
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			logger.Error().Err(err).Msg("error rotating wallet key")
+			return handlers.WrapError(err, "error rotating wallet key", http.StatusBadRequest)
+		}
+
+		if key, err := hex.DecodeString(request.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			logger.Error().Err(errPublicKeyFormat).Msg("error rotating wallet key")
+			return handlers.WrapError(errPublicKeyFormat, "error rotating wallet key", http.StatusBadRequest)
+		}
+
+		key, err := s.RotateWalletKey(r.Context(), walletID, request.PublicKey, request.Signature, request.Timestamp)
+		if err != nil {
+			logger.Error().Err(err).Msg("error rotating wallet key")
+
+			switch {
+			case errors.Is(err, model.ErrWalletNotFound):
+				return handlers.WrapError(err, "error rotating wallet key", http.StatusNotFound)
+			case errors.Is(err, errWalletKeySignature), errors.Is(err, errKeyRotationStale):
+				return handlers.WrapError(err, "error rotating wallet key", http.StatusForbidden)
+			case errors.Is(err, errWalletKeyInUse), errors.Is(err, errKeyRotationInProgress),
+				errors.Is(err, model.ErrWalletKeyConflict):
+				return handlers.WrapError(err, "error rotating wallet key", http.StatusConflict)
+			default:
+				return handlers.WrapError(errorutils.ErrInternalServerError,
+					"error rotating wallet key", http.StatusInternalServerError)
+			}
+		}
+
+		response := RotateKeyV4Response{
+			PaymentID:             paymentID,
+			PublicKey:             key.NextPublicKey,
+			PreviousKeyValidUntil: key.ValidTo.Add(keyRotationOverlap),
+		}
+
+		return handlers.RenderContent(r.Context(), response, w, http.StatusCreated)
+	}
+}
+
 // GetUpholdWalletBalanceV4 produces an http handler for the service s which handles balance inquiries of uphold wallets
 func GetUpholdWalletBalanceV4(w http.ResponseWriter, r *http.Request) *handlers.AppError {
 	return GetUpholdWalletBalanceV3(w, r)
@@ -0,0 +1,280 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/httpsignature"
+)
+
+func TestRotateWalletKeyV4(t *testing.T) {
+	type tcGiven struct {
+		signer    ed25519.PrivateKey
+		publicKey string
+		signedFor uuid.UUID
+		signedAt  time.Time
+		mock      func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, nextKey string)
+	}
+
+	type tcExpected struct {
+		code int
+		msg  string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	currentPub, currentPriv, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	nextPub, _, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	_, otherPriv, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	walletID := uuid.NewV5(ClaimNamespace, currentPub.String())
+
+	tests := []testCase{
+		{
+			name: "success",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: nextPub.String(),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, nextKey string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+
+					mockSQLWallet(mock, walletID, currentKey)
+					mock.ExpectBegin()
+					mockSQLWalletKeys(mock, walletID)
+					mock.ExpectQuery("^select exists(.+)").WithArgs(nextKey).
+						WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+					mock.ExpectExec("^update wallets set public_key (.+)").WithArgs(walletID, currentKey, nextKey).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectQuery("^insert into wallet_key_history (.+)").
+						WillReturnRows(walletKeyRows().AddRow(uuid.NewV4(), time.Now(), walletID, currentKey, nil, time.Now(), nextKey, "sig"))
+					mock.ExpectCommit()
+				},
+			},
+			exp: tcExpected{code: http.StatusCreated, msg: nextPub.String()},
+		},
+
+		{
+			name: "invalid_public_key",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: "invalid",
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, _ string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+				},
+			},
+			exp: tcExpected{code: http.StatusBadRequest, msg: errPublicKeyFormat.Error()},
+		},
+
+		{
+			name: "not_signed_by_current_key",
+			given: tcGiven{
+				signer:    otherPriv,
+				publicKey: nextPub.String(),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, _ string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+					mockSQLWallet(mock, walletID, currentKey)
+				},
+			},
+			exp: tcExpected{code: http.StatusForbidden, msg: errWalletKeySignature.Error()},
+		},
+
+		{
+			name: "signed_for_other_wallet",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: nextPub.String(),
+				signedFor: uuid.NewV4(),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, _ string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+					mockSQLWallet(mock, walletID, currentKey)
+				},
+			},
+			exp: tcExpected{code: http.StatusForbidden, msg: errWalletKeySignature.Error()},
+		},
+
+		{
+			name: "stale_timestamp",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: nextPub.String(),
+				signedAt:  time.Now().Add(-time.Hour),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, _ string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+				},
+			},
+			exp: tcExpected{code: http.StatusForbidden, msg: errKeyRotationStale.Error()},
+		},
+
+		{
+			name: "rotation_in_progress",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: nextPub.String(),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, nextKey string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+
+					mockSQLWallet(mock, walletID, currentKey)
+					mock.ExpectBegin()
+					mockSQLWalletKeys(mock, walletID, "previous")
+					mock.ExpectRollback()
+				},
+			},
+			exp: tcExpected{code: http.StatusConflict, msg: errKeyRotationInProgress.Error()},
+		},
+
+		{
+			name: "key_in_use",
+			given: tcGiven{
+				signer:    currentPriv,
+				publicKey: nextPub.String(),
+				mock: func(mock sqlmock.Sqlmock, walletID uuid.UUID, currentKey, nextKey string) {
+					mockSQLWallet(mock, walletID, currentKey)
+					mockSQLWalletKeys(mock, walletID)
+
+					mockSQLWallet(mock, walletID, currentKey)
+					mock.ExpectBegin()
+					mockSQLWalletKeys(mock, walletID)
+					mock.ExpectQuery("^select exists(.+)").WithArgs(nextKey).
+						WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+					mock.ExpectRollback()
+				},
+			},
+			exp: tcExpected{code: http.StatusConflict, msg: errWalletKeyInUse.Error()},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := initSvcWithMockDB(t)
+			tc.given.mock(mock, walletID, currentPub.String(), tc.given.publicKey)
+
+			signedFor := walletID
+			if !uuid.Equal(tc.given.signedFor, uuid.Nil) {
+				signedFor = tc.given.signedFor
+			}
+
+			signedAt := time.Now()
+			if !tc.given.signedAt.IsZero() {
+				signedAt = tc.given.signedAt
+			}
+
+			msg := keyRotationMessage(signedFor, tc.given.publicKey, signedAt.Unix())
+
+			body, err := json.Marshal(RotateKeyV4Request{
+				PublicKey: tc.given.publicKey,
+				Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(tc.given.signer, msg)),
+				Timestamp: signedAt.Unix(),
+			})
+			must.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/v4/wallets/"+walletID.String()+"/keys", bytes.NewBuffer(body))
+
+			sp := httpsignature.SignatureParams{
+				Algorithm: httpsignature.ED25519,
+				KeyID:     walletID.String(),
+				Headers:   []string{"digest", "(request-target)"},
+			}
+			must.NoError(t, sp.Sign(currentPriv, crypto.Hash(0), req))
+
+			mw := func(name string, h http.Handler) http.Handler {
+				return h
+			}
+
+			noCors := func(next http.Handler) http.Handler {
+				return next
+			}
+
+			r := RegisterRoutes(context.Background(), s, chi.NewRouter(), mw, noCors)
+
+			rw := httptest.NewRecorder()
+			r.ServeHTTP(rw, req)
+
+			must.Equal(t, tc.exp.code, rw.Code, rw.Body.String())
+			should.Contains(t, rw.Body.String(), tc.exp.msg)
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLookupVerifier_KeyRotationOverlap(t *testing.T) {
+	currentPub, currentPriv, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	previousPub, previousPriv, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	_, otherPriv, err := httpsignature.GenerateEd25519Key(nil)
+	must.NoError(t, err)
+
+	walletID := uuid.NewV4()
+
+	s, mock := initSvcWithMockDB(t)
+	mockSQLWallet(mock, walletID, currentPub.String())
+	mockSQLWalletKeys(mock, walletID, previousPub.String())
+
+	_, verifier, err := s.LookupVerifier(context.Background(), walletID.String())
+	must.NoError(t, err)
+
+	should.Equal(t, currentPub.String(), verifier.String())
+
+	msg := []byte("message")
+
+	for _, key := range []ed25519.PrivateKey{currentPriv, previousPriv} {
+		valid, err := verifier.Verify(msg, ed25519.Sign(key, msg), crypto.Hash(0))
+		must.NoError(t, err)
+		should.True(t, valid)
+	}
+
+	valid, err := verifier.Verify(msg, ed25519.Sign(otherPriv, msg), crypto.Hash(0))
+	must.NoError(t, err)
+	should.False(t, valid)
+}
+
+func mockSQLWallet(mock sqlmock.Sqlmock, walletID uuid.UUID, publicKey string) {
+	rows := sqlmock.NewRows([]string{"id", "provider", "provider_id", "public_key"}).
+		AddRow(walletID, "brave", "", publicKey)
+	mock.ExpectQuery("^select(.+) from(.+)wallets(.+)").WithArgs(walletID).WillReturnRows(rows)
+}
+
+func mockSQLWalletKeys(mock sqlmock.Sqlmock, walletID uuid.UUID, publicKeys ...string) {
+	rows := walletKeyRows()
+	for i := range publicKeys {
+		rows.AddRow(uuid.NewV4(), time.Now(), walletID, publicKeys[i], nil, time.Now(), "next", "sig")
+	}
+	mock.ExpectQuery("^select (.+) from wallet_key_history (.+)").WillReturnRows(rows)
+}
+
+func walletKeyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "created_at", "wallet_id", "public_key", "valid_from", "valid_to", "next_public_key", "signature"})
+}
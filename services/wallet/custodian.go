@@ -0,0 +1,288 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/brave-intl/bat-go/libs/clients/reputation"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/logging"
+	"github.com/brave-intl/bat-go/libs/middleware"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	errCustodianNotFound  model.Error = "wallet: custodian not found"
+	errCustodianAccountID model.Error = "wallet: custodian account has no id"
+)
+
+// custodians holds the custodians which rewards wallets can be linked to.
+type custodians []linking.Custodian
+
+// get returns the custodian with the given name.
+func (x custodians) get(name string) (linking.Custodian, bool) {
+	for i := range x {
+		if x[i].Name() == name {
+			return x[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// set replaces the custodian of the same name with c, or adds c if there is none.
+func (x *custodians) set(c linking.Custodian) {
+	for i := range *x {
+		if (*x)[i].Name() == c.Name() {
+			(*x)[i] = c
+			return
+		}
+	}
+
+	*x = append(*x, c)
+}
+
+// RegisterCustodian makes c available for linking, replacing the custodian of the same name.
+//
+// Custodians should be registered while setting up the service, before it serves requests.
+func (service *Service) RegisterCustodian(c linking.Custodian) {
+	service.custodians.set(c)
+}
+
+// LinkCustodian links the rewards wallet in req to the account with c, once the account has been verified and checked.
+func (service *Service) LinkCustodian(ctx context.Context, c linking.Custodian, req *linking.Request) (*linking.Account, error) {
+	acc, err := c.Verify(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Check(ctx, req, acc); err != nil {
+		service.recordRejection(ctx, c, req, acc, err)
+		return nil, err
+	}
+
+	if err := service.linkCustodianAccount(ctx, c, req, acc); err != nil {
+		if fin, ok := c.(linking.Finisher); ok {
+			_ = fin.Finish(ctx, req, acc, err)
+		}
+
+		service.recordRejection(ctx, c, req, acc, err)
+
+		return nil, err
+	}
+
+	if al, ok := c.(linking.AfterLinker); ok {
+		if err := al.AfterLink(ctx, req, acc); err != nil {
+			logging.Logger(ctx, "wallet").Error().Err(err).Str("custodian", c.Name()).Str("paymentID", req.PaymentID.String()).Msg("failed to finish linking")
+		}
+	}
+
+	return acc, nil
+}
+
+func (service *Service) linkCustodianAccount(ctx context.Context, c linking.Custodian, req *linking.Request, acc *linking.Account) error {
+	// An empty id would put every account of the custodian in the same linking slots.
+	if acc.ID == "" {
+		return handlers.WrapError(errCustodianAccountID, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	ctx, tx, rollback, commit, err := getTx(ctx, service.Datastore)
+	if err != nil {
+		return handlers.WrapError(err, "unable to link wallets", http.StatusInternalServerError)
+	}
+	defer rollback()
+
+	linkingID := uuid.NewV5(ClaimNamespace, acc.ID)
+	if err := service.Datastore.LinkWallet(ctx, req.PaymentID.String(), acc.DepositDestination, linkingID, c.Name(), acc.Country); err != nil {
+		switch {
+		case errors.Is(err, ErrUnusualActivity):
+			return handlers.WrapError(err, "unable to link - unusual activity", http.StatusBadRequest)
+		case errors.Is(err, ErrGeoResetDifferent):
+			return handlers.WrapError(err, "mismatched provider account regions", http.StatusBadRequest)
+		case errors.Is(err, ErrTooManyCardsLinked):
+			return linking.Reject(linking.ReasonSlotsExhausted, handlers.WrapError(err, "unable to link wallets", http.StatusConflict))
+		case errors.Is(err, ErrRelinkCooldown):
+			return linking.Reject(linking.ReasonRelinkCooldown, handlers.WrapError(err, "unable to link wallets", http.StatusConflict))
+		default:
+			return handlers.WrapError(err, "unable to link wallets", http.StatusInternalServerError)
+		}
+	}
+
+	ev := &model.LinkingEvent{
+		WalletID:  req.PaymentID,
+		Custodian: c.Name(),
+		LinkingID: &linkingID,
+		Country:   acc.Country,
+		Outcome:   model.LinkingOutcomeLinked,
+	}
+
+	if err := service.linkEvRepo.Insert(ctx, tx, ev); err != nil {
+		return handlers.WrapError(err, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	if fin, ok := c.(linking.Finisher); ok {
+		if err := fin.Finish(ctx, req, acc, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := commit(); err != nil {
+		return handlers.WrapError(err, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// consumeChallenge deletes chl within the linking transaction, so that the message signed with its nonce cannot be replayed.
+//
+// The challenge is locked first, and must still be the one the message was signed for.
+func consumeChallenge(ctx context.Context, ds Datastore, repo challengeRepo, chl model.Challenge, now time.Time) error {
+	ctx, tx, rollback, commit, err := getTx(ctx, ds)
+	if err != nil {
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+	defer rollback()
+
+	cur, err := repo.GetForUpdate(ctx, tx, chl.PaymentID)
+	if err != nil {
+		if errors.Is(err, model.ErrChallengeNotFound) {
+			return handlers.WrapError(err, "linking challenge not found", http.StatusNotFound)
+		}
+
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if cur.Nonce != chl.Nonce {
+		return handlers.WrapError(model.ErrChallengeNotFound, "linking challenge not found", http.StatusNotFound)
+	}
+
+	if err := cur.IsValid(now); err != nil {
+		return handlers.WrapError(err, "linking challenge expired", http.StatusUnauthorized)
+	}
+
+	if err := repo.Delete(ctx, tx, cur.PaymentID); err != nil {
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if err := commit(); err != nil {
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// recordRejection appends the rejection of linking acc to the linking event log.
+//
+// The rejection has already been decided, so failing to record it is only logged.
+func (service *Service) recordRejection(ctx context.Context, c linking.Custodian, req *linking.Request, acc *linking.Account, err error) {
+	ev := &model.LinkingEvent{
+		WalletID:  req.PaymentID,
+		Custodian: c.Name(),
+		Country:   acc.Country,
+		Outcome:   model.LinkingOutcomeRejected,
+		Reason:    string(rejectionReason(err)),
+	}
+
+	if acc.ID != "" {
+		linkingID := uuid.NewV5(ClaimNamespace, acc.ID)
+		ev.LinkingID = &linkingID
+	}
+
+	if err := service.linkEvRepo.Insert(ctx, service.Datastore.RawDB(), ev); err != nil {
+		logging.Logger(ctx, "wallet").Error().Err(err).Str("custodian", c.Name()).Msg("failed to record linking rejection")
+	}
+}
+
+func rejectionReason(err error) linking.Reason {
+	var rerr *linking.RejectedError
+	switch {
+	case errors.As(err, &rerr):
+		return rerr.Reason
+	case errors.Is(err, errorutils.ErrInvalidCountry):
+		return linking.ReasonRegionBlocked
+	default:
+		return linking.ReasonOther
+	}
+}
+
+// checkLinkingReputable checks with the reputation service that walletID can be linked to an account in country.
+func checkLinkingReputable(ctx context.Context, walletID uuid.UUID, country string) error {
+	repClient, ok := ctx.Value(appctx.ReputationClientCTXKey).(reputation.Client)
+	if !ok {
+		return handlers.WrapError(ErrNoReputationClient, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	if _, _, err := repClient.IsLinkingReputable(ctx, walletID, country); err != nil {
+		return handlers.WrapError(fmt.Errorf("failed to check wallet rep: %w", err), "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// linkingHandlerNames holds the names the linking requests of each custodian are instrumented under.
+var linkingHandlerNames = map[string]string{
+	"uphold":   "LinkUpholdDepositAccount",
+	"bitflyer": "LinkBitFlyerDepositAccount",
+	"gemini":   "LinkGeminiDepositAccount",
+	"zebpay":   "LinkZebPayDepositAccount",
+	"solana":   "LinkSolanaAddress",
+	"ethereum": "LinkEthereumAddress",
+}
+
+// linkingOptionsHandlerNames holds the names the preflight requests of dApp custodians are instrumented under.
+var linkingOptionsHandlerNames = map[string]string{
+	"solana":   "LinkSolanaAddressOptions",
+	"ethereum": "LinkEthereumAddressOptions",
+}
+
+// instrumentLinking instruments h under the name of the custodian in the url, or under fallback for other custodians.
+func instrumentLinking(metricsMw middleware.InstrumentHandlerDef, names map[string]string, fallback string, h http.Handler) http.Handler {
+	byCustodian := make(map[string]http.Handler, len(names))
+	for custodian, name := range names {
+		byCustodian[custodian] = metricsMw(name, h)
+	}
+
+	other := metricsMw(fallback, h)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ih, ok := byCustodian[chi.URLParam(r, "custodian")]; ok {
+			ih.ServeHTTP(w, r)
+			return
+		}
+
+		other.ServeHTTP(w, r)
+	})
+}
+
+// linkingAuthMw returns a middleware which authenticates linking requests as the custodian in the url requires.
+func linkingAuthMw(s *Service, dAppCorsMw func(next http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		signed := middleware.HTTPSignedOnly(s)(next)
+		dApp := dAppCorsMw(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := s.custodians.get(chi.URLParam(r, "custodian"))
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch c.Auth() {
+			case linking.AuthHTTPSignature:
+				signed.ServeHTTP(w, r)
+			case linking.AuthDApp:
+				dApp.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package wallet
+
+import (
+	"context"
+
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/inputs"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+)
+
+// bitFlyerCustodian links rewards wallets to bitFlyer accounts.
+//
+// The linking info is a jwt signed by bitFlyer, which is only issued for accounts that have passed KYC.
+type bitFlyerCustodian struct{}
+
+func newBitFlyerCustodian() *bitFlyerCustodian {
+	return &bitFlyerCustodian{}
+}
+
+func (c *bitFlyerCustodian) Name() string {
+	return "bitflyer"
+}
+
+func (c *bitFlyerCustodian) Auth() linking.Auth {
+	return linking.AuthHTTPSignature
+}
+
+func (c *bitFlyerCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableBitflyerLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *bitFlyerCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	blr := &BitFlyerLinkingRequest{}
+	if err := inputs.DecodeAndValidate(ctx, blr, req.Body); err != nil {
+		return nil, blr.HandleErrors(err)
+	}
+
+	result := &linking.Account{
+		ID:                 blr.AccountHash,
+		DepositDestination: blr.DepositID,
+		Country:            "JP",
+	}
+
+	return result, nil
+}
+
+func (c *bitFlyerCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	return checkLinkingReputable(ctx, req.PaymentID, acc.Country)
+}
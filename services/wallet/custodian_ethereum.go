@@ -0,0 +1,190 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/brave-intl/bat-go/libs/clients/reputation"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/custodian"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	walletutils "github.com/brave-intl/bat-go/libs/wallet"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+)
+
+type linkEthereumAddrRequest struct {
+	Address          string `json:"address" valid:"required"`
+	SignatureType    string `json:"signatureType" valid:"in(eip191|eip712),required"`
+	Message          string `json:"message"`
+	ChainID          int64  `json:"chainId"`
+	RewardsSignature string `json:"rewardsSignature"`
+	Signature        string `json:"signature" valid:"required"`
+}
+
+// ethereumCustodian links rewards wallets to self-custody EVM addresses, on Ethereum or any of its L2s.
+//
+// The request comes from the dApp, and carries either the linking message signed with personal_sign (EIP-191),
+// or the LinkAddress typed data signed with eth_signTypedData_v4 (EIP-712). Both include the nonce of a challenge
+// created for the rewards wallet.
+type ethereumCustodian struct {
+	ds      Datastore
+	chlRepo challengeRepo
+	alRepo  allowListRepo
+	rep     reputation.Client
+	regions func() custodian.Regions
+	metric  metricSvc
+}
+
+type ethereumDetails struct {
+	chl model.Challenge
+}
+
+func newEthereumCustodian(
+	ds Datastore,
+	chlRepo challengeRepo,
+	alRepo allowListRepo,
+	rep reputation.Client,
+	regions func() custodian.Regions,
+	metric metricSvc,
+) *ethereumCustodian {
+	result := &ethereumCustodian{
+		ds:      ds,
+		chlRepo: chlRepo,
+		alRepo:  alRepo,
+		rep:     rep,
+		regions: regions,
+		metric:  metric,
+	}
+
+	return result
+}
+
+func (c *ethereumCustodian) Name() string {
+	return "ethereum"
+}
+
+func (c *ethereumCustodian) Auth() linking.Auth {
+	return linking.AuthDApp
+}
+
+func (c *ethereumCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableEthereumLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *ethereumCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	var ethReq linkEthereumAddrRequest
+	if err := json.Unmarshal(req.Body, &ethReq); err != nil {
+		return nil, handlers.WrapError(err, "error decoding body", http.StatusBadRequest)
+	}
+
+	if _, err := govalidator.ValidateStruct(ethReq); err != nil {
+		return nil, handlers.WrapValidationError(err)
+	}
+
+	repSum, err := c.rep.GetReputationSummary(ctx, req.PaymentID)
+	if err != nil {
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	chl, err := c.chlRepo.Get(ctx, c.ds.RawDB(), req.PaymentID)
+	if err != nil {
+		if errors.Is(err, model.ErrChallengeNotFound) {
+			return nil, handlers.WrapError(err, "linking challenge not found", http.StatusNotFound)
+		}
+
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if err := chl.IsValid(time.Now()); err != nil {
+		c.metric.LinkFailureEthereumChl(repSum.GeoCountry)
+		return nil, handlers.WrapError(err, "linking challenge expired", http.StatusUnauthorized)
+	}
+
+	w, err := c.ds.GetWallet(ctx, req.PaymentID)
+	if err != nil {
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if w == nil {
+		return nil, handlers.WrapError(model.ErrWalletNotFound, "rewards wallet not found", http.StatusNotFound)
+	}
+
+	if err := w.LinkEVMAddress(ctx, walletutils.EVMLinkReq{
+		Address:    ethReq.Address,
+		Sig:        ethReq.Signature,
+		Kind:       ethReq.SignatureType,
+		Msg:        ethReq.Message,
+		ChainID:    ethReq.ChainID,
+		RewardsSig: ethReq.RewardsSignature,
+		Nonce:      chl.Nonce,
+	}); err != nil {
+		c.metric.LinkFailureEthereumMsg(repSum.GeoCountry)
+
+		var evmErr *walletutils.LinkEVMAddressError
+		if errors.As(err, &evmErr) {
+			return nil, handlers.WrapError(evmErr, "invalid ethereum linking signature", http.StatusUnauthorized)
+		}
+
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	result := &linking.Account{
+		ID:                 w.UserDepositDestination,
+		DepositDestination: w.UserDepositDestination,
+		Country:            repSum.GeoCountry,
+		Details:            &ethereumDetails{chl: chl},
+	}
+
+	return result, nil
+}
+
+// Check rejects accounts in regions which are not allowed. Ethereum has no regions field of its own, so linking is
+// unavailable until the custodian regions list it.
+func (c *ethereumCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	regions := c.regions()
+	if allowed, ok := regions.Get(c.Name()); !ok || !allowed.Verdict(acc.Country) {
+		c.metric.LinkFailureEthereumRegion(acc.Country)
+		return linking.Reject(
+			linking.ReasonRegionBlocked,
+			handlers.WrapError(errDisabledRegion, "region is currently disabled for linking", http.StatusBadRequest),
+		)
+	}
+
+	if err := isWalletWhitelisted(ctx, c.ds.RawDB(), c.alRepo, req.PaymentID); err != nil {
+		c.metric.LinkFailureEthereumWhitelist(acc.Country)
+
+		if errors.Is(err, model.ErrWalletNotWhitelisted) {
+			return handlers.WrapError(err, "rewards wallet not whitelisted", http.StatusForbidden)
+		}
+
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// Finish deletes the challenge together with linking, so that the signed message cannot be replayed.
+func (c *ethereumCustodian) Finish(ctx context.Context, _ *linking.Request, acc *linking.Account, linkErr error) error {
+	if linkErr != nil {
+		return nil
+	}
+
+	det, ok := acc.Details.(*ethereumDetails)
+	if !ok {
+		return handlers.WrapError(model.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+	}
+
+	if err := consumeChallenge(ctx, c.ds, c.chlRepo, det.chl, time.Now()); err != nil {
+		return err
+	}
+
+	c.metric.LinkSuccessEthereum(acc.Country)
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/brave-intl/bat-go/libs/clients/gemini"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/custodian"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/inputs"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+const errNoAcceptedDocumentType model.Error = "no accepted document type"
+
+// geminiCustodian links rewards wallets to Gemini accounts.
+//
+// The verification token is exchanged with Gemini for the validated account, and the recipient id is its deposit id.
+type geminiCustodian struct {
+	ds      Datastore
+	gemini  geminiSvc
+	metric  metricSvc
+	regions func() custodian.Regions
+}
+
+func newGeminiCustodian(ds Datastore, gemini geminiSvc, metric metricSvc, regions func() custodian.Regions) *geminiCustodian {
+	return &geminiCustodian{ds: ds, gemini: gemini, metric: metric, regions: regions}
+}
+
+func (c *geminiCustodian) Name() string {
+	return "gemini"
+}
+
+func (c *geminiCustodian) Auth() linking.Auth {
+	return linking.AuthHTTPSignature
+}
+
+func (c *geminiCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableGeminiLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *geminiCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	glr := &GeminiLinkingRequest{}
+	if err := inputs.DecodeAndValidate(ctx, glr, req.Body); err != nil {
+		return nil, glr.HandleErrors(err)
+	}
+
+	cl, err := c.ds.GetCustodianLinkByWalletID(ctx, req.PaymentID)
+	if err != nil && !errors.Is(err, model.ErrNoWalletCustodian) {
+		return nil, handlers.WrapError(err, "failed to check linking mismatch", http.StatusInternalServerError)
+	}
+
+	if cl.isLinked() && !strings.EqualFold(cl.Custodian, c.Name()) {
+		return nil, errCustodianLinkMismatch
+	}
+
+	gc, ok := ctx.Value(appctx.GeminiClientCTXKey).(gemini.Client)
+	if !ok {
+		return nil, handlers.WrapError(appctx.ErrNotInContext, "gemini client misconfigured", http.StatusInternalServerError)
+	}
+
+	acc, err := gc.FetchValidatedAccount(ctx, glr.VerificationToken, glr.DepositID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate account: %w", err)
+	}
+
+	c.metric.CountDocTypeByIssuingCntry(acc.ValidDocuments)
+
+	// Some Gemini accounts do not have valid documents setup. For accounts that are already linked i.e. are
+	// re-authenticating we can fall back to the legacy country code. New or re-linkings should not fall back.
+	isAuth := cl.isLinked() && *cl.LinkingID == uuid.NewV5(ClaimNamespace, acc.ID)
+	issuingCountry := c.gemini.GetIssuingCountry(acc, isAuth)
+	if issuingCountry == "" {
+		return nil, fmt.Errorf("failed to validate account: %w", errNoAcceptedDocumentType)
+	}
+
+	result := &linking.Account{
+		ID:                 acc.ID,
+		DepositDestination: glr.DepositID,
+		Country:            issuingCountry,
+	}
+
+	return result, nil
+}
+
+func (c *geminiCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	if err := c.gemini.IsRegionAvailable(ctx, acc.Country, c.regions()); err != nil {
+		if !errors.Is(err, errorutils.ErrInvalidCountry) {
+			return fmt.Errorf("failed to validate account: %w", err)
+		}
+
+		// If a wallet has previously been linked i.e. has a prior linking, but the country is now invalid/blocked
+		// then we can allow the account to link due to its prior successful linking i.e. it is grandfathered.
+		// If there is no prior linking and the country is invalid/blocked then we should apply the current rules and block it.
+		hasPriorLinking, priorLinkingErr := c.ds.HasPriorLinking(ctx, req.PaymentID, uuid.NewV5(ClaimNamespace, acc.ID))
+		if priorLinkingErr != nil && !errors.Is(priorLinkingErr, sql.ErrNoRows) {
+			return fmt.Errorf("failed to check prior linkings: %w", priorLinkingErr)
+		}
+
+		if !hasPriorLinking {
+			c.metric.LinkFailureGemini(acc.Country)
+			return fmt.Errorf("failed to validate account: %w", err)
+		}
+	}
+
+	c.metric.LinkSuccessGemini(acc.Country)
+
+	return checkLinkingReputable(ctx, req.PaymentID, acc.Country)
+}
@@ -0,0 +1,182 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/brave-intl/bat-go/libs/clients/reputation"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/custodian"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	walletutils "github.com/brave-intl/bat-go/libs/wallet"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+)
+
+const errDisabledRegion model.Error = "disabled region"
+
+type linkSolanaAddrRequest struct {
+	SolanaPublicKey string `json:"solanaPublicKey" valid:"length(32|44)"`
+	Message         string `json:"message" valid:"required"`
+	SolanaSignature string `json:"solanaSignature" valid:"required"`
+}
+
+// solanaCustodian links rewards wallets to self-custody Solana addresses.
+//
+// The request comes from the dApp, and carries the linking message signed with the Solana key,
+// which includes the nonce of a challenge created for the rewards wallet.
+type solanaCustodian struct {
+	ds      Datastore
+	chlRepo challengeRepo
+	alRepo  allowListRepo
+	rep     reputation.Client
+	regions func() custodian.Regions
+	metric  metricSvc
+}
+
+type solanaDetails struct {
+	chl model.Challenge
+}
+
+func newSolanaCustodian(
+	ds Datastore,
+	chlRepo challengeRepo,
+	alRepo allowListRepo,
+	rep reputation.Client,
+	regions func() custodian.Regions,
+	metric metricSvc,
+) *solanaCustodian {
+	result := &solanaCustodian{
+		ds:      ds,
+		chlRepo: chlRepo,
+		alRepo:  alRepo,
+		rep:     rep,
+		regions: regions,
+		metric:  metric,
+	}
+
+	return result
+}
+
+func (c *solanaCustodian) Name() string {
+	return "solana"
+}
+
+func (c *solanaCustodian) Auth() linking.Auth {
+	return linking.AuthDApp
+}
+
+func (c *solanaCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableSolanaLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *solanaCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	var solReq linkSolanaAddrRequest
+	if err := json.Unmarshal(req.Body, &solReq); err != nil {
+		return nil, handlers.WrapError(err, "error decoding body", http.StatusBadRequest)
+	}
+
+	if _, err := govalidator.ValidateStruct(solReq); err != nil {
+		return nil, handlers.WrapValidationError(err)
+	}
+
+	repSum, err := c.rep.GetReputationSummary(ctx, req.PaymentID)
+	if err != nil {
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	chl, err := c.chlRepo.Get(ctx, c.ds.RawDB(), req.PaymentID)
+	if err != nil {
+		if errors.Is(err, model.ErrChallengeNotFound) {
+			return nil, handlers.WrapError(err, "linking challenge not found", http.StatusNotFound)
+		}
+
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if err := chl.IsValid(time.Now()); err != nil {
+		c.metric.LinkFailureSolanaChl(repSum.GeoCountry)
+		return nil, handlers.WrapError(err, "linking challenge expired", http.StatusUnauthorized)
+	}
+
+	w, err := c.ds.GetWallet(ctx, req.PaymentID)
+	if err != nil {
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	if w == nil {
+		return nil, handlers.WrapError(model.ErrWalletNotFound, "rewards wallet not found", http.StatusNotFound)
+	}
+
+	if err := w.LinkSolanaAddress(ctx, walletutils.SolanaLinkReq{
+		Pub:   solReq.SolanaPublicKey,
+		Sig:   solReq.SolanaSignature,
+		Msg:   solReq.Message,
+		Nonce: chl.Nonce,
+	}); err != nil {
+		c.metric.LinkFailureSolanaMsg(repSum.GeoCountry)
+
+		var solErr *walletutils.LinkSolanaAddressError
+		if errors.As(err, &solErr) {
+			return nil, handlers.WrapError(solErr, "invalid solana linking message", http.StatusUnauthorized)
+		}
+
+		return nil, handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	result := &linking.Account{
+		ID:                 w.UserDepositDestination,
+		DepositDestination: w.UserDepositDestination,
+		Country:            repSum.GeoCountry,
+		Details:            &solanaDetails{chl: chl},
+	}
+
+	return result, nil
+}
+
+func (c *solanaCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	if !c.regions().Solana.Verdict(acc.Country) {
+		c.metric.LinkFailureSolanaRegion(acc.Country)
+		return linking.Reject(
+			linking.ReasonRegionBlocked,
+			handlers.WrapError(errDisabledRegion, "region is currently disabled for linking", http.StatusBadRequest),
+		)
+	}
+
+	if err := isWalletWhitelisted(ctx, c.ds.RawDB(), c.alRepo, req.PaymentID); err != nil {
+		c.metric.LinkFailureSolanaWhitelist(acc.Country)
+
+		if errors.Is(err, model.ErrWalletNotWhitelisted) {
+			return handlers.WrapError(err, "rewards wallet not whitelisted", http.StatusForbidden)
+		}
+
+		return handlers.WrapError(err, "internal server error", http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// Finish deletes the challenge together with linking, so that the signed message cannot be replayed.
+func (c *solanaCustodian) Finish(ctx context.Context, _ *linking.Request, acc *linking.Account, linkErr error) error {
+	if linkErr != nil {
+		return nil
+	}
+
+	det, ok := acc.Details.(*solanaDetails)
+	if !ok {
+		return handlers.WrapError(model.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+	}
+
+	if err := consumeChallenge(ctx, c.ds, c.chlRepo, det.chl, time.Now()); err != nil {
+		return err
+	}
+
+	c.metric.LinkSuccessSolana(acc.Country)
+
+	return nil
+}
@@ -0,0 +1,491 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+	should "github.com/stretchr/testify/assert"
+	must "github.com/stretchr/testify/require"
+
+	"github.com/brave-intl/bat-go/libs/clients/reputation"
+	mockreputation "github.com/brave-intl/bat-go/libs/clients/reputation/mock"
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/custodian"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+	"github.com/brave-intl/bat-go/libs/handlers"
+
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+	"github.com/brave-intl/bat-go/services/wallet/storage"
+)
+
+func TestCustodians(t *testing.T) {
+	var cs custodians
+
+	cs.set(&mockCustodian{name: "uphold"})
+	cs.set(&mockCustodian{name: "ethereum"})
+	cs.set(&mockCustodian{name: "uphold", auth: linking.AuthDApp})
+
+	must.Equal(t, 2, len(cs))
+
+	c, ok := cs.get("uphold")
+	must.True(t, ok)
+	should.Equal(t, linking.AuthDApp, c.Auth())
+
+	_, ok = cs.get("unknown")
+	should.False(t, ok)
+}
+
+func TestLinkDepositAccountV3_Routes(t *testing.T) {
+	type tcGiven struct {
+		path string
+		ctx  context.Context
+	}
+
+	type tcExpected struct {
+		code int
+		msg  string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	paymentID := uuid.NewV4().String()
+
+	tests := []testCase{
+		{
+			name:  "unknown_custodian",
+			given: tcGiven{path: "/v3/wallet/unknown/" + paymentID + "/connect", ctx: context.Background()},
+			exp:   tcExpected{code: http.StatusNotFound, msg: "custodian not found"},
+		},
+
+		{
+			name: "disabled_uphold",
+			given: tcGiven{
+				path: "/v3/wallet/uphold/" + paymentID + "/connect",
+				ctx:  context.WithValue(context.Background(), appctx.DisableUpholdLinkingCTXKey, true),
+			},
+			exp: tcExpected{
+				code: http.StatusBadRequest,
+				msg:  "Error validating Connecting Brave Rewards to Uphold is temporarily unavailable. Please try again later",
+			},
+		},
+
+		{
+			name:  "registered_custodian_claim",
+			given: tcGiven{path: "/v3/wallet/mock/" + paymentID + "/claim", ctx: context.Background()},
+			exp:   tcExpected{code: http.StatusTeapot, msg: "error linking wallet: verification failed"},
+		},
+
+		{
+			name:  "invalid_payment_id",
+			given: tcGiven{path: "/v3/wallet/mock/invalid/connect", ctx: context.Background()},
+			exp:   tcExpected{code: http.StatusBadRequest, msg: "Error validating error validating paymentID url parameter"},
+		},
+	}
+
+	viper.Set("enable-link-drain-flag", true)
+	t.Cleanup(func() { viper.Set("enable-link-drain-flag", false) })
+
+	s, _ := initSvcWithMockDB(t)
+	s.RegisterCustodian(&mockCustodian{
+		name:      "mock",
+		auth:      linking.AuthProof,
+		verifyErr: handlers.WrapError(errors.New("invalid token"), "verification failed", http.StatusTeapot),
+	})
+
+	mw := func(name string, h http.Handler) http.Handler {
+		return h
+	}
+
+	noCors := func(next http.Handler) http.Handler {
+		return next
+	}
+
+	r := RegisterRoutes(context.Background(), s, chi.NewRouter(), mw, noCors)
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.given.path, bytes.NewBufferString("{}")).WithContext(tc.given.ctx)
+			req.Header.Set("content-type", "application/json")
+
+			rw := httptest.NewRecorder()
+			rw.Header().Set("content-type", "application/json")
+
+			r.ServeHTTP(rw, req)
+
+			must.Equal(t, tc.exp.code, rw.Code, rw.Body.String())
+			should.Contains(t, rw.Body.String(), tc.exp.msg)
+		})
+	}
+}
+
+type mockCustodian struct {
+	name      string
+	auth      linking.Auth
+	verifyErr error
+}
+
+func (c *mockCustodian) Name() string {
+	return c.name
+}
+
+func (c *mockCustodian) Auth() linking.Auth {
+	return c.auth
+}
+
+func (c *mockCustodian) IsDisabled(_ context.Context) bool {
+	return false
+}
+
+func (c *mockCustodian) Verify(_ context.Context, _ *linking.Request) (*linking.Account, error) {
+	if c.verifyErr != nil {
+		return nil, c.verifyErr
+	}
+
+	return &linking.Account{ID: "id", DepositDestination: "destination"}, nil
+}
+
+func (c *mockCustodian) Check(_ context.Context, _ *linking.Request, _ *linking.Account) error {
+	return nil
+}
+
+func TestRejectionReason(t *testing.T) {
+	type testCase struct {
+		name  string
+		given error
+		exp   linking.Reason
+	}
+
+	tests := []testCase{
+		{
+			name:  "rejected",
+			given: linking.Reject(linking.ReasonKYCFailed, handlers.WrapError(errors.New("kyc"), "KYC required", http.StatusForbidden)),
+			exp:   linking.ReasonKYCFailed,
+		},
+
+		{
+			name:  "invalid_country",
+			given: fmt.Errorf("wallet could not be kyc checked: %w", errorutils.ErrInvalidCountry),
+			exp:   linking.ReasonRegionBlocked,
+		},
+
+		{
+			name:  "other",
+			given: handlers.WrapError(errors.New("db"), "unable to link wallets", http.StatusInternalServerError),
+			exp:   linking.ReasonOther,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			should.Equal(t, tc.exp, rejectionReason(tc.given))
+		})
+	}
+}
+
+func TestEthereumCustodian_Verify(t *testing.T) {
+	// The linking typed data is signed ahead of time for this wallet, nonce and address on Base (chain 8453).
+	const (
+		rewardsPub = "ea4a6c63e29c520abef5507b132ec5f9954776aebebe7b92421eea691446d22c"
+		nonce      = "9c2f4e1a-6b0d-4c5e-8f3a-1d7b2e9c4a60"
+		address    = "0xBBceaAaE039873aB8ba5F5162e331541BF15A95E"
+		rewSig     = "NFLGEA9xUbAhDZCYW9UsKCFOoAw7Ry6RB84lkwnIBpZEQcPexNMh0x-YGgGj0vDhSA_IAWIq3oAzUCZYMds1DQ=="
+		ethSig     = "0xbc51bc5c6a0dc1fbdc4747720bc09708a9d7664b6927144af2610d508d0131724a7eeeba5e1c158110fc8626c567a9f39ab100f4404b84f7038366effcca4cc21c"
+	)
+
+	paymentID := uuid.Must(uuid.FromString("2a8d6d6c-3f7d-4e4b-9a39-5f1c3f0c6e21"))
+
+	type tcGiven struct {
+		req linkEthereumAddrRequest
+	}
+
+	type tcExpected struct {
+		addr string
+		code int
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name: "success",
+			given: tcGiven{
+				req: linkEthereumAddrRequest{
+					Address:          address,
+					SignatureType:    "eip712",
+					ChainID:          8453,
+					RewardsSignature: rewSig,
+					Signature:        ethSig,
+				},
+			},
+			exp: tcExpected{addr: address},
+		},
+
+		{
+			name: "lower_case_address",
+			given: tcGiven{
+				req: linkEthereumAddrRequest{
+					Address:          "0xbbceaaae039873ab8ba5f5162e331541bf15a95e",
+					SignatureType:    "eip712",
+					ChainID:          8453,
+					RewardsSignature: rewSig,
+					Signature:        ethSig,
+				},
+			},
+			exp: tcExpected{addr: address},
+		},
+
+		{
+			name: "other_chain",
+			given: tcGiven{
+				req: linkEthereumAddrRequest{
+					Address:          address,
+					SignatureType:    "eip712",
+					ChainID:          1,
+					RewardsSignature: rewSig,
+					Signature:        ethSig,
+				},
+			},
+			exp: tcExpected{code: http.StatusUnauthorized},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := initSvcWithMockDB(t)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			rep := mockreputation.NewMockClient(ctrl)
+			rep.EXPECT().GetReputationSummary(gomock.Any(), paymentID).Return(reputation.RepSummaryResponse{GeoCountry: "US"}, nil)
+
+			mock.ExpectQuery("^select (.+) from challenge (.+)").WithArgs(paymentID).
+				WillReturnRows(sqlmock.NewRows([]string{"payment_id", "created_at", "nonce"}).AddRow(paymentID, time.Now(), nonce))
+			mockSQLWallet(mock, paymentID, rewardsPub)
+
+			c := newEthereumCustodian(s.Datastore, storage.NewChallenge(), storage.NewAllowList(), rep, s.getCustodianRegions, &mockMtc{})
+
+			body, err := json.Marshal(tc.given.req)
+			must.NoError(t, err)
+
+			acc, err := c.Verify(context.Background(), &linking.Request{PaymentID: paymentID, Body: body})
+			if tc.exp.code != 0 {
+				var appErr *handlers.AppError
+				must.ErrorAs(t, err, &appErr)
+
+				should.Equal(t, tc.exp.code, appErr.Code)
+				return
+			}
+
+			must.NoError(t, err)
+
+			should.Equal(t, tc.exp.addr, acc.ID)
+			should.Equal(t, tc.exp.addr, acc.DepositDestination)
+			should.Equal(t, "US", acc.Country)
+		})
+	}
+}
+
+func TestEthereumCustodian_Check(t *testing.T) {
+	type tcGiven struct {
+		regions custodian.Regions
+		country string
+	}
+
+	type tcExpected struct {
+		reason linking.Reason
+		err    bool
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	allowUS := custodian.Regions{Other: map[string]custodian.GeoAllowBlockMap{"ethereum": {Allow: []string{"US"}}}}
+
+	tests := []testCase{
+		{
+			name:  "allowed",
+			given: tcGiven{regions: allowUS, country: "US"},
+		},
+
+		{
+			name:  "not_allowed",
+			given: tcGiven{regions: allowUS, country: "DE"},
+			exp:   tcExpected{reason: linking.ReasonRegionBlocked, err: true},
+		},
+
+		{
+			name:  "not_configured",
+			given: tcGiven{regions: custodian.Regions{Solana: custodian.GeoAllowBlockMap{Allow: []string{"US"}}}, country: "US"},
+			exp:   tcExpected{reason: linking.ReasonRegionBlocked, err: true},
+		},
+	}
+
+	paymentID := uuid.NewV4()
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := initSvcWithMockDB(t)
+			s.SetCustodianRegions(tc.given.regions)
+
+			mock.ExpectQuery("^select (.+) from allow_list (.+)").WithArgs(paymentID).
+				WillReturnRows(sqlmock.NewRows([]string{"payment_id", "created_at"}).AddRow(paymentID, time.Now()))
+
+			c := newEthereumCustodian(s.Datastore, storage.NewChallenge(), storage.NewAllowList(), nil, s.getCustodianRegions, &mockMtc{})
+
+			err := c.Check(context.Background(), &linking.Request{PaymentID: paymentID}, &linking.Account{Country: tc.given.country})
+			if !tc.exp.err {
+				must.NoError(t, err)
+				return
+			}
+
+			var rejected *linking.RejectedError
+			must.ErrorAs(t, err, &rejected)
+
+			should.Equal(t, tc.exp.reason, rejected.Reason)
+		})
+	}
+}
+
+func TestConsumeChallenge(t *testing.T) {
+	type tcGiven struct {
+		rows    *sqlmock.Rows
+		noRows  bool
+		deleted bool
+	}
+
+	type tcExpected struct {
+		code int
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	paymentID := uuid.NewV4()
+	now := time.Now()
+
+	chl := model.Challenge{PaymentID: paymentID, CreatedAt: now.Add(-time.Minute), Nonce: "nonce"}
+
+	cols := []string{"payment_id", "created_at", "nonce"}
+
+	tests := []testCase{
+		{
+			name: "success",
+			given: tcGiven{
+				rows:    sqlmock.NewRows(cols).AddRow(paymentID, chl.CreatedAt, "nonce"),
+				deleted: true,
+			},
+		},
+
+		{
+			name:  "already_consumed",
+			given: tcGiven{noRows: true},
+			exp:   tcExpected{code: http.StatusNotFound},
+		},
+
+		{
+			name:  "replaced",
+			given: tcGiven{rows: sqlmock.NewRows(cols).AddRow(paymentID, now, "other_nonce")},
+			exp:   tcExpected{code: http.StatusNotFound},
+		},
+
+		{
+			name:  "expired",
+			given: tcGiven{rows: sqlmock.NewRows(cols).AddRow(paymentID, now.Add(-time.Hour), "nonce")},
+			exp:   tcExpected{code: http.StatusUnauthorized},
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := initSvcWithMockDB(t)
+
+			mock.ExpectBegin()
+
+			q := mock.ExpectQuery("^select (.+) from challenge where payment_id = (.+) for update").WithArgs(paymentID)
+			if tc.given.noRows {
+				q.WillReturnRows(sqlmock.NewRows(cols))
+			} else {
+				q.WillReturnRows(tc.given.rows)
+			}
+
+			if tc.given.deleted {
+				mock.ExpectExec("^delete from challenge (.+)").WithArgs(paymentID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			err := consumeChallenge(context.Background(), s.Datastore, storage.NewChallenge(), chl, now)
+			if tc.exp.code != 0 {
+				var appErr *handlers.AppError
+				must.ErrorAs(t, err, &appErr)
+
+				should.Equal(t, tc.exp.code, appErr.Code)
+			} else {
+				must.NoError(t, err)
+			}
+
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInstrumentLinking(t *testing.T) {
+	var actual []string
+
+	mw := func(name string, h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actual = append(actual, name)
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	h := instrumentLinking(mw, linkingHandlerNames, "LinkDepositAccount", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := chi.NewRouter()
+	r.Method(http.MethodPost, "/{custodian}/{paymentID}/connect", h)
+
+	for _, name := range []string{"uphold", "bitflyer", "solana", "other"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/"+name+"/"+uuid.NewV4().String()+"/connect", nil))
+	}
+
+	should.Equal(t, []string{"LinkUpholdDepositAccount", "LinkBitFlyerDepositAccount", "LinkSolanaAddress", "LinkDepositAccount"}, actual)
+}
@@ -0,0 +1,180 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/custodian"
+	errorutils "github.com/brave-intl/bat-go/libs/errors"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/httpsignature"
+	"github.com/brave-intl/bat-go/libs/inputs"
+	walletutils "github.com/brave-intl/bat-go/libs/wallet"
+	"github.com/brave-intl/bat-go/libs/wallet/provider/uphold"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+)
+
+type submitTxFunc func(ctx context.Context, info *walletutils.Info, transaction, destination string, confirm bool) (*walletutils.TransactionInfo, error)
+
+// upholdCustodian links rewards wallets to Uphold cards.
+//
+// The request carries a transaction to the card signed with the key of the rewards wallet,
+// which is submitted once the card has been linked if it transfers any funds.
+type upholdCustodian struct {
+	ds      Datastore
+	regions func() custodian.Regions
+	submit  submitTxFunc
+}
+
+type upholdDetails struct {
+	wallet   *uphold.Wallet
+	txInfo   *walletutils.TransactionInfo
+	signedTx string
+}
+
+func newUpholdCustodian(ds Datastore, regions func() custodian.Regions, submit submitTxFunc) *upholdCustodian {
+	return &upholdCustodian{ds: ds, regions: regions, submit: submit}
+}
+
+func (c *upholdCustodian) Name() string {
+	return "uphold"
+}
+
+func (c *upholdCustodian) Auth() linking.Auth {
+	return linking.AuthProof
+}
+
+func (c *upholdCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableUpholdLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *upholdCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	cuw := &LinkUpholdDepositAccountRequest{}
+	if err := inputs.DecodeAndValidate(ctx, cuw, req.Body); err != nil {
+		return nil, cuw.HandleErrors(err)
+	}
+
+	info, err := c.ds.GetWallet(ctx, req.PaymentID)
+	if err != nil {
+		return nil, handlers.WrapError(err, "unable to get or create wallets", http.StatusServiceUnavailable)
+	}
+
+	if info == nil {
+		return nil, handlers.WrapError(model.ErrWalletNotFound, "unable to find wallet", http.StatusNotFound)
+	}
+
+	publicKey, err := hex.DecodeString(info.PublicKey)
+	if err != nil {
+		return nil, handlers.WrapError(errors.New("unable to decode wallet public key"),
+			"unable to decode wallet public key for creation request validation",
+			http.StatusInternalServerError)
+	}
+
+	uwallet := &uphold.Wallet{
+		Info:    *info,
+		PrivKey: ed25519.PrivateKey{},
+		PubKey:  httpsignature.Ed25519PubKey(publicKey),
+	}
+
+	txInfo, err := uwallet.VerifyTransaction(ctx, cuw.SignedLinkingRequest)
+	if err != nil {
+		return nil, handlers.WrapError(
+			errors.New("failed to verify transaction"), "transaction verification failure",
+			http.StatusForbidden)
+	}
+
+	result := &linking.Account{
+		DepositDestination: txInfo.Destination,
+		Details: &upholdDetails{
+			wallet:   uwallet,
+			txInfo:   txInfo,
+			signedTx: cuw.SignedLinkingRequest,
+		},
+	}
+
+	return result, nil
+}
+
+// Check verifies with Uphold that the owner of the card has passed KYC, which reveals the user id and country.
+func (c *upholdCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	det, ok := acc.Details.(*upholdDetails)
+	if !ok {
+		return handlers.WrapError(model.ErrInternalServer, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	// add custodian regions to ctx going to client
+	if _, ok := ctx.Value(appctx.CustodianRegionsCTXKey).(custodian.Regions); !ok {
+		cr := c.regions()
+		ctx = context.WithValue(ctx, appctx.CustodianRegionsCTXKey, &cr)
+	}
+
+	var userID, country string
+
+	// verify that the user is kyc from uphold. (for all wallet provider cases)
+	if uID, ok, cc, err := det.wallet.IsUserKYC(ctx, det.txInfo.Destination); err != nil {
+		// check if this account has already been linked to this wallet,
+		if errors.Is(err, errorutils.ErrInvalidCountry) {
+			ok, priorLinkingErr := c.ds.HasPriorLinking(ctx, req.PaymentID, uuid.NewV5(ClaimNamespace, userID))
+			if priorLinkingErr != nil && !errors.Is(priorLinkingErr, sql.ErrNoRows) {
+				return fmt.Errorf("failed to check prior linkings: %w", priorLinkingErr)
+			}
+			// if a wallet has a prior linking to this account, allow the invalid country, otherwise
+			// return the kyc error
+			if !ok {
+				// then pass back the original geo error
+				return err
+			}
+			// allow invalid country if there was a prior linking
+		} else {
+			return fmt.Errorf("wallet could not be kyc checked: %w", err)
+		}
+	} else if !ok {
+		return linking.Reject(linking.ReasonKYCFailed, handlers.WrapError(
+			errors.New("user kyc did not pass"),
+			"KYC required",
+			http.StatusForbidden))
+	} else {
+		userID = uID
+		country = cc
+	}
+
+	// check kyc user id validity
+	if userID == "" {
+		return linking.Reject(linking.ReasonKYCFailed, handlers.WrapError(
+			errors.New("user id not provided"),
+			"KYC required",
+			http.StatusForbidden))
+	}
+
+	acc.ID = userID
+	acc.Country = country
+
+	return checkLinkingReputable(ctx, req.PaymentID, acc.Country)
+}
+
+// AfterLink submits the transaction if it transfers funds, rather than only proving ownership of the card.
+//
+// The card has already been linked, so a failed transfer does not fail the request.
+func (c *upholdCustodian) AfterLink(ctx context.Context, _ *linking.Request, acc *linking.Account) error {
+	det, ok := acc.Details.(*upholdDetails)
+	if !ok || !decimal.Zero.LessThan(det.txInfo.Probi) {
+		return nil
+	}
+
+	info := det.wallet.GetWalletInfo()
+	if _, err := c.submit(ctx, &info, det.signedTx, "", true); err != nil {
+		return fmt.Errorf("failed to transfer tokens: %w", err)
+	}
+
+	return nil
+}
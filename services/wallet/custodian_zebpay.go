@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	appctx "github.com/brave-intl/bat-go/libs/context"
+	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/inputs"
+	"github.com/brave-intl/bat-go/services/wallet/linking"
+	"github.com/brave-intl/bat-go/services/wallet/model"
+)
+
+// zebPayCustodian links rewards wallets to ZebPay accounts.
+type zebPayCustodian struct {
+	metric metricSvc
+}
+
+func newZebPayCustodian(metric metricSvc) *zebPayCustodian {
+	return &zebPayCustodian{metric: metric}
+}
+
+func (c *zebPayCustodian) Name() string {
+	return "zebpay"
+}
+
+func (c *zebPayCustodian) Auth() linking.Auth {
+	return linking.AuthHTTPSignature
+}
+
+func (c *zebPayCustodian) IsDisabled(ctx context.Context) bool {
+	dis, ok := ctx.Value(appctx.DisableZebPayLinkingCTXKey).(bool)
+	return ok && dis
+}
+
+func (c *zebPayCustodian) Verify(ctx context.Context, req *linking.Request) (*linking.Account, error) {
+	zplReq := &ZebPayLinkingRequest{}
+	if err := inputs.DecodeAndValidate(ctx, zplReq, req.Body); err != nil {
+		return nil, HandleErrorsZebPay(err)
+	}
+
+	claims, err := parseZebPayClaims(ctx, zplReq.VerificationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &linking.Account{
+		ID:                 claims.AccountID,
+		DepositDestination: claims.DepositID,
+		Country:            claims.CountryCode,
+		Details:            &claims,
+	}
+
+	return result, nil
+}
+
+// Check validates the claims, so that accounts failing KYC are recorded as rejected.
+func (c *zebPayCustodian) Check(ctx context.Context, req *linking.Request, acc *linking.Account) error {
+	claims, ok := acc.Details.(*claimsZP)
+	if !ok {
+		return handlers.WrapError(model.ErrInternalServer, "unable to link wallets", http.StatusInternalServerError)
+	}
+
+	if err := claims.validate(time.Now()); err != nil {
+		c.metric.LinkFailureZP(acc.Country)
+
+		if errors.Is(err, errZPInvalidKYC) {
+			return linking.Reject(linking.ReasonKYCFailed, handlers.WrapError(err, "KYC required", http.StatusForbidden))
+		}
+
+		return err
+	}
+
+	if err := checkLinkingReputable(ctx, req.PaymentID, acc.Country); err != nil {
+		c.metric.LinkFailureZP(acc.Country)
+		return err
+	}
+
+	return nil
+}
+
+func (c *zebPayCustodian) Finish(_ context.Context, _ *linking.Request, acc *linking.Account, linkErr error) error {
+	if linkErr != nil {
+		c.metric.LinkFailureZP(acc.Country)
+		return nil
+	}
+
+	c.metric.LinkSuccessZP(acc.Country)
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/brave-intl/bat-go/libs/backoff"
 	"github.com/brave-intl/bat-go/services/wallet/model"
+	"github.com/brave-intl/bat-go/services/wallet/storage"
 
 	"github.com/brave-intl/bat-go/libs/altcurrency"
 	"github.com/brave-intl/bat-go/libs/clients/reputation"
@@ -64,8 +65,8 @@ func init() {
 // Datastore holds the interface for the wallet datastore
 type Datastore interface {
 	datastore.Datastore
-	LinkWallet(ctx context.Context, id string, providerID string, providerLinkingID uuid.UUID, depositProvider string) error
-	GetLinkingLimitInfo(ctx context.Context, providerLinkingID string) (map[string]LinkingInfo, error)
+	LinkWallet(ctx context.Context, id string, providerID string, providerLinkingID uuid.UUID, depositProvider, country string) error
+	GetLinkingLimitInfo(ctx context.Context, providerLinkingID, country string) (map[string]LinkingInfo, error)
 	HasPriorLinking(ctx context.Context, walletID uuid.UUID, providerLinkingID uuid.UUID) (bool, error)
 	// GetLinkingsByProviderLinkingID gets the wallet linking info by provider linking id
 	GetLinkingsByProviderLinkingID(ctx context.Context, providerLinkingID uuid.UUID) ([]LinkingMetadata, error)
@@ -84,13 +85,13 @@ type Datastore interface {
 	// UpsertWallet UpsertWallets inserts a wallet if it does not already exist
 	UpsertWallet(ctx context.Context, wallet *walletutils.Info) error
 	// ConnectCustodialWallet - connect the wallet's custodial verified wallet.
-	ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest string) error
+	ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest, country string) error
 	// DisconnectCustodialWallet - disconnect the wallet's custodial id
 	DisconnectCustodialWallet(ctx context.Context, walletID uuid.UUID) error
 	// GetCustodianLinkByWalletID retrieves the currently linked wallet custodian by walletID.
 	GetCustodianLinkByWalletID(ctx context.Context, ID uuid.UUID) (*CustodianLink, error)
 	// GetCustodianLinkCount - get the wallet custodian link count across all wallets
-	GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian string) (int, int, error)
+	GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian, country string) (int, int, error)
 	// InsertVerifiedWalletOutboxTx inserts a verifiedWalletOutbox for processing.
 	InsertVerifiedWalletOutboxTx(ctx context.Context, tx *sqlx.Tx, paymentID uuid.UUID, verifiedWallet bool) error
 	// SendVerifiedWalletOutbox sends requests to reputation service.
@@ -110,7 +111,7 @@ type ReadOnlyDatastore interface {
 	// GetWalletByPublicKey retrieves a wallet by its public key.
 	GetWalletByPublicKey(context.Context, string) (*walletutils.Info, error)
 	// GetCustodianLinkCount - get the wallet custodian link count across all wallets
-	GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian string) (int, int, error)
+	GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian, country string) (int, int, error)
 }
 
 // Postgres is a Datastore wrapper around a postgres database
@@ -164,6 +165,8 @@ func NewPostgres() (Datastore, ReadOnlyDatastore, error) {
 var (
 	// ErrTooManyCardsLinked denotes when more than 3 cards have been linked to a single wallet
 	ErrTooManyCardsLinked = errors.New("unable to add too many wallets to a single user")
+	// ErrRelinkCooldown denotes when an account is linked again too soon after one of its wallets was unlinked
+	ErrRelinkCooldown = errors.New("unable to link a wallet so soon after unlinking")
 	// ErrNoReputationClient is returned when no reputation client is in the ctx.
 	ErrNoReputationClient = errors.New("wallet: no reputation client")
 )
@@ -361,17 +364,49 @@ func txGetCustodianLinkingIDs(ctx context.Context, tx *sqlx.Tx, providerLinkingI
 	return resp, nil
 }
 
-func txGetMaxLinkingSlots(ctx context.Context, tx *sqlx.Tx, custodian, providerLinkingID string) (int, error) {
+// txGetLinkingLimit gets the linking limit which applies to the account with providerLinkingID in country.
+//
+// Custodians without a configured limit get the slots from the environment, see getEnvMaxCards, and no relink cooldown.
+func txGetLinkingLimit(ctx context.Context, tx *sqlx.Tx, custodian, country string, providerLinkingID uuid.UUID) (model.LinkingLimit, error) {
+	lim, err := storage.NewLinkingLimit().GetEffective(ctx, tx, custodian, country, providerLinkingID)
+	if err != nil {
+		if errors.Is(err, model.ErrLinkingLimitNotFound) {
+			return model.LinkingLimit{Custodian: custodian, MaxSlots: getEnvMaxCards(custodian)}, nil
+		}
+		return model.LinkingLimit{}, err
+	}
+
+	return lim, nil
+}
+
+func txGetMaxLinkingSlots(ctx context.Context, tx *sqlx.Tx, slots int, providerLinkingID string) (int, error) {
 	var (
 		max int
 	)
 	statement := `
 		select ($2 + count(1)) as max from linking_limit_adjust where provider_linking_id = $1
 	`
-	err := tx.Get(&max, statement, providerLinkingID, getEnvMaxCards(custodian))
+	err := tx.Get(&max, statement, providerLinkingID, slots)
 	return max, err
 }
 
+func txGetLastUnlinking(ctx context.Context, tx *sqlx.Tx, providerLinkingID uuid.UUID) (*time.Time, error) {
+	stmt := `
+		select
+			max(unlinked_at) as last_unlinking
+		from
+			wallet_custodian
+		where
+			linking_id = $1 and unlinked_at is not null
+	`
+	var last *time.Time
+	if err := tx.Get(&last, stmt, providerLinkingID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return last, nil
+}
+
 func txGetUsedLinkingSlots(ctx context.Context, tx *sqlx.Tx, providerLinkingID string) (int, error) {
 	var (
 		used int
@@ -446,13 +481,14 @@ type LinkingMetadata struct {
 type LinkingInfo struct {
 	LinkingID              *uuid.UUID        `json:"-"`
 	NextAvailableUnlinking *time.Time        `json:"nextAvailableUnlinking,omitempty"`
+	NextAvailableLinking   *time.Time        `json:"nextAvailableLinking,omitempty"`
 	WalletsLinked          int               `json:"walletsLinked"`
 	OpenLinkingSlots       int               `json:"openLinkingSlots"`
 	OtherWalletsLinked     []LinkingMetadata `json:"otherWalletsLinked,omitempty"`
 }
 
-// GetLinkingLimitInfo - get some basic info about linking limit
-func (pg *Postgres) GetLinkingLimitInfo(ctx context.Context, providerLinkingID string) (map[string]LinkingInfo, error) {
+// GetLinkingLimitInfo - get some basic info about linking limit, using the limits for accounts in country
+func (pg *Postgres) GetLinkingLimitInfo(ctx context.Context, providerLinkingID, country string) (map[string]LinkingInfo, error) {
 	var infos = map[string]LinkingInfo{}
 
 	// get tx
@@ -471,20 +507,25 @@ func (pg *Postgres) GetLinkingLimitInfo(ctx context.Context, providerLinkingID s
 
 	// for each custodian linking id found, get the max/used
 	for custodian, linkingID := range custodianLinkingIDs {
-		maxLinkings, err := txGetMaxLinkingSlots(ctx, tx, custodian, linkingID)
+		// convert linking id to uuid
+		lID, err := uuid.FromString(linkingID)
 		if err != nil {
-			return nil, errorutils.Wrap(err, "error looking up max linkings for wallet")
+			return nil, fmt.Errorf("failed to parse linking id: %w", err)
 		}
 
-		usedLinkings, err := txGetUsedLinkingSlots(ctx, tx, linkingID)
+		lim, err := txGetLinkingLimit(ctx, tx, custodian, country, lID)
 		if err != nil {
-			return nil, errorutils.Wrap(err, "error looking up used linkings for wallet")
+			return nil, errorutils.Wrap(err, "error looking up linking limit for wallet")
 		}
 
-		// convert linking id to uuid
-		lID, err := uuid.FromString(linkingID)
+		maxLinkings, err := txGetMaxLinkingSlots(ctx, tx, lim.MaxSlots, linkingID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse linking id: %w", err)
+			return nil, errorutils.Wrap(err, "error looking up max linkings for wallet")
+		}
+
+		usedLinkings, err := txGetUsedLinkingSlots(ctx, tx, linkingID)
+		if err != nil {
+			return nil, errorutils.Wrap(err, "error looking up used linkings for wallet")
 		}
 
 		// lookup other linked wallets
@@ -506,21 +547,13 @@ func (pg *Postgres) GetLinkingLimitInfo(ctx context.Context, providerLinkingID s
 		}
 
 		// get the latest unlinking
-		stmt := `
-			select
-				max(unlinked_at) as last_unlinking
-			from
-				wallet_custodian
-			where
-				linking_id = $1 and unlinked_at is not null
-		`
-		var last *time.Time
-		err = tx.Get(&last, stmt, lID)
-		if err != nil && err != sql.ErrNoRows {
+		last, err := txGetLastUnlinking(ctx, tx, lID)
+		if err != nil {
 			return nil, fmt.Errorf("failed to get max time: %w", err)
 		}
 
 		var nextUnlink time.Time
+		var nextLink *time.Time
 
 		if last != nil {
 			notBefore, err := d.FromNow()
@@ -532,11 +565,16 @@ func (pg *Postgres) GetLinkingLimitInfo(ctx context.Context, providerLinkingID s
 				// now + ( last - notBefore )
 				nextUnlink = time.Now().Add(last.Sub(*notBefore))
 			}
+
+			if next := last.Add(lim.RelinkCooldown()); next.After(time.Now()) {
+				nextLink = &next
+			}
 		}
 
 		// add to result
 		infos[custodian] = LinkingInfo{
 			NextAvailableUnlinking: &nextUnlink,
+			NextAvailableLinking:   nextLink,
 			LinkingID:              &lID,
 			WalletsLinked:          usedLinkings,
 			OpenLinkingSlots:       maxLinkings - usedLinkings,
@@ -560,7 +598,7 @@ var (
 )
 
 // LinkWallet links a rewards wallet to the given deposit provider.
-func (pg *Postgres) LinkWallet(ctx context.Context, id string, userDepositDestination string, providerLinkingID uuid.UUID, depositProvider string) error {
+func (pg *Postgres) LinkWallet(ctx context.Context, id string, userDepositDestination string, providerLinkingID uuid.UUID, depositProvider, country string) error {
 	walletID, err := uuid.FromString(id)
 	if err != nil {
 		return fmt.Errorf("invalid wallet id, not uuid: %w", err)
@@ -584,7 +622,7 @@ func (pg *Postgres) LinkWallet(ctx context.Context, id string, userDepositDestin
 		WalletID:  &walletID,
 		Custodian: depositProvider,
 		LinkingID: &providerLinkingID,
-	}, userDepositDestination); err != nil {
+	}, userDepositDestination, country); err != nil {
 		return fmt.Errorf("error connect custodian wallet: %w", err)
 	}
 
@@ -623,7 +661,7 @@ func (pg *Postgres) LinkWallet(ctx context.Context, id string, userDepositDestin
 // CustodianLink representation a wallet_custodian record.
 type CustodianLink struct {
 	WalletID       *uuid.UUID `json:"wallet_id" db:"wallet_id" valid:"uuidv4"`
-	Custodian      string     `json:"custodian" db:"custodian" valid:"in(uphold,brave,gemini,bitflyer)"`
+	Custodian      string     `json:"custodian" db:"custodian" valid:"alphanum,lowercase"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at" valid:"-"`
 	UpdatedAt      *time.Time `json:"updated_at" db:"updated_at" valid:"-"`
 	LinkedAt       time.Time  `json:"linked_at" db:"linked_at" valid:"-"`
@@ -634,15 +672,6 @@ type CustodianLink struct {
 
 // TODO(clD11): Wallet Refactor. These should not be nullable, fix pointers and raname fields for consistency.
 
-func NewSolanaCustodialLink(walletID uuid.UUID, depositDestination string) *CustodianLink {
-	const depositProviderSolana = "solana"
-	return &CustodianLink{
-		WalletID:  &walletID,
-		LinkingID: ptrFromUUID(uuid.NewV5(ClaimNamespace, depositDestination)),
-		Custodian: depositProviderSolana,
-	}
-}
-
 // GetWalletIDString - get string version of the WalletID
 func (cl *CustodianLink) GetWalletIDString() string {
 	if cl.WalletID != nil {
@@ -663,8 +692,8 @@ func (cl *CustodianLink) isLinked() bool {
 	return cl != nil && cl.UnlinkedAt == nil && cl.DisconnectedAt == nil && !cl.LinkedAt.IsZero()
 }
 
-// GetCustodianLinkCount - get the wallet custodian link count across all wallets
-func (pg *Postgres) GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian string) (int, int, error) {
+// GetCustodianLinkCount - get the wallet custodian link count across all wallets, and the limit for accounts in country
+func (pg *Postgres) GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian, country string) (int, int, error) {
 	// the count of linked wallets
 	var err error
 
@@ -677,37 +706,74 @@ func (pg *Postgres) GetCustodianLinkCount(ctx context.Context, linkingID uuid.UU
 		Msg("starting GetCustodianLinkCount")
 
 	// get tx
-	ctx, _, rollback, commit, err := getTx(ctx, pg)
+	ctx, tx, rollback, commit, err := getTx(ctx, pg)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to create db transaction GetCustodianLinkByWalletID: %w", err)
 	}
 	// will rollback if tx created at this scope
 	defer rollback()
 
-	li, err := pg.GetLinkingLimitInfo(ctx, linkingID.String())
+	li, err := pg.GetLinkingLimitInfo(ctx, linkingID.String(), country)
 	if err != nil {
 		sublogger.Error().Err(err).
 			Msg("failed to get CustodianLinkCount from DB")
 		return 0, 0, fmt.Errorf("failed to get CustodianLinkCount from DB: %w", err)
 	}
 
-	// if the tx was created in this scope we will commit here
-	if err := commit(); err != nil {
-		return 0, 0, fmt.Errorf("failed to commit GetCustodianByWalletID transaction: %w", err)
-	}
-
 	for _, linkingInfo := range li {
 		// find the right linking id
 		if linkingInfo.LinkingID.String() == linkingID.String() {
+			// if the tx was created in this scope we will commit here
+			if err := commit(); err != nil {
+				return 0, 0, fmt.Errorf("failed to commit GetCustodianByWalletID transaction: %w", err)
+			}
+
 			// max is wallets linked + open slots
 			return linkingInfo.WalletsLinked, linkingInfo.WalletsLinked + linkingInfo.OpenLinkingSlots, nil
 		}
 	}
+
 	// wallets linked/ open linking slots not found
 	// this is the case where there is no prior linkages
-	// 0 linked, get max from environment
-	return 0, getEnvMaxCards(custodian), nil
+	// 0 linked, get max from the linking limits
+	lim, err := txGetLinkingLimit(ctx, tx, custodian, country, linkingID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get linking limit: %w", err)
+	}
+
+	max, err := txGetMaxLinkingSlots(ctx, tx, lim.MaxSlots, linkingID.String())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get max linkings: %w", err)
+	}
+
+	// if the tx was created in this scope we will commit here
+	if err := commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit GetCustodianByWalletID transaction: %w", err)
+	}
 
+	return 0, max, nil
+}
+
+func txCheckRelinkCooldown(ctx context.Context, tx *sqlx.Tx, custodian, country string, linkingID uuid.UUID) error {
+	lim, err := txGetLinkingLimit(ctx, tx, custodian, country, linkingID)
+	if err != nil {
+		return fmt.Errorf("failed to get linking limit: %w", err)
+	}
+
+	if lim.RelinkCooldown() == 0 {
+		return nil
+	}
+
+	last, err := txGetLastUnlinking(ctx, tx, linkingID)
+	if err != nil {
+		return fmt.Errorf("failed to get last unlinking: %w", err)
+	}
+
+	if last != nil && time.Now().Before(last.Add(lim.RelinkCooldown())) {
+		return ErrRelinkCooldown
+	}
+
+	return nil
 }
 
 func rollbackFn(ctx context.Context, datastore Datastore, tx *sqlx.Tx) func() {
@@ -835,7 +901,7 @@ func (pg *Postgres) DisconnectCustodialWallet(ctx context.Context, walletID uuid
 }
 
 // ConnectCustodialWallet - create a record of a custodian wallet
-func (pg *Postgres) ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest string) error {
+func (pg *Postgres) ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest, country string) error {
 	var err error
 	// create a sublogger
 	sublogger := logger(ctx).With().
@@ -889,7 +955,7 @@ func (pg *Postgres) ConnectCustodialWallet(ctx context.Context, cl *CustodianLin
 		// if the existingLinkingID is null then we need to check the linking limits
 
 		// get the count
-		used, max, err := pg.GetCustodianLinkCount(ctx, *cl.LinkingID, cl.Custodian)
+		used, max, err := pg.GetCustodianLinkCount(ctx, *cl.LinkingID, cl.Custodian, country)
 		if err != nil {
 			sublogger.Error().Err(err).
 				Msg("failed to insert wallet_custodian due to db err checking linking limits")
@@ -913,6 +979,11 @@ func (pg *Postgres) ConnectCustodialWallet(ctx context.Context, cl *CustodianLin
 			return ErrTooManyCardsLinked
 		}
 
+		// check the account is not linked again too soon after an unlinking
+		if err := txCheckRelinkCooldown(ctx, tx, cl.Custodian, country, *cl.LinkingID); err != nil {
+			return err
+		}
+
 		// check the linking limit does not exceed what is appropriate
 		if err != nil {
 			sublogger.Error().Err(err).
@@ -1075,7 +1146,3 @@ func waitAndLockTx(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
 	}
 	return nil
 }
-
-func ptrFromUUID(u uuid.UUID) *uuid.UUID {
-	return &u
-}
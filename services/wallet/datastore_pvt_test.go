@@ -1,11 +1,14 @@
 package wallet
 
 import (
-	"os"
+	"context"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/brave-intl/bat-go/libs/ptr"
+	"github.com/jmoiron/sqlx"
+	uuid "github.com/satori/go.uuid"
 	should "github.com/stretchr/testify/assert"
 	must "github.com/stretchr/testify/require"
 )
@@ -62,36 +65,48 @@ func TestCustodianLink_isLinked(t *testing.T) {
 	}
 }
 
-func TestGetEnvMaxCards(t *testing.T) {
+func TestTxCheckRelinkCooldown(t *testing.T) {
 	type tcGiven struct {
-		custodian string
-		key       string
-		value     string
+		cooldown   *int
+		lastUnlink *time.Time
 	}
 
 	type testCase struct {
 		name  string
 		given tcGiven
-		exp   int
+		exp   error
 	}
 
 	tests := []testCase{
 		{
-			name: "solana",
+			name: "no_linking_limit",
+		},
+
+		{
+			name:  "no_cooldown",
+			given: tcGiven{cooldown: ptr.To(0)},
+		},
+
+		{
+			name:  "never_unlinked",
+			given: tcGiven{cooldown: ptr.To(3600)},
+		},
+
+		{
+			name: "cooling_down",
 			given: tcGiven{
-				custodian: "solana",
-				key:       "SOLANA_WALLET_LINKING_LIMIT",
-				value:     "10",
+				cooldown:   ptr.To(3600),
+				lastUnlink: ptr.To(time.Now().Add(-10 * time.Minute)),
 			},
-			exp: 10,
+			exp: ErrRelinkCooldown,
 		},
+
 		{
-			name: "non_existent_custodian",
+			name: "cooled_down",
 			given: tcGiven{
-				key:   "NON_EXISTENT_CUSTODIAN",
-				value: "10",
+				cooldown:   ptr.To(3600),
+				lastUnlink: ptr.To(time.Now().Add(-2 * time.Hour)),
 			},
-			exp: 4,
 		},
 	}
 
@@ -99,17 +114,91 @@ func TestGetEnvMaxCards(t *testing.T) {
 		tc := tests[i]
 
 		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			must.NoError(t, err)
+
+			mock.ExpectBegin()
+
+			limRows := sqlmock.NewRows([]string{"custodian", "max_slots", "relink_cooldown_seconds"})
+			if tc.given.cooldown != nil {
+				limRows.AddRow("gemini", 4, *tc.given.cooldown)
+			}
+
+			mock.ExpectQuery("^select (.+) from linking_limits (.+)").WillReturnRows(limRows)
+
+			if tc.given.cooldown != nil && *tc.given.cooldown > 0 {
+				mock.ExpectQuery("^select (.+) from wallet_custodian (.+)").
+					WillReturnRows(sqlmock.NewRows([]string{"last_unlinking"}).AddRow(tc.given.lastUnlink))
+			}
+
+			tx, err := sqlx.NewDb(db, "postgres").Beginx()
+			must.NoError(t, err)
+
+			actual := txCheckRelinkCooldown(context.Background(), tx, "gemini", "US", uuid.NewV4())
+			should.ErrorIs(t, actual, tc.exp)
+
+			should.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTxGetLinkingLimit(t *testing.T) {
+	type tcGiven struct {
+		env   string
+		limit *int
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   int
+	}
+
+	tests := []testCase{
+		{
+			name: "no_linking_limit_default",
+			exp:  4,
+		},
+
+		{
+			name:  "no_linking_limit_env",
+			given: tcGiven{env: "1000"},
+			exp:   1000,
+		},
+
+		{
+			name:  "linking_limit",
+			given: tcGiven{env: "1000", limit: ptr.To(2)},
+			exp:   2,
+		},
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("UPHOLD_WALLET_LINKING_LIMIT", tc.given.env)
 
-			t.Cleanup(func() {
-				err := os.Unsetenv(tc.given.key)
-				must.NoError(t, err)
-			})
+			db, mock, err := sqlmock.New()
+			must.NoError(t, err)
+
+			mock.ExpectBegin()
+
+			limRows := sqlmock.NewRows([]string{"custodian", "max_slots", "relink_cooldown_seconds"})
+			if tc.given.limit != nil {
+				limRows.AddRow("uphold", *tc.given.limit, 0)
+			}
+
+			mock.ExpectQuery("^select (.+) from linking_limits (.+)").WillReturnRows(limRows)
+
+			tx, err := sqlx.NewDb(db, "postgres").Beginx()
+			must.NoError(t, err)
 
-			err := os.Setenv(tc.given.key, tc.given.value)
+			actual, err := txGetLinkingLimit(context.Background(), tx, "uphold", "US", uuid.NewV4())
 			must.NoError(t, err)
 
-			actual := getEnvMaxCards(tc.given.custodian)
-			should.Equal(t, tc.exp, actual)
+			should.Equal(t, tc.exp, actual.MaxSlots)
+			should.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
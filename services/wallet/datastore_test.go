@@ -128,7 +128,7 @@ func (suite *WalletPostgresTestSuite) TestCustodianLink() {
 			WalletID:  &id,
 			Custodian: "gemini",
 			LinkingID: &linkingID,
-		}, depositDest.String()),
+		}, depositDest.String(), ""),
 		"connect custodial wallet should succeed")
 
 	// get the wallet and check that the custodian link entry id is right
@@ -140,7 +140,7 @@ func (suite *WalletPostgresTestSuite) TestCustodianLink() {
 	suite.Require().True(cl.Custodian == "gemini", "custodian is not right")
 
 	// check the link count is 1 for this wallet
-	used, max, err := pg.GetCustodianLinkCount(ctx, linkingID, "gemini")
+	used, max, err := pg.GetCustodianLinkCount(ctx, linkingID, "gemini", "")
 	suite.Require().NoError(err, "should have no error getting custodian link count")
 
 	// disconnect the wallet
@@ -154,7 +154,7 @@ func (suite *WalletPostgresTestSuite) TestCustodianLink() {
 			WalletID:  &id,
 			Custodian: "gemini",
 			LinkingID: &linkingID,
-		}, depositDest.String()),
+		}, depositDest.String(), ""),
 		"connect custodial wallet should succeed")
 
 	// only one slot should be taken
@@ -185,11 +185,11 @@ func (suite *WalletPostgresTestSuite) TestConnectCustodialWallet_Rollback() {
 		WalletID:  &walletID,
 		Custodian: "uphold",
 		LinkingID: &linkingID,
-	}, depositDest)
+	}, depositDest, "")
 
 	suite.Require().True(err != nil, "should have returned error")
 
-	count, _, err := pg.GetCustodianLinkCount(ctx, linkingID, "")
+	count, _, err := pg.GetCustodianLinkCount(ctx, linkingID, "", "")
 
 	suite.Require().NoError(err)
 	suite.Require().True(count == 0, "should have performed rollback on connect custodial wallet")
@@ -232,13 +232,13 @@ func (suite *WalletPostgresTestSuite) TestLinkWallet_Concurrent_InsertUpdate() {
 		for i := 0; i < runs; i++ {
 			go func() {
 				defer wg.Done()
-				err := pg.LinkWallet(ctx, walletInfo.ID, userDepositDestination, providerLinkingID, walletInfo.Provider)
+				err := pg.LinkWallet(ctx, walletInfo.ID, userDepositDestination, providerLinkingID, walletInfo.Provider, "")
 				suite.Require().NoError(err)
 			}()
 		}
 		wg.Wait()
 
-		used, max, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "")
+		used, max, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "", "")
 
 		suite.Require().NoError(err, "should have no error getting custodian link count")
 		suite.Require().True(used == max, fmt.Sprintf("used %d should not exceed max %d", used, max))
@@ -273,11 +273,11 @@ func (suite *WalletPostgresTestSuite) seedWallet(pg Datastore) (string, uuid.UUI
 		err := pg.UpsertWallet(ctx, walletInfo)
 		suite.Require().NoError(err, "save wallet should succeed")
 
-		err = pg.LinkWallet(ctx, walletInfo.ID, userDepositDestination, providerLinkingID, "uphold")
+		err = pg.LinkWallet(ctx, walletInfo.ID, userDepositDestination, providerLinkingID, "uphold", "")
 		suite.Require().NoError(err, "link wallet should succeed")
 	}
 
-	used, _, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "")
+	used, _, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "", "")
 
 	suite.Require().NoError(err, "should have no error getting custodian link count")
 	suite.Require().True(used == walletCount, fmt.Sprintf("used %d", used))
@@ -324,12 +324,12 @@ func (suite *WalletPostgresTestSuite) TestLinkWallet_Concurrent_MaxLinkCount() {
 		go func(index int) {
 			defer wg.Done()
 			// Once we reach the limit this will return an error which is expected hence we can ignore it.
-			_ = pg.LinkWallet(ctx, wallets[index].ID, userDepositDestination, providerLinkingID, wallets[index].Provider)
+			_ = pg.LinkWallet(ctx, wallets[index].ID, userDepositDestination, providerLinkingID, wallets[index].Provider, "")
 		}(i)
 	}
 	wg.Wait()
 
-	used, max, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "")
+	used, max, err := pg.GetCustodianLinkCount(ctx, providerLinkingID, "", "")
 
 	suite.Require().NoError(err, "should have no error getting custodian link count")
 	suite.Require().True(used == max, fmt.Sprintf("used %d should not exceed max %d", used, max))
@@ -43,8 +43,8 @@ func (cn *CustodianName) String() string {
 
 // Validate - implement the validatable interface for this input
 func (cn *CustodianName) Validate(ctx context.Context) error {
-	if string(*cn) != "uphold" && string(*cn) != "bitflyer" && string(*cn) != "brave" && string(*cn) != "gemini" {
-		return fmt.Errorf("validate custodian name not in (uphold, bitflyer, brave, gemini)")
+	if !govalidator.IsAlphanumeric(string(*cn)) || !govalidator.IsLowerCase(string(*cn)) {
+		return fmt.Errorf("validate custodian name must be lowercase alphanumeric")
 	}
 	return nil
 }
@@ -60,7 +60,7 @@ func (_d DatastoreWithPrometheus) BeginTx() (tp1 *sqlx.Tx, err error) {
 }
 
 // ConnectCustodialWallet implements Datastore
-func (_d DatastoreWithPrometheus) ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest string) (err error) {
+func (_d DatastoreWithPrometheus) ConnectCustodialWallet(ctx context.Context, cl *CustodianLink, depositDest string, country string) (err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -70,7 +70,7 @@ func (_d DatastoreWithPrometheus) ConnectCustodialWallet(ctx context.Context, cl
 
 		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "ConnectCustodialWallet", result).Observe(time.Since(_since).Seconds())
 	}()
-	return _d.base.ConnectCustodialWallet(ctx, cl, depositDest)
+	return _d.base.ConnectCustodialWallet(ctx, cl, depositDest, country)
 }
 
 // DisconnectCustodialWallet implements Datastore
@@ -116,7 +116,7 @@ func (_d DatastoreWithPrometheus) GetCustodianLinkByWalletID(ctx context.Context
 }
 
 // GetCustodianLinkCount implements Datastore
-func (_d DatastoreWithPrometheus) GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian string) (i1 int, i2 int, err error) {
+func (_d DatastoreWithPrometheus) GetCustodianLinkCount(ctx context.Context, linkingID uuid.UUID, custodian string, country string) (i1 int, i2 int, err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -126,11 +126,11 @@ func (_d DatastoreWithPrometheus) GetCustodianLinkCount(ctx context.Context, lin
 
 		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "GetCustodianLinkCount", result).Observe(time.Since(_since).Seconds())
 	}()
-	return _d.base.GetCustodianLinkCount(ctx, linkingID, custodian)
+	return _d.base.GetCustodianLinkCount(ctx, linkingID, custodian, country)
 }
 
 // GetLinkingLimitInfo implements Datastore
-func (_d DatastoreWithPrometheus) GetLinkingLimitInfo(ctx context.Context, providerLinkingID string) (m1 map[string]LinkingInfo, err error) {
+func (_d DatastoreWithPrometheus) GetLinkingLimitInfo(ctx context.Context, providerLinkingID string, country string) (m1 map[string]LinkingInfo, err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -140,7 +140,7 @@ func (_d DatastoreWithPrometheus) GetLinkingLimitInfo(ctx context.Context, provi
 
 		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "GetLinkingLimitInfo", result).Observe(time.Since(_since).Seconds())
 	}()
-	return _d.base.GetLinkingLimitInfo(ctx, providerLinkingID)
+	return _d.base.GetLinkingLimitInfo(ctx, providerLinkingID, country)
 }
 
 // GetLinkingsByProviderLinkingID implements Datastore
@@ -256,7 +256,7 @@ func (_d DatastoreWithPrometheus) InsertWalletTx(ctx context.Context, tx *sqlx.T
 }
 
 // LinkWallet implements Datastore
-func (_d DatastoreWithPrometheus) LinkWallet(ctx context.Context, id string, providerID string, providerLinkingID uuid.UUID, depositProvider string) (err error) {
+func (_d DatastoreWithPrometheus) LinkWallet(ctx context.Context, id string, providerID string, providerLinkingID uuid.UUID, depositProvider string, country string) (err error) {
 	_since := time.Now()
 	defer func() {
 		result := "ok"
@@ -266,7 +266,7 @@ func (_d DatastoreWithPrometheus) LinkWallet(ctx context.Context, id string, pro
 
 		datastoreDurationSummaryVec.WithLabelValues(_d.instanceName, "LinkWallet", result).Observe(time.Since(_since).Seconds())
 	}()
-	return _d.base.LinkWallet(ctx, id, providerID, providerLinkingID, depositProvider)
+	return _d.base.LinkWallet(ctx, id, providerID, providerLinkingID, depositProvider, country)
 }
 
 // Migrate implements Datastore
@@ -2,23 +2,33 @@ package wallet
 
 import (
 	"context"
+	"crypto"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"time"
 
 	errorutils "github.com/brave-intl/bat-go/libs/errors"
 	"github.com/brave-intl/bat-go/libs/httpsignature"
 	uuid "github.com/satori/go.uuid"
 )
 
+// keyRotationOverlap is how long the previous key of a wallet is still accepted after the wallet rotated its key.
+const keyRotationOverlap = 10 * time.Minute
+
 // LookupVerifier based on the HTTP signing keyID, which in our case is the walletID
+//
+// For keyRotationOverlap after a key rotation requests signed with either the current or the previous key are valid.
+// The wallet and its key history are read from the same datastore, so that a rotation is seen by both or neither.
 func (service *Service) LookupVerifier(ctx context.Context, keyID string) (context.Context, httpsignature.Verifier, error) {
 	walletID, err := uuid.FromString(keyID)
 	if err != nil {
 		return nil, nil, errorutils.Wrap(err, "KeyID format is invalid")
 	}
 
-	wallet, err := service.GetWallet(ctx, walletID)
+	ds := service.Datastore
+
+	wallet, err := ds.GetWallet(ctx, walletID)
 	if err != nil {
 		return nil, nil, errorutils.Wrap(err, "error getting wallet")
 	}
@@ -35,7 +45,53 @@ func (service *Service) LookupVerifier(ctx context.Context, keyID string) (conte
 			return nil, nil, err
 		}
 	}
-	return ctx, publicKey, nil
+
+	previous, err := service.keyRepo.ListValidSince(ctx, ds.RawDB(), walletID, time.Now().Add(-keyRotationOverlap))
+	if err != nil {
+		return nil, nil, errorutils.Wrap(err, "error getting wallet key history")
+	}
+
+	if len(previous) == 0 {
+		return ctx, publicKey, nil
+	}
+
+	keys := ed25519KeySet{publicKey}
+	for i := range previous {
+		key, err := hex.DecodeString(previous[i].PublicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return ctx, keys, nil
+}
+
+// ed25519KeySet is a verifier which accepts signatures made with any of its keys, the first key is the current one.
+type ed25519KeySet []httpsignature.Ed25519PubKey
+
+// Verify the signature sig for message against each key of the set in turn.
+func (ks ed25519KeySet) Verify(message, sig []byte, opts crypto.SignerOpts) (bool, error) {
+	for i := range ks {
+		valid, err := ks[i].Verify(message, sig, opts)
+		if err != nil {
+			return false, err
+		}
+
+		if valid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (ks ed25519KeySet) String() string {
+	if len(ks) == 0 {
+		return ""
+	}
+
+	return ks[0].String()
 }
 
 // DecodeEd25519Keystore is a keystore that "looks up" a verifier by attempting to decode the keyID as a base64 encoded ed25519 public key
@@ -0,0 +1,122 @@
+// Package linking defines how rewards wallets are linked to accounts held with custodians.
+//
+// A custodian implements Custodian, and is registered with the wallet service which serves
+// /v3/wallet/{custodian}/{paymentID}/connect for it.
+package linking
+
+import (
+	"context"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Auth is the way a linking request proves that it was made by the owner of the rewards wallet.
+type Auth int
+
+const (
+	// AuthHTTPSignature requires the request to be signed with the key of the rewards wallet.
+	AuthHTTPSignature Auth = iota
+	// AuthProof means the body of the request carries the proof, e.g. a transaction signed by the rewards wallet.
+	AuthProof
+	// AuthDApp requires the request to come from an allowed dApp origin, and the body to carry the proof.
+	AuthDApp
+)
+
+// Request is a request to link the rewards wallet PaymentID.
+type Request struct {
+	PaymentID uuid.UUID
+	Body      []byte
+}
+
+// Account is an account with a custodian which a rewards wallet is being linked to.
+type Account struct {
+	// ID identifies the account with the custodian.
+	//
+	// The linking id, and so the linking slots, of the account are derived from it.
+	ID string
+
+	// DepositDestination is where payouts to the account are sent.
+	DepositDestination string
+
+	// Country is the country of the account.
+	Country string
+
+	// Details holds anything the custodian needs to carry from Verify to Check and Finish.
+	Details interface{}
+}
+
+// Custodian links rewards wallets to accounts held with it.
+//
+// The wallet service calls Verify and Check, then links the account if both succeed.
+// A link only succeeds while the account has a linking slot available.
+//
+// Errors returned from Verify and Check which are a *handlers.AppError keep their status code.
+type Custodian interface {
+	// Name returns the name of the custodian as stored in wallet_custodian.
+	Name() string
+
+	// Auth returns how linking requests are authenticated.
+	Auth() Auth
+
+	// IsDisabled reports whether linking is temporarily disabled.
+	IsDisabled(ctx context.Context) bool
+
+	// Verify validates the verification token in req, and returns the account it was issued for,
+	// including its deposit destination.
+	Verify(ctx context.Context, req *Request) (*Account, error)
+
+	// Check performs the KYC and region checks for acc.
+	//
+	// Check may fill in the ID and Country of acc, if they are only known from the checks.
+	// A failed check is recorded as a rejection, with the Reason given by Reject or ReasonOther.
+	Check(ctx context.Context, req *Request, acc *Account) error
+}
+
+// Finisher is implemented by custodians which act once linking has finished.
+type Finisher interface {
+	// Finish is called with the error from linking acc, which is nil if acc has been linked.
+	//
+	// On success, Finish is called within the linking transaction, and an error it returns undoes the link.
+	// On failure, the error it returns is ignored.
+	Finish(ctx context.Context, req *Request, acc *Account, linkErr error) error
+}
+
+// AfterLinker is implemented by custodians which act once the link has been committed.
+type AfterLinker interface {
+	// AfterLink is called once acc has been linked and the linking transaction has been committed.
+	//
+	// The link stands regardless, so an error AfterLink returns is only logged.
+	AfterLink(ctx context.Context, req *Request, acc *Account) error
+}
+
+// Reason is why linking an account was rejected.
+type Reason string
+
+const (
+	ReasonRegionBlocked  Reason = "region_blocked"
+	ReasonSlotsExhausted Reason = "slots_exhausted"
+	ReasonRelinkCooldown Reason = "relink_cooldown"
+	ReasonKYCFailed      Reason = "kyc_failed"
+	ReasonOther          Reason = "other"
+)
+
+// RejectedError is returned when linking an account is rejected for Reason.
+//
+// It wraps the error which is returned to the client.
+type RejectedError struct {
+	Reason Reason
+	Err    error
+}
+
+// Reject returns err as a rejection for reason.
+func Reject(reason Reason, err error) error {
+	return &RejectedError{Reason: reason, Err: err}
+}
+
+func (e *RejectedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
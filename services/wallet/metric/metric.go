@@ -16,6 +16,7 @@ const (
 type Metric struct {
 	cntLinkZP                *prometheus.CounterVec
 	cntLinkSolana            *prometheus.CounterVec
+	cntLinkEthereum          *prometheus.CounterVec
 	cntAccValidateGemini     *prometheus.CounterVec
 	cntDocTypeByIssuingCntry *prometheus.CounterVec
 }
@@ -39,6 +40,14 @@ func New() *Metric {
 	)
 	prometheus.MustRegister(clsol)
 
+	cleth := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "count_link_ethereum_country_code",
+		Help: "Counts the number of successful and failed Ethereum linkings partitioned by country code and failure type",
+	},
+		[]string{status, countryCode, failureType},
+	)
+	prometheus.MustRegister(cleth)
+
 	accValidate := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "count_gemini_wallet_account_validation",
@@ -60,6 +69,7 @@ func New() *Metric {
 	return &Metric{
 		cntLinkZP:                clzp,
 		cntLinkSolana:            clsol,
+		cntLinkEthereum:          cleth,
 		cntAccValidateGemini:     accValidate,
 		cntDocTypeByIssuingCntry: cntDocTypeByIssuingCntry,
 	}
@@ -119,6 +129,46 @@ func (m *Metric) LinkSuccessSolana(cc string) {
 	}).Inc()
 }
 
+func (m *Metric) LinkFailureEthereumWhitelist(cc string) {
+	m.cntLinkEthereum.With(prometheus.Labels{
+		countryCode: cc,
+		status:      failure,
+		failureType: "whitelist",
+	}).Inc()
+}
+
+func (m *Metric) LinkFailureEthereumRegion(cc string) {
+	m.cntLinkEthereum.With(prometheus.Labels{
+		countryCode: cc,
+		status:      failure,
+		failureType: "region",
+	}).Inc()
+}
+
+func (m *Metric) LinkFailureEthereumChl(cc string) {
+	m.cntLinkEthereum.With(prometheus.Labels{
+		countryCode: cc,
+		status:      failure,
+		failureType: "challenge",
+	}).Inc()
+}
+
+func (m *Metric) LinkFailureEthereumMsg(cc string) {
+	m.cntLinkEthereum.With(prometheus.Labels{
+		countryCode: cc,
+		status:      failure,
+		failureType: "ethereum_message",
+	}).Inc()
+}
+
+func (m *Metric) LinkSuccessEthereum(cc string) {
+	m.cntLinkEthereum.With(prometheus.Labels{
+		countryCode: cc,
+		status:      success,
+		failureType: "",
+	}).Inc()
+}
+
 func (m *Metric) LinkFailureGemini(cc string) {
 	m.cntAccValidateGemini.With(prometheus.Labels{
 		countryCode: cc,
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
@@ -17,6 +18,9 @@ const (
 	ErrNoWalletCustodian    Error = "model: no linked wallet custodian"
 	ErrInternalServer       Error = "model: internal server error"
 	ErrWalletNotFound       Error = "model: wallet not found"
+	ErrLinkingLimitNotFound Error = "model: linking limit not found"
+	ErrLinkingLimitExists   Error = "model: linking limit already exists"
+	ErrWalletKeyConflict    Error = "model: wallet key has changed"
 )
 
 type AllowListEntry struct {
@@ -54,6 +58,80 @@ func (c *Challenge) hasExpired(now time.Time) bool {
 	return expiresAt.Before(now)
 }
 
+const (
+	LinkingOutcomeLinked   = "linked"
+	LinkingOutcomeUnlinked = "unlinked"
+	LinkingOutcomeRejected = "rejected"
+)
+
+// LinkingEvent records a rewards wallet being linked to, unlinked from or rejected by a custodian account.
+type LinkingEvent struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	WalletID  uuid.UUID  `json:"walletId" db:"wallet_id"`
+	Custodian string     `json:"custodian" db:"custodian"`
+	LinkingID *uuid.UUID `json:"linkingId,omitempty" db:"linking_id"`
+	Country   string     `json:"country,omitempty" db:"country"`
+	Outcome   string     `json:"outcome" db:"outcome"`
+	Reason    string     `json:"reason,omitempty" db:"reason"`
+}
+
+// LinkingEventFilter narrows down the linking events to list, empty fields match every event.
+type LinkingEventFilter struct {
+	WalletID  *uuid.UUID
+	LinkingID *uuid.UUID
+	Custodian string
+	Country   string
+	Outcome   string
+}
+
+// LinkingLimit is the linking slot policy for the accounts of a custodian.
+//
+// A limit applies either to every account of Custodian, to the accounts in Country, or to the single account
+// identified by LinkingID. The most specific one applies.
+type LinkingLimit struct {
+	ID                    uuid.UUID  `json:"id" db:"id"`
+	CreatedAt             time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt             time.Time  `json:"updatedAt" db:"updated_at"`
+	Custodian             string     `json:"custodian" db:"custodian"`
+	Country               string     `json:"country,omitempty" db:"country"`
+	LinkingID             *uuid.UUID `json:"linkingId,omitempty" db:"linking_id"`
+	MaxSlots              int        `json:"maxSlots" db:"max_slots"`
+	RelinkCooldownSeconds int        `json:"relinkCooldownSeconds" db:"relink_cooldown_seconds"`
+}
+
+// RelinkCooldown is how long after an account last had a wallet unlinked it cannot be linked to another wallet.
+func (l *LinkingLimit) RelinkCooldown() time.Duration {
+	return time.Duration(l.RelinkCooldownSeconds) * time.Second
+}
+
+// LinkingLimitChange is an entry of the audit trail of linking limits.
+type LinkingLimitChange struct {
+	ID             int64            `json:"id" db:"id"`
+	Operation      string           `json:"operation" db:"operation"`
+	ExecutedBy     string           `json:"executedBy" db:"executed_by"`
+	RecordedAt     time.Time        `json:"recordedAt" db:"recorded_at"`
+	LinkingLimitID uuid.UUID        `json:"linkingLimitId" db:"linking_limit_id"`
+	ValueBefore    *json.RawMessage `json:"valueBefore,omitempty" db:"value_before"`
+	ValueAfter     *json.RawMessage `json:"valueAfter,omitempty" db:"value_after"`
+}
+
+// WalletKey is a public key a rewards wallet has rotated away from.
+//
+// The key was the wallet's signing key from ValidFrom until ValidTo, when it was replaced by NextPublicKey. A nil
+// ValidFrom means the key was the one the wallet was created with. Signature is the signature over NextPublicKey
+// made with the retired key.
+type WalletKey struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	WalletID      uuid.UUID  `json:"walletId" db:"wallet_id"`
+	PublicKey     string     `json:"publicKey" db:"public_key"`
+	ValidFrom     *time.Time `json:"validFrom,omitempty" db:"valid_from"`
+	ValidTo       time.Time  `json:"validTo" db:"valid_to"`
+	NextPublicKey string     `json:"nextPublicKey" db:"next_public_key"`
+	Signature     string     `json:"signature" db:"signature"`
+}
+
 type Error string
 
 func (e Error) Error() string {
@@ -205,7 +205,8 @@ func infoToResponseV4(info *walletutils.Info, selfCustody bool) ResponseV4 {
 			Name: info.Provider,
 		},
 		SelfCustodyAvailable: map[string]bool{
-			"solana": selfCustody,
+			"solana":   selfCustody,
+			"ethereum": selfCustody,
 		},
 	}
 
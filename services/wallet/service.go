@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"context"
+	"crypto"
 	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
@@ -23,7 +24,6 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
-	"github.com/shopspring/decimal"
 	"github.com/spf13/viper"
 
 	"github.com/brave-intl/bat-go/libs/altcurrency"
@@ -37,6 +37,8 @@ import (
 	"github.com/brave-intl/bat-go/libs/custodian"
 	errorutils "github.com/brave-intl/bat-go/libs/errors"
 	"github.com/brave-intl/bat-go/libs/handlers"
+	"github.com/brave-intl/bat-go/libs/httpsignature"
+	"github.com/brave-intl/bat-go/libs/inputs"
 	"github.com/brave-intl/bat-go/libs/logging"
 	"github.com/brave-intl/bat-go/libs/middleware"
 	srv "github.com/brave-intl/bat-go/libs/service"
@@ -100,6 +102,11 @@ var (
 	errZPInvalidAccountID = errors.New("zebpay: account id invalid in token")
 
 	errCustodianLinkMismatch = errors.New("wallet: custodian link mismatch")
+
+	errWalletKeySignature    = errors.New("wallet: key rotation signature is not valid for the current key")
+	errWalletKeyInUse        = errors.New("wallet: public key is already in use")
+	errKeyRotationInProgress = errors.New("wallet: previous key rotation is still in its overlap period")
+	errKeyRotationStale      = errors.New("wallet: key rotation timestamp is outside the allowed window")
 )
 
 // GeoValidator - interface describing validation of geolocation
@@ -109,6 +116,7 @@ type GeoValidator interface {
 
 type challengeRepo interface {
 	Get(ctx context.Context, dbi sqlx.QueryerContext, paymentID uuid.UUID) (model.Challenge, error)
+	GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, paymentID uuid.UUID) (model.Challenge, error)
 	Upsert(ctx context.Context, dbi sqlx.ExecerContext, chl model.Challenge) error
 	Delete(ctx context.Context, dbi sqlx.ExecerContext, paymentID uuid.UUID) error
 }
@@ -117,6 +125,25 @@ type allowListRepo interface {
 	GetAllowListEntry(ctx context.Context, dbi sqlx.QueryerContext, paymentID uuid.UUID) (model.AllowListEntry, error)
 }
 
+type linkingEventRepo interface {
+	Insert(ctx context.Context, dbi sqlx.ExecerContext, ev *model.LinkingEvent) error
+	List(ctx context.Context, dbi sqlx.QueryerContext, filter model.LinkingEventFilter, p *inputs.Pagination) ([]model.LinkingEvent, int, error)
+}
+
+type linkingLimitRepo interface {
+	List(ctx context.Context, dbi sqlx.QueryerContext, custodian string) ([]model.LinkingLimit, error)
+	Create(ctx context.Context, dbi sqlx.QueryerContext, lim *model.LinkingLimit) (model.LinkingLimit, error)
+	Update(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, maxSlots, relinkCooldownSeconds int) (model.LinkingLimit, error)
+	Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error
+	ListHistory(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]model.LinkingLimitChange, error)
+}
+
+type walletKeyRepo interface {
+	ListValidSince(ctx context.Context, dbi sqlx.QueryerContext, walletID uuid.UUID, since time.Time) ([]model.WalletKey, error)
+	IsUsed(ctx context.Context, dbi sqlx.QueryerContext, publicKey string) (bool, error)
+	Rotate(ctx context.Context, dbi sqlx.ExtContext, walletID uuid.UUID, currentKey, nextKey, signature string) (model.WalletKey, error)
+}
+
 type metricSvc interface {
 	LinkSuccessZP(cc string)
 	LinkFailureZP(cc string)
@@ -127,6 +154,11 @@ type metricSvc interface {
 	LinkFailureSolanaChl(cc string)
 	LinkFailureSolanaMsg(cc string)
 	LinkSuccessSolana(cc string)
+	LinkFailureEthereumWhitelist(cc string)
+	LinkFailureEthereumRegion(cc string)
+	LinkFailureEthereumChl(cc string)
+	LinkFailureEthereumMsg(cc string)
+	LinkSuccessEthereum(cc string)
 	CountDocTypeByIssuingCntry(validDocs []gemini.ValidDocument)
 }
 
@@ -141,6 +173,9 @@ type Service struct {
 	RoDatastore      ReadOnlyDatastore
 	chlRepo          challengeRepo
 	allowListRepo    allowListRepo
+	linkEvRepo       linkingEventRepo
+	linkLimitRepo    linkingLimitRepo
+	keyRepo          walletKeyRepo
 	repClient        reputation.Client
 	geminiClient     gemini.Client
 	geoValidator     GeoValidator
@@ -151,6 +186,7 @@ type Service struct {
 	metric           metricSvc
 	gemini           geminiSvc
 	dappConf         DAppConfig
+	custodians       custodians
 }
 
 type DAppConfig struct {
@@ -175,6 +211,9 @@ func InitService(
 		RoDatastore:   roDatastore,
 		chlRepo:       chlRepo,
 		allowListRepo: allowList,
+		linkEvRepo:    storage.NewLinkingEvent(),
+		linkLimitRepo: storage.NewLinkingLimit(),
+		keyRepo:       storage.NewWalletKey(),
 		repClient:     repClient,
 		geminiClient:  geminiClient,
 		geoValidator:  geoCountryValidator,
@@ -184,6 +223,14 @@ func InitService(
 		dappConf:      dappConf,
 		crMu:          new(sync.RWMutex),
 	}
+
+	service.RegisterCustodian(newUpholdCustodian(datastore, service.getCustodianRegions, service.SubmitCommitableAnonCardTransaction))
+	service.RegisterCustodian(newBitFlyerCustodian())
+	service.RegisterCustodian(newGeminiCustodian(datastore, gemini, metric, service.getCustodianRegions))
+	service.RegisterCustodian(newZebPayCustodian(metric))
+	service.RegisterCustodian(newSolanaCustodian(datastore, chlRepo, allowList, repClient, service.getCustodianRegions, metric))
+	service.RegisterCustodian(newEthereumCustodian(datastore, chlRepo, allowList, repClient, service.getCustodianRegions, metric))
+
 	return service, nil
 }
 
@@ -356,31 +403,24 @@ func RegisterRoutes(ctx context.Context, s *Service, r *chi.Mux, metricsMw middl
 
 		// if wallets are being migrated we do not want to over claim, we might go over the limit
 		if viper.GetBool("enable-link-drain-flag") {
-			// create wallet claim routes for our wallet providers
-			r.Post("/uphold/{paymentID}/claim", middleware.InstrumentHandlerFunc(
-				"LinkUpholdDepositAccount", LinkUpholdDepositAccountV3(s)))
-			r.Post("/bitflyer/{paymentID}/claim", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkBitFlyerDepositAccount", LinkBitFlyerDepositAccountV3(s))).ServeHTTP)
-			r.Post("/gemini/{paymentID}/claim", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkGeminiDepositAccount", LinkGeminiDepositAccountV3(s))).ServeHTTP)
-			r.Post("/zebpay/{paymentID}/claim", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkZebPayDepositAccount", LinkZebPayDepositAccountV3(s))).ServeHTTP)
-
-			// create wallet connect routes for our wallet providers
-			r.Post("/uphold/{paymentID}/connect", middleware.InstrumentHandlerFunc(
-				"LinkUpholdDepositAccount", LinkUpholdDepositAccountV3(s)))
-			r.Post("/bitflyer/{paymentID}/connect", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkBitFlyerDepositAccount", LinkBitFlyerDepositAccountV3(s))).ServeHTTP)
-			r.Post("/gemini/{paymentID}/connect", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkGeminiDepositAccount", LinkGeminiDepositAccountV3(s))).ServeHTTP)
-			r.Post("/zebpay/{paymentID}/connect", middleware.HTTPSignedOnly(s)(middleware.InstrumentHandlerFunc(
-				"LinkZebPayDepositAccount", LinkZebPayDepositAccountV3(s))).ServeHTTP)
-
-			r.Method(http.MethodPost, "/solana/{paymentID}/connect", metricsMw("LinkSolanaAddress", dAppCorsMw(LinkSolanaAddress(s))))
-			r.Method(http.MethodOptions, "/solana/{paymentID}/connect", metricsMw("LinkSolanaAddressOptions", dAppCorsMw(noOpHandler())))
+			// create wallet claim and connect routes for the registered custodians
+			link := instrumentLinking(metricsMw, linkingHandlerNames, "LinkDepositAccount", linkingAuthMw(s, dAppCorsMw)(LinkDepositAccountV3(s)))
+			linkOpts := instrumentLinking(metricsMw, linkingOptionsHandlerNames, "LinkDepositAccountOptions", dAppCorsMw(noOpHandler()))
+
+			r.Method(http.MethodPost, "/{custodian}/{paymentID}/claim", link)
+			r.Method(http.MethodPost, "/{custodian}/{paymentID}/connect", link)
+			r.Method(http.MethodOptions, "/{custodian}/{paymentID}/connect", linkOpts)
 		}
 
 		r.Get("/linking-info", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("GetLinkingInfo", GetLinkingInfoV3(s))).ServeHTTP)
+		r.Get("/linking-events", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("GetLinkingEvents", GetLinkingEventsV3(s))).ServeHTTP)
+
+		// linking limit admin routes
+		r.Get("/linking-limits", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("ListLinkingLimits", ListLinkingLimitsV3(s))).ServeHTTP)
+		r.Post("/linking-limits", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("CreateLinkingLimit", CreateLinkingLimitV3(s))).ServeHTTP)
+		r.Put("/linking-limits/{linkingLimitID}", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("UpdateLinkingLimit", UpdateLinkingLimitV3(s))).ServeHTTP)
+		r.Delete("/linking-limits/{linkingLimitID}", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("DeleteLinkingLimit", DeleteLinkingLimitV3(s))).ServeHTTP)
+		r.Get("/linking-limits/{linkingLimitID}/history", middleware.SimpleTokenAuthorizedOnly(middleware.InstrumentHandlerFunc("GetLinkingLimitHistory", GetLinkingLimitHistoryV3(s))).ServeHTTP)
 
 		// get wallet routes
 		r.Get("/{paymentID}", middleware.InstrumentHandlerFunc("GetWallet", GetWalletV3))
@@ -400,6 +440,9 @@ func RegisterRoutes(ctx context.Context, s *Service, r *chi.Mux, metricsMw middl
 		r.Patch("/{paymentID}", middleware.RateLimiter(ctx, 2)(middleware.HTTPSignedOnly(s)(
 			middleware.InstrumentHandlerFunc("UpdateWalletV4", UpdateWalletV4(s)))).ServeHTTP)
 
+		r.Post("/{paymentID}/keys", middleware.RateLimiter(ctx, 2)(middleware.HTTPSignedOnly(s)(
+			middleware.InstrumentHandlerFunc("RotateWalletKeyV4", RotateWalletKeyV4(s)))).ServeHTTP)
+
 		r.Get("/{paymentID}", middleware.RateLimiter(ctx, 7)(middleware.HTTPSignedOnly(s)(
 			middleware.InstrumentHandlerFunc("GetWalletV4", GetWalletV4(s)))).ServeHTTP)
 
@@ -477,8 +520,8 @@ func (service *Service) SubmitCommitableAnonCardTransaction(
 	return anonCard.SubmitTransaction(ctx, transaction, confirm)
 }
 
-// GetLinkingInfo - Get data about the linking info
-func (service *Service) GetLinkingInfo(ctx context.Context, providerLinkingID, custodianID string) (map[string]LinkingInfo, error) {
+// GetLinkingInfo - Get data about the linking info, using the linking limits for accounts in country
+func (service *Service) GetLinkingInfo(ctx context.Context, providerLinkingID, custodianID, country string) (map[string]LinkingInfo, error) {
 	// compute the provider linking id based on custodian id if there is one
 
 	if custodianID != "" {
@@ -486,363 +529,86 @@ func (service *Service) GetLinkingInfo(ctx context.Context, providerLinkingID, c
 		providerLinkingID = uuid.NewV5(ClaimNamespace, custodianID).String()
 	}
 
-	infos, err := service.Datastore.GetLinkingLimitInfo(ctx, providerLinkingID)
+	infos, err := service.Datastore.GetLinkingLimitInfo(ctx, providerLinkingID, country)
 	if err != nil {
 		return infos, fmt.Errorf("unable to increase linking limit: %w", err)
 	}
 	return infos, nil
 }
 
-// LinkBitFlyerWallet links a wallet and transfers funds to newly linked wallet
-func (service *Service) LinkBitFlyerWallet(ctx context.Context, walletID uuid.UUID, depositID, accountHash string) (string, error) {
-	const (
-		depositProvider = "bitflyer"
-		country         = "JP"
-	)
-
-	// In the controller validation, we verified that the account hash and deposit id were signed by bitflyer
-	// we also validated that this "info" signed the request to perform the linking with http signature
-	// we assume that since we got linkingInfo signed from BF that they are KYC
-	providerLinkingID := uuid.NewV5(ClaimNamespace, accountHash)
-	if err := service.linkCustodialAccount(ctx, walletID.String(), depositID, providerLinkingID, depositProvider, country); err != nil {
-		if errors.Is(err, ErrUnusualActivity) {
-			return "", handlers.WrapError(err, "unable to link - unusual activity", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrGeoResetDifferent) {
-			return "", handlers.WrapError(err, "mismatched provider account regions", http.StatusBadRequest)
-		}
-
-		status := http.StatusInternalServerError
-		if errors.Is(err, ErrTooManyCardsLinked) {
-			status = http.StatusConflict
-		}
-
-		return "", handlers.WrapError(err, "unable to link bitflyer wallets", status)
-	}
-
-	return country, nil
-}
-
-// LinkZebPayWallet links a wallet and transfers funds to newly linked wallet.
-func (service *Service) LinkZebPayWallet(ctx context.Context, walletID uuid.UUID, verificationToken string) (string, error) {
-	const depositProvider = "zebpay"
-
-	claims, err := parseZebPayClaims(ctx, verificationToken)
-	if err != nil {
-		return "", err
-	}
-
-	if err := claims.validate(time.Now()); err != nil {
-		service.metric.LinkFailureZP(claims.CountryCode)
-		return "", err
-	}
-
-	providerLinkingID := uuid.NewV5(ClaimNamespace, claims.AccountID)
-	if err := service.linkCustodialAccount(ctx, walletID.String(), claims.DepositID, providerLinkingID, depositProvider, claims.CountryCode); err != nil {
-		service.metric.LinkFailureZP(claims.CountryCode)
-
-		if errors.Is(err, ErrUnusualActivity) {
-			return "", handlers.WrapError(err, "unable to link - unusual activity", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrGeoResetDifferent) {
-			return "", handlers.WrapError(err, "mismatched provider account regions", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrTooManyCardsLinked) {
-			return "", handlers.WrapError(err, "unable to link zebpay wallets", http.StatusConflict)
-		}
-
-		return "", handlers.WrapError(err, "unable to link zebpay wallets", http.StatusInternalServerError)
+func (service *Service) CreateChallenge(ctx context.Context, paymentID uuid.UUID) (model.Challenge, error) {
+	chl := model.NewChallenge(paymentID)
+	if err := service.chlRepo.Upsert(ctx, service.Datastore.RawDB(), chl); err != nil {
+		return model.Challenge{}, fmt.Errorf("error creating challenge: %w", err)
 	}
-
-	service.metric.LinkSuccessZP(claims.CountryCode)
-
-	return claims.CountryCode, nil
+	return chl, nil
 }
 
-const errNoAcceptedDocumentType model.Error = "no accepted document type"
-
-// LinkGeminiWallet links a wallet to a Gemini account.
-func (service *Service) LinkGeminiWallet(ctx context.Context, walletID uuid.UUID, verificationToken, depositID string) (string, error) {
+// DisconnectCustodianLink - removes the link to the custodian wallet that is active
+func (service *Service) DisconnectCustodianLink(ctx context.Context, _ string, walletID uuid.UUID) error {
 	cl, err := service.Datastore.GetCustodianLinkByWalletID(ctx, walletID)
 	if err != nil && !errors.Is(err, model.ErrNoWalletCustodian) {
-		return "", handlers.WrapError(err, "failed to check linking mismatch", http.StatusInternalServerError)
-	}
-
-	const depositProvider = "gemini"
-
-	if cl.isLinked() && !strings.EqualFold(cl.Custodian, depositProvider) {
-		return "", errCustodianLinkMismatch
-	}
-
-	gc, ok := ctx.Value(appctx.GeminiClientCTXKey).(gemini.Client)
-	if !ok {
-		return "", handlers.WrapError(appctx.ErrNotInContext, "gemini client misconfigured", http.StatusInternalServerError)
-	}
-
-	acc, err := gc.FetchValidatedAccount(ctx, verificationToken, depositID)
-	if err != nil {
-		return "", fmt.Errorf("failed to validate account: %w", err)
-	}
-
-	service.metric.CountDocTypeByIssuingCntry(acc.ValidDocuments)
-
-	linkingID := uuid.NewV5(ClaimNamespace, acc.ID)
-	// Some Gemini accounts do not have valid documents setup. For accounts that are already linked i.e. are
-	// re-authenticating we can fall back to the legacy country code. New or re-linkings should not fall back.
-	isAuth := cl.isLinked() && *cl.LinkingID == linkingID
-	issuingCountry := service.gemini.GetIssuingCountry(acc, isAuth)
-	if issuingCountry == "" {
-		return "", fmt.Errorf("failed to validate account: %w", errNoAcceptedDocumentType)
-	}
-
-	if err := service.gemini.IsRegionAvailable(ctx, issuingCountry, service.custodianRegions); err != nil {
-		if errors.Is(err, errorutils.ErrInvalidCountry) {
-			// If a wallet has previously been linked i.e. has a prior linking, but the country is now invalid/blocked
-			// then we can allow the account to link due to its prior successful linking i.e. it is grandfathered.
-			// If there is no prior linking and the country is invalid/blocked then we should apply the current rules and block it.
-			hasPriorLinking, priorLinkingErr := service.Datastore.HasPriorLinking(ctx, walletID, linkingID)
-			if priorLinkingErr != nil && !errors.Is(err, sql.ErrNoRows) {
-				return "", fmt.Errorf("failed to check prior linkings: %w", priorLinkingErr)
-			}
-
-			if !hasPriorLinking {
-				service.metric.LinkFailureGemini(issuingCountry)
-				return "", fmt.Errorf("failed to validate account: %w", err)
-			}
-
-		} else {
-			// not err invalid country error
-			return "", fmt.Errorf("failed to validate account: %w", err)
-		}
-	}
-	service.metric.LinkSuccessGemini(issuingCountry)
-
-	if err := service.linkCustodialAccount(ctx, walletID.String(), depositID, linkingID, depositProvider, issuingCountry); err != nil {
-		if errors.Is(err, ErrUnusualActivity) {
-			return "", handlers.WrapError(err, "unable to link - unusual activity", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrGeoResetDifferent) {
-			return "", handlers.WrapError(err, "mismatched provider account regions", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrTooManyCardsLinked) {
-			return "", handlers.WrapError(err, "unable to link gemini wallets", http.StatusConflict)
-		}
-
-		return "", handlers.WrapError(err, "unable to link gemini wallets", http.StatusInternalServerError)
+		return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
 	}
 
-	return issuingCountry, nil
-}
-
-// LinkUpholdWallet links an uphold.Wallet and transfers funds.
-func (service *Service) LinkUpholdWallet(ctx context.Context, wallet uphold.Wallet, transaction string, _ *uuid.UUID) (string, error) {
-	const depositProvider = "uphold"
-	// do not confirm this transaction yet
-	info := wallet.GetWalletInfo()
-
-	var (
-		userID  string
-		country string
-		probi   decimal.Decimal
-	)
-
-	transactionInfo, err := wallet.VerifyTransaction(ctx, transaction)
+	ctx, tx, rollback, commit, err := getTx(ctx, service.Datastore)
 	if err != nil {
-		return "", handlers.WrapError(
-			errors.New("failed to verify transaction"), "transaction verification failure",
-			http.StatusForbidden)
-	}
-
-	// add custodian regions to ctx going to client
-	_, ok := ctx.Value(appctx.CustodianRegionsCTXKey).(custodian.Regions)
-	if !ok {
-		cr := service.getCustodianRegions()
-		ctx = context.WithValue(ctx, appctx.CustodianRegionsCTXKey, &cr)
+		return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
 	}
+	defer rollback()
 
-	walletID, err := uuid.FromString(info.ID)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse uphold id: %w", err)
+	if err := service.Datastore.DisconnectCustodialWallet(ctx, walletID); err != nil {
+		return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
 	}
 
-	// verify that the user is kyc from uphold. (for all wallet provider cases)
-	if uID, ok, c, err := wallet.IsUserKYC(ctx, transactionInfo.Destination); err != nil {
-		// check if this gemini accountID has already been linked to this wallet,
-		if errors.Is(err, errorutils.ErrInvalidCountry) {
-			ok, priorLinkingErr := service.Datastore.HasPriorLinking(
-				ctx, walletID, uuid.NewV5(ClaimNamespace, userID))
-			if priorLinkingErr != nil && !errors.Is(err, sql.ErrNoRows) {
-				return "", fmt.Errorf("failed to check prior linkings: %w", priorLinkingErr)
-			}
-			// if a wallet has a prior linking to this account, allow the invalid country, otherwise
-			// return the kyc error
-			if !ok {
-				// then pass back the original geo error
-				return "", err
-			}
-			// allow invalid country if there was a prior linking
-		} else {
-			return "", fmt.Errorf("wallet could not be kyc checked: %w", err)
-		}
-	} else if !ok {
-		// fail
-		return "", handlers.WrapError(
-			errors.New("user kyc did not pass"),
-			"KYC required",
-			http.StatusForbidden)
-	} else {
-		userID = uID
-		country = c
-	}
-
-	// check kyc user id validity
-	if userID == "" {
-		return "", handlers.WrapError(
-			errors.New("user id not provided"),
-			"KYC required",
-			http.StatusForbidden)
-	}
-
-	probi = transactionInfo.Probi
-
-	providerLinkingID := uuid.NewV5(ClaimNamespace, userID)
-	if err := service.linkCustodialAccount(ctx, walletID.String(), transactionInfo.Destination, providerLinkingID, depositProvider, country); err != nil {
-		if errors.Is(err, ErrUnusualActivity) {
-			return "", handlers.WrapError(err, "unable to link - unusual activity", http.StatusBadRequest)
-		}
-
-		if errors.Is(err, ErrGeoResetDifferent) {
-			return "", handlers.WrapError(err, "mismatched provider account regions", http.StatusBadRequest)
-		}
-
-		status := http.StatusInternalServerError
-		if errors.Is(err, ErrTooManyCardsLinked) {
-			status = http.StatusConflict
+	if cl.isLinked() {
+		ev := &model.LinkingEvent{
+			WalletID:  walletID,
+			Custodian: cl.Custodian,
+			LinkingID: cl.LinkingID,
+			Outcome:   model.LinkingOutcomeUnlinked,
 		}
 
-		return "", handlers.WrapError(err, "unable to link uphold wallets", status)
-	}
-
-	// if this wallet is linking a deposit account do not submit a transaction
-	if decimal.NewFromFloat(0).LessThan(probi) {
-		_, err := service.SubmitCommitableAnonCardTransaction(ctx, &info, transaction, "", true)
-		if err != nil {
-			return "", handlers.WrapError(err, "unable to transfer tokens", http.StatusBadRequest)
+		if err := service.linkEvRepo.Insert(ctx, tx, ev); err != nil {
+			return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
 		}
 	}
 
-	return country, nil
-}
-
-const errDisabledRegion model.Error = "disabled region"
-
-func (service *Service) LinkSolanaAddress(ctx context.Context, paymentID uuid.UUID, req linkSolanaAddrRequest) error {
-	repSum, err := service.repClient.GetReputationSummary(ctx, paymentID)
-	if err != nil {
-		return err
-	}
-
-	if !service.custodianRegions.Solana.Verdict(repSum.GeoCountry) {
-		service.metric.LinkFailureSolanaRegion(repSum.GeoCountry)
-		return errDisabledRegion
-	}
-
-	if err := isWalletWhitelisted(ctx, service.Datastore.RawDB(), service.allowListRepo, paymentID); err != nil {
-		service.metric.LinkFailureSolanaWhitelist(repSum.GeoCountry)
-		return err
-	}
-
-	ctx, txn, rollback, commit, err := getTx(ctx, service.Datastore)
-	if err != nil {
-		return err
-	}
-	defer rollback()
-
-	chl, err := service.chlRepo.Get(ctx, txn, paymentID)
-	if err != nil {
-		return err
-	}
-
-	if err := chl.IsValid(time.Now()); err != nil {
-		service.metric.LinkFailureSolanaChl(repSum.GeoCountry)
-		return err
-	}
-
-	w, err := service.Datastore.GetWallet(ctx, paymentID)
-	if err != nil {
-		return err
-	}
-
-	if w == nil {
-		return model.ErrWalletNotFound
-	}
-
-	if err := w.LinkSolanaAddress(ctx, walletutils.SolanaLinkReq{
-		Pub:   req.SolanaPublicKey,
-		Sig:   req.SolanaSignature,
-		Msg:   req.Message,
-		Nonce: chl.Nonce,
-	}); err != nil {
-		service.metric.LinkFailureSolanaMsg(repSum.GeoCountry)
-		return err
-	}
-
-	cl := NewSolanaCustodialLink(paymentID, w.UserDepositDestination)
-
-	if err := service.Datastore.LinkWallet(ctx, w.ID, w.UserDepositDestination, *cl.LinkingID, cl.Custodian); err != nil {
-		return err
-	}
-
-	if err := service.chlRepo.Delete(ctx, txn, chl.PaymentID); err != nil {
-		return err
-	}
-
 	if err := commit(); err != nil {
-		return err
+		return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
 	}
 
-	service.metric.LinkSuccessSolana(repSum.GeoCountry)
-
 	return nil
 }
 
-func (service *Service) linkCustodialAccount(ctx context.Context, wID string, userDepositDestination string, providerLinkingID uuid.UUID, depositProvider, country string) error {
-	walletID, err := uuid.FromString(wID)
-	if err != nil {
-		return fmt.Errorf("invalid wallet id, not uuid: %w", err)
-	}
+// ListLinkingEvents returns the page of linking events matching filter, and the total number of matching events.
+func (service *Service) ListLinkingEvents(ctx context.Context, filter model.LinkingEventFilter, p *inputs.Pagination) ([]model.LinkingEvent, int, error) {
+	return service.linkEvRepo.List(ctx, service.ReadableDatastore().RawDB(), filter, p)
+}
 
-	repClient, ok := ctx.Value(appctx.ReputationClientCTXKey).(reputation.Client)
-	if !ok {
-		return ErrNoReputationClient
-	}
+// ListLinkingLimits returns the linking limits for custodian, or all of them when custodian is empty.
+func (service *Service) ListLinkingLimits(ctx context.Context, custodian string) ([]model.LinkingLimit, error) {
+	return service.linkLimitRepo.List(ctx, service.Datastore.RawDB(), custodian)
+}
 
-	if _, _, err := repClient.IsLinkingReputable(ctx, walletID, country); err != nil {
-		return fmt.Errorf("failed to check wallet rep: %w", err)
-	}
+// CreateLinkingLimit adds a linking limit, which applies to the next linking.
+func (service *Service) CreateLinkingLimit(ctx context.Context, lim *model.LinkingLimit) (model.LinkingLimit, error) {
+	return service.linkLimitRepo.Create(ctx, service.Datastore.RawDB(), lim)
+}
 
-	return service.Datastore.LinkWallet(ctx, walletID.String(), userDepositDestination, providerLinkingID, depositProvider)
+// UpdateLinkingLimit changes the slots and relink cooldown of the linking limit identified by id.
+func (service *Service) UpdateLinkingLimit(ctx context.Context, id uuid.UUID, maxSlots, relinkCooldownSeconds int) (model.LinkingLimit, error) {
+	return service.linkLimitRepo.Update(ctx, service.Datastore.RawDB(), id, maxSlots, relinkCooldownSeconds)
 }
 
-func (service *Service) CreateChallenge(ctx context.Context, paymentID uuid.UUID) (model.Challenge, error) {
-	chl := model.NewChallenge(paymentID)
-	if err := service.chlRepo.Upsert(ctx, service.Datastore.RawDB(), chl); err != nil {
-		return model.Challenge{}, fmt.Errorf("error creating challenge: %w", err)
-	}
-	return chl, nil
+// DeleteLinkingLimit removes the linking limit identified by id.
+func (service *Service) DeleteLinkingLimit(ctx context.Context, id uuid.UUID) error {
+	return service.linkLimitRepo.Delete(ctx, service.Datastore.RawDB(), id)
 }
 
-// DisconnectCustodianLink - removes the link to the custodian wallet that is active
-func (service *Service) DisconnectCustodianLink(ctx context.Context, _ string, walletID uuid.UUID) error {
-	if err := service.Datastore.DisconnectCustodialWallet(ctx, walletID); err != nil {
-		return handlers.WrapError(err, "unable to disconnect custodian wallet", http.StatusInternalServerError)
-	}
-	return nil
+// GetLinkingLimitHistory returns the audit trail of the linking limit identified by id, newest first.
+func (service *Service) GetLinkingLimitHistory(ctx context.Context, id uuid.UUID) ([]model.LinkingLimitChange, error) {
+	return service.linkLimitRepo.ListHistory(ctx, service.Datastore.RawDB(), id)
 }
 
 // CreateRewardsWallet creates a brave rewards wallet and informs the reputation service.
@@ -905,6 +671,89 @@ func (service *Service) CreateRewardsWallet(ctx context.Context, publicKey strin
 	return info, nil
 }
 
+// keyRotationMaxSkew is how far the timestamp of a key rotation request may be from the current time.
+const keyRotationMaxSkew = 5 * time.Minute
+
+// RotateWalletKey replaces the public key of the rewards wallet identified by walletID with publicKey.
+//
+// The signature must be the base64 encoded signature over keyRotationMessage made with the current key of the wallet,
+// and timestamp must be within keyRotationMaxSkew of now, so that a signature can be neither used for another wallet
+// nor replayed later. The current key is kept in the key history and continues to be accepted for keyRotationOverlap,
+// a wallet cannot rotate its key again before that period has passed.
+func (service *Service) RotateWalletKey(ctx context.Context, walletID uuid.UUID, publicKey, signature string, timestamp int64) (*model.WalletKey, error) {
+	if skew := time.Since(time.Unix(timestamp, 0)); skew > keyRotationMaxSkew || skew < -keyRotationMaxSkew {
+		return nil, errKeyRotationStale
+	}
+
+	info, err := service.Datastore.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting wallet: %w", err)
+	}
+
+	if info == nil {
+		return nil, model.ErrWalletNotFound
+	}
+
+	currentKey, err := hex.DecodeString(info.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding current public key: %w", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errWalletKeySignature
+	}
+
+	valid, err := httpsignature.Ed25519PubKey(currentKey).Verify(keyRotationMessage(walletID, publicKey, timestamp), sig, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("error verifying key rotation signature: %w", err)
+	}
+
+	if !valid {
+		return nil, errWalletKeySignature
+	}
+
+	ctx, tx, rollback, commit, err := getTx(ctx, service.Datastore)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction: %w", err)
+	}
+	defer rollback()
+
+	overlapping, err := service.keyRepo.ListValidSince(ctx, tx, walletID, time.Now().Add(-keyRotationOverlap))
+	if err != nil {
+		return nil, fmt.Errorf("error getting wallet key history: %w", err)
+	}
+
+	if len(overlapping) > 0 {
+		return nil, errKeyRotationInProgress
+	}
+
+	used, err := service.keyRepo.IsUsed(ctx, tx, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error checking public key: %w", err)
+	}
+
+	if used {
+		return nil, errWalletKeyInUse
+	}
+
+	key, err := service.keyRepo.Rotate(ctx, tx, walletID, info.PublicKey, publicKey, signature)
+	if err != nil {
+		return nil, fmt.Errorf("error rotating wallet key: %w", err)
+	}
+
+	if err := commit(); err != nil {
+		return nil, fmt.Errorf("error committing wallet key rotation: %w", err)
+	}
+
+	return &key, nil
+}
+
+// keyRotationMessage returns the message signed by the current key of a wallet to rotate to publicKey.
+func keyRotationMessage(walletID uuid.UUID, publicKey string, timestamp int64) []byte {
+	return []byte(walletID.String() + ":" + publicKey + ":" + strconv.FormatInt(timestamp, 10))
+}
+
 // RefreshCustodianRegionsWorker - get the custodian regions from the merge param bucket
 func (service *Service) RefreshCustodianRegionsWorker(ctx context.Context) (bool, error) {
 	useCustodianRegions, featureOK := ctx.Value(appctx.UseCustodianRegionsCTXKey).(bool)
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/brave-intl/bat-go/libs/inputs"
 	"github.com/brave-intl/bat-go/services/wallet/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -30,6 +34,21 @@ func (c *Challenge) Get(ctx context.Context, dbi sqlx.QueryerContext, paymentID
 	return result, nil
 }
 
+// GetForUpdate retrieves a model.Challenge by the given paymentID, and locks it until the end of the transaction.
+func (c *Challenge) GetForUpdate(ctx context.Context, dbi sqlx.QueryerContext, paymentID uuid.UUID) (model.Challenge, error) {
+	const q = `select * from challenge where payment_id = $1 for update`
+
+	var result model.Challenge
+	if err := sqlx.GetContext(ctx, dbi, &result, q, paymentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, model.ErrChallengeNotFound
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
 // Upsert persists a model.Challenge to the database.
 func (c *Challenge) Upsert(ctx context.Context, dbi sqlx.ExecerContext, chl model.Challenge) error {
 	const q = `insert into challenge (payment_id, created_at, nonce) values($1, $2, $3) on conflict (payment_id) do update set created_at = $2, nonce = $3`
@@ -112,3 +131,248 @@ func (a *AllowList) GetAllowListEntry(ctx context.Context, dbi sqlx.QueryerConte
 
 	return result, nil
 }
+
+type LinkingEvent struct{}
+
+func NewLinkingEvent() *LinkingEvent { return &LinkingEvent{} }
+
+// Insert appends a model.LinkingEvent to the linking event log.
+func (l *LinkingEvent) Insert(ctx context.Context, dbi sqlx.ExecerContext, ev *model.LinkingEvent) error {
+	const q = `insert into wallet_linking_events (wallet_id, custodian, linking_id, country, outcome, reason) values($1, $2, $3, $4, $5, $6)`
+
+	if _, err := dbi.ExecContext(ctx, q, ev.WalletID, ev.Custodian, ev.LinkingID, ev.Country, ev.Outcome, ev.Reason); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List returns the page of linking events matching the filter, along with the total number of matching events.
+//
+// Events are listed newest first unless the pagination orders them.
+func (l *LinkingEvent) List(ctx context.Context, dbi sqlx.QueryerContext, filter model.LinkingEventFilter, p *inputs.Pagination) ([]model.LinkingEvent, int, error) {
+	where, args := linkingEventWhere(filter)
+
+	var total int
+	if err := sqlx.GetContext(ctx, dbi, &total, `select count(*) from wallet_linking_events`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	q := `select id, created_at, wallet_id, custodian, linking_id, country, outcome, reason from wallet_linking_events` + where
+
+	orderBy := p.GetOrderBy(ctx)
+	if orderBy == "" {
+		orderBy = "created_at desc, id"
+	}
+
+	q += " order by " + orderBy + fmt.Sprintf(" offset %d limit %d", p.Page*p.Items, p.Items)
+
+	result := make([]model.LinkingEvent, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, args...); err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, nil
+}
+
+func linkingEventWhere(filter model.LinkingEventFilter) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	add := func(col string, v interface{}) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+
+	if filter.WalletID != nil {
+		add("wallet_id", *filter.WalletID)
+	}
+
+	if filter.LinkingID != nil {
+		add("linking_id", *filter.LinkingID)
+	}
+
+	if filter.Custodian != "" {
+		add("custodian", filter.Custodian)
+	}
+
+	if filter.Country != "" {
+		add("country", filter.Country)
+	}
+
+	if filter.Outcome != "" {
+		add("outcome", filter.Outcome)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " where " + strings.Join(conds, " and "), args
+}
+
+type LinkingLimit struct{}
+
+func NewLinkingLimit() *LinkingLimit { return &LinkingLimit{} }
+
+// GetEffective retrieves the model.LinkingLimit which applies to the account with linkingID in country.
+//
+// An exception for the account takes precedence over an override for the country, which takes precedence over
+// the limit for the custodian.
+func (l *LinkingLimit) GetEffective(ctx context.Context, dbi sqlx.QueryerContext, custodian, country string, linkingID uuid.UUID) (model.LinkingLimit, error) {
+	const q = `select * from linking_limits
+		where custodian = $1 and (linking_id = $3 or (linking_id is null and country in ($2, '')))
+		order by linking_id is null, country = '' limit 1`
+
+	var result model.LinkingLimit
+	if err := sqlx.GetContext(ctx, dbi, &result, q, custodian, country, linkingID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, model.ErrLinkingLimitNotFound
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// List returns the linking limits for custodian, or all of them when custodian is empty.
+func (l *LinkingLimit) List(ctx context.Context, dbi sqlx.QueryerContext, custodian string) ([]model.LinkingLimit, error) {
+	const q = `select * from linking_limits where $1 = '' or custodian = $1
+		order by custodian, linking_id nulls first, country`
+
+	result := make([]model.LinkingLimit, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, custodian); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Create persists a new model.LinkingLimit to the database.
+func (l *LinkingLimit) Create(ctx context.Context, dbi sqlx.QueryerContext, lim *model.LinkingLimit) (model.LinkingLimit, error) {
+	const q = `insert into linking_limits (custodian, country, linking_id, max_slots, relink_cooldown_seconds)
+		values($1, $2, $3, $4, $5) returning *`
+
+	var result model.LinkingLimit
+	if err := sqlx.GetContext(ctx, dbi, &result, q, lim.Custodian, lim.Country, lim.LinkingID, lim.MaxSlots, lim.RelinkCooldownSeconds); err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return result, model.ErrLinkingLimitExists
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Update changes the slots and cooldown of the model.LinkingLimit identified by id.
+func (l *LinkingLimit) Update(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID, maxSlots, relinkCooldownSeconds int) (model.LinkingLimit, error) {
+	const q = `update linking_limits set max_slots = $2, relink_cooldown_seconds = $3, updated_at = now()
+		where id = $1 returning *`
+
+	var result model.LinkingLimit
+	if err := sqlx.GetContext(ctx, dbi, &result, q, id, maxSlots, relinkCooldownSeconds); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, model.ErrLinkingLimitNotFound
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Delete removes the model.LinkingLimit identified by id from the database.
+func (l *LinkingLimit) Delete(ctx context.Context, dbi sqlx.ExecerContext, id uuid.UUID) error {
+	const q = `delete from linking_limits where id = $1`
+
+	result, err := dbi.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if row == 0 {
+		return model.ErrLinkingLimitNotFound
+	}
+
+	return nil
+}
+
+// ListHistory returns the changes made to the linking limit identified by id, newest first.
+func (l *LinkingLimit) ListHistory(ctx context.Context, dbi sqlx.QueryerContext, id uuid.UUID) ([]model.LinkingLimitChange, error) {
+	const q = `select * from linking_limit_history where linking_limit_id = $1 order by id desc`
+
+	result := make([]model.LinkingLimitChange, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, id); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type WalletKey struct{}
+
+func NewWalletKey() *WalletKey { return &WalletKey{} }
+
+// ListValidSince returns the keys the wallet identified by walletID rotated away from after since, newest first.
+func (k *WalletKey) ListValidSince(ctx context.Context, dbi sqlx.QueryerContext, walletID uuid.UUID, since time.Time) ([]model.WalletKey, error) {
+	const q = `select * from wallet_key_history where wallet_id = $1 and valid_to > $2 order by valid_to desc`
+
+	result := make([]model.WalletKey, 0)
+	if err := sqlx.SelectContext(ctx, dbi, &result, q, walletID, since); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// IsUsed reports whether publicKey is, or has ever been, the key of a wallet.
+func (k *WalletKey) IsUsed(ctx context.Context, dbi sqlx.QueryerContext, publicKey string) (bool, error) {
+	const q = `select exists(select 1 from wallets where public_key = $1)
+		or exists(select 1 from wallet_key_history where public_key = $1)`
+
+	var result bool
+	if err := sqlx.GetContext(ctx, dbi, &result, q, publicKey); err != nil {
+		return false, err
+	}
+
+	return result, nil
+}
+
+// Rotate replaces the key of the wallet identified by walletID with nextKey and records currentKey in the key
+// history as valid until now.
+//
+// It returns model.ErrWalletKeyConflict if currentKey is no longer the key of the wallet.
+func (k *WalletKey) Rotate(ctx context.Context, dbi sqlx.ExtContext, walletID uuid.UUID, currentKey, nextKey, signature string) (model.WalletKey, error) {
+	const upd = `update wallets set public_key = $3 where id = $1 and public_key = $2`
+
+	result, err := dbi.ExecContext(ctx, upd, walletID, currentKey, nextKey)
+	if err != nil {
+		return model.WalletKey{}, err
+	}
+
+	row, err := result.RowsAffected()
+	if err != nil {
+		return model.WalletKey{}, err
+	}
+
+	if row != 1 {
+		return model.WalletKey{}, model.ErrWalletKeyConflict
+	}
+
+	const ins = `insert into wallet_key_history (wallet_id, public_key, valid_from, valid_to, next_public_key, signature)
+		values($1, $2, (select max(valid_to) from wallet_key_history where wallet_id = $1), now(), $3, $4) returning *`
+
+	var key model.WalletKey
+	if err := sqlx.GetContext(ctx, dbi, &key, ins, walletID, currentKey, nextKey, signature); err != nil {
+		return model.WalletKey{}, err
+	}
+
+	return key, nil
+}
@@ -4,10 +4,12 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/brave-intl/bat-go/libs/datastore"
+	"github.com/brave-intl/bat-go/libs/inputs"
 	"github.com/brave-intl/bat-go/services/wallet/model"
 	"github.com/jmoiron/sqlx"
 	uuid "github.com/satori/go.uuid"
@@ -95,6 +97,43 @@ func TestChallenge_Get(t *testing.T) {
 	}
 }
 
+func TestChallenge_GetForUpdate(t *testing.T) {
+	dbi, err := setupDBI()
+	must.NoError(t, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE challenge;")
+	}()
+
+	ctx := context.Background()
+
+	chl := model.Challenge{
+		PaymentID: uuid.FromStringOrNil("0ad3a3a4-0d0e-4f2e-9c36-4a5b0e8f7d11"),
+		CreatedAt: time.Date(2024, 1, 1, 1, 1, 1, 0, time.UTC),
+		Nonce:     "nonce-1",
+	}
+
+	const q = `insert into challenge (payment_id, created_at, nonce) values($1, $2, $3)`
+
+	_, err = dbi.ExecContext(ctx, q, chl.PaymentID, chl.CreatedAt, chl.Nonce)
+	must.NoError(t, err)
+
+	tx, err := dbi.BeginTxx(ctx, nil)
+	must.NoError(t, err)
+
+	defer func() { _ = tx.Rollback() }()
+
+	c := Challenge{}
+
+	actual, err := c.GetForUpdate(ctx, tx, chl.PaymentID)
+	must.NoError(t, err)
+
+	should.Equal(t, chl.Nonce, actual.Nonce)
+
+	_, err = c.GetForUpdate(ctx, tx, uuid.NewV4())
+	should.Equal(t, model.ErrChallengeNotFound, err)
+}
+
 func TestChallenge_Upsert(t *testing.T) {
 	dbi, err := setupDBI()
 	must.NoError(t, err)
@@ -401,6 +440,267 @@ func TestAllowList_GetAllowListEntry(t *testing.T) {
 	}
 }
 
+func TestLinkingEvent_List(t *testing.T) {
+	dbi, err := setupDBI()
+	must.NoError(t, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE wallet_linking_events;")
+	}()
+
+	walletID := uuid.NewV4()
+	linkingID := uuid.NewV4()
+
+	events := []model.LinkingEvent{
+		{WalletID: walletID, Custodian: "gemini", LinkingID: &linkingID, Country: "US", Outcome: model.LinkingOutcomeLinked},
+		{WalletID: walletID, Custodian: "gemini", LinkingID: &linkingID, Country: "US", Outcome: model.LinkingOutcomeUnlinked},
+		{WalletID: walletID, Custodian: "zebpay", Country: "IN", Outcome: model.LinkingOutcomeRejected, Reason: "kyc_failed"},
+		{WalletID: uuid.NewV4(), Custodian: "gemini", LinkingID: &linkingID, Country: "US", Outcome: model.LinkingOutcomeRejected, Reason: "slots_exhausted"},
+	}
+
+	type tcGiven struct {
+		filter model.LinkingEventFilter
+		page   int
+		items  int
+	}
+
+	type tcExpected struct {
+		total    int
+		outcomes []string
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "wallet",
+			given: tcGiven{filter: model.LinkingEventFilter{WalletID: &walletID}, items: 10},
+			exp: tcExpected{
+				total:    3,
+				outcomes: []string{model.LinkingOutcomeRejected, model.LinkingOutcomeUnlinked, model.LinkingOutcomeLinked},
+			},
+		},
+
+		{
+			name:  "linking_id_rejected",
+			given: tcGiven{filter: model.LinkingEventFilter{LinkingID: &linkingID, Outcome: model.LinkingOutcomeRejected}, items: 10},
+			exp:   tcExpected{total: 1, outcomes: []string{model.LinkingOutcomeRejected}},
+		},
+
+		{
+			name:  "second_page",
+			given: tcGiven{filter: model.LinkingEventFilter{Custodian: "gemini"}, page: 1, items: 2},
+			exp:   tcExpected{total: 3, outcomes: []string{model.LinkingOutcomeLinked}},
+		},
+	}
+
+	repo := NewLinkingEvent()
+
+	ctx := context.Background()
+	for i := range events {
+		must.NoError(t, repo.Insert(ctx, dbi, &events[i]))
+	}
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, total, err := repo.List(ctx, dbi, tc.given.filter, &inputs.Pagination{Page: tc.given.page, Items: tc.given.items})
+			must.NoError(t, err)
+
+			should.Equal(t, tc.exp.total, total)
+
+			outcomes := make([]string, 0, len(actual))
+			for j := range actual {
+				outcomes = append(outcomes, actual[j].Outcome)
+			}
+
+			should.Equal(t, tc.exp.outcomes, outcomes)
+		})
+	}
+}
+
+func TestLinkingLimit_GetEffective(t *testing.T) {
+	dbi, err := setupDBI()
+	must.NoError(t, err)
+
+	defer func() {
+		_, _ = dbi.Exec("DELETE FROM linking_limits WHERE custodian = 'example';")
+	}()
+
+	exceptionID := uuid.NewV4()
+
+	limits := []model.LinkingLimit{
+		{Custodian: "example", MaxSlots: 4},
+		{Custodian: "example", Country: "US", MaxSlots: 2, RelinkCooldownSeconds: 3600},
+		{Custodian: "example", LinkingID: &exceptionID, MaxSlots: 10},
+	}
+
+	type tcGiven struct {
+		custodian string
+		country   string
+		linkingID uuid.UUID
+	}
+
+	type tcExpected struct {
+		slots int
+		err   error
+	}
+
+	type testCase struct {
+		name  string
+		given tcGiven
+		exp   tcExpected
+	}
+
+	tests := []testCase{
+		{
+			name:  "custodian",
+			given: tcGiven{custodian: "example", country: "JP", linkingID: uuid.NewV4()},
+			exp:   tcExpected{slots: 4},
+		},
+
+		{
+			name:  "country_override",
+			given: tcGiven{custodian: "example", country: "US", linkingID: uuid.NewV4()},
+			exp:   tcExpected{slots: 2},
+		},
+
+		{
+			name:  "linking_id_exception",
+			given: tcGiven{custodian: "example", country: "US", linkingID: exceptionID},
+			exp:   tcExpected{slots: 10},
+		},
+
+		{
+			name:  "not_found",
+			given: tcGiven{custodian: "unknown", country: "US", linkingID: exceptionID},
+			exp:   tcExpected{err: model.ErrLinkingLimitNotFound},
+		},
+	}
+
+	repo := NewLinkingLimit()
+
+	ctx := context.Background()
+	for i := range limits {
+		_, err := repo.Create(ctx, dbi, &limits[i])
+		must.NoError(t, err)
+	}
+
+	_, err = repo.Create(ctx, dbi, &limits[1])
+	must.ErrorIs(t, err, model.ErrLinkingLimitExists)
+
+	for i := range tests {
+		tc := tests[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := repo.GetEffective(ctx, dbi, tc.given.custodian, tc.given.country, tc.given.linkingID)
+			must.ErrorIs(t, err, tc.exp.err)
+
+			should.Equal(t, tc.exp.slots, actual.MaxSlots)
+		})
+	}
+}
+
+func TestLinkingLimit_ListHistory(t *testing.T) {
+	dbi, err := setupDBI()
+	must.NoError(t, err)
+
+	defer func() {
+		_, _ = dbi.Exec("DELETE FROM linking_limits WHERE custodian = 'example';")
+	}()
+
+	repo := NewLinkingLimit()
+
+	ctx := context.Background()
+
+	lim, err := repo.Create(ctx, dbi, &model.LinkingLimit{Custodian: "example", Country: "DE", MaxSlots: 4})
+	must.NoError(t, err)
+
+	_, err = repo.Update(ctx, dbi, lim.ID, 6, 60)
+	must.NoError(t, err)
+
+	must.NoError(t, repo.Delete(ctx, dbi, lim.ID))
+
+	actual, err := repo.ListHistory(ctx, dbi, lim.ID)
+	must.NoError(t, err)
+
+	must.Len(t, actual, 3)
+	should.Equal(t, "DELETE", actual[0].Operation)
+	should.Equal(t, "UPDATE", actual[1].Operation)
+	should.Equal(t, "INSERT", actual[2].Operation)
+
+	should.Nil(t, actual[2].ValueBefore)
+	should.Nil(t, actual[0].ValueAfter)
+
+	var after struct {
+		MaxSlots int `json:"max_slots"`
+	}
+	must.NoError(t, json.Unmarshal(*actual[1].ValueAfter, &after))
+
+	should.Equal(t, 6, after.MaxSlots)
+}
+
+func TestWalletKey_Rotate(t *testing.T) {
+	dbi, err := setupDBI()
+	must.NoError(t, err)
+
+	defer func() {
+		_, _ = dbi.Exec("TRUNCATE TABLE wallet_key_history;")
+		_, _ = dbi.Exec("DELETE FROM wallets;")
+	}()
+
+	walletID := uuid.NewV4()
+
+	_, err = dbi.Exec("INSERT INTO wallets (id, provider, provider_id, public_key) VALUES ($1, 'brave', '', 'key-1')", walletID)
+	must.NoError(t, err)
+
+	repo := NewWalletKey()
+
+	ctx := context.Background()
+
+	first, err := repo.Rotate(ctx, dbi, walletID, "key-1", "key-2", "sig-1")
+	must.NoError(t, err)
+
+	should.Nil(t, first.ValidFrom)
+	should.Equal(t, "key-1", first.PublicKey)
+	should.Equal(t, "key-2", first.NextPublicKey)
+
+	_, err = repo.Rotate(ctx, dbi, walletID, "key-1", "key-3", "sig-2")
+	must.ErrorIs(t, err, model.ErrWalletKeyConflict)
+
+	second, err := repo.Rotate(ctx, dbi, walletID, "key-2", "key-3", "sig-2")
+	must.NoError(t, err)
+
+	must.NotNil(t, second.ValidFrom)
+	should.True(t, first.ValidTo.Equal(*second.ValidFrom))
+
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		used, err := repo.IsUsed(ctx, dbi, key)
+		must.NoError(t, err)
+		should.True(t, used, key)
+	}
+
+	used, err := repo.IsUsed(ctx, dbi, "key-4")
+	must.NoError(t, err)
+	should.False(t, used)
+
+	actual, err := repo.ListValidSince(ctx, dbi, walletID, first.ValidTo.Add(-time.Second))
+	must.NoError(t, err)
+
+	must.Len(t, actual, 2)
+	should.Equal(t, "key-2", actual[0].PublicKey)
+
+	actual, err = repo.ListValidSince(ctx, dbi, walletID, time.Now().Add(time.Minute))
+	must.NoError(t, err)
+
+	should.Len(t, actual, 0)
+}
+
 func setupDBI() (*sqlx.DB, error) {
 	pg, err := datastore.NewPostgres("", false, "")
 	if err != nil {